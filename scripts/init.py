@@ -141,6 +141,14 @@ mongodb:
   rsName: $rs_name
   #mongo的socket连接的超时时间，以秒为单位，默认10s，最小5s，最大30s。
   socketTimeoutSeconds: 10
+  #租户隔离配置
+  tenant:
+    #为true时，拒绝既没有租户(bk_supplier_account)也没有显式声明为系统任务的数据库操作，默认为false
+    strict: false
+    #每个租户在各个表中可以拥有的最大数据条数，default对所有未单独配置的租户生效，不配置或配置为0表示不限制
+    #quota:
+    #  default:
+    #    cc_HostBase: 100000
     '''
     template = FileTemplate(mongodb_file_template_str)
     result = template.substitute(**context)
@@ -180,6 +188,11 @@ mongodb:
 #operationServer:
 #  timer:
 #    spec: 00:30
+#  metric:
+#    interval: 300
+#  snapshot:
+#    objects: ''
+#    keepDays: 180
 #authServer:
 #  address: 127.0.0.1
 #  appCode: bk_cmdb
@@ -192,6 +205,40 @@ mongodb:
 #    secretsToken:
 #    secretsProject:
 #    secretsEnv:
+#    provider: secrets
+#    vault:
+#      addr:
+#      token:
+#      namespace:
+#      mount: secret
+#      pathPrefix: cmdb
+#    keyring:
+#      activeKey:
+#      keys:
+#coreService:
+#  sensitive:
+#    provider:
+#    secretKey:
+#    vault:
+#      addr:
+#      token:
+#      namespace:
+#      mount: secret
+#      pathPrefix: cmdb
+#    keyring:
+#      activeKey:
+#      keys:
+#cacheService:
+#  instance:
+#    objects: ''
+#  verify:
+#    intervalMinutes: 10
+#    sampleSize: 1000
+#  watch:
+#    hostSnapHistory: false
+#taskServer:
+#  job:
+#    concurrency: 10
 
 #elasticsearch配置
 es:
@@ -243,6 +290,14 @@ operationServer:
   timer:
     #00:00-23:59,operation_server从配置文件读取的时间,默认是为00:30
     spec: 00:30  # 00:00 - 23:59
+  metric:
+    #聚合统计图表导出为prometheus指标的刷新间隔,单位为秒,默认是300
+    interval: 300
+  snapshot:
+    #除业务拓扑外,需要每日保存实例快照的模型,多个用,(逗号)分割,默认不保存
+    objects: ''
+    #历史快照保存的天数,默认是180
+    keepDays: 180
 #auth_server专属配置
 authServer:
   #蓝鲸权限中心地址,可配置多个,用,(逗号)分割
@@ -261,10 +316,60 @@ cloudServer:
     secretsToken: ${secrets_token}
     secretsProject: ${secrets_project}
     secretsEnv: ${secrets_env}
+    # 云账户密钥的存储后端,可选secrets(使用bk-secrets服务中的密钥加密)、vault(HashiCorp Vault KV v2)、keyring(本地密钥环加密)
+    provider: secrets
+    # vault后端配置,addr以http://或https://开头
+    vault:
+      addr:
+      token:
+      namespace:
+      mount: secret
+      pathPrefix: cmdb
+    # keyring后端配置,keys格式为"密钥id:base64编码的16、24或32字节密钥",多个用,(逗号)分割,activeKey为加密新密钥使用的密钥id
+    keyring:
+      activeKey:
+      keys:
   # 云同步任务
   syncTask:
     # 同步周期,最小为5分钟
     syncPeriodMinutes: 5
+#core_service专属配置
+coreService:
+  # 敏感属性加密
+  sensitive:
+    # 敏感属性值的加密后端,可选secrets(使用secretKey加密)、vault(HashiCorp Vault KV v2)、keyring(本地密钥环加密),为空时不能设置敏感属性
+    provider:
+    # secrets后端使用的16、24或32字节的AES密钥
+    secretKey:
+    # vault后端配置,addr以http://或https://开头
+    vault:
+      addr:
+      token:
+      namespace:
+      mount: secret
+      pathPrefix: cmdb
+    # keyring后端配置,keys格式为"密钥id:base64编码的16、24或32字节密钥",多个用,(逗号)分割,activeKey为加密使用的密钥id
+    keyring:
+      activeKey:
+      keys:
+#cache_service专属配置
+cacheService:
+  instance:
+    #需要缓存实例的自定义模型,多个用,(逗号)分割,默认不缓存
+    objects: ''
+  verify:
+    #缓存与db数据一致性校验及修复的周期,单位为分钟,默认是10,为0时不校验
+    intervalMinutes: 10
+    #每个周期每种资源抽样校验的缓存数量,默认是1000
+    sampleSize: 1000
+  watch:
+    #是否产生主机快照历史的watch事件,用于主机快照变化的告警,默认不产生
+    hostSnapHistory: false
+#task_server专属配置
+taskServer:
+  job:
+    #每个task_server实例同时执行的通用任务数,默认是10
+    concurrency: 10
 #datacollection专属配置
 datacollection:
   hostsnap:
@@ -276,6 +381,40 @@ datacollection:
     rateLimiter:
       qps: 40
       burst: 100
+    history:
+      # 是否记录主机快照历史，开启后主机快照中的属性及ip发生变化时会记录一条带变化详情的历史，默认开启
+      enabled: true
+      # 每台主机保留的快照历史的最大条数，超出时删除最早的历史，默认值为100，最小值为1
+      maxCount: 100
+  ingest:
+    # 采集数据接入接口的token，多个token以逗号分隔，不配置时不开启接入接口
+    tokens: ""
+  netcollect:
+    snmp:
+      # 是否开启进程内的SNMP网络设备采集，开启后不需要通过节点管理部署netdevicebeat，多个datacollection实例时仅需在一个实例开启，默认不开启
+      enabled: false
+      # 采集的网络设备地址，多个地址以逗号分隔，如"192.168.1.1,192.168.1.2:1161"，端口默认为161
+      targets: ""
+      # 网络设备所在的云区域id，默认值为0
+      cloudID: 0
+      # SNMP版本，支持v2c和v3，默认值为v2c
+      version: v2c
+      # v2c的community，默认值为public
+      community: public
+      # 采集周期，默认值为300秒，最小值为60秒，以秒为单位
+      intervalSeconds: 300
+      # 单次请求的超时时间及重试次数，超时时间默认值为5秒，重试次数默认值为1
+      timeoutSeconds: 5
+      retries: 1
+      v3:
+        # v3的用户名，配置authPassword时进行认证，同时配置privPassword时进行加密
+        username: ""
+        # 认证协议，支持MD5和SHA，默认值为SHA
+        authProtocol: SHA
+        authPassword: ""
+        # 加密协议，支持DES和AES，默认值为AES
+        privProtocol: AES
+        privPassword: ""
     '''
 
     template = FileTemplate(common_file_template_str)
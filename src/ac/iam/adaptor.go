@@ -120,6 +120,7 @@ func ConvertResourceType(resourceType meta.ResourceType, businessID int64) (*Typ
 	case meta.EventWatch:
 		iamResourceType = SysEventWatch
 	case meta.ConfigAdmin:
+	case meta.SensitiveAttribute:
 	case meta.SystemConfig:
 	default:
 		return nil, fmt.Errorf("unsupported resource type: %s", resourceType)
@@ -369,12 +370,13 @@ var resourceActionMap = map[meta.ResourceType]map[meta.Action]ActionID{
 		meta.ModelTopologyOperation: EditBusinessLayer,
 	},
 	meta.EventWatch: {
-		meta.WatchHost:         WatchHostEvent,
-		meta.WatchHostRelation: WatchHostRelationEvent,
-		meta.WatchBiz:          WatchBizEvent,
-		meta.WatchSet:          WatchSetEvent,
-		meta.WatchModule:       WatchModuleEvent,
-		meta.WatchSetTemplate:  WatchSetTemplateEvent,
+		meta.WatchHost:            WatchHostEvent,
+		meta.WatchHostRelation:    WatchHostRelationEvent,
+		meta.WatchBiz:             WatchBizEvent,
+		meta.WatchSet:             WatchSetEvent,
+		meta.WatchModule:          WatchModuleEvent,
+		meta.WatchSetTemplate:     WatchSetTemplateEvent,
+		meta.WatchHostSnapHistory: WatchHostEvent,
 	},
 	meta.UserCustom: {
 		meta.Find:   Skip,
@@ -453,6 +455,12 @@ var resourceActionMap = map[meta.ResourceType]map[meta.Action]ActionID{
 		// unsupported action
 		meta.Create: Unsupported,
 	},
+	meta.SensitiveAttribute: {
+		meta.Find:   ViewSensitiveAttribute,
+		meta.Update: Unsupported,
+		meta.Delete: Unsupported,
+		meta.Create: Unsupported,
+	},
 }
 
 // AdoptPermissions 用于鉴权没有通过时，根据鉴权的资源信息生成需要申请的权限信息
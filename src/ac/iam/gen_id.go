@@ -84,6 +84,8 @@ func genIamResource(act ActionID, rscType TypeID, a *meta.ResourceAttribute) ([]
 		return genSkipResource(act, rscType, a)
 	case meta.ConfigAdmin:
 		return genGlobalConfigResource(act, rscType, a)
+	case meta.SensitiveAttribute:
+		return genSensitiveAttributeResource(act, rscType, a)
 	case meta.MainlineModel:
 		return genBusinessLayerResource(act, rscType, a)
 	case meta.ModelTopology:
@@ -463,6 +465,10 @@ func genGlobalConfigResource(_ ActionID, _ TypeID, _ *meta.ResourceAttribute) ([
 	return make([]types.Resource, 0), nil
 }
 
+func genSensitiveAttributeResource(_ ActionID, _ TypeID, _ *meta.ResourceAttribute) ([]types.Resource, error) {
+	return make([]types.Resource, 0), nil
+}
+
 func genBusinessLayerResource(_ ActionID, typ TypeID, _ *meta.ResourceAttribute) ([]types.Resource, error) {
 	return make([]types.Resource, 0), nil
 }
@@ -386,6 +386,9 @@ func genModelManageActionGroups() []ActionGroup {
 						{
 							ID: DeleteSysModel,
 						},
+						{
+							ID: ViewSensitiveAttribute,
+						},
 					},
 				},
 				{
@@ -70,6 +70,7 @@ func GenerateActions() []ResourceAction {
 	resourceActionList = append(resourceActionList, genAuditLogActions()...)
 	resourceActionList = append(resourceActionList, genEventWatchActions()...)
 	resourceActionList = append(resourceActionList, genConfigAdminActions()...)
+	resourceActionList = append(resourceActionList, genSensitiveAttributeActions()...)
 
 	return resourceActionList
 }
@@ -1225,3 +1226,17 @@ func genConfigAdminActions() []ResourceAction {
 	})
 	return actions
 }
+
+func genSensitiveAttributeActions() []ResourceAction {
+	actions := make([]ResourceAction, 0)
+	actions = append(actions, ResourceAction{
+		ID:                   ViewSensitiveAttribute,
+		Name:                 "敏感属性查看",
+		NameEn:               "View Sensitive Attribute",
+		Type:                 View,
+		RelatedResourceTypes: nil,
+		RelatedActions:       nil,
+		Version:              1,
+	})
+	return actions
+}
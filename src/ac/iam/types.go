@@ -310,6 +310,7 @@ const (
 	WatchModuleEvent       ActionID = "watch_module_event"
 	WatchSetTemplateEvent  ActionID = "watch_set_template_event"
 	GlobalSettings         ActionID = "global_settings"
+	ViewSensitiveAttribute ActionID = "view_sensitive_attribute"
 
 	// Unknown is an action that can not be recognized
 	Unsupported ActionID = "unsupported"
@@ -129,6 +129,8 @@ const (
 	WatchSet          Action = "set"
 	WatchModule       Action = "module"
 	WatchSetTemplate  Action = "set_template"
+	// host snapshot history shares the host event's permission, as it's derived from the host.
+	WatchHostSnapHistory Action = "host_snapshot_history"
 
 	// can view business related resources, including business and business collection resources
 	ViewBusinessResource Action = "viewBusinessResource"
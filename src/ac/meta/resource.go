@@ -64,6 +64,7 @@ const (
 	CloudAccount             ResourceType = "cloudAccount"
 	CloudResourceTask        ResourceType = "cloudResourceTask"
 	ConfigAdmin              ResourceType = "configAdmin"
+	SensitiveAttribute       ResourceType = "sensitiveAttribute" // 敏感属性
 )
 
 const (
@@ -84,6 +84,13 @@ var cloudAccountConfigs = []AuthConfig{
 			}
 			return nil, errors.New("unexpected error: this code shouldn't be reached")
 		},
+	}, {
+		Name:           "previewCloudAccountTagRuleRegex",
+		Description:    "预览云账户的标签映射规则",
+		Regex:          regexp.MustCompile(`^/api/v3/findmany/cloud/account/tag_rule/preview/([0-9]+)$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.CloudAccount,
+		ResourceAction: meta.SkipAction,
 	}, {
 		Name:           "deleteCloudAccountRegex",
 		Description:    "删除云账户",
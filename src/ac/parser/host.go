@@ -316,6 +316,9 @@ const (
 	lockHostPattern                       = "/api/v3/host/lock"
 	unLockHostPattern                     = "/api/v3/host/lock"
 	queryHostLockPattern                  = "/api/v3/host/lock/search"
+	addHostLabelsPattern                  = "/api/v3/createmany/hosts/labels"
+	removeHostLabelsPattern               = "/api/v3/deletemany/hosts/labels"
+	findHostLabelsAggregationPattern      = "/api/v3/findmany/hosts/labels/aggregation"
 
 	// used in sync framework.
 	// moveHostToBusinessOrModulePattern = "/api/v3/hosts/sync/new/host"
@@ -543,6 +546,53 @@ func (ps *parseStream) host() *parseStream {
 		return ps
 	}
 
+	// add or remove labels of hosts.
+	if ps.hitPattern(addHostLabelsPattern, http.MethodPost) || ps.hitPattern(removeHostLabelsPattern, http.MethodDelete) {
+		input := struct {
+			InstanceIDs []int64 `json:"instance_ids"`
+		}{}
+		body, err := ps.RequestCtx.getRequestBody()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		if err := json.Unmarshal(body, &input); err != nil {
+			ps.err = fmt.Errorf("unmarshal request body failed, err: %+v", err)
+			return ps
+		}
+
+		ps.Attribute.Resources = make([]meta.ResourceAttribute, 0)
+		for _, hostID := range input.InstanceIDs {
+			ps.Attribute.Resources = append(ps.Attribute.Resources, meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:       meta.HostInstance,
+					Action:     meta.UpdateMany,
+					InstanceID: hostID,
+				},
+			})
+		}
+		return ps
+	}
+
+	// aggregate labels of hosts.
+	if ps.hitPattern(findHostLabelsAggregationPattern, http.MethodPost) {
+		bizID, err := ps.parseBusinessID()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.HostInstance,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
 	// clean the hosts in a set or module, and move these hosts to the business idle module
 	// when these hosts only exist in this set or module. otherwise these hosts will only be
 	// removed from this set or module.
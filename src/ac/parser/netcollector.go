@@ -15,7 +15,9 @@ func (ps *parseStream) netCollectorRelated() *parseStream {
 	ps.netCollector().
 		netDevice().
 		netProperty().
-		netReport()
+		netReport().
+		collectorIngest().
+		hostSnapHistory()
 
 	return ps
 }
@@ -329,3 +331,33 @@ func (ps *parseStream) netReport() *parseStream {
 
 	return ps
 }
+
+var collectorIngestConfigs = []AuthConfig{
+	{
+		Name:           "ingestCollectorMessageRegex",
+		Description:    "push collector messages by the ingestion api, it's authorized by the collector token in datacollection",
+		Regex:          regexp.MustCompile(`^/api/v3/collector/ingest/[^\s/]+/action/(create|batch)/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.NetDataCollector,
+		ResourceAction: meta.SkipAction,
+	},
+}
+
+func (ps *parseStream) collectorIngest() *parseStream {
+	return ParseStreamWithFramework(ps, collectorIngestConfigs)
+}
+
+var hostSnapHistoryConfigs = []AuthConfig{
+	{
+		Name:           "findHostSnapHistoryPattern",
+		Description:    "search host snapshot history, it's skipped the same as the host snapshot",
+		Pattern:        "/api/v3/collector/hostsnap/history/action/search",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.HostInstance,
+		ResourceAction: meta.SkipAction,
+	},
+}
+
+func (ps *parseStream) hostSnapHistory() *parseStream {
+	return ParseStreamWithFramework(ps, hostSnapHistoryConfigs)
+}
@@ -25,6 +25,10 @@ import (
  http.MethodPost,  "/update/operation/chart"
  http.MethodGet,  "/search/operation/chart"
  http.MethodPost,  "/search/operation/chart/data"
+ http.MethodPost,  "/find/operation/topo/snapshot"
+ http.MethodPost,  "/find/operation/topo/snapshot/diff"
+ http.MethodPost,  "/find/operation/inst/snapshot"
+ http.MethodPost,  "/find/operation/inst/snapshot/diff"
 */
 var OperationStatisticAuthConfigs = []AuthConfig{
 	{
@@ -81,6 +85,42 @@ var OperationStatisticAuthConfigs = []AuthConfig{
 		ResourceType:   meta.OperationStatistic,
 		ResourceAction: meta.Update,
 	},
+	{
+		Name:           "SearchTopoSnapshotRegex",
+		Description:    "查看业务拓扑历史快照",
+		Regex:          regexp.MustCompile(`^/api/v3/find/operation/topo/snapshot/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Find,
+	},
+	{
+		Name:           "DiffTopoSnapshotRegex",
+		Description:    "对比业务拓扑历史快照",
+		Regex:          regexp.MustCompile(`^/api/v3/find/operation/topo/snapshot/diff/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Find,
+	},
+	{
+		Name:           "SearchInstSnapshotRegex",
+		Description:    "查看模型实例历史快照",
+		Regex:          regexp.MustCompile(`^/api/v3/find/operation/inst/snapshot/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Find,
+	},
+	{
+		Name:           "DiffInstSnapshotRegex",
+		Description:    "对比模型实例历史快照",
+		Regex:          regexp.MustCompile(`^/api/v3/find/operation/inst/snapshot/diff/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Find,
+	},
 }
 
 func (ps *parseStream) OperationStatistic() *parseStream {
@@ -104,6 +104,14 @@ var ProcessInstanceAuthConfigs = []AuthConfig{
 		BizIDGetter:    DefaultBizIDGetter,
 		ResourceType:   ProcessInstanceIAMResourceType,
 		ResourceAction: meta.Find,
+	}, {
+		Name:           "findProcessInstancesByPort",
+		Description:    "查询业务下监听指定端口的进程",
+		Pattern:        "/api/v3/findmany/proc/process_instance/by_port",
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   ProcessInstanceIAMResourceType,
+		ResourceAction: meta.Find,
 	},
 }
 
@@ -13,6 +13,8 @@
 package parser
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -23,6 +25,8 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 
 	"github.com/tidwall/gjson"
 )
@@ -811,6 +815,10 @@ var (
 	findObjectInstanceSubTopologyLatestRegexp = regexp.MustCompile(`^/api/v3/find/insttopo/object/[^\s/]+/inst/[0-9]+/?$`)
 	findObjectInstanceTopologyLatestRegexp    = regexp.MustCompile(`^/api/v3/find/instassttopo/object/[^\s/]+/inst/[0-9]+/?$`)
 	findObjectInstancesLatestRegexp           = regexp.MustCompile(`^/api/v3/find/instance/object/[^\s/]+/?$`)
+	addObjectInstanceLabelsLatestRegexp       = regexp.MustCompile(`^/api/v3/createmany/instance/object/[^\s/]+/labels/?$`)
+	removeObjectInstanceLabelsLatestRegexp    = regexp.MustCompile(`^/api/v3/deletemany/instance/object/[^\s/]+/labels/?$`)
+	findObjectInstanceLabelsAggrLatestRegexp  = regexp.MustCompile(
+		`^/api/v3/findmany/instance/object/[^\s/]+/labels/aggregation/?$`)
 )
 
 func (ps *parseStream) objectInstanceLatest() *parseStream {
@@ -973,6 +981,81 @@ func (ps *parseStream) objectInstanceLatest() *parseStream {
 		return ps
 	}
 
+	// add or remove labels of the object's instances operation
+	if ps.hitRegexp(addObjectInstanceLabelsLatestRegexp, http.MethodPost) ||
+		ps.hitRegexp(removeObjectInstanceLabelsLatestRegexp, http.MethodDelete) {
+		if len(ps.RequestCtx.Elements) != 7 {
+			ps.err = errors.New("update object instance labels, but got invalid url")
+			return ps
+		}
+
+		objectID := ps.RequestCtx.Elements[5]
+		model, err := ps.getOneModel(mapstr.MapStr{common.BKObjIDField: objectID})
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		instanceType, err := ps.getInstanceTypeByObject(objectID)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		bizID, err := ps.RequestCtx.getBizIDFromBody()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		val, err := ps.RequestCtx.getValueFromBody("instance_ids")
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		val.ForEach(
+			func(key, value gjson.Result) bool {
+				ps.Attribute.Resources = append(ps.Attribute.Resources, meta.ResourceAttribute{
+					BusinessID: bizID,
+					Basic: meta.Basic{
+						Type:       instanceType,
+						Action:     meta.Update,
+						InstanceID: value.Int(),
+					},
+					Layers: []meta.Item{{Type: meta.Model, InstanceID: model.ID}},
+				})
+				return true
+			})
+
+		return ps
+	}
+
+	// aggregate labels of the object's instances operation
+	if ps.hitRegexp(findObjectInstanceLabelsAggrLatestRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 8 {
+			ps.err = errors.New("aggregate object instance labels, but got invalid url")
+			return ps
+		}
+
+		objectID := ps.RequestCtx.Elements[5]
+		model, err := ps.getOneModel(mapstr.MapStr{common.BKObjIDField: objectID})
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.ModelInstance,
+					Action: meta.FindMany,
+				},
+				Layers: []meta.Item{{Type: meta.Model, InstanceID: model.ID}},
+			},
+		}
+		return ps
+	}
+
 	// batch delete instance operation
 	if ps.hitRegexp(deleteObjectInstanceBatchLatestRegexp, http.MethodDelete) {
 		if len(ps.RequestCtx.Elements) != 6 {
@@ -1119,6 +1202,9 @@ const (
 	createObjectLatestPattern       = "/api/v3/create/object"
 	findObjectsLatestPattern        = "/api/v3/find/object"
 	findObjectTopologyLatestPattern = "/api/v3/find/objecttopology"
+	exportModelSpecLatestPattern    = "/api/v3/find/object/spec"
+	planModelSpecLatestPattern      = "/api/v3/find/object/spec/plan"
+	applyModelSpecLatestPattern     = "/api/v3/update/object/spec"
 )
 
 var (
@@ -1238,6 +1324,38 @@ func (ps *parseStream) objectLatest() *parseStream {
 		return ps
 	}
 
+	// export or plan the models' declarative definition, it's the find of the models.
+	if ps.hitPattern(exportModelSpecLatestPattern, http.MethodPost) ||
+		ps.hitPattern(planModelSpecLatestPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.Model,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// apply the models' declarative definition, the models in the spec are created or updated, and the
+	// models which are not in the spec are deleted if prune is set.
+	if ps.hitPattern(applyModelSpecLatestPattern, http.MethodPost) {
+		body, err := ps.RequestCtx.getRequestBody()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		opt := new(metadata.ModelSpecOption)
+		if err := json.Unmarshal(body, opt); err != nil {
+			ps.err = fmt.Errorf("apply model spec, but unmarshal body failed, err: %v", err)
+			return ps
+		}
+
+		ps.Attribute.Resources, ps.err = ps.applyModelSpecResources(opt)
+		return ps
+	}
+
 	// get object operation.
 	if ps.hitPattern(findObjectsLatestPattern, http.MethodPost) {
 		bizID, err := ps.RequestCtx.getBizIDFromBody()
@@ -1635,6 +1753,11 @@ var (
 	updateObjectAttributeIndexLatestRegexp = regexp.MustCompile(`^/api/v3/update/objectattr/index/[^\s/]+/[0-9]+/?$`)
 	createBizCustomFieldLatestRegexp       = regexp.MustCompile(`^/api/v3/create/objectattr/biz/[0-9]+/?$`)
 	updateBizCustomFieldLatestRegexp       = regexp.MustCompile(`^/api/v3/update/objectattr/biz/[0-9]+/id/[0-9]+/?$`)
+
+	findObjectAttrSchemaPlanLatestRegexp      = regexp.MustCompile(`^/api/v3/find/objectattr/schema/plan/object/[^\s/]+/?$`)
+	updateObjectAttrSchemaLatestRegexp        = regexp.MustCompile(`^/api/v3/update/objectattr/schema/object/[^\s/]+/?$`)
+	rollbackObjectAttrSchemaLatestRegexp      = regexp.MustCompile(`^/api/v3/update/objectattr/schema/rollback/object/[^\s/]+/migration/[^\s/]+/?$`)
+	findObjectAttrSchemaMigrationLatestRegexp = regexp.MustCompile(`^/api/v3/find/objectattr/schema/migration/object/[^\s/]+/?$`)
 )
 
 func (ps *parseStream) objectAttributeLatest() *parseStream {
@@ -1953,6 +2076,102 @@ func (ps *parseStream) objectAttributeLatest() *parseStream {
 		return ps
 	}
 
+	// plan an attribute schema change or find the attribute schema migrations, it's a read operation.
+	if ps.hitRegexp(findObjectAttrSchemaPlanLatestRegexp, http.MethodPost) ||
+		ps.hitRegexp(findObjectAttrSchemaMigrationLatestRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 8 {
+			ps.err = errors.New("find object attribute schema, but got invalid url")
+			return ps
+		}
+
+		model, err := ps.getOneModel(mapstr.MapStr{common.BKObjIDField: ps.RequestCtx.Elements[7]})
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.ModelAttribute,
+					Action: meta.FindMany,
+				},
+				Layers: []meta.Item{{Type: meta.Model, InstanceID: model.ID}},
+			},
+		}
+		return ps
+	}
+
+	// change an attribute's schema, it's the update of the attribute.
+	if ps.hitRegexp(updateObjectAttrSchemaLatestRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 7 {
+			ps.err = errors.New("update object attribute schema, but got invalid url")
+			return ps
+		}
+
+		val, err := ps.RequestCtx.getValueFromBody(common.BKPropertyIDField)
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		cond := mapstr.MapStr{common.BKObjIDField: ps.RequestCtx.Elements[6], common.BKPropertyIDField: val.String()}
+		attr, err := ps.getModelAttribute(cond)
+		if err != nil {
+			ps.err = fmt.Errorf("update object attribute schema, but fetch attribute by %v failed %v", cond, err)
+			return ps
+		}
+
+		if len(attr) == 0 {
+			ps.err = errors.New("can not find attribute detail")
+			return ps
+		}
+
+		model, err := ps.getOneModel(mapstr.MapStr{common.BKObjIDField: attr[0].ObjectID})
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				BusinessID: attr[0].BizID,
+				Basic: meta.Basic{
+					Type:       meta.ModelAttribute,
+					Action:     meta.Update,
+					InstanceID: attr[0].ID,
+				},
+				Layers: []meta.Item{{Type: meta.Model, InstanceID: model.ID}},
+			},
+		}
+		return ps
+	}
+
+	// roll back an attribute schema migration, it's the update of the model's attributes.
+	if ps.hitRegexp(rollbackObjectAttrSchemaLatestRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 10 {
+			ps.err = errors.New("roll back object attribute schema migration, but got invalid url")
+			return ps
+		}
+
+		model, err := ps.getOneModel(mapstr.MapStr{common.BKObjIDField: ps.RequestCtx.Elements[7]})
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.ModelAttribute,
+					Action: meta.Update,
+				},
+				Layers: []meta.Item{{Type: meta.Model, InstanceID: model.ID}},
+			},
+		}
+		return ps
+	}
+
 	return ps
 }
 
@@ -2133,3 +2352,165 @@ func (ps *parseStream) cache() *parseStream {
 
 	return ps
 }
+
+// applyModelSpecResources returns the resources to apply the model spec, the new classifications and models
+// need the create permission, the existing models need the update permission, and the models to be pruned
+// need the delete permission.
+func (ps *parseStream) applyModelSpecResources(opt *metadata.ModelSpecOption) ([]meta.ResourceAttribute, error) {
+	clsIDs := make([]string, 0)
+	for _, cls := range opt.Spec.Classifications {
+		clsIDs = append(clsIDs, cls.ID)
+	}
+	objIDs := make([]string, 0)
+	specObjIDs := make(map[string]bool)
+	for _, obj := range opt.Spec.Models {
+		objIDs = append(objIDs, obj.ID)
+		specObjIDs[obj.ID] = true
+		clsIDs = append(clsIDs, obj.Classification)
+	}
+
+	classifications := make(map[string]int64)
+	clsRsp, err := ps.engine.CoreAPI.CoreService().Model().ReadModelClassification(context.Background(),
+		ps.RequestCtx.Header, &metadata.QueryCondition{
+			Condition: mapstr.MapStr{common.BKClassificationIDField: mapstr.MapStr{common.BKDBIN: clsIDs}},
+		})
+	if err != nil {
+		return nil, err
+	}
+	if !clsRsp.Result {
+		return nil, fmt.Errorf("search classifications failed, err: %s", clsRsp.ErrMsg)
+	}
+	for _, cls := range clsRsp.Data.Info {
+		classifications[cls.ClassificationID] = cls.ID
+	}
+
+	resources := make([]meta.ResourceAttribute, 0)
+	for _, cls := range opt.Spec.Classifications {
+		if _, exist := classifications[cls.ID]; exist {
+			continue
+		}
+		resources = append(resources, meta.ResourceAttribute{
+			Basic: meta.Basic{Type: meta.ModelClassification, Action: meta.Create},
+		})
+	}
+
+	objCond := mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objIDs}}
+	if opt.Prune {
+		objCond = mapstr.MapStr{common.BKDBOR: []mapstr.MapStr{objCond, {
+			common.BKClassificationIDField: mapstr.MapStr{common.BKDBIN: clsIDs},
+			common.BKIsPre:                 false,
+		}}}
+	}
+	objRsp, err := ps.engine.CoreAPI.CoreService().Model().ReadModel(context.Background(), ps.RequestCtx.Header,
+		&metadata.QueryCondition{Condition: objCond})
+	if err != nil {
+		return nil, err
+	}
+	if !objRsp.Result {
+		return nil, fmt.Errorf("search models failed, err: %s", objRsp.ErrMsg)
+	}
+
+	existObjIDs := make(map[string]bool)
+	for _, info := range objRsp.Data.Info {
+		existObjIDs[info.Spec.ObjectID] = true
+		action := meta.Update
+		if !specObjIDs[info.Spec.ObjectID] {
+			action = meta.Delete
+		}
+		resources = append(resources, meta.ResourceAttribute{
+			Basic: meta.Basic{Type: meta.Model, Action: action, InstanceID: info.Spec.ID},
+		})
+	}
+
+	for _, obj := range opt.Spec.Models {
+		if existObjIDs[obj.ID] {
+			continue
+		}
+		resource := meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model, Action: meta.Create}}
+		if clsID, exist := classifications[obj.Classification]; exist {
+			resource.Layers = []meta.Item{{Type: meta.ModelClassification, InstanceID: clsID}}
+		}
+		resources = append(resources, resource)
+	}
+
+	presetResources, err := ps.applyModelSpecPresetDataResources(opt)
+	if err != nil {
+		return nil, err
+	}
+	return append(resources, presetResources...), nil
+}
+
+// applyModelSpecPresetDataResources returns the resources to apply the preset data of the model spec, the new
+// association kinds need the create permission and the existing ones need the update permission, the new
+// service categories need the create permission of their businesses.
+func (ps *parseStream) applyModelSpecPresetDataResources(opt *metadata.ModelSpecOption) ([]meta.ResourceAttribute,
+	error) {
+
+	resources := make([]meta.ResourceAttribute, 0)
+	if len(opt.Spec.AssociationKinds) > 0 {
+		kindIDs := make([]string, 0)
+		for _, kind := range opt.Spec.AssociationKinds {
+			kindIDs = append(kindIDs, kind.ID)
+		}
+		kindRsp, err := ps.engine.CoreAPI.CoreService().Association().ReadAssociationType(context.Background(),
+			ps.RequestCtx.Header, &metadata.QueryCondition{
+				Condition: mapstr.MapStr{common.AssociationKindIDField: mapstr.MapStr{common.BKDBIN: kindIDs}},
+			})
+		if err != nil {
+			return nil, err
+		}
+		if !kindRsp.Result {
+			return nil, fmt.Errorf("search association kinds failed, err: %s", kindRsp.ErrMsg)
+		}
+
+		existKinds := make(map[string]int64)
+		for _, kind := range kindRsp.Data.Info {
+			existKinds[kind.AssociationKindID] = kind.ID
+		}
+		for _, kind := range opt.Spec.AssociationKinds {
+			if id, exist := existKinds[kind.ID]; exist {
+				resources = append(resources, meta.ResourceAttribute{
+					Basic: meta.Basic{Type: meta.AssociationType, Action: meta.Update, InstanceID: id},
+				})
+				continue
+			}
+			resources = append(resources, meta.ResourceAttribute{
+				Basic: meta.Basic{Type: meta.AssociationType, Action: meta.Create},
+			})
+		}
+	}
+
+	bizNames := make([]string, 0)
+	for _, category := range opt.Spec.ServiceCategories {
+		if !category.BuiltIn {
+			bizNames = append(bizNames, category.Business)
+		}
+	}
+	if len(bizNames) == 0 {
+		return resources, nil
+	}
+
+	// the categories of the businesses which do not exist are conflicted and will not be created.
+	bizRsp, err := ps.engine.CoreAPI.CoreService().Instance().ReadInstance(context.Background(),
+		ps.RequestCtx.Header, common.BKInnerObjIDApp, &metadata.QueryCondition{
+			Fields:    []string{common.BKAppIDField},
+			Condition: mapstr.MapStr{common.BKAppNameField: mapstr.MapStr{common.BKDBIN: bizNames}},
+		})
+	if err != nil {
+		return nil, err
+	}
+	if !bizRsp.Result {
+		return nil, fmt.Errorf("search businesses failed, err: %s", bizRsp.ErrMsg)
+	}
+	for _, biz := range bizRsp.Data.Info {
+		bizID, err := util.GetInt64ByInterface(biz[common.BKAppIDField])
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, meta.ResourceAttribute{
+			BusinessID: bizID,
+			Basic:      meta.Basic{Type: meta.ProcessServiceCategory, Action: meta.Create},
+		})
+	}
+	return resources, nil
+}
@@ -0,0 +1,79 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// ListModels list models with object id list and return with a json array string which is []string json.
+// all the models will be returned if the object id list is empty.
+func (b *baseCache) ListModels(ctx context.Context, h http.Header, opt *metadata.ListModelCacheOption) (
+	jsonArray string, err error) {
+
+	resp, err := b.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/cache/model").
+		WithHeaders(h).
+		Do().
+		IntoJsonString()
+
+	if err != nil {
+		return "", errors.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+	}
+
+	if !resp.Result {
+		return "", errors.New(resp.Code, resp.ErrMsg)
+	}
+
+	return resp.Data, nil
+}
+
+// ListAttributes list all the attributes of a model and return with a json array string which is []string json.
+func (b *baseCache) ListAttributes(ctx context.Context, h http.Header, objID string) (jsonArray string, err error) {
+	return b.listModelResource(ctx, h, objID, "attribute")
+}
+
+// ListUniques list all the unique rules of a model and return with a json array string which is []string json.
+func (b *baseCache) ListUniques(ctx context.Context, h http.Header, objID string) (jsonArray string, err error) {
+	return b.listModelResource(ctx, h, objID, "unique")
+}
+
+// ListAssociations list all the associations of a model, whether the model is the source or the destination
+// model of the association, and return with a json array string which is []string json.
+func (b *baseCache) ListAssociations(ctx context.Context, h http.Header, objID string) (jsonArray string, err error) {
+	return b.listModelResource(ctx, h, objID, "association")
+}
+
+func (b *baseCache) listModelResource(ctx context.Context, h http.Header, objID, kind string) (string, error) {
+	resp, err := b.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef("/findmany/cache/model/%s/%s", objID, kind).
+		WithHeaders(h).
+		Do().
+		IntoJsonString()
+	if err != nil {
+		return "", errors.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+	}
+	if !resp.Result {
+		return "", errors.New(resp.Code, resp.ErrMsg)
+	}
+	return resp.Data, nil
+}
@@ -16,6 +16,8 @@ import (
 	"fmt"
 
 	"configcenter/src/apimachinery/cacheservice/cache/host"
+	"configcenter/src/apimachinery/cacheservice/cache/instance"
+	"configcenter/src/apimachinery/cacheservice/cache/model"
 	"configcenter/src/apimachinery/cacheservice/cache/topology"
 	"configcenter/src/apimachinery/rest"
 	"configcenter/src/apimachinery/util"
@@ -24,6 +26,8 @@ import (
 type Cache interface {
 	Host() host.Interface
 	Topology() topology.Interface
+	Model() model.Interface
+	Instance() instance.Interface
 }
 
 type CacheServiceClientInterface interface {
@@ -58,3 +62,11 @@ func (c *cache) Host() host.Interface {
 func (c *cache) Topology() topology.Interface {
 	return topology.NewCacheClient(c.restCli)
 }
+
+func (c *cache) Model() model.Interface {
+	return model.NewCacheClient(c.restCli)
+}
+
+func (c *cache) Instance() instance.Interface {
+	return instance.NewCacheClient(c.restCli)
+}
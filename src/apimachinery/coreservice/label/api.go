@@ -79,3 +79,36 @@ func (l *label) RemoveLabel(ctx context.Context, h http.Header, tableName string
 
 	return nil
 }
+
+func (l *label) LabelAggregation(ctx context.Context, h http.Header, tableName string,
+	condition map[string]interface{}) (map[string][]string, errors.CCErrorCoder) {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ret := new(struct {
+		metadata.BaseResp `json:",inline"`
+		Data              map[string][]string `json:"data"`
+	})
+	subPath := "/findmany/labels/aggregation"
+
+	body := selector.LabelAggregationRequest{
+		Condition: condition,
+		TableName: tableName,
+	}
+	err := l.client.Post().
+		WithContext(ctx).
+		Body(body).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("LabelAggregation failed, http request failed, err: %+v, rid: %s", err, rid)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
@@ -24,6 +24,8 @@ import (
 type LabelInterface interface {
 	AddLabel(ctx context.Context, h http.Header, tableName string, option selector.LabelAddOption) errors.CCErrorCoder
 	RemoveLabel(ctx context.Context, h http.Header, tableName string, option selector.LabelRemoveOption) errors.CCErrorCoder
+	LabelAggregation(ctx context.Context, h http.Header, tableName string,
+		condition map[string]interface{}) (map[string][]string, errors.CCErrorCoder)
 }
 
 func NewLabelInterfaceClient(client rest.ClientInterface) LabelInterface {
@@ -312,6 +312,76 @@ func (m *model) ReadModelAttrByCondition(ctx context.Context, h http.Header, inp
 	return
 }
 
+func (m *model) PlanModelAttrSchemaChange(ctx context.Context, h http.Header, objID string, input *metadata.AttributeSchemaChange) (resp *metadata.AttributeSchemaChangePlanResult, err error) {
+	resp = new(metadata.AttributeSchemaChangePlanResult)
+	subPath := "/read/model/%s/attributes/schema/plan"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath, objID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (m *model) CreateModelAttrSchemaMigration(ctx context.Context, h http.Header, objID string, input *metadata.AttributeSchemaChange) (resp *metadata.AttributeSchemaMigrationTaskResult, err error) {
+	resp = new(metadata.AttributeSchemaMigrationTaskResult)
+	subPath := "/create/model/%s/attributes/schema/migration"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath, objID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (m *model) RollbackModelAttrSchemaMigration(ctx context.Context, h http.Header, migrationID string) (resp *metadata.AttributeSchemaMigrationTaskResult, err error) {
+	resp = new(metadata.AttributeSchemaMigrationTaskResult)
+	subPath := "/update/model/attributes/schema/migration/%s/rollback"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath, migrationID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (m *model) ExecuteModelAttrSchemaMigrationStep(ctx context.Context, h http.Header, input *metadata.AttributeSchemaMigrationStep) (resp *metadata.BaseResp, err error) {
+	resp = new(metadata.BaseResp)
+	subPath := "/update/model/attributes/schema/migration/step"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (m *model) ReadModelAttrSchemaMigrations(ctx context.Context, h http.Header, input *metadata.SearchAttributeSchemaMigrationOption) (resp *metadata.AttributeSchemaMigrationsResult, err error) {
+	resp = new(metadata.AttributeSchemaMigrationsResult)
+	subPath := "/read/model/attributes/schema/migration"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
 func (m *model) ReadAttributeGroup(ctx context.Context, h http.Header, objID string, input metadata.QueryCondition) (resp metadata.ReadModelAttributeGroupResult, err error) {
 	subPath := "/read/model/%s/group"
 
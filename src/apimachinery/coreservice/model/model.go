@@ -44,6 +44,11 @@ type ModelClientInterface interface {
 	ReadModelAttr(ctx context.Context, h http.Header, objID string, input *metadata.QueryCondition) (resp *metadata.ReadModelAttrResult, err error)
 	// deprecated, only for old api
 	ReadModelAttrByCondition(ctx context.Context, h http.Header, input *metadata.QueryCondition) (resp *metadata.ReadModelAttrResult, err error)
+	PlanModelAttrSchemaChange(ctx context.Context, h http.Header, objID string, input *metadata.AttributeSchemaChange) (resp *metadata.AttributeSchemaChangePlanResult, err error)
+	CreateModelAttrSchemaMigration(ctx context.Context, h http.Header, objID string, input *metadata.AttributeSchemaChange) (resp *metadata.AttributeSchemaMigrationTaskResult, err error)
+	RollbackModelAttrSchemaMigration(ctx context.Context, h http.Header, migrationID string) (resp *metadata.AttributeSchemaMigrationTaskResult, err error)
+	ExecuteModelAttrSchemaMigrationStep(ctx context.Context, h http.Header, input *metadata.AttributeSchemaMigrationStep) (resp *metadata.BaseResp, err error)
+	ReadModelAttrSchemaMigrations(ctx context.Context, h http.Header, input *metadata.SearchAttributeSchemaMigrationOption) (resp *metadata.AttributeSchemaMigrationsResult, err error)
 	GetModelStatistics(ctx context.Context, h http.Header) (resp *metadata.Response, err error)
 
 	ReadAttributeGroup(ctx context.Context, h http.Header, objID string, input metadata.QueryCondition) (resp metadata.ReadModelAttributeGroupResult, err error)
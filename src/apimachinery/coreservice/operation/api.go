@@ -35,6 +35,23 @@ func (s *operation) SearchChartData(ctx context.Context, h http.Header, data met
 	return
 }
 
+// SearchAggregationChartData search the aggregated data of the aggregation chart
+func (s *operation) SearchAggregationChartData(ctx context.Context, h http.Header, data metadata.ChartConfig) (
+	*metadata.ChartAggregationResponse, error) {
+
+	resp := new(metadata.ChartAggregationResponse)
+	subPath := "/find/operation/chart/data"
+
+	err := s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return resp, err
+}
+
 func (s *operation) CreateOperationChart(ctx context.Context, h http.Header, data interface{}) (resp *metadata.CoreUint64Response, err error) {
 	resp = new(metadata.CoreUint64Response)
 	subPath := "/create/operation/chart"
@@ -146,3 +163,95 @@ func (s *operation) TimerFreshData(ctx context.Context, h http.Header, data inte
 		Into(resp)
 	return
 }
+
+// SearchChartsCommon search all the chart configs which match the condition
+func (s *operation) SearchChartsCommon(ctx context.Context, h http.Header, data interface{}) (
+	*metadata.SearchChartsCommon, error) {
+
+	resp := new(metadata.SearchChartsCommon)
+	subPath := "/findmany/operation/chart/common"
+
+	err := s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return resp, err
+}
+
+// TakeTopoSnapshot takes today's topology snapshot of the business
+func (s *operation) TakeTopoSnapshot(ctx context.Context, h http.Header, data metadata.TakeTopoSnapshotOption) (*metadata.BaseResp, error) {
+	resp := new(metadata.BaseResp)
+	subPath := "/create/operation/topo/snapshot"
+
+	err := s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return resp, err
+}
+
+// TakeInstSnapshot takes today's instance snapshot of the model
+func (s *operation) TakeInstSnapshot(ctx context.Context, h http.Header, data metadata.TakeInstSnapshotOption) (*metadata.BaseResp, error) {
+	resp := new(metadata.BaseResp)
+	subPath := "/create/operation/inst/snapshot"
+
+	err := s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return resp, err
+}
+
+// ClearSnapshot removes the snapshots older than the keep days
+func (s *operation) ClearSnapshot(ctx context.Context, h http.Header, data metadata.ClearSnapshotOption) (*metadata.BaseResp, error) {
+	resp := new(metadata.BaseResp)
+	subPath := "/delete/operation/snapshot"
+
+	err := s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return resp, err
+}
+
+// SearchTopoSnapshot searches the latest topology snapshot of the business taken on or before the date
+func (s *operation) SearchTopoSnapshot(ctx context.Context, h http.Header, data metadata.SearchTopoSnapshotOption) (*metadata.TopoSnapshotResponse, error) {
+	resp := new(metadata.TopoSnapshotResponse)
+	subPath := "/find/operation/topo/snapshot"
+
+	err := s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return resp, err
+}
+
+// SearchInstSnapshot searches the latest instance snapshot of the model taken on or before the date
+func (s *operation) SearchInstSnapshot(ctx context.Context, h http.Header, data metadata.SearchInstSnapshotOption) (*metadata.InstSnapshotResponse, error) {
+	resp := new(metadata.InstSnapshotResponse)
+	subPath := "/find/operation/inst/snapshot"
+
+	err := s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return resp, err
+}
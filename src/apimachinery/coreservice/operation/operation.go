@@ -10,6 +10,7 @@ import (
 
 type OperationClientInterface interface {
 	SearchChartData(ctx context.Context, h http.Header, data metadata.ChartConfig) (resp *metadata.Response, err error)
+	SearchAggregationChartData(ctx context.Context, h http.Header, data metadata.ChartConfig) (*metadata.ChartAggregationResponse, error)
 	SearchInstCount(ctx context.Context, h http.Header, data interface{}) (resp *metadata.CoreUint64Response, err error)
 	CreateOperationChart(ctx context.Context, h http.Header, data interface{}) (resp *metadata.CoreUint64Response, err error)
 	SearchOperationCharts(ctx context.Context, h http.Header, data interface{}) (resp *metadata.SearchChartResponse, err error)
@@ -18,7 +19,13 @@ type OperationClientInterface interface {
 	SearchTimerChartData(ctx context.Context, h http.Header, data interface{}) (resp *metadata.Response, err error)
 	UpdateChartPosition(ctx context.Context, h http.Header, data interface{}) (resp *metadata.Response, err error)
 	SearchChartCommon(ctx context.Context, h http.Header, data interface{}) (resp *metadata.SearchChartCommon, err error)
+	SearchChartsCommon(ctx context.Context, h http.Header, data interface{}) (*metadata.SearchChartsCommon, error)
 	TimerFreshData(ctx context.Context, h http.Header, data interface{}) (resp *metadata.BoolResponse, err error)
+	TakeTopoSnapshot(ctx context.Context, h http.Header, data metadata.TakeTopoSnapshotOption) (*metadata.BaseResp, error)
+	TakeInstSnapshot(ctx context.Context, h http.Header, data metadata.TakeInstSnapshotOption) (*metadata.BaseResp, error)
+	ClearSnapshot(ctx context.Context, h http.Header, data metadata.ClearSnapshotOption) (*metadata.BaseResp, error)
+	SearchTopoSnapshot(ctx context.Context, h http.Header, data metadata.SearchTopoSnapshotOption) (*metadata.TopoSnapshotResponse, error)
+	SearchInstSnapshot(ctx context.Context, h http.Header, data metadata.SearchInstSnapshotOption) (*metadata.InstSnapshotResponse, error)
 }
 
 func NewOperationClientInterface(client rest.ClientInterface) OperationClientInterface {
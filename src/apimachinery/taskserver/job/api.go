@@ -0,0 +1,59 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/metadata"
+)
+
+type JobClientInterface interface {
+	// CreateJob 新建通用任务，任务类型需要在任务服务中注册
+	CreateJob(ctx context.Context, header http.Header, option *metadata.CreateJobOption) (resp *metadata.CreateJobResponse, err error)
+
+	ListJob(ctx context.Context, header http.Header, option *metadata.ListJobOption) (resp *metadata.ListJobResponse, err error)
+
+	JobDetail(ctx context.Context, header http.Header, jobID string) (resp *metadata.JobDetailResponse, err error)
+
+	CancelJob(ctx context.Context, header http.Header, jobID string) (resp *metadata.JobDetailResponse, err error)
+
+	// ReportJobProgress 执行任务的服务上报任务进度及日志，返回任务是否被取消，任务id在任务请求的请求头中
+	ReportJobProgress(ctx context.Context, header http.Header, jobID string, option *metadata.ReportJobProgressOption) (resp *metadata.ReportJobProgressResponse, err error)
+
+	ListJobType(ctx context.Context, header http.Header) (resp *metadata.ListJobTypeResponse, err error)
+
+	// CreateJobSchedule 新建定时任务，按照cron表达式定期创建任务
+	CreateJobSchedule(ctx context.Context, header http.Header, option *metadata.CreateJobScheduleOption) (resp *metadata.JobScheduleResponse, err error)
+
+	UpdateJobSchedule(ctx context.Context, header http.Header, scheduleID string, option *metadata.UpdateJobScheduleOption) (resp *metadata.JobScheduleResponse, err error)
+
+	DeleteJobSchedule(ctx context.Context, header http.Header, scheduleID string) (resp *metadata.Response, err error)
+
+	ListJobSchedule(ctx context.Context, header http.Header, option *metadata.ListJobScheduleOption) (resp *metadata.ListJobScheduleResponse, err error)
+
+	JobScheduleDetail(ctx context.Context, header http.Header, scheduleID string) (resp *metadata.JobScheduleResponse, err error)
+
+	// ListJobScheduleHistory 查询定时任务的执行历史
+	ListJobScheduleHistory(ctx context.Context, header http.Header, scheduleID string, option *metadata.ListJobScheduleRunOption) (resp *metadata.ListJobScheduleRunResponse, err error)
+}
+
+func NewJobClientInterface(client rest.ClientInterface) JobClientInterface {
+	return &job{client: client}
+}
+
+type job struct {
+	client rest.ClientInterface
+}
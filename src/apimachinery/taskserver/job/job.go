@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/metadata"
+)
+
+// CreateJob 新建通用任务，任务类型需要在任务服务中注册
+func (j *job) CreateJob(ctx context.Context, header http.Header, option *metadata.CreateJobOption) (resp *metadata.CreateJobResponse, err error) {
+	resp = new(metadata.CreateJobResponse)
+	subPath := "/job/create"
+
+	err = j.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (j *job) ListJob(ctx context.Context, header http.Header, option *metadata.ListJobOption) (resp *metadata.ListJobResponse, err error) {
+	resp = new(metadata.ListJobResponse)
+	subPath := "/job/findmany/list"
+
+	err = j.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (j *job) JobDetail(ctx context.Context, header http.Header, jobID string) (resp *metadata.JobDetailResponse, err error) {
+	resp = new(metadata.JobDetailResponse)
+	subPath := "/job/findone/detail/%s"
+
+	err = j.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath, jobID).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (j *job) CancelJob(ctx context.Context, header http.Header, jobID string) (resp *metadata.JobDetailResponse, err error) {
+	resp = new(metadata.JobDetailResponse)
+	subPath := "/job/cancel/%s"
+
+	err = j.client.Put().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath, jobID).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+// ReportJobProgress 执行任务的服务上报任务进度及日志，返回任务是否被取消，任务id在任务请求的请求头中
+func (j *job) ReportJobProgress(ctx context.Context, header http.Header, jobID string, option *metadata.ReportJobProgressOption) (resp *metadata.ReportJobProgressResponse, err error) {
+	resp = new(metadata.ReportJobProgressResponse)
+	subPath := "/job/progress/%s"
+
+	err = j.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath, jobID).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (j *job) ListJobType(ctx context.Context, header http.Header) (resp *metadata.ListJobTypeResponse, err error) {
+	resp = new(metadata.ListJobTypeResponse)
+	subPath := "/job/findmany/type"
+
+	err = j.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
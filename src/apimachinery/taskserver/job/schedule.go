@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package job
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/metadata"
+)
+
+// CreateJobSchedule 新建定时任务，按照cron表达式定期创建任务
+func (j *job) CreateJobSchedule(ctx context.Context, header http.Header, option *metadata.CreateJobScheduleOption) (resp *metadata.JobScheduleResponse, err error) {
+	resp = new(metadata.JobScheduleResponse)
+	subPath := "/job/schedule/create"
+
+	err = j.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (j *job) UpdateJobSchedule(ctx context.Context, header http.Header, scheduleID string, option *metadata.UpdateJobScheduleOption) (resp *metadata.JobScheduleResponse, err error) {
+	resp = new(metadata.JobScheduleResponse)
+	subPath := "/job/schedule/update/%s"
+
+	err = j.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath, scheduleID).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (j *job) DeleteJobSchedule(ctx context.Context, header http.Header, scheduleID string) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/job/schedule/delete/%s"
+
+	err = j.client.Delete().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath, scheduleID).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (j *job) ListJobSchedule(ctx context.Context, header http.Header, option *metadata.ListJobScheduleOption) (resp *metadata.ListJobScheduleResponse, err error) {
+	resp = new(metadata.ListJobScheduleResponse)
+	subPath := "/job/schedule/findmany/list"
+
+	err = j.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+func (j *job) JobScheduleDetail(ctx context.Context, header http.Header, scheduleID string) (resp *metadata.JobScheduleResponse, err error) {
+	resp = new(metadata.JobScheduleResponse)
+	subPath := "/job/schedule/findone/detail/%s"
+
+	err = j.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResourcef(subPath, scheduleID).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
+
+// ListJobScheduleHistory 查询定时任务的执行历史
+func (j *job) ListJobScheduleHistory(ctx context.Context, header http.Header, scheduleID string, option *metadata.ListJobScheduleRunOption) (resp *metadata.ListJobScheduleRunResponse, err error) {
+	resp = new(metadata.ListJobScheduleRunResponse)
+	subPath := "/job/schedule/findmany/history/%s"
+
+	err = j.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath, scheduleID).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return
+}
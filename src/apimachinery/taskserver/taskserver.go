@@ -17,6 +17,7 @@ import (
 	"sync"
 
 	"configcenter/src/apimachinery/rest"
+	"configcenter/src/apimachinery/taskserver/job"
 	"configcenter/src/apimachinery/taskserver/queue"
 	"configcenter/src/apimachinery/taskserver/task"
 	"configcenter/src/apimachinery/util"
@@ -27,6 +28,7 @@ import (
 
 type TaskServerClientInterface interface {
 	Task() task.TaskClientInterface
+	Job() job.JobClientInterface
 	Queue(flag string) queue.TaskQueueClientInterface
 }
 
@@ -50,6 +52,10 @@ func (ts *taskServer) Task() task.TaskClientInterface {
 	return task.NewTaskClientInterface(ts.client)
 }
 
+func (ts *taskServer) Job() job.JobClientInterface {
+	return job.NewJobClientInterface(ts.client)
+}
+
 func (ts *taskServer) Queue(flag string) queue.TaskQueueClientInterface {
 	ts.RLock()
 	srv, ok := ts.queueClient[flag]
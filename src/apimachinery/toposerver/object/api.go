@@ -55,6 +55,9 @@ type ObjectInterface interface {
 	SearchObjectUnique(ctx context.Context, objID string, h http.Header) (resp *metadata.Response, err error)
 	UpdateObjectUnique(ctx context.Context, objID string, h http.Header, uniqueID uint64, data *metadata.UpdateUniqueRequest) (resp *metadata.Response, err error)
 	DeleteObjectUnique(ctx context.Context, objID string, h http.Header, uniqueID uint64) (resp *metadata.Response, err error)
+	ExportModelSpec(ctx context.Context, h http.Header, opt *metadata.ExportModelSpecOption) (*metadata.ModelSpecResult, error)
+	PlanModelSpec(ctx context.Context, h http.Header, opt *metadata.ModelSpecOption) (*metadata.ModelSpecPlanResult, error)
+	ApplyModelSpec(ctx context.Context, h http.Header, opt *metadata.ModelSpecOption) (*metadata.ModelSpecApplyResultResp, error)
 }
 
 func NewObjectInterface(client rest.ClientInterface) ObjectInterface {
@@ -110,3 +110,54 @@ func (t *object) DeleteObject(ctx context.Context, objID string, h http.Header,
 		Into(resp)
 	return
 }
+
+// ExportModelSpec export the models' declarative definition.
+func (t *object) ExportModelSpec(ctx context.Context, h http.Header, opt *metadata.ExportModelSpecOption) (
+	*metadata.ModelSpecResult, error) {
+
+	resp := new(metadata.ModelSpecResult)
+	subPath := "/find/object/spec"
+
+	err := t.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return resp, err
+}
+
+// PlanModelSpec returns the changes to apply the models' declarative definition.
+func (t *object) PlanModelSpec(ctx context.Context, h http.Header, opt *metadata.ModelSpecOption) (
+	*metadata.ModelSpecPlanResult, error) {
+
+	resp := new(metadata.ModelSpecPlanResult)
+	subPath := "/find/object/spec/plan"
+
+	err := t.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return resp, err
+}
+
+// ApplyModelSpec applies the models' declarative definition.
+func (t *object) ApplyModelSpec(ctx context.Context, h http.Header, opt *metadata.ModelSpecOption) (
+	*metadata.ModelSpecApplyResultResp, error) {
+
+	resp := new(metadata.ModelSpecApplyResultResp)
+	subPath := "/update/object/spec"
+
+	err := t.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return resp, err
+}
@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	"configcenter/src/ac/iam"
+	"configcenter/src/ac/meta"
 	"configcenter/src/ac/parser"
 	"configcenter/src/common"
 	"configcenter/src/common/auth"
@@ -180,6 +181,13 @@ func (s *service) authFilter(errFunc func() errors.CCErrorIf) func(req *restful.
 			return
 		}
 
+		// the plain values of the sensitive attributes are returned only if the user has the permission to view them
+		if util.IsRevealSensitive(req.Request.Header) {
+			attribute.Resources = append(attribute.Resources, meta.ResourceAttribute{
+				Basic: meta.Basic{Type: meta.SensitiveAttribute, Action: meta.Find},
+			})
+		}
+
 		blog.V(7).Infof("auth filter parse attribute result: %v, rid: %s", attribute, rid)
 		decisions, err := s.authorizer.AuthorizeBatch(req.Request.Context(), req.Request.Header, attribute.User, attribute.Resources...)
 		if err != nil {
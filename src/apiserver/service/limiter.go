@@ -47,6 +47,11 @@ func NewLimiter(zkCli *zkclient.ZkClient) *Limiter {
 // SyncLimiterRules sync the api limiter rules from zk
 func (l *Limiter) SyncLimiterRules() error {
 	blog.Info("begin SyncLimiterRules")
+	if l.zkCli == nil {
+		// the limiter rules are stored in zk, they are not supported by the other regdiscv
+		blog.Info("regdiscv is not zookeeper, skip SyncLimiterRules")
+		return nil
+	}
 	path := types.CC_SERVLIMITER_BASEPATH
 	go func() {
 		for {
@@ -28,6 +28,7 @@ import (
 	"configcenter/src/common/blog"
 	crd "configcenter/src/common/confregdiscover"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/etcdclient"
 	"configcenter/src/common/language"
 	"configcenter/src/common/metrics"
 	"configcenter/src/common/types"
@@ -125,15 +126,15 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 	metricService := metrics.NewService(metrics.Config{ProcessName: common.GetIdentification(), ProcessInstance: input.SrvInfo.Instance()})
 
 	common.SetServerInfo(input.SrvInfo)
-	client, err := newSvcManagerClient(ctx, input.Regdiscv)
+	backend, err := newRegDiscvBackend(ctx, input.Regdiscv)
 	if err != nil {
-		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", input.Regdiscv, err)
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", etcdclient.RedactAddr(input.Regdiscv), err)
 	}
-	serviceDiscovery, err := discovery.NewServiceDiscovery(client)
+	serviceDiscovery, err := discovery.NewServiceDiscoveryWithRegDiscover(backend.regDiscv)
 	if err != nil {
-		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", input.Regdiscv, err)
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", etcdclient.RedactAddr(input.Regdiscv), err)
 	}
-	disc, err := NewServiceRegister(client)
+	disc, err := NewServiceRegisterWithRegDiscover(backend.regDiscv)
 	if err != nil {
 		return nil, fmt.Errorf("new service discover failed, err:%v", err)
 	}
@@ -151,7 +152,8 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 	if err != nil {
 		return nil, fmt.Errorf("new engine failed, err: %v", err)
 	}
-	engine.client = client
+	engine.client = backend.zkClient
+	engine.confRegDiscv = backend.confRegDiscv
 	engine.apiMachineryConfig = apiMachineryConfig
 	engine.discovery = serviceDiscovery
 	engine.ServiceManageInterface = serviceDiscovery
@@ -168,10 +170,9 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 	}
 
 	// add default configcenter
-	zkdisc := crd.NewZkRegDiscover(client)
 	configCenter := &cc.ConfigCenter{
 		Type:               common.BKDefaultConfigCenter,
-		ConfigCenterDetail: zkdisc,
+		ConfigCenterDetail: backend.confRegDiscv,
 	}
 	cc.AddConfigCenter(configCenter)
 
@@ -183,9 +184,12 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 		return nil, fmt.Errorf("new config center failed, err: %v", err)
 	}
 
-	err = handleNotice(ctx, client.Client(), input.SrvInfo.Instance())
-	if err != nil {
-		return nil, fmt.Errorf("handle notice failed, err: %v", err)
+	// the notice of the log level is only supported by zookeeper
+	if backend.zkClient != nil {
+		err = handleNotice(ctx, backend.zkClient.Client(), input.SrvInfo.Instance())
+		if err != nil {
+			return nil, fmt.Errorf("handle notice failed, err: %v", err)
+		}
 	}
 
 	return engine, nil
@@ -228,6 +232,7 @@ type Engine struct {
 	apiMachineryConfig *util.APIMachineryConfig
 
 	client                 *zk.ZkClient
+	confRegDiscv           crd.ConfRegDiscvIf
 	ServiceManageInterface discovery.ServiceManageInterface
 	SvcDisc                ServiceRegisterInterface
 	discovery              discovery.DiscoveryInterface
@@ -253,10 +258,16 @@ func (e *Engine) ApiMachineryConfig() *util.APIMachineryConfig {
 	return e.apiMachineryConfig
 }
 
+// ServiceManageClient returns the zookeeper client, it's nil if the regdiscv is not zookeeper
 func (e *Engine) ServiceManageClient() *zk.ZkClient {
 	return e.client
 }
 
+// ConfRegDiscv returns the register-discover of the config center
+func (e *Engine) ConfRegDiscv() crd.ConfRegDiscvIf {
+	return e.confRegDiscv
+}
+
 func (e *Engine) Metric() *metrics.Service {
 	return e.metric
 }
@@ -28,6 +28,7 @@ import (
 	"configcenter/src/common/language"
 	"configcenter/src/common/types"
 	"configcenter/src/storage/dal/mongo"
+	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/redis"
 
 	"github.com/spf13/viper"
@@ -371,6 +372,8 @@ func Mongo(prefix string) (mongo.Config, error) {
 		c.MaxIdleConns = parser.getUint64(prefix + ".maxIdleConns")
 	}
 
+	c.Tenant = getMongoTenantConf(parser, prefix)
+
 	if !parser.isSet(prefix + ".socketTimeoutSeconds") {
 		blog.Errorf("can not find mongo.socketTimeoutSeconds config, use default value: %d", mongo.DefaultSocketTimeout)
 		c.SocketTimeout = mongo.DefaultSocketTimeout
@@ -391,6 +394,31 @@ func Mongo(prefix string) (mongo.Config, error) {
 	return c, nil
 }
 
+// getMongoTenantConf get the tenant isolation config of mongodb, the quota is configured as:
+// tenant:
+//   strict: false
+//   quota:
+//     default:
+//       cc_HostBase: 100000
+//     tenant_a:
+//       cc_HostBase: 200000
+func getMongoTenantConf(parser *viperParser, prefix string) local.TenantConf {
+	conf := local.TenantConf{
+		Strict: parser.getBool(prefix + ".tenant.strict"),
+		Quota:  make(map[string]map[string]uint64),
+	}
+
+	quotaPath := prefix + ".tenant.quota"
+	for tenant := range parser.getStringMap(quotaPath) {
+		tenantPath := quotaPath + "." + tenant
+		conf.Quota[tenant] = make(map[string]uint64)
+		for table := range parser.getStringMap(tenantPath) {
+			conf.Quota[tenant][table] = parser.getUint64(tenantPath + "." + table)
+		}
+	}
+	return conf
+}
+
 // String return the string value of the configuration information according to the key.
 func String(key string) (string, error) {
 	confLock.RLock()
@@ -516,6 +544,10 @@ func (vp *viperParser) getBool(path string) bool {
 	return vp.parser.GetBool(path)
 }
 
+func (vp *viperParser) getStringMap(path string) map[string]interface{} {
+	return vp.parser.GetStringMap(path)
+}
+
 func (vp *viperParser) isSet(path string) bool {
 	return vp.parser.IsSet(path)
 }
@@ -0,0 +1,95 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backbone
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common/backbone/service_mange/zk"
+	"configcenter/src/common/blog"
+	crd "configcenter/src/common/confregdiscover"
+	"configcenter/src/common/etcdclient"
+	"configcenter/src/common/registerdiscover"
+)
+
+// regDiscvBackend the backend of the service register-discover and the config center, it's chosen by the
+// scheme of the regdiscv address, see registerdiscover.ParseRegDiscvAddr for details.
+type regDiscvBackend struct {
+	typ registerdiscover.RegDiscvType
+	// zkClient is only set when the backend is zookeeper
+	zkClient     *zk.ZkClient
+	regDiscv     *registerdiscover.RegDiscover
+	confRegDiscv crd.ConfRegDiscvIf
+}
+
+func newRegDiscvBackend(ctx context.Context, regdiscv string) (*regDiscvBackend, error) {
+	typ, addr, err := registerdiscover.ParseRegDiscvAddr(regdiscv)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case registerdiscover.RegDiscvEtcd:
+		client, err := newEtcdClient(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &regDiscvBackend{
+			typ:          typ,
+			regDiscv:     registerdiscover.NewRegDiscoverWithServer(registerdiscover.NewEtcdRegDiscv(client)),
+			confRegDiscv: crd.NewEtcdRegDiscover(client),
+		}, nil
+
+	case registerdiscover.RegDiscvFile:
+		fileRegDiscv := registerdiscover.NewFileRegDiscv(addr)
+		if err := fileRegDiscv.Ping(); err != nil {
+			return nil, err
+		}
+		return &regDiscvBackend{
+			typ:          typ,
+			regDiscv:     registerdiscover.NewRegDiscoverWithServer(fileRegDiscv),
+			confRegDiscv: crd.NewFileRegDiscover(addr),
+		}, nil
+
+	default:
+		client, err := newSvcManagerClient(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &regDiscvBackend{
+			typ:          typ,
+			zkClient:     client,
+			regDiscv:     registerdiscover.NewRegDiscoverEx(client),
+			confRegDiscv: crd.NewZkRegDiscover(client),
+		}, nil
+	}
+}
+
+func newEtcdClient(ctx context.Context, addr string) (*etcdclient.EtcdClient, error) {
+	conf, err := etcdclient.ParseConfig(addr, 40*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	client := etcdclient.NewEtcdClientWithConfig(conf)
+	for retry := 0; retry < maxRetry; retry++ {
+		if err = client.Ping(); err != nil {
+			blog.Errorf("connect regdiscv [%s] failed: %v", etcdclient.RedactAddr(addr), err)
+			time.Sleep(time.Second * 2)
+			continue
+		}
+		return client, nil
+	}
+	return nil, fmt.Errorf("connect etcd [%s] failed, err: %v", etcdclient.RedactAddr(addr), err)
+}
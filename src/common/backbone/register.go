@@ -38,6 +38,11 @@ func NewServiceRegister(client *zk.ZkClient) (ServiceRegisterInterface, error) {
 	return s, nil
 }
 
+// NewServiceRegisterWithRegDiscover new a service register with the register-discover backend
+func NewServiceRegisterWithRegDiscover(disc *registerdiscover.RegDiscover) (ServiceRegisterInterface, error) {
+	return &serviceRegister{client: disc}, nil
+}
+
 type serviceRegister struct {
 	client *registerdiscover.RegDiscover
 }
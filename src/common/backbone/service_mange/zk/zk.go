@@ -66,8 +66,11 @@ func (zk *ZkClient) Stop() error {
 	return nil
 }
 
-// Client return zk client
+// Client return zk client, it's nil if the ZkClient is nil, which means the regdiscv is not zookeeper
 func (zk *ZkClient) Client() *zkclient.ZkClient {
+	if zk == nil {
+		return nil
+	}
 	return zk.zkCli
 }
 
@@ -0,0 +1,83 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package confregdiscover
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"configcenter/src/common/etcdclient"
+	"configcenter/src/common/etcdclient/etcdtest"
+)
+
+type confBackend interface {
+	ConfRegDiscvIf
+	Cancel()
+}
+
+func testConfRegDiscv(t *testing.T, backend confBackend, key string, timeout time.Duration) {
+	defer backend.Cancel()
+
+	if err := backend.Ping(); err != nil {
+		t.Fatalf("ping failed, err: %v", err)
+	}
+	ch, err := backend.Discover(key)
+	if err != nil {
+		t.Fatalf("discover failed, err: %v", err)
+	}
+
+	for _, data := range []string{"first", "second"} {
+		if err := backend.Write(key, []byte(data)); err != nil {
+			t.Fatalf("write failed, err: %v", err)
+		}
+		read, err := backend.Read(key)
+		if err != nil || read != data {
+			t.Fatalf("read got (%s, %v), want %s", read, err, data)
+		}
+
+		deadline := time.After(timeout)
+	wait:
+		for {
+			select {
+			case event := <-ch:
+				if event.Err != nil {
+					t.Fatalf("discover failed, err: %v", event.Err)
+				}
+				if event.Key == key && string(event.Data) == data {
+					break wait
+				}
+			case <-deadline:
+				t.Fatalf("discover config %s timeout", data)
+			}
+		}
+	}
+}
+
+func TestEtcdRegDiscover(t *testing.T) {
+	client := etcdclient.NewEtcdClientWithConfig(etcdtest.Config(t))
+	key := etcdtest.Prefix("confregdiscover") + "/cc/services/config/common"
+	defer client.Delete(context.Background(), key)
+	testConfRegDiscv(t, NewEtcdRegDiscover(client), key, 10*time.Second)
+}
+
+func TestFileRegDiscover(t *testing.T) {
+	root, err := ioutil.TempDir("", "fileconf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	testConfRegDiscv(t, NewFileRegDiscover(root), "/cc/services/config/common", 3*fileDiscoverPeriod)
+}
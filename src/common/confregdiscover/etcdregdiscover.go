@@ -0,0 +1,133 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package confregdiscover
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/etcdclient"
+)
+
+// etcdResyncInterval the interval to read the config again in case a watch event is missed
+const etcdResyncInterval = 30 * time.Second
+
+// EtcdRegDiscover config register and discover by etcd
+type EtcdRegDiscover struct {
+	client  *etcdclient.EtcdClient
+	rootCtx context.Context
+	cancel  context.CancelFunc
+}
+
+// NewEtcdRegDiscover create a object of EtcdRegDiscover
+func NewEtcdRegDiscover(client *etcdclient.EtcdClient) *EtcdRegDiscover {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EtcdRegDiscover{
+		client:  client,
+		rootCtx: ctx,
+		cancel:  cancel,
+	}
+}
+
+// Ping to ping server
+func (e *EtcdRegDiscover) Ping() error {
+	return e.client.Ping()
+}
+
+// Write to save config data into etcd
+func (e *EtcdRegDiscover) Write(key string, data []byte) error {
+	return e.client.Put(e.rootCtx, key, data, 0)
+}
+
+// Read the config data from etcd
+func (e *EtcdRegDiscover) Read(key string) (string, error) {
+	kv, err := e.client.Get(e.rootCtx, key)
+	if err != nil {
+		return "", err
+	}
+	return string(kv.Value), nil
+}
+
+// Discover watch the config data of the key
+func (e *EtcdRegDiscover) Discover(key string) (<-chan *DiscoverEvent, error) {
+	env := make(chan *DiscoverEvent, 1)
+	go e.loopDiscover(key, env)
+	return env, nil
+}
+
+func (e *EtcdRegDiscover) loopDiscover(key string, env chan *DiscoverEvent) {
+	modRevision := int64(-1)
+	for {
+		kv, err := e.client.Get(e.rootCtx, key)
+		if err != nil {
+			if err == etcdclient.ErrKeyNotFound {
+				blog.Infof("config(%s) does not exist, will watch after 5s", key)
+			} else {
+				blog.Errorf("fail to read config(%s), err: %v", key, err)
+				if !e.send(env, &DiscoverEvent{Key: key, Err: err}) {
+					return
+				}
+			}
+			if e.sleep(5 * time.Second) {
+				return
+			}
+			continue
+		}
+
+		if kv.ModRevision != modRevision {
+			if !e.send(env, &DiscoverEvent{Key: key, Data: kv.Value}) {
+				return
+			}
+			modRevision = kv.ModRevision
+		}
+
+		watchCtx, cancel := context.WithTimeout(e.rootCtx, etcdResyncInterval)
+		err = e.client.Watch(watchCtx, key, false, kv.ModRevision+1)
+		cancel()
+		if e.rootCtx.Err() != nil {
+			blog.Infof("discover config(%s) done", key)
+			return
+		}
+		if err != nil && err != context.DeadlineExceeded {
+			blog.Errorf("watch config(%s) failed, will retry after 1s, err: %v", key, err)
+			if e.sleep(time.Second) {
+				return
+			}
+		}
+	}
+}
+
+func (e *EtcdRegDiscover) send(env chan *DiscoverEvent, event *DiscoverEvent) bool {
+	select {
+	case env <- event:
+		return true
+	case <-e.rootCtx.Done():
+		return false
+	}
+}
+
+// sleep waits for the duration, returns true if the discover is canceled
+func (e *EtcdRegDiscover) sleep(duration time.Duration) bool {
+	select {
+	case <-e.rootCtx.Done():
+		return true
+	case <-time.After(duration):
+		return false
+	}
+}
+
+// Cancel to stop the config discover
+func (e *EtcdRegDiscover) Cancel() {
+	e.cancel()
+}
@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package confregdiscover
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"configcenter/src/common/blog"
+)
+
+// fileDiscoverPeriod the interval to check whether the config file is changed
+const fileDiscoverPeriod = 3 * time.Second
+
+// FileRegDiscover config register and discover by the files under a directory, the config key is mapped to
+// the file under the root directory, such as /cc/services/config/common to <root>/cc/services/config/common.
+type FileRegDiscover struct {
+	root    string
+	rootCtx context.Context
+	cancel  context.CancelFunc
+}
+
+// NewFileRegDiscover create a object of FileRegDiscover
+func NewFileRegDiscover(root string) *FileRegDiscover {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &FileRegDiscover{
+		root:    root,
+		rootCtx: ctx,
+		cancel:  cancel,
+	}
+}
+
+func (f *FileRegDiscover) file(key string) string {
+	return filepath.Join(f.root, filepath.FromSlash(key))
+}
+
+// Ping to check the root directory
+func (f *FileRegDiscover) Ping() error {
+	info, err := os.Stat(f.root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", f.root)
+	}
+	return nil
+}
+
+// Write to save config data into the file, it's written to a temporary file and renamed, so that the
+// readers never read a partial file
+func (f *FileRegDiscover) Write(key string, data []byte) error {
+	file := f.file(key)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", file, os.Getpid())
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// Read the config data from the file
+func (f *FileRegDiscover) Read(key string) (string, error) {
+	data, err := ioutil.ReadFile(f.file(key))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Discover checks whether the config file is changed periodically
+func (f *FileRegDiscover) Discover(key string) (<-chan *DiscoverEvent, error) {
+	env := make(chan *DiscoverEvent, 1)
+
+	go func() {
+		var modTime time.Time
+		var size int64 = -1
+		for {
+			info, err := os.Stat(f.file(key))
+			switch {
+			case os.IsNotExist(err):
+				blog.V(4).Infof("config file of %s does not exist", key)
+			case err != nil:
+				blog.Errorf("fail to stat config file of %s, err: %v", key, err)
+			case !info.ModTime().Equal(modTime) || info.Size() != size:
+				data, err := ioutil.ReadFile(f.file(key))
+				event := &DiscoverEvent{Key: key, Data: data, Err: err}
+				select {
+				case env <- event:
+				case <-f.rootCtx.Done():
+					return
+				}
+				if err == nil {
+					modTime, size = info.ModTime(), info.Size()
+				}
+			}
+
+			select {
+			case <-f.rootCtx.Done():
+				blog.Infof("discover config(%s) done", key)
+				return
+			case <-time.After(fileDiscoverPeriod):
+			}
+		}
+	}()
+
+	return env, nil
+}
+
+// Cancel to stop the config discover
+func (f *FileRegDiscover) Cancel() {
+	f.cancel()
+}
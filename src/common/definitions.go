@@ -757,6 +757,21 @@ const (
 	// FieldTypeOrganization the organization field type
 	FieldTypeOrganization string = "organization"
 
+	// FieldTypeIP the ip address field type, accepts ipv4, ipv6 or cidr
+	FieldTypeIP string = "ip"
+
+	// FieldTypeURL the url field type
+	FieldTypeURL string = "url"
+
+	// FieldTypeEnumMulti the multi-select enum field type
+	FieldTypeEnumMulti string = "enummulti"
+
+	// FieldTypeInstRef the field type which references an instance of another model
+	FieldTypeInstRef string = "instref"
+
+	// FieldTypeComputed the field type which value is computed by the system
+	FieldTypeComputed string = "computed"
+
 	// FieldTypeSingleLenChar the single char length limit
 	FieldTypeSingleLenChar int = 256
 
@@ -924,8 +939,19 @@ const (
 	BKHTTPSecretsToken   = "BK-Secrets-Token"
 	BKHTTPSecretsProject = "BK-Secrets-Project"
 	BKHTTPSecretsEnv     = "BK-Secrets-Env"
+
+	// BKHTTPCollectorToken token of the collectors pushing data by datacollection ingestion api
+	BKHTTPCollectorToken = "BK-Collector-Token"
+	// BKHTTPCollectorForwarded marks the ingestion request is forwarded by another datacollection node
+	BKHTTPCollectorForwarded = "BK-Collector-Forwarded"
 	// BKHTTPReadReference  query db use secondary node
 	BKHTTPReadReference = "Cc_Read_Preference"
+	// BKHTTPRevealSensitive asks to return the plain values of the sensitive attributes instead of the masks,
+	// the api server only passes it when the user has the permission to view the sensitive attributes.
+	BKHTTPRevealSensitive = "Cc_Reveal_Sensitive"
+	// BKHTTPJobID the id of the task server job which the request executes, the executor reports the job
+	// progress with it.
+	BKHTTPJobID = "Cc_Job_Id"
 )
 
 type ReadPreferenceMode string
@@ -1182,6 +1208,7 @@ const (
 
 const (
 	OperationCustom      = "custom"
+	OperationAggregation = "aggregation"
 	OperationReportType  = "report_type"
 	OperationConfigID    = "config_id"
 	BizModuleHostChart   = "biz_module_host_chart"
@@ -1200,6 +1227,17 @@ const (
 	TimerPattern         = "^[\\d]+\\:[\\d]+$"
 	SyncSetTaskName      = "sync-settemplate2set"
 
+	// OperationDefaultMetricInterval the default interval in seconds to refresh the aggregation chart metrics
+	OperationDefaultMetricInterval = 300
+	// OperationDefaultSnapshotKeepDays the default days to keep the topology and instance snapshots
+	OperationDefaultSnapshotKeepDays = 180
+
+	// AttributeSchemaMigrationTaskName the task name of attribute schema migration
+	AttributeSchemaMigrationTaskName = "attr-schema-migration"
+
+	// HostApplyRunJobType the task server job type which applies the host apply rules of the modules in a business
+	HostApplyRunJobType = "host-apply-run"
+
 	BKHostState = "bk_state"
 )
 
@@ -1226,10 +1264,14 @@ const (
 	BKStatusDetail               = "bk_status_detail"
 	BKLastEditor                 = "bk_last_editor"
 	BKSecretID                   = "bk_secret_id"
+	BKSecretKey                  = "bk_secret_key"
 	BKVpcID                      = "bk_vpc_id"
 	BKVpcName                    = "bk_vpc_name"
 	BKRegion                     = "bk_region"
 	BKCloudSyncVpcs              = "bk_sync_vpcs"
+	BKCloudResourceType          = "bk_resource_type"
+	BKCloudResourceSync          = "bk_resource_sync"
+	BKCloudTagRules              = "bk_tag_rules"
 
 	// 是否为被销毁的云主机
 	IsDestroyedCloudHost = "is_destroyed_cloud_host"
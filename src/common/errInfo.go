@@ -226,6 +226,12 @@ const (
 	// CCErrArrayLengthWrong the length of the array is wrong
 	CCErrArrayLengthWrong = 1199089
 
+	// CCErrCommParamsNeedIP the parameter must be a valid ip address or cidr
+	CCErrCommParamsNeedIP = 1199090
+
+	// CCErrCommParamsNeedURL the parameter must be a valid url
+	CCErrCommParamsNeedURL = 1199091
+
 	// too many requests
 	CCErrTooManyRequestErr = 1199997
 
@@ -608,6 +614,8 @@ const (
 
 	CCErrHostTransferFinalModuleConflict = 1108044
 
+	CCErrProcBindInfoConflict = 1108045
+
 	// audit log 1109XXX
 	CCErrAuditSaveLogFailed      = 1109001
 	CCErrAuditTakeSnapshotFailed = 1109002
@@ -780,6 +788,20 @@ const (
 	CCErrCoreServiceOnlyNodeServiceCategoryAvailable = 1113032
 	// SearchTopoTreeScanTooManyData means hit too many data, we return directly.
 	SearchTopoTreeScanTooManyData = 1113033
+	// CCErrCoreServiceInstRefNotExist the instance [%v] of model [%s] referenced by field [%s] does not exist
+	CCErrCoreServiceInstRefNotExist = 1113035
+	// CCErrCoreServiceInstReferencedByOthers the instance is referenced by field [%s] of model [%s]
+	CCErrCoreServiceInstReferencedByOthers = 1113036
+	// CCErrCoreServiceAttrSchemaChangeNotAllowed the attribute [%s] does not allow schema change, reason: %s
+	CCErrCoreServiceAttrSchemaChangeNotAllowed = 1113037
+	// CCErrCoreServiceAttrSchemaValueUnconvertible [%d] instances' value of attribute [%s] can not be converted
+	CCErrCoreServiceAttrSchemaValueUnconvertible = 1113038
+	// CCErrCoreServiceAttrSchemaMigrationRunning the attribute [%s] has a running schema migration
+	CCErrCoreServiceAttrSchemaMigrationRunning = 1113039
+	// CCErrCoreServiceAttrSchemaMigrationNotExist the schema migration [%s] does not exist
+	CCErrCoreServiceAttrSchemaMigrationNotExist = 1113040
+	// CCErrCoreServiceAttrSchemaMigrationStatusInvalid the schema migration [%s] in status [%s] can not do this operation
+	CCErrCoreServiceAttrSchemaMigrationStatusInvalid = 1113041
 
 	// CCERrrCoreServiceUniqueRuleExist 模型唯一校验规则已经存在
 	CCERrrCoreServiceSameUniqueCheckRuleExist = 1113050
@@ -819,6 +841,8 @@ const (
 	CCErrTaskLockedTaskFail       = 1117005
 	CCErrTaskUnLockedTaskFail     = 1117006
 	CCErrTaskListTaskFail         = 1117007
+	// CCErrTaskJobTypeNotRegistered job type not registered
+	CCErrTaskJobTypeNotRegistered = 1117008
 
 	// cloud_server 1118xxx
 	// CCErrCloudVendorNotSupport cloud vendor not support
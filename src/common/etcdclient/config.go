@@ -0,0 +1,153 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package etcdclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common/ssl"
+)
+
+// Config the config of the etcd client
+type Config struct {
+	// Endpoints the addresses of the etcd members, such as 127.0.0.1:2379 or https://127.0.0.1:2379
+	Endpoints []string
+	// Timeout the timeout of the requests except the watch
+	Timeout time.Duration
+	// TLS the tls config to access the etcd members, the endpoints without scheme are accessed by https if it's set
+	TLS *tls.Config
+	// Username and Password are used to get the auth token when the etcd auth is enabled
+	Username string
+	Password string
+}
+
+// ParseConfig parses the etcd address in the format of [username:password@]host1:port1,host2:port2[?options],
+// the options are:
+// cacert: the ca file to verify the etcd members
+// cert, key: the client certificate and key file for the etcd members which verify the client certificate
+// insecure_skip_verify: true to skip the verification of the etcd members, for testing only
+// for example: root:passwd@127.0.0.1:2379,127.0.0.2:2379?cacert=/data/ca.pem&cert=/data/client.pem&key=/data/key.pem
+func ParseConfig(addr string, timeout time.Duration) (*Config, error) {
+	conf := &Config{Timeout: timeout}
+
+	hosts, rawQuery := addr, ""
+	if idx := strings.Index(addr, "?"); idx >= 0 {
+		hosts, rawQuery = addr[:idx], addr[idx+1:]
+	}
+
+	if idx := strings.LastIndex(hosts, "@"); idx >= 0 {
+		userInfo := hosts[:idx]
+		hosts = hosts[idx+1:]
+
+		username, password := userInfo, ""
+		if sep := strings.Index(userInfo, ":"); sep >= 0 {
+			username, password = userInfo[:sep], userInfo[sep+1:]
+		}
+		var err error
+		if conf.Username, err = url.PathUnescape(username); err != nil {
+			return nil, fmt.Errorf("etcd username is invalid, err: %v", err)
+		}
+		if conf.Password, err = url.PathUnescape(password); err != nil {
+			return nil, fmt.Errorf("etcd password is invalid, err: %v", err)
+		}
+		if conf.Username == "" {
+			return nil, fmt.Errorf("etcd username is not set")
+		}
+	}
+
+	for _, host := range strings.Split(hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			conf.Endpoints = append(conf.Endpoints, host)
+		}
+	}
+	if len(conf.Endpoints) == 0 {
+		return nil, fmt.Errorf("etcd address has no endpoint")
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("etcd address options are invalid, err: %v", err)
+	}
+	var caFile, certFile, keyFile string
+	var insecure bool
+	for key, values := range query {
+		value := values[len(values)-1]
+		switch key {
+		case "cacert":
+			caFile = value
+		case "cert":
+			certFile = value
+		case "key":
+			keyFile = value
+		case "insecure_skip_verify":
+			if insecure, err = strconv.ParseBool(value); err != nil {
+				return nil, fmt.Errorf("etcd option insecure_skip_verify %s is invalid", value)
+			}
+		default:
+			return nil, fmt.Errorf("etcd option %s is not supported", key)
+		}
+	}
+
+	if caFile == "" && certFile == "" && keyFile == "" && !insecure {
+		return conf, nil
+	}
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("etcd options cert and key must be set together")
+	}
+
+	conf.TLS = &tls.Config{InsecureSkipVerify: insecure}
+	if caFile != "" {
+		caConf, err := ssl.ClientTslConfVerityServer(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("load etcd ca file %s failed, err: %v", caFile, err)
+		}
+		conf.TLS.RootCAs = caConf.RootCAs
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load etcd client certificate %s failed, err: %v", certFile, err)
+		}
+		conf.TLS.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
+
+// RedactAddr returns the etcd address with the password hidden so that it can be logged, the address may
+// have the etcd:// scheme.
+func RedactAddr(addr string) string {
+	start, end := 0, len(addr)
+	if idx := strings.Index(addr, "://"); idx >= 0 {
+		start = idx + len("://")
+	}
+	if idx := strings.Index(addr, "?"); idx >= 0 {
+		end = idx
+	}
+	if start > end {
+		return addr
+	}
+
+	at := strings.LastIndex(addr[start:end], "@")
+	if at < 0 {
+		return addr
+	}
+	sep := strings.Index(addr[start:start+at], ":")
+	if sep < 0 {
+		return addr
+	}
+	return addr[:start+sep+1] + "xxxxxx" + addr[start+at:]
+}
@@ -0,0 +1,534 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package etcdclient is a light etcd v3 client which talks to the etcd's grpc gateway in json, so that no grpc
+// dependency is needed. It supports the kv, lease and watch apis used by the service register-discover and
+// the config center, the etcd members can be accessed by tls with client certificate and the user
+// authentication.
+package etcdclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrKeyNotFound the key does not exist
+	ErrKeyNotFound = errors.New("etcd key not found")
+	// ErrLeaseNotFound the lease does not exist, it may be expired
+	ErrLeaseNotFound = errors.New("etcd lease not found")
+)
+
+// KeyValue a key value pair stored in etcd
+type KeyValue struct {
+	Key            string
+	Value          []byte
+	CreateRevision int64
+	ModRevision    int64
+	Lease          int64
+}
+
+// EtcdClient the etcd v3 client
+type EtcdClient struct {
+	endpoints []string
+	client    *http.Client
+	// watchClient has no timeout since the watch is a long running request
+	watchClient *http.Client
+
+	lock sync.Mutex
+	// next the index of the endpoint to try first
+	next int
+
+	username string
+	password string
+	// authLock serializes the authentication, token is the auth token used in the requests
+	authLock sync.Mutex
+	token    string
+}
+
+// NewEtcdClient create the etcd client, the endpoints are the addresses of the etcd members, such as
+// 127.0.0.1:2379 or https://127.0.0.1:2379.
+func NewEtcdClient(endpoints []string, timeout time.Duration) *EtcdClient {
+	return NewEtcdClientWithConfig(&Config{Endpoints: endpoints, Timeout: timeout})
+}
+
+// NewEtcdClientWithConfig create the etcd client with the tls and authentication config
+func NewEtcdClientWithConfig(conf *Config) *EtcdClient {
+	scheme := "http://"
+	if conf.TLS != nil {
+		scheme = "https://"
+	}
+
+	eps := make([]string, 0)
+	for _, ep := range conf.Endpoints {
+		ep = strings.TrimRight(strings.TrimSpace(ep), "/")
+		if ep == "" {
+			continue
+		}
+		if !strings.HasPrefix(ep, "http://") && !strings.HasPrefix(ep, "https://") {
+			ep = scheme + ep
+		}
+		eps = append(eps, ep)
+	}
+
+	return &EtcdClient{
+		endpoints:   eps,
+		client:      &http.Client{Timeout: conf.Timeout, Transport: newTransport(conf.TLS)},
+		watchClient: &http.Client{Transport: newTransport(conf.TLS)},
+		username:    conf.Username,
+		password:    conf.Password,
+	}
+}
+
+func newTransport(tlsConf *tls.Config) http.RoundTripper {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	if tlsConf != nil {
+		transport.TLSClientConfig = tlsConf.Clone()
+	}
+	return transport
+}
+
+// Ping checks whether the etcd cluster can be reached
+func (c *EtcdClient) Ping() error {
+	return c.do(context.Background(), "/v3/maintenance/status", map[string]interface{}{}, nil)
+}
+
+// Put puts the key with the value, the key is attached to the lease if the lease is not 0
+func (c *EtcdClient) Put(ctx context.Context, key string, value []byte, lease int64) error {
+	req := map[string]interface{}{
+		"key":   encode(key),
+		"value": base64.StdEncoding.EncodeToString(value),
+	}
+	if lease != 0 {
+		req["lease"] = strconv.FormatInt(lease, 10)
+	}
+	return c.do(ctx, "/v3/kv/put", req, nil)
+}
+
+// Get gets the value of the key
+func (c *EtcdClient) Get(ctx context.Context, key string) (*KeyValue, error) {
+	kvs, _, err := c.rangeKeys(ctx, key, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(kvs) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	return &kvs[0], nil
+}
+
+// GetPrefix gets the keys with the prefix sorted by their create revision, and the revision of the store.
+func (c *EtcdClient) GetPrefix(ctx context.Context, prefix string) ([]KeyValue, int64, error) {
+	kvs, revision, err := c.rangeKeys(ctx, prefix, prefixEnd(prefix))
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.SliceStable(kvs, func(i, j int) bool {
+		return kvs[i].CreateRevision < kvs[j].CreateRevision
+	})
+	return kvs, revision, nil
+}
+
+// Delete deletes the key
+func (c *EtcdClient) Delete(ctx context.Context, key string) error {
+	return c.do(ctx, "/v3/kv/deleterange", map[string]interface{}{"key": encode(key)}, nil)
+}
+
+// Grant creates a lease with the ttl in seconds
+func (c *EtcdClient) Grant(ctx context.Context, ttl int64) (int64, error) {
+	resp := new(leaseResponse)
+	if err := c.do(ctx, "/v3/lease/grant", map[string]interface{}{"TTL": ttl}, resp); err != nil {
+		return 0, err
+	}
+	return int64(resp.ID), nil
+}
+
+// KeepAliveOnce renews the lease, ErrLeaseNotFound is returned if the lease is expired
+func (c *EtcdClient) KeepAliveOnce(ctx context.Context, lease int64) error {
+	resp := new(struct {
+		Result leaseResponse `json:"result"`
+	})
+	req := map[string]interface{}{"ID": strconv.FormatInt(lease, 10)}
+	if err := c.do(ctx, "/v3/lease/keepalive", req, resp); err != nil {
+		return err
+	}
+	if resp.Result.TTL <= 0 {
+		return ErrLeaseNotFound
+	}
+	return nil
+}
+
+// Revoke revokes the lease, the keys attached to it are deleted
+func (c *EtcdClient) Revoke(ctx context.Context, lease int64) error {
+	return c.do(ctx, "/v3/lease/revoke", map[string]interface{}{"ID": strconv.FormatInt(lease, 10)}, nil)
+}
+
+// Watch blocks until the key or the keys with the prefix are changed after the revision, or the context is done.
+func (c *EtcdClient) Watch(ctx context.Context, key string, prefix bool, revision int64) error {
+	create := map[string]interface{}{
+		"key":            encode(key),
+		"start_revision": strconv.FormatInt(revision, 10),
+	}
+	if prefix {
+		create["range_end"] = encode(prefixEnd(key))
+	}
+	body, err := json.Marshal(map[string]interface{}{"create_request": create})
+	if err != nil {
+		return err
+	}
+
+	token, err := c.authToken(ctx, "")
+	if err != nil {
+		return err
+	}
+	// etcd cancels the watch with an invalid token as permission denied
+	err = c.watch(ctx, key, body, token)
+	if c.username == "" || !isInvalidToken(err) && !isPermissionDenied(err) {
+		return err
+	}
+	if token, err = c.authToken(ctx, token); err != nil {
+		return err
+	}
+	return c.watch(ctx, key, body, token)
+}
+
+func (c *EtcdClient) watch(ctx context.Context, key string, body []byte, token string) error {
+	var lastErr error
+	for _, ep := range c.orderedEndpoints() {
+		req, err := http.NewRequest(http.MethodPost, ep+"/v3/watch", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := c.watchClient.Do(req.WithContext(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		err = readWatchStream(resp)
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return fmt.Errorf("watch %s failed, err: %v", key, lastErr)
+}
+
+// readWatchStream reads the watch responses until a change event is received.
+func readWatchStream(resp *http.Response) error {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(resp.Body)
+		gwErr := new(gatewayError)
+		if json.Unmarshal(data, gwErr) == nil && gwErr.Message != "" {
+			return gwErr
+		}
+		return fmt.Errorf("watch failed, status: %d, body: %s", resp.StatusCode, string(data))
+	}
+
+	decoder := json.NewDecoder(bufio.NewReader(resp.Body))
+	for {
+		event := new(struct {
+			Result *struct {
+				Canceled     bool          `json:"canceled"`
+				CancelReason string        `json:"cancel_reason"`
+				Events       []interface{} `json:"events"`
+			} `json:"result"`
+			Error *gatewayError `json:"error"`
+		})
+		if err := decoder.Decode(event); err != nil {
+			return fmt.Errorf("read watch response failed, err: %v", err)
+		}
+		if event.Error != nil {
+			return event.Error
+		}
+		if event.Result == nil {
+			continue
+		}
+		if event.Result.Canceled {
+			return &watchCanceledError{reason: event.Result.CancelReason}
+		}
+		if len(event.Result.Events) > 0 {
+			return nil
+		}
+	}
+}
+
+func (c *EtcdClient) rangeKeys(ctx context.Context, key, end string) ([]KeyValue, int64, error) {
+	req := map[string]interface{}{"key": encode(key)}
+	if end != "" {
+		req["range_end"] = encode(end)
+	}
+	resp := new(struct {
+		Header struct {
+			Revision int64String `json:"revision"`
+		} `json:"header"`
+		Kvs []struct {
+			Key            string      `json:"key"`
+			Value          string      `json:"value"`
+			CreateRevision int64String `json:"create_revision"`
+			ModRevision    int64String `json:"mod_revision"`
+			Lease          int64String `json:"lease"`
+		} `json:"kvs"`
+	})
+	if err := c.do(ctx, "/v3/kv/range", req, resp); err != nil {
+		return nil, 0, err
+	}
+
+	kvs := make([]KeyValue, 0)
+	for _, kv := range resp.Kvs {
+		k, err := base64.StdEncoding.DecodeString(kv.Key)
+		if err != nil {
+			return nil, 0, fmt.Errorf("decode key %s failed, err: %v", kv.Key, err)
+		}
+		v, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("decode value of key %s failed, err: %v", string(k), err)
+		}
+		kvs = append(kvs, KeyValue{
+			Key:            string(k),
+			Value:          v,
+			CreateRevision: int64(kv.CreateRevision),
+			ModRevision:    int64(kv.ModRevision),
+			Lease:          int64(kv.Lease),
+		})
+	}
+	return kvs, int64(resp.Header.Revision), nil
+}
+
+// do posts the request with the auth token, the token is refreshed and the request is retried once if the
+// token is expired.
+func (c *EtcdClient) do(ctx context.Context, path string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	token, err := c.authToken(ctx, "")
+	if err != nil {
+		return err
+	}
+	err = c.post(ctx, path, body, token, response)
+	if c.username == "" || !isInvalidToken(err) {
+		return err
+	}
+	if token, err = c.authToken(ctx, token); err != nil {
+		return err
+	}
+	return c.post(ctx, path, body, token, response)
+}
+
+// authToken returns the auth token, a new token is requested if there is no token or the token is the expired
+// one. no token is used if the username is not set.
+func (c *EtcdClient) authToken(ctx context.Context, expired string) (string, error) {
+	if c.username == "" {
+		return "", nil
+	}
+
+	c.authLock.Lock()
+	defer c.authLock.Unlock()
+	if c.token != "" && c.token != expired {
+		return c.token, nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"name": c.username, "password": c.password})
+	if err != nil {
+		return "", err
+	}
+	resp := new(struct {
+		Token string `json:"token"`
+	})
+	if err := c.post(ctx, "/v3/auth/authenticate", body, "", resp); err != nil {
+		return "", fmt.Errorf("authenticate etcd user %s failed, err: %v", c.username, err)
+	}
+	if resp.Token == "" {
+		return "", fmt.Errorf("authenticate etcd user %s failed, no token is returned, the auth may be disabled",
+			c.username)
+	}
+	c.token = resp.Token
+	return c.token, nil
+}
+
+// isInvalidToken checks whether the error is caused by the expired auth token or the changed auth store,
+// a new token should be requested for them.
+func isInvalidToken(err error) bool {
+	gwErr, ok := err.(*gatewayError)
+	if !ok {
+		return false
+	}
+	return strings.Contains(gwErr.Message, "invalid auth token") ||
+		strings.Contains(gwErr.Message, "revision of auth store is old")
+}
+
+func isPermissionDenied(err error) bool {
+	canceledErr, ok := err.(*watchCanceledError)
+	return ok && strings.Contains(canceledErr.reason, "permission denied")
+}
+
+// post posts the request to the endpoints one by one until one of them responds.
+func (c *EtcdClient) post(ctx context.Context, path string, body []byte, token string, response interface{}) error {
+	var lastErr error
+	for idx, ep := range c.orderedEndpoints() {
+		req, err := http.NewRequest(http.MethodPost, ep+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := c.client.Do(req.WithContext(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		c.moveNext(idx)
+
+		if resp.StatusCode != http.StatusOK {
+			gwErr := new(gatewayError)
+			if json.Unmarshal(data, gwErr) == nil && gwErr.Message != "" {
+				if strings.Contains(gwErr.Message, "lease not found") {
+					return ErrLeaseNotFound
+				}
+				return gwErr
+			}
+			return fmt.Errorf("request %s failed, status: %d, body: %s", path, resp.StatusCode, string(data))
+		}
+		if response == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, response); err != nil {
+			return fmt.Errorf("unmarshal %s response failed, err: %v, body: %s", path, err, string(data))
+		}
+		return nil
+	}
+
+	if lastErr == nil {
+		return errors.New("no etcd endpoint is available")
+	}
+	return fmt.Errorf("request %s failed, err: %v", path, lastErr)
+}
+
+// orderedEndpoints returns the endpoints starting from the last available one.
+func (c *EtcdClient) orderedEndpoints() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	eps := make([]string, 0, len(c.endpoints))
+	for idx := range c.endpoints {
+		eps = append(eps, c.endpoints[(c.next+idx)%len(c.endpoints)])
+	}
+	return eps
+}
+
+// moveNext records the available endpoint so that it's tried first next time.
+func (c *EtcdClient) moveNext(offset int) {
+	if offset == 0 {
+		return
+	}
+	c.lock.Lock()
+	c.next = (c.next + offset) % len(c.endpoints)
+	c.lock.Unlock()
+}
+
+type leaseResponse struct {
+	ID  int64String `json:"ID"`
+	TTL int64String `json:"TTL"`
+}
+
+// gatewayError the error returned by the grpc gateway
+type gatewayError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *gatewayError) Error() string {
+	return fmt.Sprintf("etcd error, code: %d, message: %s", e.Code, e.Message)
+}
+
+// watchCanceledError the watch is canceled by etcd
+type watchCanceledError struct {
+	reason string
+}
+
+func (e *watchCanceledError) Error() string {
+	return fmt.Sprintf("watch canceled, reason: %s", e.reason)
+}
+
+// int64String the int64 encoded as a string by the grpc gateway, a number is accepted too.
+type int64String int64
+
+func (i *int64String) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*i = 0
+		return nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = int64String(v)
+	return nil
+}
+
+func encode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// prefixEnd returns the range end of the keys with the prefix
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	// the prefix is all 0xff, means all the keys after it
+	return "\x00"
+}
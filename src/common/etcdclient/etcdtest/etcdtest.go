@@ -0,0 +1,48 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package etcdtest provides the helpers for the tests which run against the etcd set by the ETCD_TEST_ENDPOINTS
+// env. The env is in the format of etcdclient.ParseConfig, such as
+// root:passwd@127.0.0.1:2379?cacert=/data/ca.pem&cert=/data/client.pem&key=/data/client-key.pem
+// the tests are skipped if the env is not set.
+package etcdtest
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"configcenter/src/common/etcdclient"
+)
+
+// EndpointsEnv the env of the etcd address used by the tests
+const EndpointsEnv = "ETCD_TEST_ENDPOINTS"
+
+// Config returns the config of the etcd set by the ETCD_TEST_ENDPOINTS env, the test is skipped if the env is not set
+func Config(t testing.TB) *etcdclient.Config {
+	addr := os.Getenv(EndpointsEnv)
+	if addr == "" {
+		t.Skipf("%s is not set, skip the test against etcd", EndpointsEnv)
+	}
+	conf, err := etcdclient.ParseConfig(addr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("parse %s failed, err: %v", EndpointsEnv, err)
+	}
+	return conf
+}
+
+// Prefix returns a key prefix which is unique in each test run, so that the tests sharing the etcd don't
+// see the keys of each other
+func Prefix(name string) string {
+	return "/cc_test/" + name + "/" + strconv.FormatInt(time.Now().UnixNano(), 10)
+}
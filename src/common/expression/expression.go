@@ -0,0 +1,366 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package expression implements a small arithmetic expression language which is used by
+// the computed attributes. an expression is made of numbers, field names, the operators
+// + - * / % and parentheses, such as: "(bk_mem - bk_mem_used) / bk_mem * 100".
+package expression
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNoValue means that a field referenced by the expression has no value.
+var ErrNoValue = errors.New("field has no value")
+
+// Expression is a parsed arithmetic expression.
+type Expression struct {
+	raw    string
+	root   node
+	fields []string
+}
+
+// Parse parse the expression string into an Expression.
+func Parse(expr string) (*Expression, error) {
+	p := &parser{input: expr}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == tokenEOF {
+		return nil, errors.New("expression is empty")
+	}
+
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tok.text, p.tok.pos)
+	}
+
+	return &Expression{raw: expr, root: root, fields: p.fields}, nil
+}
+
+// String returns the raw expression.
+func (e *Expression) String() string {
+	return e.raw
+}
+
+// Fields returns the field names referenced by the expression, without duplicates.
+func (e *Expression) Fields() []string {
+	return e.fields
+}
+
+// Eval evaluate the expression with the field values.
+// if a referenced field is not exist or is nil, ErrNoValue is returned.
+func (e *Expression) Eval(values map[string]interface{}) (float64, error) {
+	return e.root.eval(values)
+}
+
+type node interface {
+	eval(values map[string]interface{}) (float64, error)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(map[string]interface{}) (float64, error) {
+	return float64(n), nil
+}
+
+type fieldNode string
+
+func (n fieldNode) eval(values map[string]interface{}) (float64, error) {
+	val, exist := values[string(n)]
+	if !exist || val == nil {
+		return 0, ErrNoValue
+	}
+
+	f, err := ToFloat(val)
+	if err != nil {
+		return 0, fmt.Errorf("field %s is not a number, %v", string(n), err)
+	}
+	return f, nil
+}
+
+type negNode struct {
+	operand node
+}
+
+func (n negNode) eval(values map[string]interface{}) (float64, error) {
+	v, err := n.operand.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	return -v, nil
+}
+
+type binaryNode struct {
+	op          byte
+	left, right node
+}
+
+func (n binaryNode) eval(values map[string]interface{}) (float64, error) {
+	l, err := n.left.eval(values)
+	if err != nil {
+		return 0, err
+	}
+
+	r, err := n.right.eval(values)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/':
+		if r == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return l / r, nil
+	case '%':
+		if int64(r) == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return float64(int64(l) % int64(r)), nil
+	default:
+		return 0, fmt.Errorf("unsupported operator %q", n.op)
+	}
+}
+
+// ToFloat convert a field value to float64.
+func ToFloat(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	default:
+		return 0, fmt.Errorf("unsupported value type %T", val)
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type parser struct {
+	input  string
+	pos    int
+	tok    token
+	fields []string
+}
+
+// next scan the next token from the input.
+func (p *parser) next() error {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+
+	if p.pos >= len(p.input) {
+		p.tok = token{kind: tokenEOF, pos: p.pos}
+		return nil
+	}
+
+	start := p.pos
+	c := p.input[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		p.tok = token{kind: tokenLParen, text: "(", pos: start}
+	case c == ')':
+		p.pos++
+		p.tok = token{kind: tokenRParen, text: ")", pos: start}
+	case strings.IndexByte("+-*/%", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenOperator, text: string(c), pos: start}
+	case isDigit(c) || c == '.':
+		for p.pos < len(p.input) && (isDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		p.tok = token{kind: tokenNumber, text: p.input[start:p.pos], pos: start}
+	case isLetter(c):
+		for p.pos < len(p.input) && (isLetter(p.input[p.pos]) || isDigit(p.input[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenIdent, text: p.input[start:p.pos], pos: start}
+	default:
+		return fmt.Errorf("invalid character %q at position %d", c, start)
+	}
+	return nil
+}
+
+// parseExpr expr := term (('+' | '-') term)*
+func (p *parser) parseExpr() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.tok.kind == tokenOperator && (p.tok.text == "+" || p.tok.text == "-") {
+		op := p.tok.text[0]
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseTerm term := unary (('*' | '/' | '%') unary)*
+func (p *parser) parseTerm() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.tok.kind == tokenOperator && strings.Contains("*/%", p.tok.text) {
+		op := p.tok.text[0]
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseUnary unary := '-' unary | primary
+func (p *parser) parseUnary() (node, error) {
+	if p.tok.kind == tokenOperator && p.tok.text == "-" {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary primary := number | field | '(' expr ')'
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenNumber:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return numberNode(f), nil
+
+	case tokenIdent:
+		p.addField(tok.text)
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return fieldNode(tok.text), nil
+
+	case tokenLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenRParen {
+			return nil, fmt.Errorf("missing ')' at position %d", p.tok.pos)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return inner, nil
+
+	case tokenEOF:
+		return nil, errors.New("unexpected end of expression")
+
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+}
+
+func (p *parser) addField(field string) {
+	for _, f := range p.fields {
+		if f == field {
+			return
+		}
+	}
+	p.fields = append(p.fields, field)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEval(t *testing.T) {
+	values := map[string]interface{}{
+		"bk_cpu":     4,
+		"bk_cpu_mhz": int64(2000),
+		"bk_mem":     "16",
+		"used":       12.5,
+	}
+
+	tests := []struct {
+		expr   string
+		fields []string
+		want   float64
+	}{
+		{"1 + 2 * 3", nil, 7},
+		{"(1 + 2) * 3", nil, 9},
+		{"-bk_cpu + 10", []string{"bk_cpu"}, 6},
+		{"bk_cpu * bk_cpu_mhz", []string{"bk_cpu", "bk_cpu_mhz"}, 8000},
+		{"(bk_mem - used) / bk_mem * 100", []string{"bk_mem", "used"}, 21.875},
+		{"bk_cpu_mhz % 3", []string{"bk_cpu_mhz"}, 2},
+	}
+
+	for _, tt := range tests {
+		exp, err := Parse(tt.expr)
+		require.NoError(t, err, tt.expr)
+		require.Equal(t, tt.fields, exp.Fields(), tt.expr)
+		got, err := exp.Eval(values)
+		require.NoError(t, err, tt.expr)
+		require.Equal(t, tt.want, got, tt.expr)
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	exp, err := Parse("bk_cpu / bk_disk")
+	require.NoError(t, err)
+
+	_, err = exp.Eval(map[string]interface{}{"bk_cpu": 1})
+	require.Equal(t, ErrNoValue, err)
+
+	_, err = exp.Eval(map[string]interface{}{"bk_cpu": 1, "bk_disk": 0})
+	require.Error(t, err)
+
+	_, err = exp.Eval(map[string]interface{}{"bk_cpu": 1, "bk_disk": []string{"a"}})
+	require.Error(t, err)
+}
+
+func TestParseError(t *testing.T) {
+	for _, expr := range []string{"", "1 +", "(1 + 2", "1 2", "bk_cpu $ 2", "*3"} {
+		_, err := Parse(expr)
+		require.Error(t, err, expr)
+	}
+}
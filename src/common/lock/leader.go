@@ -0,0 +1,76 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/storage/dal/redis"
+
+	"github.com/rs/xid"
+)
+
+const (
+	// renewLeaderScript key的值为当前实例的id时续期
+	renewLeaderScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`
+	// resignLeaderScript key的值为当前实例的id时删除
+	resignLeaderScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+)
+
+// Leader redis leader election, the instances campaign for the same key, the one holding the key is the leader
+type Leader interface {
+	// Campaign 竞选主节点，已经是主节点时续期，返回当前实例是否为主节点，需要在expire内再次调用以保持主节点
+	Campaign(key StrFormat, expire time.Duration) (isLeader bool, err error)
+	// Resign 主节点主动放弃，其他实例可以立即竞选
+	Resign(key StrFormat) error
+}
+
+// NewLeader 生成主节点选举，每个实例使用不同的id竞选
+func NewLeader(cache redis.Client) Leader {
+	return &leader{
+		cache: cache,
+		id:    xid.New().String(),
+	}
+}
+
+type leader struct {
+	cache redis.Client
+	id    string
+}
+
+// Campaign campaign for the leader or renew the leadership, key from GetLockKey function
+func (l *leader) Campaign(key StrFormat, expire time.Duration) (bool, error) {
+	redisKey := fmt.Sprintf("%s%s", common.BKCacheKeyV3Prefix, key)
+	isLeader, err := l.cache.SetNX(context.Background(), redisKey, l.id, expire).Result()
+	if err != nil || isLeader {
+		return isLeader, err
+	}
+
+	// 使用lua脚本判断并续期，避免key过期后被其他实例获得时续期了其他实例的key
+	result, err := l.cache.Eval(context.Background(), renewLeaderScript, []string{redisKey}, l.id,
+		int64(expire/time.Millisecond)).Result()
+	if err != nil {
+		return false, err
+	}
+	renewed, _ := result.(int64)
+	return renewed == 1, nil
+}
+
+// Resign resign the leadership if the instance is the leader
+func (l *leader) Resign(key StrFormat) error {
+	redisKey := fmt.Sprintf("%s%s", common.BKCacheKeyV3Prefix, key)
+	return l.cache.Eval(context.Background(), resignLeaderScript, []string{redisKey}, l.id).Err()
+}
@@ -26,6 +26,9 @@ const (
 
 	// CheckSetTemplateSyncFormat  检测集群模板同步的状态
 	CheckSetTemplateSyncFormat = "topo:settemplate:sync:status:check:%d"
+
+	// JobScheduleLeaderFormat 任务服务中负责触发定时任务的主节点
+	JobScheduleLeaderFormat = "task:job:schedule:leader"
 )
 
 // StrFormat  build  lock key format
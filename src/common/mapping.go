@@ -55,6 +55,14 @@ func GetInstIDField(objType string) string {
 		return BKCloudIDField
 	case BKTableNameInstAsst:
 		return BKFieldID
+	case BKTableNameBaseApp:
+		return BKAppIDField
+	case BKTableNameBaseSet:
+		return BKSetIDField
+	case BKTableNameBaseModule:
+		return BKModuleIDField
+	case BKTableNameBaseHost:
+		return BKHostIDField
 	case BKTableNameServiceInstance:
 		return BKFieldID
 	case BKTableNameServiceTemplate:
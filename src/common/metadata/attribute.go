@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/expression"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/util"
 
@@ -47,6 +48,7 @@ const (
 	AttributeFieldIsOnly          = "isonly"
 	AttributeFieldIsSystem        = "bk_issystem"
 	AttributeFieldIsAPI           = "bk_isapi"
+	AttributeFieldIsSensitive     = "bk_issensitive"
 	AttributeFieldPropertyType    = "bk_property_type"
 	AttributeFieldOption          = "option"
 	AttributeFieldDescription     = "description"
@@ -75,6 +77,7 @@ type Attribute struct {
 	IsOnly            bool        `field:"isonly" json:"isonly" bson:"isonly"`
 	IsSystem          bool        `field:"bk_issystem" json:"bk_issystem" bson:"bk_issystem"`
 	IsAPI             bool        `field:"bk_isapi" json:"bk_isapi" bson:"bk_isapi"`
+	IsSensitive       bool        `field:"bk_issensitive" json:"bk_issensitive" bson:"bk_issensitive"`
 	PropertyType      string      `field:"bk_property_type" json:"bk_property_type" bson:"bk_property_type"`
 	Option            interface{} `field:"option" json:"option" bson:"option"`
 	Description       string      `field:"description" json:"description" bson:"description"`
@@ -149,6 +152,16 @@ func (attribute *Attribute) Validate(ctx context.Context, data interface{}, key
 		rawError = attribute.validList(ctx, data, key)
 	case common.FieldTypeOrganization:
 		rawError = attribute.validOrganization(ctx, data, key)
+	case common.FieldTypeIP:
+		rawError = attribute.validIP(ctx, data, key)
+	case common.FieldTypeURL:
+		rawError = attribute.validURL(ctx, data, key)
+	case common.FieldTypeEnumMulti:
+		rawError = attribute.validEnumMulti(ctx, data, key)
+	case common.FieldTypeInstRef:
+		rawError = attribute.validInstRef(ctx, data, key)
+	case common.FieldTypeComputed:
+		// the value of computed attribute is maintained by the system, writers can not set it.
 	case "foreignkey", "singleasst", "multiasst":
 		// TODO what validation should do on these types
 	case common.FieldTypeTable:
@@ -723,6 +736,178 @@ func (attribute *Attribute) validOrganization(ctx context.Context, val interface
 	return errors.RawErrorInfo{}
 }
 
+// validIP valid object attribute that is ip type
+func (attribute *Attribute) validIP(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if nil == val || "" == val {
+		if attribute.IsRequired {
+			blog.Errorf("params can not be null, rid: %s", rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{key},
+			}
+		}
+		return errors.RawErrorInfo{}
+	}
+
+	value, ok := val.(string)
+	if !ok {
+		blog.Errorf("params should be string, rid: %s", rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedString,
+			Args:    []interface{}{key},
+		}
+	}
+
+	ipOption, _ := attribute.Option.(string)
+	if !IsIPOptionMatched(ipOption, strings.TrimSpace(value)) {
+		blog.Errorf("params %s:%s is not a valid %s address, rid: %s", key, value, ipOption, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedIP,
+			Args:    []interface{}{key},
+		}
+	}
+	return errors.RawErrorInfo{}
+}
+
+// validURL valid object attribute that is url type
+func (attribute *Attribute) validURL(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if nil == val || "" == val {
+		if attribute.IsRequired {
+			blog.Errorf("params can not be null, rid: %s", rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{key},
+			}
+		}
+		return errors.RawErrorInfo{}
+	}
+
+	value, ok := val.(string)
+	if !ok {
+		blog.Errorf("params should be string, rid: %s", rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedString,
+			Args:    []interface{}{key},
+		}
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) > common.FieldTypeLongLenChar {
+		blog.Errorf("params over length %d, rid: %s", common.FieldTypeLongLenChar, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommOverLimit,
+			Args:    []interface{}{key},
+		}
+	}
+
+	if !util.IsURL(value) {
+		blog.Errorf("params %s:%s is not a valid url, rid: %s", key, value, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedURL,
+			Args:    []interface{}{key},
+		}
+	}
+	return errors.RawErrorInfo{}
+}
+
+// validEnumMulti valid object attribute that is multi-select enum type
+func (attribute *Attribute) validEnumMulti(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if nil == val {
+		if attribute.IsRequired {
+			blog.Errorf("params can not be null, rid: %s", rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{key},
+			}
+		}
+		return errors.RawErrorInfo{}
+	}
+
+	values, err := ParseEnumMultiValue(val)
+	if err != nil {
+		blog.Errorf("params %s:%#v is not a valid enum array, err: %v, rid: %s", key, val, err, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
+	}
+
+	if len(values) == 0 {
+		if attribute.IsRequired {
+			blog.Errorf("params can not be empty, rid: %s", rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{key},
+			}
+		}
+		return errors.RawErrorInfo{}
+	}
+
+	enumOption, err := ParseEnumOption(ctx, attribute.Option)
+	if err != nil {
+		blog.Warnf("ParseEnumOption failed: %v, rid: %s", err, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
+	}
+
+	optionIDs := make(map[string]bool)
+	for _, k := range enumOption {
+		optionIDs[k.ID] = true
+	}
+
+	existIDs := make(map[string]bool)
+	for _, value := range values {
+		if !optionIDs[value] || existIDs[value] {
+			blog.Errorf("params %s not valid, enum value: %#v, option: %#v, rid: %s", key, val, enumOption, rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsInvalid,
+				Args:    []interface{}{key},
+			}
+		}
+		existIDs[value] = true
+	}
+	return errors.RawErrorInfo{}
+}
+
+// validInstRef valid object attribute that is instance reference type, only the value
+// format is checked here, the existence of the referenced instance is checked by coreservice.
+func (attribute *Attribute) validInstRef(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if nil == val {
+		if attribute.IsRequired {
+			blog.Errorf("params can not be null, rid: %s", rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{key},
+			}
+		}
+		return errors.RawErrorInfo{}
+	}
+
+	if !util.IsNumeric(val) {
+		blog.Errorf("params %s:%#v not int, rid: %s", key, val, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedInt,
+			Args:    []interface{}{key},
+		}
+	}
+
+	instID, err := util.GetInt64ByInterface(val)
+	if err != nil || instID <= 0 {
+		blog.Errorf("params %s:%#v not valid instance id, rid: %s", key, val, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
+	}
+	return errors.RawErrorInfo{}
+}
+
 // validTable valid object attribute that is bool type
 func (attribute *Attribute) validTable(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
 	// rid := util.ExtractRequestIDFromContext(ctx)
@@ -787,6 +972,204 @@ func ParseIntOption(ctx context.Context, val interface{}) IntOption {
 // EnumOption enum option
 type EnumOption []EnumVal
 
+const (
+	// IPOptionIPv4 ip attribute only accepts ipv4 address
+	IPOptionIPv4 = "ipv4"
+	// IPOptionIPv6 ip attribute only accepts ipv6 address
+	IPOptionIPv6 = "ipv6"
+	// IPOptionCIDR ip attribute only accepts cidr, such as 10.0.0.0/8
+	IPOptionCIDR = "cidr"
+)
+
+// IsIPOptionMatched check if the value matches the ip attribute option, an empty
+// option accepts any ipv4, ipv6 address or cidr.
+func IsIPOptionMatched(option string, value string) bool {
+	switch option {
+	case IPOptionIPv4:
+		return util.IsIPv4(value)
+	case IPOptionIPv6:
+		return util.IsIPv6(value)
+	case IPOptionCIDR:
+		return util.IsCIDR(value)
+	case "":
+		return util.IsIPv4(value) || util.IsIPv6(value) || util.IsCIDR(value)
+	default:
+		return false
+	}
+}
+
+// InstRefOption the option of instance reference attribute
+type InstRefOption struct {
+	// ObjectID the model which the referenced instance belongs to
+	ObjectID string `bson:"bk_obj_id" json:"bk_obj_id"`
+}
+
+// ParseInstRefOption parse the option of instance reference attribute
+func ParseInstRefOption(ctx context.Context, val interface{}) (InstRefOption, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	refOption := InstRefOption{}
+	switch option := val.(type) {
+	case InstRefOption:
+		refOption = option
+	case string:
+		if err := json.Unmarshal([]byte(option), &refOption); err != nil {
+			blog.Errorf("ParseInstRefOption error : %s, rid: %s", err.Error(), rid)
+			return refOption, err
+		}
+	case map[string]interface{}:
+		refOption.ObjectID = getString(option[common.BKObjIDField])
+	case mapstr.MapStr:
+		refOption.ObjectID = getString(option[common.BKObjIDField])
+	case bson.M:
+		refOption.ObjectID = getString(option[common.BKObjIDField])
+	case bson.D:
+		refOption.ObjectID = getString(option.Map()[common.BKObjIDField])
+	default:
+		return refOption, fmt.Errorf("unknow val type: %#v", val)
+	}
+
+	if refOption.ObjectID == "" {
+		return refOption, fmt.Errorf("instance reference option %#v has no %s", val, common.BKObjIDField)
+	}
+	return refOption, nil
+}
+
+const (
+	// ComputeTypeExpression the attribute value is computed from an arithmetic expression
+	// over the other fields of the same instance.
+	ComputeTypeExpression = "expression"
+	// ComputeTypeAggregation the attribute value is aggregated from a field of the associated instances.
+	ComputeTypeAggregation = "aggregation"
+)
+
+const (
+	AggregateSum   = "sum"
+	AggregateCount = "count"
+	AggregateAvg   = "avg"
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+	// AggregateFirst take the field value of the first associated instance,
+	// it is used to inherit a field from the associated instance, such as host's env from it's set.
+	AggregateFirst = "first"
+)
+
+// ComputedOption the option of computed attribute
+type ComputedOption struct {
+	// Type the compute type, expression or aggregation
+	Type string `bson:"type" json:"type"`
+	// ValueType the type of the computed value, can be int, float or singlechar.
+	// singlechar is only allowed when the aggregate function is first.
+	ValueType string `bson:"value_type" json:"value_type"`
+	// Expression the arithmetic expression, used when type is expression, such as: "bk_cpu * bk_cpu_mhz"
+	Expression string `bson:"expression,omitempty" json:"expression,omitempty"`
+	// AsstObjID the associated model, used when type is aggregation.
+	// host's mainline models (biz, set and module) are associated by the host's topology.
+	AsstObjID string `bson:"bk_asst_obj_id,omitempty" json:"bk_asst_obj_id,omitempty"`
+	// Field the field of the associated instances to aggregate, not needed by count.
+	Field string `bson:"field,omitempty" json:"field,omitempty"`
+	// Function the aggregate function, sum, count, avg, min, max or first.
+	Function string `bson:"function,omitempty" json:"function,omitempty"`
+}
+
+// Validate validate the computed option is valid or not.
+func (c ComputedOption) Validate() error {
+	switch c.ValueType {
+	case common.FieldTypeInt, common.FieldTypeFloat:
+	case common.FieldTypeSingleChar:
+		if c.Type != ComputeTypeAggregation || c.Function != AggregateFirst {
+			return fmt.Errorf("value type %s is only supported by %s function", c.ValueType, AggregateFirst)
+		}
+	default:
+		return fmt.Errorf("unsupported computed value type %s", c.ValueType)
+	}
+
+	switch c.Type {
+	case ComputeTypeExpression:
+		if _, err := expression.Parse(c.Expression); err != nil {
+			return fmt.Errorf("invalid expression %s, err: %v", c.Expression, err)
+		}
+	case ComputeTypeAggregation:
+		if c.AsstObjID == "" {
+			return fmt.Errorf("computed option has no %s", common.BKAsstObjIDField)
+		}
+		switch c.Function {
+		case AggregateCount:
+		case AggregateSum, AggregateAvg, AggregateMin, AggregateMax, AggregateFirst:
+			if c.Field == "" {
+				return fmt.Errorf("aggregate function %s need field", c.Function)
+			}
+		default:
+			return fmt.Errorf("unsupported aggregate function %s", c.Function)
+		}
+	default:
+		return fmt.Errorf("unsupported compute type %s", c.Type)
+	}
+	return nil
+}
+
+// ParseComputedOption parse and validate the option of computed attribute
+func ParseComputedOption(ctx context.Context, val interface{}) (ComputedOption, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	computedOption := ComputedOption{}
+	var raw []byte
+	var err error
+	switch option := val.(type) {
+	case ComputedOption:
+		computedOption = option
+	case string:
+		raw = []byte(option)
+	case bson.D:
+		raw, err = json.Marshal(option.Map())
+	case map[string]interface{}, mapstr.MapStr, bson.M:
+		raw, err = json.Marshal(option)
+	default:
+		return computedOption, fmt.Errorf("unknow val type: %#v", val)
+	}
+
+	if err != nil {
+		blog.Errorf("ParseComputedOption error : %s, rid: %s", err.Error(), rid)
+		return computedOption, err
+	}
+
+	if raw != nil {
+		if err := json.Unmarshal(raw, &computedOption); err != nil {
+			blog.Errorf("ParseComputedOption error : %s, rid: %s", err.Error(), rid)
+			return computedOption, err
+		}
+	}
+
+	if err := computedOption.Validate(); err != nil {
+		return computedOption, err
+	}
+	return computedOption, nil
+}
+
+// ParseEnumMultiValue convert the value of multi-select enum attribute to enum id array
+func ParseEnumMultiValue(val interface{}) ([]string, error) {
+	switch values := val.(type) {
+	case []string:
+		return values, nil
+	case []interface{}:
+		return parseEnumMultiValue(values)
+	case bson.A:
+		return parseEnumMultiValue(values)
+	default:
+		return nil, fmt.Errorf("unknow val type: %#v", val)
+	}
+}
+
+func parseEnumMultiValue(values []interface{}) ([]string, error) {
+	ids := make([]string, 0)
+	for _, value := range values {
+		id, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("enum id %#v is not string", value)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
 // IntOption integer option
 type IntOption struct {
 	Min string `bson:"min" json:"min"`
@@ -1031,6 +1414,44 @@ func (attribute Attribute) PrettyValue(ctx context.Context, val interface{}) (st
 			}
 		}
 		return "", fmt.Errorf("invalid value for list, value: %s, options: %+v", strVal, listOption)
+	case common.FieldTypeIP, common.FieldTypeURL:
+		value, ok := val.(string)
+		if !ok {
+			return "", fmt.Errorf("invalid value type for %s, value: %+v", fieldType, val)
+		}
+		return value, nil
+	case common.FieldTypeEnumMulti:
+		values, err := ParseEnumMultiValue(val)
+		if err != nil {
+			return "", fmt.Errorf("invalid value type for %s, value: %+v, err: %+v", fieldType, val, err)
+		}
+		enumOption, err := ParseEnumOption(ctx, attribute.Option)
+		if err != nil {
+			return "", fmt.Errorf("parse options for enum type failed, err: %+v", err)
+		}
+		names := make([]string, 0)
+		for _, value := range values {
+			name := ""
+			for _, k := range enumOption {
+				if k.ID == value {
+					name = k.Name
+					break
+				}
+			}
+			if name == "" {
+				return "", fmt.Errorf("invalid value for %s, value: %s", fieldType, value)
+			}
+			names = append(names, name)
+		}
+		return strings.Join(names, ","), nil
+	case common.FieldTypeInstRef:
+		value, err := util.GetInt64ByInterface(val)
+		if nil != err {
+			return "", fmt.Errorf("invalid value type for %s, value: %+v, err: %+v", fieldType, val, err)
+		}
+		return strconv.FormatInt(value, 10), nil
+	case common.FieldTypeComputed:
+		return fmt.Sprintf("%v", val), nil
 	default:
 		blog.V(3).Infof("unexpected property type: %s", fieldType)
 		return fmt.Sprintf("%#v", val), nil
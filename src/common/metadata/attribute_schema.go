@@ -0,0 +1,148 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+// AttributeSchemaChange describes a schema change of a custom model's attribute,
+// the property id, the property type and the option can be changed at the same time.
+type AttributeSchemaChange struct {
+	PropertyID string `json:"bk_property_id" bson:"bk_property_id"`
+	// NewPropertyID rename the attribute to this property id, optional.
+	NewPropertyID string `json:"new_bk_property_id,omitempty" bson:"new_bk_property_id,omitempty"`
+	// NewPropertyType change the attribute to this property type, optional.
+	NewPropertyType string `json:"new_bk_property_type,omitempty" bson:"new_bk_property_type,omitempty"`
+	// NewOption the option of the attribute after changed, required when the new property type need option.
+	NewOption interface{} `json:"new_option,omitempty" bson:"new_option,omitempty"`
+	// EnumMapping remap the old enum id to the new enum id, used by enum and multi-select enum attribute.
+	EnumMapping map[string]string `json:"enum_mapping,omitempty" bson:"enum_mapping,omitempty"`
+	// Force clear the values which can not be converted instead of rejecting the change.
+	Force bool `json:"force" bson:"force"`
+}
+
+// AttributeValueConversion is the conversion of an instance's attribute value.
+type AttributeValueConversion struct {
+	InstID int64       `json:"bk_inst_id"`
+	From   interface{} `json:"from"`
+	To     interface{} `json:"to"`
+	// Reason why the value can not be converted, empty if the value is converted.
+	Reason string `json:"reason,omitempty"`
+}
+
+// AttributeSchemaChangePlan is the plan of an attribute schema change.
+type AttributeSchemaChangePlan struct {
+	Origin Attribute `json:"origin"`
+	Target Attribute `json:"target"`
+	// InstanceCount the count of the model's instances.
+	InstanceCount int64 `json:"instance_count"`
+	// ChangedCount the count of the instances whose value will be changed.
+	ChangedCount int64 `json:"changed_count"`
+	// UnconvertibleCount the count of the instances whose value can not be converted.
+	UnconvertibleCount int64 `json:"unconvertible_count"`
+	// Samples some of the value conversions.
+	Samples []AttributeValueConversion `json:"samples"`
+	// Unconvertible some of the values which can not be converted.
+	Unconvertible []AttributeValueConversion `json:"unconvertible"`
+}
+
+// AttributeSchemaMigrationStatus the status of an attribute schema migration
+type AttributeSchemaMigrationStatus string
+
+const (
+	AttributeSchemaMigrating   AttributeSchemaMigrationStatus = "migrating"
+	AttributeSchemaMigrated    AttributeSchemaMigrationStatus = "migrated"
+	AttributeSchemaRollingBack AttributeSchemaMigrationStatus = "rolling_back"
+	AttributeSchemaRolledBack  AttributeSchemaMigrationStatus = "rolled_back"
+)
+
+// AttributeSchemaMigration is the record of an attribute schema change, which is used to
+// execute the change in batches and to roll back the change.
+type AttributeSchemaMigration struct {
+	MigrationID string                         `json:"migration_id" bson:"migration_id"`
+	ObjectID    string                         `json:"bk_obj_id" bson:"bk_obj_id"`
+	OwnerID     string                         `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Change      AttributeSchemaChange          `json:"change" bson:"change"`
+	Origin      Attribute                      `json:"origin" bson:"origin"`
+	Target      Attribute                      `json:"target" bson:"target"`
+	Status      AttributeSchemaMigrationStatus `json:"status" bson:"status"`
+	Creator     string                         `json:"creator" bson:"creator"`
+	CreateTime  time.Time                      `json:"create_time" bson:"create_time"`
+	LastTime    time.Time                      `json:"last_time" bson:"last_time"`
+	// FinishTime the time when the attribute's definition is changed, it's empty if the migration is not finished.
+	FinishTime *time.Time `json:"finish_time,omitempty" bson:"finish_time,omitempty"`
+}
+
+// AttributeSchemaBackup is the original attribute value of an instance before the schema change.
+type AttributeSchemaBackup struct {
+	MigrationID string      `json:"migration_id" bson:"migration_id"`
+	InstID      int64       `json:"bk_inst_id" bson:"bk_inst_id"`
+	Value       interface{} `json:"value" bson:"value"`
+}
+
+const (
+	// AttributeSchemaStepConvert convert the values of a batch of instances.
+	AttributeSchemaStepConvert = "convert"
+	// AttributeSchemaStepFinish change the attribute's definition and finish the migration.
+	AttributeSchemaStepFinish = "finish"
+	// AttributeSchemaStepRestore restore the values of a batch of instances from the backup.
+	AttributeSchemaStepRestore = "restore"
+	// AttributeSchemaStepRevert revert the attribute's definition and finish the roll back.
+	AttributeSchemaStepRevert = "revert"
+)
+
+// AttributeSchemaMigrationStep is a step of the attribute schema migration, which is executed by task server.
+type AttributeSchemaMigrationStep struct {
+	MigrationID string  `json:"migration_id"`
+	ObjectID    string  `json:"bk_obj_id"`
+	Step        string  `json:"step"`
+	InstIDs     []int64 `json:"bk_inst_ids,omitempty"`
+}
+
+// AttributeSchemaMigrationTask is the migration and the steps to execute it.
+type AttributeSchemaMigrationTask struct {
+	Migration AttributeSchemaMigration       `json:"migration"`
+	Steps     []AttributeSchemaMigrationStep `json:"steps"`
+}
+
+// AttributeSchemaMigrationDetail is the migration with it's execute progress.
+type AttributeSchemaMigrationDetail struct {
+	AttributeSchemaMigration `json:",inline"`
+	TaskID                   string        `json:"task_id"`
+	TaskStatus               APITaskStatus `json:"task_status"`
+	// Progress the percentage of the finished steps of the latest task.
+	Progress int64 `json:"progress"`
+}
+
+// SearchAttributeSchemaMigrationOption search attribute schema migrations option
+type SearchAttributeSchemaMigrationOption struct {
+	ObjectID    string `json:"bk_obj_id"`
+	PropertyID  string `json:"bk_property_id"`
+	MigrationID string `json:"migration_id"`
+}
+
+type AttributeSchemaChangePlanResult struct {
+	BaseResp `json:",inline"`
+	Data     AttributeSchemaChangePlan `json:"data"`
+}
+
+type AttributeSchemaMigrationTaskResult struct {
+	BaseResp `json:",inline"`
+	Data     AttributeSchemaMigrationTask `json:"data"`
+}
+
+type AttributeSchemaMigrationsResult struct {
+	BaseResp `json:",inline"`
+	Data     []AttributeSchemaMigration `json:"data"`
+}
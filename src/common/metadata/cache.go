@@ -48,3 +48,12 @@ type ListHostWithPage struct {
 	// max page limit is 1000
 	Page BasePage `json:"page"`
 }
+
+// ListModelCacheOption list models and their attributes, uniques and associations in cache.
+type ListModelCacheOption struct {
+	// object ids of the models, all the models will be returned if it's empty.
+	// length range is [0,500]
+	ObjectIDs []string `json:"bk_obj_ids"`
+	// only return these fields in models.
+	Fields []string `json:"fields"`
+}
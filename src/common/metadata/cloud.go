@@ -33,6 +33,9 @@ type CloudAccount struct {
 	LastEditor  string    `json:"bk_last_editor" bson:"bk_last_editor"`
 	CreateTime  time.Time `json:"create_time" bson:"create_time"`
 	LastTime    time.Time `json:"last_time" bson:"last_time"`
+
+	// 云主机标签映射规则，同步云主机时按顺序匹配
+	TagRules []CloudTagRule `json:"bk_tag_rules,omitempty" bson:"bk_tag_rules,omitempty"`
 }
 
 func (c *CloudAccount) ToMapStr() mapstr.MapStr {
@@ -74,7 +77,7 @@ func (c *CloudAccount) Validate() (rawError errors.RawErrorInfo) {
 		}
 	}
 
-	return errors.RawErrorInfo{}
+	return ValidateCloudTagRules(c.TagRules)
 }
 
 // 带有额外信息的云账户
@@ -108,6 +111,9 @@ type CloudAccountConf struct {
 	VendorName string `json:"bk_cloud_vendor" bson:"bk_cloud_vendor"`
 	SecretID   string `json:"bk_secret_id" bson:"bk_secret_id"`
 	SecretKey  string `json:"bk_secret_key" bson:"bk_secret_key"`
+
+	// 云主机标签映射规则
+	TagRules []CloudTagRule `json:"bk_tag_rules,omitempty" bson:"bk_tag_rules,omitempty"`
 }
 
 type SearchCloudOption struct {
@@ -231,6 +237,9 @@ type CloudSyncTask struct {
 	LastEditor        string         `json:"bk_last_editor" bson:"bk_last_editor"`
 	CreateTime        time.Time      `json:"create_time" bson:"create_time"`
 	LastTime          time.Time      `json:"last_time" bson:"last_time"`
+
+	// 非主机云资源同步到自定义模型的配置，仅在资源类型不为host时使用
+	ResourceSync *CloudResourceSyncConf `json:"bk_resource_sync,omitempty" bson:"bk_resource_sync,omitempty"`
 }
 
 // ToMapStr to mapstr
@@ -286,6 +295,9 @@ type Instance struct {
 	PublicIp      string `json:"bk_host_outerip" bson:"bk_host_outerip"`
 	InstanceState string `json:"bk_cloud_host_status" bson:"bk_cloud_host_status"`
 	VpcId         string `json:"bk_vpc_id" bson:"bk_vpc_id"`
+
+	// 云主机的标签，用于匹配标签映射规则
+	Tags map[string]string `json:"tags,omitempty" bson:"-"`
 }
 
 // 云主机同步时的资源数据
@@ -308,6 +320,9 @@ type CloudHost struct {
 	SyncDir    int64  `json:"bk_sync_dir,omitempty" bson:"bk_sync_dir,omitempty"`
 	HostID     int64  `json:"bk_host_id" bson:"bk_host_id"`
 	VendorName string `json:"bk_cloud_vendor" bson:"bk_cloud_vendor"`
+
+	// 本地主机的详情，用于比对标签映射规则设置的主机属性
+	Detail mapstr.MapStr `json:"-" bson:"-"`
 }
 
 type HostSyncInfo struct {
@@ -365,6 +380,8 @@ type SyncStatusDesc struct {
 type SyncDetail struct {
 	NewAdd SyncSuccessInfo `json:"new_add" bson:"new_add"`
 	Update SyncSuccessInfo `json:"update" bson:"update"`
+	// 云端已销毁的资源对应的被删除的实例，仅非主机云资源同步使用
+	Delete SyncSuccessInfo `json:"delete" bson:"delete"`
 }
 
 type SyncSuccessInfo struct {
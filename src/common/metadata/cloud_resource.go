@@ -0,0 +1,159 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/util"
+)
+
+// 云资源类型，即云同步任务的bk_resource_type
+const (
+	CloudResourceTypeHost          string = "host"
+	CloudResourceTypeLoadBalancer  string = "load_balancer"
+	CloudResourceTypeDatabase      string = "database"
+	CloudResourceTypeDisk          string = "disk"
+	CloudResourceTypeSecurityGroup string = "security_group"
+)
+
+// 支持同步到自定义模型的非主机云资源类型
+var SupportedCloudResourceTypes = []string{CloudResourceTypeLoadBalancer, CloudResourceTypeDatabase,
+	CloudResourceTypeDisk, CloudResourceTypeSecurityGroup}
+
+// 云资源的公共字段，可以在字段映射中使用
+const (
+	CloudResourceIDField      = "bk_resource_id"
+	CloudResourceNameField    = "bk_resource_name"
+	CloudResourceRegionField  = "bk_region"
+	CloudResourceVpcIDField   = "bk_vpc_id"
+	CloudResourceStateField   = "bk_resource_state"
+	CloudResourceAccountField = "bk_account_id"
+)
+
+// 云厂商的非主机资源，如负载均衡、云数据库、云硬盘、安全组
+type CloudResource struct {
+	ResourceID string `json:"bk_resource_id"`
+	Name       string `json:"bk_resource_name"`
+	Region     string `json:"bk_region"`
+	VpcID      string `json:"bk_vpc_id"`
+	State      string `json:"bk_resource_state"`
+	// 资源类型特有的属性，如负载均衡的vip、云数据库的引擎版本、云硬盘的大小
+	Attributes map[string]interface{} `json:"attributes"`
+	// 资源关联的其他云资源在云端的id，关联的云资源类型 -> 云端id列表，如负载均衡的后端主机、云硬盘挂载的主机
+	Relations map[string][]string `json:"relations"`
+}
+
+// GetField 获取资源字段的值，先取公共字段，再取资源类型特有的属性，bk_account_id在同步时由账号决定，不在这里获取
+func (r *CloudResource) GetField(field string) (interface{}, bool) {
+	switch field {
+	case CloudResourceIDField:
+		return r.ResourceID, true
+	case CloudResourceNameField:
+		return r.Name, true
+	case CloudResourceRegionField:
+		return r.Region, true
+	case CloudResourceVpcIDField:
+		return r.VpcID, true
+	case CloudResourceStateField:
+		return r.State, true
+	}
+	val, ok := r.Attributes[field]
+	return val, ok
+}
+
+// AddRelation 添加资源关联的其他云资源
+func (r *CloudResource) AddRelation(resourceType string, ids ...string) {
+	if r.Relations == nil {
+		r.Relations = make(map[string][]string)
+	}
+	for _, id := range ids {
+		if id == "" || util.InStrArr(r.Relations[resourceType], id) {
+			continue
+		}
+		r.Relations[resourceType] = append(r.Relations[resourceType], id)
+	}
+}
+
+// 非主机云资源同步到自定义模型的配置
+type CloudResourceSyncConf struct {
+	// 资源同步到的自定义模型
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	// 同步的地域
+	Regions []string `json:"bk_regions" bson:"bk_regions"`
+	// 模型中保存资源云端id的属性，用于比对云端和本地的资源
+	IDField string `json:"bk_id_field" bson:"bk_id_field"`
+	// 模型属性 -> 资源字段，资源字段为云资源的公共字段或资源类型特有的属性，未配置bk_inst_name时使用资源名称。
+	// 模型属性映射了bk_account_id时，只有该账号同步的实例才会因为云端资源被销毁而被删除，
+	// 否则该模型中所有保存了云端id的实例都被认为是该任务同步的，此时一个模型只能被一个任务同步
+	FieldMapping map[string]string `json:"field_mapping" bson:"field_mapping"`
+	// 资源关联的其他云资源同步为的模型实例关联
+	Associations []CloudResourceAsstConf `json:"associations" bson:"associations"`
+}
+
+// 云资源关联关系同步为模型实例关联的配置
+type CloudResourceAsstConf struct {
+	// 关联的云资源类型，如host
+	RelatedType string `json:"bk_related_type" bson:"bk_related_type"`
+	// 模型关联的唯一标识，关联的源模型必须是资源同步到的模型
+	ObjAsstID string `json:"bk_obj_asst_id" bson:"bk_obj_asst_id"`
+	// 关联模型中保存云资源云端id的属性，关联的云资源类型为host时默认为bk_cloud_inst_id
+	AsstIDField string `json:"bk_asst_id_field" bson:"bk_asst_id_field"`
+}
+
+// GetAsstIDField 获取关联模型中保存云资源云端id的属性
+func (c *CloudResourceAsstConf) GetAsstIDField() string {
+	if c.AsstIDField == "" && c.RelatedType == CloudResourceTypeHost {
+		return common.BKCloudInstIDField
+	}
+	return c.AsstIDField
+}
+
+// Validate 校验非主机云资源的同步配置
+func (c *CloudResourceSyncConf) Validate() errors.RawErrorInfo {
+	if c.ObjectID == "" {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_obj_id"}}
+	}
+
+	if !IsCommon(c.ObjectID) {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"bk_obj_id"}}
+	}
+
+	if len(c.Regions) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_regions"}}
+	}
+
+	if c.IDField == "" {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_id_field"}}
+	}
+
+	for attr, field := range c.FieldMapping {
+		if attr == "" || field == "" || attr == c.IDField {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"field_mapping"}}
+		}
+	}
+
+	for _, asst := range c.Associations {
+		if asst.RelatedType != CloudResourceTypeHost && !util.InStrArr(SupportedCloudResourceTypes, asst.RelatedType) {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"bk_related_type"}}
+		}
+		if asst.ObjAsstID == "" {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_obj_asst_id"}}
+		}
+		if asst.GetAsstIDField() == "" {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_asst_id_field"}}
+		}
+	}
+
+	return errors.RawErrorInfo{}
+}
@@ -0,0 +1,202 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"regexp"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/util"
+)
+
+// CloudTagRuleAnyValue 标签匹配条件的值为该值时，只要云主机有该标签就匹配
+const CloudTagRuleAnyValue = "*"
+
+// cloudTagRefRegex 匹配规则的属性值和拓扑名称中引用的标签，如${module}
+var cloudTagRefRegex = regexp.MustCompile(`\$\{([^{}]+)\}`)
+
+// 云同步维护的主机属性，不能通过标签映射规则设置
+var cloudSyncHostFields = []string{common.BKHostIDField, common.BKCloudIDField, common.BKCloudInstIDField,
+	common.BKHostInnerIPField, common.BKHostOuterIPField, common.BKCloudHostStatusField, common.BKCloudVendor}
+
+// 云主机标签映射规则，将云主机的标签转换为主机属性和所在的业务拓扑。
+// 一个账号的规则按顺序匹配，云主机只应用第一个匹配的规则
+type CloudTagRule struct {
+	Name string `json:"bk_rule_name" bson:"bk_rule_name"`
+	// 匹配条件，所有条件都满足时规则匹配
+	Match []CloudTagMatch `json:"match" bson:"match"`
+	// 主机属性 -> 属性值，属性值中可以用${key}引用标签的值
+	Attributes map[string]string `json:"attributes" bson:"attributes"`
+	// 云主机新增时或者仍在资源池时转移到的业务、集群、模块名称，可以用${key}引用标签的值。
+	// 业务名称为空时不转移主机，模块名称为空时转移到业务的空闲机模块
+	BizName    string `json:"bk_biz_name" bson:"bk_biz_name"`
+	SetName    string `json:"bk_set_name" bson:"bk_set_name"`
+	ModuleName string `json:"bk_module_name" bson:"bk_module_name"`
+}
+
+// 云主机标签的匹配条件
+type CloudTagMatch struct {
+	Key string `json:"key" bson:"key"`
+	// 标签的值，为*时匹配任意值
+	Value string `json:"value" bson:"value"`
+}
+
+// Validate 校验标签映射规则
+func (r *CloudTagRule) Validate() errors.RawErrorInfo {
+	if r.Name == "" {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_rule_name"}}
+	}
+
+	if len(r.Match) == 0 {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"match"}}
+	}
+	for _, match := range r.Match {
+		if match.Key == "" || match.Value == "" {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"match"}}
+		}
+	}
+
+	if len(r.Attributes) == 0 && r.BizName == "" {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"attributes"}}
+	}
+	for attr := range r.Attributes {
+		if attr == "" || util.InStrArr(cloudSyncHostFields, attr) {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsInvalid, Args: []interface{}{"attributes"}}
+		}
+	}
+
+	// 指定集群时必须指定模块，指定集群或模块时必须指定业务
+	if r.SetName != "" && r.ModuleName == "" {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_module_name"}}
+	}
+	if r.ModuleName != "" && r.BizName == "" {
+		return errors.RawErrorInfo{ErrCode: common.CCErrCommParamsNeedSet, Args: []interface{}{"bk_biz_name"}}
+	}
+
+	return errors.RawErrorInfo{}
+}
+
+// IsMatch 云主机的标签是否匹配规则，规则中引用的标签不存在时也不匹配
+func (r *CloudTagRule) IsMatch(tags map[string]string) bool {
+	for _, match := range r.Match {
+		val, exist := tags[match.Key]
+		if !exist {
+			return false
+		}
+		if match.Value != CloudTagRuleAnyValue && match.Value != val {
+			return false
+		}
+	}
+
+	for _, tmpl := range r.templates() {
+		if _, ok := RenderCloudTagValue(tmpl, tags); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *CloudTagRule) templates() []string {
+	templates := []string{r.BizName, r.SetName, r.ModuleName}
+	for _, val := range r.Attributes {
+		templates = append(templates, val)
+	}
+	return templates
+}
+
+// RenderCloudTagValue 将值中引用的标签替换为标签的值，引用的标签不存在时返回false
+func RenderCloudTagValue(tmpl string, tags map[string]string) (string, bool) {
+	ok := true
+	val := cloudTagRefRegex.ReplaceAllStringFunc(tmpl, func(ref string) string {
+		tagVal, exist := tags[cloudTagRefRegex.FindStringSubmatch(ref)[1]]
+		if !exist {
+			ok = false
+		}
+		return tagVal
+	})
+	return val, ok
+}
+
+// ValidateCloudTagRules 校验账号的标签映射规则，规则名称不能重复
+func ValidateCloudTagRules(rules []CloudTagRule) errors.RawErrorInfo {
+	names := make(map[string]bool)
+	for idx := range rules {
+		if rawErr := rules[idx].Validate(); rawErr.ErrCode != 0 {
+			return rawErr
+		}
+		if names[rules[idx].Name] {
+			return errors.RawErrorInfo{ErrCode: common.CCErrCommDuplicateItem, Args: []interface{}{rules[idx].Name}}
+		}
+		names[rules[idx].Name] = true
+	}
+	return errors.RawErrorInfo{}
+}
+
+// 云主机应用标签映射规则的结果
+type CloudTagRuleResult struct {
+	RuleName   string
+	Attributes map[string]interface{}
+	BizName    string
+	SetName    string
+	ModuleName string
+}
+
+// ApplyCloudTagRules 按顺序匹配标签映射规则，返回第一个匹配的规则应用的结果，没有匹配的规则时返回nil
+func ApplyCloudTagRules(rules []CloudTagRule, tags map[string]string) *CloudTagRuleResult {
+	for idx := range rules {
+		rule := &rules[idx]
+		if !rule.IsMatch(tags) {
+			continue
+		}
+
+		result := &CloudTagRuleResult{
+			RuleName:   rule.Name,
+			Attributes: make(map[string]interface{}),
+		}
+		for attr, tmpl := range rule.Attributes {
+			result.Attributes[attr], _ = RenderCloudTagValue(tmpl, tags)
+		}
+		result.BizName, _ = RenderCloudTagValue(rule.BizName, tags)
+		result.SetName, _ = RenderCloudTagValue(rule.SetName, tags)
+		result.ModuleName, _ = RenderCloudTagValue(rule.ModuleName, tags)
+		return result
+	}
+	return nil
+}
+
+// 标签映射规则的预览结果
+type CloudTagRulePreview struct {
+	// 账号下的云主机总数
+	Total int64 `json:"total"`
+	// 没有匹配任何规则的云主机数
+	Unmatched int64 `json:"unmatched"`
+	// 按规则顺序排列的各个规则的匹配情况
+	Rules []CloudTagRuleMatchInfo `json:"rules"`
+}
+
+// 一个标签映射规则的匹配情况
+type CloudTagRuleMatchInfo struct {
+	RuleName string `json:"bk_rule_name"`
+	// 匹配该规则的云主机数
+	Matched int64 `json:"matched"`
+	// 实际应用该规则的云主机数，规则按顺序匹配，匹配了前面的规则的云主机不会应用该规则
+	Applied int64 `json:"applied"`
+	// 应用该规则的部分云主机实例id
+	InstanceIDs []string `json:"bk_cloud_inst_ids"`
+}
+
+// 预览标签映射规则的请求参数，规则为空时预览账号已保存的规则
+type CloudTagRulePreviewOption struct {
+	Rules []CloudTagRule `json:"bk_tag_rules"`
+}
@@ -387,6 +387,7 @@ type ListProcessInstanceRelationOption struct {
 	ServiceInstanceIDs []int64  `json:"service_instance_id,omitempty"`
 	ProcessTemplateID  int64    `json:"process_template_id,omitempty"`
 	HostID             int64    `json:"host_id,omitempty"`
+	HostIDs            []int64  `json:"bk_host_ids,omitempty"`
 	Page               BasePage `json:"page" field:"page"`
 }
 
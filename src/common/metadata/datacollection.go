@@ -12,6 +12,8 @@
 
 package metadata
 
+import "encoding/json"
+
 type AddDeviceResult struct {
 	DeviceID uint64 `json:"device_id"`
 }
@@ -73,3 +75,28 @@ type SearchNetPropertyResult struct {
 type DeleteNetPropertyBatchOpt struct {
 	NetcollectPropertyIDs []uint64 `json:"netcollect_property_id"`
 }
+
+// CollectorIngestBatch is batch of collector messages pushed by datacollection ingestion api.
+type CollectorIngestBatch struct {
+	Data []json.RawMessage `json:"data"`
+}
+
+// CollectorIngestResult is result of pushing collector messages by datacollection ingestion api.
+type CollectorIngestResult struct {
+	// Accepted is count of messages added to the analyze queue of the receiving node.
+	Accepted int `json:"accepted"`
+	// Forwarded is count of messages forwarded to and accepted by the datacollection nodes they belong to.
+	Forwarded int                      `json:"forwarded"`
+	Failed    []CollectorIngestFailure `json:"failed"`
+}
+
+// CollectorIngestFailure is a message failed to be pushed, Index is its index in the batch.
+type CollectorIngestFailure struct {
+	Index  int    `json:"index"`
+	ErrMsg string `json:"error_msg"`
+}
+
+type CollectorIngestResponse struct {
+	BaseResp `json:",inline"`
+	Data     CollectorIngestResult `json:"data"`
+}
@@ -19,6 +19,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/selector"
 	"configcenter/src/common/util"
 
 	"github.com/google/uuid"
@@ -165,6 +166,10 @@ func validAttributeValueType(attrType string, value interface{}) error {
 		if _, ok := value.(bool); !ok {
 			return fmt.Errorf("attribute only support bool value, not support value, %+v", value)
 		}
+	case computedType:
+		if _, ok := value.(string); !ok && !util.IsNumeric(value) {
+			return fmt.Errorf("attribute only support numeric or string value, not support value, %+v", value)
+		}
 	}
 
 	return nil
@@ -174,17 +179,22 @@ const (
 	numericType = "numeric"
 	boolType    = "bool"
 	stringType  = "string"
+	// computedType the value of the computed attribute is numeric or string according to its value type.
+	computedType = "computed"
 )
 
 func getAttributeType(attributeType string) (string, error) {
 	switch attributeType {
 	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeEnum, common.FieldTypeDate, common.FieldTypeTime,
-		common.FieldTypeTimeZone, common.FieldTypeUser, common.FieldTypeList, common.FieldTypeOrganization:
+		common.FieldTypeTimeZone, common.FieldTypeUser, common.FieldTypeList, common.FieldTypeOrganization,
+		common.FieldTypeIP, common.FieldTypeURL, common.FieldTypeEnumMulti:
 		return stringType, nil
-	case common.FieldTypeInt, common.FieldTypeFloat:
+	case common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeInstRef:
 		return numericType, nil
 	case common.FieldTypeBool:
 		return boolType, nil
+	case common.FieldTypeComputed:
+		return computedType, nil
 	default:
 		return "", fmt.Errorf("not support attribute type, %s", attributeType)
 	}
@@ -199,6 +209,10 @@ type DynamicGroupInfoCondition struct {
 	// Condition is search condition on fields level.
 	// Example: bk_host_name $eq my-host just index host which name is "my-host".
 	Condition []DynamicGroupCondition `json:"condition" bson:"condition"`
+
+	// LabelSelector is search condition on labels level, all the selectors should be matched.
+	// Example: env in [prod, staging] just index the resources whose label env is prod or staging.
+	LabelSelector selector.Selectors `json:"label_selector,omitempty" bson:"label_selector,omitempty"`
 }
 
 // Validate validates dynamic group info conditions format.
@@ -231,6 +245,10 @@ func (c *DynamicGroupInfoCondition) Validate(validatefunc Validatefunc) error {
 			return err
 		}
 	}
+
+	if field, err := c.LabelSelector.Validate(); err != nil {
+		return fmt.Errorf("invalid label selector field %s, %+v", field, err)
+	}
 	return nil
 }
 
@@ -0,0 +1,46 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+)
+
+func TestDynamicGroupConditionComputed(t *testing.T) {
+	attributes := map[string]string{"total_cpu": common.FieldTypeComputed}
+
+	valid := []DynamicGroupCondition{
+		{Field: "total_cpu", Operator: DynamicGroupOperatorEQ, Value: 8},
+		{Field: "total_cpu", Operator: DynamicGroupOperatorGTE, Value: 1.5},
+		{Field: "total_cpu", Operator: DynamicGroupOperatorEQ, Value: "prod"},
+		{Field: "total_cpu", Operator: DynamicGroupOperatorIN, Value: []interface{}{8, "16"}},
+	}
+	for _, cond := range valid {
+		if err := cond.Validate(attributes); err != nil {
+			t.Errorf("computed attribute condition %+v should be valid, err: %v", cond, err)
+		}
+	}
+
+	invalid := []DynamicGroupCondition{
+		{Field: "total_cpu", Operator: DynamicGroupOperatorEQ, Value: true},
+		{Field: "total_cpu", Operator: DynamicGroupOperatorIN, Value: []interface{}{8, false}},
+		{Field: "total_cpu", Operator: DynamicGroupOperatorLIKE, Value: "8"},
+	}
+	for _, cond := range invalid {
+		if err := cond.Validate(attributes); err == nil {
+			t.Errorf("computed attribute condition %+v should be invalid", cond)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"configcenter/src/common/errors"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/querybuilder"
+	"configcenter/src/common/selector"
 	"configcenter/src/common/util"
 )
 
@@ -440,6 +441,29 @@ type SearchCondition struct {
 	Fields    []string        `json:"fields"`
 	Condition []ConditionItem `json:"condition"`
 	ObjectID  string          `json:"bk_obj_id"`
+	// LabelSelector filters the instances of the object by their labels, all the selectors should be matched
+	LabelSelector selector.Selectors `json:"label_selector,omitempty"`
+}
+
+// LabelSelectorConditions converts the label selectors to the condition items on the labels field in the same
+// way as the mongodb filter of the selectors.
+func LabelSelectorConditions(selectors selector.Selectors) ([]ConditionItem, error) {
+	conditions := make([]ConditionItem, 0)
+	for _, s := range selectors {
+		field, operator, value, err := s.ToMgoCondition()
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, ConditionItem{Field: field, Operator: operator, Value: value})
+	}
+	return conditions, nil
+}
+
+// HostLabelAggregationOption aggregates the labels of the hosts matching the condition,
+// the hosts are limited to the business if bk_biz_id is set.
+type HostLabelAggregationOption struct {
+	BizID     int64         `json:"bk_biz_id"`
+	Condition mapstr.MapStr `json:"condition"`
 }
 
 type SearchHost struct {
@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"errors"
+	"time"
+
+	"configcenter/src/common"
+)
+
+const (
+	// HostSnapHistoryIPsField is the field of the ips in host snapshot history, it's derived from
+	// the network interfaces of the host snapshot and not a host attribute.
+	HostSnapHistoryIPsField = "ips"
+
+	// HostSnapHistoryMaxTimeRange is the max time range of searching host snapshot history.
+	HostSnapHistoryMaxTimeRange = 31 * 24 * time.Hour
+)
+
+// HostSnapChangeType is the change type of a host field derived from host snapshots.
+type HostSnapChangeType string
+
+const (
+	// HostSnapChangeIncreased the numeric field is increased, like the memory is expanded.
+	HostSnapChangeIncreased HostSnapChangeType = "increased"
+	// HostSnapChangeDecreased the numeric field is decreased, like the memory is shrank.
+	HostSnapChangeDecreased HostSnapChangeType = "decreased"
+	// HostSnapChangeModified the field is modified, like the os version is upgraded.
+	HostSnapChangeModified HostSnapChangeType = "modified"
+	// HostSnapChangeAdded the item of the list field is added, like a new ip appeared.
+	HostSnapChangeAdded HostSnapChangeType = "added"
+	// HostSnapChangeRemoved the item of the list field is removed, like an ip disappeared.
+	HostSnapChangeRemoved HostSnapChangeType = "removed"
+)
+
+// HostSnapChange is a change of a host field derived from host snapshots.
+type HostSnapChange struct {
+	Field    string             `json:"field" bson:"field"`
+	Type     HostSnapChangeType `json:"type" bson:"type"`
+	Previous interface{}        `json:"previous" bson:"previous"`
+	Current  interface{}        `json:"current" bson:"current"`
+}
+
+// HostSnapHistory is a history record of the host fields derived from host snapshots, it's
+// recorded when the fields are changed, the first record of a host has no changes.
+type HostSnapHistory struct {
+	HostID          int64                  `json:"bk_host_id" bson:"bk_host_id"`
+	CloudID         int64                  `json:"bk_cloud_id" bson:"bk_cloud_id"`
+	InnerIP         string                 `json:"bk_host_innerip" bson:"bk_host_innerip"`
+	Fields          map[string]interface{} `json:"fields" bson:"fields"`
+	Changes         []HostSnapChange       `json:"changes" bson:"changes"`
+	SupplierAccount string                 `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime      time.Time              `json:"create_time" bson:"create_time"`
+}
+
+// SearchHostSnapHistoryOption is the option to search the snapshot history of a host in a time range.
+type SearchHostSnapHistoryOption struct {
+	HostID int64 `json:"bk_host_id"`
+	// StartTime and EndTime are unix timestamps in seconds, the time range is [StartTime, EndTime].
+	StartTime int64 `json:"start_time"`
+	EndTime   int64 `json:"end_time"`
+	// OnlyChanged only returns the records with changes, which excludes the first record of the host.
+	OnlyChanged bool     `json:"only_changed"`
+	Page        BasePage `json:"page"`
+}
+
+// Validate validates the search option, returns the invalid field and the error.
+func (o *SearchHostSnapHistoryOption) Validate() (string, error) {
+	if o.HostID <= 0 {
+		return common.BKHostIDField, errors.New("host id must be set")
+	}
+
+	if o.StartTime < 0 || o.EndTime < 0 {
+		return "start_time", errors.New("time can not be negative")
+	}
+
+	if o.StartTime != 0 && o.EndTime != 0 {
+		if o.StartTime > o.EndTime {
+			return "start_time", errors.New("start time is after end time")
+		}
+		if time.Duration(o.EndTime-o.StartTime)*time.Second > HostSnapHistoryMaxTimeRange {
+			return "end_time", errors.New("time range exceeds the max range")
+		}
+	}
+
+	if o.Page.IsIllegal() {
+		return "page.limit", errors.New("page limit is illegal")
+	}
+	return "", nil
+}
+
+type SearchHostSnapHistoryResult struct {
+	Count uint64            `json:"count"`
+	Info  []HostSnapHistory `json:"info"`
+}
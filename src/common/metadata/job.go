@@ -0,0 +1,200 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	// JobIDField the unique id of a job
+	JobIDField = "job_id"
+	// JobTypeField the registered type of a job
+	JobTypeField = "type"
+	// JobStatusField the status of a job
+	JobStatusField = "status"
+	// JobCreatorField the user who created the job
+	JobCreatorField = "creator"
+	// JobNextRunTimeField the time after which a waiting job can be run
+	JobNextRunTimeField = "next_run_time"
+	// JobLogsField the execution logs of a job
+	JobLogsField = "logs"
+	// JobRunIDField the id of the current execution of a job
+	JobRunIDField = "run_id"
+
+	// JobMaxLogCount the max number of the latest logs kept for a job
+	JobMaxLogCount = 200
+)
+
+// JobStatus the status of a job
+type JobStatus string
+
+const (
+	// JobStatusWaiting the job is waiting to be run, including the jobs waiting for a retry
+	JobStatusWaiting JobStatus = "waiting"
+	// JobStatusRunning the job is running
+	JobStatusRunning JobStatus = "running"
+	// JobStatusCanceling the running job is asked to be canceled and waiting for the handler to stop
+	JobStatusCanceling JobStatus = "canceling"
+	// JobStatusSuccess the job is finished successfully
+	JobStatusSuccess JobStatus = "success"
+	// JobStatusFailed the job is failed after all the retries
+	JobStatusFailed JobStatus = "failed"
+	// JobStatusCanceled the job is canceled
+	JobStatusCanceled JobStatus = "canceled"
+)
+
+// IsFinished returns if the job will never be run again
+func (s JobStatus) IsFinished() bool {
+	switch s {
+	case JobStatusSuccess, JobStatusFailed, JobStatusCanceled:
+		return true
+	}
+	return false
+}
+
+// JobLogLevel the level of a job log
+type JobLogLevel string
+
+const (
+	// JobLogLevelInfo info level log
+	JobLogLevelInfo JobLogLevel = "info"
+	// JobLogLevelWarn warning level log
+	JobLogLevelWarn JobLogLevel = "warn"
+	// JobLogLevelError error level log
+	JobLogLevelError JobLogLevel = "error"
+)
+
+// JobLog a log line of the job execution
+type JobLog struct {
+	Time    time.Time   `json:"time" bson:"time"`
+	Level   JobLogLevel `json:"level" bson:"level"`
+	Message string      `json:"message" bson:"message"`
+}
+
+// Job a generic job executed asynchronously by the task server
+type Job struct {
+	JobID string `json:"job_id" bson:"job_id"`
+	// Type the registered job type which decides how the job is executed
+	Type string `json:"type" bson:"type"`
+	// Flag job flag, left for the caller to identify the job
+	Flag    string `json:"flag" bson:"flag"`
+	BizID   int64  `json:"bk_biz_id" bson:"bk_biz_id"`
+	Creator string `json:"creator" bson:"creator"`
+	OwnerID string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	// Header the http header of the creator, the job is executed with it
+	Header http.Header `json:"-" bson:"header"`
+	// Params the parameters of the job, the format is decided by the job type
+	Params interface{} `json:"params" bson:"params"`
+
+	Status JobStatus `json:"status" bson:"status"`
+	// Progress the fractional progress of the job, from 0 to 1
+	Progress float64 `json:"progress" bson:"progress"`
+	// Message the description of the current step of the job
+	Message string `json:"message" bson:"message"`
+	// Logs the latest execution logs of the job, at most JobMaxLogCount logs are kept
+	Logs []JobLog `json:"logs" bson:"logs"`
+	// Result the result returned by the job handler when the job succeeds
+	Result interface{} `json:"result" bson:"result"`
+	// Error the error of the last failed execution
+	Error string `json:"error" bson:"error"`
+
+	// Attempts the number of times the job has been run
+	Attempts int64 `json:"attempts" bson:"attempts"`
+	// MaxRetry the max number of retries after the first failed execution
+	MaxRetry int64 `json:"max_retry" bson:"max_retry"`
+	// NextRunTime the waiting job is not run before this time, it is used to back off the retries
+	NextRunTime time.Time `json:"next_run_time" bson:"next_run_time"`
+	// RunID the id of the current execution, it is regenerated each time a task server claims the job
+	RunID string `json:"run_id" bson:"run_id"`
+
+	CreateTime time.Time  `json:"create_time" bson:"create_time"`
+	StartTime  *time.Time `json:"start_time" bson:"start_time"`
+	EndTime    *time.Time `json:"end_time" bson:"end_time"`
+	// LastTime the last update time of the job, it is refreshed as the heartbeat of a running job
+	LastTime time.Time `json:"last_time" bson:"last_time"`
+}
+
+// CreateJobOption create job request parameters
+type CreateJobOption struct {
+	Type  string `json:"type"`
+	Flag  string `json:"flag"`
+	BizID int64  `json:"bk_biz_id"`
+	// Params the parameters of the job, the format is decided by the job type
+	Params interface{} `json:"params"`
+	// MaxRetry overrides the max retry of the job type if it is set
+	MaxRetry *int64 `json:"max_retry"`
+}
+
+// ListJobOption list job request parameters, the empty fields are not used as filters
+type ListJobOption struct {
+	Type    string      `json:"type"`
+	Flag    string      `json:"flag"`
+	BizID   int64       `json:"bk_biz_id"`
+	Creator string      `json:"creator"`
+	Status  []JobStatus `json:"status"`
+	Page    BasePage    `json:"page"`
+}
+
+// ListJobResult list job result
+type ListJobResult struct {
+	Count int64 `json:"count"`
+	Info  []Job `json:"info"`
+}
+
+// ReportJobProgressOption the progress reported by the service which executes the job
+type ReportJobProgressOption struct {
+	// Progress the fractional progress of the job, from 0 to 1, not updated if not set
+	Progress *float64 `json:"progress"`
+	// Message the description of the current step, not updated if empty
+	Message string   `json:"message"`
+	Logs    []JobLog `json:"logs"`
+}
+
+// ReportJobProgressResult report job progress result
+type ReportJobProgressResult struct {
+	// Canceled the job is asked to be canceled, the executor should stop the job as soon as possible
+	Canceled bool `json:"canceled"`
+}
+
+// JobTypeInfo the registered job type
+type JobTypeInfo struct {
+	Name     string `json:"name"`
+	MaxRetry int64  `json:"max_retry"`
+}
+
+type CreateJobResponse struct {
+	BaseResp
+	Data Job `json:"data"`
+}
+
+type ListJobResponse struct {
+	BaseResp
+	Data ListJobResult `json:"data"`
+}
+
+type JobDetailResponse struct {
+	BaseResp
+	Data Job `json:"data"`
+}
+
+type ReportJobProgressResponse struct {
+	BaseResp
+	Data ReportJobProgressResult `json:"data"`
+}
+
+type ListJobTypeResponse struct {
+	BaseResp
+	Data []JobTypeInfo `json:"data"`
+}
@@ -0,0 +1,192 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	// JobScheduleIDField the unique id of a job schedule
+	JobScheduleIDField = "schedule_id"
+	// JobScheduleNameField the name of a job schedule
+	JobScheduleNameField = "name"
+	// JobScheduleEnabledField whether the job schedule is enabled
+	JobScheduleEnabledField = "enabled"
+	// JobScheduleFireIDField the id of the latest firing of a job schedule
+	JobScheduleFireIDField = "fire_id"
+	// JobScheduleFireTimeField the time when a run of a job schedule is fired or skipped
+	JobScheduleFireTimeField = "fire_time"
+)
+
+// MisfirePolicy decides how the runs which missed their scheduled time are handled, the runs may be missed when
+// all the task servers are down or the scheduled job type is not registered.
+type MisfirePolicy string
+
+const (
+	// MisfirePolicySkip skip all the missed runs and wait for the next scheduled time
+	MisfirePolicySkip MisfirePolicy = "skip"
+	// MisfirePolicyFireOnce fire a single run for all the missed runs immediately
+	MisfirePolicyFireOnce MisfirePolicy = "fire_once"
+	// MisfirePolicyFireAll fire each missed run immediately, at most JobScheduleMaxMisfireRuns runs are fired
+	MisfirePolicyFireAll MisfirePolicy = "fire_all"
+
+	// JobScheduleMaxMisfireRuns the max number of the missed runs fired at once by the fire all policy
+	JobScheduleMaxMisfireRuns = 10
+)
+
+// Validate validates the misfire policy
+func (p MisfirePolicy) Validate() bool {
+	switch p {
+	case MisfirePolicySkip, MisfirePolicyFireOnce, MisfirePolicyFireAll:
+		return true
+	}
+	return false
+}
+
+// JobSchedule a cron-style schedule which creates jobs of the job type periodically
+type JobSchedule struct {
+	ScheduleID string `json:"schedule_id" bson:"schedule_id"`
+	Name       string `json:"name" bson:"name"`
+	// Type the registered job type of the scheduled jobs
+	Type string `json:"type" bson:"type"`
+	// Params the parameters of the scheduled jobs
+	Params interface{} `json:"params" bson:"params"`
+	BizID  int64       `json:"bk_biz_id" bson:"bk_biz_id"`
+	// MaxRetry overrides the max retry of the job type if it is set
+	MaxRetry *int64 `json:"max_retry" bson:"max_retry"`
+
+	// Spec the standard cron spec with 5 fields, descriptors like @daily and @every 1h are supported too,
+	// the time zone can be set by a CRON_TZ= prefix, e.g. "CRON_TZ=Asia/Shanghai 30 2 * * *"
+	Spec    string `json:"spec" bson:"spec"`
+	Enabled bool   `json:"enabled" bson:"enabled"`
+	// MisfirePolicy how the runs which missed their scheduled time are handled
+	MisfirePolicy MisfirePolicy `json:"misfire_policy" bson:"misfire_policy"`
+	// AllowConcurrent whether a run is fired when the job of the last run is not finished, it is skipped if not
+	AllowConcurrent bool `json:"allow_concurrent" bson:"allow_concurrent"`
+
+	// NextRunTime the next scheduled time of the job schedule
+	NextRunTime time.Time `json:"next_run_time" bson:"next_run_time"`
+	// LastRunTime the last time when a job is fired
+	LastRunTime *time.Time `json:"last_run_time" bson:"last_run_time"`
+	// LastJobID the id of the latest fired job
+	LastJobID string `json:"last_job_id" bson:"last_job_id"`
+	// FireID the id of the latest firing, it makes sure a scheduled time is fired only once by the task servers
+	FireID string `json:"-" bson:"fire_id"`
+
+	Creator  string `json:"creator" bson:"creator"`
+	Modifier string `json:"modifier" bson:"modifier"`
+	OwnerID  string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	// Header the http header of the user who created or updated the schedule, the jobs are executed with it
+	Header     http.Header `json:"-" bson:"header"`
+	CreateTime time.Time   `json:"create_time" bson:"create_time"`
+	LastTime   time.Time   `json:"last_time" bson:"last_time"`
+}
+
+// JobScheduleRunStatus the status of a run of a job schedule
+type JobScheduleRunStatus string
+
+const (
+	// JobScheduleRunFired a job is created for the run
+	JobScheduleRunFired JobScheduleRunStatus = "fired"
+	// JobScheduleRunSkipped the run is skipped by the misfire policy or the concurrent policy
+	JobScheduleRunSkipped JobScheduleRunStatus = "skipped"
+	// JobScheduleRunFailed the job of the run is failed to be created
+	JobScheduleRunFailed JobScheduleRunStatus = "failed"
+)
+
+// JobScheduleRun the run history of a job schedule
+type JobScheduleRun struct {
+	ScheduleID string `json:"schedule_id" bson:"schedule_id"`
+	// ScheduledTime the time when the run is scheduled
+	ScheduledTime time.Time `json:"scheduled_time" bson:"scheduled_time"`
+	// FireTime the time when the run is actually fired or skipped
+	FireTime time.Time            `json:"fire_time" bson:"fire_time"`
+	Status   JobScheduleRunStatus `json:"status" bson:"status"`
+	// Message the reason why the run is skipped or failed
+	Message string `json:"message" bson:"message"`
+	// JobID the id of the fired job
+	JobID   string `json:"job_id" bson:"job_id"`
+	OwnerID string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	// Job the fired job, it is filled when the history is listed
+	Job *Job `json:"job,omitempty" bson:"-"`
+}
+
+// CreateJobScheduleOption create job schedule request parameters
+type CreateJobScheduleOption struct {
+	Name          string        `json:"name"`
+	Type          string        `json:"type"`
+	Params        interface{}   `json:"params"`
+	BizID         int64         `json:"bk_biz_id"`
+	MaxRetry      *int64        `json:"max_retry"`
+	Spec          string        `json:"spec"`
+	Enabled       bool          `json:"enabled"`
+	MisfirePolicy MisfirePolicy `json:"misfire_policy"`
+	// AllowConcurrent whether a run is fired when the job of the last run is not finished
+	AllowConcurrent bool `json:"allow_concurrent"`
+}
+
+// UpdateJobScheduleOption update job schedule request parameters, the fields that are not set are not updated
+type UpdateJobScheduleOption struct {
+	Name            *string        `json:"name"`
+	Params          interface{}    `json:"params"`
+	MaxRetry        *int64         `json:"max_retry"`
+	Spec            *string        `json:"spec"`
+	Enabled         *bool          `json:"enabled"`
+	MisfirePolicy   *MisfirePolicy `json:"misfire_policy"`
+	AllowConcurrent *bool          `json:"allow_concurrent"`
+}
+
+// ListJobScheduleOption list job schedule request parameters, the empty fields are not used as filters
+type ListJobScheduleOption struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	BizID   int64    `json:"bk_biz_id"`
+	Creator string   `json:"creator"`
+	Enabled *bool    `json:"enabled"`
+	Page    BasePage `json:"page"`
+}
+
+// ListJobScheduleResult list job schedule result
+type ListJobScheduleResult struct {
+	Count int64         `json:"count"`
+	Info  []JobSchedule `json:"info"`
+}
+
+// ListJobScheduleRunOption list the run history of a job schedule request parameters
+type ListJobScheduleRunOption struct {
+	Status []JobScheduleRunStatus `json:"status"`
+	Page   BasePage               `json:"page"`
+}
+
+// ListJobScheduleRunResult list the run history of a job schedule result
+type ListJobScheduleRunResult struct {
+	Count int64            `json:"count"`
+	Info  []JobScheduleRun `json:"info"`
+}
+
+type JobScheduleResponse struct {
+	BaseResp
+	Data JobSchedule `json:"data"`
+}
+
+type ListJobScheduleResponse struct {
+	BaseResp
+	Data ListJobScheduleResult `json:"data"`
+}
+
+type ListJobScheduleRunResponse struct {
+	BaseResp
+	Data ListJobScheduleRunResult `json:"data"`
+}
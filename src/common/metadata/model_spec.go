@@ -0,0 +1,212 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+// ModelSpecVersion the version of the model spec format.
+const ModelSpecVersion = "v1"
+
+// ModelSpec is the declarative definition of the models, which can be exported from a cmdb, kept
+// under version control, and then applied to a cmdb by comparing with the models in it.
+type ModelSpec struct {
+	Version         string               `json:"version" yaml:"version"`
+	Classifications []ClassificationSpec `json:"classifications" yaml:"classifications"`
+	Models          []ObjectSpec         `json:"models" yaml:"models"`
+	Associations    []AssociationSpec    `json:"associations" yaml:"associations"`
+	// AssociationKinds and ServiceCategories are the preset data which the models and the business topology
+	// depend on, they are exported only when asked, and are never deleted when applied.
+	AssociationKinds  []AssociationKindSpec `json:"association_kinds,omitempty" yaml:"association_kinds,omitempty"`
+	ServiceCategories []ServiceCategorySpec `json:"service_categories,omitempty" yaml:"service_categories,omitempty"`
+}
+
+// ClassificationSpec the declarative definition of a model classification.
+type ClassificationSpec struct {
+	ID   string `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+	Icon string `json:"icon,omitempty" yaml:"icon,omitempty"`
+}
+
+// ObjectSpec the declarative definition of a model with it's groups, attributes and unique rules.
+type ObjectSpec struct {
+	ID             string `json:"id" yaml:"id"`
+	Name           string `json:"name" yaml:"name"`
+	Classification string `json:"classification" yaml:"classification"`
+	Icon           string `json:"icon,omitempty" yaml:"icon,omitempty"`
+	// Preset the model is a preset model of cmdb, only it's custom groups, attributes and unique rules
+	// are managed by the spec, it's set by the export and is ignored when applied.
+	Preset     bool            `json:"preset,omitempty" yaml:"preset,omitempty"`
+	Groups     []GroupSpec     `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Attributes []AttributeSpec `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+	Uniques    []UniqueSpec    `json:"uniques,omitempty" yaml:"uniques,omitempty"`
+}
+
+// GroupSpec the declarative definition of a model's attribute group.
+type GroupSpec struct {
+	ID       string `json:"id" yaml:"id"`
+	Name     string `json:"name" yaml:"name"`
+	Index    int64  `json:"index" yaml:"index"`
+	Collapse bool   `json:"collapse,omitempty" yaml:"collapse,omitempty"`
+}
+
+// AttributeSpec the declarative definition of a model's custom attribute.
+type AttributeSpec struct {
+	ID          string      `json:"id" yaml:"id"`
+	Name        string      `json:"name" yaml:"name"`
+	Type        string      `json:"type" yaml:"type"`
+	Group       string      `json:"group" yaml:"group"`
+	Unit        string      `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Placeholder string      `json:"placeholder,omitempty" yaml:"placeholder,omitempty"`
+	Editable    bool        `json:"editable" yaml:"editable"`
+	Required    bool        `json:"required" yaml:"required"`
+	Option      interface{} `json:"option,omitempty" yaml:"option,omitempty"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// UniqueSpec the declarative definition of a model's unique rule, the keys are the property ids.
+type UniqueSpec struct {
+	Keys      []string `json:"keys" yaml:"keys"`
+	MustCheck bool     `json:"must_check" yaml:"must_check"`
+}
+
+// AssociationSpec the declarative definition of an association between two models.
+type AssociationSpec struct {
+	ID        string                    `json:"id" yaml:"id"`
+	ObjectID  string                    `json:"object" yaml:"object"`
+	AsstObjID string                    `json:"asst_object" yaml:"asst_object"`
+	Kind      string                    `json:"kind" yaml:"kind"`
+	Name      string                    `json:"name,omitempty" yaml:"name,omitempty"`
+	Mapping   AssociationMapping        `json:"mapping" yaml:"mapping"`
+	OnDelete  AssociationOnDeleteAction `json:"on_delete,omitempty" yaml:"on_delete,omitempty"`
+}
+
+// AssociationKindSpec the declarative definition of an association kind.
+type AssociationKindSpec struct {
+	ID        string               `json:"id" yaml:"id"`
+	Name      string               `json:"name" yaml:"name"`
+	SrcDes    string               `json:"src_des" yaml:"src_des"`
+	DestDes   string               `json:"dest_des" yaml:"dest_des"`
+	Direction AssociationDirection `json:"direction" yaml:"direction"`
+	// Preset the association kind is a preset one of cmdb, it can not be changed by the spec.
+	Preset bool `json:"preset,omitempty" yaml:"preset,omitempty"`
+}
+
+// ServiceCategorySpec the declarative definition of a service category, the business is referred by it's
+// name and the parent category by it's name, because their ids are different between the cmdb instances.
+type ServiceCategorySpec struct {
+	// Business the name of the business which the category belongs to, it's empty for a built-in category.
+	Business string `json:"bk_biz_name,omitempty" yaml:"business,omitempty"`
+	// Parent the name of the first level category which the category belongs to, empty for a first level one.
+	Parent string `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Name   string `json:"name" yaml:"name"`
+	// BuiltIn the category is a built-in one of cmdb, it's set by the export and is never created.
+	BuiltIn bool `json:"built_in,omitempty" yaml:"built_in,omitempty"`
+}
+
+// ExportModelSpecOption export the models in the classifications or the models with the ids,
+// all the models are exported if both of them are empty.
+type ExportModelSpecOption struct {
+	Classifications []string `json:"classifications"`
+	ObjectIDs       []string `json:"bk_obj_ids"`
+	// PresetData export the association kinds and the service categories too.
+	PresetData bool `json:"preset_data"`
+	// Businesses export the service categories of the businesses with these names, default is all.
+	Businesses []string `json:"bk_biz_names"`
+}
+
+// ModelSpecOption the option to plan or apply a model spec.
+type ModelSpecOption struct {
+	Spec ModelSpec `json:"spec"`
+	// Prune delete the groups, attributes, unique rules and associations of the models in the spec which
+	// are not declared, and the custom models in the spec's classifications which are not declared.
+	Prune bool `json:"prune"`
+	// Changes the keys of the changes to apply, all the changes are applied if not set, the others are
+	// skipped, so that a part of the spec can be applied, e.g. when the models are promoted from another cmdb.
+	Changes []string `json:"changes,omitempty"`
+}
+
+// ModelSpecAction the action of a model spec change
+type ModelSpecAction string
+
+const (
+	ModelSpecCreate ModelSpecAction = "create"
+	ModelSpecUpdate ModelSpecAction = "update"
+	ModelSpecDelete ModelSpecAction = "delete"
+)
+
+// ModelSpecKind the kind of the resource of a model spec change
+type ModelSpecKind string
+
+const (
+	ModelSpecKindClassification  ModelSpecKind = "classification"
+	ModelSpecKindModel           ModelSpecKind = "model"
+	ModelSpecKindGroup           ModelSpecKind = "group"
+	ModelSpecKindAttribute       ModelSpecKind = "attribute"
+	ModelSpecKindUnique          ModelSpecKind = "unique"
+	ModelSpecKindAssociation     ModelSpecKind = "association"
+	ModelSpecKindAssociationKind ModelSpecKind = "association_kind"
+	ModelSpecKindServiceCategory ModelSpecKind = "service_category"
+)
+
+// ModelSpecChange is a change to make the models the same as the spec.
+type ModelSpecChange struct {
+	Action ModelSpecAction `json:"action"`
+	Kind   ModelSpecKind   `json:"kind"`
+	// ObjectID the model which the group, attribute or unique rule belongs to, or the business name which
+	// the service category belongs to.
+	ObjectID string `json:"bk_obj_id,omitempty"`
+	// ID the id of the resource in the spec, the keys joined by comma for a unique rule, and the parent
+	// and the name joined by slash for a second level service category.
+	ID string `json:"id"`
+	// Fields the changed fields and their values in the spec.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+	// Conflict the reason why the change can not be applied, such changes are skipped.
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// Key returns the key of the change which is used to select the changes to apply, it's like
+// "model:switch", "attribute:switch/vendor", "association:switch_connect_router".
+func (c ModelSpecChange) Key() string {
+	if c.ObjectID == "" || c.Kind == ModelSpecKindAssociation {
+		return string(c.Kind) + ":" + c.ID
+	}
+	return string(c.Kind) + ":" + c.ObjectID + "/" + c.ID
+}
+
+// ModelSpecPlan the changes to apply a model spec, they are ordered as they will be applied.
+type ModelSpecPlan struct {
+	Changes []ModelSpecChange `json:"changes"`
+}
+
+// ModelSpecApplyResult the result of applying a model spec, the changes are applied in order
+// until one of them failed, the failed one and the ones after it are not applied.
+type ModelSpecApplyResult struct {
+	Applied []ModelSpecChange `json:"applied"`
+	Failed  *ModelSpecChange  `json:"failed,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	// Skipped the conflicted changes, the changes not selected, and the changes not applied because of the failure.
+	Skipped []ModelSpecChange `json:"skipped"`
+}
+
+type ModelSpecResult struct {
+	BaseResp `json:",inline"`
+	Data     ModelSpec `json:"data"`
+}
+
+type ModelSpecPlanResult struct {
+	BaseResp `json:",inline"`
+	Data     ModelSpecPlan `json:"data"`
+}
+
+type ModelSpecApplyResultResp struct {
+	BaseResp `json:",inline"`
+	Data     ModelSpecApplyResult `json:"data"`
+}
@@ -13,9 +13,12 @@
 package metadata
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"configcenter/src/common"
+	"configcenter/src/common/querybuilder"
 )
 
 type ChartConfig struct {
@@ -29,6 +32,117 @@ type ChartConfig struct {
 	ChartType  string `json:"chart_type" bson:"chart_type"`
 	Field      string `json:"field" bson:"field"`
 	XAxisCount int64  `json:"x_axis_count" bson:"x_axis_count"`
+
+	// the following fields are used by the aggregation chart, which aggregates the instances of the model
+	// matching the filter, grouped by at most two fields.
+	// Filter the querybuilder rule to filter the instances, all the instances are used if not set.
+	Filter map[string]interface{} `json:"filter,omitempty" bson:"filter,omitempty"`
+	// GroupBy the fields to group the instances, at most two fields.
+	GroupBy []string `json:"group_by,omitempty" bson:"group_by,omitempty"`
+	// Aggregation how the instances of a group are aggregated, default is count.
+	Aggregation ChartAggregation `json:"aggregation,omitempty" bson:"aggregation,omitempty"`
+	// AggregateField the numeric field to sum or average.
+	AggregateField string `json:"aggregate_field,omitempty" bson:"aggregate_field,omitempty"`
+}
+
+// ChartAggregation the aggregation of the aggregation chart
+type ChartAggregation string
+
+const (
+	ChartAggregationCount ChartAggregation = "count"
+	ChartAggregationSum   ChartAggregation = "sum"
+	ChartAggregationAvg   ChartAggregation = "avg"
+)
+
+// ChartMaxGroupByFields the max number of the fields the aggregation chart can be grouped by.
+const ChartMaxGroupByFields = 2
+
+// ValidateAggregation validates the aggregation chart's options, returns the invalid field and the reason.
+func (c *ChartConfig) ValidateAggregation() (string, error) {
+	if c.ObjID == "" {
+		return common.BKObjIDField, errors.New("model is not set")
+	}
+
+	if len(c.GroupBy) > ChartMaxGroupByFields {
+		return "group_by", fmt.Errorf("at most %d group by fields are allowed", ChartMaxGroupByFields)
+	}
+	for idx, field := range c.GroupBy {
+		if field == "" {
+			return "group_by", errors.New("group by field is empty")
+		}
+		if idx > 0 && field == c.GroupBy[0] {
+			return "group_by", errors.New("group by fields are duplicated")
+		}
+	}
+
+	switch c.Aggregation {
+	case "", ChartAggregationCount:
+	case ChartAggregationSum, ChartAggregationAvg:
+		if c.AggregateField == "" {
+			return "aggregate_field", fmt.Errorf("aggregate field is needed by %s", c.Aggregation)
+		}
+	default:
+		return "aggregation", fmt.Errorf("aggregation %s is invalid", c.Aggregation)
+	}
+
+	if len(c.Filter) == 0 {
+		return "", nil
+	}
+	rule, key, err := querybuilder.ParseRule(c.Filter)
+	if err != nil {
+		return "filter." + key, err
+	}
+	if key, err := rule.Validate(); err != nil {
+		return "filter." + key, err
+	}
+	return "", nil
+}
+
+// ValidateAggregationFields validates the group by fields and the aggregate field with the model's attributes,
+// the aggregate field must be numeric.
+func (c *ChartConfig) ValidateAggregationFields(attributes []Attribute) (string, error) {
+	attrTypes := make(map[string]string)
+	for _, attr := range attributes {
+		attrTypes[attr.PropertyID] = attr.PropertyType
+	}
+
+	for _, field := range c.GroupBy {
+		if _, exist := attrTypes[field]; !exist {
+			return "group_by", fmt.Errorf("group by field %s is not an attribute of %s", field, c.ObjID)
+		}
+	}
+
+	if c.Aggregation != ChartAggregationSum && c.Aggregation != ChartAggregationAvg {
+		return "", nil
+	}
+	switch attrTypes[c.AggregateField] {
+	case common.FieldTypeInt, common.FieldTypeFloat:
+		return "", nil
+	default:
+		return "aggregate_field", fmt.Errorf("aggregate field %s is not a numeric attribute of %s",
+			c.AggregateField, c.ObjID)
+	}
+}
+
+// ChartAggregationData the data of the aggregation chart, each item is a group of the instances.
+type ChartAggregationData struct {
+	GroupBy     []string               `json:"group_by"`
+	Aggregation ChartAggregation       `json:"aggregation"`
+	Items       []ChartAggregationItem `json:"items"`
+}
+
+// ChartAggregationItem the aggregated value of a group, the keys are the values of the group by fields in order,
+// the enum values are displayed by their names.
+type ChartAggregationItem struct {
+	Keys  []string `json:"keys"`
+	Count int64    `json:"count"`
+	// Value the aggregated value, it's the same as the count if aggregated by count.
+	Value float64 `json:"value"`
+}
+
+type ChartAggregationResponse struct {
+	BaseResp `json:",inline"`
+	Data     ChartAggregationData `json:"data"`
 }
 
 type ChartPosition struct {
@@ -124,6 +238,15 @@ type CommonSearchChart struct {
 	Info  ChartConfig `json:"info"`
 }
 
+// SearchChartsCommon is the response of searching all the chart configs which match the condition
+type SearchChartsCommon struct {
+	BaseResp `json:",inline"`
+	Data     struct {
+		Count uint64        `json:"count"`
+		Info  []ChartConfig `json:"info"`
+	} `json:"data"`
+}
+
 type SearchChartConfig struct {
 	Count uint64                   `json:"count"`
 	Info  map[string][]ChartConfig `json:"info"`
@@ -0,0 +1,55 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+)
+
+func TestChartConfigValidateAggregation(t *testing.T) {
+	attributes := []Attribute{
+		{PropertyID: "region", PropertyType: common.FieldTypeEnum},
+		{PropertyID: "name", PropertyType: common.FieldTypeSingleChar},
+		{PropertyID: "ports", PropertyType: common.FieldTypeInt},
+	}
+	tests := []struct {
+		name  string
+		chart ChartConfig
+		field string
+	}{
+		{"count", ChartConfig{ObjID: "switch", GroupBy: []string{"region", "name"}}, ""},
+		{"sum", ChartConfig{ObjID: "switch", Aggregation: ChartAggregationSum, AggregateField: "ports"}, ""},
+		{"no model", ChartConfig{GroupBy: []string{"region"}}, common.BKObjIDField},
+		{"too many group by", ChartConfig{ObjID: "switch", GroupBy: []string{"region", "name", "ports"}}, "group_by"},
+		{"duplicated group by", ChartConfig{ObjID: "switch", GroupBy: []string{"region", "region"}}, "group_by"},
+		{"unknown group by", ChartConfig{ObjID: "switch", GroupBy: []string{"vendor"}}, "group_by"},
+		{"invalid aggregation", ChartConfig{ObjID: "switch", Aggregation: "max"}, "aggregation"},
+		{"no aggregate field", ChartConfig{ObjID: "switch", Aggregation: ChartAggregationAvg}, "aggregate_field"},
+		{"non-numeric aggregate field",
+			ChartConfig{ObjID: "switch", Aggregation: ChartAggregationAvg, AggregateField: "name"}, "aggregate_field"},
+		{"invalid filter", ChartConfig{ObjID: "switch", Filter: map[string]interface{}{"field": "name"}}, "filter."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, err := tt.chart.ValidateAggregation()
+			if err == nil {
+				field, err = tt.chart.ValidateAggregationFields(attributes)
+			}
+			if (err != nil) != (tt.field != "") || (tt.field != "" && field[:len(tt.field)] != tt.field) {
+				t.Errorf("validate %s got field: %s, err: %v, want field: %s", tt.name, field, err, tt.field)
+			}
+		})
+	}
+}
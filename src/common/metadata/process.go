@@ -545,6 +545,10 @@ const (
 	BindAll       SocketBindType = "2"
 	BindInnerIP   SocketBindType = "3"
 	BindOuterIP   SocketBindType = "4"
+	// BindLocalHostV6 bind the ipv6 loopback address ::1
+	BindLocalHostV6 SocketBindType = "5"
+	// BindAllV6 bind all the ipv6 addresses ::
+	BindAllV6 SocketBindType = "6"
 )
 
 func (p *SocketBindType) NeedIPFromHost() bool {
@@ -572,6 +576,10 @@ func (p *SocketBindType) IP(host map[string]interface{}) string {
 		return "127.0.0.1"
 	case BindAll:
 		return "0.0.0.0"
+	case BindLocalHostV6:
+		return "::1"
+	case BindAllV6:
+		return "::"
 	case BindInnerIP:
 		if host == nil {
 			return ""
@@ -607,6 +615,10 @@ func (p *SocketBindType) String() string {
 		return "127.0.0.1"
 	case BindAll:
 		return "0.0.0.0"
+	case BindLocalHostV6:
+		return "::1"
+	case BindAllV6:
+		return "::"
 	case BindInnerIP:
 		return "第一内网IP"
 	case BindOuterIP:
@@ -617,7 +629,7 @@ func (p *SocketBindType) String() string {
 }
 
 func (p SocketBindType) Validate() error {
-	validValues := []SocketBindType{BindLocalHost, BindAll, BindInnerIP, BindOuterIP}
+	validValues := []SocketBindType{BindLocalHost, BindAll, BindInnerIP, BindOuterIP, BindLocalHostV6, BindAllV6}
 	if util.InArray(p, validValues) == false {
 		return fmt.Errorf("invalid socket bind type, value: %s, available values: %+v", p, validValues)
 	}
@@ -1621,41 +1633,13 @@ func (ti *PropertyPort) Validate() error {
 		if len(*ti.Value) == 0 {
 			return nil
 		}
-		if matched := ProcessPortFormat.MatchString(*ti.Value); matched == false {
-			return fmt.Errorf("port format invalid")
-		}
-		var tmpPortArr []propertyPortItem
-		strPortItemArr := strings.Split(*ti.Value, ",")
-		for _, strPortItem := range strPortItemArr {
-			portArr := strings.Split(strPortItem, "-")
-			var start, end int64
-			start, _ = util.GetInt64ByInterface(portArr[0])
-			if len(portArr) > 1 {
-				end, _ = util.GetInt64ByInterface(portArr[1])
-			} else {
-				end = start
-			}
-			if start > end {
-				return fmt.Errorf("port format invalid, start > end")
-			}
-			for _, tmpItem := range tmpPortArr {
-				if !(end < tmpItem.start || start > tmpItem.end) {
-					return fmt.Errorf("port format invalid,  port duplicate:" + strPortItem)
-				}
-			}
-			tmpPortArr = append(tmpPortArr, propertyPortItem{start: start, end: end})
+		if _, err := ParseBindPorts(*ti.Value); err != nil {
+			return err
 		}
-
 	}
 	return nil
 }
 
-// propertyPortItem 记录进程端口起始范围
-type propertyPortItem struct {
-	start int64
-	end   int64
-}
-
 type PropertyBindIP struct {
 	Value          *SocketBindType `field:"value" json:"value" bson:"value"`
 	AsDefaultValue *bool           `field:"as_default_value" json:"as_default_value" bson:"as_default_value"`
@@ -0,0 +1,359 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+)
+
+// BindPortRange is a closed range of ports that a process listens on, start equals to end for a single port.
+type BindPortRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// Contains checks if the port is in the range.
+func (r BindPortRange) Contains(port int64) bool {
+	return port >= r.Start && port <= r.End
+}
+
+// Overlap checks if the two ranges have common ports.
+func (r BindPortRange) Overlap(other BindPortRange) bool {
+	return r.Start <= other.End && other.Start <= r.End
+}
+
+func (r BindPortRange) String() string {
+	if r.Start == r.End {
+		return strconv.FormatInt(r.Start, 10)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// ParseBindPorts parses the port value of bind info, the value is a comma separated list of ports or port
+// ranges, like "80", "8000-8100" or "80,443,8000-8100". the ranges should not overlap with each other.
+func ParseBindPorts(value string) ([]BindPortRange, error) {
+	if !ProcessPortFormat.MatchString(value) {
+		return nil, fmt.Errorf("port format invalid")
+	}
+
+	ranges := make([]BindPortRange, 0)
+	for _, item := range strings.Split(value, ",") {
+		portArr := strings.Split(item, "-")
+		start, _ := strconv.ParseInt(portArr[0], 10, 64)
+		end := start
+		if len(portArr) > 1 {
+			end, _ = strconv.ParseInt(portArr[1], 10, 64)
+		}
+		if start > end {
+			return nil, fmt.Errorf("port format invalid, start > end")
+		}
+
+		portRange := BindPortRange{Start: start, End: end}
+		for _, exist := range ranges {
+			if exist.Overlap(portRange) {
+				return nil, fmt.Errorf("port format invalid, port duplicate: %s", item)
+			}
+		}
+		ranges = append(ranges, portRange)
+	}
+	return ranges, nil
+}
+
+// ValidateBindIP checks the bind ip of process instance, which should be an ipv4 or ipv6 address.
+func ValidateBindIP(ip string) error {
+	if net.ParseIP(ip) == nil {
+		return fmt.Errorf("bind ip %s is not a valid ipv4 or ipv6 address", ip)
+	}
+	return nil
+}
+
+// Validate validates the ip and port of the process instance's bind info row.
+func (pbi *ProcBindInfo) Validate() (string, error) {
+	if pbi.Std == nil {
+		return "", nil
+	}
+
+	if pbi.Std.IP != nil && len(*pbi.Std.IP) != 0 {
+		if err := ValidateBindIP(*pbi.Std.IP); err != nil {
+			return common.BKIP, err
+		}
+	}
+
+	if pbi.Std.Port != nil && len(*pbi.Std.Port) != 0 {
+		if _, err := ParseBindPorts(*pbi.Std.Port); err != nil {
+			return common.BKPort, err
+		}
+	}
+
+	if pbi.Std.Protocol != nil && len(*pbi.Std.Protocol) != 0 {
+		if err := ProtocolType(*pbi.Std.Protocol).Validate(); err != nil {
+			return common.BKProtocol, err
+		}
+	}
+	return "", nil
+}
+
+// ValidateBindInfo validates all the bind info rows of the process.
+func (p *Process) ValidateBindInfo() (string, error) {
+	for idx := range p.BindInfo {
+		if field, err := p.BindInfo[idx].Validate(); err != nil {
+			return fmt.Sprintf("%s[%d].%s", common.BKProcBindInfo, idx, field), err
+		}
+	}
+	return "", nil
+}
+
+// ProcessBindItem is an enabled bind info row of a process with parsed ports.
+type ProcessBindItem struct {
+	ProcessID   int64           `json:"bk_process_id"`
+	ProcessName string          `json:"bk_process_name"`
+	IP          string          `json:"ip"`
+	Protocol    ProtocolType    `json:"protocol"`
+	Ports       []BindPortRange `json:"ports"`
+}
+
+// GetBindItems returns the process's enabled bind info rows, rows without ip, port or protocol
+// can not be listened on so that they are ignored, so are the invalid rows.
+func (p *Process) GetBindItems() []ProcessBindItem {
+	items := make([]ProcessBindItem, 0)
+	for _, row := range p.BindInfo {
+		if row.Std == nil || row.Std.IP == nil || row.Std.Port == nil || row.Std.Protocol == nil {
+			continue
+		}
+		if len(*row.Std.IP) == 0 || len(*row.Std.Port) == 0 {
+			continue
+		}
+		if row.Std.Enable != nil && !*row.Std.Enable {
+			continue
+		}
+
+		ports, err := ParseBindPorts(*row.Std.Port)
+		if err != nil {
+			continue
+		}
+
+		item := ProcessBindItem{
+			ProcessID: p.ProcessID,
+			IP:        *row.Std.IP,
+			Protocol:  ProtocolType(*row.Std.Protocol),
+			Ports:     ports,
+		}
+		if p.ProcessName != nil {
+			item.ProcessName = *p.ProcessName
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// ListenOn checks if the bind item listens on the port with the protocol, empty protocol matches all protocols.
+func (b ProcessBindItem) ListenOn(port int64, protocol ProtocolType) bool {
+	if len(protocol) != 0 && protocol != b.Protocol {
+		return false
+	}
+	for _, r := range b.Ports {
+		if r.Contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConflictWith checks if the two bind items listen on the same address, returns the conflict ports.
+func (b ProcessBindItem) ConflictWith(other ProcessBindItem) ([]BindPortRange, bool) {
+	if b.Protocol != other.Protocol || !bindIPConflict(b.IP, other.IP) {
+		return nil, false
+	}
+
+	conflicts := make([]BindPortRange, 0)
+	for _, r := range b.Ports {
+		for _, o := range other.Ports {
+			if !r.Overlap(o) {
+				continue
+			}
+			conflict := BindPortRange{Start: r.Start, End: r.End}
+			if o.Start > conflict.Start {
+				conflict.Start = o.Start
+			}
+			if o.End < conflict.End {
+				conflict.End = o.End
+			}
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts, len(conflicts) > 0
+}
+
+// bindIPConflict checks if the two bind ips are overlapped. the wildcard address 0.0.0.0 conflicts with
+// all the ipv4 addresses, and :: conflicts with all the addresses since sockets are dual stack by default.
+func bindIPConflict(ip, other string) bool {
+	a, b := net.ParseIP(ip), net.ParseIP(other)
+	if a == nil || b == nil {
+		return ip == other
+	}
+
+	if a.Equal(b) {
+		return true
+	}
+
+	if a.Equal(net.IPv6unspecified) || b.Equal(net.IPv6unspecified) {
+		return true
+	}
+
+	isV4 := func(ip net.IP) bool { return ip.To4() != nil }
+	if a.Equal(net.IPv4zero) {
+		return isV4(b)
+	}
+	if b.Equal(net.IPv4zero) {
+		return isV4(a)
+	}
+	return false
+}
+
+// ProcessBindConflict is a conflict between two processes on the same host which listen on the same address.
+type ProcessBindConflict struct {
+	HostID            int64           `json:"bk_host_id"`
+	ProcessID         int64           `json:"bk_process_id"`
+	ConflictProcessID int64           `json:"conflict_process_id"`
+	IP                string          `json:"ip"`
+	ConflictIP        string          `json:"conflict_ip"`
+	Protocol          ProtocolType    `json:"protocol"`
+	Ports             []BindPortRange `json:"ports"`
+}
+
+func (c ProcessBindConflict) String() string {
+	ports := make([]string, len(c.Ports))
+	for idx, port := range c.Ports {
+		ports[idx] = port.String()
+	}
+	return fmt.Sprintf("host %d process %d(%s) and process %d(%s) both listen on %s port %s", c.HostID,
+		c.ProcessID, c.IP, c.ConflictProcessID, c.ConflictIP, c.Protocol.String(), strings.Join(ports, ","))
+}
+
+// FindProcessBindConflicts finds the bind conflicts between the processes on the same host.
+// the bind info rows of a process are not checked against each other.
+func FindProcessBindConflicts(hostID int64, processes []Process) []ProcessBindConflict {
+	return findProcessBindConflicts(hostID, processes, len(processes))
+}
+
+// FindPendingProcessBindConflicts finds the bind conflicts on the host which involve the pending processes.
+// the pending processes are the ones to be written, they replace the existing processes with the same id,
+// the conflicts between the existing processes are not reported since they are not caused by the pending ones.
+func FindPendingProcessBindConflicts(hostID int64, existing, pending []Process) []ProcessBindConflict {
+	pendingIDs := make(map[int64]bool)
+	for _, process := range pending {
+		if process.ProcessID != 0 {
+			pendingIDs[process.ProcessID] = true
+		}
+	}
+
+	processes := make([]Process, 0, len(pending)+len(existing))
+	processes = append(processes, pending...)
+	for _, process := range existing {
+		if !pendingIDs[process.ProcessID] {
+			processes = append(processes, process)
+		}
+	}
+	return findProcessBindConflicts(hostID, processes, len(pending))
+}
+
+// findProcessBindConflicts finds the bind conflicts between the processes, only the pairs which contain
+// at least one of the first checkCount processes are checked.
+func findProcessBindConflicts(hostID int64, processes []Process, checkCount int) []ProcessBindConflict {
+	conflicts := make([]ProcessBindConflict, 0)
+	items := make([][]ProcessBindItem, len(processes))
+	for idx := range processes {
+		items[idx] = processes[idx].GetBindItems()
+	}
+
+	for i := 0; i < checkCount; i++ {
+		for j := i + 1; j < len(items); j++ {
+			for _, item := range items[i] {
+				for _, other := range items[j] {
+					ports, conflict := item.ConflictWith(other)
+					if !conflict {
+						continue
+					}
+					conflicts = append(conflicts, ProcessBindConflict{
+						HostID:            hostID,
+						ProcessID:         item.ProcessID,
+						ConflictProcessID: other.ProcessID,
+						IP:                item.IP,
+						ConflictIP:        other.IP,
+						Protocol:          item.Protocol,
+						Ports:             ports,
+					})
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// FindProcessByPortOption is the option to find the processes which listen on the port in a business.
+type FindProcessByPortOption struct {
+	BizID    int64        `json:"bk_biz_id"`
+	Port     int64        `json:"port"`
+	Protocol ProtocolType `json:"protocol"`
+	IP       string       `json:"ip"`
+}
+
+// Validate validates the find process by port option.
+func (o *FindProcessByPortOption) Validate() (string, error) {
+	if o.BizID <= 0 {
+		return common.BKAppIDField, fmt.Errorf("invalid bk_biz_id %d", o.BizID)
+	}
+	if o.Port <= 0 || o.Port > 65535 {
+		return "port", fmt.Errorf("invalid port %d", o.Port)
+	}
+	if len(o.Protocol) != 0 {
+		if err := o.Protocol.Validate(); err != nil {
+			return "protocol", err
+		}
+	}
+	if len(o.IP) != 0 {
+		if err := ValidateBindIP(o.IP); err != nil {
+			return "ip", err
+		}
+	}
+	return "", nil
+}
+
+// Match checks if the bind item listens on the port, the ip matches if the bind item listens on it
+// either directly or through a wildcard address.
+func (o *FindProcessByPortOption) Match(item ProcessBindItem) bool {
+	if !item.ListenOn(o.Port, o.Protocol) {
+		return false
+	}
+	if len(o.IP) != 0 && !bindIPConflict(item.IP, o.IP) {
+		return false
+	}
+	return true
+}
+
+// ProcessListenInfo is a process which listens on the port.
+type ProcessListenInfo struct {
+	BizID             int64           `json:"bk_biz_id"`
+	HostID            int64           `json:"bk_host_id"`
+	ServiceInstanceID int64           `json:"service_instance_id"`
+	ProcessID         int64           `json:"bk_process_id"`
+	ProcessName       string          `json:"bk_process_name"`
+	IP                string          `json:"ip"`
+	Protocol          ProtocolType    `json:"protocol"`
+	Ports             []BindPortRange `json:"ports"`
+}
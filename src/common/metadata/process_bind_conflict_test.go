@@ -0,0 +1,167 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestProcess(id int64, rows ...[3]string) Process {
+	bindInfo := make([]ProcBindInfo, 0)
+	for _, row := range rows {
+		ip, port, protocol := row[0], row[1], row[2]
+		bindInfo = append(bindInfo, ProcBindInfo{Std: &stdProcBindInfo{IP: &ip, Port: &port, Protocol: &protocol}})
+	}
+	return Process{ProcessID: id, BindInfo: bindInfo}
+}
+
+func TestParseBindPorts(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []BindPortRange
+		wantErr bool
+	}{
+		{"80", []BindPortRange{{80, 80}}, false},
+		{"8000-8100", []BindPortRange{{8000, 8100}}, false},
+		{"80,443,8000-8100", []BindPortRange{{80, 80}, {443, 443}, {8000, 8100}}, false},
+		{"8100-8000", nil, true},
+		{"80,80", nil, true},
+		{"8000-8100,8050", nil, true},
+		{"0", nil, true},
+		{"65536", nil, true},
+		{"80,", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseBindPorts(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBindPorts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBindPorts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindIPConflict(t *testing.T) {
+	tests := []struct {
+		ip, other string
+		want      bool
+	}{
+		{"127.0.0.1", "127.0.0.1", true},
+		{"127.0.0.1", "10.0.0.1", false},
+		{"0.0.0.0", "10.0.0.1", true},
+		{"10.0.0.1", "0.0.0.0", true},
+		{"0.0.0.0", "::1", false},
+		{"::", "10.0.0.1", true},
+		{"::1", "::", true},
+		{"::1", "0:0:0:0:0:0:0:1", true},
+		{"fe80::1", "fe80::2", false},
+	}
+	for _, tt := range tests {
+		if got := bindIPConflict(tt.ip, tt.other); got != tt.want {
+			t.Errorf("bindIPConflict(%s, %s) = %v, want %v", tt.ip, tt.other, got, tt.want)
+		}
+	}
+}
+
+func TestFindProcessBindConflicts(t *testing.T) {
+	processes := []Process{
+		newTestProcess(1, [3]string{"0.0.0.0", "80,8000-8100", "1"}),
+		newTestProcess(2, [3]string{"10.0.0.1", "8080", "1"}, [3]string{"10.0.0.1", "8080", "2"}),
+		newTestProcess(3, [3]string{"::1", "80", "1"}),
+		newTestProcess(4, [3]string{"10.0.0.2", "9000", "1"}),
+	}
+
+	conflicts := FindProcessBindConflicts(1, processes)
+	if len(conflicts) != 1 {
+		t.Fatalf("FindProcessBindConflicts() got %d conflicts, want 1, conflicts: %v", len(conflicts), conflicts)
+	}
+	want := ProcessBindConflict{
+		HostID:            1,
+		ProcessID:         1,
+		ConflictProcessID: 2,
+		IP:                "0.0.0.0",
+		ConflictIP:        "10.0.0.1",
+		Protocol:          ProtocolTypeTCP,
+		Ports:             []BindPortRange{{8080, 8080}},
+	}
+	if !reflect.DeepEqual(conflicts[0], want) {
+		t.Errorf("FindProcessBindConflicts() = %v, want %v", conflicts[0], want)
+	}
+
+	// rows of the same process do not conflict with each other
+	if conflicts := FindProcessBindConflicts(1, processes[1:2]); len(conflicts) != 0 {
+		t.Errorf("FindProcessBindConflicts() got unexpected conflicts %v", conflicts)
+	}
+}
+
+func TestFindPendingProcessBindConflicts(t *testing.T) {
+	existing := []Process{
+		newTestProcess(1, [3]string{"0.0.0.0", "80", "1"}),
+		newTestProcess(2, [3]string{"10.0.0.1", "80", "1"}),
+		newTestProcess(3, [3]string{"10.0.0.1", "9000", "1"}),
+	}
+
+	// the conflict between the existing process 1 and 2 is not caused by the pending processes
+	pending := []Process{newTestProcess(3, [3]string{"10.0.0.2", "9000", "1"})}
+	if conflicts := FindPendingProcessBindConflicts(1, existing, pending); len(conflicts) != 0 {
+		t.Errorf("FindPendingProcessBindConflicts() got unexpected conflicts %v", conflicts)
+	}
+
+	// the pending process replaces the existing one with the same id, and the new process without id
+	// conflicts with the updated one
+	pending = []Process{
+		newTestProcess(3, [3]string{"10.0.0.2", "8080", "1"}),
+		newTestProcess(0, [3]string{"10.0.0.2", "8080", "1"}),
+	}
+	conflicts := FindPendingProcessBindConflicts(1, existing, pending)
+	if len(conflicts) != 1 {
+		t.Fatalf("FindPendingProcessBindConflicts() got %d conflicts, want 1, conflicts: %v", len(conflicts), conflicts)
+	}
+	if conflicts[0].ProcessID != 3 || conflicts[0].ConflictProcessID != 0 {
+		t.Errorf("FindPendingProcessBindConflicts() = %v, want conflict between process 3 and 0", conflicts[0])
+	}
+
+	pending = []Process{newTestProcess(0, [3]string{"10.0.0.1", "80", "1"})}
+	if conflicts := FindPendingProcessBindConflicts(1, existing, pending); len(conflicts) != 2 {
+		t.Errorf("FindPendingProcessBindConflicts() got %d conflicts, want 2, conflicts: %v", len(conflicts), conflicts)
+	}
+}
+
+func TestFindProcessByPortOptionMatch(t *testing.T) {
+	process := newTestProcess(1, [3]string{"0.0.0.0", "8000-8100", "1"})
+	items := process.GetBindItems()
+	if len(items) != 1 {
+		t.Fatalf("GetBindItems() got %d items, want 1", len(items))
+	}
+
+	tests := []struct {
+		option FindProcessByPortOption
+		want   bool
+	}{
+		{FindProcessByPortOption{BizID: 1, Port: 8050}, true},
+		{FindProcessByPortOption{BizID: 1, Port: 8050, Protocol: ProtocolTypeTCP}, true},
+		{FindProcessByPortOption{BizID: 1, Port: 8050, Protocol: ProtocolTypeUDP}, false},
+		{FindProcessByPortOption{BizID: 1, Port: 8050, IP: "10.0.0.1"}, true},
+		{FindProcessByPortOption{BizID: 1, Port: 8050, IP: "::1"}, false},
+		{FindProcessByPortOption{BizID: 1, Port: 80}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.option.Match(items[0]); got != tt.want {
+			t.Errorf("Match() with option %+v = %v, want %v", tt.option, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"errors"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+const (
+	// SnapshotDateLayout the layout of the snapshot's date, a snapshot is taken at most once a day.
+	SnapshotDateLayout = "2006-01-02"
+	// SnapshotDateField the field of the snapshot's date.
+	SnapshotDateField = "date"
+	// SnapshotChunkField the field of the chunk's index in the snapshot.
+	SnapshotChunkField = "chunk"
+	// SnapshotBaseDateField the field of the date of the base snapshot which the instance snapshot is diffed from.
+	SnapshotBaseDateField = "base_date"
+)
+
+// TopoSnapshot is the point-in-time snapshot of a business's topology, it only keeps the ids, names and relations
+// of the sets, modules and hosts, so that it can be stored compactly.
+type TopoSnapshot struct {
+	BizID      int64          `json:"bk_biz_id" bson:"bk_biz_id"`
+	BizName    string         `json:"bk_biz_name" bson:"bk_biz_name"`
+	Date       string         `json:"date" bson:"date"`
+	OwnerID    string         `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Sets       []SnapshotNode `json:"sets" bson:"sets"`
+	Modules    []SnapshotNode `json:"modules" bson:"modules"`
+	Hosts      []SnapshotHost `json:"hosts" bson:"hosts"`
+	CreateTime time.Time      `json:"create_time" bson:"create_time"`
+}
+
+// TopoSnapshotChunk is a chunk of the stored topology snapshot, a snapshot is stored as several chunks so that
+// the snapshot of a large business does not exceed the document size limit. the first chunk keeps the count of
+// the chunks and is written after the others, so that a snapshot can only be read after all its chunks are written.
+type TopoSnapshotChunk struct {
+	BizID      int64          `json:"bk_biz_id" bson:"bk_biz_id"`
+	BizName    string         `json:"bk_biz_name" bson:"bk_biz_name"`
+	Date       string         `json:"date" bson:"date"`
+	OwnerID    string         `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Chunk      int            `json:"chunk" bson:"chunk"`
+	ChunkCount int            `json:"chunk_count" bson:"chunk_count"`
+	Sets       []SnapshotNode `json:"sets" bson:"sets"`
+	Modules    []SnapshotNode `json:"modules" bson:"modules"`
+	Hosts      []SnapshotHost `json:"hosts" bson:"hosts"`
+	CreateTime time.Time      `json:"create_time" bson:"create_time"`
+}
+
+// SnapshotNode is a set or module of the topology snapshot, the parent of a module is it's set,
+// and the parent of a set is the business or the custom mainline instance.
+type SnapshotNode struct {
+	ID       int64  `json:"id" bson:"id"`
+	Name     string `json:"name" bson:"name"`
+	ParentID int64  `json:"parent_id" bson:"parent_id"`
+}
+
+// SnapshotHost is a host of the topology snapshot and the modules it belongs to.
+type SnapshotHost struct {
+	ID        int64   `json:"id" bson:"id"`
+	InnerIP   string  `json:"ip" bson:"ip"`
+	ModuleIDs []int64 `json:"modules" bson:"modules"`
+}
+
+// InstSnapshot is the point-in-time snapshot of a model's instances with their attributes.
+type InstSnapshot struct {
+	ObjID      string         `json:"bk_obj_id" bson:"bk_obj_id"`
+	Date       string         `json:"date" bson:"date"`
+	OwnerID    string         `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Instances  []SnapshotInst `json:"instances" bson:"instances"`
+	CreateTime time.Time      `json:"create_time" bson:"create_time"`
+}
+
+// InstSnapshotChunk is a chunk of the stored instance snapshot, it's stored like the TopoSnapshotChunk.
+// Only the base snapshot stores all the instances with their attributes, the snapshots taken after it store
+// the diff since the previous snapshot: the added instances, the changed attributes of the changed instances
+// and the ids of the removed instances. A snapshot is rebuilt from its base and all the diffs up to it.
+type InstSnapshotChunk struct {
+	ObjID   string `json:"bk_obj_id" bson:"bk_obj_id"`
+	Date    string `json:"date" bson:"date"`
+	OwnerID string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	// BaseDate is the date of the base snapshot, it's the same as the Date for the base snapshot.
+	BaseDate   string         `json:"base_date" bson:"base_date"`
+	Chunk      int            `json:"chunk" bson:"chunk"`
+	ChunkCount int            `json:"chunk_count" bson:"chunk_count"`
+	Instances  []SnapshotInst `json:"instances" bson:"instances"`
+	// Removed is the ids of the instances removed since the previous snapshot, it's only used by the diff.
+	Removed    []int64   `json:"removed,omitempty" bson:"removed,omitempty"`
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+}
+
+// IsBase returns whether the chunk belongs to a base snapshot.
+func (c *InstSnapshotChunk) IsBase() bool {
+	return c.BaseDate == c.Date
+}
+
+// SnapshotInst is an instance of the model's instance snapshot, the checksum of the attributes is used to
+// find the instances changed between two snapshots quickly.
+type SnapshotInst struct {
+	ID       int64         `json:"id" bson:"id"`
+	Name     string        `json:"name" bson:"name"`
+	Checksum string        `json:"checksum" bson:"checksum"`
+	Data     mapstr.MapStr `json:"data" bson:"data"`
+	// Unset is the attributes removed since the previous snapshot, it's only used by the diff snapshot
+	// whose Data only has the changed attributes.
+	Unset []string `json:"unset,omitempty" bson:"unset,omitempty"`
+}
+
+// TakeSnapshotOption is the option to take the snapshots of the topology and the models' instances.
+type TakeSnapshotOption struct {
+	// Objects the models whose instances are also taken snapshots besides the business topology.
+	Objects []string `json:"bk_obj_ids"`
+	// KeepDays the snapshots older than it are removed.
+	KeepDays int `json:"keep_days"`
+}
+
+// TakeTopoSnapshotOption is the option to take the topology snapshot of a business.
+type TakeTopoSnapshotOption struct {
+	BizID int64 `json:"bk_biz_id"`
+}
+
+// TakeInstSnapshotOption is the option to take the instance snapshot of a model.
+type TakeInstSnapshotOption struct {
+	ObjID string `json:"bk_obj_id"`
+}
+
+// ClearSnapshotOption is the option to remove the snapshots older than the keep days.
+type ClearSnapshotOption struct {
+	KeepDays int `json:"keep_days"`
+}
+
+// SearchTopoSnapshotOption searches the business's topology on the date, the latest snapshot taken on or before
+// the date is returned.
+type SearchTopoSnapshotOption struct {
+	BizID int64  `json:"bk_biz_id"`
+	Date  string `json:"date"`
+}
+
+// Validate validates the search topology snapshot option, returns the invalid field and the reason.
+func (o *SearchTopoSnapshotOption) Validate() (string, error) {
+	if o.BizID <= 0 {
+		return common.BKAppIDField, errors.New("business id is invalid")
+	}
+	if _, err := time.Parse(SnapshotDateLayout, o.Date); err != nil {
+		return "date", err
+	}
+	return "", nil
+}
+
+// SearchInstSnapshotOption searches the model's instances on the date, the latest snapshot taken on or before
+// the date is returned.
+type SearchInstSnapshotOption struct {
+	ObjID string `json:"bk_obj_id"`
+	Date  string `json:"date"`
+}
+
+// Validate validates the search instance snapshot option, returns the invalid field and the reason.
+func (o *SearchInstSnapshotOption) Validate() (string, error) {
+	if o.ObjID == "" {
+		return common.BKObjIDField, errors.New("model is not set")
+	}
+	if _, err := time.Parse(SnapshotDateLayout, o.Date); err != nil {
+		return "date", err
+	}
+	return "", nil
+}
+
+// DiffTopoSnapshotOption diffs the business's topology between the two dates.
+type DiffTopoSnapshotOption struct {
+	BizID int64  `json:"bk_biz_id"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Validate validates the diff topology snapshot option, returns the invalid field and the reason.
+func (o *DiffTopoSnapshotOption) Validate() (string, error) {
+	if o.BizID <= 0 {
+		return common.BKAppIDField, errors.New("business id is invalid")
+	}
+	return validateSnapshotDateRange(o.From, o.To)
+}
+
+// DiffInstSnapshotOption diffs the model's instances between the two dates.
+type DiffInstSnapshotOption struct {
+	ObjID string `json:"bk_obj_id"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Validate validates the diff instance snapshot option, returns the invalid field and the reason.
+func (o *DiffInstSnapshotOption) Validate() (string, error) {
+	if o.ObjID == "" {
+		return common.BKObjIDField, errors.New("model is not set")
+	}
+	return validateSnapshotDateRange(o.From, o.To)
+}
+
+func validateSnapshotDateRange(from, to string) (string, error) {
+	fromDate, err := time.Parse(SnapshotDateLayout, from)
+	if err != nil {
+		return "from", err
+	}
+	toDate, err := time.Parse(SnapshotDateLayout, to)
+	if err != nil {
+		return "to", err
+	}
+	if fromDate.After(toDate) {
+		return "from", errors.New("from date is after to date")
+	}
+	return "", nil
+}
+
+// TopoSnapshotDiff is the difference of the business's topology between two snapshots.
+type TopoSnapshotDiff struct {
+	BizID int64 `json:"bk_biz_id"`
+	// From and To are the dates of the snapshots, they may be earlier than the requested ones
+	// if there is no snapshot taken on the requested dates.
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	Sets    SnapshotNodeDiff `json:"sets"`
+	Modules SnapshotNodeDiff `json:"modules"`
+	Hosts   SnapshotHostDiff `json:"hosts"`
+}
+
+// SnapshotNodeDiff is the difference of the sets or modules between two snapshots.
+type SnapshotNodeDiff struct {
+	Added   []SnapshotNode       `json:"added"`
+	Removed []SnapshotNode       `json:"removed"`
+	Changed []SnapshotNodeChange `json:"changed"`
+}
+
+// SnapshotNodeChange is a set or module renamed or moved between two snapshots.
+type SnapshotNodeChange struct {
+	Before SnapshotNode `json:"before"`
+	After  SnapshotNode `json:"after"`
+}
+
+// SnapshotHostDiff is the difference of the hosts between two snapshots.
+type SnapshotHostDiff struct {
+	Added   []SnapshotHost       `json:"added"`
+	Removed []SnapshotHost       `json:"removed"`
+	Changed []SnapshotHostChange `json:"changed"`
+}
+
+// SnapshotHostChange is a host whose ip or modules changed between two snapshots.
+type SnapshotHostChange struct {
+	Before SnapshotHost `json:"before"`
+	After  SnapshotHost `json:"after"`
+}
+
+// InstSnapshotDiff is the difference of the model's instances between two snapshots.
+type InstSnapshotDiff struct {
+	ObjID   string               `json:"bk_obj_id"`
+	From    string               `json:"from"`
+	To      string               `json:"to"`
+	Added   []SnapshotInst       `json:"added"`
+	Removed []SnapshotInst       `json:"removed"`
+	Changed []SnapshotInstChange `json:"changed"`
+}
+
+// SnapshotInstChange is an instance whose attributes changed between two snapshots.
+type SnapshotInstChange struct {
+	ID     int64                 `json:"id"`
+	Name   string                `json:"name"`
+	Fields []SnapshotFieldChange `json:"fields"`
+}
+
+// SnapshotFieldChange is the change of an attribute of the instance, the value is nil if the attribute
+// does not exist in the snapshot.
+type SnapshotFieldChange struct {
+	Field  string      `json:"bk_property_id"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type TopoSnapshotResponse struct {
+	BaseResp `json:",inline"`
+	Data     *TopoSnapshot `json:"data"`
+}
+
+type InstSnapshotResponse struct {
+	BaseResp `json:",inline"`
+	Data     *InstSnapshot `json:"data"`
+}
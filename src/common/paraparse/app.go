@@ -14,26 +14,57 @@ package params
 
 import (
 	"fmt"
+	"reflect"
 	"regexp"
 	"strings"
 
 	"configcenter/src/common"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/selector"
 )
 
 // common search struct
 type SearchParams struct {
-	Condition map[string]interface{} `json:"condition"`
-	Page      map[string]interface{} `json:"page,omitempty"`
-	Fields    []string               `json:"fields,omitempty"`
+	Condition     map[string]interface{} `json:"condition"`
+	Page          map[string]interface{} `json:"page,omitempty"`
+	Fields        []string               `json:"fields,omitempty"`
+	LabelSelector selector.Selectors     `json:"label_selector,omitempty"`
 }
 
+// MergeLabelSelector validates the label selectors and merges them into the search condition,
+// returns the invalid field of the label selector if validate failed.
+func (s *SearchParams) MergeLabelSelector() (string, error) {
+	if len(s.LabelSelector) == 0 {
+		return "", nil
+	}
+
+	if key, err := s.LabelSelector.Validate(); err != nil {
+		return "label_selector." + key, err
+	}
+
+	filter, err := s.LabelSelector.ToMgoFilter()
+	if err != nil {
+		return "label_selector", err
+	}
+
+	if len(s.Condition) == 0 {
+		s.Condition = filter
+		return "", nil
+	}
+	s.Condition = map[string]interface{}{
+		common.BKDBAND: []map[string]interface{}{s.Condition, filter},
+	}
+	return "", nil
+}
+
+// ParseCommonParams converts the condition items to the mongodb condition in output, the conditions on the
+// same field, such as the label selectors with the same key, are all kept by putting the later ones into $and.
 func ParseCommonParams(input []metadata.ConditionItem, output map[string]interface{}) error {
 	for _, i := range input {
 		switch i.Operator {
 		case common.BKDBEQ:
-			output[i.Field] = i.Value
+			addCondition(output, i.Field, i.Value)
 			/*if reflect.TypeOf(i.Value).Kind() == reflect.String {
 				output[i.Field] = SpecialCharChange(i.Value.(string))
 			} else {
@@ -44,7 +75,7 @@ func ParseCommonParams(input []metadata.ConditionItem, output map[string]interfa
 			regex[common.BKDBLIKE] = i.Value
 			// Case insensitivity to match upper and lower cases
 			regex[common.BKDBOPTIONS] = "i"
-			output[i.Field] = regex
+			addCondition(output, i.Field, regex)
 
 		case common.BKDBMULTIPLELike:
 			multi, ok := i.Value.([]interface{})
@@ -62,7 +93,7 @@ func ParseCommonParams(input []metadata.ConditionItem, output map[string]interfa
 			if len(fields) != 0 {
 				// only when the fields is none empty, then the fields is valid.
 				// a or operator can not have a empty value in mongodb.
-				output[common.BKDBOR] = fields
+				addCondition(output, common.BKDBOR, fields)
 			}
 		case common.BKDBIN:
 			d := make(map[string]interface{})
@@ -71,7 +102,7 @@ func ParseCommonParams(input []metadata.ConditionItem, output map[string]interfa
 			} else {
 				d[i.Operator] = i.Value
 			}
-			output[i.Field] = d
+			addCondition(output, i.Field, d)
 		default:
 			d := make(map[string]interface{})
 			if i.Value == nil {
@@ -81,12 +112,29 @@ func ParseCommonParams(input []metadata.ConditionItem, output map[string]interfa
 			} else {
 				d[i.Operator] = i.Value
 			}
-			output[i.Field] = d
+			addCondition(output, i.Field, d)
 		}
 	}
 	return nil
 }
 
+// addCondition sets the condition of the field in output, the condition is appended to $and if the field
+// already has one, so that it does not overwrite the earlier one.
+func addCondition(output map[string]interface{}, field string, cond interface{}) {
+	if _, exists := output[field]; !exists {
+		output[field] = cond
+		return
+	}
+
+	and := make([]interface{}, 0)
+	if existing := reflect.ValueOf(output[common.BKDBAND]); existing.Kind() == reflect.Slice {
+		for idx := 0; idx < existing.Len(); idx++ {
+			and = append(and, existing.Index(idx).Interface())
+		}
+	}
+	output[common.BKDBAND] = append(and, map[string]interface{}{field: cond})
+}
+
 func SpecialCharChange(targetStr string) string {
 
 	re := regexp.MustCompile("[.()\\\\|\\[\\]\\*{}\\^\\$\\?]")
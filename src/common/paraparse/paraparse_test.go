@@ -3,6 +3,9 @@ package params
 import (
 	"testing"
 
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/selector"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -99,3 +102,64 @@ func TestSpecialCharChange(t *testing.T) {
 	}
 
 }
+
+func TestMergeLabelSelector(t *testing.T) {
+	params := SearchParams{}
+	field, err := params.MergeLabelSelector()
+	require.NoError(t, err)
+	require.Empty(t, field)
+	require.Nil(t, params.Condition)
+
+	params = SearchParams{
+		LabelSelector: selector.Selectors{{Key: "env", Operator: selector.Equals, Values: []string{"prod"}}},
+	}
+	_, err = params.MergeLabelSelector()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"labels.env": "prod"}, params.Condition)
+
+	params = SearchParams{
+		Condition: map[string]interface{}{"bk_inst_name": "test"},
+		LabelSelector: selector.Selectors{
+			{Key: "env", Operator: selector.In, Values: []string{"prod", "staging"}},
+			{Key: "owner", Operator: selector.Exists},
+		},
+	}
+	_, err = params.MergeLabelSelector()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"$and": []map[string]interface{}{
+			{"bk_inst_name": "test"},
+			{"$and": []map[string]interface{}{
+				{"labels.env": map[string]interface{}{"$in": []string{"prod", "staging"}}},
+				{"labels.owner": map[string]interface{}{"$exists": true}},
+			}},
+		},
+	}, params.Condition)
+
+	params = SearchParams{
+		LabelSelector: selector.Selectors{{Key: "env", Operator: selector.In}},
+	}
+	field, err = params.MergeLabelSelector()
+	require.Error(t, err)
+	require.Equal(t, "label_selector.values", field)
+}
+
+func TestParseCommonParamsRepeatedLabel(t *testing.T) {
+	conditions, err := metadata.LabelSelectorConditions(selector.Selectors{
+		{Key: "env", Operator: selector.In, Values: []string{"prod", "staging"}},
+		{Key: "env", Operator: selector.NotEquals, Values: []string{"staging"}},
+		{Key: "env", Operator: selector.Exists},
+	})
+	require.NoError(t, err)
+
+	output := map[string]interface{}{"bk_host_innerip": "127.0.0.1"}
+	require.NoError(t, ParseCommonParams(conditions, output))
+	require.Equal(t, map[string]interface{}{
+		"bk_host_innerip": "127.0.0.1",
+		"labels.env":      map[string]interface{}{"$in": []string{"prod", "staging"}},
+		"$and": []interface{}{
+			map[string]interface{}{"labels.env": map[string]interface{}{"$ne": "staging"}},
+			map[string]interface{}{"labels.env": map[string]interface{}{"$exists": true}},
+		},
+	}, output)
+}
@@ -95,6 +95,10 @@ var (
 	// exist check
 	OperatorExist    = Operator("exist")
 	OperatorNotExist = Operator("not_exist")
+
+	// ip operator
+	OperatorCIDRContains    = Operator("cidr_contains")
+	OperatorNotCIDRContains = Operator("not_cidr_contains")
 )
 
 var SupportOperators = map[Operator]bool{
@@ -129,6 +133,9 @@ var SupportOperators = map[Operator]bool{
 
 	OperatorExist:    true,
 	OperatorNotExist: false,
+
+	OperatorCIDRContains:    true,
+	OperatorNotCIDRContains: true,
 }
 
 func (op Operator) Validate() error {
@@ -198,6 +205,8 @@ func (r AtomRule) validateValue() error {
 		return nil
 	case OperatorExist, OperatorNotExist:
 		return nil
+	case OperatorCIDRContains, OperatorNotCIDRContains:
+		return validateIPv4CIDRType(r.Value)
 	default:
 		return fmt.Errorf("unsupported operator: %s", r.Operator)
 	}
@@ -325,6 +334,24 @@ func (r AtomRule) ToMgo() (mgoFiler map[string]interface{}, key string, err erro
 		filter[r.Field] = map[string]interface{}{
 			common.BKDBExists: false,
 		}
+	case OperatorCIDRContains:
+		pattern, err := cidrToRegex(r.Value.(string))
+		if err != nil {
+			return nil, "value", err
+		}
+		filter[r.Field] = map[string]interface{}{
+			common.BKDBLIKE: pattern,
+		}
+	case OperatorNotCIDRContains:
+		pattern, err := cidrToRegex(r.Value.(string))
+		if err != nil {
+			return nil, "value", err
+		}
+		filter[r.Field] = map[string]interface{}{
+			common.BKDBNot: map[string]interface{}{
+				common.BKDBLIKE: pattern,
+			},
+		}
 	default:
 		return nil, "operator", fmt.Errorf("unsupported operator: %s", r.Operator)
 	}
@@ -419,3 +419,49 @@ func TestExceedMaxDeep(t *testing.T) {
 		assert.NotNil(t, err)
 	}
 }
+
+func TestCIDRContainsAtomRule(t *testing.T) {
+	cases := []struct {
+		cidr     string
+		matched  []string
+		excluded []string
+	}{
+		{"192.168.4.0/22", []string{"192.168.4.1", "192.168.7.255"}, []string{"192.168.8.1", "192.168.3.1", "10.168.4.1"}},
+		{"10.0.0.0/8", []string{"10.1.2.3", "10.255.0.1"}, []string{"11.0.0.1", "110.0.0.1"}},
+		{"172.16.1.5/32", []string{"172.16.1.5"}, []string{"172.16.1.50", "172.16.1.6"}},
+		{"0.0.0.0/0", []string{"1.2.3.4", "255.255.255.255"}, []string{"1.2.3", "1.2.3.256", "999.1.1.1"}},
+		{"10.0.0.0/24", []string{"10.0.0.1,10.0.0.2", "192.168.1.1,10.0.0.5", "172.16.0.1,10.0.0.255,192.168.1.1"},
+			[]string{"192.168.1.1,172.16.0.1", "110.0.0.1,10.0.1.1", "10.0.0.1000", "10.0.0.300,192.168.1.1"}},
+	}
+	for idx, c := range cases {
+		t.Logf("running cidr contains case %d, cidr: %s", idx, c.cidr)
+		rule := querybuilder.AtomRule{
+			Operator: querybuilder.OperatorCIDRContains,
+			Field:    "bk_host_innerip",
+			Value:    c.cidr,
+		}
+		filter, errKey, err := rule.ToMgo()
+		assert.Nil(t, err)
+		assert.Empty(t, errKey)
+
+		pattern := filter["bk_host_innerip"].(map[string]interface{})["$regex"].(string)
+		for _, ip := range c.matched {
+			assert.Regexp(t, pattern, ip)
+		}
+		for _, ip := range c.excluded {
+			assert.NotRegexp(t, pattern, ip)
+		}
+	}
+
+	invalidValues := []interface{}{"10.0.0.1", "2001:db8::/32", 1}
+	for _, value := range invalidValues {
+		rule := querybuilder.AtomRule{
+			Operator: querybuilder.OperatorNotCIDRContains,
+			Field:    "bk_host_innerip",
+			Value:    value,
+		}
+		errKey, err := rule.Validate()
+		assert.Equal(t, "value", errKey)
+		assert.NotNil(t, err)
+	}
+}
@@ -15,7 +15,10 @@ package querybuilder
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"configcenter/src/common/util"
@@ -89,6 +92,58 @@ func validateDatetimeStringType(value interface{}) error {
 	return nil
 }
 
+func validateIPv4CIDRType(value interface{}) error {
+	if err := validateStringType(value); err != nil {
+		return err
+	}
+	ip, _, err := net.ParseCIDR(value.(string))
+	if err != nil {
+		return err
+	}
+	if ip.To4() == nil {
+		return fmt.Errorf("only ipv4 cidr is supported, value: %s", value)
+	}
+	return nil
+}
+
+// ipv4OctetRegex matches a decimal ipv4 octet between 0 and 255
+const ipv4OctetRegex = `(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)`
+
+// cidrToRegex convert an ipv4 cidr to the regular expression which matches all the ipv4 addresses
+// inside it, so that ip fields stored as string can be filtered by mongodb. ip fields like bk_host_innerip
+// may contain multiple ips separated by comma, the value matches if any of the ips is inside the cidr.
+// for example, 192.168.4.0/22 is converted to (^|,)192\.168\.(4|5|6|7)\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(,|$)
+func cidrToRegex(cidr string) (string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	network := ipNet.IP.To4()
+	if network == nil {
+		return "", fmt.Errorf("only ipv4 cidr is supported, value: %s", cidr)
+	}
+	ones, _ := ipNet.Mask.Size()
+
+	octets := make([]string, 4)
+	for idx := range octets {
+		prefixBits := ones - idx*8
+		switch {
+		case prefixBits >= 8:
+			octets[idx] = strconv.Itoa(int(network[idx]))
+		case prefixBits <= 0:
+			octets[idx] = ipv4OctetRegex
+		default:
+			size := 1 << uint(8-prefixBits)
+			values := make([]string, size)
+			for i := 0; i < size; i++ {
+				values[i] = strconv.Itoa(int(network[idx]) + i)
+			}
+			octets[idx] = "(" + strings.Join(values, "|") + ")"
+		}
+	}
+	return "(^|,)" + strings.Join(octets, `\.`) + "(,|$)", nil
+}
+
 func validateSliceOfBasicType(value interface{}, requireSameType bool) error {
 	if value == nil {
 		return nil
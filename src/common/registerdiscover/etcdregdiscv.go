@@ -0,0 +1,241 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registerdiscover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/etcdclient"
+)
+
+const (
+	// etcdLeaseTTL the seconds the registered node lives after the server is gone
+	etcdLeaseTTL = 10
+	// etcdResyncInterval the interval to get the server info again in case a watch event is missed
+	etcdResyncInterval = 30 * time.Second
+)
+
+// EtcdRegDiscv do register and discover by etcd, the server is registered with a lease, so that it's removed
+// when the server is gone, and the changes of the servers are discovered by watch.
+type EtcdRegDiscv struct {
+	client  *etcdclient.EtcdClient
+	cancel  context.CancelFunc
+	rootCxt context.Context
+
+	lock         sync.Mutex
+	registerPath string
+	lease        int64
+}
+
+// NewEtcdRegDiscv create a object of EtcdRegDiscv
+func NewEtcdRegDiscv(client *etcdclient.EtcdClient) *EtcdRegDiscv {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EtcdRegDiscv{
+		client:  client,
+		cancel:  cancel,
+		rootCxt: ctx,
+	}
+}
+
+// RegisterAndWatch registers the server under the path with a lease and keeps the lease alive,
+// if the lease is expired, then register again
+func (e *EtcdRegDiscv) RegisterAndWatch(path string, data []byte) error {
+	blog.Infof("register server and watch it. path(%s), data(%s)", path, string(data))
+	go func() {
+		for {
+			if err := e.register(path, data); err != nil {
+				blog.Errorf("fail to register server node(%s), err: %v", path, err)
+				if e.sleep(time.Second) {
+					return
+				}
+				continue
+			}
+
+			// keep the lease alive until it's expired or the register is canceled
+			for {
+				if e.sleep(etcdLeaseTTL * time.Second / 3) {
+					e.revoke()
+					blog.Infof("watch register node(%s) done, now exist service register.", path)
+					return
+				}
+				err := e.client.KeepAliveOnce(e.rootCxt, e.currentLease())
+				if err == nil {
+					continue
+				}
+				blog.Errorf("keep alive register node(%s) failed, err: %v", e.currentPath(), err)
+				if err == etcdclient.ErrLeaseNotFound {
+					// the lease is expired, register again
+					break
+				}
+			}
+		}
+	}()
+
+	blog.Infof("finish register server node(%s) and watch it", path)
+	return nil
+}
+
+func (e *EtcdRegDiscv) register(path string, data []byte) error {
+	lease, err := e.client.Grant(e.rootCxt, etcdLeaseTTL)
+	if err != nil {
+		return err
+	}
+	// the lease id is unique, so that the servers registered under the same path are not conflicted
+	node := fmt.Sprintf("%s/%016x", path, lease)
+	if err := e.client.Put(e.rootCxt, node, data, lease); err != nil {
+		return err
+	}
+
+	e.lock.Lock()
+	e.registerPath, e.lease = node, lease
+	e.lock.Unlock()
+	return nil
+}
+
+func (e *EtcdRegDiscv) revoke() {
+	lease := e.currentLease()
+	if lease == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.client.Revoke(ctx, lease); err != nil {
+		blog.Errorf("revoke the lease of register node(%s) failed, err: %v", e.currentPath(), err)
+	}
+}
+
+func (e *EtcdRegDiscv) currentLease() int64 {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.lease
+}
+
+func (e *EtcdRegDiscv) currentPath() string {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.registerPath
+}
+
+// sleep waits for the duration, returns true if the register-discover is canceled
+func (e *EtcdRegDiscv) sleep(duration time.Duration) bool {
+	select {
+	case <-e.rootCxt.Done():
+		return true
+	case <-time.After(duration):
+		return false
+	}
+}
+
+// GetServNodes get server nodes by path, they are sorted by the registered order
+func (e *EtcdRegDiscv) GetServNodes(path string) ([]string, error) {
+	kvs, _, err := e.client.GetPrefix(e.rootCxt, path+"/")
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]string, 0)
+	for _, kv := range kvs {
+		nodes = append(nodes, strings.TrimPrefix(kv.Key, path+"/"))
+	}
+	return nodes, nil
+}
+
+// Ping to ping server
+func (e *EtcdRegDiscv) Ping() error {
+	return e.client.Ping()
+}
+
+// Discover watch the servers registered under the path
+func (e *EtcdRegDiscv) Discover(path string) (<-chan *DiscoverEvent, error) {
+	blog.Infof("begin to discover by watch the servers under path(%s)", path)
+	env := make(chan *DiscoverEvent, 1)
+	go e.loopDiscover(path, env)
+	return env, nil
+}
+
+func (e *EtcdRegDiscv) loopDiscover(path string, env chan *DiscoverEvent) {
+	var previous *DiscoverEvent
+	for {
+		kvs, revision, err := e.client.GetPrefix(e.rootCxt, path+"/")
+		if err != nil {
+			blog.Errorf("get the servers under path(%s) failed, will retry after 5s, err: %v", path, err)
+			if e.sleep(5 * time.Second) {
+				return
+			}
+			continue
+		}
+
+		event := &DiscoverEvent{Key: path}
+		for _, kv := range kvs {
+			event.Nodes = append(event.Nodes, strings.TrimPrefix(kv.Key, path+"/"))
+			event.Server = append(event.Server, string(kv.Value))
+		}
+		// the servers are sorted by the registered order, the first one is the master
+		if previous == nil || !sameServers(previous.Server, event.Server) {
+			select {
+			case env <- event:
+			case <-e.rootCxt.Done():
+				return
+			}
+			previous = event
+		}
+
+		// watch the changes after the revision, watch again after a while in case an event is missed
+		watchCtx, cancel := context.WithTimeout(e.rootCxt, etcdResyncInterval)
+		err = e.client.Watch(watchCtx, path+"/", true, revision+1)
+		cancel()
+		if e.rootCxt.Err() != nil {
+			blog.Infof("discover path(%s) done", path)
+			return
+		}
+		if err != nil && err != context.DeadlineExceeded {
+			blog.Errorf("watch the servers under path(%s) failed, will retry after 1s, err: %v", path, err)
+			if e.sleep(time.Second) {
+				return
+			}
+		}
+	}
+}
+
+// sameServers checks whether the servers and their order are the same
+func sameServers(previous, current []string) bool {
+	if len(previous) != len(current) {
+		return false
+	}
+	for idx := range previous {
+		if previous[idx] != current[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// Cancel to stop server register and discover
+func (e *EtcdRegDiscv) Cancel() {
+	e.cancel()
+}
+
+// ClearRegisterPath to delete server register node from etcd
+func (e *EtcdRegDiscv) ClearRegisterPath() error {
+	path := e.currentPath()
+	if path == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return e.client.Delete(ctx, path)
+}
@@ -0,0 +1,205 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registerdiscover
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+)
+
+const (
+	// EphemeralFileSuffix the suffix of the node files written by the registered servers, they are
+	// refreshed by the server, and regarded as gone if they are not refreshed for fileNodeExpire.
+	EphemeralFileSuffix = ".ephemeral"
+	fileNodeRefresh     = 3 * time.Second
+	fileNodeExpire      = 15 * time.Second
+	fileDiscoverPeriod  = time.Second
+)
+
+// FileRegDiscv do register and discover by the files under a directory, it's used by the single node or
+// development deployments. The discover path is mapped to a directory under the root directory, and each
+// file in it is a server node whose content is the server info. Besides the nodes registered by the
+// servers, the static node files can be written by hand, they never expire.
+type FileRegDiscv struct {
+	root    string
+	cancel  context.CancelFunc
+	rootCxt context.Context
+
+	lock         sync.Mutex
+	registerPath string
+}
+
+// NewFileRegDiscv create a object of FileRegDiscv
+func NewFileRegDiscv(root string) *FileRegDiscv {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &FileRegDiscv{
+		root:    root,
+		cancel:  cancel,
+		rootCxt: ctx,
+	}
+}
+
+func (f *FileRegDiscv) dir(path string) string {
+	return filepath.Join(f.root, filepath.FromSlash(path))
+}
+
+// RegisterAndWatch writes the server node file and refreshes it, if it's removed, then write it again.
+// like the sequential node of zookeeper, the node file is written beside the path, such as the node of
+// /cc/services/endpoints/host/127.0.0.1 is <root>/cc/services/endpoints/host/<time>_127.0.0.1_<pid>.ephemeral
+func (f *FileRegDiscv) RegisterAndWatch(path string, data []byte) error {
+	blog.Infof("register server and watch it. path(%s), data(%s)", path, string(data))
+	dir := filepath.Dir(f.dir(path))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create register directory of path(%s) failed, err: %v", path, err)
+	}
+
+	// the node name starts with the register time, so that the nodes are sorted by the registered order
+	node := fmt.Sprintf("%019d_%s_%d%s", time.Now().UnixNano(), filepath.Base(path), os.Getpid(),
+		EphemeralFileSuffix)
+	nodeFile := filepath.Join(dir, node)
+	f.lock.Lock()
+	f.registerPath = nodeFile
+	f.lock.Unlock()
+
+	go func() {
+		for {
+			now := time.Now()
+			if err := os.Chtimes(nodeFile, now, now); err != nil {
+				if err := ioutil.WriteFile(nodeFile, data, 0644); err != nil {
+					blog.Errorf("fail to register server node(%s), err: %v", nodeFile, err)
+				}
+			}
+
+			select {
+			case <-f.rootCxt.Done():
+				blog.Infof("watch register node(%s) done, now exist service register.", nodeFile)
+				return
+			case <-time.After(fileNodeRefresh):
+			}
+		}
+	}()
+	return nil
+}
+
+// GetServNodes get server nodes by path, they are sorted by the registered order
+func (f *FileRegDiscv) GetServNodes(path string) ([]string, error) {
+	nodes, _, err := f.readNodes(path)
+	return nodes, err
+}
+
+// readNodes reads the alive server nodes and their server info under the path
+func (f *FileRegDiscv) readNodes(path string) ([]string, []string, error) {
+	files, err := ioutil.ReadDir(f.dir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, []string{}, nil
+		}
+		return nil, nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+
+	nodes, servers := make([]string, 0), make([]string, 0)
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if strings.HasSuffix(name, EphemeralFileSuffix) && time.Since(file.ModTime()) > fileNodeExpire {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(f.dir(path), name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, nil, err
+		}
+		nodes = append(nodes, name)
+		servers = append(servers, strings.TrimSpace(string(data)))
+	}
+	return nodes, servers, nil
+}
+
+// Ping to check the root directory
+func (f *FileRegDiscv) Ping() error {
+	info, err := os.Stat(f.root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", f.root)
+	}
+	return nil
+}
+
+// Discover checks the server nodes under the path periodically
+func (f *FileRegDiscv) Discover(path string) (<-chan *DiscoverEvent, error) {
+	blog.Infof("begin to discover by the files under path(%s)", path)
+	env := make(chan *DiscoverEvent, 1)
+
+	go func() {
+		var previous *DiscoverEvent
+		for {
+			nodes, servers, err := f.readNodes(path)
+			if err != nil {
+				blog.Errorf("read the server nodes under path(%s) failed, err: %v", path, err)
+			} else if previous == nil || !sameServers(previous.Server, servers) {
+				event := &DiscoverEvent{Key: path, Nodes: nodes, Server: servers}
+				select {
+				case env <- event:
+				case <-f.rootCxt.Done():
+					return
+				}
+				previous = event
+			}
+
+			select {
+			case <-f.rootCxt.Done():
+				blog.Infof("discover path(%s) done", path)
+				return
+			case <-time.After(fileDiscoverPeriod):
+			}
+		}
+	}()
+
+	return env, nil
+}
+
+// Cancel to stop server register and discover
+func (f *FileRegDiscv) Cancel() {
+	f.cancel()
+}
+
+// ClearRegisterPath to delete server node file
+func (f *FileRegDiscv) ClearRegisterPath() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.registerPath == "" {
+		return nil
+	}
+	if err := os.Remove(f.registerPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
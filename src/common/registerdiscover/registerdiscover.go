@@ -13,6 +13,8 @@
 package registerdiscover
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"configcenter/src/common/backbone/service_mange/zk"
@@ -53,6 +55,44 @@ func NewRegDiscoverEx(client *zk.ZkClient) *RegDiscover {
 	return regDiscv
 }
 
+// NewRegDiscoverWithServer used to create a object of RegDiscover with the register-discover backend
+func NewRegDiscoverWithServer(server RegDiscvServer) *RegDiscover {
+	return &RegDiscover{
+		rdServer: server,
+	}
+}
+
+// RegDiscvType the type of the register-discover backend
+type RegDiscvType string
+
+const (
+	RegDiscvZk   RegDiscvType = "zk"
+	RegDiscvEtcd RegDiscvType = "etcd"
+	RegDiscvFile RegDiscvType = "file"
+)
+
+// ParseRegDiscvAddr parses the address of the register-discover backend, the backend is chosen by the scheme:
+// 127.0.0.1:2181 or zk://127.0.0.1:2181 for zookeeper, etcd://127.0.0.1:2379,127.0.0.2:2379 for etcd,
+// and file:///data/cmdb/discovery for the files under the directory. The address without the scheme is returned.
+func ParseRegDiscvAddr(addr string) (RegDiscvType, string, error) {
+	addr = strings.TrimSpace(addr)
+	idx := strings.Index(addr, "://")
+	if idx < 0 {
+		return RegDiscvZk, addr, nil
+	}
+
+	typ, host := RegDiscvType(addr[:idx]), addr[idx+len("://"):]
+	if host == "" {
+		return "", "", fmt.Errorf("regdiscv %s has no address", addr)
+	}
+	switch typ {
+	case RegDiscvZk, RegDiscvEtcd, RegDiscvFile:
+		return typ, host, nil
+	default:
+		return "", "", fmt.Errorf("regdiscv %s is not supported, the scheme should be zk, etcd or file", addr)
+	}
+}
+
 // RegisterAndWatchService register service info into register-discover platform
 // and then watch the service info, if not exist, then register again
 // key is the index of registered service
@@ -0,0 +1,179 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registerdiscover
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"configcenter/src/common/etcdclient"
+	"configcenter/src/common/etcdclient/etcdtest"
+)
+
+func TestParseRegDiscvAddr(t *testing.T) {
+	cases := []struct {
+		addr string
+		typ  RegDiscvType
+		host string
+		err  bool
+	}{
+		{addr: "127.0.0.1:2181,127.0.0.2:2181", typ: RegDiscvZk, host: "127.0.0.1:2181,127.0.0.2:2181"},
+		{addr: "zk://127.0.0.1:2181", typ: RegDiscvZk, host: "127.0.0.1:2181"},
+		{addr: "etcd://127.0.0.1:2379,127.0.0.2:2379", typ: RegDiscvEtcd, host: "127.0.0.1:2379,127.0.0.2:2379"},
+		{addr: "file:///data/cmdb/discovery", typ: RegDiscvFile, host: "/data/cmdb/discovery"},
+		{addr: "consul://127.0.0.1:8500", err: true},
+		{addr: "etcd://", err: true},
+	}
+
+	for _, c := range cases {
+		typ, host, err := ParseRegDiscvAddr(c.addr)
+		if c.err {
+			if err == nil {
+				t.Errorf("parse %s should fail", c.addr)
+			}
+			continue
+		}
+		if err != nil || typ != c.typ || host != c.host {
+			t.Errorf("parse %s got (%s, %s, %v), want (%s, %s)", c.addr, typ, host, err, c.typ, c.host)
+		}
+	}
+}
+
+// waitServers waits for the discover event with the servers
+func waitServers(t *testing.T, ch <-chan *DiscoverEvent, servers []string) {
+	timeout := time.After(10 * time.Second)
+	var last *DiscoverEvent
+	for {
+		select {
+		case event := <-ch:
+			if event.Err != nil {
+				t.Fatalf("discover failed, err: %v", event.Err)
+			}
+			if reflect.DeepEqual(event.Server, servers) || (len(servers) == 0 && len(event.Server) == 0) {
+				return
+			}
+			last = event
+		case <-timeout:
+			t.Fatalf("discover servers %v timeout, last event: %+v", servers, last)
+		}
+	}
+}
+
+func TestEtcdRegDiscv(t *testing.T) {
+	conf := etcdtest.Config(t)
+	path := etcdtest.Prefix("registerdiscover") + "/cc/services/endpoints/host"
+	client := etcdclient.NewEtcdClientWithConfig(conf)
+	newClient := func() *EtcdRegDiscv {
+		return NewEtcdRegDiscv(etcdclient.NewEtcdClientWithConfig(conf))
+	}
+
+	watcher := newClient()
+	defer watcher.Cancel()
+	ch, err := watcher.Discover(path)
+	if err != nil {
+		t.Fatalf("discover failed, err: %v", err)
+	}
+	waitServers(t, ch, nil)
+
+	first, second := newClient(), newClient()
+	defer second.Cancel()
+	if err := first.RegisterAndWatch(path+"/127.0.0.1", []byte("first")); err != nil {
+		t.Fatalf("register failed, err: %v", err)
+	}
+	waitServers(t, ch, []string{"first"})
+	if err := second.RegisterAndWatch(path+"/127.0.0.2", []byte("second")); err != nil {
+		t.Fatalf("register failed, err: %v", err)
+	}
+	// the servers are in the registered order, the first one is the master
+	waitServers(t, ch, []string{"first", "second"})
+
+	nodes, err := watcher.GetServNodes(path)
+	if err != nil || len(nodes) != 2 {
+		t.Fatalf("get server nodes failed, nodes: %v, err: %v", nodes, err)
+	}
+
+	// the lease of the first one is gone, it registers again and becomes the last one
+	if err := client.Revoke(context.Background(), first.currentLease()); err != nil {
+		t.Fatalf("revoke the lease failed, err: %v", err)
+	}
+	waitServers(t, ch, []string{"second"})
+	waitServers(t, ch, []string{"second", "first"})
+
+	// the node is removed after the register is canceled
+	first.Cancel()
+	waitServers(t, ch, []string{"second"})
+}
+
+func TestFileRegDiscv(t *testing.T) {
+	root, err := ioutil.TempDir("", "filediscv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	watcher := NewFileRegDiscv(root)
+	defer watcher.Cancel()
+	if err := watcher.Ping(); err != nil {
+		t.Fatalf("ping failed, err: %v", err)
+	}
+	ch, err := watcher.Discover("/cc/services/endpoints/host")
+	if err != nil {
+		t.Fatalf("discover failed, err: %v", err)
+	}
+	waitServers(t, ch, []string{})
+
+	// the static node written by hand never expires
+	dir := filepath.Join(root, "cc", "services", "endpoints", "host")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	static := filepath.Join(dir, "0_static")
+	if err := ioutil.WriteFile(static, []byte("static\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(static, old, old); err != nil {
+		t.Fatal(err)
+	}
+	waitServers(t, ch, []string{"static"})
+
+	register := NewFileRegDiscv(root)
+	if err := register.RegisterAndWatch("/cc/services/endpoints/host/127.0.0.1", []byte("registered")); err != nil {
+		t.Fatalf("register failed, err: %v", err)
+	}
+	waitServers(t, ch, []string{"static", "registered"})
+
+	// the ephemeral node which is not refreshed is regarded as gone
+	stale := filepath.Join(dir, "1_127.0.0.2_1"+EphemeralFileSuffix)
+	if err := ioutil.WriteFile(stale, []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := watcher.GetServNodes("/cc/services/endpoints/host")
+	if err != nil || len(nodes) != 2 {
+		t.Fatalf("get server nodes failed, nodes: %v, err: %v", nodes, err)
+	}
+
+	register.Cancel()
+	if err := register.ClearRegisterPath(); err != nil {
+		t.Fatalf("clear register path failed, err: %v", err)
+	}
+	waitServers(t, ch, []string{"static"})
+}
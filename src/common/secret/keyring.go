@@ -0,0 +1,186 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"configcenter/src/common/cryptor"
+)
+
+// keyringRefPrefix 密钥环加密的引用前缀，引用格式为keyring:{密钥id}:{base64编码的nonce及密文}
+const keyringRefPrefix = ProviderKeyring + ":"
+
+// KeyringConfig 本地密钥环配置
+type KeyringConfig struct {
+	// Keys 密钥环中的密钥，密钥id -> 16、24或32字节的AES密钥
+	Keys map[string][]byte
+	// ActiveKey 当前生效的密钥id，新的密钥使用该密钥加密，其他密钥仅用于解密轮换前的密文
+	ActiveKey string
+}
+
+// ParseKeyringKeys 解析密钥环配置，格式为{密钥id}:{base64编码的密钥}，多个密钥以逗号分隔
+func ParseKeyringKeys(val string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("keyring key %s is invalid, the format should be id:base64 key", item)
+		}
+		if _, exists := keys[parts[0]]; exists {
+			return nil, fmt.Errorf("keyring key id %s is duplicated", parts[0])
+		}
+
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("keyring key %s is not base64 encoded, err: %v", parts[0], err)
+		}
+		keys[parts[0]] = key
+	}
+	return keys, nil
+}
+
+// keyringProvider 本地密钥环后端，引用即为使用密钥环中的密钥加密的密文
+type keyringProvider struct {
+	aeads     map[string]cipher.AEAD
+	activeKey string
+	// legacy 轮换前使用的bk-secrets服务密钥的密码器，用于解密不带keyring前缀的历史密文
+	legacy cryptor.Cryptor
+}
+
+// NewKeyringProvider 生成本地密钥环后端，legacy不为空时可以解密并轮换其加密的历史密文
+func NewKeyringProvider(conf KeyringConfig, legacy cryptor.Cryptor) (SecretProvider, error) {
+	if len(conf.Keys) == 0 {
+		return nil, errors.New("keyring has no key")
+	}
+	if _, exists := conf.Keys[conf.ActiveKey]; !exists {
+		return nil, fmt.Errorf("keyring active key %s is not in the keyring", conf.ActiveKey)
+	}
+
+	p := &keyringProvider{aeads: make(map[string]cipher.AEAD), activeKey: conf.ActiveKey, legacy: legacy}
+	for id, key := range conf.Keys {
+		if strings.Contains(id, ":") {
+			return nil, fmt.Errorf("keyring key id %s can't contain colon", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("keyring key %s is invalid, it must be 128, 192 or 256 bit, err: %v", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("create keyring key %s gcm failed, err: %v", id, err)
+		}
+		p.aeads[id] = aead
+	}
+	return p, nil
+}
+
+// Name 后端名称
+func (p *keyringProvider) Name() string {
+	return ProviderKeyring
+}
+
+// Put 使用当前生效的密钥加密密钥
+func (p *keyringProvider) Put(_ context.Context, _, secret string) (string, error) {
+	aead := p.aeads[p.activeKey]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("generate nonce failed, err: %v", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(secret), []byte(p.activeKey))
+	return keyringRefPrefix + p.activeKey + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Get 使用引用中的密钥id对应的密钥解密
+func (p *keyringProvider) Get(_ context.Context, ref string) (string, error) {
+	keyID, sealed, err := p.parseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	if keyID == "" {
+		return p.legacy.Decrypt(ref)
+	}
+
+	aead, exists := p.aeads[keyID]
+	if !exists {
+		return "", fmt.Errorf("keyring key %s is not in the keyring", keyID)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("keyring secret reference is too short")
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret with keyring key %s failed, err: %v", keyID, err)
+	}
+	return string(plain), nil
+}
+
+// Delete 密文保存在调用方，无需删除
+func (p *keyringProvider) Delete(_ context.Context, _ string) error {
+	return nil
+}
+
+// Rotate 使用当前生效的密钥重新加密非当前密钥加密的引用
+func (p *keyringProvider) Rotate(ctx context.Context, ref string) (string, bool, error) {
+	keyID, _, err := p.parseRef(ref)
+	if err != nil {
+		return "", false, err
+	}
+	if keyID == p.activeKey {
+		return ref, false, nil
+	}
+
+	secret, err := p.Get(ctx, ref)
+	if err != nil {
+		return "", false, err
+	}
+	rotated, err := p.Put(ctx, "", secret)
+	if err != nil {
+		return "", false, err
+	}
+	return rotated, true, nil
+}
+
+// parseRef 解析引用中的密钥id及nonce和密文，历史密文的密钥id为空
+func (p *keyringProvider) parseRef(ref string) (string, []byte, error) {
+	if !strings.HasPrefix(ref, keyringRefPrefix) {
+		if p.legacy == nil {
+			return "", nil, ErrInvalidReference
+		}
+		return "", nil, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(ref, keyringRefPrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", nil, ErrInvalidReference
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, ErrInvalidReference
+	}
+	return parts[0], sealed, nil
+}
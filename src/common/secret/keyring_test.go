@@ -0,0 +1,131 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"configcenter/src/common/cryptor"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKey1 = "0123456789abcdef"
+	testKey2 = "abcdef0123456789abcdef0123456789"
+)
+
+func TestParseKeyringKeys(t *testing.T) {
+	val := "k1:" + base64.StdEncoding.EncodeToString([]byte(testKey1)) + ", k2:" +
+		base64.StdEncoding.EncodeToString([]byte(testKey2)) + ","
+	keys, err := ParseKeyringKeys(val)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"k1": []byte(testKey1), "k2": []byte(testKey2)}, keys)
+
+	_, err = ParseKeyringKeys("k1")
+	require.Error(t, err)
+	_, err = ParseKeyringKeys("k1:not base64")
+	require.Error(t, err)
+	_, err = ParseKeyringKeys("k1:YQ==,k1:Yg==")
+	require.Error(t, err)
+}
+
+func TestKeyringRotate(t *testing.T) {
+	ctx := context.Background()
+	legacy := cryptor.NewAesEncrpytor(testKey1)
+	legacyRef, err := legacy.Encrypt("legacy-secret")
+	require.NoError(t, err)
+
+	oldRing, err := NewKeyringProvider(KeyringConfig{Keys: map[string][]byte{"k1": []byte(testKey1)}, ActiveKey: "k1"},
+		legacy)
+	require.NoError(t, err)
+	ref, err := oldRing.Put(ctx, "account", "secret")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(ref, "keyring:k1:"))
+	secret, err := oldRing.Get(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, "secret", secret)
+
+	// the new active key encrypts the new secrets, the old key is still used to decrypt.
+	keys := map[string][]byte{"k1": []byte(testKey1), "k2": []byte(testKey2)}
+	ring, err := NewKeyringProvider(KeyringConfig{Keys: keys, ActiveKey: "k2"}, legacy)
+	require.NoError(t, err)
+	secret, err = ring.Get(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, "secret", secret)
+
+	rotator := ring.(Rotator)
+	rotated, changed, err := rotator.Rotate(ctx, ref)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.True(t, strings.HasPrefix(rotated, "keyring:k2:"))
+	secret, err = ring.Get(ctx, rotated)
+	require.NoError(t, err)
+	require.Equal(t, "secret", secret)
+
+	_, changed, err = rotator.Rotate(ctx, rotated)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// the legacy cipher text is rotated too.
+	rotated, changed, err = rotator.Rotate(ctx, legacyRef)
+	require.NoError(t, err)
+	require.True(t, changed)
+	secret, err = ring.Get(ctx, rotated)
+	require.NoError(t, err)
+	require.Equal(t, "legacy-secret", secret)
+
+	// the old keyring can't decrypt the secret encrypted by the new key.
+	_, err = oldRing.Get(ctx, rotated)
+	require.Error(t, err)
+
+	// the tampered cipher text can't be decrypted.
+	_, err = ring.Get(ctx, rotated[:len(rotated)-4]+"AAA=")
+	require.Error(t, err)
+}
+
+func TestKeyringInvalid(t *testing.T) {
+	_, err := NewKeyringProvider(KeyringConfig{}, nil)
+	require.Error(t, err)
+	_, err = NewKeyringProvider(KeyringConfig{Keys: map[string][]byte{"k1": []byte(testKey1)}, ActiveKey: "k2"}, nil)
+	require.Error(t, err)
+	_, err = NewKeyringProvider(KeyringConfig{Keys: map[string][]byte{"k1": []byte("short")}, ActiveKey: "k1"}, nil)
+	require.Error(t, err)
+
+	ring, err := NewKeyringProvider(KeyringConfig{Keys: map[string][]byte{"k1": []byte(testKey1)}, ActiveKey: "k1"}, nil)
+	require.NoError(t, err)
+	_, err = ring.Get(context.Background(), "plain text")
+	require.Equal(t, ErrInvalidReference, err)
+	_, err = ring.Get(context.Background(), "keyring:k3:AAAA")
+	require.Error(t, err)
+}
+
+func TestNewSecretProvider(t *testing.T) {
+	_, err := NewSecretProvider(Config{Provider: ProviderSecrets}, nil)
+	require.Error(t, err)
+
+	provider, err := NewSecretProvider(Config{Provider: ProviderSecrets}, cryptor.NewAesEncrpytor(testKey1))
+	require.NoError(t, err)
+	require.Equal(t, ProviderSecrets, provider.Name())
+	ref, err := provider.Put(context.Background(), "account", "secret")
+	require.NoError(t, err)
+	secret, err := provider.Get(context.Background(), ref)
+	require.NoError(t, err)
+	require.Equal(t, "secret", secret)
+
+	_, err = NewSecretProvider(Config{Provider: "kms"}, nil)
+	require.Error(t, err)
+}
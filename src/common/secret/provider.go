@@ -0,0 +1,145 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package secret 提供可插拔的密钥存储后端，用于云账户密钥等凭证的存储
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/cryptor"
+)
+
+// 支持的密钥存储后端
+const (
+	// ProviderSecrets 使用蓝鲸bk-secrets服务下发的密钥进行AES加密，加密后的密文保存在数据库中
+	ProviderSecrets = "secrets"
+	// ProviderVault 密钥保存在HashiCorp Vault的KV v2引擎中，数据库中只保存密钥在Vault中的引用
+	ProviderVault = "vault"
+	// ProviderKeyring 使用本地配置的密钥环进行AES-GCM加密，支持密钥轮换
+	ProviderKeyring = "keyring"
+)
+
+// ErrInvalidReference 密钥引用不属于当前的密钥存储后端
+var ErrInvalidReference = errors.New("secret reference is not recognized by the provider")
+
+// SecretProvider 密钥存储后端，调用方保存Put返回的引用以代替密钥明文，再通过同一后端的Get解析出密钥明文
+type SecretProvider interface {
+	// Name 后端名称
+	Name() string
+	// Put 保存名称为name的密钥，返回需要调用方保存的引用，name用于在外部存储中标识密钥，同一name重复保存会生成新的版本
+	Put(ctx context.Context, name, secret string) (string, error)
+	// Get 解析引用对应的密钥明文
+	Get(ctx context.Context, ref string) (string, error)
+	// Delete 删除引用对应的外部存储中的密钥，引用本身即为密文的后端无需删除
+	Delete(ctx context.Context, ref string) error
+}
+
+// Rotator 支持密钥轮换的后端实现该接口
+type Rotator interface {
+	// Rotate 使用当前生效的密钥重新加密引用，引用已经使用当前密钥加密时返回false
+	Rotate(ctx context.Context, ref string) (string, bool, error)
+}
+
+// Config 密钥存储后端配置
+type Config struct {
+	// Provider 后端名称，为空时使用ProviderSecrets
+	Provider string
+	Vault    VaultConfig
+	Keyring  KeyringConfig
+}
+
+// ParseConfig 从配置中心读取prefix下的密钥存储后端配置，如cloudServer.cryptor
+func ParseConfig(prefix string) (Config, error) {
+	getString := func(key string) string {
+		val, _ := cc.String(prefix + "." + key)
+		return strings.TrimSpace(val)
+	}
+
+	conf := Config{
+		Provider: getString("provider"),
+		Vault: VaultConfig{
+			Addr:       getString("vault.addr"),
+			Token:      getString("vault.token"),
+			Namespace:  getString("vault.namespace"),
+			Mount:      getString("vault.mount"),
+			PathPrefix: getString("vault.pathPrefix"),
+		},
+		Keyring: KeyringConfig{
+			ActiveKey: getString("keyring.activeKey"),
+		},
+	}
+	if conf.Provider == "" {
+		conf.Provider = ProviderSecrets
+	}
+
+	if conf.Provider == ProviderKeyring {
+		keys, err := ParseKeyringKeys(getString("keyring.keys"))
+		if err != nil {
+			return Config{}, fmt.Errorf("parse %s.keyring.keys failed, err: %v", prefix, err)
+		}
+		conf.Keyring.Keys = keys
+	}
+	return conf, nil
+}
+
+// NewSecretProvider 根据配置创建密钥存储后端，legacy为使用bk-secrets服务下发的密钥的AES密码器，
+// ProviderSecrets后端必须传入，ProviderKeyring后端传入时可以解密并轮换使用该密码器加密的历史密文
+func NewSecretProvider(conf Config, legacy cryptor.Cryptor) (SecretProvider, error) {
+	switch conf.Provider {
+	case ProviderSecrets, "":
+		if legacy == nil {
+			return nil, errors.New("secrets provider needs the cryptor with the key from bk-secrets service")
+		}
+		return NewCryptorProvider(legacy), nil
+	case ProviderVault:
+		return NewVaultProvider(conf.Vault)
+	case ProviderKeyring:
+		return NewKeyringProvider(conf.Keyring, legacy)
+	default:
+		return nil, fmt.Errorf("secret provider %s is not supported", conf.Provider)
+	}
+}
+
+// cryptorProvider 使用密码器加密的后端，引用即为密文，兼容使用bk-secrets服务下发的密钥加密的历史数据
+type cryptorProvider struct {
+	cryptor cryptor.Cryptor
+}
+
+// NewCryptorProvider 生成使用密码器加密的后端
+func NewCryptorProvider(c cryptor.Cryptor) SecretProvider {
+	return &cryptorProvider{cryptor: c}
+}
+
+// Name 后端名称
+func (p *cryptorProvider) Name() string {
+	return ProviderSecrets
+}
+
+// Put 加密密钥，返回密文
+func (p *cryptorProvider) Put(_ context.Context, _, secret string) (string, error) {
+	return p.cryptor.Encrypt(secret)
+}
+
+// Get 解密密文
+func (p *cryptorProvider) Get(_ context.Context, ref string) (string, error) {
+	return p.cryptor.Decrypt(ref)
+}
+
+// Delete 密文保存在调用方，无需删除
+func (p *cryptorProvider) Delete(_ context.Context, _ string) error {
+	return nil
+}
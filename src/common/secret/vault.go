@@ -0,0 +1,206 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// vaultRefPrefix Vault密钥的引用前缀，引用格式为vault:{密钥路径}#{版本}
+	vaultRefPrefix        = ProviderVault + ":"
+	defaultVaultMount     = "secret"
+	defaultVaultPrefix    = "cmdb"
+	vaultSecretValueField = "value"
+	vaultRequestTimeout   = 10 * time.Second
+)
+
+// VaultConfig HashiCorp Vault配置
+type VaultConfig struct {
+	// Addr Vault地址，以http://或https://开头
+	Addr string
+	// Token 访问Vault使用的token，需要有KV引擎下PathPrefix路径的读写及删除权限
+	Token string
+	// Namespace Vault企业版的命名空间，可以为空
+	Namespace string
+	// Mount KV v2引擎的挂载路径，默认为secret
+	Mount string
+	// PathPrefix 密钥在KV引擎中的路径前缀，默认为cmdb
+	PathPrefix string
+}
+
+// vaultProvider Vault KV v2后端，密钥保存在Vault中，引用为密钥的路径及版本
+type vaultProvider struct {
+	conf   VaultConfig
+	client *http.Client
+}
+
+// NewVaultProvider 生成Vault KV v2后端
+func NewVaultProvider(conf VaultConfig) (SecretProvider, error) {
+	conf.Addr = strings.TrimSuffix(conf.Addr, "/")
+	if !strings.HasPrefix(conf.Addr, "http://") && !strings.HasPrefix(conf.Addr, "https://") {
+		return nil, errors.New("vault addr must start with http:// or https://")
+	}
+	if conf.Token == "" {
+		return nil, errors.New("vault token is not set")
+	}
+	if conf.Mount = strings.Trim(conf.Mount, "/"); conf.Mount == "" {
+		conf.Mount = defaultVaultMount
+	}
+	if conf.PathPrefix = strings.Trim(conf.PathPrefix, "/"); conf.PathPrefix == "" {
+		conf.PathPrefix = defaultVaultPrefix
+	}
+
+	return &vaultProvider{conf: conf, client: &http.Client{Timeout: vaultRequestTimeout}}, nil
+}
+
+// Name 后端名称
+func (p *vaultProvider) Name() string {
+	return ProviderVault
+}
+
+// vaultResponse Vault KV v2接口的返回，写入时data为版本信息，读取时data.data为密钥数据
+type vaultResponse struct {
+	Errors []string `json:"errors"`
+	Data   struct {
+		Version int64             `json:"version"`
+		Data    map[string]string `json:"data"`
+	} `json:"data"`
+}
+
+// Put 将密钥写入Vault中PathPrefix/name路径的新版本
+func (p *vaultProvider) Put(ctx context.Context, name, secret string) (string, error) {
+	name = strings.Trim(name, "/")
+	if name == "" || strings.Contains(name, "#") {
+		return "", fmt.Errorf("vault secret name %s is invalid", name)
+	}
+
+	path := p.conf.PathPrefix + "/" + name
+	body := map[string]interface{}{"data": map[string]string{vaultSecretValueField: secret}}
+	resp, err := p.do(ctx, http.MethodPost, "data/"+path, nil, body)
+	if err != nil {
+		return "", err
+	}
+	return vaultRefPrefix + path + "#" + strconv.FormatInt(resp.Data.Version, 10), nil
+}
+
+// Get 读取引用对应版本的密钥
+func (p *vaultProvider) Get(ctx context.Context, ref string) (string, error) {
+	path, version, err := parseVaultRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{"version": []string{version}}
+	resp, err := p.do(ctx, http.MethodGet, "data/"+path, query, nil)
+	if err != nil {
+		return "", err
+	}
+	secret, exists := resp.Data.Data[vaultSecretValueField]
+	if !exists {
+		return "", fmt.Errorf("vault secret %s version %s has no value", path, version)
+	}
+	return secret, nil
+}
+
+// Delete 删除引用对应路径的密钥的所有版本
+func (p *vaultProvider) Delete(ctx context.Context, ref string) error {
+	path, _, err := parseVaultRef(ref)
+	if err != nil {
+		return err
+	}
+	_, err = p.do(ctx, http.MethodDelete, "metadata/"+path, nil, nil)
+	return err
+}
+
+// do 请求Vault KV v2引擎的接口
+func (p *vaultProvider) do(ctx context.Context, method, subPath string, query url.Values,
+	body interface{}) (*vaultResponse, error) {
+
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	reqURL := fmt.Sprintf("%s/v1/%s/%s", p.conf.Addr, p.conf.Mount, subPath)
+	if len(query) != 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, reqURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", p.conf.Token)
+	req.Header.Set("Content-Type", "application/json")
+	if p.conf.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.conf.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request vault %s %s failed, err: %v", method, subPath, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read vault %s %s response failed, err: %v", method, subPath, err)
+	}
+
+	result := new(vaultResponse)
+	if len(respBody) != 0 {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return nil, fmt.Errorf("unmarshal vault %s %s response failed, status: %d, err: %v", method, subPath,
+				resp.StatusCode, err)
+		}
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("vault %s %s failed, status: %d, errors: %v", method, subPath, resp.StatusCode,
+			result.Errors)
+	}
+	return result, nil
+}
+
+// parseVaultRef 解析引用中的密钥路径及版本
+func parseVaultRef(ref string) (string, string, error) {
+	if !strings.HasPrefix(ref, vaultRefPrefix) {
+		return "", "", ErrInvalidReference
+	}
+
+	idx := strings.LastIndex(ref, "#")
+	if idx == -1 {
+		return "", "", ErrInvalidReference
+	}
+	path, version := ref[len(vaultRefPrefix):idx], ref[idx+1:]
+	if path == "" {
+		return "", "", ErrInvalidReference
+	}
+	if _, err := strconv.ParseInt(version, 10, 64); err != nil {
+		return "", "", ErrInvalidReference
+	}
+	return path, version, nil
+}
@@ -0,0 +1,114 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault is a kv v2 engine mounted at secret/ which keeps all the versions in memory.
+type fakeVault struct {
+	sync.Mutex
+	secrets map[string][]map[string]string
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.Lock()
+	defer v.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+		body := struct {
+			Data map[string]string `json:"data"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		v.secrets[path] = append(v.secrets[path], body.Data)
+		w.Write([]byte(`{"data":{"version":` + strconv.Itoa(len(v.secrets[path])) + `}}`))
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		versions := v.secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")]
+		version, _ := strconv.Atoi(r.URL.Query().Get("version"))
+		if version < 1 || version > len(versions) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		data, _ := json.Marshal(versions[version-1])
+		w.Write([]byte(`{"data":{"data":` + string(data) + `}}`))
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/"):
+		delete(v.secrets, strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	vault := &fakeVault{secrets: make(map[string][]map[string]string)}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	ctx := context.Background()
+	provider, err := NewVaultProvider(VaultConfig{Addr: server.URL + "/", Token: "token"})
+	require.NoError(t, err)
+
+	ref, err := provider.Put(ctx, "cloud_account/1", "secret-v1")
+	require.NoError(t, err)
+	require.Equal(t, "vault:cmdb/cloud_account/1#1", ref)
+	ref2, err := provider.Put(ctx, "cloud_account/1", "secret-v2")
+	require.NoError(t, err)
+	require.Equal(t, "vault:cmdb/cloud_account/1#2", ref2)
+
+	// each reference reads its own version.
+	secret, err := provider.Get(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, "secret-v1", secret)
+	secret, err = provider.Get(ctx, ref2)
+	require.NoError(t, err)
+	require.Equal(t, "secret-v2", secret)
+
+	_, err = provider.Get(ctx, "plain text")
+	require.Equal(t, ErrInvalidReference, err)
+
+	require.NoError(t, provider.Delete(ctx, ref2))
+	_, err = provider.Get(ctx, ref)
+	require.Error(t, err)
+
+	// the request without a valid token is rejected.
+	provider, err = NewVaultProvider(VaultConfig{Addr: server.URL, Token: "invalid"})
+	require.NoError(t, err)
+	_, err = provider.Put(ctx, "cloud_account/1", "secret")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "permission denied")
+
+	_, err = NewVaultProvider(VaultConfig{Addr: "127.0.0.1:8200", Token: "token"})
+	require.Error(t, err)
+}
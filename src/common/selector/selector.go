@@ -39,6 +39,12 @@ type LabelRemoveRequest struct {
 	TableName string            `json:"table_name"`
 }
 
+// LabelAggregationRequest aggregates the label values of the instances matching the condition in the table
+type LabelAggregationRequest struct {
+	Condition map[string]interface{} `json:"condition"`
+	TableName string                 `json:"table_name"`
+}
+
 type Operator string
 
 const (
@@ -88,48 +94,46 @@ func (s *Selector) Validate() (string, error) {
 	return "", nil
 }
 
-func (s *Selector) ToMgoFilter() (map[string]interface{}, error) {
-	filter := make(map[string]interface{})
+// ToMgoCondition converts the selector to the mongodb operator and value on the labels field of its key,
+// it's shared by the filters and the condition items of the label selectors.
+func (s *Selector) ToMgoCondition() (string, string, interface{}, error) {
 	field := "labels." + s.Key
 	switch s.Operator {
 	case In:
-		filter = map[string]interface{}{
-			field: map[string]interface{}{
-				common.BKDBIN: s.Values,
-			},
-		}
+		return field, common.BKDBIN, s.Values, nil
 	case NotIn:
-		filter = map[string]interface{}{
-			field: map[string]interface{}{
-				common.BKDBNIN: s.Values,
-			},
-		}
+		return field, common.BKDBNIN, s.Values, nil
 	case DoesNotExist, Exists:
-		filter = map[string]interface{}{
-			field: map[string]interface{}{
-				common.BKDBExists: s.Operator == Exists,
-			},
-		}
+		return field, common.BKDBExists, s.Operator == Exists, nil
 	case Equals:
 		if len(s.Values) == 0 {
-			return nil, errors.New("values empty")
-		}
-		firstValue := s.Values[0]
-		filter = map[string]interface{}{
-			field: firstValue,
+			return "", "", nil, errors.New("values empty")
 		}
+		return field, common.BKDBEQ, s.Values[0], nil
 	case NotEquals:
 		if len(s.Values) == 0 {
-			return nil, errors.New("values empty")
-		}
-		firstValue := s.Values[0]
-		filter = map[string]interface{}{
-			field: map[string]interface{}{
-				common.BKDBNE: firstValue,
-			},
+			return "", "", nil, errors.New("values empty")
 		}
+		return field, common.BKDBNE, s.Values[0], nil
+	default:
+		return "", "", nil, fmt.Errorf("operator %s not available, available operators: %+v", s.Operator,
+			AvailableOperators)
 	}
-	return filter, nil
+}
+
+func (s *Selector) ToMgoFilter() (map[string]interface{}, error) {
+	field, operator, value, err := s.ToMgoCondition()
+	if err != nil {
+		return nil, err
+	}
+	if operator == common.BKDBEQ {
+		return map[string]interface{}{field: value}, nil
+	}
+	return map[string]interface{}{
+		field: map[string]interface{}{
+			operator: value,
+		},
+	}, nil
 }
 
 type Selectors []Selector
@@ -17,6 +17,7 @@ import (
 	"strings"
 	"testing"
 
+	"configcenter/src/common"
 	"configcenter/src/common/selector"
 
 	"github.com/stretchr/testify/assert"
@@ -177,3 +178,32 @@ func TestSelector(t *testing.T) {
 	assert.Empty(t, filter)
 	assert.NotNil(t, err)
 }
+
+func TestToMgoCondition(t *testing.T) {
+	sl := selector.Selector{Key: "key", Operator: "!=", Values: []string{"value"}}
+	field, operator, value, err := sl.ToMgoCondition()
+	assert.Nil(t, err)
+	assert.Equal(t, "labels.key", field)
+	assert.Equal(t, common.BKDBNE, operator)
+	assert.Equal(t, "value", value)
+
+	sl = selector.Selector{Key: "key", Operator: "=", Values: []string{"value"}}
+	filter, err := sl.ToMgoFilter()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"labels.key": "value"}, filter)
+
+	sl = selector.Selector{Key: "key", Operator: "exists"}
+	filter, err = sl.ToMgoFilter()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"labels.key": map[string]interface{}{common.BKDBExists: true}}, filter)
+
+	// the selectors without values and with unknown operators can't be converted
+	for _, sl := range []selector.Selector{
+		{Key: "key", Operator: "="},
+		{Key: "key", Operator: "!="},
+		{Key: "key", Operator: "?", Values: []string{"value"}},
+	} {
+		_, _, _, err := sl.ToMgoCondition()
+		assert.NotNil(t, err)
+	}
+}
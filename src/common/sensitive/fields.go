@@ -0,0 +1,45 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sensitive
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+// GetFields returns the sensitive attributes of the models, the key is the model id and
+// the value is the property ids of the model's sensitive attributes.
+func GetFields(ctx context.Context, db dal.RDB, ownerID string, objIDs ...string) (map[string][]string, error) {
+	cond := map[string]interface{}{
+		common.BKObjIDField:                map[string]interface{}{common.BKDBIN: objIDs},
+		metadata.AttributeFieldIsSensitive: true,
+	}
+	cond = util.SetQueryOwner(cond, ownerID)
+
+	attrs := make([]metadata.Attribute, 0)
+	err := db.Table(common.BKTableNameObjAttDes).Find(cond).
+		Fields(common.BKObjIDField, common.BKPropertyIDField).All(ctx, &attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string][]string)
+	for _, attr := range attrs {
+		fields[attr.ObjectID] = append(fields[attr.ObjectID], attr.PropertyID)
+	}
+	return fields, nil
+}
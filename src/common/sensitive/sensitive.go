@@ -0,0 +1,171 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sensitive encrypts the values of the sensitive attributes at rest with the secret provider,
+// and masks or redacts them when they are returned to the users, saved in the audit logs or the events.
+package sensitive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/cryptor"
+	"configcenter/src/common/secret"
+)
+
+const (
+	// Mask replaces the values of the sensitive attributes which are not allowed to be seen.
+	Mask = "******"
+	// valuePrefix marks the value saved in db is encrypted, the rest of the value is the secret reference.
+	valuePrefix = "sensitive:"
+)
+
+// ErrNotEnabled the sensitive attribute encryption is not configured.
+var ErrNotEnabled = errors.New("sensitive attribute encryption is not enabled")
+
+var provider secret.SecretProvider
+
+// Init builds the secret provider with the config under the prefix, the encryption is disabled if the
+// provider is not configured. the secrets provider encrypts the values with the aes cryptor of the secretKey.
+func Init(prefix string) error {
+	if name, _ := cc.String(prefix + ".provider"); name == "" {
+		blog.Infof("%s.provider is not configured, sensitive attribute encryption is disabled", prefix)
+		return nil
+	}
+
+	conf, err := secret.ParseConfig(prefix)
+	if err != nil {
+		return err
+	}
+
+	var legacy cryptor.Cryptor
+	if secretKey, _ := cc.String(prefix + ".secretKey"); secretKey != "" {
+		switch len(secretKey) {
+		case 16, 24, 32:
+		default:
+			return fmt.Errorf("%s.secretKey must be 16, 24 or 32 bytes", prefix)
+		}
+		legacy = cryptor.NewAesEncrpytor(secretKey)
+	}
+
+	p, err := secret.NewSecretProvider(conf, legacy)
+	if err != nil {
+		return fmt.Errorf("new sensitive attribute secret provider failed, err: %v", err)
+	}
+	SetProvider(p)
+	blog.Infof("sensitive attribute secret provider: %s", p.Name())
+	return nil
+}
+
+// SetProvider sets the secret provider which encrypts the values, nil disables the encryption.
+func SetProvider(p secret.SecretProvider) {
+	provider = p
+}
+
+// Enabled returns whether the sensitive attribute encryption is enabled.
+func Enabled() bool {
+	return provider != nil
+}
+
+// SecretName returns the name of the secret which saves the value of the instance's sensitive attribute.
+func SecretName(ownerID, objID string, instID int64, propertyID string) string {
+	return strings.Join([]string{"instance", ownerID, objID, strconv.FormatInt(instID, 10), propertyID}, "/")
+}
+
+// IsEncrypted returns whether the value is encrypted.
+func IsEncrypted(val interface{}) bool {
+	str, ok := val.(string)
+	return ok && strings.HasPrefix(str, valuePrefix)
+}
+
+// Encrypt encrypts the value with the secret provider, the empty and the encrypted values are not changed.
+func Encrypt(ctx context.Context, name string, val interface{}) (interface{}, error) {
+	if val == nil || val == "" || IsEncrypted(val) {
+		return val, nil
+	}
+
+	if provider == nil {
+		return nil, ErrNotEnabled
+	}
+
+	str, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("sensitive value of %s must be string, but got %T", name, val)
+	}
+
+	ref, err := provider.Put(ctx, name, str)
+	if err != nil {
+		return nil, err
+	}
+	return valuePrefix + ref, nil
+}
+
+// Decrypt decrypts the encrypted value, the value which is not encrypted is not changed.
+func Decrypt(ctx context.Context, val interface{}) (interface{}, error) {
+	if !IsEncrypted(val) {
+		return val, nil
+	}
+
+	if provider == nil {
+		return nil, ErrNotEnabled
+	}
+	return provider.Get(ctx, strings.TrimPrefix(val.(string), valuePrefix))
+}
+
+// Delete deletes the secret of the encrypted value from the secret provider.
+func Delete(ctx context.Context, val interface{}) error {
+	if !IsEncrypted(val) || provider == nil {
+		return nil
+	}
+	return provider.Delete(ctx, strings.TrimPrefix(val.(string), valuePrefix))
+}
+
+// Redact replaces the non-empty values of the fields with the mask.
+func Redact(data map[string]interface{}, fields []string) {
+	for _, field := range fields {
+		if val, exists := data[field]; exists && val != nil && val != "" {
+			data[field] = Mask
+		}
+	}
+}
+
+// Reveal decrypts the values of the fields, the value which can not be decrypted is masked.
+func Reveal(ctx context.Context, data map[string]interface{}, fields []string, rid string) {
+	for _, field := range fields {
+		val, exists := data[field]
+		if !exists {
+			continue
+		}
+
+		plain, err := Decrypt(ctx, val)
+		if err != nil {
+			blog.Errorf("decrypt sensitive field %s failed, err: %v, rid: %s", field, err, rid)
+			data[field] = Mask
+			continue
+		}
+		data[field] = plain
+	}
+}
+
+// Output decrypts the values of the fields if reveal is true, otherwise replaces them with the mask.
+func Output(ctx context.Context, data map[string]interface{}, fields []string, reveal bool, rid string) {
+	if reveal {
+		Reveal(ctx, data, fields, rid)
+		return
+	}
+	Redact(data, fields)
+}
@@ -0,0 +1,83 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sensitive
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common/secret"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+	SetProvider(nil)
+	_, err := Encrypt(ctx, "name", "value")
+	require.Equal(t, ErrNotEnabled, err)
+
+	p, err := secret.NewKeyringProvider(secret.KeyringConfig{
+		Keys:      map[string][]byte{"k1": []byte("0123456789abcdef")},
+		ActiveKey: "k1",
+	}, nil)
+	require.NoError(t, err)
+	SetProvider(p)
+	defer SetProvider(nil)
+	require.True(t, Enabled())
+
+	encrypted, err := Encrypt(ctx, SecretName("0", "host", 1, "password"), "value")
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(encrypted))
+	require.NotContains(t, encrypted, "value")
+
+	again, err := Encrypt(ctx, "name", encrypted)
+	require.NoError(t, err)
+	require.Equal(t, encrypted, again)
+
+	empty, err := Encrypt(ctx, "name", "")
+	require.NoError(t, err)
+	require.Equal(t, "", empty)
+
+	_, err = Encrypt(ctx, "name", 1)
+	require.Error(t, err)
+
+	plain, err := Decrypt(ctx, encrypted)
+	require.NoError(t, err)
+	require.Equal(t, "value", plain)
+
+	plain, err = Decrypt(ctx, "not encrypted")
+	require.NoError(t, err)
+	require.Equal(t, "not encrypted", plain)
+
+	data := map[string]interface{}{"a": encrypted, "b": "sensitive:invalid", "c": "plain"}
+	Reveal(ctx, data, []string{"a", "b", "d"}, "")
+	require.Equal(t, map[string]interface{}{"a": "value", "b": Mask, "c": "plain"}, data)
+
+	data = map[string]interface{}{"a": encrypted}
+	Output(ctx, data, []string{"a"}, false, "")
+	require.Equal(t, map[string]interface{}{"a": Mask}, data)
+	data = map[string]interface{}{"a": encrypted}
+	Output(ctx, data, []string{"a"}, true, "")
+	require.Equal(t, map[string]interface{}{"a": "value"}, data)
+}
+
+func TestRedact(t *testing.T) {
+	data := map[string]interface{}{"a": "value", "b": "", "c": nil, "d": "plain"}
+	Redact(data, []string{"a", "b", "c", "e"})
+	require.Equal(t, map[string]interface{}{"a": Mask, "b": "", "c": nil, "d": "plain"}, data)
+}
+
+func TestSecretName(t *testing.T) {
+	require.Equal(t, "instance/0/host/12/password", SecretName("0", "host", 12, "password"))
+}
@@ -107,6 +107,27 @@ const (
 	BKTableNameCloudSyncTask    = "cc_CloudSyncTask"
 	BKTableNameCloudAccount     = "cc_CloudAccount"
 	BKTableNameCloudSyncHistory = "cc_CloudSyncHistory"
+
+	// attribute schema change tables
+	BKTableNameAttributeSchemaMigration = "cc_AttributeSchemaMigration"
+	BKTableNameAttributeSchemaBackup    = "cc_AttributeSchemaBackup"
+
+	// point-in-time snapshot tables
+	BKTableNameTopoSnapshot = "cc_TopoSnapshot"
+	BKTableNameInstSnapshot = "cc_InstSnapshot"
+
+	// BKTableNameMigrationHistory the run history of the db migrations
+	BKTableNameMigrationHistory = "cc_MigrationHistory"
+
+	// BKTableNameHostSnapHistory the history of the host fields derived from host snapshots
+	BKTableNameHostSnapHistory = "cc_HostSnapHistory"
+
+	// BKTableNameAPIJob the generic jobs executed by the task server
+	BKTableNameAPIJob = "cc_APIJob"
+	// BKTableNameAPIJobSchedule the cron-style schedules which create task server jobs periodically
+	BKTableNameAPIJobSchedule = "cc_APIJobSchedule"
+	// BKTableNameAPIJobScheduleHistory the run history of the job schedules
+	BKTableNameAPIJobScheduleHistory = "cc_APIJobScheduleHistory"
 )
 
 // AllTables alltables
@@ -166,6 +187,15 @@ var AllTables = []string{
 	BKTableNameCloudSyncTask,
 	BKTableNameCloudAccount,
 	BKTableNameCloudSyncHistory,
+	BKTableNameAttributeSchemaMigration,
+	BKTableNameAttributeSchemaBackup,
+	BKTableNameTopoSnapshot,
+	BKTableNameInstSnapshot,
+	BKTableNameMigrationHistory,
+	BKTableNameHostSnapHistory,
+	BKTableNameAPIJob,
+	BKTableNameAPIJobSchedule,
+	BKTableNameAPIJobScheduleHistory,
 }
 
 // GetInstTableName returns inst data table name
@@ -208,10 +208,16 @@ func CCHeader(header http.Header) http.Header {
 	newHeader.Add(common.BKHTTPRequestAppCode, header.Get(common.BKHTTPRequestAppCode))
 	newHeader.Add(common.BKHTTPRequestRealIP, header.Get(common.BKHTTPRequestRealIP))
 	newHeader.Add(common.BKHTTPReadReference, header.Get(common.BKHTTPReadReference))
+	newHeader.Add(common.BKHTTPRevealSensitive, header.Get(common.BKHTTPRevealSensitive))
 
 	return newHeader
 }
 
+// IsRevealSensitive returns whether the request asks to return the plain values of the sensitive attributes.
+func IsRevealSensitive(header http.Header) bool {
+	return header.Get(common.BKHTTPRevealSensitive) == "true"
+}
+
 // SetHTTPReadPreference  再header 头中设置mongodb read preference， 这个是给调用子流程使用
 func SetHTTPReadPreference(header http.Header, mode common.ReadPreferenceMode) http.Header {
 	header.Set(common.BKHTTPReadReference, mode.String())
@@ -15,8 +15,10 @@ package util
 import (
 	"bytes"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 // GetDailAddress returns the address for net.Dail
@@ -43,3 +45,30 @@ func PeekRequest(req *http.Request) ([]byte, error) {
 	}
 	return make([]byte, 0), nil
 }
+
+// IsIPv4 check if the value is an ipv4 address
+func IsIPv4(value string) bool {
+	ip := net.ParseIP(value)
+	return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+}
+
+// IsIPv6 check if the value is an ipv6 address
+func IsIPv6(value string) bool {
+	ip := net.ParseIP(value)
+	return ip != nil && strings.Contains(value, ":")
+}
+
+// IsCIDR check if the value is a cidr notation ip address and prefix length, like 192.0.2.0/24 or 2001:db8::/32
+func IsCIDR(value string) bool {
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
+}
+
+// IsURL check if the value is an absolute url with scheme and host, like http://example.com/path
+func IsURL(value string) bool {
+	uri, err := url.ParseRequestURI(value)
+	if err != nil {
+		return false
+	}
+	return uri.Scheme != "" && uri.Host != ""
+}
@@ -65,3 +65,17 @@ func TestPeekRequest(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "", string(ncontent))
 }
+
+func TestIsIPAndURL(t *testing.T) {
+	require.True(t, IsIPv4("192.168.1.1"))
+	require.False(t, IsIPv4("::ffff:192.168.1.1"))
+	require.False(t, IsIPv4("192.168.1.256"))
+	require.True(t, IsIPv6("2001:db8::1"))
+	require.False(t, IsIPv6("192.168.1.1"))
+	require.True(t, IsCIDR("10.0.0.0/8"))
+	require.True(t, IsCIDR("2001:db8::/32"))
+	require.False(t, IsCIDR("10.0.0.0"))
+	require.True(t, IsURL("https://example.com/path?q=a"))
+	require.False(t, IsURL("example.com/path"))
+	require.False(t, IsURL("http://"))
+}
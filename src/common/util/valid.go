@@ -30,6 +30,76 @@ func ValidPropertyOption(propertyType string, option interface{}, errProxy error
 		return ValidFieldTypeIntOption(option, errProxy)
 	case common.FieldTypeList:
 		return ValidFieldTypeListOption(option, errProxy)
+	case common.FieldTypeEnumMulti:
+		return ValidFieldTypeEnumOption(option, errProxy)
+	case common.FieldTypeIP:
+		return ValidFieldTypeIPOption(option, errProxy)
+	case common.FieldTypeInstRef:
+		return ValidFieldTypeInstRefOption(option, errProxy)
+	case common.FieldTypeComputed:
+		return ValidFieldTypeComputedOption(option, errProxy)
+	}
+	return nil
+}
+
+// ValidFieldTypeIPOption valid ip field option, which can be empty or one of ipv4, ipv6 and cidr
+func ValidFieldTypeIPOption(option interface{}, errProxy errors.DefaultCCErrorIf) error {
+	if nil == option {
+		return nil
+	}
+
+	strOption, ok := option.(string)
+	if !ok {
+		blog.Errorf(" option %v not string type ip option", option)
+		return errProxy.Errorf(common.CCErrCommParamsNeedString, "option")
+	}
+
+	switch strOption {
+	case "", "ipv4", "ipv6", "cidr":
+	default:
+		blog.Errorf(" option %s not in ipv4, ipv6 and cidr", strOption)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+	return nil
+}
+
+// ValidFieldTypeInstRefOption valid instance reference field option, which must specify the referenced model
+func ValidFieldTypeInstRefOption(option interface{}, errProxy errors.DefaultCCErrorIf) error {
+	if nil == option {
+		return errProxy.Errorf(common.CCErrCommParamsLostField, "option")
+	}
+
+	mapOption, ok := option.(map[string]interface{})
+	if !ok {
+		blog.Errorf(" option %v not instance reference option", option)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+
+	objID, ok := mapOption[common.BKObjIDField].(string)
+	if !ok || objID == "" {
+		blog.Errorf(" instance reference option %v has no bk_obj_id", option)
+		return errProxy.Errorf(common.CCErrCommParamsNeedSet, "option bk_obj_id")
+	}
+	return nil
+}
+
+// ValidFieldTypeComputedOption valid computed field option, which must specify the compute type,
+// the whole rule is validated in coreservice.
+func ValidFieldTypeComputedOption(option interface{}, errProxy errors.DefaultCCErrorIf) error {
+	if nil == option {
+		return errProxy.Errorf(common.CCErrCommParamsLostField, "option")
+	}
+
+	mapOption, ok := option.(map[string]interface{})
+	if !ok {
+		blog.Errorf(" option %v not computed option", option)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+
+	computeType, ok := mapOption["type"].(string)
+	if !ok || computeType == "" {
+		blog.Errorf(" computed option %v has no type", option)
+		return errProxy.Errorf(common.CCErrCommParamsNeedSet, "option type")
 	}
 	return nil
 }
@@ -69,3 +69,29 @@ func TestCursorDecode(t *testing.T) {
 	}
 
 }
+
+func TestCursorTypeEncodeDecode(t *testing.T) {
+	for _, typ := range ListCursorTypes() {
+		cursor := Cursor{
+			ClusterTime: types.TimeStamp{Sec: uint32(1588853652)},
+			Oid:         "5eb385974770a118f4922abe",
+			Type:        typ,
+		}
+		encode, err := cursor.Encode()
+		if err != nil {
+			t.Errorf("encode %s cursor failed, err: %v", typ, err)
+			return
+		}
+
+		decoded := new(Cursor)
+		if err := decoded.Decode(encode); err != nil {
+			t.Errorf("decode %s cursor failed, err: %v", typ, err)
+			return
+		}
+
+		if decoded.Type != typ {
+			t.Errorf("decode cursor, got invalid cursor type: %s, expect: %s", decoded.Type, typ)
+			return
+		}
+	}
+}
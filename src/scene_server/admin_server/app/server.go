@@ -78,7 +78,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	service.Config = *process.Config
 	process.Core = engine
 	process.Service = service
-	process.ConfigCenter = configures.NewConfCenter(ctx, engine.ServiceManageClient())
+	process.ConfigCenter = configures.NewConfCenter(ctx, engine.ConfRegDiscv())
 
 	// adminserver conf not depend discovery
 	err = process.ConfigCenter.Start(
@@ -110,4 +110,10 @@ import (
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202011021415"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202011171550"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202011192014"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202011261130"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202012021030"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202012101430"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202012151030"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202012211030"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202012281030"
 )
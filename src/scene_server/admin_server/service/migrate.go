@@ -133,3 +133,125 @@ type MigrateSpecifyVersionRequest struct {
 	TimeStamp int64  `json:"time_stamp"`
 	Version   string `json:"version"`
 }
+
+// migrateDryRun lists the pending migrations and the changes they intend to make without changing the db.
+func (s *Service) migrateDryRun(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	updateCfg := &upgrader.Config{
+		OwnerID:      common.BKDefaultOwnerID,
+		User:         common.CCSystemOperatorUserName,
+		CCApiSrvAddr: s.ccApiSrvAddr,
+	}
+
+	currentVersion, plans, err := upgrader.DryRun(s.ctx, s.db, updateCfg)
+	if err != nil {
+		blog.Errorf("db upgrade dry run failed, err: %+v, rid: %s", err, rid)
+		result := &metadata.RespError{
+			Msg: defErr.Errorf(common.CCErrCommMigrateFailed, err.Error()),
+		}
+		resp.WriteError(http.StatusInternalServerError, result)
+		return
+	}
+
+	result := MigrationPlanResponse{
+		BaseResp:       metadata.SuccessBaseResp,
+		CurrentVersion: currentVersion,
+		Data:           plans,
+	}
+	resp.WriteEntity(result)
+}
+
+// migrateStatus returns the status and the last run of all the migrations.
+func (s *Service) migrateStatus(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	currentVersion, statuses, err := upgrader.Status(s.ctx, s.db)
+	if err != nil {
+		blog.Errorf("get migration status failed, err: %+v, rid: %s", err, rid)
+		result := &metadata.RespError{
+			Msg: defErr.Errorf(common.CCErrCommMigrateFailed, err.Error()),
+		}
+		resp.WriteError(http.StatusInternalServerError, result)
+		return
+	}
+
+	result := MigrationStatusResponse{
+		BaseResp:       metadata.SuccessBaseResp,
+		CurrentVersion: currentVersion,
+		Data:           statuses,
+	}
+	resp.WriteEntity(result)
+}
+
+// migrateDown rolls the db back to the specified version with the migrations' down handlers.
+func (s *Service) migrateDown(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	updateCfg := &upgrader.Config{
+		OwnerID:      common.BKDefaultOwnerID,
+		User:         common.CCSystemOperatorUserName,
+		CCApiSrvAddr: s.ccApiSrvAddr,
+	}
+
+	input := new(MigrateSpecifyVersionRequest)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("migrateDown failed, decode body err: %v, body:%+v,rid:%s", err, req.Request.Body, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	// 不处理十秒前的请求
+	subTS := time.Now().Unix() - input.TimeStamp
+	if subTS > 10 || subTS < 0 {
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid,
+			"time_stamp")})
+		return
+	}
+
+	if input.CommitID != version.CCGitHash {
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid,
+			"commit_id")})
+		return
+	}
+
+	currentVersion, revertedVersions, err := upgrader.Downgrade(s.ctx, s.db, updateCfg, input.Version)
+	if err != nil {
+		blog.Errorf("db downgrade to %s failed, reverted: %v, err: %+v, rid: %s", input.Version, revertedVersions,
+			err, rid)
+		result := &metadata.RespError{
+			Msg: defErr.Errorf(common.CCErrCommMigrateFailed, err.Error()),
+		}
+		resp.WriteError(http.StatusInternalServerError, result)
+		return
+	}
+
+	preVersion := currentVersion
+	if len(revertedVersions) > 0 {
+		preVersion = revertedVersions[0]
+	}
+
+	result := MigrationResponse{
+		BaseResp:         metadata.SuccessBaseResp,
+		Data:             "downgrade success. version: " + input.Version,
+		PreVersion:       preVersion,
+		CurrentVersion:   currentVersion,
+		FinishedVersions: revertedVersions,
+	}
+	resp.WriteEntity(result)
+}
+
+type MigrationPlanResponse struct {
+	metadata.BaseResp `json:",inline"`
+	CurrentVersion    string                   `json:"current_version"`
+	Data              []upgrader.MigrationPlan `json:"data"`
+}
+
+type MigrationStatusResponse struct {
+	metadata.BaseResp `json:",inline"`
+	CurrentVersion    string                     `json:"current_version"`
+	Data              []upgrader.MigrationStatus `json:"data"`
+}
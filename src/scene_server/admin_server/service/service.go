@@ -42,7 +42,8 @@ type Service struct {
 
 func NewService(ctx context.Context) *Service {
 	return &Service{
-		ctx: ctx,
+		// the migrations operate on all the tenants' data, so they are not scoped by tenant.
+		ctx: dal.WithoutTenant(ctx),
 	}
 }
 
@@ -81,6 +82,9 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.GET("/find/system/config_admin").To(s.SearchConfigAdmin))
 	api.Route(api.PUT("/update/system/config_admin").To(s.UpdateConfigAdmin))
 	api.Route(api.POST("/migrate/specify/version/{distribution}/{ownerID}").To(s.migrateSpecifyVersion))
+	api.Route(api.POST("/migrate/dryrun/{distribution}/{ownerID}").To(s.migrateDryRun))
+	api.Route(api.GET("/find/migrate/status").To(s.migrateStatus))
+	api.Route(api.POST("/migrate/down/{distribution}/{ownerID}").To(s.migrateDown))
 	api.Route(api.GET("/healthz").To(s.Healthz))
 
 	container.Add(api)
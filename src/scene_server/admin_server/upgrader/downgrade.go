@@ -0,0 +1,152 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrader
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/storage/dal"
+)
+
+// Downgrade rolls the db back to the target version by running the down handlers of the applied migrations
+// newer than it in the reverse order. It's refused before running anything if any of these migrations has no
+// down handler, and the db's version is saved after each migration is rolled back, so it can be continued
+// after a failure. The migration whose up run failed is partially applied without the db's version being
+// changed, it's rolled back first if it has a down handler, the target version can be the current version
+// to roll back only this migration.
+func Downgrade(ctx context.Context, db dal.RDB, conf *Config, targetVersion string) (currentVersion string,
+	revertedMigrations []string, err error) {
+
+	sort.Slice(upgraderPool, func(i, j int) bool {
+		return VersionCmp(upgraderPool[i].version, upgraderPool[j].version) < 0
+	})
+
+	cmdbVersion, err := getVersion(ctx, db)
+	if err != nil {
+		return "", nil, fmt.Errorf("getVersion failed, err: %s", err.Error())
+	}
+	currentVersion = remapVersion(cmdbVersion.CurrentVersion)
+
+	histories, err := getMigrationHistories(ctx, db)
+	if err != nil {
+		return currentVersion, nil, err
+	}
+
+	failedVersion := failedUpVersion(currentVersion, histories)
+	reverts, err := downgradeVersions(currentVersion, targetVersion, failedVersion)
+	if err != nil {
+		return currentVersion, nil, err
+	}
+
+	revertedMigrations = make([]string, 0)
+	for idx, version := range reverts {
+		blog.Infof("run down migration: %s", version)
+		down := downgraderPool[version]
+		err = runWithHistory(ctx, db, version, MigrationDirectionDown, func() error {
+			return down(ctx, db, conf)
+		})
+		if err != nil {
+			blog.Errorf("downgrade version %s error: %s", version, err.Error())
+			return currentVersion, revertedMigrations, fmt.Errorf("run down migration %s failed, err: %s", version,
+				err.Error())
+		}
+
+		// the version before the reverted one is the current version now
+		previous := targetVersion
+		if idx+1 < len(reverts) {
+			previous = reverts[idx+1]
+		}
+		cmdbVersion.CurrentVersion = previous
+		if err = saveVersion(ctx, db, cmdbVersion); err != nil {
+			blog.Errorf("save version %s error: %s", previous, err.Error())
+			return currentVersion, revertedMigrations, fmt.Errorf("saveVersion failed, err: %s", err.Error())
+		}
+		currentVersion = previous
+		revertedMigrations = append(revertedMigrations, version)
+		blog.Infof("downgrade version %s success, current version: %s", version, previous)
+	}
+
+	return currentVersion, revertedMigrations, nil
+}
+
+// failedUpVersion returns the latest migration newer than the current version whose last run is a failed up run
+// and which has a down handler, it returns empty if there is none. The histories are in the order of their runs.
+func failedUpVersion(currentVersion string, histories []MigrationHistory) string {
+	lastRuns := lastMigrationRuns(histories)
+	for idx := len(upgraderPool) - 1; idx >= 0; idx-- {
+		version := upgraderPool[idx].version
+		if VersionCmp(version, currentVersion) <= 0 {
+			break
+		}
+		lastRun, exist := lastRuns[version]
+		if !exist || lastRun.Direction != MigrationDirectionUp || lastRun.Result != MigrationResultFailed {
+			continue
+		}
+		if _, exist := downgraderPool[version]; !exist {
+			blog.Warnf("migration %s failed to upgrade, but it has no down handler to roll back", version)
+			continue
+		}
+		return version
+	}
+	return ""
+}
+
+// downgradeVersions returns the versions to roll back from the current version to the target version,
+// the newest one goes first. The failed version is the partially applied migration returned by
+// failedUpVersion, it goes before all the others if it's not empty.
+func downgradeVersions(currentVersion, targetVersion, failedVersion string) ([]string, error) {
+	targetExist := false
+	for _, v := range upgraderPool {
+		if v.version == targetVersion {
+			targetExist = true
+			break
+		}
+	}
+	if !targetExist {
+		return nil, fmt.Errorf("target version %s is not a registered migration", targetVersion)
+	}
+	cmp := VersionCmp(targetVersion, currentVersion)
+	if cmp > 0 || (cmp == 0 && failedVersion == "") {
+		return nil, fmt.Errorf("target version %s is not earlier than the current version %s", targetVersion,
+			currentVersion)
+	}
+
+	reverts := make([]string, 0)
+	if failedVersion != "" {
+		reverts = append(reverts, failedVersion)
+	}
+	missing := make([]string, 0)
+	for idx := len(upgraderPool) - 1; idx >= 0; idx-- {
+		version := upgraderPool[idx].version
+		if VersionCmp(version, currentVersion) > 0 {
+			continue
+		}
+		if VersionCmp(version, targetVersion) <= 0 {
+			break
+		}
+		if _, exist := downgraderPool[version]; !exist {
+			missing = append(missing, version)
+		}
+		reverts = append(reverts, version)
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("migrations %s have no down handler, can not downgrade to %s",
+			strings.Join(missing, ","), targetVersion)
+	}
+	return reverts, nil
+}
@@ -0,0 +1,152 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrader
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"configcenter/src/storage/dal"
+)
+
+func setupMigrations(t *testing.T, versions []string, downs []string) {
+	oldUpgraders, oldDowngraders := upgraderPool, downgraderPool
+	t.Cleanup(func() {
+		upgraderPool, downgraderPool = oldUpgraders, oldDowngraders
+	})
+
+	upgraderPool = make([]Upgrader, 0)
+	for _, version := range versions {
+		upgraderPool = append(upgraderPool, Upgrader{version: version})
+	}
+	downgraderPool = make(map[string]func(context.Context, dal.RDB, *Config) error)
+	for _, version := range downs {
+		downgraderPool[version] = func(context.Context, dal.RDB, *Config) error { return nil }
+	}
+}
+
+func TestDowngradeVersions(t *testing.T) {
+	versions := []string{"y3.9.202011021415", "y3.9.202011171550", "y3.9.202011261130", "y3.9.202012021030"}
+	setupMigrations(t, versions, []string{"y3.9.202011261130", "y3.9.202012021030"})
+
+	reverts, err := downgradeVersions("y3.9.202012021030", "y3.9.202011171550", "")
+	if err != nil {
+		t.Fatalf("downgrade versions failed, err: %v", err)
+	}
+	expect := []string{"y3.9.202012021030", "y3.9.202011261130"}
+	if !reflect.DeepEqual(reverts, expect) {
+		t.Fatalf("expect reverts %v, got %v", expect, reverts)
+	}
+
+	// the migrations newer than the current version are not applied, so they are not reverted
+	reverts, err = downgradeVersions("y3.9.202011261130", "y3.9.202011171550", "")
+	if err != nil {
+		t.Fatalf("downgrade versions failed, err: %v", err)
+	}
+	if !reflect.DeepEqual(reverts, []string{"y3.9.202011261130"}) {
+		t.Fatalf("expect reverts [y3.9.202011261130], got %v", reverts)
+	}
+
+	if _, err = downgradeVersions("y3.9.202012021030", "y3.9.202011021415", ""); err == nil {
+		t.Fatalf("expect downgrade refused since y3.9.202011171550 has no down handler")
+	}
+	if _, err = downgradeVersions("y3.9.202011171550", "y3.9.202011261130", ""); err == nil {
+		t.Fatalf("expect downgrade refused since the target is not earlier than the current version")
+	}
+	if _, err = downgradeVersions("y3.9.202012021030", "y3.9.202011171551", ""); err == nil {
+		t.Fatalf("expect downgrade refused since the target is not registered")
+	}
+}
+
+func TestDowngradeFailedUpVersion(t *testing.T) {
+	versions := []string{"y3.9.202011171550", "y3.9.202011261130", "y3.9.202012021030", "y3.9.202012101430"}
+	setupMigrations(t, versions, []string{"y3.9.202011261130", "y3.9.202012021030"})
+
+	// y3.9.202012021030 failed to upgrade after y3.9.202011261130 is applied
+	histories := []MigrationHistory{
+		{Version: "y3.9.202011261130", Direction: MigrationDirectionUp, Result: MigrationResultSuccess},
+		{Version: "y3.9.202012021030", Direction: MigrationDirectionUp, Result: MigrationResultFailed},
+	}
+	failed := failedUpVersion("y3.9.202011261130", histories)
+	if failed != "y3.9.202012021030" {
+		t.Fatalf("expect failed version y3.9.202012021030, got %s", failed)
+	}
+
+	// the failed migration is rolled back first, then the applied ones
+	reverts, err := downgradeVersions("y3.9.202011261130", "y3.9.202011171550", failed)
+	if err != nil {
+		t.Fatalf("downgrade versions failed, err: %v", err)
+	}
+	expect := []string{"y3.9.202012021030", "y3.9.202011261130"}
+	if !reflect.DeepEqual(reverts, expect) {
+		t.Fatalf("expect reverts %v, got %v", expect, reverts)
+	}
+
+	// only the failed migration is rolled back if the target is the current version
+	reverts, err = downgradeVersions("y3.9.202011261130", "y3.9.202011261130", failed)
+	if err != nil {
+		t.Fatalf("downgrade versions failed, err: %v", err)
+	}
+	if !reflect.DeepEqual(reverts, []string{"y3.9.202012021030"}) {
+		t.Fatalf("expect reverts [y3.9.202012021030], got %v", reverts)
+	}
+
+	// the failed migration which has been rolled back or retried successfully is not rolled back again
+	rolledBack := append(histories, MigrationHistory{Version: "y3.9.202012021030",
+		Direction: MigrationDirectionDown, Result: MigrationResultSuccess})
+	if failed := failedUpVersion("y3.9.202011261130", rolledBack); failed != "" {
+		t.Fatalf("expect no failed version after it's rolled back, got %s", failed)
+	}
+
+	// the failed migration without a down handler can not be rolled back
+	noDown := []MigrationHistory{
+		{Version: "y3.9.202012101430", Direction: MigrationDirectionUp, Result: MigrationResultFailed},
+	}
+	if failed := failedUpVersion("y3.9.202012021030", noDown); failed != "" {
+		t.Fatalf("expect no failed version without a down handler, got %s", failed)
+	}
+
+	// the migrations not newer than the current version are applied, their failed runs are retried ones
+	if failed := failedUpVersion("y3.9.202012021030", histories); failed != "" {
+		t.Fatalf("expect no failed version not newer than the current version, got %s", failed)
+	}
+}
+
+func TestChangeRecorder(t *testing.T) {
+	recorder := &changeRecorder{changes: make([]Change, 0)}
+	table := &recordingTable{name: "cc_Test", recorder: recorder}
+	db := &recordingDB{recorder: recorder}
+
+	ctx := context.Background()
+	_ = db.CreateTable(ctx, "cc_Test")
+	_ = table.Insert(ctx, []map[string]interface{}{{"a": 1}, {"a": 2}})
+	_ = table.Insert(ctx, map[string]interface{}{"a": 3})
+	_ = table.Update(ctx, map[string]interface{}{"a": 1}, map[string]interface{}{"a": 4})
+	_ = table.DropColumn(ctx, "b")
+
+	expect := []Change{
+		{Table: "cc_Test", Action: ChangeActionCreateTable},
+		{Table: "cc_Test", Action: ChangeActionInsert, Count: 3},
+		{Table: "cc_Test", Action: ChangeActionUpdate, Count: 1},
+		{Table: "cc_Test", Action: ChangeActionDropColumn, Detail: "b"},
+	}
+	if !reflect.DeepEqual(recorder.changes, expect) {
+		t.Fatalf("expect changes %+v, got %+v", expect, recorder.changes)
+	}
+
+	sequences, _ := db.NextSequences(ctx, "cc_Test", 2)
+	if !reflect.DeepEqual(sequences, []uint64{1, 2}) {
+		t.Fatalf("expect fake sequences [1 2], got %v", sequences)
+	}
+}
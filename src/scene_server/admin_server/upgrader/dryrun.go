@@ -0,0 +1,315 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrader
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/dal/types"
+)
+
+// ChangeAction the kind of the change a migration makes to the db
+type ChangeAction string
+
+const (
+	ChangeActionCreateTable  ChangeAction = "create_table"
+	ChangeActionDropTable    ChangeAction = "drop_table"
+	ChangeActionCreateIndex  ChangeAction = "create_index"
+	ChangeActionDropIndex    ChangeAction = "drop_index"
+	ChangeActionAddColumn    ChangeAction = "add_column"
+	ChangeActionRenameColumn ChangeAction = "rename_column"
+	ChangeActionDropColumn   ChangeAction = "drop_column"
+	ChangeActionInsert       ChangeAction = "insert"
+	ChangeActionUpdate       ChangeAction = "update"
+	ChangeActionUpsert       ChangeAction = "upsert"
+	ChangeActionDelete       ChangeAction = "delete"
+)
+
+// dataActions the changes of the documents, they are merged by table and action because a migration may
+// change a lot of documents one by one.
+var dataActions = map[ChangeAction]bool{
+	ChangeActionInsert: true,
+	ChangeActionUpdate: true,
+	ChangeActionUpsert: true,
+	ChangeActionDelete: true,
+}
+
+// Change a change the migration intends to make to the db
+type Change struct {
+	Table  string       `json:"table"`
+	Action ChangeAction `json:"action"`
+	Detail string       `json:"detail,omitempty"`
+	// Count the times of the document changes, the documents of an insert are counted one by one
+	Count int `json:"count,omitempty"`
+}
+
+// MigrationPlan the changes a pending migration intends to make
+type MigrationPlan struct {
+	Version string   `json:"version"`
+	Changes []Change `json:"changes"`
+	// HasDown whether the migration has a down handler to roll it back
+	HasDown bool `json:"has_down"`
+	// Error the reason why the migration can't be fully previewed, the changes recorded before it's
+	// stopped are still returned
+	Error string `json:"error,omitempty"`
+}
+
+// DryRun runs the pending migrations without changing the db, the reads are done with the db while the writes
+// are recorded as the migration's intended changes. Since the writes are not applied, a migration reading what
+// itself or the previous migrations wrote may behave differently from the real run, and the migrations changing
+// the redis cache or the apiserver are not run.
+func DryRun(ctx context.Context, db dal.RDB, conf *Config) (currentVersion string, plans []MigrationPlan,
+	err error) {
+
+	sort.Slice(upgraderPool, func(i, j int) bool {
+		return VersionCmp(upgraderPool[i].version, upgraderPool[j].version) < 0
+	})
+
+	cmdbVersion, err := getVersion(ctx, db)
+	if err != nil {
+		return "", nil, fmt.Errorf("getVersion failed, err: %s", err.Error())
+	}
+	currentVersion = remapVersion(cmdbVersion.CurrentVersion)
+
+	// the apiserver can't be previewed, so the migrations calling it are failed instead of changing it
+	dryRunConf := *conf
+	dryRunConf.CCApiSrvAddr = ""
+
+	plans = make([]MigrationPlan, 0)
+	for _, v := range upgraderPool {
+		if VersionCmp(v.version, currentVersion) <= 0 {
+			continue
+		}
+		plans = append(plans, dryRunMigration(ctx, db, &dryRunConf, v))
+	}
+	return currentVersion, plans, nil
+}
+
+// dryRunMigration runs the migration with the recording db, a panic of the migration is recovered as it may
+// not expect the fake results of the writes.
+func dryRunMigration(ctx context.Context, db dal.RDB, conf *Config, v Upgrader) (plan MigrationPlan) {
+	recorder := &changeRecorder{changes: make([]Change, 0)}
+	plan.Version = v.version
+	_, plan.HasDown = downgraderPool[v.version]
+
+	defer func() {
+		if r := recover(); r != nil {
+			blog.Errorf("dry run migration %s panic: %v", v.version, r)
+			plan.Error = fmt.Sprintf("migration panic: %v", r)
+		}
+		plan.Changes = recorder.changes
+	}()
+
+	if v.useCache {
+		plan.Error = "the migration changes the redis cache, which can not be previewed"
+		return plan
+	}
+
+	blog.Infof("dry run migration: %s", v.version)
+	if err := v.do(ctx, &recordingDB{RDB: db, recorder: recorder}, nil, conf); err != nil {
+		blog.Errorf("dry run migration %s failed, err: %v", v.version, err)
+		plan.Error = err.Error()
+	}
+	return plan
+}
+
+// changeRecorder records the changes the migration intends to make.
+type changeRecorder struct {
+	lock    sync.Mutex
+	changes []Change
+	// sequence the fake sequence returned to the migration
+	sequence uint64
+}
+
+func (r *changeRecorder) record(table string, action ChangeAction, detail string, count int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if dataActions[action] {
+		for idx := range r.changes {
+			if r.changes[idx].Table == table && r.changes[idx].Action == action {
+				r.changes[idx].Count += count
+				return
+			}
+		}
+	}
+	r.changes = append(r.changes, Change{Table: table, Action: action, Detail: detail, Count: count})
+}
+
+func (r *changeRecorder) nextSequence() uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.sequence++
+	return r.sequence
+}
+
+// recordingDB reads with the real db and records the writes instead of doing them.
+type recordingDB struct {
+	dal.RDB
+	recorder *changeRecorder
+}
+
+// Table returns the recording table
+func (d *recordingDB) Table(collection string) types.Table {
+	return &recordingTable{Table: d.RDB.Table(collection), name: collection, recorder: d.recorder}
+}
+
+// NextSequence returns a fake sequence, so that the sequence in the db is not increased
+func (d *recordingDB) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	return d.recorder.nextSequence(), nil
+}
+
+// NextSequences returns the fake sequences, so that the sequence in the db is not increased
+func (d *recordingDB) NextSequences(ctx context.Context, sequenceName string, num int) ([]uint64, error) {
+	sequences := make([]uint64, num)
+	for idx := range sequences {
+		sequences[idx] = d.recorder.nextSequence()
+	}
+	return sequences, nil
+}
+
+// DropTable records the table to drop
+func (d *recordingDB) DropTable(ctx context.Context, name string) error {
+	d.recorder.record(name, ChangeActionDropTable, "", 0)
+	return nil
+}
+
+// CreateTable records the table to create
+func (d *recordingDB) CreateTable(ctx context.Context, name string) error {
+	d.recorder.record(name, ChangeActionCreateTable, "", 0)
+	return nil
+}
+
+// Close does nothing, the real db is still used after the dry run
+func (d *recordingDB) Close() error {
+	return nil
+}
+
+// CommitTransaction does nothing, since the writes are not done
+func (d *recordingDB) CommitTransaction(context.Context, *metadata.TxnCapable) error {
+	return nil
+}
+
+// AbortTransaction does nothing, since the writes are not done
+func (d *recordingDB) AbortTransaction(context.Context, *metadata.TxnCapable) error {
+	return nil
+}
+
+// InitTxnManager does nothing, the real db's transaction manager is already initialized
+func (d *recordingDB) InitTxnManager(r redis.Client) error {
+	return nil
+}
+
+// recordingTable reads with the real table and records the writes instead of doing them.
+type recordingTable struct {
+	types.Table
+	name     string
+	recorder *changeRecorder
+}
+
+// Insert records the documents to insert
+func (t *recordingTable) Insert(ctx context.Context, docs interface{}) error {
+	count := 1
+	value := reflect.ValueOf(docs)
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		count = value.Len()
+	}
+	t.recorder.record(t.name, ChangeActionInsert, "", count)
+	return nil
+}
+
+// Update records the update
+func (t *recordingTable) Update(ctx context.Context, filter types.Filter, doc interface{}) error {
+	t.recorder.record(t.name, ChangeActionUpdate, "", 1)
+	return nil
+}
+
+// Upsert records the upsert
+func (t *recordingTable) Upsert(ctx context.Context, filter types.Filter, doc interface{}) error {
+	t.recorder.record(t.name, ChangeActionUpsert, "", 1)
+	return nil
+}
+
+// UpdateMultiModel records the update
+func (t *recordingTable) UpdateMultiModel(ctx context.Context, filter types.Filter,
+	updateModel ...types.ModeUpdate) error {
+
+	t.recorder.record(t.name, ChangeActionUpdate, "", 1)
+	return nil
+}
+
+// Delete records the deletion
+func (t *recordingTable) Delete(ctx context.Context, filter types.Filter) error {
+	t.recorder.record(t.name, ChangeActionDelete, "", 1)
+	return nil
+}
+
+// CreateIndex records the index to create
+func (t *recordingTable) CreateIndex(ctx context.Context, index types.Index) error {
+	keys := make([]string, 0)
+	for key, order := range index.Keys {
+		keys = append(keys, fmt.Sprintf("%s:%d", key, order))
+	}
+	sort.Strings(keys)
+	detail := fmt.Sprintf("%s{%s}", index.Name, strings.Join(keys, ","))
+	if index.Unique {
+		detail += " unique"
+	}
+	t.recorder.record(t.name, ChangeActionCreateIndex, detail, 0)
+	return nil
+}
+
+// DropIndex records the index to drop
+func (t *recordingTable) DropIndex(ctx context.Context, indexName string) error {
+	t.recorder.record(t.name, ChangeActionDropIndex, indexName, 0)
+	return nil
+}
+
+// AddColumn records the column to add
+func (t *recordingTable) AddColumn(ctx context.Context, column string, value interface{}) error {
+	t.recorder.record(t.name, ChangeActionAddColumn, column, 0)
+	return nil
+}
+
+// RenameColumn records the column to rename
+func (t *recordingTable) RenameColumn(ctx context.Context, oldName, newColumn string) error {
+	t.recorder.record(t.name, ChangeActionRenameColumn, oldName+"->"+newColumn, 0)
+	return nil
+}
+
+// DropColumn records the column to drop
+func (t *recordingTable) DropColumn(ctx context.Context, field string) error {
+	t.recorder.record(t.name, ChangeActionDropColumn, field, 0)
+	return nil
+}
+
+// DropColumns records the columns to drop
+func (t *recordingTable) DropColumns(ctx context.Context, filter types.Filter, fields []string) error {
+	t.recorder.record(t.name, ChangeActionDropColumn, strings.Join(fields, ","), 0)
+	return nil
+}
+
+// DropDocsColumn records the column to drop
+func (t *recordingTable) DropDocsColumn(ctx context.Context, field string, filter types.Filter) error {
+	t.recorder.record(t.name, ChangeActionDropColumn, field, 0)
+	return nil
+}
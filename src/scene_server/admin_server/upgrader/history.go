@@ -0,0 +1,156 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrader
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/storage/dal"
+)
+
+// MigrationDirection the direction a migration runs in
+type MigrationDirection string
+
+const (
+	MigrationDirectionUp   MigrationDirection = "up"
+	MigrationDirectionDown MigrationDirection = "down"
+)
+
+// MigrationResult the result of a migration's run
+type MigrationResult string
+
+const (
+	MigrationResultSuccess MigrationResult = "success"
+	MigrationResultFailed  MigrationResult = "failed"
+)
+
+// MigrationHistory a run of the migration, each run of the upgrader or downgrader is recorded.
+type MigrationHistory struct {
+	Version   string             `json:"version" bson:"version"`
+	Direction MigrationDirection `json:"direction" bson:"direction"`
+	Result    MigrationResult    `json:"result" bson:"result"`
+	Error     string             `json:"error,omitempty" bson:"error,omitempty"`
+	StartTime time.Time          `json:"start_time" bson:"start_time"`
+	EndTime   time.Time          `json:"end_time" bson:"end_time"`
+	// Duration the milliseconds the run costs
+	Duration int64 `json:"duration" bson:"duration"`
+}
+
+// runWithHistory runs the migration and records it's timing and result, failing to record the history
+// doesn't fail the migration.
+func runWithHistory(ctx context.Context, db dal.RDB, version string, direction MigrationDirection,
+	run func() error) error {
+
+	history := MigrationHistory{
+		Version:   version,
+		Direction: direction,
+		Result:    MigrationResultSuccess,
+		StartTime: time.Now(),
+	}
+
+	err := run()
+
+	history.EndTime = time.Now()
+	history.Duration = int64(history.EndTime.Sub(history.StartTime) / time.Millisecond)
+	if err != nil {
+		history.Result = MigrationResultFailed
+		history.Error = err.Error()
+	}
+	if insertErr := db.Table(common.BKTableNameMigrationHistory).Insert(ctx, history); insertErr != nil {
+		blog.Errorf("save migration %s %s history failed, err: %v", version, direction, insertErr)
+	}
+
+	return err
+}
+
+// MigrationState the state of the migration compared with the db's current version
+type MigrationState string
+
+const (
+	MigrationStateApplied MigrationState = "applied"
+	MigrationStatePending MigrationState = "pending"
+)
+
+// MigrationStatus the status of a registered migration
+type MigrationStatus struct {
+	Version string         `json:"version"`
+	State   MigrationState `json:"state"`
+	// HasDown whether the migration has a down handler to roll it back
+	HasDown bool `json:"has_down"`
+	// LastRun the last run of the migration, it's nil if the migration has never been run since
+	// the history is recorded.
+	LastRun *MigrationHistory `json:"last_run"`
+}
+
+// Status returns the db's current version and the status of all the registered migrations in order.
+func Status(ctx context.Context, db dal.RDB) (string, []MigrationStatus, error) {
+	sort.Slice(upgraderPool, func(i, j int) bool {
+		return VersionCmp(upgraderPool[i].version, upgraderPool[j].version) < 0
+	})
+
+	cmdbVersion, err := getVersion(ctx, db)
+	if err != nil {
+		return "", nil, fmt.Errorf("getVersion failed, err: %s", err.Error())
+	}
+	currentVersion := remapVersion(cmdbVersion.CurrentVersion)
+
+	histories, err := getMigrationHistories(ctx, db)
+	if err != nil {
+		return "", nil, err
+	}
+	lastRuns := lastMigrationRuns(histories)
+
+	statuses := make([]MigrationStatus, 0)
+	for _, v := range upgraderPool {
+		status := MigrationStatus{
+			Version: v.version,
+			State:   MigrationStatePending,
+		}
+		if VersionCmp(v.version, currentVersion) <= 0 {
+			status.State = MigrationStateApplied
+		}
+		if _, exist := downgraderPool[v.version]; exist {
+			status.HasDown = true
+		}
+		if lastRun, exist := lastRuns[v.version]; exist {
+			status.LastRun = &lastRun
+		}
+		statuses = append(statuses, status)
+	}
+	return currentVersion, statuses, nil
+}
+
+// getMigrationHistories returns all the runs of the migrations in the order of their start time.
+func getMigrationHistories(ctx context.Context, db dal.RDB) ([]MigrationHistory, error) {
+	histories := make([]MigrationHistory, 0)
+	cond := map[string]interface{}{}
+	err := db.Table(common.BKTableNameMigrationHistory).Find(cond).Sort("start_time").All(ctx, &histories)
+	if err != nil {
+		return nil, fmt.Errorf("get migration history failed, err: %s", err.Error())
+	}
+	return histories, nil
+}
+
+// lastMigrationRuns returns the last run of each migration, the histories are in the order of their runs.
+func lastMigrationRuns(histories []MigrationHistory) map[string]MigrationHistory {
+	lastRuns := make(map[string]MigrationHistory)
+	for _, history := range histories {
+		lastRuns[history.Version] = history
+	}
+	return lastRuns
+}
@@ -40,10 +40,15 @@ type Config struct {
 type Upgrader struct {
 	version string // v3.0.8-beta.11
 	do      func(context.Context, dal.RDB, redis.Client, *Config) error
+	// useCache the upgrader changes the redis cache, which can't be previewed in the dry run
+	useCache bool
 }
 
 var upgraderPool = []Upgrader{}
 
+// downgraderPool the optional down handlers of the migrations, the key is the migration's version
+var downgraderPool = map[string]func(context.Context, dal.RDB, *Config) error{}
+
 var registLock sync.Mutex
 
 /*
@@ -186,10 +191,21 @@ func RegisterUpgraderWithRedis(version string, handlerFunc func(context.Context,
 	}
 	registLock.Lock()
 	defer registLock.Unlock()
-	v := Upgrader{version: version, do: handlerFunc}
+	v := Upgrader{version: version, do: handlerFunc, useCache: true}
 	upgraderPool = append(upgraderPool, v)
 }
 
+// RegistDowngrader register the down handler of the migration, it reverts what the migration's upgrader did,
+// so that a failed upgrade can be rolled back without restoring the whole db.
+func RegistDowngrader(version string, handlerFunc func(context.Context, dal.RDB, *Config) error) {
+	if err := ValidateMigrationVersionFormat(version); err != nil {
+		blog.Fatalf("ValidateMigrationVersionFormat failed, err: %s", err.Error())
+	}
+	registLock.Lock()
+	defer registLock.Unlock()
+	downgraderPool[version] = handlerFunc
+}
+
 // Upgrade upgrade the db data to newest version
 // we use date instead of version later since 2018.09.04, because the version wasn't manage by the developer
 // ps: when use date instead of version, the date should add x prefix cause x > v
@@ -215,7 +231,9 @@ func Upgrade(ctx context.Context, db dal.RDB, cache redis.Client, conf *Config)
 			continue
 		}
 		blog.Infof(`run migration: %s`, v.version)
-		err = v.do(ctx, db, cache, conf)
+		err = runWithHistory(ctx, db, v.version, MigrationDirectionUp, func() error {
+			return v.do(ctx, db, cache, conf)
+		})
 		if err != nil {
 			blog.Errorf("upgrade version %s error: %s", v.version, err.Error())
 			return currentVersion, finishedMigrations, fmt.Errorf("run migration %s failed, err: %s", v.version, err.Error())
@@ -252,7 +270,9 @@ func UpgradeSpecifyVersion(ctx context.Context, db dal.RDB, cache redis.Client,
 			continue
 		}
 		blog.Infof(`run specify migration: %s`, v.version)
-		err = v.do(ctx, db, cache, conf)
+		err = runWithHistory(ctx, db, v.version, MigrationDirectionUp, func() error {
+			return v.do(ctx, db, cache, conf)
+		})
 		if err != nil {
 			blog.Errorf("upgrade specify version %s error: %s", v.version, err.Error())
 			return fmt.Errorf("run specify migration %s failed, err: %s", v.version, err.Error())
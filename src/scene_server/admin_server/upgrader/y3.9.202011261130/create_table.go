@@ -0,0 +1,73 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_9_202011261130
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+
+	"gopkg.in/mgo.v2"
+)
+
+// createTable create the tables of the attribute schema migration
+func createTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) (err error) {
+	for tableName, indexes := range tables {
+		exists, err := db.HasTable(ctx, tableName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err = db.CreateTable(ctx, tableName); err != nil && !mgo.IsDup(err) {
+				return err
+			}
+		}
+		for index := range indexes {
+			if err = db.Table(tableName).CreateIndex(ctx, indexes[index]); err != nil && !db.IsDuplicatedError(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dropTable drop the tables of the attribute schema migration, the data in them is dropped too
+func dropTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) (err error) {
+	for tableName := range tables {
+		exists, err := db.HasTable(ctx, tableName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		if err = db.DropTable(ctx, tableName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var tables = map[string][]types.Index{
+	common.BKTableNameAttributeSchemaMigration: {
+		types.Index{Name: "idx_migrationID", Keys: map[string]int32{"migration_id": 1}, Background: true, Unique: true},
+		types.Index{Name: "idx_objID", Keys: map[string]int32{common.BKObjIDField: 1}, Background: true},
+	},
+	common.BKTableNameAttributeSchemaBackup: {
+		types.Index{Name: "idx_migrationID_instID", Keys: map[string]int32{"migration_id": 1, common.BKInstIDField: 1},
+			Background: true, Unique: true},
+	},
+}
@@ -0,0 +1,75 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_9_202012101430
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+)
+
+var (
+	// newBindInfoOption the process bind ip could be ipv4 or ipv6 address, and the port could be
+	// a comma separated list of ports and port ranges.
+	newBindInfoOption = map[string]string{
+		common.BKIP:   `^(([0-9]{1,3}\.){3}[0-9]{1,3}|[0-9a-fA-F:]*:[0-9a-fA-F:.]*)$`,
+		common.BKPort: metadata.ProcessPortFormat.String(),
+	}
+
+	oldBindInfoOption = map[string]string{
+		common.BKIP: "^([0-9]{1,3}\\.){3}[0-9]{1,3}$",
+		common.BKPort: "^(((([1-9][0-9]{0,3})|([1-5][0-9]{4})|(6[0-4][0-9]{3})|(65[0-4][0-9]{2})|(655[0-2][0-9])|" +
+			"(6553[0-5]))-(([1-9][0-9]{0,3})|([1-5][0-9]{4})|(6[0-4][0-9]{3})|(65[0-4][0-9]{2})|(655[0-2][0-9])|" +
+			"(6553[0-5])))|((([1-9][0-9]{0,3})|([1-5][0-9]{4})|(6[0-4][0-9]{3})|(65[0-4][0-9]{2})|(655[0-2][0-9])|" +
+			"(6553[0-5]))))$",
+	}
+)
+
+type bindInfoAttribute struct {
+	ID     int64                   `bson:"id"`
+	Option []metadata.SubAttribute `bson:"option"`
+}
+
+// updateBindInfoOption update the regular expression options of the ip and port of process bind info attribute
+func updateBindInfoOption(ctx context.Context, db dal.RDB, options map[string]string) error {
+	filter := map[string]interface{}{
+		common.BKObjIDField:      common.BKInnerObjIDProc,
+		common.BKPropertyIDField: common.BKProcBindInfo,
+	}
+
+	attrs := make([]bindInfoAttribute, 0)
+	if err := db.Table(common.BKTableNameObjAttDes).Find(filter).Fields(common.BKFieldID, "option").
+		All(ctx, &attrs); err != nil {
+		blog.Errorf("find process bind info attribute failed, err: %v", err)
+		return err
+	}
+
+	for _, attr := range attrs {
+		for idx := range attr.Option {
+			if option, exists := options[attr.Option[idx].PropertyID]; exists {
+				attr.Option[idx].Option = option
+			}
+		}
+
+		updateFilter := map[string]interface{}{common.BKFieldID: attr.ID}
+		doc := map[string]interface{}{"option": attr.Option}
+		if err := db.Table(common.BKTableNameObjAttDes).Update(ctx, updateFilter, doc); err != nil {
+			blog.Errorf("update process bind info attribute %d option failed, err: %v", attr.ID, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -25,10 +25,12 @@ import (
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/cryptor"
+	"configcenter/src/common/secret"
 	"configcenter/src/common/types"
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/cloud_server/app/options"
 	"configcenter/src/scene_server/cloud_server/cloudsync"
+	ccom "configcenter/src/scene_server/cloud_server/common"
 	"configcenter/src/scene_server/cloud_server/logics"
 	svc "configcenter/src/scene_server/cloud_server/service"
 	"configcenter/src/thirdparty/secrets"
@@ -80,18 +82,16 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 
 	blog.Infof("enable auth center: %v", auth.EnableAuthorize())
 
-	var accountCryptor cryptor.Cryptor
+	var secretProvider secret.SecretProvider
 	blog.Infof("enable cryptor: %v", op.EnableCryptor)
 	if op.EnableCryptor == true {
-		secretKey, err := process.getSecretKey()
+		secretProvider, err = process.newSecretProvider()
 		if err != nil {
-			blog.Errorf("getSecretKey failed, err: %s", err.Error())
+			blog.Errorf("newSecretProvider failed, err: %s", err.Error())
 			return err
 		}
-		accountCryptor = cryptor.NewAesEncrpytor(secretKey)
 	}
 
-	process.Service.SetEncryptor(accountCryptor)
 	process.Service.EnableTxn = op.EnableTxn
 
 	authorizer := iam.NewAuthorizer(engine.CoreAPI)
@@ -99,7 +99,12 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 
 	mongoConf := mongoConfig.GetMongoConf()
 
-	process.Service.Logics = logics.NewLogics(service.Engine, accountCryptor, authorizer)
+	process.Service.Logics = logics.NewLogics(service.Engine, secretProvider, authorizer)
+	go func() {
+		if err := process.Service.Logics.RotateAccountSecrets(ccom.NewKit()); err != nil {
+			blog.Errorf("rotate cloud account secrets failed, err: %v", err)
+		}
+	}()
 
 	process.setSyncPeriod()
 	syncConf := cloudsync.SyncConf{
@@ -145,6 +150,31 @@ func (c *CloudServer) onCloudConfigUpdate(previous, current cc.ProcessConfig) {
 	c.Config.SyncPeriodMinutes, _ = cc.Int("cloudServer.syncTask.syncPeriodMinutes")
 }
 
+// newSecretProvider 根据cloudServer.cryptor.provider配置生成云账户密钥的存储后端
+func (c *CloudServer) newSecretProvider() (secret.SecretProvider, error) {
+	conf, err := secret.ParseConfig("cloudServer.cryptor")
+	if err != nil {
+		return nil, err
+	}
+
+	// secrets后端使用bk-secrets服务中的密钥加密，keyring后端配置了bk-secrets服务时用于解密历史密钥
+	var legacy cryptor.Cryptor
+	if conf.Provider == secret.ProviderSecrets || (conf.Provider == secret.ProviderKeyring && c.Config.SecretKeyUrl != "") {
+		secretKey, err := c.getSecretKey()
+		if err != nil {
+			return nil, fmt.Errorf("getSecretKey failed, err: %v", err)
+		}
+		legacy = cryptor.NewAesEncrpytor(secretKey)
+	}
+
+	provider, err := secret.NewSecretProvider(conf, legacy)
+	if err != nil {
+		return nil, err
+	}
+	blog.Infof("cloud account secret provider: %s", provider.Name())
+	return provider, nil
+}
+
 // getSecretKey get the secret key from bk-secrets service
 func (c *CloudServer) getSecretKey() (string, error) {
 	if c.Config.SecretKeyUrl == "" {
@@ -18,6 +18,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 	"configcenter/src/common/zkclient"
 	"configcenter/src/scene_server/cloud_server/logics"
 	"configcenter/src/storage/dal/mongo/local"
@@ -88,14 +89,30 @@ func SyncCloudResource(taskChan chan *metadata.CloudSyncTask, conf *SyncConf) {
 		}(syncor)
 	}
 
+	// 非主机云资源channel
+	resourceChan := make(chan *metadata.CloudSyncTask, 10)
+
+	// 非主机云资源同步器处理同步任务
+	for i := 1; i <= syncorNum; i++ {
+		syncor := NewResourceSyncor(conf.Logics)
+		go func(syncor *ResourceSyncor) {
+			for {
+				task := <-resourceChan
+				syncor.Sync(task)
+			}
+		}(syncor)
+	}
+
 	// 根据任务类型，将任务放入不同的任务channel
 	go func() {
 		for {
 			if task, ok := <-taskChan; ok {
 				blog.V(4).Infof("processing taskid:%d, resource type:%s", task.TaskID, task.ResourceType)
-				switch task.ResourceType {
-				case "host":
+				switch {
+				case task.ResourceType == metadata.CloudResourceTypeHost:
 					hostChan <- task
+				case util.InStrArr(metadata.SupportedCloudResourceTypes, task.ResourceType):
+					resourceChan <- task
 				default:
 					blog.Errorf("unknown resource type:%s, ignore it!", task.ResourceType)
 				}
@@ -24,6 +24,15 @@ type HostSyncor struct {
 	readKit *rest.Kit
 	// writeKit used for write operation
 	writeKit *rest.Kit
+
+	// 同步任务的开发商ID，标签映射规则只匹配该开发商的拓扑
+	ownerID string
+	// 账号的标签映射规则
+	tagRules []metadata.CloudTagRule
+	// 标签映射规则指定的拓扑缓存，业务/集群/模块名称 -> 拓扑
+	tagRuleTopos map[string]*tagRuleTopo
+	// 资源池业务id缓存
+	resPoolBizID int64
 }
 
 // 创建云主机同步器
@@ -59,6 +68,7 @@ func (h *HostSyncor) Sync(task *metadata.CloudSyncTask) error {
 			err.Error(), h.readKit.Rid)
 		return err
 	}
+	h.resetTagRules(task.OwnerID, accountConf.TagRules)
 
 	// 根据任务详情和账号信息获取要同步的云主机资源
 	hostResource, err := h.getCloudHostResource(task, accountConf)
@@ -338,6 +348,7 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 
 	// 有差异的主机
 	diffHosts := make(map[string][]*metadata.CloudHost)
+	tagRules := h.tagRules
 	// 本地需要同步新增和更新的主机
 	for _, h := range remoteHostsMap {
 		if _, ok := localIdHostsMap[h.InstanceId]; ok {
@@ -348,7 +359,8 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 				continue
 			}
 			if h.InstanceState != lh.InstanceState || h.PublicIp != lh.PublicIp ||
-				h.PrivateIp != lh.PrivateIp || h.CloudID != lh.CloudID {
+				h.PrivateIp != lh.PrivateIp || h.CloudID != lh.CloudID ||
+				tagRuleAttrsChanged(metadata.ApplyCloudTagRules(tagRules, h.Tags), lh.Detail) {
 				diffHosts["update"] = append(diffHosts["update"], h)
 			}
 		} else {
@@ -367,6 +379,16 @@ func (h *HostSyncor) getDiffHosts(hostResource *metadata.CloudHostResource) (map
 		}
 	}
 
+	// 仍在资源池中的主机，按标签映射规则转移到规则指定的拓扑
+	moveHosts, err := h.getTagRuleMoveHosts(localIdHostsMap, remoteHostsMap)
+	if err != nil {
+		blog.Errorf("getTagRuleMoveHosts fail, err:%s, rid:%s", err.Error(), h.readKit.Rid)
+		return nil, err
+	}
+	if len(moveHosts) > 0 {
+		diffHosts["move"] = moveHosts
+	}
+
 	return diffHosts, nil
 }
 
@@ -404,6 +426,14 @@ func (h *HostSyncor) syncDiffHosts(diffhosts map[string][]*metadata.CloudHost, s
 			}
 			syncResult.Detail.Update.Count += result.SuccessInfo.Count
 			syncResult.Detail.Update.IPs = append(syncResult.Detail.Update.IPs, result.SuccessInfo.IPs...)
+		case "move":
+			result, err = h.transferHostsByTagRule(hosts)
+			if err != nil {
+				blog.Errorf("syncDiffHosts fail, err:%s, rid:%s", err.Error(), h.readKit.Rid)
+				return err
+			}
+			syncResult.Detail.Update.Count += result.SuccessInfo.Count
+			syncResult.Detail.Update.IPs = append(syncResult.Detail.Update.IPs, result.SuccessInfo.IPs...)
 		default:
 			blog.Errorf("syncDiffHosts fail, op:%s is invalid, rid:%s", op, h.readKit.Rid)
 			return fmt.Errorf("syncDiffHosts op:%s is invalid", op)
@@ -595,6 +625,7 @@ func (h *HostSyncor) getLocalHosts(cloudIDs []int64) ([]*metadata.CloudHost, err
 			},
 			CloudID: cloudID,
 			HostID:  hostID,
+			Detail:  host,
 		})
 	}
 
@@ -658,6 +689,25 @@ func (h *HostSyncor) addHosts(hosts []*metadata.CloudHost) (*metadata.SyncResult
 		}
 	}
 
+	// 新增的主机按标签映射规则从资源池转移到规则指定的拓扑
+	hostIDs := make(map[string]int64)
+	for _, data := range curData {
+		instID, _ := data.String(common.BKCloudInstIDField)
+		hostIDs[instID], _ = data.Int64(common.BKHostIDField)
+	}
+	for _, host := range hosts {
+		host.HostID = hostIDs[host.InstanceId]
+	}
+	tResult, err := h.transferHostsByTagRule(hosts)
+	if err != nil {
+		blog.Errorf("addHosts transferHostsByTagRule err:%s, rid:%s", err.Error(), h.readKit.Rid)
+		return nil, err
+	}
+	syncResult.FailInfo.Count += tResult.FailInfo.Count
+	for ip, errInfo := range tResult.FailInfo.IPError {
+		syncResult.FailInfo.IPError[ip] = errInfo
+	}
+
 	return syncResult, nil
 }
 
@@ -671,6 +721,12 @@ func (h *HostSyncor) addHost(cHost *metadata.CloudHost) (string, error) {
 		common.BKCloudHostStatusField: cHost.InstanceState,
 		common.BKCloudVendor:          cHost.VendorName,
 	}
+	// 设置标签映射规则指定的主机属性
+	if result := metadata.ApplyCloudTagRules(h.tagRules, cHost.Tags); result != nil {
+		for attr, val := range result.Attributes {
+			host[attr] = val
+		}
+	}
 	input := &metadata.CreateModelInstance{
 		Data: host,
 	}
@@ -755,6 +811,12 @@ func (h *HostSyncor) updateHosts(hosts []*metadata.CloudHost) (*metadata.SyncRes
 			common.BKHostOuterIPField:     host.PublicIp,
 			common.BKCloudHostStatusField: host.InstanceState,
 		}
+		// 设置标签映射规则指定的主机属性
+		if result := metadata.ApplyCloudTagRules(h.tagRules, host.Tags); result != nil {
+			for attr, val := range result.Attributes {
+				updateInfo[attr] = val
+			}
+		}
 
 		// generate audit log.
 		preData, err := h.getHostDetailByInstIDs(h.readKit, []string{host.InstanceId})
@@ -0,0 +1,700 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudsync
+
+import (
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+	"configcenter/src/scene_server/cloud_server/logics"
+)
+
+// 非主机云资源同步器，将负载均衡、云数据库、云硬盘、安全组等云资源同步为自定义模型的实例
+type ResourceSyncor struct {
+	logics    *logics.Logics
+	enableTxn bool
+	// readKit used for read operation
+	readKit *rest.Kit
+	// writeKit used for write operation
+	writeKit *rest.Kit
+}
+
+// 云资源和本地实例的差异
+type resourceDiff struct {
+	// 需要新增的云资源
+	add []*metadata.CloudResource
+	// 需要更新的实例，云端id -> 更新的数据
+	update map[string]mapstr.MapStr
+	// 云端已经被销毁，需要删除的实例，云端id -> 实例id
+	delete map[string]int64
+}
+
+func (d *resourceDiff) empty() bool {
+	return len(d.add) == 0 && len(d.update) == 0 && len(d.delete) == 0
+}
+
+// 创建非主机云资源同步器
+func NewResourceSyncor(logics *logics.Logics) *ResourceSyncor {
+	return &ResourceSyncor{
+		logics:    logics,
+		enableTxn: true,
+	}
+}
+
+// 同步非主机云资源
+func (r *ResourceSyncor) Sync(task *metadata.CloudSyncTask) error {
+	defer func() {
+		if err := recover(); err != nil {
+			blog.Errorf("sync panic err:%#v, rid:%s, debug strace:%s", err, r.readKit.Rid, debug.Stack())
+		}
+	}()
+
+	// 每次同步生成新的kit
+	r.readKit = ccom.NewKit()
+	// 将云同步任务的开发商ID作为写kit的开发商ID
+	r.writeKit = ccom.NewWriteKit(task.OwnerID)
+	// 让读写kit的requestID保持一致，以追踪同一个task的日志
+	r.writeKit.Header.Set(common.BKHTTPCCRequestID, r.readKit.Header.Get(common.BKHTTPCCRequestID))
+
+	startTime := time.Now()
+	blog.Infof("start sync taskid:%d, resource type:%s, rid:%s", task.TaskID, task.ResourceType, r.readKit.Rid)
+
+	conf := task.ResourceSync
+	if conf == nil {
+		blog.Errorf("sync config of taskid:%d is not set, resource type:%s, rid:%s", task.TaskID, task.ResourceType,
+			r.readKit.Rid)
+		err := fmt.Errorf("sync config of task %d is not set", task.TaskID)
+		// 配置缺失的任务无法同步，标记为同步失败，避免任务一直显示为上次的同步状态
+		if err := r.updateTaskState(task.TaskID, metadata.CloudSyncFail,
+			&metadata.SyncStatusDesc{ErrorInfo: err.Error()}); err != nil {
+			blog.Errorf("updateTaskState fail, taskid:%d, err:%v, rid:%s", task.TaskID, err, r.readKit.Rid)
+		}
+		return err
+	}
+
+	// 根据账号id获取账号详情
+	accountConf, err := r.logics.GetCloudAccountConf(r.readKit, task.AccountID)
+	if err != nil {
+		blog.Errorf("GetCloudAccountConf fail, taskid:%d, err:%s, rid:%s", task.TaskID, err.Error(), r.readKit.Rid)
+		return err
+	}
+
+	// 获取要同步的云资源
+	resources, err := r.logics.GetCloudResources(r.readKit, *accountConf, task.ResourceType, conf.Regions)
+	if err != nil {
+		blog.Errorf("GetCloudResources fail, taskid:%d, err:%s, rid:%s", task.TaskID, err.Error(), r.readKit.Rid)
+		if err := r.updateTaskState(task.TaskID, metadata.CloudSyncFail,
+			&metadata.SyncStatusDesc{ErrorInfo: err.Error()}); err != nil {
+			blog.Errorf("updateTaskState fail, taskid:%d, err:%v, rid:%s", task.TaskID, err, r.readKit.Rid)
+		}
+		return err
+	}
+	blog.Infof("taskid:%d, cloud resource count:%d, rid:%s", task.TaskID, len(resources), r.readKit.Rid)
+
+	syncResult := new(metadata.SyncResult)
+	syncResult.FailInfo.IPError = make(map[string]string)
+
+	txnErr := r.logics.CoreAPI.CoreService().Txn().AutoRunTxn(r.readKit.Ctx, r.enableTxn, r.readKit.Header, func() error {
+		// 让writeKit的header含有同样的事务信息，以保证同一个事务里写操作后的数据能够被读到
+		ccom.CopyHeaderTxnInfo(r.readKit.Header, r.writeKit.Header)
+
+		// 获取本地已同步的实例，云端id -> 实例
+		localInsts, err := r.getLocalInstances(task, conf)
+		if err != nil {
+			blog.Errorf("getLocalInstances fail, taskid:%d, err:%s, rid:%s", task.TaskID, err.Error(), r.readKit.Rid)
+			return err
+		}
+
+		diff := r.getDiffResources(task.AccountID, conf, resources, localInsts)
+		if !diff.empty() {
+			// 有差异的更新任务同步状态为同步中
+			if err := r.updateTaskState(task.TaskID, metadata.CloudSyncInProgress, nil); err != nil {
+				blog.Errorf("updateTaskState fail, taskid:%d, err:%s, rid:%s", task.TaskID, err.Error(),
+					r.readKit.Rid)
+				return err
+			}
+
+			// 同步有差异的实例
+			if err := r.syncDiffResources(task.AccountID, conf, diff, syncResult); err != nil {
+				blog.Errorf("syncDiffResources fail, taskid:%d, err:%s, rid:%s", task.TaskID, err.Error(),
+					r.readKit.Rid)
+				return err
+			}
+		}
+
+		// 同步云资源的关联关系
+		asstChanged, err := r.syncAssociations(conf, resources)
+		if err != nil {
+			blog.Errorf("syncAssociations fail, taskid:%d, err:%s, rid:%s", task.TaskID, err.Error(), r.readKit.Rid)
+			return err
+		}
+
+		// 没差异则结束
+		if diff.empty() && !asstChanged {
+			blog.Infof("no diff resources for taskid:%d, rid:%s", task.TaskID, r.readKit.Rid)
+			return nil
+		}
+
+		// 设置SyncResult的状态信息
+		r.setSyncResultStatus(syncResult, startTime)
+
+		// 增加任务同步历史记录
+		syncHistory := metadata.SyncHistory{
+			TaskID:            task.TaskID,
+			SyncStatus:        syncResult.SyncStatus,
+			StatusDescription: syncResult.StatusDescription,
+			Detail:            syncResult.Detail,
+		}
+		if _, err := r.logics.CreateSyncHistory(r.writeKit, &syncHistory); err != nil {
+			blog.Errorf("CreateSyncHistory fail, taskid:%d, err:%s, rid:%s", task.TaskID, err.Error(),
+				r.readKit.Rid)
+			return err
+		}
+
+		// 完成后更新任务同步状态
+		err = r.updateTaskState(task.TaskID, syncResult.SyncStatus, &syncResult.StatusDescription)
+		if err != nil {
+			blog.Errorf("updateTaskState fail, taskid:%d, err:%s, rid:%s", task.TaskID, err.Error(), r.readKit.Rid)
+			return err
+		}
+
+		blog.Infof("sync success, finish sync taskid:%d, costTime:%ds, Detail:%#v, rid:%s", task.TaskID,
+			time.Since(startTime)/time.Second, syncResult.Detail, r.readKit.Rid)
+		return nil
+	})
+
+	// 事务结束，去掉readKit、writeKit中header的事务信息
+	ccom.DelHeaderTxnInfo(r.readKit.Header)
+	ccom.DelHeaderTxnInfo(r.writeKit.Header)
+
+	if txnErr != nil {
+		blog.Errorf("sync fail, taskid:%d, txnErr:%v, rid:%s", task.TaskID, txnErr, r.readKit.Rid)
+		err := r.updateTaskState(task.TaskID, metadata.CloudSyncFail, &metadata.SyncStatusDesc{ErrorInfo: txnErr.Error()})
+		if err != nil {
+			blog.Errorf("updateTaskState fail, taskid:%d, err:%v, rid:%s", task.TaskID, err, r.readKit.Rid)
+		}
+		return txnErr
+	}
+
+	blog.Infof("sync loop for taskid:%d is over, costTime:%ds, rid:%s", task.TaskID, time.Since(startTime)/time.Second,
+		r.readKit.Rid)
+	return nil
+}
+
+// 获取本地数据库中该任务同步的实例，云端id -> 实例
+func (r *ResourceSyncor) getLocalInstances(task *metadata.CloudSyncTask,
+	conf *metadata.CloudResourceSyncConf) (map[string]mapstr.MapStr, error) {
+
+	cond := mapstr.MapStr{
+		// 必须带有云端id，说明是同步的云资源
+		conf.IDField: mapstr.MapStr{common.BKDBNIN: []interface{}{nil, ""}},
+	}
+	// 映射了账号id时，只处理该账号同步的实例
+	if attr := accountAttribute(conf); attr != "" {
+		cond[attr] = task.AccountID
+	}
+
+	insts, err := r.readInstances(conf.ObjectID, cond)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]mapstr.MapStr)
+	for _, inst := range insts {
+		cloudID, err := inst.String(conf.IDField)
+		if err != nil {
+			blog.Errorf("got invalid %s instance %s field, inst:%#v, rid:%s", conf.ObjectID, conf.IDField, inst,
+				r.readKit.Rid)
+			return nil, err
+		}
+		result[cloudID] = inst
+	}
+	return result, nil
+}
+
+// 比对云端资源和本地实例，获取有差异的资源
+func (r *ResourceSyncor) getDiffResources(accountID int64, conf *metadata.CloudResourceSyncConf,
+	resources []*metadata.CloudResource, localInsts map[string]mapstr.MapStr) *resourceDiff {
+
+	diff := &resourceDiff{
+		add:    make([]*metadata.CloudResource, 0),
+		update: make(map[string]mapstr.MapStr),
+		delete: make(map[string]int64),
+	}
+
+	remote := make(map[string]bool)
+	for _, res := range resources {
+		remote[res.ResourceID] = true
+
+		inst, exist := localInsts[res.ResourceID]
+		if !exist {
+			diff.add = append(diff.add, res)
+			continue
+		}
+
+		updateData := mapstr.MapStr{}
+		for attr, val := range buildResourceInstance(accountID, conf, res) {
+			if !isSameValue(inst[attr], val) {
+				updateData[attr] = val
+			}
+		}
+		if len(updateData) > 0 {
+			diff.update[res.ResourceID] = updateData
+		}
+	}
+
+	instIDField := common.GetInstIDField(conf.ObjectID)
+	for cloudID, inst := range localInsts {
+		if remote[cloudID] {
+			continue
+		}
+		instID, err := inst.Int64(instIDField)
+		if err != nil {
+			blog.Errorf("got invalid %s instance id, inst:%#v, rid:%s", conf.ObjectID, inst, r.readKit.Rid)
+			continue
+		}
+		diff.delete[cloudID] = instID
+	}
+
+	return diff
+}
+
+// 同步有差异的实例
+func (r *ResourceSyncor) syncDiffResources(accountID int64, conf *metadata.CloudResourceSyncConf,
+	diff *resourceDiff, syncResult *metadata.SyncResult) error {
+
+	audit := auditlog.NewInstanceAudit(r.logics.CoreAPI.CoreService())
+	logs := make([]metadata.AuditLog, 0)
+	instIDField := common.GetInstIDField(conf.ObjectID)
+
+	// 新增云资源对应的实例
+	addedIDs := make([]int64, 0)
+	for _, res := range diff.add {
+		input := &metadata.CreateModelInstance{Data: buildResourceInstance(accountID, conf, res)}
+		result, err := r.logics.CoreAPI.CoreService().Instance().CreateInstance(r.writeKit.Ctx, r.writeKit.Header,
+			conf.ObjectID, input)
+		if err != nil {
+			blog.Errorf("create %s instance fail, err:%s, input:%#v, rid:%s", conf.ObjectID, err.Error(), input,
+				r.readKit.Rid)
+			return err
+		}
+		if !result.Result {
+			blog.Errorf("create %s instance fail, err:%s, input:%#v, rid:%s", conf.ObjectID, result.ErrMsg, input,
+				r.readKit.Rid)
+			syncResult.FailInfo.Count++
+			syncResult.FailInfo.IPError[res.ResourceID] = result.ErrMsg
+			continue
+		}
+		addedIDs = append(addedIDs, int64(result.Data.Created.ID))
+		syncResult.Detail.NewAdd.Count++
+		syncResult.Detail.NewAdd.IPs = append(syncResult.Detail.NewAdd.IPs, res.ResourceID)
+	}
+	if len(addedIDs) > 0 {
+		param := auditlog.NewGenerateAuditCommonParameter(r.readKit, metadata.AuditCreate).
+			WithOperateFrom(metadata.FromCloudSync)
+		auditLogs, err := audit.GenerateAuditLogByCondGetData(param, conf.ObjectID,
+			mapstr.MapStr{instIDField: mapstr.MapStr{common.BKDBIN: addedIDs}})
+		if err != nil {
+			return err
+		}
+		logs = append(logs, auditLogs...)
+	}
+
+	// 更新云资源对应的实例
+	for cloudID, updateData := range diff.update {
+		cond := mapstr.MapStr{conf.IDField: cloudID}
+		param := auditlog.NewGenerateAuditCommonParameter(r.readKit, metadata.AuditUpdate).
+			WithOperateFrom(metadata.FromCloudSync).WithUpdateFields(updateData)
+		auditLogs, err := audit.GenerateAuditLogByCondGetData(param, conf.ObjectID, cond)
+		if err != nil {
+			return err
+		}
+
+		input := &metadata.UpdateOption{Condition: cond, Data: updateData, CanEditAll: true}
+		result, err := r.logics.CoreAPI.CoreService().Instance().UpdateInstance(r.writeKit.Ctx, r.writeKit.Header,
+			conf.ObjectID, input)
+		if err != nil {
+			blog.Errorf("update %s instance fail, err:%s, input:%#v, rid:%s", conf.ObjectID, err.Error(), input,
+				r.readKit.Rid)
+			return err
+		}
+		if !result.Result {
+			blog.Errorf("update %s instance fail, err:%s, input:%#v, rid:%s", conf.ObjectID, result.ErrMsg, input,
+				r.readKit.Rid)
+			syncResult.FailInfo.Count++
+			syncResult.FailInfo.IPError[cloudID] = result.ErrMsg
+			continue
+		}
+		logs = append(logs, auditLogs...)
+		syncResult.Detail.Update.Count++
+		syncResult.Detail.Update.IPs = append(syncResult.Detail.Update.IPs, cloudID)
+	}
+
+	// 删除云端已经被销毁的资源对应的实例，实例关联一并删除
+	if len(diff.delete) > 0 {
+		instIDs := make([]int64, 0)
+		cloudIDs := make([]string, 0)
+		for cloudID, instID := range diff.delete {
+			instIDs = append(instIDs, instID)
+			cloudIDs = append(cloudIDs, cloudID)
+		}
+		cond := mapstr.MapStr{instIDField: mapstr.MapStr{common.BKDBIN: instIDs}}
+		param := auditlog.NewGenerateAuditCommonParameter(r.readKit, metadata.AuditDelete).
+			WithOperateFrom(metadata.FromCloudSync)
+		auditLogs, err := audit.GenerateAuditLogByCondGetData(param, conf.ObjectID, cond)
+		if err != nil {
+			return err
+		}
+
+		input := &metadata.DeleteOption{Condition: cond}
+		result, err := r.logics.CoreAPI.CoreService().Instance().DeleteInstanceCascade(r.writeKit.Ctx,
+			r.writeKit.Header, conf.ObjectID, input)
+		if err != nil {
+			blog.Errorf("delete %s instance fail, err:%s, input:%#v, rid:%s", conf.ObjectID, err.Error(), input,
+				r.readKit.Rid)
+			return err
+		}
+		if !result.Result {
+			blog.Errorf("delete %s instance fail, err:%s, input:%#v, rid:%s", conf.ObjectID, result.ErrMsg, input,
+				r.readKit.Rid)
+			return result.CCError()
+		}
+		logs = append(logs, auditLogs...)
+		syncResult.Detail.Delete.Count += int64(len(cloudIDs))
+		syncResult.Detail.Delete.IPs = append(syncResult.Detail.Delete.IPs, cloudIDs...)
+	}
+
+	syncResult.SuccessInfo.Count = syncResult.Detail.NewAdd.Count + syncResult.Detail.Update.Count +
+		syncResult.Detail.Delete.Count
+	syncResult.SuccessInfo.IPs = make([]string, 0, syncResult.SuccessInfo.Count)
+	syncResult.SuccessInfo.IPs = append(syncResult.SuccessInfo.IPs, syncResult.Detail.NewAdd.IPs...)
+	syncResult.SuccessInfo.IPs = append(syncResult.SuccessInfo.IPs, syncResult.Detail.Update.IPs...)
+	syncResult.SuccessInfo.IPs = append(syncResult.SuccessInfo.IPs, syncResult.Detail.Delete.IPs...)
+
+	if len(logs) > 0 {
+		if err := audit.SaveAuditLog(r.writeKit, logs...); err != nil {
+			blog.Errorf("save audit log failed after sync %s instances, err: %v, rid: %s", conf.ObjectID, err,
+				r.readKit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// 同步云资源的关联关系为模型实例关联，新增缺少的关联，删除云端已经不存在的关联，返回关联是否有变化
+func (r *ResourceSyncor) syncAssociations(conf *metadata.CloudResourceSyncConf,
+	resources []*metadata.CloudResource) (bool, error) {
+
+	if len(conf.Associations) == 0 {
+		return false, nil
+	}
+
+	// 重新获取同步后的本地实例，云端id -> 实例id
+	cloudIDs := make([]string, 0)
+	for _, res := range resources {
+		cloudIDs = append(cloudIDs, res.ResourceID)
+	}
+	instIDs, err := r.getInstIDs(conf.ObjectID, conf.IDField, cloudIDs)
+	if err != nil {
+		return false, err
+	}
+	if len(instIDs) == 0 {
+		return false, nil
+	}
+
+	changed := false
+	for _, asstConf := range conf.Associations {
+		asstChanged, err := r.syncAssociation(conf, &asstConf, resources, instIDs)
+		if err != nil {
+			return false, err
+		}
+		changed = changed || asstChanged
+	}
+	return changed, nil
+}
+
+// 同步一种云资源关联关系
+func (r *ResourceSyncor) syncAssociation(conf *metadata.CloudResourceSyncConf, asstConf *metadata.CloudResourceAsstConf,
+	resources []*metadata.CloudResource, instIDs map[string]int64) (bool, error) {
+
+	modelAsst, err := r.getModelAssociation(asstConf.ObjAsstID)
+	if err != nil {
+		return false, err
+	}
+	if modelAsst.ObjectID != conf.ObjectID {
+		blog.Errorf("the source object of association %s is %s, not %s, rid:%s", asstConf.ObjAsstID,
+			modelAsst.ObjectID, conf.ObjectID, r.readKit.Rid)
+		return false, fmt.Errorf("the source object of association %s is not %s", asstConf.ObjAsstID, conf.ObjectID)
+	}
+
+	// 关联的云资源对应的实例，云端id -> 实例id
+	relatedIDs := make([]string, 0)
+	for _, res := range resources {
+		relatedIDs = append(relatedIDs, res.Relations[asstConf.RelatedType]...)
+	}
+	asstInstIDs, err := r.getInstIDs(modelAsst.AsstObjID, asstConf.GetAsstIDField(), relatedIDs)
+	if err != nil {
+		return false, err
+	}
+
+	// 云端的关联关系，实例id -> 关联实例id
+	expected := make(map[int64]map[int64]bool)
+	for _, res := range resources {
+		instID, exist := instIDs[res.ResourceID]
+		if !exist {
+			continue
+		}
+		expected[instID] = make(map[int64]bool)
+		for _, relatedID := range res.Relations[asstConf.RelatedType] {
+			// 关联的云资源还没有同步到本地时跳过，在之后的同步中再添加关联
+			if asstInstID, exist := asstInstIDs[relatedID]; exist {
+				expected[instID][asstInstID] = true
+			}
+		}
+	}
+
+	// 本地已有的关联关系
+	allInstIDs := make([]int64, 0)
+	for _, instID := range instIDs {
+		allInstIDs = append(allInstIDs, instID)
+	}
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{
+			common.AssociationObjAsstIDField: asstConf.ObjAsstID,
+			common.BKInstIDField:             mapstr.MapStr{common.BKDBIN: allInstIDs},
+		},
+	}
+	existing, err := r.logics.CoreAPI.CoreService().Association().ReadInstAssociation(r.readKit.Ctx,
+		r.readKit.Header, query)
+	if err != nil {
+		blog.Errorf("ReadInstAssociation fail, err:%s, query:%#v, rid:%s", err.Error(), query, r.readKit.Rid)
+		return false, err
+	}
+	if !existing.Result {
+		blog.Errorf("ReadInstAssociation fail, err:%s, query:%#v, rid:%s", existing.ErrMsg, query, r.readKit.Rid)
+		return false, existing.CCError()
+	}
+
+	staleIDs := make([]int64, 0)
+	for _, asst := range existing.Data.Info {
+		if expected[asst.InstID][asst.AsstInstID] {
+			delete(expected[asst.InstID], asst.AsstInstID)
+			continue
+		}
+		staleIDs = append(staleIDs, asst.ID)
+	}
+
+	changed := false
+	for instID, asstInstIDs := range expected {
+		for asstInstID := range asstInstIDs {
+			input := &metadata.CreateOneInstanceAssociation{
+				Data: metadata.InstAsst{
+					InstID:            instID,
+					ObjectID:          conf.ObjectID,
+					AsstInstID:        asstInstID,
+					AsstObjectID:      modelAsst.AsstObjID,
+					ObjectAsstID:      asstConf.ObjAsstID,
+					AssociationKindID: modelAsst.AsstKindID,
+				},
+			}
+			result, err := r.logics.CoreAPI.CoreService().Association().CreateInstAssociation(r.writeKit.Ctx,
+				r.writeKit.Header, input)
+			if err != nil {
+				blog.Errorf("CreateInstAssociation fail, err:%s, input:%#v, rid:%s", err.Error(), input, r.readKit.Rid)
+				return false, err
+			}
+			if !result.Result {
+				blog.Errorf("CreateInstAssociation fail, err:%s, input:%#v, rid:%s", result.ErrMsg, input,
+					r.readKit.Rid)
+				return false, result.CCError()
+			}
+			changed = true
+		}
+	}
+
+	if len(staleIDs) > 0 {
+		input := &metadata.DeleteOption{
+			Condition: mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: staleIDs}},
+		}
+		result, err := r.logics.CoreAPI.CoreService().Association().DeleteInstAssociation(r.writeKit.Ctx,
+			r.writeKit.Header, input)
+		if err != nil {
+			blog.Errorf("DeleteInstAssociation fail, err:%s, input:%#v, rid:%s", err.Error(), input, r.readKit.Rid)
+			return false, err
+		}
+		if !result.Result {
+			blog.Errorf("DeleteInstAssociation fail, err:%s, input:%#v, rid:%s", result.ErrMsg, input, r.readKit.Rid)
+			return false, result.CCError()
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// 获取模型关联
+func (r *ResourceSyncor) getModelAssociation(objAsstID string) (*metadata.Association, error) {
+	query := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{common.AssociationObjAsstIDField: objAsstID},
+	}
+	result, err := r.logics.CoreAPI.CoreService().Association().ReadModelAssociation(r.readKit.Ctx,
+		r.readKit.Header, query)
+	if err != nil {
+		blog.Errorf("ReadModelAssociation fail, err:%s, query:%#v, rid:%s", err.Error(), query, r.readKit.Rid)
+		return nil, err
+	}
+	if !result.Result {
+		blog.Errorf("ReadModelAssociation fail, err:%s, query:%#v, rid:%s", result.ErrMsg, query, r.readKit.Rid)
+		return nil, result.CCError()
+	}
+	if len(result.Data.Info) == 0 {
+		blog.Errorf("model association %s is not found, rid:%s", objAsstID, r.readKit.Rid)
+		return nil, fmt.Errorf("model association %s is not found", objAsstID)
+	}
+	return &result.Data.Info[0], nil
+}
+
+// 根据云端id获取模型实例的实例id，云端id -> 实例id
+func (r *ResourceSyncor) getInstIDs(objID, idField string, cloudIDs []string) (map[string]int64, error) {
+	result := make(map[string]int64)
+	if len(cloudIDs) == 0 {
+		return result, nil
+	}
+
+	instIDField := common.GetInstIDField(objID)
+	insts, err := r.readInstances(objID, mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: cloudIDs}},
+		instIDField, idField)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range insts {
+		cloudID, err := inst.String(idField)
+		if err != nil {
+			return nil, err
+		}
+		instID, err := inst.Int64(instIDField)
+		if err != nil {
+			return nil, err
+		}
+		result[cloudID] = instID
+	}
+	return result, nil
+}
+
+// 查询模型实例
+func (r *ResourceSyncor) readInstances(objID string, cond mapstr.MapStr, fields ...string) ([]mapstr.MapStr, error) {
+	query := &metadata.QueryCondition{
+		Fields:    fields,
+		Condition: cond,
+	}
+	res, err := r.logics.CoreAPI.CoreService().Instance().ReadInstance(r.readKit.Ctx, r.readKit.Header, objID, query)
+	if err != nil {
+		blog.Errorf("read %s instances failed, err: %v, query:%#v, rid:%s", objID, err, query, r.readKit.Rid)
+		return nil, err
+	}
+	if !res.Result {
+		blog.Errorf("read %s instances failed, query:%#v, err code:%d, err msg:%s, rid:%s", objID, query, res.Code,
+			res.ErrMsg, r.readKit.Rid)
+		return nil, res.CCError()
+	}
+	return res.Data.Info, nil
+}
+
+// 更新任务同步状态
+func (r *ResourceSyncor) updateTaskState(taskid int64, status string, syncStatusDesc *metadata.SyncStatusDesc) error {
+	option := mapstr.MapStr{common.BKCloudSyncStatus: status}
+	if status == metadata.CloudSyncSuccess || status == metadata.CloudSyncFail {
+		ts := time.Now()
+		option.Set(common.BKCloudLastSyncTime, &ts)
+		option.Set(common.BKCloudSyncStatusDescription, syncStatusDesc)
+	}
+
+	if err := r.logics.UpdateSyncTask(r.writeKit, taskid, option); err != nil {
+		blog.Errorf("UpdateSyncTask failed, taskid: %v, err: %s, rid:%s", taskid, err.Error(), r.readKit.Rid)
+		return err
+	}
+	return nil
+}
+
+// 设置SyncResult的状态信息
+func (r *ResourceSyncor) setSyncResultStatus(syncResult *metadata.SyncResult, startTime time.Time) {
+	syncStatus := metadata.CloudSyncSuccess
+	costTime, _ := strconv.ParseFloat(fmt.Sprintf("%.1f", float64(time.Since(startTime)/time.Millisecond)/1000.0), 64)
+	statusDesc := metadata.SyncStatusDesc{CostTime: costTime}
+	if syncResult.FailInfo.Count > 0 {
+		syncStatus = metadata.CloudSyncFail
+		for _, errinfo := range syncResult.FailInfo.IPError {
+			statusDesc.ErrorInfo = errinfo
+			break
+		}
+	}
+	syncResult.SyncStatus = syncStatus
+	syncResult.StatusDescription = statusDesc
+}
+
+// 根据字段映射生成云资源对应的实例数据
+func buildResourceInstance(accountID int64, conf *metadata.CloudResourceSyncConf,
+	res *metadata.CloudResource) mapstr.MapStr {
+
+	inst := mapstr.MapStr{conf.IDField: res.ResourceID}
+	for attr, field := range conf.FieldMapping {
+		if field == metadata.CloudResourceAccountField {
+			inst[attr] = accountID
+			continue
+		}
+		if val, ok := res.GetField(field); ok {
+			inst[attr] = val
+		}
+	}
+
+	// 没有配置实例名称的映射时，使用资源名称作为实例名称，没有名称的使用云端id
+	if _, exist := conf.FieldMapping[common.BKInstNameField]; !exist {
+		inst[common.BKInstNameField] = res.Name
+		if res.Name == "" {
+			inst[common.BKInstNameField] = res.ResourceID
+		}
+	}
+	return inst
+}
+
+// 获取映射了账号id的模型属性
+func accountAttribute(conf *metadata.CloudResourceSyncConf) string {
+	for attr, field := range conf.FieldMapping {
+		if field == metadata.CloudResourceAccountField {
+			return attr
+		}
+	}
+	return ""
+}
+
+// 比较本地实例的值和云端资源的值是否相同，数字类型从数据库读出后类型可能不同，统一转换为字符串比较
+func isSameValue(local, remote interface{}) bool {
+	if reflect.DeepEqual(local, remote) {
+		return true
+	}
+	if local == nil || remote == nil {
+		return false
+	}
+	return fmt.Sprintf("%v", local) == fmt.Sprintf("%v", remote)
+}
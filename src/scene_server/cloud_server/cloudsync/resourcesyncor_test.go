@@ -0,0 +1,92 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudsync
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildResourceInstance(t *testing.T) {
+	conf := &metadata.CloudResourceSyncConf{
+		ObjectID: "clb",
+		IDField:  "lb_id",
+		FieldMapping: map[string]string{
+			"vpc":     metadata.CloudResourceVpcIDField,
+			"vips":    "vips",
+			"account": metadata.CloudResourceAccountField,
+			"unknown": "not_exist",
+		},
+	}
+	res := &metadata.CloudResource{
+		ResourceID: "lb-1",
+		Name:       "payments",
+		VpcID:      "vpc-1",
+		Attributes: map[string]interface{}{"vips": "10.0.0.1"},
+	}
+
+	inst := buildResourceInstance(3, conf, res)
+	require.Equal(t, mapstr.MapStr{
+		"lb_id":                "lb-1",
+		"vpc":                  "vpc-1",
+		"vips":                 "10.0.0.1",
+		"account":              int64(3),
+		common.BKInstNameField: "payments",
+	}, inst)
+
+	// use the cloud id as the instance name if the resource has no name
+	res.Name = ""
+	inst = buildResourceInstance(3, conf, res)
+	require.Equal(t, "lb-1", inst[common.BKInstNameField])
+	require.Equal(t, "account", accountAttribute(conf))
+}
+
+func TestGetDiffResources(t *testing.T) {
+	conf := &metadata.CloudResourceSyncConf{
+		ObjectID:     "disk",
+		IDField:      "disk_id",
+		FieldMapping: map[string]string{"size": "disk_size", "account": metadata.CloudResourceAccountField},
+	}
+	resources := []*metadata.CloudResource{
+		{ResourceID: "disk-1", Name: "data", Attributes: map[string]interface{}{"disk_size": "50"}},
+		{ResourceID: "disk-2", Name: "log", Attributes: map[string]interface{}{"disk_size": "100"}},
+		{ResourceID: "disk-3", Name: "new", Attributes: map[string]interface{}{"disk_size": "20"}},
+	}
+	localInsts := map[string]mapstr.MapStr{
+		// same as the cloud, numbers read from db are compared as strings
+		"disk-1": {common.BKInstIDField: int64(1), "disk_id": "disk-1", common.BKInstNameField: "data",
+			"size": "50", "account": float64(2)},
+		// disk size is changed in the cloud
+		"disk-2": {common.BKInstIDField: int64(2), "disk_id": "disk-2", common.BKInstNameField: "log",
+			"size": "80", "account": int64(2)},
+		// destroyed in the cloud
+		"disk-4": {common.BKInstIDField: int64(4), "disk_id": "disk-4", common.BKInstNameField: "old"},
+	}
+
+	syncor := &ResourceSyncor{readKit: ccom.NewKit()}
+	diff := syncor.getDiffResources(2, conf, resources, localInsts)
+	require.Len(t, diff.add, 1)
+	require.Equal(t, "disk-3", diff.add[0].ResourceID)
+	require.Equal(t, map[string]mapstr.MapStr{"disk-2": {"size": "100"}}, diff.update)
+	require.Equal(t, map[string]int64{"disk-4": 4}, diff.delete)
+	require.False(t, diff.empty())
+
+	diff = syncor.getDiffResources(2, conf, nil, nil)
+	require.True(t, diff.empty())
+}
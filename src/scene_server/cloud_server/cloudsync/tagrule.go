@@ -0,0 +1,275 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudsync
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// 标签映射规则指定的主机拓扑
+type tagRuleTopo struct {
+	bizID     int64
+	moduleIDs []int64
+	// 拓扑解析失败的原因，同一次同步中不再重复解析
+	err error
+}
+
+// 重置同步使用的标签映射规则和拓扑缓存，每次同步时调用
+func (h *HostSyncor) resetTagRules(ownerID string, rules []metadata.CloudTagRule) {
+	h.ownerID = ownerID
+	h.tagRules = rules
+	h.tagRuleTopos = make(map[string]*tagRuleTopo)
+	h.resPoolBizID = 0
+}
+
+// 标签映射规则设置的主机属性和本地主机是否有差异
+func tagRuleAttrsChanged(result *metadata.CloudTagRuleResult, detail mapstr.MapStr) bool {
+	if result == nil {
+		return false
+	}
+	for attr, val := range result.Attributes {
+		if !isSameValue(detail[attr], val) {
+			return true
+		}
+	}
+	return false
+}
+
+// 获取仍在资源池中、匹配了指定业务的标签映射规则的已有主机，这些主机需要转移到规则指定的拓扑，
+// 已经不在资源池中的主机由用户管理，不再根据规则转移
+func (h *HostSyncor) getTagRuleMoveHosts(localHosts, remoteHosts map[string]*metadata.CloudHost) (
+	[]*metadata.CloudHost, error) {
+
+	candidates := make(map[int64]*metadata.CloudHost)
+	for instID, rh := range remoteHosts {
+		lh, exist := localHosts[instID]
+		if !exist || lh.InstanceState == common.BKCloudHostStatusDestroyed {
+			continue
+		}
+		result := metadata.ApplyCloudTagRules(h.tagRules, rh.Tags)
+		if result == nil || result.BizName == "" {
+			continue
+		}
+		host := *rh
+		host.HostID = lh.HostID
+		candidates[lh.HostID] = &host
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	resPoolBizID, err := h.getResPoolBizID()
+	if err != nil {
+		return nil, err
+	}
+
+	hostIDs := make([]int64, 0)
+	for hostID := range candidates {
+		hostIDs = append(hostIDs, hostID)
+	}
+	input := &metadata.HostModuleRelationRequest{
+		HostIDArr: hostIDs,
+		Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		Fields:    []string{common.BKAppIDField, common.BKHostIDField},
+	}
+	res, err := h.logics.CoreAPI.CoreService().Host().GetHostModuleRelation(h.readKit.Ctx, h.readKit.Header, input)
+	if err != nil {
+		blog.Errorf("GetHostModuleRelation failed, err:%s, input:%#v, rid:%s", err.Error(), input, h.readKit.Rid)
+		return nil, err
+	}
+	if !res.Result {
+		blog.Errorf("GetHostModuleRelation failed, err:%s, input:%#v, rid:%s", res.ErrMsg, input, h.readKit.Rid)
+		return nil, res.CCError()
+	}
+
+	hosts := make([]*metadata.CloudHost, 0)
+	for _, relation := range res.Data.Info {
+		if relation.AppID != resPoolBizID {
+			continue
+		}
+		if host, exist := candidates[relation.HostID]; exist {
+			hosts = append(hosts, host)
+			delete(candidates, relation.HostID)
+		}
+	}
+	return hosts, nil
+}
+
+// 将资源池中的主机转移到匹配的标签映射规则指定的拓扑，主机的HostID必须已设置。
+// 规则指定的拓扑不存在时，主机保留在资源池中，并记录为同步失败
+func (h *HostSyncor) transferHostsByTagRule(hosts []*metadata.CloudHost) (*metadata.SyncResult, error) {
+	syncResult := new(metadata.SyncResult)
+	syncResult.FailInfo.IPError = make(map[string]string)
+	if len(h.tagRules) == 0 {
+		return syncResult, nil
+	}
+
+	resPoolBizID, err := h.getResPoolBizID()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, host := range hosts {
+		if host.HostID == 0 {
+			continue
+		}
+		result := metadata.ApplyCloudTagRules(h.tagRules, host.Tags)
+		if result == nil || result.BizName == "" {
+			continue
+		}
+
+		topo := h.getTagRuleTopo(result)
+		if topo.err != nil {
+			blog.Errorf("host %s matches tag rule %s, but get topo failed, err:%v, rid:%s", host.InstanceId,
+				result.RuleName, topo.err, h.readKit.Rid)
+			syncResult.FailInfo.Count++
+			syncResult.FailInfo.IPError[host.PrivateIp] = fmt.Sprintf("apply tag rule %s failed, err: %v",
+				result.RuleName, topo.err)
+			continue
+		}
+
+		input := &metadata.TransferHostsCrossBusinessRequest{
+			SrcApplicationID: resPoolBizID,
+			DstApplicationID: topo.bizID,
+			HostIDArr:        []int64{host.HostID},
+			DstModuleIDArr:   topo.moduleIDs,
+		}
+		res, err := h.logics.CoreAPI.CoreService().Host().TransferToAnotherBusiness(h.writeKit.Ctx,
+			h.writeKit.Header, input)
+		if err != nil {
+			blog.Errorf("TransferToAnotherBusiness failed, err:%s, input:%#v, rid:%s", err.Error(), input,
+				h.readKit.Rid)
+			return nil, err
+		}
+		if !res.Result {
+			blog.Errorf("TransferToAnotherBusiness failed, err:%s, input:%#v, rid:%s", res.ErrMsg, input,
+				h.readKit.Rid)
+			return nil, res.CCError()
+		}
+		syncResult.SuccessInfo.Count++
+		syncResult.SuccessInfo.IPs = append(syncResult.SuccessInfo.IPs, host.PrivateIp)
+	}
+
+	return syncResult, nil
+}
+
+// 获取标签映射规则指定的业务和模块，未指定模块时为业务的空闲机模块
+func (h *HostSyncor) getTagRuleTopo(result *metadata.CloudTagRuleResult) *tagRuleTopo {
+	key := strings.Join([]string{result.BizName, result.SetName, result.ModuleName}, "/")
+	if topo, exist := h.tagRuleTopos[key]; exist {
+		return topo
+	}
+
+	topo := new(tagRuleTopo)
+	topo.bizID, topo.moduleIDs, topo.err = h.searchTagRuleTopo(result)
+	h.tagRuleTopos[key] = topo
+	return topo
+}
+
+func (h *HostSyncor) searchTagRuleTopo(result *metadata.CloudTagRuleResult) (int64, []int64, error) {
+	bizIDs, err := h.searchInstIDs(common.BKInnerObjIDApp, mapstr.MapStr{
+		common.BKAppNameField: result.BizName,
+		common.BKDefaultField: mapstr.MapStr{common.BKDBNE: common.DefaultAppFlag},
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(bizIDs) != 1 {
+		return 0, nil, fmt.Errorf("business %s is not found", result.BizName)
+	}
+	bizID := bizIDs[0]
+
+	moduleCond := mapstr.MapStr{common.BKAppIDField: bizID}
+	switch {
+	case result.ModuleName == "":
+		moduleCond[common.BKDefaultField] = common.DefaultResModuleFlag
+	case result.SetName != "":
+		setIDs, err := h.searchInstIDs(common.BKInnerObjIDSet, mapstr.MapStr{
+			common.BKAppIDField:   bizID,
+			common.BKSetNameField: result.SetName,
+		})
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(setIDs) != 1 {
+			return 0, nil, fmt.Errorf("set %s is not found or not unique in business %s", result.SetName,
+				result.BizName)
+		}
+		moduleCond[common.BKSetIDField] = setIDs[0]
+		moduleCond[common.BKModuleNameField] = result.ModuleName
+	default:
+		moduleCond[common.BKModuleNameField] = result.ModuleName
+	}
+
+	moduleIDs, err := h.searchInstIDs(common.BKInnerObjIDModule, moduleCond)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(moduleIDs) != 1 {
+		return 0, nil, fmt.Errorf("module %s is not found or not unique in business %s", result.ModuleName,
+			result.BizName)
+	}
+	return bizID, moduleIDs, nil
+}
+
+// 查询任务所属开发商的实例的id，读kit不区分开发商，需要限定开发商以免匹配到其他开发商的同名拓扑
+func (h *HostSyncor) searchInstIDs(objID string, cond mapstr.MapStr) ([]int64, error) {
+	idField := common.GetInstIDField(objID)
+	cond[common.BKOwnerIDField] = h.ownerID
+	query := &metadata.QueryCondition{
+		Fields:    []string{idField},
+		Condition: cond,
+	}
+	res, err := h.logics.CoreAPI.CoreService().Instance().ReadInstance(h.readKit.Ctx, h.readKit.Header, objID, query)
+	if err != nil {
+		blog.Errorf("read %s instances failed, err:%v, query:%#v, rid:%s", objID, err, query, h.readKit.Rid)
+		return nil, err
+	}
+	if !res.Result {
+		blog.Errorf("read %s instances failed, err:%s, query:%#v, rid:%s", objID, res.ErrMsg, query, h.readKit.Rid)
+		return nil, res.CCError()
+	}
+
+	ids := make([]int64, 0)
+	for _, inst := range res.Data.Info {
+		id, err := inst.Int64(idField)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// 获取资源池业务id
+func (h *HostSyncor) getResPoolBizID() (int64, error) {
+	if h.resPoolBizID != 0 {
+		return h.resPoolBizID, nil
+	}
+
+	bizIDs, err := h.searchInstIDs(common.BKInnerObjIDApp, mapstr.MapStr{common.BKDefaultField: common.DefaultAppFlag})
+	if err != nil {
+		return 0, err
+	}
+	if len(bizIDs) == 0 {
+		return 0, fmt.Errorf("no default biz is found")
+	}
+	h.resPoolBizID = bizIDs[0]
+	return h.resPoolBizID, nil
+}
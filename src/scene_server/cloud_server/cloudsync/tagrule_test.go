@@ -0,0 +1,100 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudsync
+
+import (
+	"testing"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCloudTagRules(t *testing.T) {
+	rules := []metadata.CloudTagRule{
+		{
+			Name:       "payments",
+			Match:      []metadata.CloudTagMatch{{Key: "biz", Value: "payments"}, {Key: "module", Value: "*"}},
+			Attributes: map[string]string{"bk_comment": "${biz}-${module}"},
+			BizName:    "${biz}",
+			SetName:    "online",
+			ModuleName: "${module}",
+		},
+		{
+			Name:       "owner",
+			Match:      []metadata.CloudTagMatch{{Key: "owner", Value: "*"}},
+			Attributes: map[string]string{"operator": "${owner}"},
+		},
+	}
+	for idx := range rules {
+		require.Equal(t, 0, rules[idx].Validate().ErrCode)
+	}
+
+	// the first matched rule is applied
+	result := metadata.ApplyCloudTagRules(rules, map[string]string{"biz": "payments", "module": "api", "owner": "a"})
+	require.NotNil(t, result)
+	require.Equal(t, "payments", result.RuleName)
+	require.Equal(t, map[string]interface{}{"bk_comment": "payments-api"}, result.Attributes)
+	require.Equal(t, "payments", result.BizName)
+	require.Equal(t, "online", result.SetName)
+	require.Equal(t, "api", result.ModuleName)
+
+	// the first rule does not match if the module tag is missing
+	result = metadata.ApplyCloudTagRules(rules, map[string]string{"biz": "payments", "owner": "a"})
+	require.NotNil(t, result)
+	require.Equal(t, "owner", result.RuleName)
+	require.Equal(t, "", result.BizName)
+
+	require.Nil(t, metadata.ApplyCloudTagRules(rules, map[string]string{"biz": "game"}))
+	require.Nil(t, metadata.ApplyCloudTagRules(nil, map[string]string{"biz": "payments"}))
+
+	// a rule referring to a tag which is not matched does not match the hosts without the tag
+	rule := metadata.CloudTagRule{
+		Name:       "ref",
+		Match:      []metadata.CloudTagMatch{{Key: "env", Value: "prod"}},
+		Attributes: map[string]string{"bk_comment": "${team}"},
+	}
+	require.False(t, rule.IsMatch(map[string]string{"env": "prod"}))
+	require.True(t, rule.IsMatch(map[string]string{"env": "prod", "team": "x"}))
+}
+
+func TestValidateCloudTagRules(t *testing.T) {
+	rule := metadata.CloudTagRule{
+		Name:       "a",
+		Match:      []metadata.CloudTagMatch{{Key: "env", Value: "prod"}},
+		Attributes: map[string]string{"bk_comment": "prod"},
+	}
+	require.Equal(t, 0, metadata.ValidateCloudTagRules([]metadata.CloudTagRule{rule}).ErrCode)
+	require.NotEqual(t, 0, metadata.ValidateCloudTagRules([]metadata.CloudTagRule{rule, rule}).ErrCode)
+
+	invalid := rule
+	invalid.Attributes = map[string]string{"bk_host_innerip": "127.0.0.1"}
+	require.NotEqual(t, 0, invalid.Validate().ErrCode)
+
+	invalid = rule
+	invalid.SetName = "online"
+	require.NotEqual(t, 0, invalid.Validate().ErrCode)
+
+	invalid = rule
+	invalid.Match = nil
+	require.NotEqual(t, 0, invalid.Validate().ErrCode)
+}
+
+func TestTagRuleAttrsChanged(t *testing.T) {
+	result := &metadata.CloudTagRuleResult{Attributes: map[string]interface{}{"bk_comment": "payments"}}
+	require.False(t, tagRuleAttrsChanged(nil, mapstr.MapStr{}))
+	require.False(t, tagRuleAttrsChanged(result, mapstr.MapStr{"bk_comment": "payments", "operator": "a"}))
+	require.True(t, tagRuleAttrsChanged(result, mapstr.MapStr{"bk_comment": "game"}))
+	require.True(t, tagRuleAttrsChanged(result, mapstr.MapStr{}))
+}
@@ -195,6 +195,7 @@ func (c *awsClient) getInstances(region string, opt *ccom.InstanceOpt) ([]*metad
 					PublicIp:      *inst.PublicIpAddress,
 					InstanceState: ccom.CovertInstState(*inst.State.Name),
 					VpcId:         *inst.VpcId,
+					Tags:          c.getInstanceTags(inst),
 				})
 			}
 		}
@@ -298,3 +299,14 @@ func (c *awsClient) getVpcName(vpc *ec2.Vpc) string {
 	}
 	return *vpc.VpcId
 }
+
+// 获取实例的标签
+func (c *awsClient) getInstanceTags(inst *ec2.Instance) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range inst.Tags {
+		if tag.Key != nil {
+			tags[*tag.Key] = aws.StringValue(tag.Value)
+		}
+	}
+	return tags
+}
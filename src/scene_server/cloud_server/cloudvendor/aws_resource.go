@@ -0,0 +1,315 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudvendor
+
+import (
+	"strconv"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+// GetLoadBalancers 获取负载均衡列表
+// API文档：https://docs.aws.amazon.com/elasticloadbalancing/latest/APIReference/API_DescribeLoadBalancers.html
+func (c *awsClient) GetLoadBalancers(region string) ([]*metadata.CloudResource, error) {
+	sess, err := c.newSession(region)
+	if err != nil {
+		return nil, err
+	}
+	elbSvc := elbv2.New(sess)
+
+	resources := make([]*metadata.CloudResource, 0)
+	input := new(elbv2.DescribeLoadBalancersInput)
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeLoadBalancers loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d",
+				loopCnt, len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+
+		output, err := elbSvc.DescribeLoadBalancers(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, lb := range output.LoadBalancers {
+			state := ""
+			if lb.State != nil {
+				state = aws.StringValue(lb.State.Code)
+			}
+			resource := &metadata.CloudResource{
+				ResourceID: aws.StringValue(lb.LoadBalancerArn),
+				Name:       aws.StringValue(lb.LoadBalancerName),
+				Region:     region,
+				VpcID:      aws.StringValue(lb.VpcId),
+				State:      state,
+				Attributes: map[string]interface{}{
+					"dns_name": aws.StringValue(lb.DNSName),
+					"type":     aws.StringValue(lb.Type),
+					"scheme":   aws.StringValue(lb.Scheme),
+				},
+			}
+
+			// 获取负载均衡的后端主机
+			targets, err := c.getLoadBalancerTargets(elbSvc, lb.LoadBalancerArn)
+			if err != nil {
+				return nil, err
+			}
+			resource.AddRelation(metadata.CloudResourceTypeHost, targets...)
+			resources = append(resources, resource)
+		}
+
+		if aws.StringValue(output.NextMarker) == "" {
+			break
+		}
+		// 设置分页请求参数
+		input.Marker = output.NextMarker
+	}
+
+	return resources, nil
+}
+
+// getLoadBalancerTargets 获取负载均衡的目标组中注册的实例id
+// API文档：https://docs.aws.amazon.com/elasticloadbalancing/latest/APIReference/API_DescribeTargetHealth.html
+func (c *awsClient) getLoadBalancerTargets(elbSvc *elbv2.ELBV2, lbArn *string) ([]string, error) {
+	groups, err := elbSvc.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{LoadBalancerArn: lbArn})
+	if err != nil {
+		return nil, err
+	}
+
+	instIDs := make([]string, 0)
+	for _, group := range groups.TargetGroups {
+		if aws.StringValue(group.TargetType) != elbv2.TargetTypeEnumInstance {
+			continue
+		}
+
+		health, err := elbSvc.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{
+			TargetGroupArn: group.TargetGroupArn,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, desc := range health.TargetHealthDescriptions {
+			if desc.Target != nil {
+				instIDs = append(instIDs, aws.StringValue(desc.Target.Id))
+			}
+		}
+	}
+	return instIDs, nil
+}
+
+// GetDatabases 获取RDS数据库实例列表
+// API文档：https://docs.aws.amazon.com/AmazonRDS/latest/APIReference/API_DescribeDBInstances.html
+func (c *awsClient) GetDatabases(region string) ([]*metadata.CloudResource, error) {
+	sess, err := c.newSession(region)
+	if err != nil {
+		return nil, err
+	}
+	rdsSvc := rds.New(sess)
+
+	resources := make([]*metadata.CloudResource, 0)
+	input := new(rds.DescribeDBInstancesInput)
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeDBInstances loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d",
+				loopCnt, len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+
+		output, err := rdsSvc.DescribeDBInstances(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, db := range output.DBInstances {
+			resource := &metadata.CloudResource{
+				ResourceID: aws.StringValue(db.DBInstanceIdentifier),
+				Name:       aws.StringValue(db.DBInstanceIdentifier),
+				Region:     region,
+				State:      aws.StringValue(db.DBInstanceStatus),
+				Attributes: map[string]interface{}{
+					"engine":         aws.StringValue(db.Engine),
+					"engine_version": aws.StringValue(db.EngineVersion),
+					"instance_class": aws.StringValue(db.DBInstanceClass),
+					"storage":        strconv.FormatInt(aws.Int64Value(db.AllocatedStorage), 10),
+					"zone":           aws.StringValue(db.AvailabilityZone),
+				},
+			}
+			if db.DBSubnetGroup != nil {
+				resource.VpcID = aws.StringValue(db.DBSubnetGroup.VpcId)
+			}
+			if db.Endpoint != nil {
+				resource.Attributes["address"] = aws.StringValue(db.Endpoint.Address)
+				resource.Attributes["port"] = strconv.FormatInt(aws.Int64Value(db.Endpoint.Port), 10)
+			}
+			resources = append(resources, resource)
+		}
+
+		if aws.StringValue(output.Marker) == "" {
+			break
+		}
+		// 设置分页请求参数
+		input.Marker = output.Marker
+	}
+
+	return resources, nil
+}
+
+// GetDisks 获取EBS卷列表
+// API文档：https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeVolumes.html
+func (c *awsClient) GetDisks(region string) ([]*metadata.CloudResource, error) {
+	sess, err := c.newSession(region)
+	if err != nil {
+		return nil, err
+	}
+	ec2Svc := ec2.New(sess)
+
+	resources := make([]*metadata.CloudResource, 0)
+	input := new(ec2.DescribeVolumesInput)
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeVolumes loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d",
+				loopCnt, len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+
+		output, err := ec2Svc.DescribeVolumes(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, volume := range output.Volumes {
+			resource := &metadata.CloudResource{
+				ResourceID: aws.StringValue(volume.VolumeId),
+				Name:       c.getTagName(volume.Tags, aws.StringValue(volume.VolumeId)),
+				Region:     region,
+				State:      aws.StringValue(volume.State),
+				Attributes: map[string]interface{}{
+					"disk_type": aws.StringValue(volume.VolumeType),
+					"disk_size": strconv.FormatInt(aws.Int64Value(volume.Size), 10),
+					"zone":      aws.StringValue(volume.AvailabilityZone),
+				},
+			}
+			for _, attachment := range volume.Attachments {
+				resource.AddRelation(metadata.CloudResourceTypeHost, aws.StringValue(attachment.InstanceId))
+			}
+			resources = append(resources, resource)
+		}
+
+		if aws.StringValue(output.NextToken) == "" {
+			break
+		}
+		// 设置分页请求参数
+		input.NextToken = output.NextToken
+	}
+
+	return resources, nil
+}
+
+// GetSecurityGroups 获取安全组列表
+// API文档：https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_DescribeSecurityGroups.html
+func (c *awsClient) GetSecurityGroups(region string) ([]*metadata.CloudResource, error) {
+	sess, err := c.newSession(region)
+	if err != nil {
+		return nil, err
+	}
+	ec2Svc := ec2.New(sess)
+
+	// 安全组绑定的主机需要从实例的安全组信息中获取
+	groupInstances, err := c.getSecurityGroupInstances(ec2Svc)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	input := new(ec2.DescribeSecurityGroupsInput)
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeSecurityGroups loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d",
+				loopCnt, len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+
+		output, err := ec2Svc.DescribeSecurityGroups(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range output.SecurityGroups {
+			resource := &metadata.CloudResource{
+				ResourceID: aws.StringValue(group.GroupId),
+				Name:       aws.StringValue(group.GroupName),
+				Region:     region,
+				VpcID:      aws.StringValue(group.VpcId),
+				Attributes: map[string]interface{}{
+					"description": aws.StringValue(group.Description),
+				},
+			}
+			resource.AddRelation(metadata.CloudResourceTypeHost, groupInstances[resource.ResourceID]...)
+			resources = append(resources, resource)
+		}
+
+		if aws.StringValue(output.NextToken) == "" {
+			break
+		}
+		// 设置分页请求参数
+		input.NextToken = output.NextToken
+	}
+
+	return resources, nil
+}
+
+// getSecurityGroupInstances 获取地域下各个安全组绑定的实例id
+func (c *awsClient) getSecurityGroupInstances(ec2Svc *ec2.EC2) (map[string][]string, error) {
+	groupInstances := make(map[string][]string)
+	input := new(ec2.DescribeInstancesInput)
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeInstances loopCnt:%d, bigger than MaxLoopCnt", loopCnt)
+			return nil, ccom.ErrorLoopCnt
+		}
+
+		output, err := ec2Svc.DescribeInstances(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, reservation := range output.Reservations {
+			for _, inst := range reservation.Instances {
+				for _, group := range inst.SecurityGroups {
+					groupID := aws.StringValue(group.GroupId)
+					groupInstances[groupID] = append(groupInstances[groupID], aws.StringValue(inst.InstanceId))
+				}
+			}
+		}
+
+		if aws.StringValue(output.NextToken) == "" {
+			break
+		}
+		// 设置分页请求参数
+		input.NextToken = output.NextToken
+	}
+
+	return groupInstances, nil
+}
+
+// 获取资源的名称标签，没有名称标签，则使用defaultName作为名称
+func (c *awsClient) getTagName(tags []*ec2.Tag, defaultName string) string {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == "Name" {
+			return aws.StringValue(tag.Value)
+		}
+	}
+	return defaultName
+}
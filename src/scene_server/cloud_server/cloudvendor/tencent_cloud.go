@@ -163,6 +163,7 @@ func (c *tcClient) GetInstances(region string, opt *ccom.InstanceOpt) (*metadata
 				PublicIp:      publicIP,
 				InstanceState: ccom.CovertInstState(*inst.InstanceState),
 				VpcId:         *inst.VirtualPrivateCloud.VpcId,
+				Tags:          c.getInstanceTags(inst),
 			})
 		}
 		totalCnt = int64(*resp.Response.TotalCount)
@@ -274,3 +275,14 @@ func (c *tcClient) setCvmLimit(Dstlimit **int64, limit int64) {
 		limit = tcMaxPageSize
 	}
 }
+
+// 获取实例的标签
+func (c *tcClient) getInstanceTags(inst *cvm.Instance) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range inst.Tags {
+		if tag.Key != nil {
+			tags[*tag.Key] = tcString(tag.Value)
+		}
+	}
+	return tags
+}
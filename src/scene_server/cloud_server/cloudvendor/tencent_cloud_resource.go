@@ -0,0 +1,351 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudvendor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	ccom "configcenter/src/scene_server/cloud_server/common"
+
+	cbs "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cbs/v20170312"
+	cdb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cdb/v20170320"
+	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	tcVpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+// GetLoadBalancers 获取负载均衡列表
+// API文档：https://cloud.tencent.com/document/api/214/30685
+func (c *tcClient) GetLoadBalancers(region string) ([]*metadata.CloudResource, error) {
+	client, err := clb.NewClient(c.newCredential(c.secretID, c.secretKey), region, profile.NewClientProfile())
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	request := clb.NewDescribeLoadBalancersRequest()
+	request.Limit = ccom.Int64Ptr(tcMaxPageSize)
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeLoadBalancers loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+		request.Offset = ccom.Int64Ptr(int64(len(resources)))
+
+		resp, err := client.DescribeLoadBalancers(request)
+		if err != nil {
+			return nil, err
+		}
+		for _, lb := range resp.Response.LoadBalancerSet {
+			resource := &metadata.CloudResource{
+				ResourceID: tcString(lb.LoadBalancerId),
+				Name:       tcString(lb.LoadBalancerName),
+				Region:     region,
+				VpcID:      tcString(lb.VpcId),
+				State:      tcUint64(lb.Status),
+				Attributes: map[string]interface{}{
+					"vips": strings.Join(tcStrings(lb.LoadBalancerVips), ","),
+					"type": tcString(lb.LoadBalancerType),
+				},
+			}
+
+			// 获取负载均衡的后端主机
+			targets, err := c.getLoadBalancerTargets(client, resource.ResourceID)
+			if err != nil {
+				return nil, err
+			}
+			resource.AddRelation(metadata.CloudResourceTypeHost, targets...)
+			resources = append(resources, resource)
+		}
+
+		if len(resp.Response.LoadBalancerSet) == 0 || uint64(len(resources)) >= tcUint64Value(resp.Response.TotalCount) {
+			break
+		}
+	}
+
+	return resources, nil
+}
+
+// getLoadBalancerTargets 获取负载均衡绑定的后端云主机实例id
+// API文档：https://cloud.tencent.com/document/api/214/30684
+func (c *tcClient) getLoadBalancerTargets(client *clb.Client, lbID string) ([]string, error) {
+	request := clb.NewDescribeTargetsRequest()
+	request.LoadBalancerId = &lbID
+	resp, err := client.DescribeTargets(request)
+	if err != nil {
+		return nil, err
+	}
+
+	instIDs := make([]string, 0)
+	addTargets := func(targets []*clb.Backend) {
+		for _, target := range targets {
+			if tcString(target.Type) == "CVM" {
+				instIDs = append(instIDs, tcString(target.InstanceId))
+			}
+		}
+	}
+	for _, listener := range resp.Response.Listeners {
+		addTargets(listener.Targets)
+		for _, rule := range listener.Rules {
+			addTargets(rule.Targets)
+		}
+	}
+	return instIDs, nil
+}
+
+// GetDatabases 获取云数据库MySQL实例列表
+// API文档：https://cloud.tencent.com/document/api/236/15872
+func (c *tcClient) GetDatabases(region string) ([]*metadata.CloudResource, error) {
+	client, err := cdb.NewClient(c.newCredential(c.secretID, c.secretKey), region, profile.NewClientProfile())
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	request := cdb.NewDescribeDBInstancesRequest()
+	limit := uint64(tcMaxPageSize)
+	request.Limit = &limit
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeDBInstances loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+		offset := uint64(len(resources))
+		request.Offset = &offset
+
+		resp, err := client.DescribeDBInstances(request)
+		if err != nil {
+			return nil, err
+		}
+		for _, db := range resp.Response.Items {
+			resources = append(resources, &metadata.CloudResource{
+				ResourceID: tcString(db.InstanceId),
+				Name:       tcString(db.InstanceName),
+				Region:     region,
+				VpcID:      tcString(db.UniqVpcId),
+				State:      tcInt64(db.Status),
+				Attributes: map[string]interface{}{
+					"engine_version": tcString(db.EngineVersion),
+					"vip":            tcString(db.Vip),
+					"vport":          tcInt64(db.Vport),
+					"memory":         tcInt64(db.Memory),
+					"volume":         tcInt64(db.Volume),
+					"zone":           tcString(db.Zone),
+				},
+			})
+		}
+
+		if len(resp.Response.Items) == 0 || int64(len(resources)) >= tcInt64Value(resp.Response.TotalCount) {
+			break
+		}
+	}
+
+	return resources, nil
+}
+
+// GetDisks 获取云硬盘列表
+// API文档：https://cloud.tencent.com/document/api/362/16315
+func (c *tcClient) GetDisks(region string) ([]*metadata.CloudResource, error) {
+	client, err := cbs.NewClient(c.newCredential(c.secretID, c.secretKey), region, profile.NewClientProfile())
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	request := cbs.NewDescribeDisksRequest()
+	limit := uint64(tcMaxPageSize)
+	request.Limit = &limit
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeDisks loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+		offset := uint64(len(resources))
+		request.Offset = &offset
+
+		resp, err := client.DescribeDisks(request)
+		if err != nil {
+			return nil, err
+		}
+		for _, disk := range resp.Response.DiskSet {
+			zone := ""
+			if disk.Placement != nil {
+				zone = tcString(disk.Placement.Zone)
+			}
+			resource := &metadata.CloudResource{
+				ResourceID: tcString(disk.DiskId),
+				Name:       tcString(disk.DiskName),
+				Region:     region,
+				State:      tcString(disk.DiskState),
+				Attributes: map[string]interface{}{
+					"disk_type":  tcString(disk.DiskType),
+					"disk_usage": tcString(disk.DiskUsage),
+					"disk_size":  tcUint64(disk.DiskSize),
+					"zone":       zone,
+				},
+			}
+			if disk.Attached != nil && *disk.Attached {
+				resource.AddRelation(metadata.CloudResourceTypeHost, tcString(disk.InstanceId))
+			}
+			resources = append(resources, resource)
+		}
+
+		if len(resp.Response.DiskSet) == 0 || uint64(len(resources)) >= tcUint64Value(resp.Response.TotalCount) {
+			break
+		}
+	}
+
+	return resources, nil
+}
+
+// GetSecurityGroups 获取安全组列表
+// API文档：https://cloud.tencent.com/document/api/215/15808
+func (c *tcClient) GetSecurityGroups(region string) ([]*metadata.CloudResource, error) {
+	client, err := tcVpc.NewClient(c.newCredential(c.secretID, c.secretKey), region, profile.NewClientProfile())
+	if err != nil {
+		return nil, err
+	}
+
+	// 安全组绑定的云主机需要从云主机的安全组信息中获取
+	groupInstances, err := c.getSecurityGroupInstances(region)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	request := tcVpc.NewDescribeSecurityGroupsRequest()
+	request.Limit = ccom.StringPtr(strconv.FormatInt(tcMaxPageSize, 10))
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeSecurityGroups loopCnt:%d, bigger than MaxLoopCnt, len(resources):%d", loopCnt,
+				len(resources))
+			return nil, ccom.ErrorLoopCnt
+		}
+		request.Offset = ccom.StringPtr(strconv.Itoa(len(resources)))
+
+		resp, err := client.DescribeSecurityGroups(request)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range resp.Response.SecurityGroupSet {
+			resource := &metadata.CloudResource{
+				ResourceID: tcString(group.SecurityGroupId),
+				Name:       tcString(group.SecurityGroupName),
+				Region:     region,
+				Attributes: map[string]interface{}{
+					"description": tcString(group.SecurityGroupDesc),
+				},
+			}
+			resource.AddRelation(metadata.CloudResourceTypeHost, groupInstances[resource.ResourceID]...)
+			resources = append(resources, resource)
+		}
+
+		if len(resp.Response.SecurityGroupSet) == 0 ||
+			uint64(len(resources)) >= tcUint64Value(resp.Response.TotalCount) {
+			break
+		}
+	}
+
+	return resources, nil
+}
+
+// getSecurityGroupInstances 获取地域下各个安全组绑定的云主机实例id
+// API文档：https://cloud.tencent.com/document/api/213/15728
+func (c *tcClient) getSecurityGroupInstances(region string) (map[string][]string, error) {
+	client, err := cvm.NewClient(c.newCredential(c.secretID, c.secretKey), region, profile.NewClientProfile())
+	if err != nil {
+		return nil, err
+	}
+
+	groupInstances := make(map[string][]string)
+	request := cvm.NewDescribeInstancesRequest()
+	request.Limit = ccom.Int64Ptr(tcMaxPageSize)
+	var count int64
+	for loopCnt := 0; ; loopCnt++ {
+		if loopCnt > ccom.MaxLoopCnt {
+			blog.Errorf("DescribeInstances loopCnt:%d, bigger than MaxLoopCnt, count:%d", loopCnt, count)
+			return nil, ccom.ErrorLoopCnt
+		}
+		request.Offset = ccom.Int64Ptr(count)
+
+		resp, err := client.DescribeInstances(request)
+		if err != nil {
+			return nil, err
+		}
+		for _, inst := range resp.Response.InstanceSet {
+			for _, groupID := range tcStrings(inst.SecurityGroupIds) {
+				groupInstances[groupID] = append(groupInstances[groupID], tcString(inst.InstanceId))
+			}
+		}
+
+		count += int64(len(resp.Response.InstanceSet))
+		if len(resp.Response.InstanceSet) == 0 || count >= tcInt64Value(resp.Response.TotalCount) {
+			break
+		}
+	}
+
+	return groupInstances, nil
+}
+
+func tcString(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+func tcStrings(ps []*string) []string {
+	vals := make([]string, 0, len(ps))
+	for _, p := range ps {
+		if p != nil {
+			vals = append(vals, *p)
+		}
+	}
+	return vals
+}
+
+func tcInt64Value(p *int64) int64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+func tcUint64Value(p *uint64) uint64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+func tcInt64(p *int64) string {
+	if p == nil {
+		return ""
+	}
+	return strconv.FormatInt(*p, 10)
+}
+
+func tcUint64(p *uint64) string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", *p)
+}
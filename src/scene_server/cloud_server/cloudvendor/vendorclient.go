@@ -34,6 +34,51 @@ type VendorClient interface {
 	GetInstancesTotalCnt(region string, opt *ccom.InstanceOpt) (int64, error)
 }
 
+// 以下为可选的非主机云资源获取接口，云厂商客户端按需实现，资源的关联资源在云端的id放在Relations中
+
+// LoadBalancerLister 获取负载均衡列表，后端主机作为host类型的关联资源
+type LoadBalancerLister interface {
+	GetLoadBalancers(region string) ([]*metadata.CloudResource, error)
+}
+
+// DatabaseLister 获取云数据库列表
+type DatabaseLister interface {
+	GetDatabases(region string) ([]*metadata.CloudResource, error)
+}
+
+// DiskLister 获取云硬盘列表，挂载的主机作为host类型的关联资源
+type DiskLister interface {
+	GetDisks(region string) ([]*metadata.CloudResource, error)
+}
+
+// SecurityGroupLister 获取安全组列表，绑定的主机作为host类型的关联资源
+type SecurityGroupLister interface {
+	GetSecurityGroups(region string) ([]*metadata.CloudResource, error)
+}
+
+// GetResources 获取地域下的非主机云资源，云厂商客户端没有实现该资源类型的获取接口时返回错误
+func GetResources(client VendorClient, resourceType, region string) ([]*metadata.CloudResource, error) {
+	switch resourceType {
+	case metadata.CloudResourceTypeLoadBalancer:
+		if lister, ok := client.(LoadBalancerLister); ok {
+			return lister.GetLoadBalancers(region)
+		}
+	case metadata.CloudResourceTypeDatabase:
+		if lister, ok := client.(DatabaseLister); ok {
+			return lister.GetDatabases(region)
+		}
+	case metadata.CloudResourceTypeDisk:
+		if lister, ok := client.(DiskLister); ok {
+			return lister.GetDisks(region)
+		}
+	case metadata.CloudResourceTypeSecurityGroup:
+		if lister, ok := client.(SecurityGroupLister); ok {
+			return lister.GetSecurityGroups(region)
+		}
+	}
+	return nil, fmt.Errorf("resource type %s is not supported by the vendor", resourceType)
+}
+
 // Register 注册云厂商客户端
 func Register(vendorName string, client VendorClient) {
 	vendorClients[vendorName] = client
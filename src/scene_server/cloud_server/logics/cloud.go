@@ -354,6 +354,113 @@ func (lgc *Logics) GetCloudHostResource(kit *rest.Kit, conf metadata.CloudAccoun
 	return result, nil
 }
 
+// GetCloudResources 获取需要同步的非主机云资源，依次获取每个地域下的资源
+func (lgc *Logics) GetCloudResources(kit *rest.Kit, conf metadata.CloudAccountConf, resourceType string,
+	regions []string) ([]*metadata.CloudResource, error) {
+
+	client, err := cloudvendor.GetVendorClient(conf)
+	if err != nil {
+		blog.Errorf("GetCloudResources GetVendorClient failed, AccountID:%d, err:%s, rid:%s", conf.AccountID,
+			err.Error(), kit.Rid)
+		return nil, err
+	}
+
+	resources := make([]*metadata.CloudResource, 0)
+	for _, region := range regions {
+		regionResources, err := cloudvendor.GetResources(client, resourceType, region)
+		if err != nil {
+			blog.Errorf("GetCloudResources failed, AccountID:%d, resource type:%s, region:%s, err:%s, rid:%s",
+				conf.AccountID, resourceType, region, err.Error(), kit.Rid)
+			return nil, err
+		}
+		resources = append(resources, regionResources...)
+	}
+
+	return resources, nil
+}
+
+// tagRulePreviewSampleSize 标签映射规则预览时每个规则返回的云主机实例id的最大数量
+const tagRulePreviewSampleSize = 10
+
+// PreviewTagRules 预览标签映射规则，统计账号下所有地域的云主机匹配各个规则的数量
+func (lgc *Logics) PreviewTagRules(kit *rest.Kit, conf metadata.CloudAccountConf, rules []metadata.CloudTagRule) (
+	*metadata.CloudTagRulePreview, error) {
+
+	client, err := cloudvendor.GetVendorClient(conf)
+	if err != nil {
+		blog.Errorf("PreviewTagRules GetVendorClient failed, AccountID:%d, err:%s, rid:%s", conf.AccountID,
+			err.Error(), kit.Rid)
+		return nil, err
+	}
+
+	regionSet, err := client.GetRegions()
+	if err != nil {
+		blog.Errorf("PreviewTagRules GetRegions err:%s, rid:%s", err.Error(), kit.Rid)
+		return nil, err
+	}
+
+	// 并发请求获取每个地域下的云主机
+	instances := make([]*metadata.Instance, 0)
+	var firstErr error
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for _, region := range regionSet {
+		wg.Add(1)
+		go func(region *metadata.Region) {
+			defer wg.Done()
+			result, err := client.GetInstances(region.RegionId, nil)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				blog.Errorf("PreviewTagRules GetInstances failed, AccountID:%d, region:%s, err:%s, rid:%s",
+					conf.AccountID, region.RegionId, err.Error(), kit.Rid)
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			instances = append(instances, result.InstanceSet...)
+		}(region)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	preview := &metadata.CloudTagRulePreview{
+		Total: int64(len(instances)),
+		Rules: make([]metadata.CloudTagRuleMatchInfo, len(rules)),
+	}
+	for idx := range rules {
+		preview.Rules[idx] = metadata.CloudTagRuleMatchInfo{RuleName: rules[idx].Name, InstanceIDs: make([]string, 0)}
+	}
+
+	for _, inst := range instances {
+		applied := false
+		for idx := range rules {
+			if !rules[idx].IsMatch(inst.Tags) {
+				continue
+			}
+			info := &preview.Rules[idx]
+			info.Matched++
+			if applied {
+				continue
+			}
+			// 云主机只应用第一个匹配的规则
+			applied = true
+			info.Applied++
+			if len(info.InstanceIDs) < tagRulePreviewSampleSize {
+				info.InstanceIDs = append(info.InstanceIDs, inst.InstanceId)
+			}
+		}
+		if !applied {
+			preview.Unmatched++
+		}
+	}
+
+	return preview, nil
+}
+
 // GetCloudAccountConf 获取云账户配置
 func (lgc *Logics) GetCloudAccountConf(kit *rest.Kit, accountID int64) (*metadata.CloudAccountConf, error) {
 	option := &metadata.SearchCloudOption{Condition: mapstr.MapStr{common.BKCloudAccountID: accountID}}
@@ -369,14 +476,12 @@ func (lgc *Logics) GetCloudAccountConf(kit *rest.Kit, accountID int64) (*metadat
 
 	accountConf := result.Info[0]
 	// 解密云账户密钥
-	if lgc.cryptor != nil {
-		secretKey, err := lgc.cryptor.Decrypt(accountConf.SecretKey)
-		if err != nil {
-			blog.Errorf("GetCloudAccountConf failed, Encrypt err: %st, rid:%s", err.Error(), kit.Rid)
-			return nil, err
-		}
-		accountConf.SecretKey = secretKey
+	secretKey, secretErr := lgc.resolveAccountSecret(kit, accountConf.SecretKey)
+	if secretErr != nil {
+		blog.Errorf("GetCloudAccountConf failed, accountID: %d, err: %s, rid:%s", accountID, secretErr.Error(), kit.Rid)
+		return nil, secretErr
 	}
+	accountConf.SecretKey = secretKey
 
 	return &accountConf, nil
 }
@@ -406,16 +511,14 @@ func (lgc *Logics) GetCloudAccountConfBatch(kit *rest.Kit, accountIDs []int64) (
 
 	accountConfs := result.Info
 	// 解密云账户密钥
-	if lgc.cryptor != nil {
-		for i, _ := range accountConfs {
-			secretKey, err := lgc.cryptor.Decrypt(accountConfs[i].SecretKey)
-			if err != nil {
-				blog.Errorf("GetCloudAccountConfBatch failed, Encrypt err: %s, rid:%s", err.Error(), kit.Rid)
-				return nil, err
-			}
-			accountConfs[i].SecretKey = secretKey
+	for i, _ := range accountConfs {
+		secretKey, err := lgc.resolveAccountSecret(kit, accountConfs[i].SecretKey)
+		if err != nil {
+			blog.Errorf("GetCloudAccountConfBatch failed, accountID: %d, err: %s, rid:%s", accountConfs[i].AccountID,
+				err.Error(), kit.Rid)
+			return nil, err
 		}
-
+		accountConfs[i].SecretKey = secretKey
 	}
 
 	return accountConfs, nil
@@ -15,20 +15,20 @@ package logics
 import (
 	"configcenter/src/ac"
 	"configcenter/src/common/backbone"
-	"configcenter/src/common/cryptor"
+	"configcenter/src/common/secret"
 )
 
 // Logics framwork need
 type Logics struct {
 	*backbone.Engine
-	cryptor    cryptor.Cryptor
-	authorizer ac.AuthorizeInterface
+	secretProvider secret.SecretProvider
+	authorizer     ac.AuthorizeInterface
 }
 
-func NewLogics(engine *backbone.Engine, cryptor cryptor.Cryptor, authorizer ac.AuthorizeInterface) *Logics {
+func NewLogics(engine *backbone.Engine, secretProvider secret.SecretProvider, authorizer ac.AuthorizeInterface) *Logics {
 	return &Logics{
-		Engine:     engine,
-		cryptor:    cryptor,
-		authorizer: authorizer,
+		Engine:         engine,
+		secretProvider: secretProvider,
+		authorizer:     authorizer,
 	}
 }
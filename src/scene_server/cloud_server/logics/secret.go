@@ -0,0 +1,124 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
+
+	"github.com/rs/xid"
+)
+
+// cloudAccountSecretName 云账户密钥在密钥后端中的名称前缀
+const cloudAccountSecretName = "cloud_account/"
+
+// PutAccountSecret 将云账户密钥保存到密钥后端，返回需要保存在云账户中的密钥引用，未启用密钥后端时返回明文
+func (lgc *Logics) PutAccountSecret(kit *rest.Kit, secretKey string) (string, error) {
+	if lgc.secretProvider == nil {
+		return secretKey, nil
+	}
+
+	ref, err := lgc.secretProvider.Put(kit.Ctx, cloudAccountSecretName+xid.New().String(), secretKey)
+	if err != nil {
+		blog.Errorf("put cloud account secret to %s failed, err: %v, rid: %s", lgc.secretProvider.Name(), err, kit.Rid)
+		return "", err
+	}
+	return ref, nil
+}
+
+// DeleteAccountSecret 删除密钥后端中不再使用的云账户密钥，失败时只记录日志
+func (lgc *Logics) DeleteAccountSecret(kit *rest.Kit, ref string) {
+	if lgc.secretProvider == nil || ref == "" {
+		return
+	}
+
+	if err := lgc.secretProvider.Delete(kit.Ctx, ref); err != nil {
+		blog.Errorf("delete cloud account secret from %s failed, err: %v, rid: %s", lgc.secretProvider.Name(), err,
+			kit.Rid)
+	}
+}
+
+// GetAccountSecretRef 获取云账户中保存的密钥引用
+func (lgc *Logics) GetAccountSecretRef(kit *rest.Kit, accountID int64) (string, error) {
+	option := &metadata.SearchCloudOption{
+		Condition: mapstr.MapStr{common.BKCloudAccountID: accountID},
+		Fields:    []string{common.BKCloudAccountID, common.BKSecretKey},
+	}
+	result, err := lgc.CoreAPI.CoreService().Cloud().SearchAccountConf(kit.Ctx, kit.Header, option)
+	if err != nil {
+		blog.Errorf("search cloud account conf failed, accountID: %d, err: %v, rid: %s", accountID, err, kit.Rid)
+		return "", err
+	}
+	if len(result.Info) == 0 {
+		return "", kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKCloudAccountID)
+	}
+	return result.Info[0].SecretKey, nil
+}
+
+// resolveAccountSecret 从密钥后端中读取云账户密钥引用对应的明文密钥
+func (lgc *Logics) resolveAccountSecret(kit *rest.Kit, ref string) (string, error) {
+	if lgc.secretProvider == nil {
+		return ref, nil
+	}
+
+	secretKey, err := lgc.secretProvider.Get(kit.Ctx, ref)
+	if err != nil {
+		blog.Errorf("get cloud account secret from %s failed, err: %v, rid: %s", lgc.secretProvider.Name(), err, kit.Rid)
+		return "", err
+	}
+	return secretKey, nil
+}
+
+// RotateAccountSecrets 密钥后端支持密钥轮换时，将所有云账户的密钥使用当前生效的密钥重新加密
+func (lgc *Logics) RotateAccountSecrets(kit *rest.Kit) error {
+	rotator, ok := lgc.secretProvider.(secret.Rotator)
+	if !ok {
+		return nil
+	}
+
+	option := &metadata.SearchCloudOption{Fields: []string{common.BKCloudAccountID, common.BKSecretKey}}
+	result, err := lgc.CoreAPI.CoreService().Cloud().SearchAccountConf(kit.Ctx, kit.Header, option)
+	if err != nil {
+		blog.Errorf("search cloud account conf failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+
+	rotatedCnt := 0
+	for _, conf := range result.Info {
+		ref, rotated, err := rotator.Rotate(kit.Ctx, conf.SecretKey)
+		if err != nil {
+			blog.Errorf("rotate secret of cloud account %d failed, err: %v, rid: %s", conf.AccountID, err, kit.Rid)
+			continue
+		}
+		if !rotated {
+			continue
+		}
+
+		data := mapstr.MapStr{common.BKSecretKey: ref}
+		if err := lgc.CoreAPI.CoreService().Cloud().UpdateAccount(kit.Ctx, kit.Header, conf.AccountID,
+			data); err != nil {
+			blog.Errorf("update rotated secret of cloud account %d failed, err: %v, rid: %s", conf.AccountID, err,
+				kit.Rid)
+			continue
+		}
+		rotatedCnt++
+	}
+
+	blog.Infof("rotate cloud account secrets finished, rotated: %d, total: %d, rid: %s", rotatedCnt, len(result.Info),
+		kit.Rid)
+	return nil
+}
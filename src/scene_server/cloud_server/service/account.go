@@ -106,16 +106,14 @@ func (s *Service) CreateAccount(ctx *rest.Contexts) {
 		return
 	}
 
-	// 加密云账户密钥
-	if s.cryptor != nil {
-		secretKey, err := s.cryptor.Encrypt(account.SecretKey)
-		if err != nil {
-			blog.Errorf("CreateAccount failed, Encrypt err: %s, rid: %d", err, ctx.Kit.Rid)
-			ctx.RespWithError(err, common.CCErrCloudAccountCreateFail, "CreateAccount Encrypt err")
-			return
-		}
-		account.SecretKey = secretKey
+	// 将云账户密钥保存到密钥后端
+	secretRef, err := s.Logics.PutAccountSecret(ctx.Kit, account.SecretKey)
+	if err != nil {
+		blog.Errorf("CreateAccount failed, PutAccountSecret err: %s, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespWithError(err, common.CCErrCloudAccountCreateFail, "CreateAccount PutAccountSecret err")
+		return
 	}
+	account.SecretKey = secretRef
 
 	var res *metadata.CloudAccount
 	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, s.EnableTxn, ctx.Kit.Header, func() error {
@@ -162,6 +160,7 @@ func (s *Service) CreateAccount(ctx *rest.Contexts) {
 		return nil
 	})
 	if txnErr != nil {
+		s.Logics.DeleteAccountSecret(ctx.Kit, secretRef)
 		ctx.RespAutoError(txnErr)
 		return
 	}
@@ -250,6 +249,25 @@ func (s *Service) UpdateAccount(ctx *rest.Contexts) {
 		return
 	}
 
+	// 更新密钥时将新密钥保存到密钥后端，更新成功后删除旧密钥
+	var oldSecretRef, newSecretRef string
+	if secretKey, ok := option[common.BKSecretKey].(string); ok && secretKey != "" {
+		oldSecretRef, err = s.Logics.GetAccountSecretRef(ctx.Kit, accountID)
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+
+		newSecretRef, err = s.Logics.PutAccountSecret(ctx.Kit, secretKey)
+		if err != nil {
+			blog.Errorf("UpdateAccount failed, PutAccountSecret err: %s, accountID: %d, rid: %s", err, accountID,
+				ctx.Kit.Rid)
+			ctx.RespWithError(err, common.CCErrCommInternalServerError, "UpdateAccount PutAccountSecret err")
+			return
+		}
+		option[common.BKSecretKey] = newSecretRef
+	}
+
 	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, s.EnableTxn, ctx.Kit.Header, func() error {
 		// generate audit log.
 		audit := auditlog.NewCloudAccountAuditLog(s.CoreAPI.CoreService())
@@ -279,9 +297,13 @@ func (s *Service) UpdateAccount(ctx *rest.Contexts) {
 	})
 
 	if txnErr != nil {
+		s.Logics.DeleteAccountSecret(ctx.Kit, newSecretRef)
 		ctx.RespAutoError(txnErr)
 		return
 	}
+	if oldSecretRef != newSecretRef {
+		s.Logics.DeleteAccountSecret(ctx.Kit, oldSecretRef)
+	}
 
 	ctx.RespEntity(nil)
 }
@@ -296,6 +318,12 @@ func (s *Service) DeleteAccount(ctx *rest.Contexts) {
 		return
 	}
 
+	secretRef, err := s.Logics.GetAccountSecretRef(ctx.Kit, accountID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, s.EnableTxn, ctx.Kit.Header, func() error {
 		// generate audit log.
 		audit := auditlog.NewCloudAccountAuditLog(s.CoreAPI.CoreService())
@@ -326,6 +354,48 @@ func (s *Service) DeleteAccount(ctx *rest.Contexts) {
 		ctx.RespAutoError(txnErr)
 		return
 	}
+	s.Logics.DeleteAccountSecret(ctx.Kit, secretRef)
 
 	ctx.RespEntity(nil)
 }
+
+// 预览云账户的标签映射规则，统计账户下的云主机匹配各个规则的数量，未传规则时预览账户已保存的规则
+func (s *Service) PreviewTagRules(ctx *rest.Contexts) {
+	accountIDStr := ctx.Request.PathParameter(common.BKCloudAccountID)
+	accountID, err := strconv.ParseInt(accountIDStr, 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKCloudAccountID))
+		return
+	}
+
+	option := new(metadata.CloudTagRulePreviewOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := metadata.ValidateCloudTagRules(option.Rules); rawErr.ErrCode != 0 {
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	accountConf, err := s.Logics.GetCloudAccountConf(ctx.Kit, accountID)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCloudAccountIDNoExistFail, accountID))
+		return
+	}
+
+	rules := option.Rules
+	if len(rules) == 0 {
+		rules = accountConf.TagRules
+	}
+
+	preview, err := s.Logics.PreviewTagRules(ctx.Kit, *accountConf, rules)
+	if err != nil {
+		blog.Errorf("PreviewTagRules failed, accountID: %d, err: %v, rid: %s", accountID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCloudVendorInterfaceCalledFailed))
+		return
+	}
+
+	ctx.RespEntity(preview)
+}
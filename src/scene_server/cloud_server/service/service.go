@@ -18,7 +18,6 @@ import (
 
 	"configcenter/src/ac"
 	"configcenter/src/common/backbone"
-	"configcenter/src/common/cryptor"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/rdapi"
@@ -28,8 +27,7 @@ import (
 
 type Service struct {
 	*backbone.Engine
-	ctx     context.Context
-	cryptor cryptor.Cryptor
+	ctx context.Context
 	*logics.Logics
 	EnableTxn  bool
 	authorizer ac.AuthorizeInterface
@@ -41,10 +39,6 @@ func NewService(ctx context.Context) *Service {
 	}
 }
 
-func (s *Service) SetEncryptor(cryptor cryptor.Cryptor) {
-	s.cryptor = cryptor
-}
-
 func (s *Service) SetAuthorizer(authorizer ac.AuthorizeInterface) {
 	s.authorizer = authorizer
 }
@@ -84,6 +78,7 @@ func (s *Service) initRoute(api *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/account", Handler: s.SearchAccount})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/cloud/account/{bk_account_id}", Handler: s.UpdateAccount})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/cloud/account/{bk_account_id}", Handler: s.DeleteAccount})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/account/tag_rule/preview/{bk_account_id}", Handler: s.PreviewTagRules})
 
 	// cloud sync task
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/account/vpc/{bk_account_id}", Handler: s.SearchVpc})
@@ -23,6 +23,9 @@ import (
 type ServerOption struct {
 	// ServConf is CC API config.
 	ServConf *config.CCAPIConfig
+
+	// EnableTxn is whether to apply the discovered instances and associations of a message in a transaction.
+	EnableTxn bool
 }
 
 // NewServerOption creates a new ServerOption object.
@@ -39,4 +42,5 @@ func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServConf.ExConfig, "config", "", "The config path. e.g conf/api.conf")
 	fs.StringVar(&s.ServConf.RegisterIP, "register-ip", "", "the ip address registered on zookeeper, it can be domain")
 	fs.Var(auth.EnableAuthFlag, "enable-auth", "The auth center enable status, true for enabled, false for disabled")
+	fs.BoolVar(&s.EnableTxn, "enable-txn", true, "enable transaction or not")
 }
\ No newline at end of file
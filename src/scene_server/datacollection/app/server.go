@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -90,6 +91,10 @@ type DataCollectionConfig struct {
 
 	// DefaultAppName default name of this app.
 	DefaultAppName string
+
+	// IngestTokens tokens of collectors that push data by the ingestion api,
+	// the ingestion api is disabled if there is no token.
+	IngestTokens []string
 }
 
 // DataCollection is data collection server.
@@ -136,6 +141,9 @@ type DataCollection struct {
 	// hash collections hash object, that updates target nodes in dynamic mode,
 	// and calculates node base on hash key of data.
 	hash *collections.Hash
+
+	// enableTxn is whether to apply the discovered data of a message in a transaction.
+	enableTxn bool
 }
 
 // NewDataCollection creates a new DataCollection object.
@@ -147,7 +155,7 @@ func NewDataCollection(ctx context.Context, op *options.ServerOption) (*DataColl
 	}
 
 	// new DataCollection instance.
-	newDataCollection := &DataCollection{ctx: ctx}
+	newDataCollection := &DataCollection{ctx: ctx, enableTxn: op.EnableTxn}
 
 	engine, err := backbone.NewBackbone(ctx, &backbone.BackboneParameter{
 		ConfigUpdate: newDataCollection.OnHostConfigUpdate,
@@ -201,6 +209,9 @@ func (c *DataCollection) OnHostConfigUpdate(prev, curr cc.ProcessConfig) {
 		c.config.Esb.Addrs, _ = cc.String("datacollection.esb.addr")
 		c.config.Esb.AppCode, _ = cc.String("datacollection.esb.appCode")
 		c.config.Esb.AppSecret, _ = cc.String("datacollection.esb.appSecret")
+
+		// ingestion api configs.
+		c.config.IngestTokens = parseIngestTokens()
 	}
 }
 
@@ -390,6 +401,11 @@ func (c *DataCollection) runCollectPorters() {
 	c.porterManager = collections.NewPorterManager()
 	go c.porterManager.Run()
 
+	if err := c.service.SetIngestion(c.porterManager, c.config.IngestTokens); err != nil {
+		blog.Errorf("DataCollection| setup ingestion api failed, %+v", err)
+	}
+	isIngestEnabled := len(c.config.IngestTokens) != 0
+
 	// default appid.
 	for {
 		defaultAppID, err := c.getDefaultAppID()
@@ -405,8 +421,9 @@ func (c *DataCollection) runCollectPorters() {
 	}
 	blog.Info("DataCollection| get default appid id success[%s]", c.defaultAppID)
 
-	// create and add new porters.
-	if c.snapCli != nil {
+	// create and add new porters, hostsnap and middleware porters also handle
+	// messages from ingestion api, and run without redis if ingestion is enabled.
+	if c.snapCli != nil || isIngestEnabled {
 		topic := c.snapMessageTopic(c.defaultAppID)
 		analyzer := hostsnap.NewHostSnap(c.ctx, c.redisCli, c.db, c.engine, c.authManager)
 
@@ -415,9 +432,9 @@ func (c *DataCollection) runCollectPorters() {
 		blog.Info("DataCollection| create hostsnap analyzer with target porter[%s] on topic[%s] success", snapPorterName, topic)
 	}
 
-	if c.disCli != nil {
+	if c.disCli != nil || isIngestEnabled {
 		topic := c.discoverMessageTopic(c.defaultAppID)
-		analyzer := middleware.NewDiscover(c.ctx, c.redisCli, c.engine, c.authManager)
+		analyzer := middleware.NewDiscover(c.ctx, c.redisCli, c.engine, c.authManager, c.enableTxn)
 
 		porter := collections.NewSimplePorter(middlewarePorterName, c.engine, c.hash, analyzer, c.disCli, topic, c.registry)
 		c.porterManager.AddPorter(porter)
@@ -432,6 +449,16 @@ func (c *DataCollection) runCollectPorters() {
 		c.porterManager.AddPorter(porter)
 		blog.Info("DataCollection| create netcollect analyzer with target porter[%s] on topic[%s] success", netCollectPorterName, topic)
 	}
+
+	// snmp poller collects the network devices in process, it reports like netdevicebeat but needs no netcollect redis.
+	poller, err := netcollect.NewSnmpPoller(netcollect.NewNetCollect(c.ctx, c.db, c.authManager),
+		c.engine.ServiceManageInterface)
+	if err != nil {
+		blog.Errorf("DataCollection| create netcollect snmp poller failed, %+v", err)
+	} else if poller != nil {
+		go poller.Run()
+		blog.Info("DataCollection| create netcollect snmp poller success")
+	}
 }
 
 // Run runs a new datacollection server.
@@ -480,6 +507,22 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	return nil
 }
 
+// parseIngestTokens parses collector tokens of the ingestion api, the tokens are separated by comma.
+func parseIngestTokens() []string {
+	val, err := cc.String("datacollection.ingest.tokens")
+	if err != nil {
+		return nil
+	}
+
+	tokens := make([]string, 0)
+	for _, token := range strings.Split(val, ",") {
+		if token = strings.TrimSpace(token); len(token) != 0 {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
 func (c *DataCollection) setSnapshotBizName() error {
 	tryCnt := 30
 	header := util.BuildHeader(common.CCSystemOperatorUserName, common.BKDefaultOwnerID)
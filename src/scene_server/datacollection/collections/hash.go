@@ -15,6 +15,7 @@ package collections
 import (
 	"fmt"
 	"net/url"
+	"sync"
 	"time"
 
 	"configcenter/src/apimachinery/discovery"
@@ -42,6 +43,9 @@ type Hash struct {
 
 	// nodes records datacollection nodes infos, hash value -> address.
 	nodes map[string]string
+
+	// nodesMu makes nodes infos read/write safe.
+	nodesMu sync.RWMutex
 }
 
 // NewHash creates a new hash object with local node hash value.
@@ -75,6 +79,28 @@ func (h *Hash) IsMatch(hash string) bool {
 	return true
 }
 
+// Owner returns the address of datacollection node that the data hash belongs to,
+// and a bool that marks if the node is the local node.
+func (h *Hash) Owner(hash string) (string, bool, error) {
+	nodeHashValue, err := h.consistent.Get(hash)
+	if err != nil {
+		return "", false, fmt.Errorf("can't get target node hash, %+v", err)
+	}
+
+	if h.localHashValue == nodeHashValue {
+		return "", true, nil
+	}
+
+	h.nodesMu.RLock()
+	defer h.nodesMu.RUnlock()
+
+	address, isExist := h.nodes[nodeHashValue]
+	if !isExist {
+		return "", false, fmt.Errorf("can't find address of target node %s", nodeHashValue)
+	}
+	return address, false, nil
+}
+
 // updateLoop keeps discovering datacollection instances and update local consistent.
 func (h *Hash) updateLoop() {
 	ticker := time.NewTicker(defaultUpdateInterval)
@@ -117,6 +143,7 @@ func (h *Hash) updateLoop() {
 		}
 
 		// update.
+		h.nodesMu.Lock()
 		for hashValue, svr := range newest {
 			if _, isExist := h.nodes[hashValue]; !isExist {
 				// new node, add to consistent, do not add more replicas.
@@ -136,6 +163,7 @@ func (h *Hash) updateLoop() {
 				delete(h.nodes, hashValue)
 			}
 		}
+		h.nodesMu.Unlock()
 		blog.V(4).Infof("Hash| sync consistent hash done, members %+v", h.consistent.Members())
 	}
 }
@@ -0,0 +1,322 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/redis"
+)
+
+const (
+	// defaultHistoryMaxCount is the default max count of the snapshot history records kept for a host
+	defaultHistoryMaxCount = 100
+	// minHistoryMaxCount is the minimum max count of the snapshot history records kept for a host
+	minHistoryMaxCount = 1
+	// historyBaselineTTL is the expire time of the latest recorded history fields cached in redis
+	historyBaselineTTL = 24 * time.Hour
+)
+
+// numericHistoryFields are the numeric fields whose changes are increased or decreased,
+// the changes less than the changeRangePercent are tolerated the same as needToUpdate.
+var numericHistoryFields = map[string]bool{"bk_cpu": true, "bk_cpu_mhz": true, "bk_disk": true, "bk_mem": true}
+
+// historyRecorder records the history of the host fields derived from host snapshots, a record is
+// only added when the fields are changed, and the oldest records of a host exceeding the cap are removed.
+type historyRecorder struct {
+	enabled  bool
+	maxCount int
+	db       dal.RDB
+	redisCli redis.Client
+}
+
+func newHistoryRecorder(db dal.RDB, redisCli redis.Client) *historyRecorder {
+	enabled := true
+	if cc.IsExist("datacollection.hostsnap.history.enabled") {
+		val, err := cc.Bool("datacollection.hostsnap.history.enabled")
+		if err != nil {
+			blog.Errorf("get datacollection.hostsnap.history.enabled value error, err: %v", err)
+		} else {
+			enabled = val
+		}
+	}
+
+	return &historyRecorder{
+		enabled:  enabled,
+		maxCount: getLimitConfig("datacollection.hostsnap.history.maxCount", defaultHistoryMaxCount, minHistoryMaxCount),
+		db:       db,
+		redisCli: redisCli,
+	}
+}
+
+func hostSnapHistoryKey(hostID int64) string {
+	return common.BKCacheKeyV3Prefix + "hostsnap:history:" + strconv.FormatInt(hostID, 10)
+}
+
+// record compares the host fields derived from the snapshot with the latest history record of the host,
+// and adds a history record of the host's supplier account with the changes if they are changed.
+func (r *historyRecorder) record(ctx context.Context, rid string, hostID, cloudID int64, innerIP, ownerID string,
+	setter map[string]interface{}, ips []string) error {
+
+	if !r.enabled {
+		return nil
+	}
+
+	fields := buildHistoryFields(setter, ips)
+	current, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("marshal host %d history fields failed, err: %v", hostID, err)
+	}
+
+	key := hostSnapHistoryKey(hostID)
+	cached, err := r.redisCli.Get(ctx, key).Result()
+	if err != nil && !redis.IsNilErr(err) {
+		// do not return, get the latest record from db instead.
+		blog.Errorf("get host %d history fields from redis failed, err: %v, rid: %s", hostID, err, rid)
+	}
+
+	if cached == string(current) {
+		return nil
+	}
+
+	var previous []byte
+	if len(cached) != 0 {
+		previous = []byte(cached)
+	} else {
+		latest := make([]metadata.HostSnapHistory, 0)
+		cond := map[string]interface{}{common.BKHostIDField: hostID}
+		err := r.db.Table(common.BKTableNameHostSnapHistory).Find(cond).Fields("fields").
+			Sort("-create_time").Limit(1).All(ctx, &latest)
+		if err != nil {
+			return fmt.Errorf("get host %d latest history failed, err: %v", hostID, err)
+		}
+
+		if len(latest) != 0 {
+			previous, err = json.Marshal(latest[0].Fields)
+			if err != nil {
+				return fmt.Errorf("marshal host %d latest history fields failed, err: %v", hostID, err)
+			}
+			r.setBaseline(ctx, rid, hostID, previous)
+
+			if string(previous) == string(current) {
+				return nil
+			}
+		}
+	}
+
+	changes := make([]metadata.HostSnapChange, 0)
+	if len(previous) != 0 {
+		changes, err = diffHistoryFields(previous, current, getChangeRangePercent())
+		if err != nil {
+			return fmt.Errorf("diff host %d history fields failed, err: %v", hostID, err)
+		}
+
+		// the changes are tolerated, keep the previous record as the baseline to compare with.
+		if len(changes) == 0 {
+			return nil
+		}
+	}
+
+	history := &metadata.HostSnapHistory{
+		HostID:          hostID,
+		CloudID:         cloudID,
+		InnerIP:         innerIP,
+		Fields:          fields,
+		Changes:         changes,
+		SupplierAccount: ownerID,
+		CreateTime:      time.Now(),
+	}
+	if err := r.db.Table(common.BKTableNameHostSnapHistory).Insert(ctx, history); err != nil {
+		return fmt.Errorf("insert host %d history failed, err: %v", hostID, err)
+	}
+	r.setBaseline(ctx, rid, hostID, current)
+
+	if len(changes) != 0 {
+		blog.V(4).Infof("host %d/%s snapshot changed, changes: %+v, rid: %s", hostID, innerIP, changes, rid)
+	}
+
+	return r.trim(ctx, hostID)
+}
+
+func (r *historyRecorder) setBaseline(ctx context.Context, rid string, hostID int64, fields []byte) {
+	if err := r.redisCli.Set(ctx, hostSnapHistoryKey(hostID), fields, historyBaselineTTL).Err(); err != nil {
+		blog.Errorf("set host %d history fields to redis failed, err: %v, rid: %s", hostID, err, rid)
+	}
+}
+
+// trim removes the oldest history records of the host which exceed the max count.
+func (r *historyRecorder) trim(ctx context.Context, hostID int64) error {
+	cond := map[string]interface{}{common.BKHostIDField: hostID}
+	oldest := make([]metadata.HostSnapHistory, 0)
+	err := r.db.Table(common.BKTableNameHostSnapHistory).Find(cond).Fields(common.CreateTimeField).
+		Sort("-create_time").Start(uint64(r.maxCount-1)).Limit(1).All(ctx, &oldest)
+	if err != nil {
+		return fmt.Errorf("get host %d oldest history to keep failed, err: %v", hostID, err)
+	}
+
+	if len(oldest) == 0 {
+		return nil
+	}
+
+	delCond := map[string]interface{}{
+		common.BKHostIDField:   hostID,
+		common.CreateTimeField: map[string]interface{}{common.BKDBLT: oldest[0].CreateTime},
+	}
+	if err := r.db.Table(common.BKTableNameHostSnapHistory).Delete(ctx, delCond); err != nil {
+		return fmt.Errorf("delete host %d exceeded history failed, err: %v", hostID, err)
+	}
+	return nil
+}
+
+func getChangeRangePercent() int {
+	return getLimitConfig("datacollection.hostsnap.changeRangePercent", defaultChangeRangePercent, minChangeRangePercent)
+}
+
+// buildHistoryFields returns the history fields with the host fields and the deduplicated sorted ips.
+func buildHistoryFields(setter map[string]interface{}, ips []string) map[string]interface{} {
+	fields := make(map[string]interface{}, len(setter)+1)
+	for field, value := range setter {
+		fields[field] = value
+	}
+
+	ipSet := make(map[string]struct{}, len(ips))
+	uniqueIPs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if _, exist := ipSet[ip]; exist {
+			continue
+		}
+		ipSet[ip] = struct{}{}
+		uniqueIPs = append(uniqueIPs, ip)
+	}
+	sort.Strings(uniqueIPs)
+	fields[metadata.HostSnapHistoryIPsField] = uniqueIPs
+
+	return fields
+}
+
+// diffHistoryFields returns the changes from the previous history fields to the current ones, both of
+// them are json encoded so that the values stored in db and derived from snapshots are compared alike.
+func diffHistoryFields(previous, current []byte, changeRangePercent int) ([]metadata.HostSnapChange, error) {
+	prevFields := make(map[string]interface{})
+	if err := json.Unmarshal(previous, &prevFields); err != nil {
+		return nil, err
+	}
+
+	curFields := make(map[string]interface{})
+	if err := json.Unmarshal(current, &curFields); err != nil {
+		return nil, err
+	}
+
+	allFields := make([]string, 0, len(curFields))
+	for field := range curFields {
+		allFields = append(allFields, field)
+	}
+	for field := range prevFields {
+		if _, exist := curFields[field]; !exist {
+			allFields = append(allFields, field)
+		}
+	}
+	sort.Strings(allFields)
+
+	changes := make([]metadata.HostSnapChange, 0)
+	for _, field := range allFields {
+		prev, cur := prevFields[field], curFields[field]
+
+		if field == metadata.HostSnapHistoryIPsField {
+			changes = append(changes, diffHistoryIPs(prev, cur)...)
+			continue
+		}
+
+		if numericHistoryFields[field] {
+			prevNum, prevOk := prev.(float64)
+			curNum, curOk := cur.(float64)
+			if prevOk && curOk {
+				if prevNum == curNum {
+					continue
+				}
+
+				// tolerate the changes less than the set value
+				val := prevNum * (float64(changeRangePercent) / 100.0)
+				diff := curNum - prevNum
+				if -val < diff && diff < val {
+					continue
+				}
+
+				changeType := metadata.HostSnapChangeIncreased
+				if diff < 0 {
+					changeType = metadata.HostSnapChangeDecreased
+				}
+				changes = append(changes, metadata.HostSnapChange{Field: field, Type: changeType, Previous: prev,
+					Current: cur})
+				continue
+			}
+		}
+
+		if fmt.Sprint(prev) == fmt.Sprint(cur) {
+			continue
+		}
+		changes = append(changes, metadata.HostSnapChange{Field: field, Type: metadata.HostSnapChangeModified,
+			Previous: prev, Current: cur})
+	}
+
+	return changes, nil
+}
+
+// diffHistoryIPs returns a change for each ip which is added or removed.
+func diffHistoryIPs(previous, current interface{}) []metadata.HostSnapChange {
+	prevIPs, curIPs := toStringSet(previous), toStringSet(current)
+
+	changes := make([]metadata.HostSnapChange, 0)
+	for _, ip := range sortedKeys(curIPs) {
+		if _, exist := prevIPs[ip]; !exist {
+			changes = append(changes, metadata.HostSnapChange{Field: metadata.HostSnapHistoryIPsField,
+				Type: metadata.HostSnapChangeAdded, Current: ip})
+		}
+	}
+
+	for _, ip := range sortedKeys(prevIPs) {
+		if _, exist := curIPs[ip]; !exist {
+			changes = append(changes, metadata.HostSnapChange{Field: metadata.HostSnapHistoryIPsField,
+				Type: metadata.HostSnapChangeRemoved, Previous: ip})
+		}
+	}
+	return changes
+}
+
+func toStringSet(val interface{}) map[string]struct{} {
+	items, _ := val.([]interface{})
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		set[fmt.Sprint(item)] = struct{}{}
+	}
+	return set
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
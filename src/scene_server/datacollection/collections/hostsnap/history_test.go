@@ -0,0 +1,90 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestBuildHistoryFields(t *testing.T) {
+	setter := map[string]interface{}{"bk_mem": uint64(1024), "bk_os_name": "linux centos"}
+	fields := buildHistoryFields(setter, []string{"192.168.1.2", "192.168.1.1", "192.168.1.2"})
+
+	require.Equal(t, uint64(1024), fields["bk_mem"])
+	require.Equal(t, "linux centos", fields["bk_os_name"])
+	require.Equal(t, []string{"192.168.1.1", "192.168.1.2"}, fields[metadata.HostSnapHistoryIPsField])
+}
+
+func TestHostOwnerID(t *testing.T) {
+	// the history belongs to the matched host's supplier account.
+	host := `{"bk_host_id":1,"bk_host_innerip":"192.168.1.1","bk_supplier_account":"tenant_a"}`
+	require.Equal(t, "tenant_a", hostOwnerID(gjson.Get(host, common.BKOwnerIDField)))
+
+	host = `{"bk_host_id":1,"bk_host_innerip":"192.168.1.1"}`
+	require.Equal(t, common.BKDefaultOwnerID, hostOwnerID(gjson.Get(host, common.BKOwnerIDField)))
+}
+
+func TestDiffHistoryFields(t *testing.T) {
+	previous := mustMarshal(t, buildHistoryFields(map[string]interface{}{
+		"bk_mem":        uint64(8192),
+		"bk_cpu":        int64(8),
+		"bk_disk":       uint64(100),
+		"bk_os_version": "7.2",
+	}, []string{"192.168.1.1", "192.168.1.2"}))
+
+	// unchanged
+	changes, err := diffHistoryFields(previous, previous, 10)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	current := mustMarshal(t, buildHistoryFields(map[string]interface{}{
+		"bk_mem":        uint64(4096),
+		"bk_cpu":        int64(16),
+		"bk_disk":       uint64(105),
+		"bk_os_version": "7.6",
+	}, []string{"192.168.1.1", "10.0.0.1"}))
+
+	changes, err = diffHistoryFields(previous, current, 10)
+	require.NoError(t, err)
+	require.Equal(t, []metadata.HostSnapChange{
+		{Field: "bk_cpu", Type: metadata.HostSnapChangeIncreased, Previous: float64(8), Current: float64(16)},
+		{Field: "bk_mem", Type: metadata.HostSnapChangeDecreased, Previous: float64(8192), Current: float64(4096)},
+		{Field: "bk_os_version", Type: metadata.HostSnapChangeModified, Previous: "7.2", Current: "7.6"},
+		{Field: metadata.HostSnapHistoryIPsField, Type: metadata.HostSnapChangeAdded, Current: "10.0.0.1"},
+		{Field: metadata.HostSnapHistoryIPsField, Type: metadata.HostSnapChangeRemoved, Previous: "192.168.1.2"},
+	}, changes, "the disk change is tolerated")
+}
+
+func TestDiffHistoryFieldsAddedField(t *testing.T) {
+	previous := []byte(`{"bk_os_name":"linux centos"}`)
+	current := []byte(`{"bk_os_name":"linux centos","bk_host_name":"host-1"}`)
+
+	changes, err := diffHistoryFields(previous, current, 10)
+	require.NoError(t, err)
+	require.Equal(t, []metadata.HostSnapChange{
+		{Field: "bk_host_name", Type: metadata.HostSnapChangeModified, Current: "host-1"},
+	}, changes)
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	js, err := json.Marshal(v)
+	require.NoError(t, err)
+	return js
+}
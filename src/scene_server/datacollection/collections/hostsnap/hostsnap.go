@@ -55,7 +55,7 @@ var (
 	compareFields = []string{"bk_cpu", "bk_cpu_module", "bk_cpu_mhz", "bk_disk", "bk_mem", "bk_os_type", "bk_os_name",
 		"bk_os_version", "bk_host_name", "bk_outer_mac", "bk_mac", "bk_os_bit",
 		common.HostFieldDockerClientVersion, common.HostFieldDockerServerVersion}
-	reqireFields = append(compareFields, "bk_host_id", "bk_host_innerip", "bk_host_outerip")
+	reqireFields = append(compareFields, "bk_host_id", "bk_host_innerip", "bk_host_outerip", common.BKOwnerIDField)
 
 	// notice: 为了对应不同版本和环境差异，再当前版本中设置compareFields中不参加对比的字段
 	ignoreCompareField = make(map[string]struct{}, 0)
@@ -70,6 +70,8 @@ type HostSnap struct {
 	ctx    context.Context
 	db     dal.RDB
 	window *Window
+	// history records the history of the host fields derived from host snapshots.
+	history *historyRecorder
 }
 
 func NewHostSnap(ctx context.Context, redisCli redis.Client, db dal.RDB, engine *backbone.Engine, authManager *extensions.AuthManager) *HostSnap {
@@ -83,6 +85,7 @@ func NewHostSnap(ctx context.Context, redisCli redis.Client, db dal.RDB, engine
 		Engine:      engine,
 		filter:      newFilter(),
 		window:      newWindow(),
+		history:     newHistoryRecorder(db, redisCli),
 	}
 	return h
 }
@@ -160,7 +163,8 @@ func (h *HostSnap) Analyze(msg *string) error {
 		blog.Errorf("get host detail with ips: %v failed, err: %v, rid: %s", ips, err, rid)
 		return err
 	}
-	elements := gjson.GetMany(host, common.BKHostIDField, common.BKHostInnerIPField, common.BKHostOuterIPField)
+	elements := gjson.GetMany(host, common.BKHostIDField, common.BKHostInnerIPField, common.BKHostOuterIPField,
+		common.BKOwnerIDField)
 	// check host id field
 	if !elements[0].Exists() {
 		blog.Errorf("snapshot analyze, but host id not exist, host: %s, ips: %v, rid: %s", host, ips, rid)
@@ -184,6 +188,17 @@ func (h *HostSnap) Analyze(msg *string) error {
 	// save host snapshot in redis
 	h.saveHostsnap(header, &val, hostID)
 
+	setter, raw := parseSetter(&val, innerIP, outerIP)
+
+	// record the snapshot history whether the host is updated or not, it's not restricted by the window.
+	// the history belongs to the matched host's supplier account.
+	ownerID := hostOwnerID(elements[3])
+	ctx := dal.WithTenant(h.ctx, ownerID)
+	if err := h.history.record(ctx, rid, hostID, cloudID, innerIP, ownerID, setter, ips); err != nil {
+		// do not return, the history does not affect the host update.
+		blog.Errorf("record host %d/%s snapshot history failed, err: %v, rid: %s", hostID, innerIP, err, rid)
+	}
+
 	// window restriction on request
 	if !h.window.canPassWindow() {
 		if blog.V(4) {
@@ -192,7 +207,6 @@ func (h *HostSnap) Analyze(msg *string) error {
 		}
 		return nil
 	}
-	setter, raw := parseSetter(&val, innerIP, outerIP)
 	// no need to update
 	if !needToUpdate(raw, host) {
 		return nil
@@ -493,6 +507,15 @@ func (h *HostSnap) getHostByVal(header http.Header, cloudID int64, ips []string,
 	return "", errors.New("can not find ip detail from cache")
 }
 
+// hostOwnerID returns the supplier account of the host, the hosts created before the supplier account
+// is stored belong to the default supplier account.
+func hostOwnerID(owner gjson.Result) string {
+	if owner.String() == "" {
+		return common.BKDefaultOwnerID
+	}
+	return owner.String()
+}
+
 func getIPS(val *gjson.Result) []string {
 	ipv4 := make([]string, 0)
 	ipv6 := make([]string, 0)
@@ -0,0 +1,81 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collections
+
+import (
+	"fmt"
+
+	"github.com/tidwall/gjson"
+)
+
+// Ingest handles a message pushed by the ingestion api. The message is sharded base
+// on the same hashring as messages from redis topics, if it belongs to another
+// datacollection node, Ingest returns the address of that node and leaves the message
+// to it. The message is always handled in local node when force is true, eg it has
+// been forwarded by another node already.
+func (p *SimplePorter) Ingest(message string, force bool) (string, error) {
+	// metrics stats for message receiving.
+	p.receiveTotal.Inc()
+
+	if !gjson.Valid(message) || !gjson.Parse(message).IsObject() {
+		// metrics stats for invalid message.
+		p.receiveInvalidTotal.Inc()
+		return "", fmt.Errorf("invalid message, not a json object")
+	}
+
+	// message data sharding hashring check.
+	hashKey, err := p.hashKey(message)
+	if err != nil {
+		// metrics stats for invalid message.
+		p.receiveInvalidTotal.Inc()
+		return "", err
+	}
+
+	if !force {
+		owner, isLocal, err := p.hash.Owner(hashKey)
+		if err != nil {
+			return "", err
+		}
+
+		if !isLocal {
+			// handled by the owner node.
+			return owner, nil
+		}
+	}
+
+	// metrics stats for suitable sharding message.
+	p.receiveShardingTotal.Inc()
+
+	if err := p.AddMessage(&message); err != nil {
+		// metrics stats for message sending timeout.
+		p.receiveTimeoutTotal.Inc()
+		return "", fmt.Errorf("add message to analyze failed, %+v", err)
+	}
+
+	return "", nil
+}
+
+// Ingest pushes a message to the porter with target name, see SimplePorter.Ingest.
+func (mgr *PorterManager) Ingest(name, message string, force bool) (string, error) {
+	porter, isExist := mgr.getPorter(name)
+	if !isExist {
+		return "", fmt.Errorf("unknow porter: %s", name)
+	}
+
+	ingester, ok := porter.(Ingester)
+	if !ok {
+		return "", fmt.Errorf("porter %s does not support ingestion", name)
+	}
+
+	return ingester.Ingest(message, force)
+}
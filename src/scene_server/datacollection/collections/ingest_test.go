@@ -0,0 +1,135 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package collections
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"configcenter/src/scene_server/datacollection/collections/hostsnap"
+	"configcenter/src/scene_server/datacollection/collections/middleware"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"stathat.com/c/consistent"
+)
+
+const (
+	testLocalNode  = "127.0.0.1:80"
+	testRemoteNode = "127.0.0.2:80"
+)
+
+// newTestHash creates a hash with a local node and a remote node.
+func newTestHash() *Hash {
+	h := &Hash{
+		localHashValue: testLocalNode,
+		consistent:     consistent.New(),
+		nodes: map[string]string{
+			testLocalNode:  "http://" + testLocalNode,
+			testRemoteNode: "http://" + testRemoteNode,
+		},
+	}
+	h.consistent.Add(testLocalNode)
+	h.consistent.Add(testRemoteNode)
+	return h
+}
+
+func readFixture(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestIngest(t *testing.T) {
+	fixtures := []struct {
+		name     string
+		path     string
+		analyzer Analyzer
+	}{
+		{name: "hostsnap", path: "hostsnap/snapshot.json", analyzer: &hostsnap.HostSnap{}},
+		{name: "middleware", path: "testdata/discover.json", analyzer: &middleware.Discover{}},
+	}
+
+	for _, fixture := range fixtures {
+		hash := newTestHash()
+		porter := NewSimplePorter(fixture.name, nil, hash, fixture.analyzer, nil, nil, prometheus.NewRegistry())
+		message := readFixture(t, fixture.path)
+
+		// the fixture is sharded with the same hash key as messages from redis topics.
+		hashKey, err := porter.hashKey(message)
+		require.NoError(t, err)
+		require.Equal(t, "0:127.0.0.1", hashKey)
+
+		owner, isLocal, err := hash.Owner(hashKey)
+		require.NoError(t, err)
+		require.Equal(t, hash.IsMatch(hashKey), isLocal)
+
+		addr, err := porter.Ingest(message, false)
+		require.NoError(t, err)
+		require.Equal(t, owner, addr)
+		if isLocal {
+			require.Equal(t, message, *<-porter.msgChan)
+		}
+		require.Len(t, porter.msgChan, 0)
+
+		// forwarded message is always handled in local node.
+		addr, err = porter.Ingest(message, true)
+		require.NoError(t, err)
+		require.Empty(t, addr)
+		require.Equal(t, message, *<-porter.msgChan)
+
+		// message without cloudid can't be sharded.
+		_, err = porter.Ingest(readFixture(t, "testdata/invalid.json"), false)
+		require.Error(t, err)
+
+		_, err = porter.Ingest("not json", true)
+		require.Error(t, err)
+		require.Len(t, porter.msgChan, 0)
+	}
+}
+
+func TestIngestSharding(t *testing.T) {
+	hash := newTestHash()
+	porter := NewSimplePorter("hostsnap", nil, hash, &hostsnap.HostSnap{}, nil, nil, prometheus.NewRegistry())
+
+	local, remote := 0, 0
+	for i := 0; i < 100; i++ {
+		message := fmt.Sprintf(`{"cloudid": %d, "ip": "10.0.0.%d"}`, i%3, i)
+		addr, err := porter.Ingest(message, false)
+		require.NoError(t, err)
+
+		if len(addr) == 0 {
+			local++
+			continue
+		}
+		require.Equal(t, "http://"+testRemoteNode, addr)
+		remote++
+	}
+
+	require.Equal(t, local, len(porter.msgChan))
+	require.NotZero(t, local)
+	require.NotZero(t, remote)
+}
+
+func TestPorterManagerIngest(t *testing.T) {
+	mgr := NewPorterManager()
+	mgr.porters["hostsnap"] = NewSimplePorter("hostsnap", nil, newTestHash(), &hostsnap.HostSnap{}, nil, nil,
+		prometheus.NewRegistry())
+
+	_, err := mgr.Ingest("hostsnap", readFixture(t, "hostsnap/snapshot.json"), true)
+	require.NoError(t, err)
+
+	_, err = mgr.Ingest("unknown", readFixture(t, "hostsnap/snapshot.json"), true)
+	require.Error(t, err)
+}
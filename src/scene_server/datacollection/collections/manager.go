@@ -16,6 +16,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"configcenter/src/common/blog"
@@ -44,6 +45,9 @@ type PorterManager struct {
 	// porters saves all runtime porters, porter name -> porter instance.
 	porters map[string]Porter
 
+	// portersMu makes porters read/write safe.
+	portersMu sync.RWMutex
+
 	// portersChan is used for add a new porter instance when setups the manager.
 	portersChan chan Porter
 }
@@ -69,7 +73,7 @@ func (mgr *PorterManager) runMockServer() {
 			return
 		}
 
-		if porter, ok := mgr.porters[mock.Name]; ok {
+		if porter, ok := mgr.getPorter(mock.Name); ok {
 			if err := porter.Mock(); err != nil {
 				fmt.Fprintf(resp, "mock failed, %+v", err)
 				resp.WriteHeader(http.StatusBadRequest)
@@ -91,14 +95,25 @@ func (mgr *PorterManager) runMockServer() {
 func (mgr *PorterManager) handlePorters() {
 	for porter := range mgr.portersChan {
 		// porter is Porter interface, eg SimplePorter instance point.
+		mgr.portersMu.Lock()
 		if _, isExist := mgr.porters[porter.Name()]; !isExist {
 			// new porter, add and run it.
 			mgr.porters[porter.Name()] = porter
 			go porter.Run()
 		}
+		mgr.portersMu.Unlock()
 	}
 }
 
+// getPorter returns the porter with target name.
+func (mgr *PorterManager) getPorter(name string) (Porter, bool) {
+	mgr.portersMu.RLock()
+	defer mgr.portersMu.RUnlock()
+
+	porter, isExist := mgr.porters[name]
+	return porter, isExist
+}
+
 // AddPorter adds and runs a new porter.
 func (mgr *PorterManager) AddPorter(p Porter) error {
 	select {
@@ -30,11 +30,14 @@ type Discover struct {
 	redisCli redis.Client
 	*backbone.Engine
 	authManager *extensions.AuthManager
+	// enableTxn is whether to apply the discovered data of a message in a transaction.
+	enableTxn bool
 }
 
 var msgHandlerCnt = int64(0)
 
-func NewDiscover(ctx context.Context, redisCli redis.Client, backbone *backbone.Engine, authManager *extensions.AuthManager) *Discover {
+func NewDiscover(ctx context.Context, redisCli redis.Client, backbone *backbone.Engine,
+	authManager *extensions.AuthManager, enableTxn bool) *Discover {
 	header := http.Header{}
 	header.Add(bkc.BKHTTPOwnerID, bkc.BKDefaultOwnerID)
 	header.Add(bkc.BKHTTPHeaderUser, bkc.CCSystemCollectorUserName)
@@ -44,6 +47,7 @@ func NewDiscover(ctx context.Context, redisCli redis.Client, backbone *backbone.
 		ctx:         ctx,
 		httpHeader:  header,
 		authManager: authManager,
+		enableTxn:   enableTxn,
 	}
 	discover.Engine = backbone
 	return discover
@@ -69,9 +73,13 @@ func (d *Discover) Mock() string {
 }
 
 func (d *Discover) Analyze(msg *string) error {
+	if msg == nil {
+		return fmt.Errorf("message nil")
+	}
+
 	err := d.UpdateOrCreateInst(msg)
 	if err != nil {
-		return fmt.Errorf("create inst err: %v, raw: %s", err, msg)
+		return fmt.Errorf("create inst err: %v, raw: %s", err, *msg)
 	}
 	return nil
 }
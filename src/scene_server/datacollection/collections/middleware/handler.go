@@ -15,6 +15,7 @@ package middleware
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -25,8 +26,6 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
-
-	"github.com/tidwall/gjson"
 )
 
 const (
@@ -37,28 +36,6 @@ const (
 	defaultRelateAttr = "host"
 )
 
-func (d *Discover) parseData(msg *string) (data map[string]interface{}, err error) {
-	dataStr := gjson.Get(*msg, "data.data").String()
-	if err = json.Unmarshal([]byte(dataStr), &data); err != nil {
-		blog.Errorf("parse data error: %s", err)
-		return
-	}
-	return
-}
-
-func (d *Discover) parseObjID(msg *string) string {
-	return gjson.Get(*msg, "data.meta.model.bk_obj_id").String()
-}
-
-func (d *Discover) parseOwnerId(msg *string) string {
-	ownerId := gjson.Get(*msg, "data.meta.model.bk_supplier_account").String()
-
-	if ownerId == "" {
-		ownerId = common.BKDefaultOwnerID
-	}
-	return ownerId
-}
-
 func (d *Discover) CreateInstKey(objID string, ownerID string, val []string) string {
 	return fmt.Sprintf("cc:v3:inst[%s:%s:%s:%s]",
 		common.CCSystemCollectorUserName,
@@ -136,33 +113,124 @@ func (d *Discover) GetInst(ownerID, objID string, instKey string, cond map[strin
 	return nil, nil
 }
 
+// UpdateOrCreateInst applies the instances and associations discovered in the message, they are applied in a
+// transaction, and the instance cache in redis is updated after the transaction is committed.
 func (d *Discover) UpdateOrCreateInst(msg *string) error {
 	if msg == nil {
 		return fmt.Errorf("message nil")
 	}
 
-	rid := util.GetHTTPCCRequestID(d.httpHeader)
+	payload, err := parsePayload(*msg)
+	if err != nil {
+		return fmt.Errorf("parse message failed, err: %v", err)
+	}
 
-	ownerID := d.parseOwnerId(msg)
+	applier := d.newApplier(payload.OwnerID)
+	txnErr := d.CoreAPI.CoreService().Txn().AutoRunTxn(d.ctx, d.enableTxn, applier.header, func() error {
+		for _, obj := range payload.Objects {
+			if err := applier.upsertInst(obj); err != nil {
+				return err
+			}
+		}
 
-	objID := d.parseObjID(msg)
+		for _, asst := range payload.Associations {
+			if err := applier.createAssociation(asst); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if txnErr != nil {
+		blog.Errorf("apply discovered data failed, err: %v, rid: %s", txnErr, applier.rid)
+		return txnErr
+	}
+
+	applier.flushCache()
+	return nil
+}
+
+// discoverApplier applies the discovered data of a message.
+type discoverApplier struct {
+	*Discover
+	header  http.Header
+	rid     string
+	ownerID string
+	kit     *rest.Kit
+
+	// instIDs is the ids of the instances upserted in the message, the key is the instance cache key.
+	instIDs map[string]int64
+	// uniqueKeys is the must check unique fields of the models, the key is the object id.
+	uniqueKeys map[string][]string
+
+	// cacheToSet and cacheToUnset are the instance cache changes, they are applied after the data is applied,
+	// so that the cache is not polluted by the data in the transaction, which may be aborted.
+	cacheToSet   map[string][]byte
+	cacheToUnset map[string]struct{}
+}
+
+func (d *Discover) newApplier(ownerID string) *discoverApplier {
+	// use a new header for each message, the transaction info is set in the header.
+	header := util.CloneHeader(d.httpHeader)
+	rid := util.GenerateRID()
+	header.Set(common.BKHTTPCCRequestID, rid)
+
+	return &discoverApplier{
+		Discover: d,
+		header:   header,
+		rid:      rid,
+		ownerID:  ownerID,
+		kit: &rest.Kit{
+			Rid:             rid,
+			Header:          header,
+			Ctx:             d.ctx,
+			CCError:         d.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header)),
+			User:            common.CCSystemCollectorUserName,
+			SupplierAccount: common.BKDefaultOwnerID,
+		},
+		instIDs:      make(map[string]int64),
+		uniqueKeys:   make(map[string][]string),
+		cacheToSet:   make(map[string][]byte),
+		cacheToUnset: make(map[string]struct{}),
+	}
+}
+
+func (a *discoverApplier) flushCache() {
+	for key, val := range a.cacheToSet {
+		a.TrySetRedis(key, val, cacheTime)
+	}
+
+	for key := range a.cacheToUnset {
+		a.TryUnsetRedis(key)
+	}
+}
 
-	// get must check unique to judge if the instance exists
+func (a *discoverApplier) unsetCache(instKey string) {
+	delete(a.cacheToSet, instKey)
+	a.cacheToUnset[instKey] = struct{}{}
+}
+
+// getMustCheckKeys get the fields of the must check unique of the model, which identifies the instance.
+func (a *discoverApplier) getMustCheckKeys(objID string) ([]string, error) {
+	if keys, exist := a.uniqueKeys[objID]; exist {
+		return keys, nil
+	}
+
+	rid := a.rid
 	cond := map[string]interface{}{
 		common.BKObjIDField: objID,
 		"must_check":        true,
 	}
-	uniqueResp, err := d.CoreAPI.CoreService().Model().ReadModelAttrUnique(d.ctx, d.httpHeader, metadata.QueryCondition{Condition: cond})
+	uniqueResp, err := a.CoreAPI.CoreService().Model().ReadModelAttrUnique(a.ctx, a.header, metadata.QueryCondition{Condition: cond})
 	if err != nil {
 		blog.Errorf("search model unique failed, cond: %s, error: %s, rid: %s", cond, err.Error(), rid)
-		return fmt.Errorf("search model unique failed: %s", err.Error())
+		return nil, fmt.Errorf("search model unique failed: %s", err.Error())
 	}
 	if !uniqueResp.Result {
 		blog.Errorf("search model unique failed, cond: %s, error message: %s, rid: %s", cond, uniqueResp.ErrMsg, rid)
-		return fmt.Errorf("search model unique failed: %s", uniqueResp.ErrMsg)
+		return nil, fmt.Errorf("search model unique failed: %s", uniqueResp.ErrMsg)
 	}
 	if uniqueResp.Data.Count != 1 {
-		return fmt.Errorf("model %s has wrong must check unique num", objID)
+		return nil, fmt.Errorf("model %s has wrong must check unique num", objID)
 	}
 	keyIDs := make([]int64, 0)
 	for _, key := range uniqueResp.Data.Info[0].Keys {
@@ -171,48 +239,131 @@ func (d *Discover) UpdateOrCreateInst(msg *string) error {
 	keys := make([]string, 0)
 	cond = map[string]interface{}{
 		common.BKObjIDField:   objID,
-		common.BKOwnerIDField: ownerID,
+		common.BKOwnerIDField: a.ownerID,
 		common.BKFieldID: map[string]interface{}{
 			common.BKDBIN: keyIDs,
 		},
 	}
-	attrResp, err := d.CoreAPI.CoreService().Model().ReadModelAttr(d.ctx, d.httpHeader, objID, &metadata.QueryCondition{Condition: cond})
+	attrResp, err := a.CoreAPI.CoreService().Model().ReadModelAttr(a.ctx, a.header, objID, &metadata.QueryCondition{Condition: cond})
 	if err != nil {
 		blog.Errorf("search model attribute failed, cond: %s, error: %s, rid: %s", cond, err.Error(), rid)
-		return fmt.Errorf("search model attribute failed: %s", err.Error())
+		return nil, fmt.Errorf("search model attribute failed: %s", err.Error())
 	}
 	if !attrResp.Result {
 		blog.Errorf("search model attribute failed, cond: %s, error message: %s, rid: %s", cond, attrResp.ErrMsg, rid)
-		return fmt.Errorf("search model attribute failed: %s", attrResp.ErrMsg)
+		return nil, fmt.Errorf("search model attribute failed: %s", attrResp.ErrMsg)
 	}
 	if attrResp.Data.Count <= 0 {
 		blog.Errorf("unique model attribute count illegal, cond: %s, rid: %s", cond, rid)
-		return fmt.Errorf("search model attribute failed: %s", attrResp.ErrMsg)
+		return nil, fmt.Errorf("search model attribute failed: %s", attrResp.ErrMsg)
 	}
 	for _, attr := range attrResp.Data.Info {
 		keys = append(keys, attr.PropertyID)
 	}
 
-	bodyData, err := d.parseData(msg)
+	a.uniqueKeys[objID] = keys
+	return keys, nil
+}
+
+// parseUnique returns the instance cache key and the condition to find the instance with its unique values.
+func (a *discoverApplier) parseUnique(objID string, data map[string]interface{}) (string, map[string]interface{}, error) {
+	keys, err := a.getMustCheckKeys(objID)
 	if err != nil {
-		return fmt.Errorf("parse data error: %s", err)
+		return "", nil, err
 	}
 
-	cond = map[string]interface{}{
-		common.BKObjIDField:   objID,
-		common.BKOwnerIDField: ownerID,
+	cond := map[string]interface{}{}
+	if !common.IsInnerModel(objID) {
+		cond[common.BKObjIDField] = objID
+		cond[common.BKOwnerIDField] = a.ownerID
 	}
+
 	valArr := make([]string, 0)
 	for _, key := range keys {
-		val := util.GetStrByInterface(bodyData[key])
+		val := util.GetStrByInterface(data[key])
 		if val == "" {
-			return fmt.Errorf("skip inst because of empty unique key %s value", key)
+			return "", nil, fmt.Errorf("skip inst because of empty unique key %s value", key)
 		}
 		valArr = append(valArr, val)
-		cond[key] = bodyData[key]
+		cond[key] = data[key]
+	}
+
+	return a.CreateInstKey(objID, a.ownerID, valArr), cond, nil
+}
+
+// getInst get the instance from the redis cache, or from db if it's not cached, the instance got from db is
+// cached after the data is applied.
+func (a *discoverApplier) getInst(objID string, instKey string, cond map[string]interface{}) (map[string]interface{}, error) {
+	// the instance changed in the message is not read from cache, since the cache is stale.
+	if _, unset := a.cacheToUnset[instKey]; !unset {
+		instData, err := a.GetInstFromRedis(instKey)
+		if err == nil {
+			blog.V(4).Infof("inst exist in redis: %s, rid: %s", instKey, a.rid)
+			return instData, nil
+		}
+	}
+
+	resp, err := a.CoreAPI.CoreService().Instance().ReadInstance(a.ctx, a.header, objID, &metadata.QueryCondition{Condition: cond})
+	if err != nil {
+		blog.Errorf("search inst failed, cond: %s, error: %s, rid: %s", cond, err.Error(), a.rid)
+		return nil, fmt.Errorf("search inst failed: %s", err.Error())
+	}
+	if !resp.Result {
+		blog.Errorf("search inst failed, cond: %s, error message: %s, rid: %s", cond, resp.ErrMsg, a.rid)
+		return nil, fmt.Errorf("search inst failed: %s", resp.ErrMsg)
+	}
+
+	if len(resp.Data.Info) > 0 {
+		val, err := json.Marshal(resp.Data.Info[0])
+		if err != nil {
+			blog.Errorf("%s: flush to redis marshal failed: %s, rid: %s", instKey, err, a.rid)
+		} else if _, unset := a.cacheToUnset[instKey]; !unset {
+			a.cacheToSet[instKey] = val
+		}
+		return resp.Data.Info[0], nil
+	}
+
+	return nil, nil
+}
+
+// getInstID get the id of the instance identified by the unique values, the instance may be upserted in the message.
+func (a *discoverApplier) getInstID(end discoverEndpoint) (int64, error) {
+	instKey, cond, err := a.parseUnique(end.ObjectID, end.Unique)
+	if err != nil {
+		return 0, err
 	}
-	instKeyStr := d.CreateInstKey(objID, ownerID, valArr)
-	inst, err := d.GetInst(ownerID, objID, instKeyStr, cond)
+
+	if instID, exist := a.instIDs[instKey]; exist {
+		return instID, nil
+	}
+
+	inst, err := a.getInst(end.ObjectID, instKey, cond)
+	if err != nil {
+		return 0, fmt.Errorf("get inst error: %s", err)
+	}
+	if len(inst) == 0 {
+		return 0, fmt.Errorf("inst %s not found", instKey)
+	}
+
+	instIDField := common.GetInstIDField(end.ObjectID)
+	instID, err := util.GetInt64ByInterface(inst[instIDField])
+	if err != nil {
+		return 0, fmt.Errorf("get %s failed: %v %s", instIDField, inst[instIDField], err.Error())
+	}
+	return instID, nil
+}
+
+// upsertInst create the instance, or update it if the instance identified by the must check unique exists.
+func (a *discoverApplier) upsertInst(obj discoverObject) error {
+	rid := a.rid
+	objID := obj.ObjectID
+	bodyData := obj.Data
+
+	instKeyStr, cond, err := a.parseUnique(objID, bodyData)
+	if err != nil {
+		return err
+	}
+	inst, err := a.getInst(objID, instKeyStr, cond)
 	if nil != err {
 		return fmt.Errorf("get inst error: %s", err)
 	}
@@ -222,7 +373,7 @@ func (d *Discover) UpdateOrCreateInst(msg *string) error {
 	instIDField := common.GetInstIDField(objID)
 
 	if len(inst) <= 0 {
-		resp, err := d.CoreAPI.CoreService().Instance().CreateInstance(d.ctx, d.httpHeader, objID, &metadata.CreateModelInstance{Data: bodyData})
+		resp, err := a.CoreAPI.CoreService().Instance().CreateInstance(a.ctx, a.header, objID, &metadata.CreateModelInstance{Data: bodyData})
 		if err != nil {
 			blog.Errorf("search model failed %s", err.Error())
 			return fmt.Errorf("search model failed: %s", err.Error())
@@ -231,20 +382,15 @@ func (d *Discover) UpdateOrCreateInst(msg *string) error {
 			blog.Errorf("search model failed %s", resp.ErrMsg)
 			return fmt.Errorf("search model failed: %s", resp.ErrMsg)
 		}
-		blog.Infof("create inst result: %v", resp)
+		blog.Infof("create inst result: %v, rid: %s", resp, rid)
+		a.instIDs[instKeyStr] = int64(resp.Data.Created.ID)
+		a.unsetCache(instKeyStr)
 
 		// add audit log.
 		if err := func() error {
 			// ready audit interface of instance.
-			audit := auditlog.NewInstanceAudit(d.CoreAPI.CoreService())
-			kit := &rest.Kit{
-				Rid:             rid,
-				Header:          d.httpHeader,
-				Ctx:             d.ctx,
-				CCError:         d.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(d.httpHeader)),
-				User:            common.CCSystemCollectorUserName,
-				SupplierAccount: common.BKDefaultOwnerID,
-			}
+			audit := auditlog.NewInstanceAudit(a.CoreAPI.CoreService())
+			kit := a.kit
 
 			// generate audit log for create instance.
 			data := []mapstr.MapStr{mapstr.NewFromMap(bodyData)}
@@ -274,6 +420,7 @@ func (d *Discover) UpdateOrCreateInst(msg *string) error {
 	if nil != err {
 		return fmt.Errorf("get bk_inst_id failed: %s %s", inst[instIDField], err.Error())
 	}
+	a.instIDs[instKeyStr] = instID
 
 	dataChange := map[string]interface{}{}
 	hasDiff := false
@@ -322,15 +469,8 @@ func (d *Discover) UpdateOrCreateInst(msg *string) error {
 	delete(inst, common.CreateTimeField)
 
 	// ready audit interface of instance.
-	audit := auditlog.NewInstanceAudit(d.CoreAPI.CoreService())
-	kit := &rest.Kit{
-		Rid:             rid,
-		Header:          d.httpHeader,
-		Ctx:             d.ctx,
-		CCError:         d.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(d.httpHeader)),
-		User:            common.CCSystemCollectorUserName,
-		SupplierAccount: common.BKDefaultOwnerID,
-	}
+	audit := auditlog.NewInstanceAudit(a.CoreAPI.CoreService())
+	kit := a.kit
 
 	// generate audit log before update instance.
 	auditCond := map[string]interface{}{instIDField: instID}
@@ -351,7 +491,7 @@ func (d *Discover) UpdateOrCreateInst(msg *string) error {
 		},
 		CanEditAll: true,
 	}
-	resp, err := d.CoreAPI.CoreService().Instance().UpdateInstance(d.ctx, d.httpHeader, objID, &input)
+	resp, err := a.CoreAPI.CoreService().Instance().UpdateInstance(a.ctx, a.header, objID, &input)
 	if err != nil {
 		blog.Errorf("search model failed %s", err.Error())
 		return fmt.Errorf("search model failed: %s", err.Error())
@@ -361,7 +501,7 @@ func (d *Discover) UpdateOrCreateInst(msg *string) error {
 		return fmt.Errorf("search model failed: %s", resp.ErrMsg)
 	}
 	blog.Infof("update inst result: %v", resp)
-	d.TryUnsetRedis(instKeyStr)
+	a.unsetCache(instKeyStr)
 
 	// save audit log.
 	if err := audit.SaveAuditLog(kit, auditLog...); err != nil {
@@ -372,3 +512,134 @@ func (d *Discover) UpdateOrCreateInst(msg *string) error {
 
 	return nil
 }
+
+// createAssociation create the association between the instances if it's not exist, the association mapping
+// is checked the same as the association created by the topo server.
+func (a *discoverApplier) createAssociation(asst discoverAssociation) error {
+	rid := a.rid
+	cond := map[string]interface{}{common.AssociationObjAsstIDField: asst.ObjectAsstID}
+	modelAsstResp, err := a.CoreAPI.CoreService().Association().ReadModelAssociation(a.ctx, a.header,
+		&metadata.QueryCondition{Condition: cond})
+	if err != nil {
+		blog.Errorf("search model association failed, cond: %v, err: %v, rid: %s", cond, err, rid)
+		return fmt.Errorf("search model association failed: %v", err)
+	}
+	if !modelAsstResp.Result {
+		blog.Errorf("search model association failed, cond: %v, err: %s, rid: %s", cond, modelAsstResp.ErrMsg, rid)
+		return fmt.Errorf("search model association failed: %s", modelAsstResp.ErrMsg)
+	}
+	if len(modelAsstResp.Data.Info) == 0 {
+		return fmt.Errorf("model association %s not exist", asst.ObjectAsstID)
+	}
+
+	modelAsst := modelAsstResp.Data.Info[0]
+	if modelAsst.ObjectID != asst.Src.ObjectID || modelAsst.AsstObjID != asst.Dst.ObjectID {
+		return fmt.Errorf("model association %s is between %s and %s, not %s and %s", asst.ObjectAsstID,
+			modelAsst.ObjectID, modelAsst.AsstObjID, asst.Src.ObjectID, asst.Dst.ObjectID)
+	}
+
+	instID, err := a.getInstID(asst.Src)
+	if err != nil {
+		return fmt.Errorf("get association %s src inst failed, err: %v", asst.ObjectAsstID, err)
+	}
+
+	asstInstID, err := a.getInstID(asst.Dst)
+	if err != nil {
+		return fmt.Errorf("get association %s dst inst failed, err: %v", asst.ObjectAsstID, err)
+	}
+
+	// the association is reported repeatedly by the collectors, skip it if it already exists.
+	exist, err := a.isInstAsstExist(map[string]interface{}{
+		common.AssociationObjAsstIDField: asst.ObjectAsstID,
+		common.BKInstIDField:             instID,
+		common.BKAsstInstIDField:         asstInstID,
+	})
+	if err != nil {
+		return err
+	}
+	if exist {
+		return nil
+	}
+
+	switch modelAsst.Mapping {
+	case metadata.OneToOneMapping:
+		exist, err := a.isInstAsstExist(map[string]interface{}{
+			common.AssociationObjAsstIDField: asst.ObjectAsstID,
+			common.BKDBOR: []map[string]interface{}{
+				{common.BKInstIDField: instID},
+				{common.BKAsstInstIDField: asstInstID},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if exist {
+			return fmt.Errorf("association %s is one to one, inst %d or %d already has association",
+				asst.ObjectAsstID, instID, asstInstID)
+		}
+	case metadata.OneToManyMapping:
+		exist, err := a.isInstAsstExist(map[string]interface{}{
+			common.AssociationObjAsstIDField: asst.ObjectAsstID,
+			common.BKAsstInstIDField:         asstInstID,
+		})
+		if err != nil {
+			return err
+		}
+		if exist {
+			return fmt.Errorf("association %s is one to many, inst %d already has association",
+				asst.ObjectAsstID, asstInstID)
+		}
+	}
+
+	input := &metadata.CreateOneInstanceAssociation{
+		Data: metadata.InstAsst{
+			ObjectAsstID:      asst.ObjectAsstID,
+			InstID:            instID,
+			AsstInstID:        asstInstID,
+			ObjectID:          modelAsst.ObjectID,
+			AsstObjectID:      modelAsst.AsstObjID,
+			AssociationKindID: modelAsst.AsstKindID,
+		},
+	}
+	createResp, err := a.CoreAPI.CoreService().Association().CreateInstAssociation(a.ctx, a.header, input)
+	if err != nil {
+		blog.Errorf("create inst association failed, data: %+v, err: %v, rid: %s", input.Data, err, rid)
+		return fmt.Errorf("create inst association failed: %v", err)
+	}
+	if !createResp.Result {
+		blog.Errorf("create inst association failed, data: %+v, err: %s, rid: %s", input.Data, createResp.ErrMsg, rid)
+		return fmt.Errorf("create inst association failed: %s", createResp.ErrMsg)
+	}
+	blog.Infof("create inst association %s, %d -> %d success, rid: %s", asst.ObjectAsstID, instID, asstInstID, rid)
+
+	// save audit log.
+	audit := auditlog.NewInstanceAssociationAudit(a.CoreAPI.CoreService())
+	generateAuditParameter := auditlog.NewGenerateAuditCommonParameter(a.kit, metadata.AuditCreate).
+		WithOperateFrom(metadata.FromDataCollection)
+	auditLog, err := audit.GenerateAuditLog(generateAuditParameter, int64(createResp.Data.Created.ID), &input.Data)
+	if err != nil {
+		blog.Errorf("generate inst association audit log failed, data: %+v, err: %v, rid: %s", input.Data, err, rid)
+		return err
+	}
+
+	if err := audit.SaveAuditLog(a.kit, *auditLog); err != nil {
+		blog.Errorf("save inst association audit log failed, data: %+v, err: %v, rid: %s", input.Data, err, rid)
+		return err
+	}
+
+	return nil
+}
+
+func (a *discoverApplier) isInstAsstExist(cond map[string]interface{}) (bool, error) {
+	resp, err := a.CoreAPI.CoreService().Association().ReadInstAssociation(a.ctx, a.header,
+		&metadata.QueryCondition{Condition: cond, Page: metadata.BasePage{Limit: 1}})
+	if err != nil {
+		blog.Errorf("search inst association failed, cond: %v, err: %v, rid: %s", cond, err, a.rid)
+		return false, fmt.Errorf("search inst association failed: %v", err)
+	}
+	if !resp.Result {
+		blog.Errorf("search inst association failed, cond: %v, err: %s, rid: %s", cond, resp.ErrMsg, a.rid)
+		return false, fmt.Errorf("search inst association failed: %s", resp.ErrMsg)
+	}
+	return len(resp.Data.Info) != 0, nil
+}
@@ -0,0 +1,167 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"configcenter/src/common"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// maxPayloadObjects is the max count of the objects discovered in a message.
+	maxPayloadObjects = 100
+	// maxPayloadAssociations is the max count of the associations discovered in a message.
+	maxPayloadAssociations = 100
+)
+
+// discoverPayload is the instances and the associations between them discovered in a message,
+// they are applied atomically, that is, none of them is applied if any of them fails.
+type discoverPayload struct {
+	OwnerID      string
+	Objects      []discoverObject
+	Associations []discoverAssociation
+}
+
+// discoverObject is an instance to be created or updated, it's identified by the must check unique of the model.
+type discoverObject struct {
+	ObjectID string                 `json:"bk_obj_id"`
+	Data     map[string]interface{} `json:"data"`
+}
+
+// discoverAssociation is an association to be created between two instances, both of the instances are
+// identified by the values of the must check unique fields of their models.
+type discoverAssociation struct {
+	ObjectAsstID string           `json:"bk_obj_asst_id"`
+	Src          discoverEndpoint `json:"src"`
+	Dst          discoverEndpoint `json:"dst"`
+}
+
+type discoverEndpoint struct {
+	ObjectID string                 `json:"bk_obj_id"`
+	Unique   map[string]interface{} `json:"unique"`
+}
+
+// parsePayload parses the discover message, the message carries multiple objects in data.objects, or
+// a single object with its model in data.meta.model and its data in data.data for compatibility, the
+// associations are declared in data.associations, and can be used with both of them.
+func parsePayload(msg string) (*discoverPayload, error) {
+	if !gjson.Valid(msg) {
+		return nil, errors.New("invalid message format, not a json")
+	}
+
+	payload := &discoverPayload{
+		OwnerID: gjson.Get(msg, "data.meta.model.bk_supplier_account").String(),
+	}
+	if payload.OwnerID == "" {
+		payload.OwnerID = common.BKDefaultOwnerID
+	}
+
+	if objects := gjson.Get(msg, "data.objects"); objects.Exists() {
+		if err := json.Unmarshal([]byte(objects.Raw), &payload.Objects); err != nil {
+			return nil, fmt.Errorf("parse objects failed, err: %v", err)
+		}
+	} else if objID := gjson.Get(msg, "data.meta.model.bk_obj_id").String(); objID != "" {
+		data, err := parseObjectData(gjson.Get(msg, "data.data"))
+		if err != nil {
+			return nil, fmt.Errorf("parse data failed, err: %v", err)
+		}
+		payload.Objects = []discoverObject{{ObjectID: objID, Data: data}}
+	}
+
+	if assts := gjson.Get(msg, "data.associations"); assts.Exists() {
+		if err := json.Unmarshal([]byte(assts.Raw), &payload.Associations); err != nil {
+			return nil, fmt.Errorf("parse associations failed, err: %v", err)
+		}
+	}
+
+	if err := payload.validate(); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// parseObjectData parses the instance data, which is a json object or a string of json object.
+func parseObjectData(val gjson.Result) (map[string]interface{}, error) {
+	raw := val.Raw
+	if val.Type == gjson.String {
+		raw = val.String()
+	}
+
+	data := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (p *discoverPayload) validate() error {
+	if len(p.Objects) == 0 && len(p.Associations) == 0 {
+		return errors.New("no object or association in message")
+	}
+
+	if len(p.Objects) > maxPayloadObjects {
+		return fmt.Errorf("objects count %d exceeds the max count %d", len(p.Objects), maxPayloadObjects)
+	}
+
+	if len(p.Associations) > maxPayloadAssociations {
+		return fmt.Errorf("associations count %d exceeds the max count %d", len(p.Associations),
+			maxPayloadAssociations)
+	}
+
+	for idx, obj := range p.Objects {
+		if obj.ObjectID == "" {
+			return fmt.Errorf("objects[%d] bk_obj_id is empty", idx)
+		}
+
+		// the inner models like host are maintained by their own services, they can only be associated.
+		if common.IsInnerModel(obj.ObjectID) {
+			return fmt.Errorf("objects[%d] inner model %s can not be discovered", idx, obj.ObjectID)
+		}
+
+		if len(obj.Data) == 0 {
+			return fmt.Errorf("objects[%d] data is empty", idx)
+		}
+	}
+
+	for idx, asst := range p.Associations {
+		if asst.ObjectAsstID == "" {
+			return fmt.Errorf("associations[%d] bk_obj_asst_id is empty", idx)
+		}
+
+		if err := asst.Src.validate(); err != nil {
+			return fmt.Errorf("associations[%d] src %v", idx, err)
+		}
+
+		if err := asst.Dst.validate(); err != nil {
+			return fmt.Errorf("associations[%d] dst %v", idx, err)
+		}
+	}
+
+	return nil
+}
+
+func (e *discoverEndpoint) validate() error {
+	if e.ObjectID == "" {
+		return errors.New("bk_obj_id is empty")
+	}
+
+	if len(e.Unique) == 0 {
+		return errors.New("unique is empty")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package middleware
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"configcenter/src/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePayloadLegacy(t *testing.T) {
+	msg := `{"data":{"meta":{"model":{"bk_obj_id":"bk_apache"}},"data":"{\"bk_inst_name\":\"apache\"}"}}`
+	payload, err := parsePayload(msg)
+	require.NoError(t, err)
+	require.Equal(t, common.BKDefaultOwnerID, payload.OwnerID)
+	require.Equal(t, []discoverObject{{ObjectID: "bk_apache", Data: map[string]interface{}{"bk_inst_name": "apache"}}},
+		payload.Objects)
+	require.Empty(t, payload.Associations)
+
+	// the data can be a json object too.
+	msg = `{"data":{"meta":{"model":{"bk_obj_id":"bk_apache"}},"data":{"bk_inst_name":"apache"}}}`
+	payload, err = parsePayload(msg)
+	require.NoError(t, err)
+	require.Equal(t, "apache", payload.Objects[0].Data["bk_inst_name"])
+}
+
+func TestParsePayloadMultiObjects(t *testing.T) {
+	msg, err := ioutil.ReadFile("testdata/multi_objects.json")
+	require.NoError(t, err)
+
+	payload, err := parsePayload(string(msg))
+	require.NoError(t, err)
+	require.Len(t, payload.Objects, 1)
+	require.Equal(t, "bk_mysql", payload.Objects[0].ObjectID)
+	require.Equal(t, []discoverAssociation{{
+		ObjectAsstID: "bk_mysql_run_host",
+		Src: discoverEndpoint{ObjectID: "bk_mysql",
+			Unique: map[string]interface{}{"bk_ip": "192.168.0.1", "bk_port": "3306"}},
+		Dst: discoverEndpoint{ObjectID: common.BKInnerObjIDHost,
+			Unique: map[string]interface{}{"bk_host_innerip": "192.168.0.1", "bk_cloud_id": float64(0)}},
+	}}, payload.Associations)
+}
+
+func TestParsePayloadInvalid(t *testing.T) {
+	cases := map[string]string{
+		"not json":        `{"data":`,
+		"empty":           `{"data":{"meta":{"model":{}}}}`,
+		"no object id":    `{"data":{"objects":[{"data":{"bk_inst_name":"apache"}}]}}`,
+		"inner model":     `{"data":{"objects":[{"bk_obj_id":"host","data":{"bk_host_innerip":"127.0.0.1"}}]}}`,
+		"no object data":  `{"data":{"objects":[{"bk_obj_id":"bk_apache"}]}}`,
+		"no obj asst id":  `{"data":{"associations":[{"src":{"bk_obj_id":"a","unique":{"k":1}},"dst":{"bk_obj_id":"b","unique":{"k":1}}}]}}`,
+		"no dst unique":   `{"data":{"associations":[{"bk_obj_asst_id":"a_run_b","src":{"bk_obj_id":"a","unique":{"k":1}},"dst":{"bk_obj_id":"b"}}]}}`,
+		"invalid objects": `{"data":{"objects":{"bk_obj_id":"bk_apache"}}}`,
+		"invalid data":    `{"data":{"meta":{"model":{"bk_obj_id":"bk_apache"}},"data":"apache"}}`,
+	}
+
+	for name, msg := range cases {
+		_, err := parsePayload(msg)
+		require.Error(t, err, name)
+	}
+}
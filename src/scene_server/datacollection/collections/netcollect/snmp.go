@@ -0,0 +1,286 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	defaultSnmpPort          = 161
+	defaultSnmpCommunity     = "public"
+	defaultSnmpInterval      = 300
+	minSnmpInterval          = 60
+	defaultSnmpTimeout       = 5
+	defaultSnmpRetries       = 1
+	snmpConfigPrefix         = "datacollection.netcollect.snmp."
+	snmpVersion2c            = "v2c"
+	snmpVersion3             = "v3"
+	snmpAuthProtocolMD5      = "MD5"
+	snmpAuthProtocolSHA      = "SHA"
+	snmpPrivProtocolDES      = "DES"
+	snmpPrivProtocolAES      = "AES"
+	snmpMaxConcurrentTargets = 10
+)
+
+// snmpTarget is a network device polled by the snmp poller.
+type snmpTarget struct {
+	host string
+	port uint16
+}
+
+// snmpConfig is the config of the in-process snmp poller.
+type snmpConfig struct {
+	targets   []snmpTarget
+	cloudID   int64
+	version   gosnmp.SnmpVersion
+	community string
+	msgFlags  gosnmp.SnmpV3MsgFlags
+	usm       *gosnmp.UsmSecurityParameters
+	interval  time.Duration
+	timeout   time.Duration
+	retries   int
+}
+
+// parseSnmpConfig parses the snmp poller config, returns nil if the poller is not enabled.
+func parseSnmpConfig() (*snmpConfig, error) {
+	if !cc.IsExist(snmpConfigPrefix + "enabled") {
+		return nil, nil
+	}
+	enabled, err := cc.Bool(snmpConfigPrefix + "enabled")
+	if err != nil {
+		return nil, fmt.Errorf("get %senabled value failed, err: %v", snmpConfigPrefix, err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	conf := &snmpConfig{
+		community: getSnmpString("community", defaultSnmpCommunity),
+		interval:  time.Duration(getSnmpInt("intervalSeconds", defaultSnmpInterval, minSnmpInterval)) * time.Second,
+		timeout:   time.Duration(getSnmpInt("timeoutSeconds", defaultSnmpTimeout, 1)) * time.Second,
+		retries:   getSnmpInt("retries", defaultSnmpRetries, 0),
+		cloudID:   int64(getSnmpInt("cloudID", 0, 0)),
+	}
+
+	conf.targets, err = parseSnmpTargets(getSnmpString("targets", ""))
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.targets) == 0 {
+		return nil, fmt.Errorf("%stargets is not configured", snmpConfigPrefix)
+	}
+
+	switch version := getSnmpString("version", snmpVersion2c); version {
+	case snmpVersion2c:
+		conf.version = gosnmp.Version2c
+	case snmpVersion3:
+		conf.version = gosnmp.Version3
+		conf.msgFlags, conf.usm, err = parseSnmpV3Security(getSnmpString("v3.username", ""),
+			getSnmpString("v3.authProtocol", snmpAuthProtocolSHA), getSnmpString("v3.authPassword", ""),
+			getSnmpString("v3.privProtocol", snmpPrivProtocolAES), getSnmpString("v3.privPassword", ""))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%sversion %s is invalid, only v2c and v3 are supported", snmpConfigPrefix, version)
+	}
+
+	return conf, nil
+}
+
+func getSnmpString(key, defaultVal string) string {
+	if !cc.IsExist(snmpConfigPrefix + key) {
+		return defaultVal
+	}
+	val, err := cc.String(snmpConfigPrefix + key)
+	if err != nil {
+		blog.Errorf("get %s%s value failed, use default value %s, err: %v", snmpConfigPrefix, key, defaultVal, err)
+		return defaultVal
+	}
+	if val = strings.TrimSpace(val); val == "" {
+		return defaultVal
+	}
+	return val
+}
+
+func getSnmpInt(key string, defaultVal, minVal int) int {
+	if !cc.IsExist(snmpConfigPrefix + key) {
+		return defaultVal
+	}
+	val, err := cc.Int(snmpConfigPrefix + key)
+	if err != nil {
+		blog.Errorf("get %s%s value failed, use default value %d, err: %v", snmpConfigPrefix, key, defaultVal, err)
+		return defaultVal
+	}
+	if val < minVal {
+		blog.Errorf("%s%s value %d is less than %d, use %d instead", snmpConfigPrefix, key, val, minVal, minVal)
+		return minVal
+	}
+	return val
+}
+
+// parseSnmpTargets parses the targets separated by comma, each target is an ipv4 address with an optional port.
+func parseSnmpTargets(val string) ([]snmpTarget, error) {
+	targets := make([]snmpTarget, 0)
+	exists := make(map[snmpTarget]bool)
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		target := snmpTarget{host: item, port: defaultSnmpPort}
+		if host, port, err := net.SplitHostPort(item); err == nil {
+			portVal, err := strconv.ParseUint(port, 10, 16)
+			if err != nil || portVal == 0 {
+				return nil, fmt.Errorf("snmp target %s port is invalid", item)
+			}
+			target = snmpTarget{host: host, port: uint16(portVal)}
+		}
+
+		if ip := net.ParseIP(target.host); ip == nil || ip.To4() == nil {
+			return nil, fmt.Errorf("snmp target %s is not a valid ipv4 address", item)
+		}
+
+		if !exists[target] {
+			exists[target] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// parseSnmpV3Security parses the user based security model parameters, the security level is determined by
+// whether the passwords are configured.
+func parseSnmpV3Security(user, authProtocol, authPassword, privProtocol, privPassword string) (
+	gosnmp.SnmpV3MsgFlags, *gosnmp.UsmSecurityParameters, error) {
+
+	if user == "" {
+		return 0, nil, fmt.Errorf("%sv3.username is not configured", snmpConfigPrefix)
+	}
+
+	usm := &gosnmp.UsmSecurityParameters{
+		UserName:               user,
+		AuthenticationProtocol: gosnmp.NoAuth,
+		PrivacyProtocol:        gosnmp.NoPriv,
+	}
+	if authPassword == "" {
+		if privPassword != "" {
+			return 0, nil, fmt.Errorf("%sv3.privPassword is configured without authPassword", snmpConfigPrefix)
+		}
+		return gosnmp.NoAuthNoPriv, usm, nil
+	}
+
+	switch strings.ToUpper(authProtocol) {
+	case snmpAuthProtocolMD5:
+		usm.AuthenticationProtocol = gosnmp.MD5
+	case snmpAuthProtocolSHA:
+		usm.AuthenticationProtocol = gosnmp.SHA
+	default:
+		return 0, nil, fmt.Errorf("%sv3.authProtocol %s is invalid", snmpConfigPrefix, authProtocol)
+	}
+	usm.AuthenticationPassphrase = authPassword
+	if privPassword == "" {
+		return gosnmp.AuthNoPriv, usm, nil
+	}
+
+	switch strings.ToUpper(privProtocol) {
+	case snmpPrivProtocolDES:
+		usm.PrivacyProtocol = gosnmp.DES
+	case snmpPrivProtocolAES:
+		usm.PrivacyProtocol = gosnmp.AES
+	default:
+		return 0, nil, fmt.Errorf("%sv3.privProtocol %s is invalid", snmpConfigPrefix, privProtocol)
+	}
+	usm.PrivacyPassphrase = privPassword
+	return gosnmp.AuthPriv, usm, nil
+}
+
+// newClient returns a snmp client of the target, the client should be connected before use.
+func (c *snmpConfig) newClient(ctx context.Context, target snmpTarget) *gosnmp.GoSNMP {
+	client := &gosnmp.GoSNMP{
+		Target:    target.host,
+		Port:      target.port,
+		Transport: "udp",
+		Community: c.community,
+		Version:   c.version,
+		Context:   ctx,
+		Timeout:   c.timeout,
+		Retries:   c.retries,
+		MaxOids:   gosnmp.MaxOids,
+	}
+
+	if c.version == gosnmp.Version3 {
+		client.SecurityModel = gosnmp.UserSecurityModel
+		client.MsgFlags = c.msgFlags
+		client.SecurityParameters = c.usm.Copy()
+	}
+	return client
+}
+
+// snmpValue converts the value of a variable to the value of a property, returns false if the variable has no value.
+func snmpValue(pdu gosnmp.SnmpPDU) (interface{}, bool) {
+	switch pdu.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		return nil, false
+	case gosnmp.OctetString:
+		val, ok := pdu.Value.([]byte)
+		if !ok {
+			return nil, false
+		}
+		return octetString(val), true
+	case gosnmp.ObjectIdentifier, gosnmp.IPAddress:
+		val, ok := pdu.Value.(string)
+		if !ok {
+			return nil, false
+		}
+		return val, true
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64, gosnmp.Uinteger32:
+		return gosnmp.ToBigInt(pdu.Value).Int64(), true
+	default:
+		return nil, false
+	}
+}
+
+// octetString converts the octet string to a text if it's printable, otherwise the hex format like a mac address.
+func octetString(val []byte) string {
+	text := strings.TrimRight(string(val), "\x00")
+	if utf8.ValidString(text) && strings.IndexFunc(text, func(r rune) bool {
+		return !unicode.IsPrint(r) && !unicode.IsSpace(r)
+	}) == -1 {
+		return strings.TrimSpace(text)
+	}
+
+	hex := make([]string, len(val))
+	for idx, b := range val {
+		hex[idx] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(hex, ":")
+}
+
+// normalizeOID returns the oid without the leading dot, so that the oids from the config and the responses can be compared.
+func normalizeOID(oid string) string {
+	return strings.TrimPrefix(strings.TrimSpace(oid), ".")
+}
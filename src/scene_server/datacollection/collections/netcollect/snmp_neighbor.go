@@ -0,0 +1,210 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	// lldpRemEntryOID is the entry of LLDP-MIB lldpRemTable, indexed by timeMark.localPortNum.remIndex
+	lldpRemEntryOID = "1.0.8802.1.1.2.1.4.1.1"
+	// lldpRemManAddrEntryOID is the entry of LLDP-MIB lldpRemManAddrTable, indexed by
+	// timeMark.localPortNum.remIndex.addrSubtype.addrLen.addr
+	lldpRemManAddrEntryOID = "1.0.8802.1.1.2.1.4.2.1"
+	// cdpCacheEntryOID is the entry of CISCO-CDP-MIB cdpCacheTable, indexed by ifIndex.deviceIndex
+	cdpCacheEntryOID = "1.3.6.1.4.1.9.9.23.1.2.1.1"
+
+	lldpRemChassisIDSubtype = "4"
+	lldpRemChassisID        = "5"
+	lldpRemPortID           = "7"
+	lldpRemSysName          = "9"
+	cdpCacheAddressType     = "3"
+	cdpCacheAddress         = "4"
+	cdpCacheDeviceID        = "6"
+	cdpCacheDevicePort      = "7"
+
+	// lldpChassisIDNetworkAddress is the chassis id subtype whose value is an iana address family and an address
+	lldpChassisIDNetworkAddress = 5
+	ianaAddressFamilyIPv4       = 1
+	cdpAddressTypeIP            = 1
+
+	neighborProtocolLLDP = "lldp"
+	neighborProtocolCDP  = "cdp"
+)
+
+// neighbor is a remote device discovered by the lldp or cdp neighbor table of a network device.
+type neighbor struct {
+	protocol string
+	// name is the remote system name of lldp or the device id of cdp.
+	name string
+	// address is the ipv4 management address of the remote device.
+	address string
+	// port is the remote port connected to the local device.
+	port string
+}
+
+// neighborTable collects the neighbors of a table in the order they are walked.
+type neighborTable struct {
+	protocol  string
+	indexes   []string
+	neighbors map[string]*neighbor
+}
+
+func newNeighborTable(protocol string) *neighborTable {
+	return &neighborTable{protocol: protocol, neighbors: make(map[string]*neighbor)}
+}
+
+func (t *neighborTable) get(index string) *neighbor {
+	if n, exists := t.neighbors[index]; exists {
+		return n
+	}
+	n := &neighbor{protocol: t.protocol}
+	t.neighbors[index] = n
+	t.indexes = append(t.indexes, index)
+	return n
+}
+
+// list returns the neighbors which can be identified by the name or the address.
+func (t *neighborTable) list() []neighbor {
+	neighbors := make([]neighbor, 0)
+	for _, index := range t.indexes {
+		if n := t.neighbors[index]; n.name != "" || n.address != "" {
+			neighbors = append(neighbors, *n)
+		}
+	}
+	return neighbors
+}
+
+// splitColumnIndex splits the oid of a table entry column into the column and the index.
+func splitColumnIndex(entryOID, oid string) (string, string, bool) {
+	suffix := strings.TrimPrefix(normalizeOID(oid), entryOID+".")
+	if suffix == normalizeOID(oid) {
+		return "", "", false
+	}
+	parts := strings.SplitN(suffix, ".", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// parseLldpNeighbors parses the neighbors from the variables of lldpRemTable and lldpRemManAddrTable.
+func parseLldpNeighbors(remPDUs, manAddrPDUs []gosnmp.SnmpPDU) []neighbor {
+	table := newNeighborTable(neighborProtocolLLDP)
+	chassisSubtypes := make(map[string]int64)
+	chassisIDs := make(map[string][]byte)
+	for _, pdu := range remPDUs {
+		column, index, ok := splitColumnIndex(lldpRemEntryOID, pdu.Name)
+		if !ok {
+			continue
+		}
+
+		switch column {
+		case lldpRemChassisIDSubtype:
+			if val, ok := snmpValue(pdu); ok {
+				chassisSubtypes[index], _ = val.(int64)
+			}
+		case lldpRemChassisID:
+			if val, ok := pdu.Value.([]byte); ok {
+				chassisIDs[index] = val
+			}
+			table.get(index)
+		case lldpRemPortID:
+			if val, ok := snmpValue(pdu); ok {
+				table.get(index).port, _ = val.(string)
+			}
+		case lldpRemSysName:
+			if val, ok := snmpValue(pdu); ok {
+				table.get(index).name, _ = val.(string)
+			}
+		}
+	}
+
+	// the management address is preferred, the chassis id is used if it's a network address.
+	for index, subtype := range chassisSubtypes {
+		chassisID := chassisIDs[index]
+		if subtype == lldpChassisIDNetworkAddress && len(chassisID) == net.IPv4len+1 &&
+			chassisID[0] == ianaAddressFamilyIPv4 {
+			table.get(index).address = net.IP(chassisID[1:]).String()
+		}
+	}
+
+	for _, pdu := range manAddrPDUs {
+		_, index, ok := splitColumnIndex(lldpRemManAddrEntryOID, pdu.Name)
+		if !ok {
+			continue
+		}
+
+		// index is timeMark.localPortNum.remIndex.addrSubtype.addrLen.addr
+		parts := strings.Split(index, ".")
+		if len(parts) != 9 || parts[3] != strconv.Itoa(ianaAddressFamilyIPv4) || parts[4] != strconv.Itoa(net.IPv4len) {
+			continue
+		}
+		ip := net.ParseIP(strings.Join(parts[5:], "."))
+		if ip == nil {
+			continue
+		}
+		table.get(strings.Join(parts[:3], ".")).address = ip.String()
+	}
+
+	return table.list()
+}
+
+// parseCdpNeighbors parses the neighbors from the variables of cdpCacheTable.
+func parseCdpNeighbors(cachePDUs []gosnmp.SnmpPDU) []neighbor {
+	table := newNeighborTable(neighborProtocolCDP)
+	addressTypes := make(map[string]int64)
+	addresses := make(map[string][]byte)
+	for _, pdu := range cachePDUs {
+		column, index, ok := splitColumnIndex(cdpCacheEntryOID, pdu.Name)
+		if !ok {
+			continue
+		}
+
+		switch column {
+		case cdpCacheAddressType:
+			if val, ok := snmpValue(pdu); ok {
+				addressTypes[index], _ = val.(int64)
+			}
+		case cdpCacheAddress:
+			if val, ok := pdu.Value.([]byte); ok {
+				addresses[index] = val
+			}
+			table.get(index)
+		case cdpCacheDeviceID:
+			if val, ok := snmpValue(pdu); ok {
+				table.get(index).name, _ = val.(string)
+			}
+		case cdpCacheDevicePort:
+			if val, ok := snmpValue(pdu); ok {
+				table.get(index).port, _ = val.(string)
+			}
+		}
+	}
+
+	for index, address := range addresses {
+		if addressType, exists := addressTypes[index]; exists && addressType != cdpAddressTypeIP {
+			continue
+		}
+		if len(address) == net.IPv4len {
+			table.get(index).address = net.IP(address).String()
+		}
+	}
+
+	return table.list()
+}
@@ -0,0 +1,501 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	sysDescrOID    = "1.3.6.1.2.1.1.1.0"
+	sysObjectIDOID = "1.3.6.1.2.1.1.2.0"
+	sysNameOID     = "1.3.6.1.2.1.1.5.0"
+)
+
+// SnmpPoller polls the network devices by snmp in process, the properties configured for the devices are reported
+// with their oid values, and the lldp/cdp neighbors are reported as the associations to the other polled devices
+// or the hosts. the reports are saved like the ones from netdevicebeat and wait to be confirmed.
+// only the master datacollection node polls, so that the devices are not polled by every node.
+type SnmpPoller struct {
+	collect  *NetCollect
+	config   *snmpConfig
+	isMaster discovery.ServiceManageInterface
+}
+
+// NewSnmpPoller returns a new snmp poller with the datacollection.netcollect.snmp config,
+// returns nil if the poller is not enabled.
+func NewSnmpPoller(collect *NetCollect, isMaster discovery.ServiceManageInterface) (*SnmpPoller, error) {
+	config, err := parseSnmpConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+	return &SnmpPoller{collect: collect, config: config, isMaster: isMaster}, nil
+}
+
+// Run polls the devices periodically until the context is done.
+func (p *SnmpPoller) Run() {
+	blog.Infof("[data-collection][netcollect] snmp poller start, targets: %d, interval: %v", len(p.config.targets),
+		p.config.interval)
+
+	ticker := time.NewTicker(p.config.interval)
+	defer ticker.Stop()
+	for {
+		p.tryPoll()
+
+		select {
+		case <-p.collect.ctx.Done():
+			blog.Infof("[data-collection][netcollect] snmp poller stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// polledDevice is the result of polling a target.
+type polledDevice struct {
+	target    snmpTarget
+	device    metadata.NetcollectDevice
+	sysName   string
+	attrs     []metadata.NetcollectReportAttribute
+	neighbors []neighbor
+}
+
+// instKey returns the instance name of the device, which is the system name or the address if it has no name.
+func (d *polledDevice) instKey() string {
+	if d.sysName != "" {
+		return d.sysName
+	}
+	return d.target.host
+}
+
+// tryPoll polls the devices if this node is the master, returns whether the devices are polled.
+func (p *SnmpPoller) tryPoll() bool {
+	if !p.isMaster.IsMaster() {
+		blog.V(4).Infof("[data-collection][netcollect] not master, skip snmp polling")
+		return false
+	}
+	p.pollOnce()
+	return true
+}
+
+func (p *SnmpPoller) pollOnce() {
+	rid := util.GenerateRID()
+	ctx := context.WithValue(p.collect.ctx, common.ContextRequestIDField, rid)
+	ctx = dal.WithTenant(ctx, common.BKDefaultOwnerID)
+
+	devices, properties, err := p.findDevices(ctx)
+	if err != nil {
+		blog.Errorf("[data-collection][netcollect] find net devices failed, err: %v, rid: %s", err, rid)
+		return
+	}
+	if len(devices) == 0 {
+		blog.V(4).Infof("[data-collection][netcollect] no net device is configured, skip snmp polling, rid: %s", rid)
+		return
+	}
+
+	polled := p.pollTargets(ctx, devices, properties, rid)
+	if len(polled) == 0 {
+		return
+	}
+
+	hostIPs, err := p.findHostIPs(ctx, neighborAddresses(polled))
+	if err != nil {
+		blog.Errorf("[data-collection][netcollect] find neighbor hosts failed, err: %v, rid: %s", err, rid)
+		return
+	}
+
+	objIDs := make([]string, 0)
+	for _, device := range devices {
+		objIDs = append(objIDs, device.ObjectID)
+	}
+	objAssts, err := p.findObjAssts(ctx, util.StrArrayUnique(objIDs))
+	if err != nil {
+		blog.Errorf("[data-collection][netcollect] find net device associations failed, err: %v, rid: %s", err, rid)
+		return
+	}
+
+	reports := buildReports(polled, hostIPs, objAssts, p.config.cloudID, metadata.Now())
+	for idx := range reports {
+		if err := p.collect.handleReport(&reports[idx]); err != nil {
+			blog.Errorf("[data-collection][netcollect] save snmp report of %s failed, err: %v, rid: %s",
+				reports[idx].InstKey, err, rid)
+		}
+	}
+	blog.V(4).Infof("[data-collection][netcollect] snmp poll %d targets, report %d devices, rid: %s",
+		len(p.config.targets), len(reports), rid)
+}
+
+// pollTargets polls the targets concurrently, the targets failed to poll are skipped.
+func (p *SnmpPoller) pollTargets(ctx context.Context, devices []metadata.NetcollectDevice,
+	properties map[uint64][]metadata.NetcollectProperty, rid string) []*polledDevice {
+
+	results := make([]*polledDevice, len(p.config.targets))
+	pipeline := make(chan struct{}, snmpMaxConcurrentTargets)
+	wg := sync.WaitGroup{}
+	for idx := range p.config.targets {
+		pipeline <- struct{}{}
+		wg.Add(1)
+		go func(idx int) {
+			defer func() {
+				<-pipeline
+				wg.Done()
+			}()
+
+			target := p.config.targets[idx]
+			polled, err := p.pollTarget(ctx, target, devices, properties)
+			if err != nil {
+				blog.Errorf("[data-collection][netcollect] snmp poll %s:%d failed, err: %v, rid: %s", target.host,
+					target.port, err, rid)
+				return
+			}
+			results[idx] = polled
+		}(idx)
+	}
+	wg.Wait()
+
+	polled := make([]*polledDevice, 0)
+	for _, result := range results {
+		if result != nil {
+			polled = append(polled, result)
+		}
+	}
+	return polled
+}
+
+// pollTarget polls the system info of the target to match its device, then polls the properties and the neighbors.
+func (p *SnmpPoller) pollTarget(ctx context.Context, target snmpTarget, devices []metadata.NetcollectDevice,
+	properties map[uint64][]metadata.NetcollectProperty) (*polledDevice, error) {
+
+	client := p.config.newClient(ctx, target)
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("connect failed, err: %v", err)
+	}
+	defer client.Conn.Close()
+
+	sysInfo, err := snmpGet(client, []string{sysDescrOID, sysObjectIDOID, sysNameOID})
+	if err != nil {
+		return nil, fmt.Errorf("get system info failed, err: %v", err)
+	}
+	sysDescr := util.GetStrByInterface(sysInfo[sysDescrOID])
+	device := matchDevice(devices, sysDescr)
+	if device == nil {
+		return nil, fmt.Errorf("no net device matches the system description %s", sysDescr)
+	}
+
+	polled := &polledDevice{
+		target:  target,
+		device:  *device,
+		sysName: util.GetStrByInterface(sysInfo[sysNameOID]),
+	}
+	polled.attrs, err = pollProperties(client, properties[device.DeviceID])
+	if err != nil {
+		return nil, err
+	}
+	polled.neighbors = pollNeighbors(client)
+	return polled, nil
+}
+
+// snmpGet gets the values of the oids in batches, the oids which have no value are not returned.
+func snmpGet(client *gosnmp.GoSNMP, oids []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for start := 0; start < len(oids); start += client.MaxOids {
+		end := start + client.MaxOids
+		if end > len(oids) {
+			end = len(oids)
+		}
+
+		result, err := client.Get(oids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if result.Error != gosnmp.NoError {
+			return nil, fmt.Errorf("get %v returns error status %s", oids[start:end], result.Error)
+		}
+		for _, pdu := range result.Variables {
+			if val, ok := snmpValue(pdu); ok {
+				values[normalizeOID(pdu.Name)] = val
+			}
+		}
+	}
+	return values, nil
+}
+
+// pollProperties polls the oids of the properties with their actions.
+func pollProperties(client *gosnmp.GoSNMP, properties []metadata.NetcollectProperty) (
+	[]metadata.NetcollectReportAttribute, error) {
+
+	getOIDs := make([]string, 0)
+	for _, property := range properties {
+		if property.Action != common.SNMPActionGetNext {
+			getOIDs = append(getOIDs, normalizeOID(property.OID))
+		}
+	}
+	values, err := snmpGet(client, getOIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get property oids failed, err: %v", err)
+	}
+
+	attrs := make([]metadata.NetcollectReportAttribute, 0)
+	for _, property := range properties {
+		oid := normalizeOID(property.OID)
+		if property.Action == common.SNMPActionGetNext {
+			result, err := client.GetNext([]string{oid})
+			if err != nil {
+				return nil, fmt.Errorf("get next of property %s oid %s failed, err: %v", property.PropertyID, oid, err)
+			}
+			if len(result.Variables) == 0 {
+				continue
+			}
+			if val, ok := snmpValue(result.Variables[0]); ok {
+				attrs = append(attrs, metadata.NetcollectReportAttribute{PropertyID: property.PropertyID, CurValue: val})
+			}
+			continue
+		}
+
+		if val, exists := values[oid]; exists {
+			attrs = append(attrs, metadata.NetcollectReportAttribute{PropertyID: property.PropertyID, CurValue: val})
+		}
+	}
+	return attrs, nil
+}
+
+// pollNeighbors walks the lldp and cdp neighbor tables, the tables not supported by the device are ignored.
+func pollNeighbors(client *gosnmp.GoSNMP) []neighbor {
+	walk := func(oid string) []gosnmp.SnmpPDU {
+		pdus, err := client.BulkWalkAll(oid)
+		if err != nil {
+			blog.V(4).Infof("[data-collection][netcollect] walk %s of %s failed, err: %v", oid, client.Target, err)
+			return nil
+		}
+		return pdus
+	}
+
+	neighbors := parseLldpNeighbors(walk(lldpRemEntryOID), walk(lldpRemManAddrEntryOID))
+	return append(neighbors, parseCdpNeighbors(walk(cdpCacheEntryOID))...)
+}
+
+// matchDevice matches the device whose model is contained in the system description, the device whose vendor is also
+// contained is preferred, then the device with the longer model.
+func matchDevice(devices []metadata.NetcollectDevice, sysDescr string) *metadata.NetcollectDevice {
+	descr := strings.ToLower(sysDescr)
+	var matched *metadata.NetcollectDevice
+	matchedVendor := false
+	for idx := range devices {
+		device := &devices[idx]
+		model := strings.ToLower(strings.TrimSpace(device.DeviceModel))
+		if model == "" || !strings.Contains(descr, model) {
+			continue
+		}
+
+		vendor := strings.ToLower(strings.TrimSpace(device.BkVendor))
+		isVendorMatch := vendor != "" && strings.Contains(descr, vendor)
+		if matched == nil || (isVendorMatch && !matchedVendor) ||
+			(isVendorMatch == matchedVendor && len(model) > len(matched.DeviceModel)) {
+			matched = device
+			matchedVendor = isVendorMatch
+		}
+	}
+	return matched
+}
+
+// neighborAddresses returns the addresses of the neighbors which are not the polled devices, they may be the hosts.
+func neighborAddresses(polled []*polledDevice) []string {
+	polledAddrs := make(map[string]bool)
+	for _, device := range polled {
+		polledAddrs[device.target.host] = true
+	}
+
+	addresses := make([]string, 0)
+	for _, device := range polled {
+		for _, n := range device.neighbors {
+			if n.address != "" && !polledAddrs[n.address] {
+				addresses = append(addresses, n.address)
+			}
+		}
+	}
+	return util.StrArrayUnique(addresses)
+}
+
+func objAsstKey(objID, asstObjID string) string {
+	return objID + "#" + asstObjID
+}
+
+// buildReports builds the reports of the polled devices, the neighbors are associated to the polled device with the
+// same address or name, or the host with the same address. the neighbors can't be associated by any model association
+// are skipped.
+func buildReports(polled []*polledDevice, hostIPs map[string]bool, objAssts map[string]string, cloudID int64,
+	now metadata.Time) []metadata.NetcollectReport {
+
+	byAddr := make(map[string]*polledDevice)
+	byName := make(map[string]*polledDevice)
+	for _, device := range polled {
+		byAddr[device.target.host] = device
+		if device.sysName != "" {
+			byName[strings.ToLower(device.sysName)] = device
+		}
+	}
+
+	reports := make([]metadata.NetcollectReport, 0)
+	for _, device := range polled {
+		report := metadata.NetcollectReport{
+			CloudID:      cloudID,
+			ObjectID:     device.device.ObjectID,
+			InnerIP:      device.target.host,
+			OwnerID:      common.BKDefaultOwnerID,
+			InstKey:      device.instKey(),
+			LastTime:     now,
+			Attributes:   make([]metadata.NetcollectReportAttribute, 0),
+			Associations: make([]metadata.NetcollectReportAssociation, 0),
+		}
+
+		hasInstName := false
+		for _, attr := range device.attrs {
+			if attr.PropertyID == common.BKInstNameField {
+				hasInstName = true
+			}
+		}
+		if !hasInstName {
+			report.Attributes = append(report.Attributes, metadata.NetcollectReportAttribute{
+				PropertyID: common.BKInstNameField,
+				CurValue:   report.InstKey,
+			})
+		}
+		report.Attributes = append(report.Attributes, device.attrs...)
+
+		exists := make(map[string]bool)
+		for _, n := range device.neighbors {
+			var asstObjID, asstInstName string
+			peer := byAddr[n.address]
+			if peer == nil && n.name != "" {
+				peer = byName[strings.ToLower(n.name)]
+			}
+			switch {
+			case peer != nil && peer != device:
+				asstObjID, asstInstName = peer.device.ObjectID, peer.instKey()
+			case peer == nil && n.address != "" && hostIPs[n.address]:
+				asstObjID, asstInstName = common.BKInnerObjIDHost, n.address
+			default:
+				continue
+			}
+
+			objAsstID, ok := objAssts[objAsstKey(report.ObjectID, asstObjID)]
+			if !ok {
+				blog.V(4).Infof("[data-collection][netcollect] %s has no association to %s, skip neighbor %s of %s",
+					report.ObjectID, asstObjID, asstInstName, report.InstKey)
+				continue
+			}
+
+			key := objAsstKey(asstObjID, asstInstName)
+			if exists[key] {
+				continue
+			}
+			exists[key] = true
+
+			report.Associations = append(report.Associations, metadata.NetcollectReportAssociation{
+				AsstInstName:  asstInstName,
+				AsstObjectID:  asstObjID,
+				ObjectAsstID:  objAsstID,
+				Configuration: strings.TrimSpace(n.protocol + " " + n.port),
+			})
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// findDevices finds the configured net devices, and their properties grouped by the device id.
+func (p *SnmpPoller) findDevices(ctx context.Context) ([]metadata.NetcollectDevice,
+	map[uint64][]metadata.NetcollectProperty, error) {
+
+	devices := make([]metadata.NetcollectDevice, 0)
+	if err := p.collect.db.Table(common.BKTableNameNetcollectDevice).Find(nil).All(ctx, &devices); err != nil {
+		return nil, nil, err
+	}
+
+	properties := make([]metadata.NetcollectProperty, 0)
+	if err := p.collect.db.Table(common.BKTableNameNetcollectProperty).Find(nil).All(ctx, &properties); err != nil {
+		return nil, nil, err
+	}
+
+	propertyMap := make(map[uint64][]metadata.NetcollectProperty)
+	for _, property := range properties {
+		propertyMap[property.DeviceID] = append(propertyMap[property.DeviceID], property)
+	}
+	return devices, propertyMap, nil
+}
+
+// findHostIPs finds the inner ips of the hosts in the cloud area which are one of the addresses.
+func (p *SnmpPoller) findHostIPs(ctx context.Context, addresses []string) (map[string]bool, error) {
+	hostIPs := make(map[string]bool)
+	if len(addresses) == 0 {
+		return hostIPs, nil
+	}
+
+	cond := map[string]interface{}{
+		common.BKCloudIDField:     p.config.cloudID,
+		common.BKHostInnerIPField: map[string]interface{}{common.BKDBIN: addresses},
+	}
+	hosts := make([]map[string]interface{}, 0)
+	err := p.collect.db.Table(common.BKTableNameBaseHost).Find(cond).Fields(common.BKHostInnerIPField).All(ctx, &hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, host := range hosts {
+		hostIPs[util.GetStrByInterface(host[common.BKHostInnerIPField])] = true
+	}
+	return hostIPs, nil
+}
+
+// findObjAssts finds the model associations from the net device models to the net device models and the host model,
+// the connect kind association is preferred if there are several ones between two models.
+func (p *SnmpPoller) findObjAssts(ctx context.Context, objIDs []string) (map[string]string, error) {
+	asstObjIDs := append([]string{common.BKInnerObjIDHost}, objIDs...)
+	cond := map[string]interface{}{
+		common.BKObjIDField:     map[string]interface{}{common.BKDBIN: objIDs},
+		common.BKAsstObjIDField: map[string]interface{}{common.BKDBIN: asstObjIDs},
+	}
+	assts := make([]metadata.Association, 0)
+	if err := p.collect.db.Table(common.BKTableNameObjAsst).Find(cond).All(ctx, &assts); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(assts, func(i, j int) bool {
+		return assts[i].AsstKindID == common.AssociationTypeConnect && assts[j].AsstKindID != common.AssociationTypeConnect
+	})
+	objAssts := make(map[string]string)
+	for _, asst := range assts {
+		key := objAsstKey(asst.ObjectID, asst.AsstObjID)
+		if _, exists := objAssts[key]; !exists {
+			objAssts[key] = asst.AssociationName
+		}
+	}
+	return objAssts, nil
+}
@@ -0,0 +1,206 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/require"
+)
+
+var testNetDevices = []metadata.NetcollectDevice{
+	{DeviceID: 1, DeviceModel: "S5720", ObjectID: "bk_switch", BkVendor: "huawei"},
+	{DeviceID: 2, DeviceModel: "C2960", ObjectID: "bk_switch", BkVendor: "cisco"},
+	{DeviceID: 3, DeviceModel: "S57", ObjectID: "bk_router"},
+}
+
+var testNetProperties = map[uint64][]metadata.NetcollectProperty{
+	1: {
+		{PropertyID: "bk_port_count", DeviceID: 1, OID: ".1.3.6.1.2.1.2.1.0", Action: common.SNMPActionGet},
+		{PropertyID: "bk_location", DeviceID: 1, OID: "1.3.6.1.2.1.1.6.0", Action: common.SNMPActionGet},
+		{PropertyID: "bk_uptime", DeviceID: 1, OID: "1.3.6.1.2.1.1.3", Action: common.SNMPActionGetNext},
+		{PropertyID: "bk_not_exist", DeviceID: 1, OID: "1.3.6.1.2.1.1.9.0", Action: common.SNMPActionGet},
+	},
+	2: {
+		{PropertyID: "bk_port_count", DeviceID: 2, OID: "1.3.6.1.2.1.2.1.0", Action: common.SNMPActionGet},
+	},
+}
+
+func newTestSnmpPoller() *SnmpPoller {
+	return &SnmpPoller{config: &snmpConfig{
+		version:   gosnmp.Version2c,
+		community: defaultSnmpCommunity,
+		timeout:   time.Second,
+		retries:   1,
+	}}
+}
+
+type fakeMaster bool
+
+func (m fakeMaster) IsMaster() bool {
+	return bool(m)
+}
+
+func TestSnmpPollerNotMaster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the poller of the node which is not the master does not poll, the nil db would panic if it polls.
+	poller := newTestSnmpPoller()
+	poller.config.interval = time.Hour
+	poller.collect = NewNetCollect(ctx, nil, nil)
+	poller.isMaster = fakeMaster(false)
+	require.False(t, poller.tryPoll())
+
+	done := make(chan struct{})
+	go func() {
+		poller.Run()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("snmp poller is not stopped after the context is done")
+	}
+}
+
+func TestPollTarget(t *testing.T) {
+	sim := newSnmpSimulator(t, "127.0.0.1", "testdata/switch-a.snmprec")
+	poller := newTestSnmpPoller()
+
+	polled, err := poller.pollTarget(context.Background(), sim.target(), testNetDevices, testNetProperties)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), polled.device.DeviceID)
+	require.Equal(t, "switch-a", polled.instKey())
+	require.Equal(t, []metadata.NetcollectReportAttribute{
+		{PropertyID: "bk_port_count", CurValue: int64(28)},
+		{PropertyID: "bk_location", CurValue: "room 1"},
+		{PropertyID: "bk_uptime", CurValue: int64(12345)},
+	}, polled.attrs)
+	require.Equal(t, []neighbor{
+		{protocol: neighborProtocolLLDP, name: "switch-b", address: "127.0.0.2", port: "Gi0/2"},
+		{protocol: neighborProtocolLLDP, name: "server-1", address: "10.0.0.5", port: "eth0"},
+		{protocol: neighborProtocolCDP, name: "server-2", address: "10.0.0.6", port: "eth1"},
+	}, polled.neighbors)
+
+	// the target matches no device.
+	_, err = poller.pollTarget(context.Background(), sim.target(), testNetDevices[1:2], testNetProperties)
+	require.Error(t, err)
+}
+
+func TestBuildReports(t *testing.T) {
+	simA := newSnmpSimulator(t, "127.0.0.1", "testdata/switch-a.snmprec")
+	simB := newSnmpSimulator(t, "127.0.0.2", "testdata/switch-b.snmprec")
+	poller := newTestSnmpPoller()
+	poller.config.targets = []snmpTarget{simA.target(), simB.target()}
+
+	polled := poller.pollTargets(context.Background(), testNetDevices, testNetProperties, "")
+	require.Len(t, polled, 2)
+	require.Equal(t, []string{"10.0.0.5", "10.0.0.6"}, neighborAddresses(polled))
+
+	hostIPs := map[string]bool{"10.0.0.5": true}
+	objAssts := map[string]string{
+		objAsstKey("bk_switch", "bk_switch"):             "bk_switch_connect_bk_switch",
+		objAsstKey("bk_switch", common.BKInnerObjIDHost): "bk_switch_connect_host",
+	}
+	now := metadata.Now()
+	reports := buildReports(polled, hostIPs, objAssts, 2, now)
+	require.Len(t, reports, 2)
+
+	require.Equal(t, "bk_switch", reports[0].ObjectID)
+	require.Equal(t, "switch-a", reports[0].InstKey)
+	require.Equal(t, "127.0.0.1", reports[0].InnerIP)
+	require.Equal(t, int64(2), reports[0].CloudID)
+	require.Equal(t, common.BKDefaultOwnerID, reports[0].OwnerID)
+	require.Equal(t, now, reports[0].LastTime)
+	require.Equal(t, metadata.NetcollectReportAttribute{PropertyID: common.BKInstNameField, CurValue: "switch-a"},
+		reports[0].Attributes[0])
+	require.Len(t, reports[0].Attributes, 4)
+	require.Equal(t, []metadata.NetcollectReportAssociation{
+		{AsstInstName: "switch-b", AsstObjectID: "bk_switch", ObjectAsstID: "bk_switch_connect_bk_switch",
+			Configuration: "lldp Gi0/2"},
+		{AsstInstName: "10.0.0.5", AsstObjectID: common.BKInnerObjIDHost, ObjectAsstID: "bk_switch_connect_host",
+			Configuration: "lldp eth0"},
+	}, reports[0].Associations)
+
+	require.Equal(t, "switch-b", reports[1].InstKey)
+	require.Equal(t, []metadata.NetcollectReportAssociation{
+		{AsstInstName: "switch-a", AsstObjectID: "bk_switch", ObjectAsstID: "bk_switch_connect_bk_switch",
+			Configuration: "cdp GigabitEthernet0/1"},
+	}, reports[1].Associations)
+
+	// neighbors without model association are skipped.
+	reports = buildReports(polled, hostIPs, map[string]string{}, 2, now)
+	require.Empty(t, reports[0].Associations)
+	require.Empty(t, reports[1].Associations)
+}
+
+func TestMatchDevice(t *testing.T) {
+	device := matchDevice(testNetDevices, "Huawei Versatile Routing Platform Software, S5720-28X-SI")
+	require.Equal(t, uint64(1), device.DeviceID)
+
+	// the longer model is preferred if the vendor is not matched.
+	device = matchDevice(testNetDevices, "Versatile Routing Platform Software, S5720-28X-SI")
+	require.Equal(t, uint64(1), device.DeviceID)
+
+	device = matchDevice(testNetDevices, "S5700-28C-EI")
+	require.Equal(t, uint64(3), device.DeviceID)
+
+	require.Nil(t, matchDevice(testNetDevices, "Juniper Networks, Inc. ex2200"))
+}
+
+func TestParseSnmpTargets(t *testing.T) {
+	targets, err := parseSnmpTargets(" 192.168.1.1, 192.168.1.2:1161,,192.168.1.1 ")
+	require.NoError(t, err)
+	require.Equal(t, []snmpTarget{{host: "192.168.1.1", port: 161}, {host: "192.168.1.2", port: 1161}}, targets)
+
+	_, err = parseSnmpTargets("192.168.1.1:0")
+	require.Error(t, err)
+	_, err = parseSnmpTargets("switch-a")
+	require.Error(t, err)
+}
+
+func TestParseSnmpV3Security(t *testing.T) {
+	flags, usm, err := parseSnmpV3Security("cmdb", "md5", "authpass", "aes", "privpass")
+	require.NoError(t, err)
+	require.Equal(t, gosnmp.AuthPriv, flags)
+	require.Equal(t, gosnmp.MD5, usm.AuthenticationProtocol)
+	require.Equal(t, gosnmp.AES, usm.PrivacyProtocol)
+
+	flags, usm, err = parseSnmpV3Security("cmdb", "SHA", "authpass", "AES", "")
+	require.NoError(t, err)
+	require.Equal(t, gosnmp.AuthNoPriv, flags)
+	require.Equal(t, gosnmp.NoPriv, usm.PrivacyProtocol)
+
+	flags, _, err = parseSnmpV3Security("cmdb", "SHA", "", "AES", "")
+	require.NoError(t, err)
+	require.Equal(t, gosnmp.NoAuthNoPriv, flags)
+
+	_, _, err = parseSnmpV3Security("", "SHA", "authpass", "AES", "")
+	require.Error(t, err)
+	_, _, err = parseSnmpV3Security("cmdb", "SHA", "", "AES", "privpass")
+	require.Error(t, err)
+	_, _, err = parseSnmpV3Security("cmdb", "SHA256", "authpass", "AES", "")
+	require.Error(t, err)
+}
+
+func TestOctetString(t *testing.T) {
+	require.Equal(t, "switch-a", octetString([]byte("switch-a\x00")))
+	require.Equal(t, "00:11:22:33:44:55", octetString([]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}))
+}
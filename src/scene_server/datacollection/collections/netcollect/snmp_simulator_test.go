@@ -0,0 +1,186 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"bufio"
+	"encoding/hex"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/require"
+)
+
+// snmpSimulator is a local snmp v2c agent which responds the variables recorded in a snmprec file,
+// each line of the file is oid|tag|value like the recordings of snmpsim.
+type snmpSimulator struct {
+	conn      *net.UDPConn
+	community string
+	oids      []string
+	variables map[string]gosnmp.SnmpPDU
+}
+
+// newSnmpSimulator starts a simulator listening on a random port of the host, the simulator is closed with the test.
+func newSnmpSimulator(t *testing.T, host, file string) *snmpSimulator {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP(host)})
+	if err != nil {
+		t.Skipf("listen udp on %s failed, err: %v", host, err)
+	}
+
+	sim := &snmpSimulator{conn: conn, community: defaultSnmpCommunity, variables: make(map[string]gosnmp.SnmpPDU)}
+	sim.load(t, file)
+	go sim.serve()
+	t.Cleanup(func() { conn.Close() })
+	return sim
+}
+
+func (s *snmpSimulator) target() snmpTarget {
+	addr := s.conn.LocalAddr().(*net.UDPAddr)
+	return snmpTarget{host: addr.IP.String(), port: uint16(addr.Port)}
+}
+
+func (s *snmpSimulator) load(t *testing.T, file string) {
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "|", 3)
+		require.Len(t, parts, 3, scanner.Text())
+
+		pdu := gosnmp.SnmpPDU{Name: "." + parts[0]}
+		switch parts[1] {
+		case "2":
+			pdu.Type = gosnmp.Integer
+			pdu.Value, err = strconv.Atoi(parts[2])
+		case "4":
+			pdu.Type, pdu.Value = gosnmp.OctetString, []byte(parts[2])
+		case "4x":
+			pdu.Type = gosnmp.OctetString
+			pdu.Value, err = hex.DecodeString(parts[2])
+		case "6":
+			pdu.Type, pdu.Value = gosnmp.ObjectIdentifier, "."+parts[2]
+		case "64":
+			pdu.Type, pdu.Value = gosnmp.IPAddress, parts[2]
+		case "65", "66", "67":
+			var val uint64
+			val, err = strconv.ParseUint(parts[2], 10, 32)
+			pdu.Type = map[string]gosnmp.Asn1BER{"65": gosnmp.Counter32, "66": gosnmp.Gauge32, "67": gosnmp.TimeTicks}[parts[1]]
+			pdu.Value = uint32(val)
+		default:
+			t.Fatalf("unsupported tag %s", parts[1])
+		}
+		require.NoError(t, err, scanner.Text())
+
+		s.variables[parts[0]] = pdu
+		s.oids = append(s.oids, parts[0])
+	}
+	require.NoError(t, scanner.Err())
+
+	sort.Slice(s.oids, func(i, j int) bool { return compareOID(s.oids[i], s.oids[j]) < 0 })
+}
+
+// compareOID compares the oids by their sub identifiers.
+func compareOID(a, b string) int {
+	as, bs := strings.Split(normalizeOID(a), "."), strings.Split(normalizeOID(b), ".")
+	for idx := 0; idx < len(as) && idx < len(bs); idx++ {
+		av, _ := strconv.Atoi(as[idx])
+		bv, _ := strconv.Atoi(bs[idx])
+		if av != bv {
+			return av - bv
+		}
+	}
+	return len(as) - len(bs)
+}
+
+func (s *snmpSimulator) get(oid string) gosnmp.SnmpPDU {
+	if pdu, exists := s.variables[normalizeOID(oid)]; exists {
+		return pdu
+	}
+	return gosnmp.SnmpPDU{Name: oid, Type: gosnmp.NoSuchObject}
+}
+
+func (s *snmpSimulator) next(oid string) gosnmp.SnmpPDU {
+	idx := sort.Search(len(s.oids), func(i int) bool { return compareOID(s.oids[i], oid) > 0 })
+	if idx == len(s.oids) {
+		return gosnmp.SnmpPDU{Name: oid, Type: gosnmp.EndOfMibView}
+	}
+	return s.variables[s.oids[idx]]
+}
+
+func (s *snmpSimulator) serve() {
+	decoder := &gosnmp.GoSNMP{Version: gosnmp.Version2c}
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		req, err := decoder.SnmpDecodePacket(buf[:n])
+		if err != nil || req.Community != s.community {
+			continue
+		}
+
+		variables := make([]gosnmp.SnmpPDU, 0)
+		switch req.PDUType {
+		case gosnmp.GetRequest:
+			for _, v := range req.Variables {
+				variables = append(variables, s.get(v.Name))
+			}
+		case gosnmp.GetNextRequest:
+			for _, v := range req.Variables {
+				variables = append(variables, s.next(v.Name))
+			}
+		case gosnmp.GetBulkRequest:
+			nonRepeaters := int(req.NonRepeaters)
+			for _, v := range req.Variables[:nonRepeaters] {
+				variables = append(variables, s.next(v.Name))
+			}
+			// the decoder doesn't parse the max repetitions of the request, respond a fixed count instead.
+			current := req.Variables[nonRepeaters:]
+			for rep := 0; rep < 10 && len(current) > 0; rep++ {
+				nextVars := make([]gosnmp.SnmpPDU, 0)
+				for _, v := range current {
+					pdu := s.next(v.Name)
+					variables = append(variables, pdu)
+					if pdu.Type != gosnmp.EndOfMibView {
+						nextVars = append(nextVars, pdu)
+					}
+				}
+				current = nextVars
+			}
+		default:
+			continue
+		}
+
+		resp := &gosnmp.SnmpPacket{
+			Version:   gosnmp.Version2c,
+			Community: s.community,
+			PDUType:   gosnmp.GetResponse,
+			RequestID: req.RequestID,
+			Variables: variables,
+		}
+		out, err := resp.MarshalMsg()
+		if err != nil {
+			continue
+		}
+		s.conn.WriteToUDP(out, addr)
+	}
+}
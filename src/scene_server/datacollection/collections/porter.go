@@ -113,7 +113,7 @@ type SimplePorter struct {
 func NewSimplePorter(name string, engine *backbone.Engine, hash *Hash, analyzer Analyzer,
 	redisCli redis.Client, topics []string, registry prometheus.Registerer) *SimplePorter {
 
-	return &SimplePorter{
+	p := &SimplePorter{
 		name:      name,
 		engine:    engine,
 		hash:      hash,
@@ -124,6 +124,11 @@ func NewSimplePorter(name string, engine *backbone.Engine, hash *Hash, analyzer
 		registry:  registry,
 		needDebug: needInternalDebug,
 	}
+
+	// init metrics here, the porter may receive messages from ingestion api before running.
+	p.init()
+
+	return p
 }
 
 // init inits a new simple porter.
@@ -288,7 +293,7 @@ func (p *SimplePorter) collectLoop() error {
 			}
 
 			// message data sharding hashring check.
-			hashKey, err := p.analyzer.Hash(gjson.Get(newMsg.Payload, "cloudid").String(), gjson.Get(newMsg.Payload, "ip").String())
+			hashKey, err := p.hashKey(newMsg.Payload)
 			if err != nil {
 				blog.Errorf("SimplePorter[%s]| calculates message hash key failed, %+v", p.name, err)
 
@@ -321,6 +326,11 @@ func (p *SimplePorter) collectLoop() error {
 	return nil
 }
 
+// hashKey calculates hash key of the message base on the cloudid and ip of it.
+func (p *SimplePorter) hashKey(message string) (string, error) {
+	return p.analyzer.Hash(gjson.Get(message, "cloudid").String(), gjson.Get(message, "ip").String())
+}
+
 // fusing is fuse controller, it would weed out the stacked message in channel,
 // in order to keep the newest message could be analyzed in time.
 func (p *SimplePorter) fusing() {
@@ -385,9 +395,6 @@ func (p *SimplePorter) fusing() {
 
 // Run runs the porter.
 func (p *SimplePorter) Run() error {
-	// init new simple porter.
-	p.init()
-
 	// setups analyze goroutines.
 	for i := 0; i < runtime.NumCPU(); i++ {
 		go p.analyzeLoop()
@@ -399,6 +406,12 @@ func (p *SimplePorter) Run() error {
 	// internal debug infos.
 	go p.debug()
 
+	// no redis topics to subscribe, the porter only handles messages from ingestion api.
+	if p.redisCli == nil {
+		blog.Infof("SimplePorter[%s]| no redis client, only handle messages from ingestion api!", p.name)
+		return nil
+	}
+
 	// NOTE: keep collecting message here.
 	p.collectLoop()
 
@@ -36,3 +36,12 @@ type Porter interface {
 	// Mock supports mock service in Porter.
 	Mock() error
 }
+
+// Ingester is implemented by porters that could handle messages pushed
+// by the ingestion api rather than subscribed from redis topics.
+type Ingester interface {
+	// Ingest handles a message pushed by the ingestion api. It returns the
+	// address of the datacollection node that the message belongs to if the
+	// message should not be handled in local node.
+	Ingest(message string, force bool) (string, error)
+}
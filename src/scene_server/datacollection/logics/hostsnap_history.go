@@ -0,0 +1,73 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+// SearchHostSnapHistory search the snapshot history of a host in the time range, the latest history comes first by default.
+func (lgc *Logics) SearchHostSnapHistory(header http.Header, opt *metadata.SearchHostSnapHistoryOption) (
+	*metadata.SearchHostSnapHistoryResult, error) {
+
+	rid := util.GetHTTPCCRequestID(header)
+	ctx := dal.WithTenant(lgc.ctx, util.GetOwnerID(header))
+
+	cond := map[string]interface{}{
+		common.BKHostIDField: opt.HostID,
+	}
+
+	timeCond := make(map[string]interface{})
+	if opt.StartTime != 0 {
+		timeCond[common.BKDBGTE] = time.Unix(opt.StartTime, 0)
+	}
+	if opt.EndTime != 0 {
+		timeCond[common.BKDBLTE] = time.Unix(opt.EndTime, 0)
+	}
+	if len(timeCond) != 0 {
+		cond[common.CreateTimeField] = timeCond
+	}
+
+	if opt.OnlyChanged {
+		// the first history of a host has no changes.
+		cond["changes.0"] = map[string]interface{}{common.BKDBExists: true}
+	}
+
+	count, err := lgc.db.Table(common.BKTableNameHostSnapHistory).Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("count host snapshot history failed, cond: %+v, err: %v, rid: %s", cond, err, rid)
+		return nil, err
+	}
+
+	sort := opt.Page.Sort
+	if len(sort) == 0 {
+		sort = "-" + common.CreateTimeField
+	}
+
+	histories := make([]metadata.HostSnapHistory, 0)
+	err = lgc.db.Table(common.BKTableNameHostSnapHistory).Find(cond).Sort(sort).Start(uint64(opt.Page.Start)).
+		Limit(uint64(opt.Page.Limit)).All(ctx, &histories)
+	if err != nil {
+		blog.Errorf("search host snapshot history failed, cond: %+v, err: %v, rid: %s", cond, err, rid)
+		return nil, err
+	}
+
+	return &metadata.SearchHostSnapHistoryResult{Count: count, Info: histories}, nil
+}
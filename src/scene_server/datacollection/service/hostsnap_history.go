@@ -0,0 +1,54 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// SearchHostSnapHistory search the history of the host fields derived from host snapshots with the changes.
+func (s *Service) SearchHostSnapHistory(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(pHeader)
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+
+	opt := new(metadata.SearchHostSnapHistoryOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(opt); err != nil {
+		blog.Errorf("search host snapshot history, but decode body failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if field, err := opt.Validate(); err != nil {
+		blog.Errorf("search host snapshot history, but option %+v is invalid, err: %v, rid: %s", opt, err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, field)})
+		return
+	}
+
+	result, err := s.logics.SearchHostSnapHistory(pHeader, opt)
+	if err != nil {
+		blog.Errorf("search host snapshot history failed, option: %+v, err: %v, rid: %s", opt, err, rid)
+		_ = resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	_ = resp.WriteEntity(metadata.NewSuccessResp(result))
+}
@@ -0,0 +1,231 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	stderr "errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+	"github.com/tidwall/gjson"
+)
+
+// maxIngestBodySize is the max size in bytes of the body pushed by the ingestion api, a batch contains
+// at most common.BKMaxInstanceLimit messages, which is far less than this limit for normal collectors.
+const maxIngestBodySize = 32 << 20
+
+var errIngestBodyTooLarge = stderr.New("ingestion body too large")
+
+// IngestMessage handles a collector message pushed by the ingestion api.
+func (s *Service) IngestMessage(req *restful.Request, resp *restful.Response) {
+	s.ingest(req, resp, false)
+}
+
+// BatchIngestMessage handles a batch of collector messages pushed by the ingestion api.
+func (s *Service) BatchIngestMessage(req *restful.Request, resp *restful.Response) {
+	s.ingest(req, resp, true)
+}
+
+func (s *Service) ingest(req *restful.Request, resp *restful.Response, isBatch bool) {
+	header := req.Request.Header
+	rid := util.GetHTTPCCRequestID(header)
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+
+	token := header.Get(common.BKHTTPCollectorToken)
+	if !isValidIngestToken(s.ingestTokens, token) {
+		blog.Errorf("ingest collector message failed, invalid collector token, token configured: %v, rid: %s",
+			len(s.ingestTokens) != 0, rid)
+		resp.WriteError(http.StatusUnauthorized, &meta.RespError{Msg: defErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	if s.porterManager == nil {
+		blog.Errorf("ingest collector message failed, porters are not ready, rid: %s", rid)
+		resp.WriteError(http.StatusServiceUnavailable, &meta.RespError{
+			Msg: defErr.Errorf(common.CCErrCommInternalServerError, "porters not ready")})
+		return
+	}
+
+	body, err := readIngestBody(req.Request.Body, maxIngestBodySize)
+	if err == errIngestBodyTooLarge {
+		blog.Errorf("ingest collector message failed, body exceeds %d bytes, rid: %s", maxIngestBodySize, rid)
+		resp.WriteError(http.StatusRequestEntityTooLarge, &meta.RespError{
+			Msg: defErr.Errorf(common.CCErrCommXXExceedLimit, "body", maxIngestBodySize)})
+		return
+	}
+	if err != nil {
+		blog.Errorf("ingest collector message failed, read body err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPReadBodyFailed)})
+		return
+	}
+
+	messages, err := parseIngestMessages(body, isBatch)
+	if err != nil {
+		blog.Errorf("ingest collector message failed, parse body err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if len(messages) == 0 {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, "data")})
+		return
+	}
+
+	if len(messages) > common.BKMaxInstanceLimit {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{
+			Msg: defErr.Errorf(common.CCErrCommXXExceedLimit, "data", common.BKMaxInstanceLimit)})
+		return
+	}
+
+	// messages forwarded by another datacollection node are sharded already.
+	isForwarded := header.Get(common.BKHTTPCollectorForwarded) != ""
+	name := req.PathParameter("collector")
+
+	result := meta.CollectorIngestResult{Failed: make([]meta.CollectorIngestFailure, 0)}
+	// owner node address -> indexes of the messages belong to the node.
+	forwards := make(map[string][]int)
+
+	for index, message := range messages {
+		owner, err := s.porterManager.Ingest(name, string(message), isForwarded)
+		if err != nil {
+			blog.Errorf("ingest collector message to porter %s failed, index: %d, err: %v, rid: %s", name, index, err, rid)
+			result.Failed = append(result.Failed, meta.CollectorIngestFailure{Index: index, ErrMsg: err.Error()})
+			continue
+		}
+
+		if len(owner) != 0 {
+			forwards[owner] = append(forwards[owner], index)
+			continue
+		}
+		result.Accepted++
+	}
+
+	for owner, indexes := range forwards {
+		forwardMessages := make([]json.RawMessage, len(indexes))
+		for idx, index := range indexes {
+			forwardMessages[idx] = messages[index]
+		}
+
+		forwardResult, err := s.forwardIngest(header, owner, name, forwardMessages)
+		if err != nil {
+			blog.Errorf("forward collector messages to %s failed, err: %v, rid: %s", owner, err, rid)
+			for _, index := range indexes {
+				result.Failed = append(result.Failed, meta.CollectorIngestFailure{Index: index, ErrMsg: err.Error()})
+			}
+			continue
+		}
+
+		result.Forwarded += forwardResult.Accepted + forwardResult.Forwarded
+		for _, failed := range forwardResult.Failed {
+			if failed.Index < 0 || failed.Index >= len(indexes) {
+				continue
+			}
+			failed.Index = indexes[failed.Index]
+			result.Failed = append(result.Failed, failed)
+		}
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(result))
+}
+
+// readIngestBody reads the ingestion body, returns errIngestBodyTooLarge if it is larger than limit bytes.
+func readIngestBody(body io.Reader, limit int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errIngestBodyTooLarge
+	}
+	return data, nil
+}
+
+// forwardIngest forwards the collector messages to the datacollection node they belong to.
+func (s *Service) forwardIngest(header http.Header, owner, name string,
+	messages []json.RawMessage) (*meta.CollectorIngestResult, error) {
+
+	body, err := json.Marshal(meta.CollectorIngestBatch{Data: messages})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/collector/v3/ingest/%s/action/batch", strings.TrimSuffix(owner, "/"), name)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = util.CloneHeader(header)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(common.BKHTTPCollectorForwarded, "true")
+
+	resp, err := s.ingestCli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := new(meta.CollectorIngestResponse)
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("decode forward response failed, status: %d, err: %v", resp.StatusCode, err)
+	}
+
+	if !result.Result {
+		return nil, errors.New(result.Code, result.ErrMsg)
+	}
+
+	return &result.Data, nil
+}
+
+// parseIngestMessages parses collector messages from the body of ingestion request, the body
+// of single ingestion is the message itself, the batch one's is CollectorIngestBatch.
+func parseIngestMessages(body []byte, isBatch bool) ([]json.RawMessage, error) {
+	if !isBatch {
+		if !gjson.Valid(string(body)) {
+			return nil, fmt.Errorf("invalid json message")
+		}
+		return []json.RawMessage{body}, nil
+	}
+
+	batch := meta.CollectorIngestBatch{}
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil, err
+	}
+	return batch.Data, nil
+}
+
+// isValidIngestToken checks the collector token, ingestion api is disabled if no token is configured.
+func isValidIngestToken(tokens []string, token string) bool {
+	if len(token) == 0 {
+		return false
+	}
+
+	for _, valid := range tokens {
+		if subtle.ConstantTimeCompare([]byte(valid), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestParseIngestMessages(t *testing.T) {
+	body, err := ioutil.ReadFile("testdata/batch.json")
+	require.NoError(t, err)
+
+	messages, err := parseIngestMessages(body, true)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.Equal(t, "127.0.0.1", gjson.Get(string(messages[0]), "ip").String())
+	require.Equal(t, "host-2", gjson.Get(string(messages[1]), "data.system.info.hostname").String())
+
+	// single ingestion uses the message as body.
+	messages, err = parseIngestMessages(messages[0], false)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, int64(0), gjson.Get(string(messages[0]), "cloudid").Int())
+
+	_, err = parseIngestMessages([]byte("not json"), false)
+	require.Error(t, err)
+
+	_, err = parseIngestMessages([]byte(`{"data": {}}`), true)
+	require.Error(t, err)
+}
+
+func TestIsValidIngestToken(t *testing.T) {
+	tokens := []string{"token1", "token2"}
+
+	require.True(t, isValidIngestToken(tokens, "token1"))
+	require.True(t, isValidIngestToken(tokens, "token2"))
+	require.False(t, isValidIngestToken(tokens, "token3"))
+	require.False(t, isValidIngestToken(tokens, ""))
+
+	// ingestion api is disabled without tokens.
+	require.False(t, isValidIngestToken(nil, "token1"))
+	require.False(t, isValidIngestToken([]string{}, ""))
+}
+
+func TestReadIngestBody(t *testing.T) {
+	body, err := readIngestBody(bytes.NewReader([]byte("1234567890")), 10)
+	require.NoError(t, err)
+	require.Equal(t, "1234567890", string(body))
+
+	_, err = readIngestBody(bytes.NewReader([]byte("12345678901")), 10)
+	require.Equal(t, errIngestBodyTooLarge, err)
+}
@@ -16,6 +16,7 @@ import (
 	"context"
 	"fmt"
 
+	"configcenter/src/apimachinery/util"
 	"configcenter/src/common"
 	"configcenter/src/common/backbone"
 	"configcenter/src/common/errors"
@@ -23,6 +24,7 @@ import (
 	"configcenter/src/common/metric"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
+	"configcenter/src/scene_server/datacollection/collections"
 	"configcenter/src/scene_server/datacollection/logics"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/redis"
@@ -43,6 +45,15 @@ type Service struct {
 	netCli  redis.Client
 
 	logics *logics.Logics
+
+	// porterManager handles messages pushed by the ingestion api.
+	porterManager *collections.PorterManager
+
+	// ingestTokens is the tokens of collectors that are allowed to use the ingestion api.
+	ingestTokens []string
+
+	// ingestCli is used to forward ingestion messages to other datacollection nodes.
+	ingestCli util.HttpClient
 }
 
 // NewService creates a new Service object.
@@ -80,6 +91,19 @@ func (s *Service) SetNetCollectCli(db redis.Client) {
 	s.netCli = db
 }
 
+// SetIngestion setups porters manager and collector tokens for the ingestion api.
+func (s *Service) SetIngestion(porterManager *collections.PorterManager, tokens []string) error {
+	client, err := util.NewClient(s.engine.ApiMachineryConfig().TLSConfig)
+	if err != nil {
+		return fmt.Errorf("create ingestion forward client, %+v", err)
+	}
+
+	s.ingestCli = client
+	s.ingestTokens = tokens
+	s.porterManager = porterManager
+	return nil
+}
+
 // WebService setups a new restful web service.
 func (s *Service) WebService() *restful.Container {
 	container := restful.NewContainer()
@@ -112,6 +136,11 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/netcollect/collector/action/update").To(s.UpdateCollector))
 	api.Route(api.POST("/netcollect/collector/action/discover").To(s.DiscoverNetDevice))
 
+	api.Route(api.POST("/hostsnap/history/action/search").To(s.SearchHostSnapHistory))
+
+	api.Route(api.POST("/ingest/{collector}/action/create").To(s.IngestMessage))
+	api.Route(api.POST("/ingest/{collector}/action/batch").To(s.BatchIngestMessage))
+
 	container.Add(api)
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
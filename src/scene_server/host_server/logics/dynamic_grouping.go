@@ -99,7 +99,9 @@ func NewHostDynamicGroupExecutor(kit *rest.Kit, lgc *Logics, params *metadata.Ho
 // Execute executes host dynamic group.
 func (e *HostDynamicGroupExecutor) Execute() ([]mapstr.MapStr, int, error) {
 	// parse conditions.
-	e.parseCondition()
+	if err := e.parseCondition(); err != nil {
+		return nil, 0, err
+	}
 
 	// search host with conditions.
 	if err := e.searchHostByConds(); err != nil {
@@ -110,8 +112,22 @@ func (e *HostDynamicGroupExecutor) Execute() ([]mapstr.MapStr, int, error) {
 	return result, count, nil
 }
 
-func (e *HostDynamicGroupExecutor) parseCondition() {
+func (e *HostDynamicGroupExecutor) parseCondition() errors.CCError {
 	for _, cond := range e.params.Condition {
+		// validate the label selectors again, the dynamic group may be saved before they are validated.
+		if key, err := cond.LabelSelector.Validate(); err != nil {
+			blog.Errorf("parse condition failed, object %s label selector invalid, err: %v, rid: %s",
+				cond.ObjectID, err, e.ccRid)
+			return e.ccErr.CCErrorf(common.CCErrCommParamsInvalid, "label_selector."+key)
+		}
+		labelConds, err := metadata.LabelSelectorConditions(cond.LabelSelector)
+		if err != nil {
+			blog.Errorf("parse condition failed, object %s label selector invalid, err: %v, rid: %s",
+				cond.ObjectID, err, e.ccRid)
+			return e.ccErr.CCErrorf(common.CCErrCommParamsInvalid, "label_selector")
+		}
+		cond.Condition = append(cond.Condition, labelConds...)
+
 		switch cond.ObjectID {
 		case common.BKInnerObjIDHost:
 			e.conds.hostCond = cond
@@ -144,6 +160,7 @@ func (e *HostDynamicGroupExecutor) parseCondition() {
 		condItem := metadata.ConditionItem{Field: common.BKAppIDField, Operator: common.BKDBEQ, Value: e.params.AppID}
 		e.conds.appCond.Condition = append(e.conds.appCond.Condition, condItem)
 	}
+	return nil
 }
 
 func (e *HostDynamicGroupExecutor) searchHostByConds() error {
@@ -41,7 +41,9 @@ func (lgc *Logics) FindHostByModuleIDs(kit *rest.Kit, data *metadata.HostModuleF
 	hostSearchParam.Condition = []metadata.SearchCondition{hostFindCond, moduleFindCond, setFindCond, bizFindCond}
 
 	findHostInst := NewSearchHost(kit, lgc, hostSearchParam)
-	findHostInst.ParseCondition()
+	if err := findHostInst.ParseCondition(); err != nil {
+		return retHostInfo, err
+	}
 
 	err = findHostInst.SearchHostByConds()
 	if err != nil {
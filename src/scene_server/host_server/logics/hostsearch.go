@@ -31,10 +31,12 @@ import (
 
 func (lgc *Logics) SearchHost(kit *rest.Kit, data *metadata.HostCommonSearch, isDetail bool) (*metadata.SearchHost, error) {
 	searchHostInst := NewSearchHost(kit, lgc, data)
-	searchHostInst.ParseCondition()
 	retHostInfo := &metadata.SearchHost{
 		Info: make([]mapstr.MapStr, 0),
 	}
+	if err := searchHostInst.ParseCondition(); err != nil {
+		return retHostInfo, err
+	}
 	err := searchHostInst.SearchHostByConds()
 	if err != nil {
 		return retHostInfo, err
@@ -130,7 +132,7 @@ type appLevelInfo struct {
 
 // searchHostInterface Too many methods, hiding private methods
 type searchHostInterface interface {
-	ParseCondition()
+	ParseCondition() errors.CCError
 	SearchHostByConds() errors.CCError
 	FillTopologyData() ([]mapstr.MapStr, int, errors.CCError)
 }
@@ -152,9 +154,22 @@ func NewSearchHost(kit *rest.Kit, lgc *Logics, hostSearchParam *metadata.HostCom
 	return sh
 }
 
-func (sh *searchHost) ParseCondition() {
+func (sh *searchHost) ParseCondition() errors.CCError {
 
 	for _, object := range sh.hostSearchParam.Condition {
+		if key, err := object.LabelSelector.Validate(); err != nil {
+			blog.Errorf("parse condition failed, object %s label selector invalid, err: %v, rid: %s",
+				object.ObjectID, err, sh.ccRid)
+			return sh.ccErr.CCErrorf(common.CCErrCommParamsInvalid, "label_selector."+key)
+		}
+		labelConds, err := metadata.LabelSelectorConditions(object.LabelSelector)
+		if err != nil {
+			blog.Errorf("parse condition failed, object %s label selector invalid, err: %v, rid: %s",
+				object.ObjectID, err, sh.ccRid)
+			return sh.ccErr.CCErrorf(common.CCErrCommParamsInvalid, "label_selector")
+		}
+		object.Condition = append(object.Condition, labelConds...)
+
 		if object.ObjectID == common.BKInnerObjIDHost {
 			sh.conds.hostCond = object
 		} else if object.ObjectID == common.BKInnerObjIDSet {
@@ -178,6 +193,7 @@ func (sh *searchHost) ParseCondition() {
 
 	sh.tryParseAppID()
 
+	return nil
 }
 
 func (sh *searchHost) SearchHostByConds() errors.CCError {
@@ -66,6 +66,18 @@ func (lgc *Logics) ExecuteSetDynamicGroup(kit *rest.Kit, setCommonSearch *metada
 
 	// parse set search conditions.
 	for _, searchCondition := range setCommonSearch.Condition {
+		// validate the label selectors again, the dynamic group may be saved before they are validated.
+		if key, err := searchCondition.LabelSelector.Validate(); err != nil {
+			blog.Errorf("search set failed, label selector invalid, err: %v, rid: %s", err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "label_selector."+key)
+		}
+		labelConds, err := metadata.LabelSelectorConditions(searchCondition.LabelSelector)
+		if err != nil {
+			blog.Errorf("search set failed, label selector invalid, err: %v, rid: %s", err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "label_selector")
+		}
+		searchCondition.Condition = append(searchCondition.Condition, labelConds...)
+
 		condc := make(map[string]interface{})
 		if err := parse.ParseCommonParams(searchCondition.Condition, condc); err != nil {
 			blog.Errorf("search set failed, can't parse condition, err: %+v, cond: %+v, rid: %s", err, searchCondition.Condition, kit.Rid)
@@ -467,7 +467,8 @@ func (s *Service) ExecuteDynamicGroup(ctx *rest.Contexts) {
 
 	// parse all dynamic group conditions to search condition.
 	for _, cond := range targetDynamicGroup.Info.Condition {
-		searchCondition := meta.SearchCondition{ObjectID: cond.ObjID, Condition: []meta.ConditionItem{}}
+		searchCondition := meta.SearchCondition{ObjectID: cond.ObjID, Condition: []meta.ConditionItem{},
+			LabelSelector: cond.LabelSelector}
 
 		// build condition items.
 		for _, item := range cond.Condition {
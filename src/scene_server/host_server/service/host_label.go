@@ -0,0 +1,133 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/selector"
+)
+
+// AddHostLabels add labels to hosts
+func (s *Service) AddHostLabels(ctx *rest.Contexts) {
+	option := selector.LabelAddOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if len(option.InstanceIDs) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "instance_ids"))
+		return
+	}
+	if len(option.InstanceIDs) > common.BKMaxRecordsAtOnce {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrExceedMaxOperationRecordsAtOnce, common.BKMaxRecordsAtOnce))
+		return
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, s.EnableTxn, ctx.Kit.Header, func() error {
+		err := s.CoreAPI.CoreService().Label().AddLabel(ctx.Kit.Ctx, ctx.Kit.Header, common.BKTableNameBaseHost, option)
+		if err != nil {
+			blog.Errorf("add host labels failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// RemoveHostLabels remove labels from hosts
+func (s *Service) RemoveHostLabels(ctx *rest.Contexts) {
+	option := selector.LabelRemoveOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if len(option.InstanceIDs) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "instance_ids"))
+		return
+	}
+	if len(option.Keys) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "keys"))
+		return
+	}
+	if len(option.InstanceIDs) > common.BKMaxRecordsAtOnce {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrExceedMaxOperationRecordsAtOnce, common.BKMaxRecordsAtOnce))
+		return
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, s.EnableTxn, ctx.Kit.Header, func() error {
+		err := s.CoreAPI.CoreService().Label().RemoveLabel(ctx.Kit.Ctx, ctx.Kit.Header, common.BKTableNameBaseHost, option)
+		if err != nil {
+			blog.Errorf("remove host labels failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// HostLabelsAggregation aggregates the label keys and values of hosts, the hosts could be limited to a business.
+func (s *Service) HostLabelsAggregation(ctx *rest.Contexts) {
+	option := metadata.HostLabelAggregationOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	cond := option.Condition
+	if cond == nil {
+		cond = mapstr.New()
+	}
+
+	if option.BizID != 0 {
+		hostIDs, err := s.Logic.GetHostIDByCond(ctx.Kit, metadata.HostModuleRelationRequest{
+			ApplicationID: option.BizID,
+			Page:          metadata.BasePage{Limit: common.BKNoLimit},
+		})
+		if err != nil {
+			blog.Errorf("get host ids of biz %d failed, err: %v, rid: %s", option.BizID, err, ctx.Kit.Rid)
+			ctx.RespAutoError(err)
+			return
+		}
+		cond = mapstr.MapStr{
+			common.BKDBAND: []mapstr.MapStr{
+				cond,
+				{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs}},
+			},
+		}
+	}
+
+	result, err := s.CoreAPI.CoreService().Label().LabelAggregation(ctx.Kit.Ctx, ctx.Kit.Header,
+		common.BKTableNameBaseHost, cond)
+	if err != nil {
+		blog.Errorf("aggregate host labels failed, cond: %+v, err: %v, rid: %s", cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
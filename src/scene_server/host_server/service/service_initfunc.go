@@ -99,6 +99,9 @@ func (s *Service) initHost(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/hosts/search/asstdetail", Handler: s.SearchHostWithAsstDetail})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/hosts/batch", Handler: s.UpdateHostBatch})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/hosts/property/batch", Handler: s.UpdateHostPropertyBatch})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/hosts/labels", Handler: s.AddHostLabels})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/deletemany/hosts/labels", Handler: s.RemoveHostLabels})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/hosts/labels/aggregation", Handler: s.HostLabelsAggregation})
 	// TODO: Deprecated, delete this api, used in framework
 	// utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/hosts/sync/new/host", Handler: s.NewHostSyncAppTopo})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/hosts/modules/idle/set", Handler: s.MoveSetHost2IdleModule})
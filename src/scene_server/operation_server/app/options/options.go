@@ -15,6 +15,7 @@ package options
 import (
 	"configcenter/src/common/auth"
 	"configcenter/src/common/core/cc/config"
+	"configcenter/src/common/metadata"
 	"configcenter/src/storage/dal/mongo"
 
 	"github.com/spf13/pflag"
@@ -28,6 +29,10 @@ type Config struct {
 	ConfigMap map[string]string
 	Mongo     mongo.Config
 	Timer     string
+	// MetricInterval the interval in seconds to refresh the aggregation charts' prometheus metrics
+	MetricInterval int
+	// Snapshot the models to take instance snapshots and how long the snapshots are kept
+	Snapshot metadata.TakeSnapshotOption
 }
 
 func (c *Config) Ready() bool {
@@ -0,0 +1,234 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/metrics"
+	"configcenter/src/common/util"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ValidateAggregationChart validates the aggregation chart's options and it's fields with the model's attributes.
+func (lgc *Logics) ValidateAggregationChart(kit *rest.Kit, chart *metadata.ChartConfig) error {
+	if field, err := chart.ValidateAggregation(); err != nil {
+		blog.Errorf("aggregation chart %s is invalid, field: %s, err: %v, rid: %s", chart.Name, field, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	query := &metadata.QueryCondition{
+		Condition: map[string]interface{}{common.BKObjIDField: chart.ObjID},
+	}
+	result, err := lgc.CoreAPI.CoreService().Model().ReadModelAttr(kit.Ctx, kit.Header, chart.ObjID, query)
+	if err != nil {
+		blog.Errorf("search model %s attributes failed, err: %v, rid: %s", chart.ObjID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("search model %s attributes failed, err: %s, rid: %s", chart.ObjID, result.ErrMsg, kit.Rid)
+		return result.CCError()
+	}
+	if len(result.Data.Info) == 0 {
+		blog.Errorf("model %s of aggregation chart %s has no attribute, rid: %s", chart.ObjID, chart.Name, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+	}
+
+	if field, err := chart.ValidateAggregationFields(result.Data.Info); err != nil {
+		blog.Errorf("aggregation chart %s is invalid, field: %s, err: %v, rid: %s", chart.Name, field, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+	return nil
+}
+
+const (
+	// chartMetricMaxSeries the max number of the series exported for each chart, the groups with the smallest
+	// values are folded into the other group, so that a chart grouped by a field with many values doesn't
+	// export too many series.
+	chartMetricMaxSeries = 50
+	// chartMetricOtherGroup the group label of the folded groups.
+	chartMetricOtherGroup = "other"
+)
+
+// chartMetricLabels the labels of the aggregation chart gauge, the group labels are empty if the chart is grouped
+// by less fields.
+var chartMetricLabels = []string{"config_id", "name", "bk_obj_id", "aggregation", "group1_field", "group1",
+	"group2_field", "group2"}
+
+// ChartMetric exports the aggregation charts' data as prometheus gauges, so that they can be shown with grafana.
+type ChartMetric struct {
+	value *prometheus.GaugeVec
+	count *prometheus.GaugeVec
+}
+
+// NewChartMetric creates the aggregation chart gauges and registers them.
+func NewChartMetric(registry prometheus.Registerer) *ChartMetric {
+	m := &ChartMetric{
+		value: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "operation",
+			Name:      "chart_value",
+			Help:      "the aggregated value of each group of the aggregation charts.",
+		}, chartMetricLabels),
+		count: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "operation",
+			Name:      "chart_instance_count",
+			Help:      "the instance count of each group of the aggregation charts.",
+		}, chartMetricLabels),
+	}
+	registry.MustRegister(m.value, m.count)
+	return m
+}
+
+// set replaces the series of the chart with it's aggregated data, at most chartMetricMaxSeries series are set.
+func (m *ChartMetric) set(chart metadata.ChartConfig, data *metadata.ChartAggregationData) {
+	for _, item := range foldChartItems(data, chartMetricMaxSeries) {
+		labels := prometheus.Labels{
+			"config_id":    strconv.FormatUint(chart.ConfigID, 10),
+			"name":         chart.Name,
+			"bk_obj_id":    chart.ObjID,
+			"aggregation":  string(data.Aggregation),
+			"group1_field": "",
+			"group1":       "",
+			"group2_field": "",
+			"group2":       "",
+		}
+		for idx, field := range data.GroupBy {
+			if idx >= metadata.ChartMaxGroupByFields || idx >= len(item.Keys) {
+				break
+			}
+			labels["group"+strconv.Itoa(idx+1)+"_field"] = field
+			labels["group"+strconv.Itoa(idx+1)] = item.Keys[idx]
+		}
+		m.value.With(labels).Set(item.Value)
+		m.count.With(labels).Set(float64(item.Count))
+	}
+}
+
+// foldChartItems returns at most max items of the chart data, the max-1 items with the largest values are kept,
+// the others are folded into an item whose keys are all chartMetricOtherGroup.
+func foldChartItems(data *metadata.ChartAggregationData, max int) []metadata.ChartAggregationItem {
+	if len(data.Items) <= max {
+		return data.Items
+	}
+
+	items := make([]metadata.ChartAggregationItem, len(data.Items))
+	copy(items, data.Items)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Value > items[j].Value })
+
+	other := metadata.ChartAggregationItem{Keys: make([]string, len(data.GroupBy))}
+	for idx := range other.Keys {
+		other.Keys[idx] = chartMetricOtherGroup
+	}
+	total := float64(0)
+	for _, item := range items[max-1:] {
+		other.Count += item.Count
+		if data.Aggregation == metadata.ChartAggregationAvg {
+			total += item.Value * float64(item.Count)
+		} else {
+			total += item.Value
+		}
+	}
+	other.Value = total
+	if data.Aggregation == metadata.ChartAggregationAvg && other.Count > 0 {
+		other.Value = total / float64(other.Count)
+	}
+
+	return append(items[:max-1], other)
+}
+
+func (m *ChartMetric) reset() {
+	m.value.Reset()
+	m.count.Reset()
+}
+
+// RefreshChartMetrics refreshes the aggregation charts' gauges every interval seconds, only the master does it,
+// the others clear their gauges so that the series are exported by only one instance.
+func (lgc *Logics) RefreshChartMetrics(ctx context.Context, metric *ChartMetric, interval int) {
+	ticker := time.NewTicker(chartMetricInterval(interval))
+	defer ticker.Stop()
+
+	for {
+		if !lgc.Engine.ServiceManageInterface.IsMaster() {
+			metric.reset()
+		} else {
+			lgc.refreshChartMetrics(ctx, metric)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// chartMetricInterval returns the interval to refresh the chart metrics, the default interval is used if the
+// configured one is not positive.
+func chartMetricInterval(interval int) time.Duration {
+	if interval <= 0 {
+		blog.Warnf("chart metric interval %d is invalid, use the default interval %d", interval,
+			common.OperationDefaultMetricInterval)
+		interval = common.OperationDefaultMetricInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+func (lgc *Logics) refreshChartMetrics(ctx context.Context, metric *ChartMetric) {
+	header := util.CloneHeader(lgc.header)
+	rid := util.GenerateRID()
+	header.Set(common.BKHTTPCCRequestID, rid)
+
+	cond := map[string]interface{}{common.OperationReportType: common.OperationAggregation}
+	charts, err := lgc.CoreAPI.CoreService().Operation().SearchChartsCommon(ctx, header, cond)
+	if err != nil {
+		blog.Errorf("search aggregation charts failed, err: %v, rid: %s", err, rid)
+		return
+	}
+	if !charts.Result {
+		blog.Errorf("search aggregation charts failed, err: %s, rid: %s", charts.ErrMsg, rid)
+		return
+	}
+
+	datas := make(map[uint64]*metadata.ChartAggregationData)
+	for _, chart := range charts.Data.Info {
+		result, err := lgc.CoreAPI.CoreService().Operation().SearchAggregationChartData(ctx, header, chart)
+		if err != nil {
+			blog.Errorf("search aggregation chart %s data failed, err: %v, rid: %s", chart.Name, err, rid)
+			continue
+		}
+		if !result.Result {
+			blog.Errorf("search aggregation chart %s data failed, err: %s, rid: %s", chart.Name, result.ErrMsg, rid)
+			continue
+		}
+		datas[chart.ConfigID] = &result.Data
+	}
+
+	// reset the gauges so that the series of the deleted charts or disappeared groups are removed.
+	metric.reset()
+	for _, chart := range charts.Data.Info {
+		if data, exist := datas[chart.ConfigID]; exist {
+			metric.set(chart, data)
+		}
+	}
+	blog.V(4).Infof("refreshed %d aggregation chart metrics, rid: %s", len(datas), rid)
+}
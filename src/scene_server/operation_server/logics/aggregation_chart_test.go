@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func TestFoldChartItems(t *testing.T) {
+	data := &metadata.ChartAggregationData{
+		GroupBy:     []string{"region", "vendor"},
+		Aggregation: metadata.ChartAggregationAvg,
+		Items: []metadata.ChartAggregationItem{
+			{Keys: []string{"bj", "a"}, Count: 1, Value: 10},
+			{Keys: []string{"sz", "a"}, Count: 2, Value: 40},
+			{Keys: []string{"sh", "b"}, Count: 3, Value: 20},
+			{Keys: []string{"gz", "b"}, Count: 1, Value: 30},
+		},
+	}
+
+	if items := foldChartItems(data, 4); !reflect.DeepEqual(items, data.Items) {
+		t.Errorf("expect items not folded, got %+v", items)
+	}
+
+	want := []metadata.ChartAggregationItem{
+		{Keys: []string{"sz", "a"}, Count: 2, Value: 40},
+		{Keys: []string{"other", "other"}, Count: 5, Value: 20},
+	}
+	if items := foldChartItems(data, 2); !reflect.DeepEqual(items, want) {
+		t.Errorf("foldChartItems() = %+v, want %+v", items, want)
+	}
+	if data.Items[0].Value != 10 {
+		t.Errorf("expect the chart data not changed, got %+v", data.Items)
+	}
+
+	// the sum and count values of the folded groups are added up.
+	data.Aggregation = metadata.ChartAggregationSum
+	want[1].Value = 60
+	if items := foldChartItems(data, 2); !reflect.DeepEqual(items, want) {
+		t.Errorf("foldChartItems() = %+v, want %+v", items, want)
+	}
+}
+
+func TestChartMetricInterval(t *testing.T) {
+	if interval := chartMetricInterval(60); interval != time.Minute {
+		t.Errorf("expect the configured interval used, got %v", interval)
+	}
+
+	// the ticker panics with a non-positive interval, the default interval is used instead.
+	want := time.Duration(common.OperationDefaultMetricInterval) * time.Second
+	for _, invalid := range []int{0, -1} {
+		if interval := chartMetricInterval(invalid); interval != want {
+			t.Errorf("expect the default interval used for %d, got %v", invalid, interval)
+		}
+	}
+}
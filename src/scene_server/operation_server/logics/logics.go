@@ -21,6 +21,7 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 
 	"github.com/robfig/cron"
 )
@@ -130,7 +131,8 @@ func (lgc *Logics) InnerChartData(kit *rest.Kit, chartInfo metadata.ChartConfig)
 	}
 }
 
-func (lgc *Logics) TimerFreshData(ctx context.Context) {
+// TimerFreshData statistics the chart data and takes the snapshots of the topology and instances at the timer spec
+func (lgc *Logics) TimerFreshData(ctx context.Context, snapshot metadata.TakeSnapshotOption) {
 	lgc.CheckTableExist(ctx)
 
 	c := cron.New()
@@ -144,6 +146,10 @@ func (lgc *Logics) TimerFreshData(ctx context.Context) {
 			if _, err := lgc.CoreAPI.CoreService().Operation().TimerFreshData(ctx, lgc.header, opt); err != nil {
 				blog.Error("statistic chart data fail, err: %v", err)
 			}
+
+			header := util.CloneHeader(lgc.header)
+			header.Set(common.BKHTTPCCRequestID, util.GenerateRID())
+			lgc.TakeSnapshot(ctx, header, snapshot)
 		}
 	})
 
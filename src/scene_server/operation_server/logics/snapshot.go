@@ -0,0 +1,329 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// TakeSnapshot takes today's topology snapshots of all the businesses and the instance snapshots of the
+// configured models, then removes the expired snapshots. A failed business or model doesn't stop the others.
+func (lgc *Logics) TakeSnapshot(ctx context.Context, header http.Header, opt metadata.TakeSnapshotOption) {
+	rid := util.GetHTTPCCRequestID(header)
+
+	bizIDs, err := lgc.searchSnapshotBizIDs(ctx, header)
+	if err != nil {
+		blog.Errorf("search businesses to take topo snapshot failed, err: %v, rid: %s", err, rid)
+	}
+	for _, bizID := range bizIDs {
+		topoOpt := metadata.TakeTopoSnapshotOption{BizID: bizID}
+		result, err := lgc.CoreAPI.CoreService().Operation().TakeTopoSnapshot(ctx, header, topoOpt)
+		if err != nil {
+			blog.Errorf("take business %d topo snapshot failed, err: %v, rid: %s", bizID, err, rid)
+			continue
+		}
+		if !result.Result {
+			blog.Errorf("take business %d topo snapshot failed, err: %s, rid: %s", bizID, result.ErrMsg, rid)
+		}
+	}
+
+	for _, objID := range opt.Objects {
+		instOpt := metadata.TakeInstSnapshotOption{ObjID: objID}
+		result, err := lgc.CoreAPI.CoreService().Operation().TakeInstSnapshot(ctx, header, instOpt)
+		if err != nil {
+			blog.Errorf("take model %s instance snapshot failed, err: %v, rid: %s", objID, err, rid)
+			continue
+		}
+		if !result.Result {
+			blog.Errorf("take model %s instance snapshot failed, err: %s, rid: %s", objID, result.ErrMsg, rid)
+		}
+	}
+
+	clearOpt := metadata.ClearSnapshotOption{KeepDays: opt.KeepDays}
+	result, err := lgc.CoreAPI.CoreService().Operation().ClearSnapshot(ctx, header, clearOpt)
+	if err != nil {
+		blog.Errorf("clear snapshots over %d days failed, err: %v, rid: %s", opt.KeepDays, err, rid)
+		return
+	}
+	if !result.Result {
+		blog.Errorf("clear snapshots over %d days failed, err: %s, rid: %s", opt.KeepDays, result.ErrMsg, rid)
+		return
+	}
+	blog.Infof("take snapshots of %d businesses and %d models finished, rid: %s", len(bizIDs), len(opt.Objects), rid)
+}
+
+// searchSnapshotBizIDs searches the ids of all the businesses which are not archived.
+func (lgc *Logics) searchSnapshotBizIDs(ctx context.Context, header http.Header) ([]int64, error) {
+	bizIDs := make([]int64, 0)
+	for start := 0; ; start += common.BKMaxPageSize {
+		cond := &metadata.QueryCondition{
+			Fields: []string{common.BKAppIDField},
+			Page:   metadata.BasePage{Start: start, Limit: common.BKMaxPageSize, Sort: common.BKAppIDField},
+			Condition: mapstr.MapStr{
+				common.BKDataStatusField: mapstr.MapStr{common.BKDBNE: common.DataStatusDisabled},
+			},
+		}
+		result, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(ctx, header, common.BKInnerObjIDApp, cond)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Result {
+			return nil, result.CCError()
+		}
+
+		for _, biz := range result.Data.Info {
+			bizID, err := util.GetInt64ByInterface(biz[common.BKAppIDField])
+			if err != nil {
+				return nil, err
+			}
+			bizIDs = append(bizIDs, bizID)
+		}
+		if len(result.Data.Info) < common.BKMaxPageSize {
+			return bizIDs, nil
+		}
+	}
+}
+
+// SearchTopoSnapshot searches the business's topology on the date.
+func (lgc *Logics) SearchTopoSnapshot(kit *rest.Kit, opt metadata.SearchTopoSnapshotOption) (
+	*metadata.TopoSnapshot, error) {
+
+	result, err := lgc.CoreAPI.CoreService().Operation().SearchTopoSnapshot(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("search business %d topo snapshot on %s failed, err: %v, rid: %s", opt.BizID, opt.Date, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("search business %d topo snapshot on %s failed, err: %s, rid: %s", opt.BizID, opt.Date,
+			result.ErrMsg, kit.Rid)
+		return nil, result.CCError()
+	}
+	return result.Data, nil
+}
+
+// SearchInstSnapshot searches the model's instances on the date.
+func (lgc *Logics) SearchInstSnapshot(kit *rest.Kit, opt metadata.SearchInstSnapshotOption) (
+	*metadata.InstSnapshot, error) {
+
+	result, err := lgc.CoreAPI.CoreService().Operation().SearchInstSnapshot(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("search model %s instance snapshot on %s failed, err: %v, rid: %s", opt.ObjID, opt.Date, err,
+			kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("search model %s instance snapshot on %s failed, err: %s, rid: %s", opt.ObjID, opt.Date,
+			result.ErrMsg, kit.Rid)
+		return nil, result.CCError()
+	}
+	return result.Data, nil
+}
+
+// DiffTopoSnapshot diffs the business's topology between the two dates, an empty snapshot is used if there is
+// no snapshot on or before the date.
+func (lgc *Logics) DiffTopoSnapshot(kit *rest.Kit, opt metadata.DiffTopoSnapshotOption) (
+	*metadata.TopoSnapshotDiff, error) {
+
+	if field, err := opt.Validate(); err != nil {
+		blog.Errorf("diff topo snapshot option is invalid, field: %s, err: %v, rid: %s", field, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	from, err := lgc.SearchTopoSnapshot(kit, metadata.SearchTopoSnapshotOption{BizID: opt.BizID, Date: opt.From})
+	if err != nil {
+		return nil, err
+	}
+	to, err := lgc.SearchTopoSnapshot(kit, metadata.SearchTopoSnapshotOption{BizID: opt.BizID, Date: opt.To})
+	if err != nil {
+		return nil, err
+	}
+
+	if from == nil {
+		from = &metadata.TopoSnapshot{BizID: opt.BizID}
+	}
+	if to == nil {
+		to = &metadata.TopoSnapshot{BizID: opt.BizID}
+	}
+	return diffTopoSnapshot(from, to), nil
+}
+
+// DiffInstSnapshot diffs the model's instances between the two dates, an empty snapshot is used if there is
+// no snapshot on or before the date.
+func (lgc *Logics) DiffInstSnapshot(kit *rest.Kit, opt metadata.DiffInstSnapshotOption) (
+	*metadata.InstSnapshotDiff, error) {
+
+	if field, err := opt.Validate(); err != nil {
+		blog.Errorf("diff instance snapshot option is invalid, field: %s, err: %v, rid: %s", field, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	from, err := lgc.SearchInstSnapshot(kit, metadata.SearchInstSnapshotOption{ObjID: opt.ObjID, Date: opt.From})
+	if err != nil {
+		return nil, err
+	}
+	to, err := lgc.SearchInstSnapshot(kit, metadata.SearchInstSnapshotOption{ObjID: opt.ObjID, Date: opt.To})
+	if err != nil {
+		return nil, err
+	}
+
+	if from == nil {
+		from = &metadata.InstSnapshot{ObjID: opt.ObjID}
+	}
+	if to == nil {
+		to = &metadata.InstSnapshot{ObjID: opt.ObjID}
+	}
+	return diffInstSnapshot(from, to), nil
+}
+
+func diffTopoSnapshot(from, to *metadata.TopoSnapshot) *metadata.TopoSnapshotDiff {
+	diff := &metadata.TopoSnapshotDiff{
+		BizID:   to.BizID,
+		From:    from.Date,
+		To:      to.Date,
+		Sets:    diffSnapshotNodes(from.Sets, to.Sets),
+		Modules: diffSnapshotNodes(from.Modules, to.Modules),
+		Hosts: metadata.SnapshotHostDiff{
+			Added:   make([]metadata.SnapshotHost, 0),
+			Removed: make([]metadata.SnapshotHost, 0),
+			Changed: make([]metadata.SnapshotHostChange, 0),
+		},
+	}
+
+	fromHosts := make(map[int64]metadata.SnapshotHost)
+	for _, host := range from.Hosts {
+		fromHosts[host.ID] = host
+	}
+	toHosts := make(map[int64]struct{})
+	for _, host := range to.Hosts {
+		toHosts[host.ID] = struct{}{}
+		before, exist := fromHosts[host.ID]
+		if !exist {
+			diff.Hosts.Added = append(diff.Hosts.Added, host)
+			continue
+		}
+		if before.InnerIP != host.InnerIP || !reflect.DeepEqual(before.ModuleIDs, host.ModuleIDs) {
+			diff.Hosts.Changed = append(diff.Hosts.Changed, metadata.SnapshotHostChange{Before: before, After: host})
+		}
+	}
+	for _, host := range from.Hosts {
+		if _, exist := toHosts[host.ID]; !exist {
+			diff.Hosts.Removed = append(diff.Hosts.Removed, host)
+		}
+	}
+
+	return diff
+}
+
+func diffSnapshotNodes(from, to []metadata.SnapshotNode) metadata.SnapshotNodeDiff {
+	diff := metadata.SnapshotNodeDiff{
+		Added:   make([]metadata.SnapshotNode, 0),
+		Removed: make([]metadata.SnapshotNode, 0),
+		Changed: make([]metadata.SnapshotNodeChange, 0),
+	}
+
+	fromNodes := make(map[int64]metadata.SnapshotNode)
+	for _, node := range from {
+		fromNodes[node.ID] = node
+	}
+	toNodes := make(map[int64]struct{})
+	for _, node := range to {
+		toNodes[node.ID] = struct{}{}
+		before, exist := fromNodes[node.ID]
+		if !exist {
+			diff.Added = append(diff.Added, node)
+			continue
+		}
+		if before != node {
+			diff.Changed = append(diff.Changed, metadata.SnapshotNodeChange{Before: before, After: node})
+		}
+	}
+	for _, node := range from {
+		if _, exist := toNodes[node.ID]; !exist {
+			diff.Removed = append(diff.Removed, node)
+		}
+	}
+
+	return diff
+}
+
+func diffInstSnapshot(from, to *metadata.InstSnapshot) *metadata.InstSnapshotDiff {
+	diff := &metadata.InstSnapshotDiff{
+		ObjID:   to.ObjID,
+		From:    from.Date,
+		To:      to.Date,
+		Added:   make([]metadata.SnapshotInst, 0),
+		Removed: make([]metadata.SnapshotInst, 0),
+		Changed: make([]metadata.SnapshotInstChange, 0),
+	}
+
+	fromInsts := make(map[int64]metadata.SnapshotInst)
+	for _, inst := range from.Instances {
+		fromInsts[inst.ID] = inst
+	}
+	toInsts := make(map[int64]struct{})
+	for _, inst := range to.Instances {
+		toInsts[inst.ID] = struct{}{}
+		before, exist := fromInsts[inst.ID]
+		if !exist {
+			diff.Added = append(diff.Added, inst)
+			continue
+		}
+		if before.Checksum != inst.Checksum {
+			diff.Changed = append(diff.Changed, metadata.SnapshotInstChange{
+				ID:     inst.ID,
+				Name:   inst.Name,
+				Fields: diffSnapshotInstFields(before.Data, inst.Data),
+			})
+		}
+	}
+	for _, inst := range from.Instances {
+		if _, exist := toInsts[inst.ID]; !exist {
+			diff.Removed = append(diff.Removed, inst)
+		}
+	}
+
+	return diff
+}
+
+// diffSnapshotInstFields returns the attributes changed between the two snapshots of an instance in the order
+// of the attributes' ids.
+func diffSnapshotInstFields(from, to mapstr.MapStr) []metadata.SnapshotFieldChange {
+	fields := make([]string, 0)
+	for field := range from {
+		fields = append(fields, field)
+	}
+	for field := range to {
+		if _, exist := from[field]; !exist {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := make([]metadata.SnapshotFieldChange, 0)
+	for _, field := range fields {
+		if !reflect.DeepEqual(from[field], to[field]) {
+			changes = append(changes, metadata.SnapshotFieldChange{Field: field, Before: from[field], After: to[field]})
+		}
+	}
+	return changes
+}
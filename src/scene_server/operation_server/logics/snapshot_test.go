@@ -0,0 +1,131 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package logics
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestDiffTopoSnapshot(t *testing.T) {
+	from := &metadata.TopoSnapshot{
+		BizID: 2,
+		Date:  "2020-11-01",
+		Sets:  []metadata.SnapshotNode{{ID: 1, Name: "set1", ParentID: 2}, {ID: 2, Name: "set2", ParentID: 2}},
+		Modules: []metadata.SnapshotNode{
+			{ID: 10, Name: "module1", ParentID: 1},
+			{ID: 11, Name: "module2", ParentID: 2},
+		},
+		Hosts: []metadata.SnapshotHost{
+			{ID: 100, InnerIP: "127.0.0.1", ModuleIDs: []int64{10}},
+			{ID: 101, InnerIP: "127.0.0.2", ModuleIDs: []int64{11}},
+		},
+	}
+	to := &metadata.TopoSnapshot{
+		BizID: 2,
+		Date:  "2020-11-05",
+		Sets:  []metadata.SnapshotNode{{ID: 1, Name: "set1-renamed", ParentID: 2}, {ID: 3, Name: "set3", ParentID: 2}},
+		Modules: []metadata.SnapshotNode{
+			{ID: 10, Name: "module1", ParentID: 1},
+			{ID: 12, Name: "module3", ParentID: 3},
+		},
+		Hosts: []metadata.SnapshotHost{
+			{ID: 100, InnerIP: "127.0.0.1", ModuleIDs: []int64{10, 12}},
+			{ID: 102, InnerIP: "127.0.0.3", ModuleIDs: []int64{12}},
+		},
+	}
+
+	want := &metadata.TopoSnapshotDiff{
+		BizID: 2,
+		From:  "2020-11-01",
+		To:    "2020-11-05",
+		Sets: metadata.SnapshotNodeDiff{
+			Added:   []metadata.SnapshotNode{{ID: 3, Name: "set3", ParentID: 2}},
+			Removed: []metadata.SnapshotNode{{ID: 2, Name: "set2", ParentID: 2}},
+			Changed: []metadata.SnapshotNodeChange{{
+				Before: metadata.SnapshotNode{ID: 1, Name: "set1", ParentID: 2},
+				After:  metadata.SnapshotNode{ID: 1, Name: "set1-renamed", ParentID: 2},
+			}},
+		},
+		Modules: metadata.SnapshotNodeDiff{
+			Added:   []metadata.SnapshotNode{{ID: 12, Name: "module3", ParentID: 3}},
+			Removed: []metadata.SnapshotNode{{ID: 11, Name: "module2", ParentID: 2}},
+			Changed: []metadata.SnapshotNodeChange{},
+		},
+		Hosts: metadata.SnapshotHostDiff{
+			Added:   []metadata.SnapshotHost{{ID: 102, InnerIP: "127.0.0.3", ModuleIDs: []int64{12}}},
+			Removed: []metadata.SnapshotHost{{ID: 101, InnerIP: "127.0.0.2", ModuleIDs: []int64{11}}},
+			Changed: []metadata.SnapshotHostChange{{
+				Before: metadata.SnapshotHost{ID: 100, InnerIP: "127.0.0.1", ModuleIDs: []int64{10}},
+				After:  metadata.SnapshotHost{ID: 100, InnerIP: "127.0.0.1", ModuleIDs: []int64{10, 12}},
+			}},
+		},
+	}
+
+	if diff := diffTopoSnapshot(from, to); !reflect.DeepEqual(diff, want) {
+		t.Errorf("diffTopoSnapshot() = %+v, want %+v", diff, want)
+	}
+}
+
+func TestDiffInstSnapshot(t *testing.T) {
+	from := &metadata.InstSnapshot{ObjID: "switch"}
+	to := &metadata.InstSnapshot{
+		ObjID:     "switch",
+		Date:      "2020-11-05",
+		Instances: []metadata.SnapshotInst{{ID: 1, Name: "sw1", Checksum: "a"}},
+	}
+
+	diff := diffInstSnapshot(from, to)
+	if len(diff.Added) != 1 || len(diff.Removed) != 0 || len(diff.Changed) != 0 || diff.From != "" {
+		t.Errorf("diff with empty snapshot got %+v", diff)
+	}
+
+	from = &metadata.InstSnapshot{
+		ObjID: "switch",
+		Date:  "2020-11-05",
+		Instances: []metadata.SnapshotInst{
+			{ID: 1, Name: "sw1", Checksum: "a", Data: mapstr.MapStr{"ports": 48, "vendor": "a"}},
+			{ID: 3, Name: "sw3", Checksum: "d", Data: mapstr.MapStr{"ports": 24}},
+		},
+	}
+	to = &metadata.InstSnapshot{
+		ObjID: "switch",
+		Date:  "2020-11-06",
+		Instances: []metadata.SnapshotInst{
+			{ID: 1, Name: "sw1", Checksum: "b", Data: mapstr.MapStr{"ports": 24, "model": "x"}},
+			{ID: 2, Name: "sw2", Checksum: "c"},
+			{ID: 3, Name: "sw3", Checksum: "d", Data: mapstr.MapStr{"ports": 24}},
+		},
+	}
+	want := &metadata.InstSnapshotDiff{
+		ObjID:   "switch",
+		From:    "2020-11-05",
+		To:      "2020-11-06",
+		Added:   []metadata.SnapshotInst{{ID: 2, Name: "sw2", Checksum: "c"}},
+		Removed: []metadata.SnapshotInst{},
+		Changed: []metadata.SnapshotInstChange{{
+			ID:   1,
+			Name: "sw1",
+			Fields: []metadata.SnapshotFieldChange{
+				{Field: "model", Before: nil, After: "x"},
+				{Field: "ports", Before: 48, After: 24},
+				{Field: "vendor", Before: "a", After: nil},
+			},
+		}},
+	}
+	if diff := diffInstSnapshot(from, to); !reflect.DeepEqual(diff, want) {
+		t.Errorf("diffInstSnapshot() = %+v, want %+v", diff, want)
+	}
+}
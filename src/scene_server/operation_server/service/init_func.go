@@ -17,6 +17,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/operation_server/logics"
 )
 
 func (o *OperationServer) InitFunc() {
@@ -27,5 +28,8 @@ func (o *OperationServer) InitFunc() {
 	}
 
 	srvData := o.newSrvComm(header)
-	go srvData.lgc.TimerFreshData(srvData.ctx)
+	go srvData.lgc.TimerFreshData(srvData.ctx, o.Config.Snapshot)
+
+	chartMetric := logics.NewChartMetric(o.Engine.Metric().Registry())
+	go srvData.lgc.RefreshChartMetrics(srvData.ctx, chartMetric, o.Config.MetricInterval)
 }
@@ -14,6 +14,7 @@ package service
 
 import (
 	"configcenter/src/common"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
@@ -27,11 +28,24 @@ func (o *OperationServer) CreateOperationChart(ctx *rest.Contexts) {
 		return
 	}
 
+	srvData := o.newSrvComm(ctx.Kit.Header)
+	if chartInfo.ReportType == common.OperationAggregation {
+		if err := srvData.lgc.ValidateAggregationChart(ctx.Kit, chartInfo); err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
 	// 图表是否已经存在
 	filterCondition := mapstr.MapStr{}
 	filterCondition[common.BKObjIDField] = chartInfo.ObjID
 	filterCondition[common.OperationReportType] = chartInfo.ReportType
 	filterCondition["field"] = chartInfo.Field
+	// 聚合报表可以对同一模型按不同条件统计，以名称区分
+	if chartInfo.ReportType == common.OperationAggregation {
+		delete(filterCondition, "field")
+		filterCondition["name"] = chartInfo.Name
+	}
 	exist, err := o.CoreAPI.CoreService().Operation().SearchChartCommon(ctx.Kit.Ctx, ctx.Kit.Header, filterCondition)
 	if err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrOperationNewAddStatisticFail, "new add operation chart fail, err: %v, rid: %v", err, ctx.Kit.Rid)
@@ -58,8 +72,8 @@ func (o *OperationServer) CreateOperationChart(ctx *rest.Contexts) {
 		return
 	}()
 
-	// 自定义报表
-	if chartInfo.ReportType == common.OperationCustom {
+	// 自定义报表和聚合报表
+	if chartInfo.ReportType == common.OperationCustom || chartInfo.ReportType == common.OperationAggregation {
 		result, err := o.Engine.CoreAPI.CoreService().Operation().CreateOperationChart(ctx.Kit.Ctx, ctx.Kit.Header, chartInfo)
 		if err != nil {
 			ctx.RespErrorCodeOnly(common.CCErrOperationNewAddStatisticFail, "create operation chart fail, err: %v, rid: %v", err, ctx.Kit.Rid)
@@ -71,7 +85,6 @@ func (o *OperationServer) CreateOperationChart(ctx *rest.Contexts) {
 	}
 
 	// 内置报表
-	srvData := o.newSrvComm(ctx.Kit.Header)
 	configID, err := srvData.lgc.CreateInnerChart(ctx.Kit, chartInfo)
 	if err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrOperationNewAddStatisticFail, "create operation chart fail, err: %v, rid: %v", err, ctx.Kit.Rid)
@@ -111,6 +124,20 @@ func (o *OperationServer) UpdateOperationChart(ctx *rest.Contexts) {
 		return
 	}
 
+	if opt[common.OperationReportType] == common.OperationAggregation {
+		chartInfo := new(metadata.ChartConfig)
+		if err := mapstr.DecodeFromMapStr(chartInfo, opt); err != nil {
+			blog.Errorf("decode aggregation chart failed, chart: %v, err: %v, rid: %s", opt, err, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed))
+			return
+		}
+		srvData := o.newSrvComm(ctx.Kit.Header)
+		if err := srvData.lgc.ValidateAggregationChart(ctx.Kit, chartInfo); err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
 	if _, err := o.Engine.CoreAPI.CoreService().Operation().UpdateOperationChart(ctx.Kit.Ctx, ctx.Kit.Header, opt); err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrOperationUpdateChartFail, "update operation chart fail, err: %v, chartInfo: %v, rid: %v", err, opt, ctx.Kit.Rid)
 		return
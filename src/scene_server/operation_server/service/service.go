@@ -112,6 +112,12 @@ func (o *OperationServer) newOperationService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/chart/data", Handler: o.SearchChartData})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/operation/chart/position", Handler: o.UpdateChartPosition})
 
+	// point-in-time snapshot
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/topo/snapshot", Handler: o.SearchTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/topo/snapshot/diff", Handler: o.DiffTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/inst/snapshot", Handler: o.SearchInstSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/inst/snapshot/diff", Handler: o.DiffInstSnapshot})
+
 	utility.AddToRestfulWebService(web)
 }
 
@@ -167,6 +173,55 @@ func (o *OperationServer) OnOperationConfigUpdate(previous, current cc.ProcessCo
 		blog.Errorf("parse timer config failed, err: %v", err)
 		return
 	}
+	o.Config.MetricInterval = o.ParseMetricIntervalFromKV("operationServer.metric")
+	o.Config.Snapshot = o.ParseSnapshotConfigFromKV("operationServer.snapshot")
+}
+
+// ParseSnapshotConfigFromKV parse the models to take instance snapshots and the days to keep the snapshots,
+// the business topology snapshots are always taken.
+func (o *OperationServer) ParseSnapshotConfigFromKV(prefix string) metadata.TakeSnapshotOption {
+	opt := metadata.TakeSnapshotOption{
+		Objects:  make([]string, 0),
+		KeepDays: common.OperationDefaultSnapshotKeepDays,
+	}
+
+	if cc.IsExist(prefix + ".objects") {
+		objects, err := cc.String(prefix + ".objects")
+		if err != nil {
+			blog.Errorf("parse snapshot objects config failed, err: %v", err)
+		}
+		for _, objID := range strings.Split(objects, ",") {
+			if objID = strings.TrimSpace(objID); objID != "" {
+				opt.Objects = append(opt.Objects, objID)
+			}
+		}
+	}
+
+	if cc.IsExist(prefix + ".keepDays") {
+		keepDays, err := cc.Int(prefix + ".keepDays")
+		if err != nil || keepDays <= 0 {
+			blog.Errorf("parse snapshot keep days config failed, set it to default value: %d, err: %v",
+				common.OperationDefaultSnapshotKeepDays, err)
+		} else {
+			opt.KeepDays = keepDays
+		}
+	}
+	return opt
+}
+
+// ParseMetricIntervalFromKV parse the interval to refresh the aggregation chart metrics, use the default one if
+// it's not configured or invalid.
+func (o *OperationServer) ParseMetricIntervalFromKV(prefix string) int {
+	if !cc.IsExist(prefix + ".interval") {
+		return common.OperationDefaultMetricInterval
+	}
+	interval, err := cc.Int(prefix + ".interval")
+	if err != nil || interval <= 0 {
+		blog.Errorf("parse metric interval config failed, set it to default value: %d, err: %v",
+			common.OperationDefaultMetricInterval, err)
+		return common.OperationDefaultMetricInterval
+	}
+	return interval
 }
 
 func (o *OperationServer) ParseTimerConfigFromKV(prefix string, configMap map[string]string) (string, error) {
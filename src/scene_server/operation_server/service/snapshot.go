@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// SearchTopoSnapshot searches what the business's topology looked like on the date
+func (o *OperationServer) SearchTopoSnapshot(ctx *rest.Contexts) {
+	opt := metadata.SearchTopoSnapshotOption{}
+	if err := ctx.DecodeInto(&opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field, err := opt.Validate(); err != nil {
+		blog.Errorf("search topo snapshot option is invalid, field: %s, err: %v, rid: %s", field, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	ctx.SetReadPreference(common.SecondaryPreferredMode)
+	srvData := o.newSrvComm(ctx.Kit.Header)
+	snapshot, err := srvData.lgc.SearchTopoSnapshot(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(snapshot)
+}
+
+// DiffTopoSnapshot diffs the business's topology between two dates
+func (o *OperationServer) DiffTopoSnapshot(ctx *rest.Contexts) {
+	opt := metadata.DiffTopoSnapshotOption{}
+	if err := ctx.DecodeInto(&opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.SetReadPreference(common.SecondaryPreferredMode)
+	srvData := o.newSrvComm(ctx.Kit.Header)
+	diff, err := srvData.lgc.DiffTopoSnapshot(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(diff)
+}
+
+// SearchInstSnapshot searches what the model's instances looked like on the date
+func (o *OperationServer) SearchInstSnapshot(ctx *rest.Contexts) {
+	opt := metadata.SearchInstSnapshotOption{}
+	if err := ctx.DecodeInto(&opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field, err := opt.Validate(); err != nil {
+		blog.Errorf("search instance snapshot option is invalid, field: %s, err: %v, rid: %s", field, err,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	ctx.SetReadPreference(common.SecondaryPreferredMode)
+	srvData := o.newSrvComm(ctx.Kit.Header)
+	snapshot, err := srvData.lgc.SearchInstSnapshot(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(snapshot)
+}
+
+// DiffInstSnapshot diffs the model's instances between two dates
+func (o *OperationServer) DiffInstSnapshot(ctx *rest.Contexts) {
+	opt := metadata.DiffInstSnapshotOption{}
+	if err := ctx.DecodeInto(&opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.SetReadPreference(common.SecondaryPreferredMode)
+	srvData := o.newSrvComm(ctx.Kit.Header)
+	diff, err := srvData.lgc.DiffInstSnapshot(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(diff)
+}
@@ -0,0 +1,228 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// FindProcessInstancesByPort find the processes which listen on the port in the business
+func (ps *ProcServer) FindProcessInstancesByPort(ctx *rest.Contexts) {
+	option := metadata.FindProcessByPortOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if field, err := option.Validate(); err != nil {
+		blog.Errorf("find process instances by port failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	listenItems := make(map[int64][]metadata.ProcessBindItem)
+	processIDs := make([]int64, 0)
+	query := &metadata.QueryCondition{
+		Fields:    []string{common.BKProcessIDField, common.BKProcessNameField, common.BKProcBindInfo},
+		Condition: mapstr.MapStr{common.BKAppIDField: option.BizID},
+		Page:      metadata.BasePage{Limit: common.BKMaxPageSize, Sort: common.BKProcessIDField},
+	}
+	for {
+		result, err := ps.CoreAPI.CoreService().Instance().ReadInstance(ctx.Kit.Ctx, ctx.Kit.Header,
+			common.BKInnerObjIDProc, query)
+		if err != nil {
+			blog.Errorf("read process instances failed, query: %+v, err: %v, rid: %s", query, err, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed))
+			return
+		}
+		if err := result.CCError(); err != nil {
+			blog.Errorf("read process instances failed, query: %+v, err: %v, rid: %s", query, err, ctx.Kit.Rid)
+			ctx.RespAutoError(err)
+			return
+		}
+
+		for _, data := range result.Data.Info {
+			process := metadata.Process{}
+			if err := data.MarshalJSONInto(&process); err != nil {
+				blog.Errorf("unmarshal process %+v failed, err: %v, rid: %s", data, err, ctx.Kit.Rid)
+				ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed))
+				return
+			}
+
+			for _, item := range process.GetBindItems() {
+				if !option.Match(item) {
+					continue
+				}
+				if _, exists := listenItems[process.ProcessID]; !exists {
+					processIDs = append(processIDs, process.ProcessID)
+				}
+				listenItems[process.ProcessID] = append(listenItems[process.ProcessID], item)
+			}
+		}
+
+		if len(result.Data.Info) < common.BKMaxPageSize {
+			break
+		}
+		query.Page.Start += common.BKMaxPageSize
+	}
+
+	listenInfos := make([]metadata.ProcessListenInfo, 0)
+	if len(processIDs) == 0 {
+		ctx.RespEntity(listenInfos)
+		return
+	}
+
+	relationOption := &metadata.ListProcessInstanceRelationOption{
+		BusinessID: option.BizID,
+		ProcessIDs: processIDs,
+		Page:       metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	relations, err := ps.CoreAPI.CoreService().Process().ListProcessInstanceRelation(ctx.Kit.Ctx, ctx.Kit.Header,
+		relationOption)
+	if err != nil {
+		blog.Errorf("list process relations failed, option: %+v, err: %v, rid: %s", relationOption, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	for _, relation := range relations.Info {
+		for _, item := range listenItems[relation.ProcessID] {
+			listenInfos = append(listenInfos, metadata.ProcessListenInfo{
+				BizID:             option.BizID,
+				HostID:            relation.HostID,
+				ServiceInstanceID: relation.ServiceInstanceID,
+				ProcessID:         item.ProcessID,
+				ProcessName:       item.ProcessName,
+				IP:                item.IP,
+				Protocol:          item.Protocol,
+				Ports:             item.Ports,
+			})
+		}
+	}
+	ctx.RespEntity(listenInfos)
+}
+
+// validateHostsBindInfoConflict checks that the pending processes do not listen on the same address with each
+// other or with the other processes on their hosts, pendingProcesses is the map of host id to the processes to be
+// written on the host, they replace the existing processes with the same id, and the removed processes are ignored.
+// it must be called before the processes are written so that nothing is left if the transaction is not enabled.
+func (ps *ProcServer) validateHostsBindInfoConflict(kit *rest.Kit, bizID int64,
+	pendingProcesses map[int64][]metadata.Process, removedProcessIDs []int64) errors.CCErrorCoder {
+
+	hostIDs := make([]int64, 0)
+	for hostID, processes := range pendingProcesses {
+		if len(processes) > 0 {
+			hostIDs = append(hostIDs, hostID)
+		}
+	}
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	option := &metadata.ListProcessInstanceRelationOption{
+		BusinessID: bizID,
+		HostIDs:    hostIDs,
+		Page:       metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	relations, err := ps.CoreAPI.CoreService().Process().ListProcessInstanceRelation(kit.Ctx, kit.Header, option)
+	if err != nil {
+		blog.Errorf("list process relations failed, option: %+v, err: %v, rid: %s", option, err, kit.Rid)
+		return err
+	}
+
+	processIDs := make([]int64, 0)
+	process2HostMap := make(map[int64]int64)
+	for _, relation := range relations.Info {
+		if util.InArray(relation.ProcessID, removedProcessIDs) {
+			continue
+		}
+		processIDs = append(processIDs, relation.ProcessID)
+		process2HostMap[relation.ProcessID] = relation.HostID
+	}
+
+	hostProcessMap := make(map[int64][]metadata.Process)
+	if len(processIDs) > 0 {
+		processes, err := ps.Logic.ListProcessInstanceWithIDs(kit, processIDs)
+		if err != nil {
+			blog.Errorf("list processes failed, ids: %v, err: %v, rid: %s", processIDs, err, kit.Rid)
+			return err
+		}
+		for _, process := range processes {
+			hostID := process2HostMap[process.ProcessID]
+			hostProcessMap[hostID] = append(hostProcessMap[hostID], process)
+		}
+	}
+
+	conflictMsgs := make([]string, 0)
+	for _, hostID := range hostIDs {
+		conflicts := metadata.FindPendingProcessBindConflicts(hostID, hostProcessMap[hostID],
+			pendingProcesses[hostID])
+		for _, conflict := range conflicts {
+			conflictMsgs = append(conflictMsgs, conflict.String())
+		}
+	}
+	if len(conflictMsgs) > 0 {
+		blog.Errorf("process bind info conflict, conflicts: %v, rid: %s", conflictMsgs, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrProcBindInfoConflict, strings.Join(conflictMsgs, "; "))
+	}
+	return nil
+}
+
+// pendingBindInfoProcess returns the process with the bind info of the process data to be written, the data
+// which does not change the bind info can not cause new conflicts so that false is returned for it.
+func pendingBindInfoProcess(processID int64, processData map[string]interface{}) (metadata.Process, bool, error) {
+	bindInfo, exists := processData[common.BKProcBindInfo]
+	if !exists {
+		return metadata.Process{}, false, nil
+	}
+
+	process := metadata.Process{}
+	if err := mapstr.DecodeFromMapStr(&process, mapstr.MapStr{common.BKProcBindInfo: bindInfo}); err != nil {
+		return metadata.Process{}, false, err
+	}
+	process.ProcessID = processID
+	return process, true, nil
+}
+
+// validateRawProcessBindInfo checks the bind info of the process data before it is written,
+// and returns the decoded process.
+func (ps *ProcServer) validateRawProcessBindInfo(kit *rest.Kit, processData map[string]interface{}) (
+	*metadata.Process, errors.CCErrorCoder) {
+
+	process := metadata.Process{}
+	if err := mapstr.DecodeFromMapStr(&process, processData); err != nil {
+		blog.Errorf("decode process data %+v failed, err: %v, rid: %s", processData, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+	if err := ps.validateProcessBindInfo(kit, &process); err != nil {
+		return nil, err
+	}
+	return &process, nil
+}
+
+// validateProcessBindInfo checks the ip, port and protocol of the process bind info.
+func (ps *ProcServer) validateProcessBindInfo(kit *rest.Kit, process *metadata.Process) errors.CCErrorCoder {
+	if field, err := process.ValidateBindInfo(); err != nil {
+		blog.Errorf("process %d bind info is invalid, err: %v, rid: %s", process.ProcessID, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+	return nil
+}
@@ -37,10 +37,14 @@ func (ps *ProcServer) CreateProcessInstances(ctx *rest.Contexts) {
 
 	var processIDs []int64
 	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ps.EnableTxn, ctx.Kit.Header, func() error {
-		var err error
+		var err errors.CCErrorCoder
 		processIDs, err = ps.createProcessInstances(ctx, input)
 		if err != nil {
 			blog.Errorf("create process instance failed, serviceInstanceID: %d, input: %+v, err: %+v", input.ServiceInstanceID, input, err)
+			switch err.GetCode() {
+			case common.CCErrProcBindInfoConflict, common.CCErrCommParamsInvalid:
+				return err
+			}
 			return ctx.Kit.CCError.CCError(common.CCErrProcCreateProcessFailed)
 		}
 		return nil
@@ -68,6 +72,21 @@ func (ps *ProcServer) createProcessInstances(ctx *rest.Contexts, input *metadata
 		return nil, ctx.Kit.CCError.CCError(common.CCErrProcEditProcessInstanceCreateByTemplateForbidden)
 	}
 
+	// check the bind info before any process is written
+	pendingProcesses := make([]metadata.Process, 0)
+	for _, item := range input.Processes {
+		process, err := ps.validateRawProcessBindInfo(ctx.Kit, item.ProcessData)
+		if err != nil {
+			return nil, err
+		}
+		process.ProcessID = 0
+		pendingProcesses = append(pendingProcesses, *process)
+	}
+	pendingHostProcesses := map[int64][]metadata.Process{serviceInstance.HostID: pendingProcesses}
+	if err := ps.validateHostsBindInfoConflict(ctx.Kit, input.BizID, pendingHostProcesses, nil); err != nil {
+		return nil, err
+	}
+
 	processIDs := make([]int64, 0)
 	for _, item := range input.Processes {
 		now := time.Now()
@@ -212,6 +231,10 @@ func (ps *ProcServer) updateProcessInstances(ctx *rest.Contexts, input metadata.
 		}
 		input.Processes = append(input.Processes, process)
 
+		if err := ps.validateProcessBindInfo(ctx.Kit, &process); err != nil {
+			return nil, err
+		}
+
 		if process.ProcessID == 0 {
 			blog.Errorf("update process instance failed, process_id invalid, rid: %s", rid)
 			return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKProcessIDField)
@@ -279,6 +302,8 @@ func (ps *ProcServer) updateProcessInstances(ctx *rest.Contexts, input metadata.
 	}
 
 	var processTemplate *metadata.ProcessTemplate
+	processDatas := make([]map[string]interface{}, len(input.Processes))
+	pendingProcesses := make(map[int64][]metadata.Process)
 	for idx, process := range input.Processes {
 		// 单独提取需要被重置成 nil 的字段
 		raw := input.Raw[idx]
@@ -329,8 +354,24 @@ func (ps *ProcServer) updateProcessInstances(ctx *rest.Contexts, input metadata.
 		for _, field := range clearFields {
 			processData[field] = nil
 		}
+		processDatas[idx] = processData
 
-		if err := ps.Logic.UpdateProcessInstance(ctx.Kit, process.ProcessID, processData); err != nil {
+		pending, changed, err := pendingBindInfoProcess(process.ProcessID, processData)
+		if err != nil {
+			blog.Errorf("decode process %d bind info failed, data: %+v, err: %v, rid: %s", process.ProcessID, processData, err, rid)
+			return nil, ctx.Kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+		}
+		if changed {
+			pendingProcesses[relation.HostID] = append(pendingProcesses[relation.HostID], pending)
+		}
+	}
+
+	if err := ps.validateHostsBindInfoConflict(ctx.Kit, bizID, pendingProcesses, nil); err != nil {
+		return nil, err
+	}
+
+	for idx, process := range input.Processes {
+		if err := ps.Logic.UpdateProcessInstance(ctx.Kit, process.ProcessID, processDatas[idx]); err != nil {
 			blog.Errorf("update process failed, processID: %d, process: %+v, err: %v, rid: %s", process.ProcessID, process, err, rid)
 			return nil, err
 		}
@@ -83,6 +83,15 @@ func (ps *ProcServer) createServiceInstances(ctx *rest.Contexts, input metadata.
 		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCoreServiceHasModuleNotBelongBusiness, module.ModuleID, bizID)
 	}
 
+	// the processes created by service template may conflict with the existing processes on the hosts,
+	// check them before the service instances are created
+	if module.ServiceTemplateID != 0 {
+		if err := ps.validateTemplateProcessesBindInfo(ctx.Kit, bizID, module.ServiceTemplateID, hostIDs,
+			input.Instances); err != nil {
+			return nil, err
+		}
+	}
+
 	serviceInstanceIDs := make([]int64, 0)
 	for _, inst := range input.Instances {
 		instance := &metadata.ServiceInstance{
@@ -1089,6 +1098,55 @@ func (ps *ProcServer) CalculateModuleAttributeDifference(ctx context.Context, he
 	return changedAttributes, nil
 }
 
+// validateTemplateProcessesBindInfo checks the bind info of the processes which will be created by the
+// service template for the service instances, the process data of the instances overwrites the template's.
+func (ps *ProcServer) validateTemplateProcessesBindInfo(kit *rest.Kit, bizID, serviceTemplateID int64,
+	hostIDs []int64, instances []metadata.CreateServiceInstanceDetail) errors.CCErrorCoder {
+
+	option := &metadata.ListProcessTemplatesOption{
+		BusinessID:         bizID,
+		ServiceTemplateIDs: []int64{serviceTemplateID},
+		Page:               metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	templates, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(kit.Ctx, kit.Header, option)
+	if err != nil {
+		blog.Errorf("list process templates failed, option: %+v, err: %v, rid: %s", option, err, kit.Rid)
+		return err
+	}
+	if len(templates.Info) == 0 {
+		return nil
+	}
+
+	hostMap, err := ps.getHostIPMapByID(kit, hostIDs)
+	if err != nil {
+		return err
+	}
+
+	pendingProcesses := make(map[int64][]metadata.Process)
+	for _, inst := range instances {
+		processDataMap := make(map[int64]map[string]interface{})
+		for _, item := range inst.Processes {
+			processDataMap[item.ProcessTemplateID] = item.ProcessData
+		}
+
+		for idx := range templates.Info {
+			template := &templates.Info[idx]
+			process := template.NewProcess(bizID, kit.SupplierAccount, hostMap[inst.HostID])
+			if processData, exists := processDataMap[template.ID]; exists {
+				input := new(metadata.Process)
+				if err := mapstr.DecodeFromMapStr(input, processData); err != nil {
+					blog.Errorf("decode process data %+v failed, err: %v, rid: %s", processData, err, kit.Rid)
+					return kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+				}
+				process.BindInfo = template.Property.BindInfo.ExtractInstanceUpdateData(input, hostMap[inst.HostID])
+			}
+			pendingProcesses[inst.HostID] = append(pendingProcesses[inst.HostID], *process)
+		}
+	}
+
+	return ps.validateHostsBindInfoConflict(kit, bizID, pendingProcesses, nil)
+}
+
 // SyncServiceInstanceByTemplate sync the service instance with it's bounded service template.
 // It keeps the processes exactly same with the process template in the service template,
 // which means the number of process is same, and the process instance's info is also exactly same.
@@ -1249,6 +1307,8 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 	// step 6:
 	// compare the difference between process instance and process template from one service instance to another.
 	removedProcessIDs := make([]int64, 0)
+	updatedProcesses := make(map[int64]mapstr.MapStr)
+	pendingProcesses := make(map[int64][]metadata.Process)
 	for serviceInstanceID, processes := range serviceInstance2ProcessMap {
 		for _, process := range processes {
 			processTemplateID := processInstanceWithTemplateMap[process.ProcessID]
@@ -1266,30 +1326,25 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 			if !changed {
 				continue
 			}
-			if err := ps.Logic.UpdateProcessInstance(ctx.Kit, process.ProcessID, proc); err != nil {
-				blog.Errorf("syncServiceInstanceByTemplate failed, UpdateProcessInstance failed, processID:%d, err: %s, rid:%s", process.ProcessID, err.Error(), rid)
-				return err
+			updatedProcesses[process.ProcessID] = proc
+
+			pending, bindInfoChanged, err := pendingBindInfoProcess(process.ProcessID, proc)
+			if err != nil {
+				blog.Errorf("decode process %d bind info failed, data: %+v, err: %v, rid: %s", process.ProcessID, proc, err, rid)
+				return ctx.Kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+			}
+			if bindInfoChanged {
+				hostID := serviceInstance2HostMap[serviceInstanceID]
+				pendingProcesses[hostID] = append(pendingProcesses[hostID], pending)
 			}
-		}
-	}
-	// remove processes whose template has been removed
-	if len(removedProcessIDs) != 0 {
-		if err := ps.Logic.DeleteProcessInstanceBatch(ctx.Kit, removedProcessIDs); err != nil {
-			blog.Errorf("syncServiceInstanceByTemplate failed, DeleteProcessInstance failed, processID: %d, err: %s, rid: %s", removedProcessIDs, err.Error(), rid)
-			return err
-		}
-		// remove process instance relation now.
-		deleteOption := metadata.DeleteProcessInstanceRelationOption{}
-		deleteOption.ProcessIDs = removedProcessIDs
-		if err := ps.CoreAPI.CoreService().Process().DeleteProcessInstanceRelation(ctx.Kit.Ctx, ctx.Kit.Header, deleteOption); err != nil {
-			blog.ErrorJSON("syncServiceInstanceByTemplate failed, DeleteProcessInstanceRelation failed, option: %s, err: %s, rid: %s", deleteOption, err.Error(), rid)
-			return err
 		}
 	}
 
 	// step 7:
 	// check if a new process is added to the service template.
 	// if true, then create a new process instance for every service instance with process template's default value.
+	newProcesses := make([]*metadata.ProcessInstanceRelation, 0)
+	newProcessDatas := make([]map[string]interface{}, 0)
 	for processTemplateID, processTemplate := range processTemplateMap {
 		for svcID, templates := range serviceInstanceWithTemplateMap {
 			if processTemplate.ServiceTemplateID != serviceInstanceTemplateMap[svcID] {
@@ -1301,28 +1356,60 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 
 			// we can not find this process template in all this service instance,
 			// which means that a new process template need to be added to this service instance
-			newProcess := processTemplate.NewProcess(bizID, ctx.Kit.SupplierAccount, hostMap[serviceInstance2HostMap[svcID]])
-			processData := newProcess.Map()
-			newProcessID, err := ps.Logic.CreateProcessInstance(ctx.Kit, processData)
-			if err != nil {
-				blog.ErrorJSON("syncServiceInstanceByTemplate failed, CreateProcessInstance failed, option: %s, err: %s, rid: %s", processData, err.Error(), rid)
-				return err
-			}
-
-			relation := &metadata.ProcessInstanceRelation{
+			hostID := serviceInstance2HostMap[svcID]
+			newProcess := processTemplate.NewProcess(bizID, ctx.Kit.SupplierAccount, hostMap[hostID])
+			pendingProcesses[hostID] = append(pendingProcesses[hostID], *newProcess)
+			newProcessDatas = append(newProcessDatas, newProcess.Map())
+			newProcesses = append(newProcesses, &metadata.ProcessInstanceRelation{
 				BizID:             bizID,
-				ProcessID:         newProcessID,
 				ServiceInstanceID: svcID,
 				ProcessTemplateID: processTemplateID,
-				HostID:            serviceInstance2HostMap[svcID],
-			}
+				HostID:            hostID,
+			})
+		}
+	}
 
-			// create service instance relation, so that the process instance created upper can be related to this service instance.
-			_, err = ps.CoreAPI.CoreService().Process().CreateProcessInstanceRelation(ctx.Kit.Ctx, ctx.Kit.Header, relation)
-			if err != nil {
-				blog.ErrorJSON("syncServiceInstanceByTemplate failed, CreateProcessInstanceRelation failed, relation: %s, err: %s, rid: %s", relation, err.Error(), rid)
-				return err
-			}
+	// make sure the synchronized processes do not conflict with the other processes on the hosts
+	if err := ps.validateHostsBindInfoConflict(ctx.Kit, bizID, pendingProcesses, removedProcessIDs); err != nil {
+		return err
+	}
+
+	for processID, proc := range updatedProcesses {
+		if err := ps.Logic.UpdateProcessInstance(ctx.Kit, processID, proc); err != nil {
+			blog.Errorf("syncServiceInstanceByTemplate failed, UpdateProcessInstance failed, processID:%d, err: %s, rid:%s", processID, err.Error(), rid)
+			return err
+		}
+	}
+
+	// remove processes whose template has been removed
+	if len(removedProcessIDs) != 0 {
+		if err := ps.Logic.DeleteProcessInstanceBatch(ctx.Kit, removedProcessIDs); err != nil {
+			blog.Errorf("syncServiceInstanceByTemplate failed, DeleteProcessInstance failed, processID: %d, err: %s, rid: %s", removedProcessIDs, err.Error(), rid)
+			return err
+		}
+		// remove process instance relation now.
+		deleteOption := metadata.DeleteProcessInstanceRelationOption{}
+		deleteOption.ProcessIDs = removedProcessIDs
+		if err := ps.CoreAPI.CoreService().Process().DeleteProcessInstanceRelation(ctx.Kit.Ctx, ctx.Kit.Header, deleteOption); err != nil {
+			blog.ErrorJSON("syncServiceInstanceByTemplate failed, DeleteProcessInstanceRelation failed, option: %s, err: %s, rid: %s", deleteOption, err.Error(), rid)
+			return err
+		}
+	}
+
+	for idx, processData := range newProcessDatas {
+		newProcessID, err := ps.Logic.CreateProcessInstance(ctx.Kit, processData)
+		if err != nil {
+			blog.ErrorJSON("syncServiceInstanceByTemplate failed, CreateProcessInstance failed, option: %s, err: %s, rid: %s", processData, err.Error(), rid)
+			return err
+		}
+
+		// create service instance relation, so that the process instance created upper can be related to this service instance.
+		relation := newProcesses[idx]
+		relation.ProcessID = newProcessID
+		_, err = ps.CoreAPI.CoreService().Process().CreateProcessInstanceRelation(ctx.Kit.Ctx, ctx.Kit.Header, relation)
+		if err != nil {
+			blog.ErrorJSON("syncServiceInstanceByTemplate failed, CreateProcessInstanceRelation failed, relation: %s, err: %s, rid: %s", relation, err.Error(), rid)
+			return err
 		}
 	}
 
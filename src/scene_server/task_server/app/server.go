@@ -100,6 +100,12 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 
 	queue := service.NewQueue(taskSrv.taskQueue)
 	queue.Start()
+
+	jobConcurrency, _ := cc.Int("taskServer.job.concurrency")
+	jobRunner := service.NewJobRunner(jobConcurrency)
+	jobRunner.Start(ctx)
+	jobScheduler := service.NewJobScheduler()
+	jobScheduler.Start(ctx)
 	select {
 	case <-ctx.Done():
 	}
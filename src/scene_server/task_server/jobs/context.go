@@ -0,0 +1,108 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+// Context 任务执行的上下文，任务被取消或者执行超时时Context被取消，任务执行的函数需要及时退出
+type Context struct {
+	context.Context
+	// Job 正在执行的任务，Attempts为包含本次执行在内的执行次数
+	Job *metadata.Job
+	// Header 创建任务的用户的请求头，带有任务id
+	Header http.Header
+	Rid    string
+	Engine *backbone.Engine
+	db     dal.RDB
+}
+
+// Kit 生成以创建任务的用户身份调用其他服务的kit
+func (c *Context) Kit() *rest.Kit {
+	return &rest.Kit{
+		Rid:             c.Rid,
+		Header:          c.Header,
+		Ctx:             c.Context,
+		CCError:         c.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(c.Header)),
+		User:            util.GetUser(c.Header),
+		SupplierAccount: util.GetOwnerID(c.Header),
+	}
+}
+
+// SetProgress 更新任务的进度，progress为0到1之间的小数，message为空时不更新当前步骤的描述
+func (c *Context) SetProgress(progress float64, message string) error {
+	return UpdateProgress(c.Context, c.db, c.Job.JobID, &metadata.ReportJobProgressOption{
+		Progress: &progress,
+		Message:  message,
+	})
+}
+
+// Logf 记录任务的执行日志，任务只保留最新的日志
+func (c *Context) Logf(level metadata.JobLogLevel, format string, args ...interface{}) error {
+	return UpdateProgress(c.Context, c.db, c.Job.JobID, &metadata.ReportJobProgressOption{
+		Logs: []metadata.JobLog{{Time: time.Now(), Level: level, Message: fmt.Sprintf(format, args...)}},
+	})
+}
+
+// UpdateProgress 更新执行中的任务的进度、当前步骤的描述，并追加执行日志
+func UpdateProgress(ctx context.Context, db dal.RDB, jobID string, opt *metadata.ReportJobProgressOption) error {
+	doc := mapstr.MapStr{common.LastTimeField: time.Now()}
+	if opt.Progress != nil {
+		if *opt.Progress < 0 || *opt.Progress > 1 {
+			return fmt.Errorf("job progress %v is invalid, it should be between 0 and 1", *opt.Progress)
+		}
+		doc["progress"] = *opt.Progress
+	}
+	if opt.Message != "" {
+		doc["message"] = opt.Message
+	}
+	updates := []types.ModeUpdate{{Op: "set", Doc: doc}}
+	if len(opt.Logs) > 0 {
+		for idx := range opt.Logs {
+			if opt.Logs[idx].Time.IsZero() {
+				opt.Logs[idx].Time = time.Now()
+			}
+			if opt.Logs[idx].Level == "" {
+				opt.Logs[idx].Level = metadata.JobLogLevelInfo
+			}
+		}
+		updates = append(updates, types.ModeUpdate{Op: "push", Doc: mapstr.MapStr{
+			metadata.JobLogsField: mapstr.MapStr{"$each": opt.Logs, "$slice": -metadata.JobMaxLogCount},
+		}})
+	}
+
+	filter := mapstr.MapStr{
+		metadata.JobIDField:     jobID,
+		metadata.JobStatusField: mapstr.MapStr{common.BKDBIN: activeStatus},
+	}
+	if err := db.Table(common.BKTableNameAPIJob).UpdateMultiModel(ctx, filter, updates...); err != nil {
+		blog.Errorf("update job %s progress failed, err: %v, rid: %v", jobID, err,
+			ctx.Value(common.ContextRequestIDField))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobs
+
+import (
+	"strconv"
+	"strings"
+
+	taskUtil "configcenter/src/apimachinery/taskserver/util"
+	"configcenter/src/common"
+)
+
+// RegisterHTTP 注册由其他服务的接口执行的任务类型，任务的参数作为请求体发送给addr对应服务的path接口，
+// path中的{bk_biz_id}替换为任务的业务id，请求头中带有任务id，接口可以通过任务服务的上报进度接口上报进度、
+// 记录日志以及检查任务是否被取消
+func RegisterHTTP(jobType JobType, addr func() ([]string, error), path string) {
+	taskUtil.UpdateTaskServerConfigServ(jobType.Name, addr)
+	jobType.Handler = httpHandler(jobType.Name, path)
+	Register(jobType)
+}
+
+func httpHandler(name, path string) Handler {
+	return func(ctx *Context) (interface{}, error) {
+		subPath := strings.Replace(path, "{"+common.BKAppIDField+"}", strconv.FormatInt(ctx.Job.BizID, 10), -1)
+		resp, err := ctx.Engine.CoreAPI.TaskServer().Queue(name).Post(ctx, ctx.Header, subPath, ctx.Job.Params)
+		if err != nil {
+			return nil, err
+		}
+		if err := resp.CCError(); err != nil {
+			return nil, err
+		}
+		return resp.Data, nil
+	}
+}
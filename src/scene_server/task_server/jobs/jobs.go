@@ -0,0 +1,174 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/rs/xid"
+)
+
+const (
+	// defaultRetryInterval 第一次重试前默认等待的时间
+	defaultRetryInterval = 10 * time.Second
+	// defaultMaxRetryInterval 重试前默认最多等待的时间
+	defaultMaxRetryInterval = 10 * time.Minute
+)
+
+// Handler 执行任务的函数，返回的结果保存在任务的result中，返回错误时按照任务类型的配置重试
+type Handler func(ctx *Context) (interface{}, error)
+
+// JobType 任务类型，决定任务如何执行以及失败后如何重试
+type JobType struct {
+	// Name 任务类型名称，全局唯一
+	Name string
+	// Handler 执行任务的函数
+	Handler Handler
+	// MaxRetry 任务执行失败后的最大重试次数，创建任务时可以覆盖
+	MaxRetry int64
+	// RetryInterval 第一次重试前等待的时间，之后每次重试等待的时间翻倍，默认为10秒
+	RetryInterval time.Duration
+	// MaxRetryInterval 重试前最多等待的时间，默认为10分钟
+	MaxRetryInterval time.Duration
+	// Timeout 单次执行的超时时间，超时后取消执行并按照失败重试，为0时不超时
+	Timeout time.Duration
+}
+
+// backoff 任务第attempts次执行失败后，重试前需要等待的时间
+func (t JobType) backoff(attempts int64) time.Duration {
+	interval, maxInterval := t.RetryInterval, t.MaxRetryInterval
+	if interval <= 0 {
+		interval = defaultRetryInterval
+	}
+	if maxInterval <= 0 {
+		maxInterval = defaultMaxRetryInterval
+	}
+
+	for i := int64(1); i < attempts && interval < maxInterval; i++ {
+		interval *= 2
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	return interval
+}
+
+var (
+	typeLock sync.RWMutex
+	jobTypes = make(map[string]JobType)
+)
+
+// Register 注册任务类型，任务类型不合法或者名称重复时panic，需要在任务服务启动前注册
+func Register(jobType JobType) {
+	if jobType.Name == "" || jobType.Handler == nil {
+		panic(fmt.Sprintf("job type %s is invalid, name and handler must be set", jobType.Name))
+	}
+	if jobType.MaxRetry < 0 {
+		panic(fmt.Sprintf("job type %s max retry %d is invalid", jobType.Name, jobType.MaxRetry))
+	}
+
+	typeLock.Lock()
+	defer typeLock.Unlock()
+	if _, exists := jobTypes[jobType.Name]; exists {
+		panic(fmt.Sprintf("job type %s is registered repeatedly", jobType.Name))
+	}
+	blog.Infof("register job type %s, max retry: %d", jobType.Name, jobType.MaxRetry)
+	jobTypes[jobType.Name] = jobType
+}
+
+// GetType 获取已注册的任务类型
+func GetType(name string) (JobType, bool) {
+	typeLock.RLock()
+	defer typeLock.RUnlock()
+	jobType, exists := jobTypes[name]
+	return jobType, exists
+}
+
+// ListTypes 按名称排序返回所有已注册的任务类型
+func ListTypes() []metadata.JobTypeInfo {
+	typeLock.RLock()
+	defer typeLock.RUnlock()
+
+	infos := make([]metadata.JobTypeInfo, 0, len(jobTypes))
+	for _, jobType := range jobTypes {
+		infos = append(infos, metadata.JobTypeInfo{Name: jobType.Name, MaxRetry: jobType.MaxRetry})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// typeNames 所有已注册的任务类型名称
+func typeNames() []string {
+	typeLock.RLock()
+	defer typeLock.RUnlock()
+
+	names := make([]string, 0, len(jobTypes))
+	for name := range jobTypes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Build 生成待执行的任务，header为创建任务的用户的请求头，任务以该用户的身份执行
+func Build(jobType JobType, opt *metadata.CreateJobOption, header http.Header) *metadata.Job {
+	maxRetry := jobType.MaxRetry
+	if opt.MaxRetry != nil {
+		maxRetry = *opt.MaxRetry
+	}
+
+	now := time.Now()
+	return &metadata.Job{
+		JobID:       "task:job:" + xid.New().String(),
+		Type:        jobType.Name,
+		Flag:        opt.Flag,
+		BizID:       opt.BizID,
+		Creator:     util.GetUser(header),
+		OwnerID:     util.GetOwnerID(header),
+		Header:      header,
+		Params:      opt.Params,
+		Status:      metadata.JobStatusWaiting,
+		Logs:        make([]metadata.JobLog, 0),
+		MaxRetry:    maxRetry,
+		NextRunTime: now,
+		CreateTime:  now,
+		LastTime:    now,
+	}
+}
+
+// noRetryError 不需要重试的错误
+type noRetryError struct {
+	error
+}
+
+// NoRetry 包装不需要重试的错误，例如参数错误，任务返回该错误时直接失败
+func NoRetry(err error) error {
+	if err == nil {
+		return nil
+	}
+	return noRetryError{error: err}
+}
+
+// isNoRetry 判断错误是否不需要重试
+func isNoRetry(err error) bool {
+	var target noRetryError
+	return errors.As(err, &target)
+}
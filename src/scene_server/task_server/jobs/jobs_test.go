@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	jobType := JobType{RetryInterval: time.Second, MaxRetryInterval: 10 * time.Second}
+	expects := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second,
+		10 * time.Second}
+	for idx, expect := range expects {
+		if wait := jobType.backoff(int64(idx + 1)); wait != expect {
+			t.Errorf("attempts %d backoff should be %s, but got %s", idx+1, expect, wait)
+		}
+	}
+
+	if wait := (JobType{}).backoff(1); wait != defaultRetryInterval {
+		t.Errorf("default backoff should be %s, but got %s", defaultRetryInterval, wait)
+	}
+	if wait := (JobType{}).backoff(100); wait != defaultMaxRetryInterval {
+		t.Errorf("default max backoff should be %s, but got %s", defaultMaxRetryInterval, wait)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	handler := func(ctx *Context) (interface{}, error) { return nil, nil }
+	Register(JobType{Name: "test-register-b", Handler: handler, MaxRetry: 3})
+	Register(JobType{Name: "test-register-a", Handler: handler})
+
+	jobType, exists := GetType("test-register-b")
+	if !exists || jobType.MaxRetry != 3 {
+		t.Fatalf("registered job type is not found, exists: %v, type: %+v", exists, jobType)
+	}
+	if _, exists := GetType("test-register-not-exist"); exists {
+		t.Fatal("job type that is not registered should not exist")
+	}
+
+	types := ListTypes()
+	if len(types) != 2 || types[0].Name != "test-register-a" || types[1].Name != "test-register-b" {
+		t.Errorf("job types should be sorted by name, but got %+v", types)
+	}
+
+	invalids := []JobType{
+		{Name: "test-register-a", Handler: handler},
+		{Name: "", Handler: handler},
+		{Name: "test-register-no-handler"},
+		{Name: "test-register-negative-retry", Handler: handler, MaxRetry: -1},
+	}
+	for _, invalid := range invalids {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("register invalid job type %+v should panic", invalid)
+				}
+			}()
+			Register(invalid)
+		}()
+	}
+}
+
+func TestNoRetry(t *testing.T) {
+	if NoRetry(nil) != nil {
+		t.Error("no retry of nil error should be nil")
+	}
+
+	err := errors.New("invalid params")
+	if !isNoRetry(NoRetry(err)) {
+		t.Error("no retry error should not be retried")
+	}
+	if !isNoRetry(fmt.Errorf("run job failed, err: %w", NoRetry(err))) {
+		t.Error("wrapped no retry error should not be retried")
+	}
+	if isNoRetry(err) {
+		t.Error("normal error should be retried")
+	}
+	if NoRetry(err).Error() != err.Error() {
+		t.Errorf("no retry error message should be %s, but got %s", err, NoRetry(err))
+	}
+}
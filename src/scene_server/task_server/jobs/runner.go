@@ -0,0 +1,365 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+
+	"github.com/rs/xid"
+)
+
+const (
+	// pollInterval 查询待执行任务的间隔
+	pollInterval = 3 * time.Second
+	// heartbeatInterval 执行中的任务更新心跳以及检查是否被取消的间隔
+	heartbeatInterval = 10 * time.Second
+	// staleTimeout 执行中的任务超过该时间没有心跳时，认为执行任务的实例已退出
+	staleTimeout = 2 * time.Minute
+	// recoverInterval 检查没有心跳的任务的间隔
+	recoverInterval = time.Minute
+	// dbMaxRetry 保存任务执行结果失败时的最大重试次数
+	dbMaxRetry = 10
+	// defaultConcurrency 每个任务服务实例默认同时执行的任务数
+	defaultConcurrency = 10
+)
+
+// errExecutorLost 执行任务的实例退出导致任务中断
+var errExecutorLost = errors.New("the task server executing the job is lost")
+
+// activeStatus 正在执行的任务的状态
+var activeStatus = []metadata.JobStatus{metadata.JobStatusRunning, metadata.JobStatusCanceling}
+
+// Runner 通用任务的执行器，多个任务服务实例通过更新任务的状态及run_id争抢待执行的任务
+type Runner struct {
+	engine *backbone.Engine
+	db     dal.RDB
+	// slots 限制同时执行的任务数
+	slots chan struct{}
+
+	lock sync.Mutex
+	// running 当前实例正在执行的任务，run id -> 取消执行的函数
+	running map[string]context.CancelFunc
+}
+
+// NewRunner 生成通用任务的执行器，concurrency为当前实例同时执行的任务数
+func NewRunner(engine *backbone.Engine, db dal.RDB, concurrency int) *Runner {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	return &Runner{
+		engine:  engine,
+		db:      db,
+		slots:   make(chan struct{}, concurrency),
+		running: make(map[string]context.CancelFunc),
+	}
+}
+
+// Start 开始领取并执行任务，ctx结束后不再领取新的任务，已领取的任务由其他实例在心跳超时后重新执行
+func (r *Runner) Start(ctx context.Context) {
+	// 领取和维护所有租户的任务，数据库操作不按租户隔离，任务执行时再限定为提交任务的租户
+	ctx = dal.WithoutTenant(ctx)
+	go loop(ctx, pollInterval, r.dispatch)
+	go loop(ctx, heartbeatInterval, r.heartbeat)
+	go loop(ctx, recoverInterval, r.recoverStale)
+}
+
+// loop 每隔interval执行一次do，直到ctx结束
+func loop(ctx context.Context, interval time.Duration, do func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		func() {
+			defer func() {
+				if fetalErr := recover(); fetalErr != nil {
+					blog.Errorf("job loop panic, err: %v, stack: %s", fetalErr, debug.Stack())
+				}
+			}()
+			do(ctx)
+		}()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch 领取到期的待执行任务，并在空闲的执行槽中执行
+func (r *Runner) dispatch(ctx context.Context) {
+	free := cap(r.slots) - len(r.slots)
+	names := typeNames()
+	if free <= 0 || len(names) == 0 {
+		return
+	}
+
+	filter := mapstr.MapStr{
+		metadata.JobStatusField:      metadata.JobStatusWaiting,
+		metadata.JobTypeField:        mapstr.MapStr{common.BKDBIN: names},
+		metadata.JobNextRunTimeField: mapstr.MapStr{common.BKDBLTE: time.Now()},
+	}
+	waitingJobs := make([]metadata.Job, 0)
+	err := r.db.Table(common.BKTableNameAPIJob).Find(filter).Sort(metadata.JobNextRunTimeField).
+		Limit(uint64(free)).All(ctx, &waitingJobs)
+	if err != nil {
+		blog.Errorf("find waiting jobs failed, err: %v", err)
+		return
+	}
+
+	for idx := range waitingJobs {
+		job := &waitingJobs[idx]
+		claimed, err := r.claim(ctx, job)
+		if err != nil {
+			blog.Errorf("claim job %s failed, err: %v", job.JobID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		r.slots <- struct{}{}
+		go func() {
+			defer func() {
+				<-r.slots
+			}()
+			r.run(job)
+		}()
+	}
+}
+
+// claim 将待执行的任务更新为执行中，更新条件中包含任务状态，只有一个实例可以领取成功，领取成功的实例的run_id会保存到任务中
+func (r *Runner) claim(ctx context.Context, job *metadata.Job) (bool, error) {
+	runID := xid.New().String()
+	now := time.Now()
+	filter := mapstr.MapStr{
+		metadata.JobIDField:     job.JobID,
+		metadata.JobStatusField: metadata.JobStatusWaiting,
+	}
+	updates := []types.ModeUpdate{
+		{Op: "set", Doc: mapstr.MapStr{
+			metadata.JobStatusField: metadata.JobStatusRunning,
+			metadata.JobRunIDField:  runID,
+			"start_time":            now,
+			common.LastTimeField:    now,
+		}},
+		{Op: "inc", Doc: mapstr.MapStr{"attempts": 1}},
+	}
+	if err := r.db.Table(common.BKTableNameAPIJob).UpdateMultiModel(ctx, filter, updates...); err != nil {
+		return false, err
+	}
+
+	claimed := new(metadata.Job)
+	err := r.db.Table(common.BKTableNameAPIJob).Find(mapstr.MapStr{metadata.JobIDField: job.JobID}).One(ctx, claimed)
+	if err != nil {
+		return false, err
+	}
+	if claimed.RunID != runID {
+		return false, nil
+	}
+	*job = *claimed
+	return true, nil
+}
+
+// run 执行任务并保存执行结果
+func (r *Runner) run(job *metadata.Job) {
+	jobType, _ := GetType(job.Type)
+
+	header := util.CloneHeader(job.Header)
+	header.Set(common.BKHTTPJobID, job.JobID)
+	rid := util.GetHTTPCCRequestID(header)
+	if rid == "" {
+		rid = util.GenerateRID()
+		header.Set(common.BKHTTPCCRequestID, rid)
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if jobType.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), jobType.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	ctx = context.WithValue(ctx, common.ContextRequestIDField, rid)
+	ctx = context.WithValue(ctx, common.ContextRequestUserField, util.GetUser(header))
+	// 任务只能操作提交任务的租户的数据
+	ctx = dal.WithTenant(ctx, util.GetOwnerID(header))
+
+	r.lock.Lock()
+	r.running[job.RunID] = cancel
+	r.lock.Unlock()
+	defer func() {
+		r.lock.Lock()
+		delete(r.running, job.RunID)
+		r.lock.Unlock()
+		cancel()
+	}()
+
+	blog.Infof("start job %s, type: %s, attempts: %d, rid: %s", job.JobID, job.Type, job.Attempts, rid)
+	result, err := r.execute(&Context{Context: ctx, Job: job, Header: header, Rid: rid, Engine: r.engine, db: r.db},
+		jobType.Handler)
+	if err != nil {
+		blog.Errorf("job %s attempts %d failed, err: %v, rid: %s", job.JobID, job.Attempts, err, rid)
+	}
+	r.finish(job, jobType, result, err)
+}
+
+// execute 调用任务执行的函数，执行函数panic时返回错误
+func (r *Runner) execute(ctx *Context, handler Handler) (result interface{}, err error) {
+	defer func() {
+		if fetalErr := recover(); fetalErr != nil {
+			blog.Errorf("job %s panic, err: %v, stack: %s, rid: %s", ctx.Job.JobID, fetalErr, debug.Stack(), ctx.Rid)
+			err = fmt.Errorf("job panic: %v", fetalErr)
+		}
+	}()
+	return handler(ctx)
+}
+
+// finish 保存任务本次执行的结果，失败时根据重试次数决定是否退避后重试，执行中被取消的任务更新为已取消
+func (r *Runner) finish(job *metadata.Job, jobType JobType, result interface{}, err error) {
+	now := time.Now()
+	doc := mapstr.MapStr{common.LastTimeField: now}
+	filterStatus := activeStatus
+	log := metadata.JobLog{Time: now}
+
+	switch {
+	case err == nil:
+		doc[metadata.JobStatusField] = metadata.JobStatusSuccess
+		doc["progress"] = 1
+		doc["result"] = result
+		doc["error"] = ""
+		doc["end_time"] = now
+		log.Level, log.Message = metadata.JobLogLevelInfo, fmt.Sprintf("attempt %d succeeded", job.Attempts)
+
+	case !isNoRetry(err) && job.Attempts <= job.MaxRetry:
+		// 已被取消的任务不再重试，由之后的更新改为已取消
+		filterStatus = []metadata.JobStatus{metadata.JobStatusRunning}
+		wait := jobType.backoff(job.Attempts)
+		doc[metadata.JobStatusField] = metadata.JobStatusWaiting
+		doc[metadata.JobNextRunTimeField] = now.Add(wait)
+		doc["error"] = err.Error()
+		log.Level = metadata.JobLogLevelWarn
+		log.Message = fmt.Sprintf("attempt %d failed, retry after %s, err: %v", job.Attempts, wait, err)
+
+	default:
+		doc[metadata.JobStatusField] = metadata.JobStatusFailed
+		doc["error"] = err.Error()
+		doc["end_time"] = now
+		log.Level, log.Message = metadata.JobLogLevelError, fmt.Sprintf("attempt %d failed, err: %v", job.Attempts, err)
+	}
+
+	filter := mapstr.MapStr{
+		metadata.JobIDField:     job.JobID,
+		metadata.JobRunIDField:  job.RunID,
+		metadata.JobStatusField: mapstr.MapStr{common.BKDBIN: filterStatus},
+	}
+	r.updateWithRetry(job.JobID, filter, types.ModeUpdate{Op: "set", Doc: doc},
+		types.ModeUpdate{Op: "push", Doc: mapstr.MapStr{metadata.JobLogsField: mapstr.MapStr{
+			"$each": []metadata.JobLog{log}, "$slice": -metadata.JobMaxLogCount}}})
+
+	// 执行期间被取消的任务，如果没有执行成功或者失败，更新为已取消
+	cancelFilter := mapstr.MapStr{
+		metadata.JobIDField:     job.JobID,
+		metadata.JobRunIDField:  job.RunID,
+		metadata.JobStatusField: metadata.JobStatusCanceling,
+	}
+	r.updateWithRetry(job.JobID, cancelFilter, types.ModeUpdate{Op: "set", Doc: mapstr.MapStr{
+		metadata.JobStatusField: metadata.JobStatusCanceled,
+		"end_time":              now,
+		common.LastTimeField:    now,
+	}})
+}
+
+func (r *Runner) updateWithRetry(jobID string, filter mapstr.MapStr, updates ...types.ModeUpdate) {
+	for retry := 0; retry < dbMaxRetry; retry++ {
+		err := r.db.Table(common.BKTableNameAPIJob).UpdateMultiModel(context.Background(), filter, updates...)
+		if err == nil {
+			return
+		}
+		blog.Errorf("update job %s failed, retry: %d, err: %v", jobID, retry, err)
+		time.Sleep(3 * time.Second)
+	}
+}
+
+// heartbeat 更新当前实例执行中的任务的心跳时间，并取消被要求取消的任务
+func (r *Runner) heartbeat(ctx context.Context) {
+	r.lock.Lock()
+	runIDs := make([]string, 0, len(r.running))
+	for runID := range r.running {
+		runIDs = append(runIDs, runID)
+	}
+	r.lock.Unlock()
+	if len(runIDs) == 0 {
+		return
+	}
+
+	filter := mapstr.MapStr{
+		metadata.JobRunIDField:  mapstr.MapStr{common.BKDBIN: runIDs},
+		metadata.JobStatusField: mapstr.MapStr{common.BKDBIN: activeStatus},
+	}
+	if err := r.db.Table(common.BKTableNameAPIJob).Update(ctx, filter,
+		mapstr.MapStr{common.LastTimeField: time.Now()}); err != nil {
+		blog.Errorf("update running jobs heartbeat failed, run ids: %v, err: %v", runIDs, err)
+	}
+
+	filter[metadata.JobStatusField] = metadata.JobStatusCanceling
+	canceling := make([]metadata.Job, 0)
+	err := r.db.Table(common.BKTableNameAPIJob).Find(filter).Fields(metadata.JobIDField, metadata.JobRunIDField).
+		All(ctx, &canceling)
+	if err != nil {
+		blog.Errorf("find canceling jobs failed, run ids: %v, err: %v", runIDs, err)
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, job := range canceling {
+		if cancel, exists := r.running[job.RunID]; exists {
+			blog.Infof("job %s is canceled, stop it", job.JobID)
+			cancel()
+		}
+	}
+}
+
+// recoverStale 处理心跳超时的任务，执行任务的实例已退出，按照执行失败处理
+func (r *Runner) recoverStale(ctx context.Context) {
+	filter := mapstr.MapStr{
+		metadata.JobStatusField: mapstr.MapStr{common.BKDBIN: activeStatus},
+		common.LastTimeField:    mapstr.MapStr{common.BKDBLT: time.Now().Add(-staleTimeout)},
+	}
+	staleJobs := make([]metadata.Job, 0)
+	if err := r.db.Table(common.BKTableNameAPIJob).Find(filter).Limit(100).All(ctx, &staleJobs); err != nil {
+		blog.Errorf("find stale jobs failed, err: %v", err)
+		return
+	}
+
+	for idx := range staleJobs {
+		job := &staleJobs[idx]
+		blog.Warnf("job %s run %s has no heartbeat since %s, recover it", job.JobID, job.RunID, job.LastTime)
+		jobType, _ := GetType(job.Type)
+		r.finish(job, jobType, nil, errExecutorLost)
+	}
+}
@@ -0,0 +1,232 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/discovery"
+	apiutil "configcenter/src/apimachinery/util"
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/task_server/taskconfig"
+	"configcenter/src/storage/dal/mongo/local"
+)
+
+// hostApplyServer 模拟主机服务执行主机属性自动应用的接口，记录收到的请求
+type hostApplyServer struct {
+	*httptest.Server
+	lock     sync.Mutex
+	requests []*http.Request
+	bodies   []string
+	fail     bool
+}
+
+func newHostApplyServer() *hostApplyServer {
+	s := new(hostApplyServer)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		s.lock.Lock()
+		s.requests = append(s.requests, req)
+		s.bodies = append(s.bodies, string(body))
+		fail := s.fail
+		s.lock.Unlock()
+
+		resp := metadata.Response{BaseResp: metadata.SuccessBaseResp, Data: mapstr.MapStr{"updated": 2}}
+		if fail {
+			resp.BaseResp = metadata.BaseResp{Code: common.CCErrCommHTTPDoRequestFailed, ErrMsg: "host server failed"}
+			resp.Data = nil
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	return s
+}
+
+func (s *hostApplyServer) setFail(fail bool) {
+	s.lock.Lock()
+	s.fail = fail
+	s.lock.Unlock()
+}
+
+// registerHostApplyJobType 按照代码中的配置注册主机属性自动应用任务类型，接口地址为模拟的主机服务
+func registerHostApplyJobType(t *testing.T, server *hostApplyServer) (JobType, *backbone.Engine) {
+	if jobType, exists := GetType(common.HostApplyRunJobType); exists {
+		return jobType, newTestEngine(t)
+	}
+
+	for _, config := range taskconfig.GetCodeJobConfig() {
+		if config.Name != common.HostApplyRunJobType {
+			continue
+		}
+		RegisterHTTP(JobType{Name: config.Name, MaxRetry: config.MaxRetry, Timeout: config.Timeout},
+			func() ([]string, error) { return []string{server.URL}, nil }, config.Path)
+		jobType, _ := GetType(common.HostApplyRunJobType)
+		return jobType, newTestEngine(t)
+	}
+	t.Fatalf("job type %s is not configured", common.HostApplyRunJobType)
+	return JobType{}, nil
+}
+
+func newTestEngine(t *testing.T) *backbone.Engine {
+	client, err := apimachinery.NewApiMachinery(&apiutil.APIMachineryConfig{QPS: 1000, Burst: 1000},
+		discovery.NewMockDiscoveryInterface())
+	if err != nil {
+		t.Fatalf("new api machinery failed, err: %v", err)
+	}
+	return &backbone.Engine{CoreAPI: client}
+}
+
+func newHostApplyJob(jobType JobType) *metadata.Job {
+	header := util.BuildHeader("admin", common.BKDefaultOwnerID)
+	job := Build(jobType, &metadata.CreateJobOption{
+		Type:  jobType.Name,
+		BizID: 3,
+		Params: struct {
+			ModuleIDs []int64 `json:"bk_module_ids"`
+		}{ModuleIDs: []int64{5}},
+	}, header)
+	job.Attempts = 1
+	return job
+}
+
+func TestHostApplyJobHandler(t *testing.T) {
+	server := newHostApplyServer()
+	defer server.Close()
+	jobType, engine := registerHostApplyJobType(t, server)
+
+	job := newHostApplyJob(jobType)
+	header := util.CloneHeader(job.Header)
+	header.Set(common.BKHTTPJobID, job.JobID)
+	ctx := &Context{Context: context.Background(), Job: job, Header: header, Rid: "rid", Engine: engine}
+
+	// 任务服务的接口地址是异步注册的，注册完成前执行会失败
+	runner := NewRunner(engine, nil, 1)
+	var result interface{}
+	var err error
+	for i := 0; i < 50; i++ {
+		if result, err = runner.execute(ctx, jobType.Handler); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("execute host apply job failed, err: %v", err)
+	}
+	if data, ok := result.(map[string]interface{}); !ok || fmt.Sprint(data["updated"]) != "2" {
+		t.Errorf("host apply job result should be the data of the response, but got %#v", result)
+	}
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if len(server.requests) != 1 {
+		t.Fatalf("host apply job should request host server once, but got %d", len(server.requests))
+	}
+	req := server.requests[0]
+	if req.Method != http.MethodPost || req.URL.Path != "/host/v3/updatemany/host_apply_plan/bk_biz_id/3/run" {
+		t.Errorf("host apply job requests %s %s, the biz id in path should be replaced", req.Method, req.URL.Path)
+	}
+	if req.Header.Get(common.BKHTTPJobID) != job.JobID {
+		t.Errorf("host apply job request should carry the job id, but got %s", req.Header.Get(common.BKHTTPJobID))
+	}
+	if server.bodies[0] != `{"bk_module_ids":[5]}` {
+		t.Errorf("host apply job request body should be the job params, but got %s", server.bodies[0])
+	}
+}
+
+func TestExecutePanic(t *testing.T) {
+	runner := NewRunner(nil, nil, 1)
+	ctx := &Context{Context: context.Background(), Job: &metadata.Job{JobID: "panic"}}
+	_, err := runner.execute(ctx, func(ctx *Context) (interface{}, error) {
+		panic("test panic")
+	})
+	if err == nil {
+		t.Fatal("panic job should return error")
+	}
+}
+
+// TestRunnerHostApplyJob 使用真实的mongodb验证主机属性自动应用任务被领取、执行失败后退避重试、重试成功的过程
+func TestRunnerHostApplyJob(t *testing.T) {
+	uri := os.Getenv("MONGOURI")
+	if uri == "" {
+		t.Skip("MONGOURI is not set")
+	}
+	db, err := local.NewMgo(local.MongoConf{MaxOpenConns: 10, MaxIdleConns: 5, URI: uri,
+		RsName: os.Getenv("MONGORS")}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("connect mongodb failed, err: %v", err)
+	}
+
+	server := newHostApplyServer()
+	defer server.Close()
+	jobType, engine := registerHostApplyJobType(t, server)
+	runner := NewRunner(engine, db, 1)
+	ctx := context.Background()
+
+	job := newHostApplyJob(jobType)
+	job.Attempts = 0
+	job.MaxRetry = 1
+	if err := db.Table(common.BKTableNameAPIJob).Insert(ctx, job); err != nil {
+		t.Fatalf("insert job failed, err: %v", err)
+	}
+	defer db.Table(common.BKTableNameAPIJob).Delete(ctx, mapstr.MapStr{metadata.JobIDField: job.JobID})
+
+	waitJob := func(status metadata.JobStatus, attempts int64) *metadata.Job {
+		result := new(metadata.Job)
+		for i := 0; i < 100; i++ {
+			runner.dispatch(ctx)
+			err := db.Table(common.BKTableNameAPIJob).Find(mapstr.MapStr{metadata.JobIDField: job.JobID}).
+				One(ctx, result)
+			if err != nil {
+				t.Fatalf("find job failed, err: %v", err)
+			}
+			if result.Status == status && result.Attempts == attempts {
+				return result
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("job status should be %s after %d attempts, but got %+v", status, attempts, result)
+		return nil
+	}
+
+	// 第一次执行失败后退避重试
+	server.setFail(true)
+	result := waitJob(metadata.JobStatusWaiting, 1)
+	if result.Attempts != 1 || !result.NextRunTime.After(time.Now()) || result.Error == "" {
+		t.Fatalf("failed job should be retried later, but got %+v", result)
+	}
+
+	// 重试时执行成功
+	server.setFail(false)
+	if err := db.Table(common.BKTableNameAPIJob).Update(ctx, mapstr.MapStr{metadata.JobIDField: job.JobID},
+		mapstr.MapStr{metadata.JobNextRunTimeField: time.Now()}); err != nil {
+		t.Fatalf("update job next run time failed, err: %v", err)
+	}
+	result = waitJob(metadata.JobStatusSuccess, 2)
+	if result.Attempts != 2 || result.Progress != 1 || result.Error != "" {
+		t.Errorf("succeeded job is unexpected, got %+v", result)
+	}
+}
@@ -0,0 +1,341 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/lock"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/redis"
+
+	"github.com/robfig/cron"
+	"github.com/rs/xid"
+)
+
+const (
+	// scheduleInterval 检查到期的定时任务的间隔
+	scheduleInterval = 10 * time.Second
+	// leaderExpire 触发定时任务的主节点的过期时间，主节点每次检查时续期
+	leaderExpire = 30 * time.Second
+	// misfireThreshold 超过调度时间该时间后才触发的调度认为错过了调度时间，按照错过策略处理
+	misfireThreshold = time.Minute
+	// minScheduleInterval 定时任务的最小调度间隔
+	minScheduleInterval = time.Minute
+	// historyCleanInterval 清理定时任务执行历史的间隔
+	historyCleanInterval = time.Hour
+	// historyKeepTime 定时任务执行历史的保留时间
+	historyKeepTime = 30 * 24 * time.Hour
+)
+
+// ParseSpec 解析定时任务的cron表达式，支持标准的5位cron表达式、@daily等描述符以及CRON_TZ=前缀指定的时区，
+// 调度间隔不能小于1分钟
+func ParseSpec(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+	if delay, ok := schedule.(cron.ConstantDelaySchedule); ok && delay.Delay < minScheduleInterval {
+		return nil, fmt.Errorf("schedule interval %s is less than %s", delay.Delay, minScheduleInterval)
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, errors.New("schedule will never be fired")
+	}
+	return schedule, nil
+}
+
+// dueRuns 返回从next开始到now为止到期的调度时间，最多返回最近的max个，以及now之后的下一次调度时间
+func dueRuns(schedule cron.Schedule, next, now time.Time, max int) ([]time.Time, time.Time) {
+	due := make([]time.Time, 0)
+	for t := next; !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		due = append(due, t)
+		if len(due) > max {
+			due = due[1:]
+		}
+	}
+	return due, schedule.Next(now)
+}
+
+// misfireRuns 按照错过策略从到期的调度时间中选出需要触发的调度时间，其余的调度时间被跳过
+func misfireRuns(policy metadata.MisfirePolicy, due []time.Time, now time.Time) (fire []time.Time,
+	skip []time.Time) {
+
+	missed := make([]time.Time, 0)
+	for _, t := range due {
+		if now.Sub(t) <= misfireThreshold {
+			fire = append(fire, t)
+		} else {
+			missed = append(missed, t)
+		}
+	}
+	if len(missed) == 0 {
+		return fire, nil
+	}
+
+	switch policy {
+	case metadata.MisfirePolicyFireAll:
+		return append(missed, fire...), nil
+	case metadata.MisfirePolicyFireOnce:
+		// 有按时触发的调度时，错过的调度全部跳过，否则触发最近一次错过的调度
+		if len(fire) != 0 {
+			return fire, missed
+		}
+		return missed[len(missed)-1:], missed[:len(missed)-1]
+	default:
+		return fire, missed
+	}
+}
+
+// Scheduler 定时任务的调度器，多个任务服务实例通过common/lock选举主节点，由主节点按照cron表达式创建任务
+type Scheduler struct {
+	db     dal.RDB
+	leader lock.Leader
+}
+
+// NewScheduler 生成定时任务的调度器
+func NewScheduler(db dal.RDB, cache redis.Client) *Scheduler {
+	return &Scheduler{
+		db:     db,
+		leader: lock.NewLeader(cache),
+	}
+}
+
+// Start 开始竞选主节点并调度定时任务，ctx结束后放弃主节点
+func (s *Scheduler) Start(ctx context.Context) {
+	// 调度器处理所有租户的定时任务，数据库操作不按租户隔离
+	ctx = dal.WithoutTenant(ctx)
+	go loop(ctx, scheduleInterval, s.schedule)
+	go loop(ctx, historyCleanInterval, s.cleanHistory)
+	go func() {
+		<-ctx.Done()
+		if err := s.leader.Resign(lock.GetLockKey(lock.JobScheduleLeaderFormat)); err != nil {
+			blog.Errorf("resign job schedule leader failed, err: %v", err)
+		}
+	}()
+}
+
+// isLeader 竞选或续期主节点，返回当前实例是否为主节点
+func (s *Scheduler) isLeader() bool {
+	isLeader, err := s.leader.Campaign(lock.GetLockKey(lock.JobScheduleLeaderFormat), leaderExpire)
+	if err != nil {
+		blog.Errorf("campaign job schedule leader failed, err: %v", err)
+		return false
+	}
+	return isLeader
+}
+
+// schedule 主节点触发到期的定时任务
+func (s *Scheduler) schedule(ctx context.Context) {
+	if !s.isLeader() {
+		return
+	}
+
+	now := time.Now()
+	filter := mapstr.MapStr{
+		metadata.JobScheduleEnabledField: true,
+		metadata.JobNextRunTimeField:     mapstr.MapStr{common.BKDBLTE: now},
+	}
+	schedules := make([]metadata.JobSchedule, 0)
+	err := s.db.Table(common.BKTableNameAPIJobSchedule).Find(filter).Sort(metadata.JobNextRunTimeField).Limit(100).
+		All(ctx, &schedules)
+	if err != nil {
+		blog.Errorf("find due job schedules failed, err: %v", err)
+		return
+	}
+
+	for idx := range schedules {
+		if err := s.fire(ctx, &schedules[idx], now); err != nil {
+			blog.Errorf("fire job schedule %s failed, err: %v", schedules[idx].ScheduleID, err)
+		}
+	}
+}
+
+// fire 计算定时任务到期的调度时间，按照错过策略及是否允许并发执行创建任务，并记录执行历史
+func (s *Scheduler) fire(ctx context.Context, sched *metadata.JobSchedule, now time.Time) error {
+	schedule, err := ParseSpec(sched.Spec)
+	if err != nil {
+		// cron表达式已经不合法，例如时区不存在，停用定时任务避免每次都检查
+		blog.Errorf("job schedule %s spec %s is invalid, disable it, err: %v", sched.ScheduleID, sched.Spec, err)
+		return s.disable(ctx, sched, now, fmt.Sprintf("spec %s is invalid, err: %v", sched.Spec, err))
+	}
+	due, next := dueRuns(schedule, sched.NextRunTime, now, metadata.JobScheduleMaxMisfireRuns)
+
+	// 更新下一次调度时间，更新条件中包含当前的调度时间，只有一个实例可以触发本次调度
+	claimed, err := s.claim(ctx, sched, next)
+	if err != nil || !claimed {
+		return err
+	}
+
+	fire, skip := misfireRuns(sched.MisfirePolicy, due, now)
+	runs := make([]metadata.JobScheduleRun, 0, len(fire)+len(skip))
+	for _, t := range skip {
+		runs = append(runs, metadata.JobScheduleRun{
+			ScheduleID:    sched.ScheduleID,
+			ScheduledTime: t,
+			FireTime:      now,
+			Status:        metadata.JobScheduleRunSkipped,
+			Message:       fmt.Sprintf("missed the scheduled time, skipped by misfire policy %s", sched.MisfirePolicy),
+			OwnerID:       sched.OwnerID,
+		})
+	}
+
+	lastJobID := sched.LastJobID
+	for _, t := range fire {
+		run := metadata.JobScheduleRun{
+			ScheduleID:    sched.ScheduleID,
+			ScheduledTime: t,
+			FireTime:      now,
+			OwnerID:       sched.OwnerID,
+		}
+		runs = append(runs, run)
+		last := &runs[len(runs)-1]
+
+		if !sched.AllowConcurrent {
+			running, err := s.isJobRunning(ctx, lastJobID)
+			if err != nil {
+				last.Status, last.Message = metadata.JobScheduleRunFailed, err.Error()
+				continue
+			}
+			if running {
+				last.Status = metadata.JobScheduleRunSkipped
+				last.Message = fmt.Sprintf("the last job %s is not finished", lastJobID)
+				continue
+			}
+		}
+
+		job, err := s.createJob(ctx, sched)
+		if err != nil {
+			blog.Errorf("create job of schedule %s failed, err: %v", sched.ScheduleID, err)
+			last.Status, last.Message = metadata.JobScheduleRunFailed, err.Error()
+			continue
+		}
+		last.Status, last.JobID = metadata.JobScheduleRunFired, job.JobID
+		lastJobID = job.JobID
+	}
+
+	if len(runs) != 0 {
+		if err := s.db.Table(common.BKTableNameAPIJobScheduleHistory).Insert(ctx, runs); err != nil {
+			blog.Errorf("insert job schedule %s run history failed, err: %v", sched.ScheduleID, err)
+		}
+	}
+
+	if lastJobID == sched.LastJobID {
+		return nil
+	}
+	return s.db.Table(common.BKTableNameAPIJobSchedule).Update(ctx,
+		mapstr.MapStr{metadata.JobScheduleIDField: sched.ScheduleID},
+		mapstr.MapStr{"last_job_id": lastJobID, "last_run_time": now})
+}
+
+// claim 将定时任务的下一次调度时间更新为next，通过fire_id判断是否由当前实例更新成功，next为空时停用定时任务
+func (s *Scheduler) claim(ctx context.Context, sched *metadata.JobSchedule, next time.Time) (bool, error) {
+	fireID := xid.New().String()
+	filter := mapstr.MapStr{
+		metadata.JobScheduleIDField:      sched.ScheduleID,
+		metadata.JobScheduleEnabledField: true,
+		metadata.JobNextRunTimeField:     sched.NextRunTime,
+	}
+	doc := mapstr.MapStr{
+		metadata.JobNextRunTimeField:    next,
+		metadata.JobScheduleFireIDField: fireID,
+	}
+	if next.IsZero() {
+		doc[metadata.JobScheduleEnabledField] = false
+	}
+	if err := s.db.Table(common.BKTableNameAPIJobSchedule).Update(ctx, filter, doc); err != nil {
+		return false, err
+	}
+
+	claimed := new(metadata.JobSchedule)
+	err := s.db.Table(common.BKTableNameAPIJobSchedule).Find(mapstr.MapStr{
+		metadata.JobScheduleIDField: sched.ScheduleID}).Fields(metadata.JobScheduleFireIDField).One(ctx, claimed)
+	if err != nil {
+		return false, err
+	}
+	return claimed.FireID == fireID, nil
+}
+
+// disable 停用定时任务，并记录执行失败的原因
+func (s *Scheduler) disable(ctx context.Context, sched *metadata.JobSchedule, now time.Time, reason string) error {
+	filter := mapstr.MapStr{metadata.JobScheduleIDField: sched.ScheduleID}
+	doc := mapstr.MapStr{metadata.JobScheduleEnabledField: false, common.LastTimeField: now}
+	if err := s.db.Table(common.BKTableNameAPIJobSchedule).Update(ctx, filter, doc); err != nil {
+		return err
+	}
+
+	run := metadata.JobScheduleRun{
+		ScheduleID:    sched.ScheduleID,
+		ScheduledTime: sched.NextRunTime,
+		FireTime:      now,
+		Status:        metadata.JobScheduleRunFailed,
+		Message:       reason,
+		OwnerID:       sched.OwnerID,
+	}
+	return s.db.Table(common.BKTableNameAPIJobScheduleHistory).Insert(ctx, run)
+}
+
+// isJobRunning 判断定时任务上一次创建的任务是否还没有结束
+func (s *Scheduler) isJobRunning(ctx context.Context, jobID string) (bool, error) {
+	if jobID == "" {
+		return false, nil
+	}
+
+	rows := make([]metadata.Job, 0)
+	err := s.db.Table(common.BKTableNameAPIJob).Find(mapstr.MapStr{metadata.JobIDField: jobID}).
+		Fields(metadata.JobStatusField).All(ctx, &rows)
+	if err != nil {
+		return false, fmt.Errorf("get the last job %s failed, err: %v", jobID, err)
+	}
+	return len(rows) != 0 && !rows[0].Status.IsFinished(), nil
+}
+
+// createJob 以定时任务的参数创建任务，任务的flag为定时任务的id
+func (s *Scheduler) createJob(ctx context.Context, sched *metadata.JobSchedule) (*metadata.Job, error) {
+	jobType, exists := GetType(sched.Type)
+	if !exists {
+		return nil, fmt.Errorf("job type %s is not registered", sched.Type)
+	}
+
+	job := Build(jobType, &metadata.CreateJobOption{
+		Type:     sched.Type,
+		Flag:     sched.ScheduleID,
+		BizID:    sched.BizID,
+		Params:   sched.Params,
+		MaxRetry: sched.MaxRetry,
+	}, sched.Header)
+	if err := s.db.Table(common.BKTableNameAPIJob).Insert(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// cleanHistory 主节点清理超过保留时间的定时任务执行历史
+func (s *Scheduler) cleanHistory(ctx context.Context) {
+	if !s.isLeader() {
+		return
+	}
+
+	filter := mapstr.MapStr{
+		metadata.JobScheduleFireTimeField: mapstr.MapStr{common.BKDBLT: time.Now().Add(-historyKeepTime)},
+	}
+	if err := s.db.Table(common.BKTableNameAPIJobScheduleHistory).Delete(ctx, filter); err != nil {
+		blog.Errorf("clean job schedule run history failed, err: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestParseSpec(t *testing.T) {
+	valids := []string{"30 2 * * *", "0 3 * * 1", "@daily", "@every 1h", "CRON_TZ=Asia/Shanghai 30 2 * * *"}
+	for _, spec := range valids {
+		if _, err := ParseSpec(spec); err != nil {
+			t.Errorf("spec %s should be valid, but got err: %v", spec, err)
+		}
+	}
+
+	invalids := []string{"", "* * *", "61 * * * *", "@every 10s", "CRON_TZ=Not/Exist 30 2 * * *", "0 0 30 2 *"}
+	for _, spec := range invalids {
+		if _, err := ParseSpec(spec); err == nil {
+			t.Errorf("spec %s should be invalid", spec)
+		}
+	}
+}
+
+func TestDueRuns(t *testing.T) {
+	schedule, err := ParseSpec("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2020, 12, 28, 10, 0, 0, 0, time.Local)
+	now := base.Add(3*time.Hour + 30*time.Minute)
+
+	due, next := dueRuns(schedule, base, now, 10)
+	expects := []time.Time{base, base.Add(time.Hour), base.Add(2 * time.Hour), base.Add(3 * time.Hour)}
+	if !reflect.DeepEqual(due, expects) {
+		t.Errorf("due runs should be %v, but got %v", expects, due)
+	}
+	if !next.Equal(base.Add(4 * time.Hour)) {
+		t.Errorf("next run time should be %s, but got %s", base.Add(4*time.Hour), next)
+	}
+
+	due, _ = dueRuns(schedule, base, now, 2)
+	if !reflect.DeepEqual(due, expects[2:]) {
+		t.Errorf("due runs should keep the latest %v, but got %v", expects[2:], due)
+	}
+
+	due, _ = dueRuns(schedule, base, base.Add(-time.Minute), 10)
+	if len(due) != 0 {
+		t.Errorf("no run should be due before the next run time, but got %v", due)
+	}
+}
+
+func TestMisfireRuns(t *testing.T) {
+	now := time.Date(2020, 12, 28, 13, 0, 30, 0, time.Local)
+	missed := []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour)}
+	onTime := now.Add(-30 * time.Second)
+
+	cases := []struct {
+		policy metadata.MisfirePolicy
+		due    []time.Time
+		fire   []time.Time
+		skip   []time.Time
+	}{
+		{metadata.MisfirePolicySkip, []time.Time{onTime}, []time.Time{onTime}, nil},
+		{metadata.MisfirePolicyFireAll, []time.Time{onTime}, []time.Time{onTime}, nil},
+		{metadata.MisfirePolicySkip, missed, nil, missed},
+		{metadata.MisfirePolicySkip, append(missed, onTime), []time.Time{onTime}, missed},
+		{metadata.MisfirePolicyFireOnce, missed, missed[1:], missed[:1]},
+		{metadata.MisfirePolicyFireOnce, append(missed, onTime), []time.Time{onTime}, missed},
+		{metadata.MisfirePolicyFireAll, missed, missed, nil},
+		{metadata.MisfirePolicyFireAll, append(missed, onTime), append(missed, onTime), nil},
+	}
+
+	for idx, c := range cases {
+		fire, skip := misfireRuns(c.policy, c.due, now)
+		if len(fire) != len(c.fire) || (len(fire) != 0 && !reflect.DeepEqual(fire, c.fire)) {
+			t.Errorf("case %d policy %s fire runs should be %v, but got %v", idx, c.policy, c.fire, fire)
+		}
+		if len(skip) != len(c.skip) || (len(skip) != 0 && !reflect.DeepEqual(skip, c.skip)) {
+			t.Errorf("case %d policy %s skip runs should be %v, but got %v", idx, c.policy, c.skip, skip)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/task_server/jobs"
+)
+
+// CreateJob 新建通用任务，任务由注册了对应任务类型的任务服务实例执行
+func (lgc *Logics) CreateJob(ctx context.Context, input *metadata.CreateJobOption) (*metadata.Job, error) {
+	input.Type = strings.TrimSpace(input.Type)
+	if input.Type == "" {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsNeedSet, "type")
+	}
+	jobType, exists := jobs.GetType(input.Type)
+	if !exists {
+		return nil, lgc.ccErr.Errorf(common.CCErrTaskJobTypeNotRegistered, input.Type)
+	}
+
+	if input.MaxRetry != nil && *input.MaxRetry < 0 {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "max_retry")
+	}
+
+	job := jobs.Build(jobType, input, GetDBHTTPHeader(lgc.header))
+	if err := lgc.db.Table(common.BKTableNameAPIJob).Insert(ctx, job); err != nil {
+		blog.ErrorJSON("create job failed, data: %s, err: %s, rid: %s", job, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBInsertFailed)
+	}
+	return job, nil
+}
+
+// ListJob 根据任务类型、创建人、业务、状态等查询通用任务，任务按创建时间倒序返回
+func (lgc *Logics) ListJob(ctx context.Context, input *metadata.ListJobOption) (*metadata.ListJobResult, error) {
+	if input.Page.IsIllegal() {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommPageLimitIsExceeded)
+	}
+	if input.Page.Sort == "" {
+		input.Page.Sort = "-" + common.CreateTimeField
+	}
+
+	cond := mapstr.New()
+	if input.Type != "" {
+		cond[metadata.JobTypeField] = input.Type
+	}
+	if input.Flag != "" {
+		cond["flag"] = input.Flag
+	}
+	if input.BizID != 0 {
+		cond[common.BKAppIDField] = input.BizID
+	}
+	if input.Creator != "" {
+		cond[metadata.JobCreatorField] = input.Creator
+	}
+	if len(input.Status) != 0 {
+		cond[metadata.JobStatusField] = mapstr.MapStr{common.BKDBIN: input.Status}
+	}
+	cond = util.SetQueryOwner(cond, lgc.ownerID)
+
+	cnt, err := lgc.db.Table(common.BKTableNameAPIJob).Find(cond).Count(ctx)
+	if err != nil {
+		blog.ErrorJSON("count job failed, cond: %s, err: %s, rid: %s", cond, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	infos := make([]metadata.Job, 0)
+	err = lgc.db.Table(common.BKTableNameAPIJob).Find(cond).Start(uint64(input.Page.Start)).
+		Limit(uint64(input.Page.Limit)).Sort(input.Page.Sort).All(ctx, &infos)
+	if err != nil {
+		blog.ErrorJSON("list job failed, cond: %s, err: %s, rid: %s", cond, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	return &metadata.ListJobResult{Count: int64(cnt), Info: infos}, nil
+}
+
+// GetJob 查询通用任务的详情
+func (lgc *Logics) GetJob(ctx context.Context, jobID string) (*metadata.Job, error) {
+	cond := util.SetQueryOwner(mapstr.MapStr{metadata.JobIDField: jobID}, lgc.ownerID)
+	rows := make([]metadata.Job, 0)
+	if err := lgc.db.Table(common.BKTableNameAPIJob).Find(cond).All(ctx, &rows); err != nil {
+		blog.ErrorJSON("get job failed, cond: %s, err: %s, rid: %s", cond, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(rows) == 0 {
+		return nil, lgc.ccErr.CCError(common.CCErrTaskNotFound)
+	}
+	return &rows[0], nil
+}
+
+// CancelJob 取消通用任务，待执行的任务直接取消，执行中的任务更新为取消中，由执行任务的实例停止执行后更新为已取消
+func (lgc *Logics) CancelJob(ctx context.Context, jobID string) (*metadata.Job, error) {
+	job, err := lgc.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status.IsFinished() {
+		return nil, lgc.ccErr.CCErrorf(common.CCErrTaskStatusNotAllowChangeTo, metadata.JobStatusCanceled)
+	}
+
+	// 先将执行中的任务更新为取消中，再取消待执行的任务，执行失败重新变为待执行的任务也可以被取消
+	now := time.Now()
+	runningCond := mapstr.MapStr{
+		metadata.JobIDField:     jobID,
+		metadata.JobStatusField: metadata.JobStatusRunning,
+	}
+	runningData := mapstr.MapStr{
+		metadata.JobStatusField: metadata.JobStatusCanceling,
+		common.LastTimeField:    now,
+	}
+	if err := lgc.db.Table(common.BKTableNameAPIJob).Update(ctx, runningCond, runningData); err != nil {
+		blog.ErrorJSON("cancel running job failed, cond: %s, err: %s, rid: %s", runningCond, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+
+	waitingCond := mapstr.MapStr{
+		metadata.JobIDField:     jobID,
+		metadata.JobStatusField: metadata.JobStatusWaiting,
+	}
+	waitingData := mapstr.MapStr{
+		metadata.JobStatusField: metadata.JobStatusCanceled,
+		"end_time":              now,
+		common.LastTimeField:    now,
+	}
+	if err := lgc.db.Table(common.BKTableNameAPIJob).Update(ctx, waitingCond, waitingData); err != nil {
+		blog.ErrorJSON("cancel waiting job failed, cond: %s, err: %s, rid: %s", waitingCond, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+
+	return lgc.GetJob(ctx, jobID)
+}
+
+// ReportJobProgress 执行任务的服务上报任务的进度及日志，返回任务是否被要求取消
+func (lgc *Logics) ReportJobProgress(ctx context.Context, jobID string, input *metadata.ReportJobProgressOption) (
+	*metadata.ReportJobProgressResult, error) {
+
+	if input.Progress != nil && (*input.Progress < 0 || *input.Progress > 1) {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "progress")
+	}
+
+	job, err := lgc.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != metadata.JobStatusRunning && job.Status != metadata.JobStatusCanceling {
+		return &metadata.ReportJobProgressResult{Canceled: job.Status == metadata.JobStatusCanceled}, nil
+	}
+
+	if err := jobs.UpdateProgress(ctx, lgc.db, jobID, input); err != nil {
+		return nil, lgc.ccErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return &metadata.ReportJobProgressResult{Canceled: job.Status == metadata.JobStatusCanceling}, nil
+}
@@ -0,0 +1,319 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/task_server/jobs"
+)
+
+// CreateJobSchedule 新建定时任务，启用时按照cron表达式计算第一次调度时间
+func (lgc *Logics) CreateJobSchedule(ctx context.Context, input *metadata.CreateJobScheduleOption) (
+	*metadata.JobSchedule, error) {
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsNeedSet, "name")
+	}
+	if _, exists := jobs.GetType(input.Type); !exists {
+		return nil, lgc.ccErr.Errorf(common.CCErrTaskJobTypeNotRegistered, input.Type)
+	}
+	if input.MaxRetry != nil && *input.MaxRetry < 0 {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "max_retry")
+	}
+	if input.MisfirePolicy == "" {
+		input.MisfirePolicy = metadata.MisfirePolicySkip
+	}
+	if !input.MisfirePolicy.Validate() {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "misfire_policy")
+	}
+	nextRunTime, err := lgc.nextRunTime(input.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := lgc.checkJobScheduleName(ctx, input.Name, ""); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	schedule := &metadata.JobSchedule{
+		ScheduleID:      getStrTaskID("schedule"),
+		Name:            input.Name,
+		Type:            input.Type,
+		Params:          input.Params,
+		BizID:           input.BizID,
+		MaxRetry:        input.MaxRetry,
+		Spec:            input.Spec,
+		Enabled:         input.Enabled,
+		MisfirePolicy:   input.MisfirePolicy,
+		AllowConcurrent: input.AllowConcurrent,
+		NextRunTime:     nextRunTime,
+		Creator:         lgc.user,
+		Modifier:        lgc.user,
+		OwnerID:         lgc.ownerID,
+		Header:          GetDBHTTPHeader(lgc.header),
+		CreateTime:      now,
+		LastTime:        now,
+	}
+	if err := lgc.db.Table(common.BKTableNameAPIJobSchedule).Insert(ctx, schedule); err != nil {
+		blog.ErrorJSON("create job schedule failed, data: %s, err: %s, rid: %s", schedule, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBInsertFailed)
+	}
+	return schedule, nil
+}
+
+// UpdateJobSchedule 更新定时任务，定时任务以更新人的身份执行，修改cron表达式或重新启用时重新计算下一次调度时间，
+// 停用期间错过的调度不再触发
+func (lgc *Logics) UpdateJobSchedule(ctx context.Context, scheduleID string, input *metadata.UpdateJobScheduleOption) (
+	*metadata.JobSchedule, error) {
+
+	schedule, err := lgc.GetJobSchedule(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	doc := mapstr.MapStr{
+		"modifier":           lgc.user,
+		"header":             GetDBHTTPHeader(lgc.header),
+		common.LastTimeField: now,
+	}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			return nil, lgc.ccErr.Errorf(common.CCErrCommParamsNeedSet, "name")
+		}
+		if err := lgc.checkJobScheduleName(ctx, name, scheduleID); err != nil {
+			return nil, err
+		}
+		doc[metadata.JobScheduleNameField] = name
+	}
+	if input.Params != nil {
+		doc["params"] = input.Params
+	}
+	if input.MaxRetry != nil {
+		if *input.MaxRetry < 0 {
+			return nil, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "max_retry")
+		}
+		doc["max_retry"] = *input.MaxRetry
+	}
+	if input.MisfirePolicy != nil {
+		if !input.MisfirePolicy.Validate() {
+			return nil, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "misfire_policy")
+		}
+		doc["misfire_policy"] = *input.MisfirePolicy
+	}
+	if input.AllowConcurrent != nil {
+		doc["allow_concurrent"] = *input.AllowConcurrent
+	}
+	if input.Enabled != nil {
+		doc[metadata.JobScheduleEnabledField] = *input.Enabled
+	}
+
+	specChanged := input.Spec != nil && *input.Spec != schedule.Spec
+	reEnabled := input.Enabled != nil && *input.Enabled && !schedule.Enabled
+	if specChanged || reEnabled {
+		spec := schedule.Spec
+		if input.Spec != nil {
+			spec = *input.Spec
+		}
+		nextRunTime, err := lgc.nextRunTime(spec)
+		if err != nil {
+			return nil, err
+		}
+		doc["spec"] = spec
+		doc[metadata.JobNextRunTimeField] = nextRunTime
+	}
+
+	cond := mapstr.MapStr{metadata.JobScheduleIDField: scheduleID}
+	if err := lgc.db.Table(common.BKTableNameAPIJobSchedule).Update(ctx, cond, doc); err != nil {
+		blog.ErrorJSON("update job schedule failed, cond: %s, data: %s, err: %s, rid: %s", cond, doc, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBUpdateFailed)
+	}
+	return lgc.GetJobSchedule(ctx, scheduleID)
+}
+
+// DeleteJobSchedule 删除定时任务及其执行历史，已经创建的任务不受影响
+func (lgc *Logics) DeleteJobSchedule(ctx context.Context, scheduleID string) error {
+	if _, err := lgc.GetJobSchedule(ctx, scheduleID); err != nil {
+		return err
+	}
+
+	cond := mapstr.MapStr{metadata.JobScheduleIDField: scheduleID}
+	if err := lgc.db.Table(common.BKTableNameAPIJobSchedule).Delete(ctx, cond); err != nil {
+		blog.ErrorJSON("delete job schedule failed, cond: %s, err: %s, rid: %s", cond, err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommDBDeleteFailed)
+	}
+	if err := lgc.db.Table(common.BKTableNameAPIJobScheduleHistory).Delete(ctx, cond); err != nil {
+		blog.ErrorJSON("delete job schedule history failed, cond: %s, err: %s, rid: %s", cond, err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+// GetJobSchedule 查询定时任务的详情
+func (lgc *Logics) GetJobSchedule(ctx context.Context, scheduleID string) (*metadata.JobSchedule, error) {
+	cond := util.SetQueryOwner(mapstr.MapStr{metadata.JobScheduleIDField: scheduleID}, lgc.ownerID)
+	rows := make([]metadata.JobSchedule, 0)
+	if err := lgc.db.Table(common.BKTableNameAPIJobSchedule).Find(cond).All(ctx, &rows); err != nil {
+		blog.ErrorJSON("get job schedule failed, cond: %s, err: %s, rid: %s", cond, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(rows) == 0 {
+		return nil, lgc.ccErr.CCError(common.CCErrCommNotFound)
+	}
+	return &rows[0], nil
+}
+
+// ListJobSchedule 根据名称、任务类型、业务、创建人、是否启用查询定时任务
+func (lgc *Logics) ListJobSchedule(ctx context.Context, input *metadata.ListJobScheduleOption) (
+	*metadata.ListJobScheduleResult, error) {
+
+	if input.Page.IsIllegal() {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommPageLimitIsExceeded)
+	}
+	if input.Page.Sort == "" {
+		input.Page.Sort = "-" + common.CreateTimeField
+	}
+
+	cond := mapstr.New()
+	if input.Name != "" {
+		cond[metadata.JobScheduleNameField] = input.Name
+	}
+	if input.Type != "" {
+		cond[metadata.JobTypeField] = input.Type
+	}
+	if input.BizID != 0 {
+		cond[common.BKAppIDField] = input.BizID
+	}
+	if input.Creator != "" {
+		cond[metadata.JobCreatorField] = input.Creator
+	}
+	if input.Enabled != nil {
+		cond[metadata.JobScheduleEnabledField] = *input.Enabled
+	}
+	cond = util.SetQueryOwner(cond, lgc.ownerID)
+
+	cnt, err := lgc.db.Table(common.BKTableNameAPIJobSchedule).Find(cond).Count(ctx)
+	if err != nil {
+		blog.ErrorJSON("count job schedule failed, cond: %s, err: %s, rid: %s", cond, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	infos := make([]metadata.JobSchedule, 0)
+	err = lgc.db.Table(common.BKTableNameAPIJobSchedule).Find(cond).Start(uint64(input.Page.Start)).
+		Limit(uint64(input.Page.Limit)).Sort(input.Page.Sort).All(ctx, &infos)
+	if err != nil {
+		blog.ErrorJSON("list job schedule failed, cond: %s, err: %s, rid: %s", cond, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	return &metadata.ListJobScheduleResult{Count: int64(cnt), Info: infos}, nil
+}
+
+// ListJobScheduleHistory 按触发时间倒序查询定时任务的执行历史，并返回触发的任务的状态
+func (lgc *Logics) ListJobScheduleHistory(ctx context.Context, scheduleID string,
+	input *metadata.ListJobScheduleRunOption) (*metadata.ListJobScheduleRunResult, error) {
+
+	if input.Page.IsIllegal() {
+		return nil, lgc.ccErr.Errorf(common.CCErrCommPageLimitIsExceeded)
+	}
+	if input.Page.Sort == "" {
+		input.Page.Sort = "-" + metadata.JobScheduleFireTimeField
+	}
+	if _, err := lgc.GetJobSchedule(ctx, scheduleID); err != nil {
+		return nil, err
+	}
+
+	cond := mapstr.MapStr{metadata.JobScheduleIDField: scheduleID}
+	if len(input.Status) != 0 {
+		cond[metadata.JobStatusField] = mapstr.MapStr{common.BKDBIN: input.Status}
+	}
+	cnt, err := lgc.db.Table(common.BKTableNameAPIJobScheduleHistory).Find(cond).Count(ctx)
+	if err != nil {
+		blog.ErrorJSON("count job schedule history failed, cond: %s, err: %s, rid: %s", cond, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	runs := make([]metadata.JobScheduleRun, 0)
+	err = lgc.db.Table(common.BKTableNameAPIJobScheduleHistory).Find(cond).Start(uint64(input.Page.Start)).
+		Limit(uint64(input.Page.Limit)).Sort(input.Page.Sort).All(ctx, &runs)
+	if err != nil {
+		blog.ErrorJSON("list job schedule history failed, cond: %s, err: %s, rid: %s", cond, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	jobIDs := make([]string, 0)
+	for _, run := range runs {
+		if run.JobID != "" {
+			jobIDs = append(jobIDs, run.JobID)
+		}
+	}
+	if len(jobIDs) == 0 {
+		return &metadata.ListJobScheduleRunResult{Count: int64(cnt), Info: runs}, nil
+	}
+
+	jobCond := mapstr.MapStr{metadata.JobIDField: mapstr.MapStr{common.BKDBIN: jobIDs}}
+	firedJobs := make([]metadata.Job, 0)
+	if err := lgc.db.Table(common.BKTableNameAPIJob).Find(jobCond).Fields(metadata.JobIDField,
+		metadata.JobStatusField, "progress", "message", "error", "attempts", "start_time", "end_time").
+		All(ctx, &firedJobs); err != nil {
+		blog.ErrorJSON("list fired jobs failed, cond: %s, err: %s, rid: %s", jobCond, err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	jobMap := make(map[string]*metadata.Job, len(firedJobs))
+	for idx := range firedJobs {
+		jobMap[firedJobs[idx].JobID] = &firedJobs[idx]
+	}
+	for idx := range runs {
+		runs[idx].Job = jobMap[runs[idx].JobID]
+	}
+	return &metadata.ListJobScheduleRunResult{Count: int64(cnt), Info: runs}, nil
+}
+
+// nextRunTime 校验cron表达式并计算下一次调度时间
+func (lgc *Logics) nextRunTime(spec string) (time.Time, error) {
+	schedule, err := jobs.ParseSpec(spec)
+	if err != nil {
+		blog.Errorf("job schedule spec %s is invalid, err: %v, rid: %s", spec, err, lgc.rid)
+		return time.Time{}, lgc.ccErr.Errorf(common.CCErrCommParamsInvalid, "spec")
+	}
+	return schedule.Next(time.Now()), nil
+}
+
+// checkJobScheduleName 校验定时任务名称在开发商下是否重复
+func (lgc *Logics) checkJobScheduleName(ctx context.Context, name, scheduleID string) error {
+	cond := mapstr.MapStr{
+		metadata.JobScheduleNameField: name,
+		common.BKOwnerIDField:         lgc.ownerID,
+	}
+	if scheduleID != "" {
+		cond[metadata.JobScheduleIDField] = mapstr.MapStr{common.BKDBNE: scheduleID}
+	}
+	cnt, err := lgc.db.Table(common.BKTableNameAPIJobSchedule).Find(cond).Count(ctx)
+	if err != nil {
+		blog.ErrorJSON("check job schedule name failed, cond: %s, err: %s, rid: %s", cond, err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if cnt > 0 {
+		return lgc.ccErr.Errorf(common.CCErrCommDuplicateItem, metadata.JobScheduleNameField)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/task_server/jobs"
+	"configcenter/src/scene_server/task_server/taskconfig"
+)
+
+// initCodeJobConfig 注册在代码中配置的由其他服务的接口执行的任务类型
+func (s *Service) initCodeJobConfig() {
+	for _, codeJobConfig := range taskconfig.GetCodeJobConfig() {
+		addr, exists := s.getSvrAddrFunc(codeJobConfig.SvrType)
+		if !exists {
+			panicErr := fmt.Sprintf("job code init. job type:%s, svrType:%s, not exist", codeJobConfig.Name,
+				codeJobConfig.SvrType)
+			panic(panicErr)
+		}
+		jobType := jobs.JobType{
+			Name:     codeJobConfig.Name,
+			MaxRetry: codeJobConfig.MaxRetry,
+			Timeout:  codeJobConfig.Timeout,
+		}
+		jobs.RegisterHTTP(jobType, addr, codeJobConfig.Path)
+	}
+}
+
+// NewJobRunner 注册代码中配置的任务类型，并生成通用任务的执行器
+func (s *Service) NewJobRunner(concurrency int) *jobs.Runner {
+	s.initCodeJobConfig()
+	return jobs.NewRunner(s.Engine, s.DB, concurrency)
+}
+
+func (s *Service) CreateJob(ctx *rest.Contexts) {
+	input := new(metadata.CreateJobOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	job, err := srvData.lgc.CreateJob(srvData.ctx, input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(job)
+}
+
+func (s *Service) ListJob(ctx *rest.Contexts) {
+	input := new(metadata.ListJobOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	result, err := srvData.lgc.ListJob(srvData.ctx, input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+func (s *Service) DetailJob(ctx *rest.Contexts) {
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	job, err := srvData.lgc.GetJob(srvData.ctx, ctx.Request.PathParameter("job_id"))
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(job)
+}
+
+func (s *Service) CancelJob(ctx *rest.Contexts) {
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	job, err := srvData.lgc.CancelJob(srvData.ctx, ctx.Request.PathParameter("job_id"))
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(job)
+}
+
+func (s *Service) ReportJobProgress(ctx *rest.Contexts) {
+	input := new(metadata.ReportJobProgressOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	result, err := srvData.lgc.ReportJobProgress(srvData.ctx, ctx.Request.PathParameter("job_id"), input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+func (s *Service) ListJobType(ctx *rest.Contexts) {
+	ctx.RespEntity(jobs.ListTypes())
+}
@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/task_server/jobs"
+)
+
+// NewJobScheduler 生成定时任务的调度器
+func (s *Service) NewJobScheduler() *jobs.Scheduler {
+	return jobs.NewScheduler(s.DB, s.CacheDB)
+}
+
+func (s *Service) CreateJobSchedule(ctx *rest.Contexts) {
+	input := new(metadata.CreateJobScheduleOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	schedule, err := srvData.lgc.CreateJobSchedule(srvData.ctx, input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(schedule)
+}
+
+func (s *Service) UpdateJobSchedule(ctx *rest.Contexts) {
+	input := new(metadata.UpdateJobScheduleOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	schedule, err := srvData.lgc.UpdateJobSchedule(srvData.ctx, ctx.Request.PathParameter("schedule_id"), input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(schedule)
+}
+
+func (s *Service) DeleteJobSchedule(ctx *rest.Contexts) {
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	if err := srvData.lgc.DeleteJobSchedule(srvData.ctx, ctx.Request.PathParameter("schedule_id")); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+func (s *Service) ListJobSchedule(ctx *rest.Contexts) {
+	input := new(metadata.ListJobScheduleOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	result, err := srvData.lgc.ListJobSchedule(srvData.ctx, input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
+
+func (s *Service) DetailJobSchedule(ctx *rest.Contexts) {
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	schedule, err := srvData.lgc.GetJobSchedule(srvData.ctx, ctx.Request.PathParameter("schedule_id"))
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(schedule)
+}
+
+func (s *Service) ListJobScheduleHistory(ctx *rest.Contexts) {
+	input := new(metadata.ListJobScheduleRunOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	srvData := s.newSrvComm(ctx.Request.Request.Header)
+	result, err := srvData.lgc.ListJobScheduleHistory(srvData.ctx, ctx.Request.PathParameter("schedule_id"), input)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
@@ -366,23 +366,32 @@ func (s *Service) initCodeTaskConfig() map[string]TaskInfo {
 			Retry: codeTaskConfig.Retry,
 			Path:  codeTaskConfig.Path,
 		}
-		switch codeTaskConfig.SvrType {
-		case types.CC_MODULE_APISERVER:
-			ti.Addr = s.Engine.Discovery().ApiServer().GetServers
-		case types.CC_MODULE_HOST:
-			ti.Addr = s.Engine.Discovery().HostServer().GetServers
-		case types.CC_MODULE_PROC:
-			ti.Addr = s.Engine.Discovery().ProcServer().GetServers
-		case types.CC_MODULE_TOPO:
-			ti.Addr = s.Engine.Discovery().TopoServer().GetServers
-		case types.CC_MODULE_TASK:
-			ti.Addr = s.Engine.Discovery().TaskServer().GetServers
-		default:
+		addr, exists := s.getSvrAddrFunc(codeTaskConfig.SvrType)
+		if !exists {
 			panicErr := fmt.Sprintf("task code init. task:%s, svrType:%s, not exist", ti.Name, codeTaskConfig.SvrType)
 			panic(panicErr)
 		}
+		ti.Addr = addr
 		taskInfoMap[ti.Name] = ti
 	}
 
 	return taskInfoMap
 }
+
+// getSvrAddrFunc 获取服务类型对应的服务地址的函数
+func (s *Service) getSvrAddrFunc(svrType string) (func() ([]string, error), bool) {
+	switch svrType {
+	case types.CC_MODULE_APISERVER:
+		return s.Engine.Discovery().ApiServer().GetServers, true
+	case types.CC_MODULE_HOST:
+		return s.Engine.Discovery().HostServer().GetServers, true
+	case types.CC_MODULE_PROC:
+		return s.Engine.Discovery().ProcServer().GetServers, true
+	case types.CC_MODULE_TOPO:
+		return s.Engine.Discovery().TopoServer().GetServers, true
+	case types.CC_MODULE_TASK:
+		return s.Engine.Discovery().TaskServer().GetServers, true
+	default:
+		return nil, false
+	}
+}
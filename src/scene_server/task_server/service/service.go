@@ -109,6 +109,22 @@ func (s *Service) addAPIService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/task/set/status/sucess/id/{task_id}/sub_id/{sub_task_id}", Handler: s.StatusToSuccess})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/task/set/status/failure/id/{task_id}/sub_id/{sub_task_id}", Handler: s.StatusToFailure})
 
+	// generic job
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/job/create", Handler: s.CreateJob})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/job/findmany/list", Handler: s.ListJob})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/job/findone/detail/{job_id}", Handler: s.DetailJob})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/job/cancel/{job_id}", Handler: s.CancelJob})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/job/progress/{job_id}", Handler: s.ReportJobProgress})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/job/findmany/type", Handler: s.ListJobType})
+
+	// job schedule
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/job/schedule/create", Handler: s.CreateJobSchedule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/job/schedule/update/{schedule_id}", Handler: s.UpdateJobSchedule})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/job/schedule/delete/{schedule_id}", Handler: s.DeleteJobSchedule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/job/schedule/findmany/list", Handler: s.ListJobSchedule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/job/schedule/findone/detail/{schedule_id}", Handler: s.DetailJobSchedule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/job/schedule/findmany/history/{schedule_id}", Handler: s.ListJobScheduleHistory})
+
 	utility.AddToRestfulWebService(web)
 
 }
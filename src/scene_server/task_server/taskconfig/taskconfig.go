@@ -13,6 +13,9 @@
 package taskconfig
 
 import (
+	"time"
+
+	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/types"
 )
@@ -35,7 +38,19 @@ var (
 
 // init for auto task
 func init() {
-	AddCodeTaskConfig("sync-settemplate2set", types.CC_MODULE_TOPO, "/topo/v3/internal/task", 1)
+	// 集群模板同步和属性结构迁移仍然使用旧的任务队列：topo服务通过任务队列的任务详情（包括子任务的数据和状态）
+	// 计算集群的同步状态和迁移状态，迁移到通用任务需要同时改造topo服务的状态查询，新增的任务类型使用AddCodeJobConfig
+	AddCodeTaskConfig(common.SyncSetTaskName, types.CC_MODULE_TOPO, "/topo/v3/internal/task", 1)
+	AddCodeTaskConfig(common.AttributeSchemaMigrationTaskName, types.CC_MODULE_TOPO, "/topo/v3/internal/task/attribute/schema", 1)
+
+	// 按照业务下模块的主机属性自动应用规则更新主机属性，可以配置为定时任务定期执行
+	AddCodeJobConfig(CodeJobConfig{
+		Name:     common.HostApplyRunJobType,
+		SvrType:  types.CC_MODULE_HOST,
+		Path:     "/host/v3/updatemany/host_apply_plan/bk_biz_id/{bk_biz_id}/run",
+		MaxRetry: 1,
+		Timeout:  30 * time.Minute,
+	})
 }
 
 // AddCodeTaskConfig add task
@@ -53,3 +68,33 @@ func AddCodeTaskConfig(name, srvType, path string, retry int64) {
 func GetCodeTaskConfig() []CodeTaskConfig {
 	return codeTaskConfigArr
 }
+
+// CodeJobConfig 在代码中配置的由其他服务的接口执行的通用任务类型
+type CodeJobConfig struct {
+	// job type name
+	Name string
+	// service name, apiserver, host, topo, proc etc
+	SvrType string
+	// url path
+	Path string
+	// 执行失败后的最大重试次数
+	MaxRetry int64
+	// 单次执行的超时时间，为0时不超时
+	Timeout time.Duration
+}
+
+var (
+	// 在代码中配置的通用任务类型
+	codeJobConfigArr = []CodeJobConfig{}
+)
+
+// AddCodeJobConfig add job type
+func AddCodeJobConfig(config CodeJobConfig) {
+	blog.Infof("add job type. name:%s, service type:%s, path:%s", config.Name, config.SvrType, config.Path)
+	codeJobConfigArr = append(codeJobConfigArr, config)
+}
+
+// GetCodeJobConfig return code job type config
+func GetCodeJobConfig() []CodeJobConfig {
+	return codeJobConfigArr
+}
@@ -153,7 +153,7 @@ func (a *attribute) IsValid(isUpdate bool, data mapstr.MapStr) error {
 		}
 
 		option, exists := data.Get(metadata.AttributeFieldOption)
-		if exists && a.isPropertyTypeIntEnumList(propertyType) {
+		if exists && a.isPropertyTypeWithOption(propertyType) {
 			if err := util.ValidPropertyOption(propertyType, option, a.kit.CCError); nil != err {
 				return err
 			}
@@ -343,9 +343,10 @@ func (a *attribute) SetSupplierAccount(supplierAccount string) {
 	a.attr.OwnerID = supplierAccount
 }
 
-func (a *attribute) isPropertyTypeIntEnumList(propertyType string) bool {
+func (a *attribute) isPropertyTypeWithOption(propertyType string) bool {
 	switch propertyType {
-	case common.FieldTypeInt, common.FieldTypeEnum, common.FieldTypeList:
+	case common.FieldTypeInt, common.FieldTypeEnum, common.FieldTypeList, common.FieldTypeEnumMulti, common.FieldTypeIP,
+		common.FieldTypeInstRef, common.FieldTypeComputed:
 		return true
 	default:
 		return false
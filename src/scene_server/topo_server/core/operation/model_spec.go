@@ -0,0 +1,1074 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"fmt"
+	"sort"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// ModelSpecOperationInterface model spec operation methods, the spec is the declarative definition of
+// the models which can be exported from a cmdb and applied to a cmdb.
+type ModelSpecOperationInterface interface {
+	ExportModelSpec(kit *rest.Kit, opt *metadata.ExportModelSpecOption) (*metadata.ModelSpec, error)
+	PlanModelSpec(kit *rest.Kit, opt *metadata.ModelSpecOption) (*metadata.ModelSpecPlan, error)
+	// ApplyModelSpecChange apply a change of the plan, returns the id of the created classification or model.
+	ApplyModelSpecChange(kit *rest.Kit, spec *metadata.ModelSpec, change metadata.ModelSpecChange) (int64, error)
+
+	SetProxy(cls ClassificationOperationInterface, obj ObjectOperationInterface, attr AttributeOperationInterface,
+		grp GroupOperationInterface, unique UniqueOperationInterface, asst AssociationOperationInterface)
+}
+
+// NewModelSpecOperation create a new model spec operation instance
+func NewModelSpecOperation(client apimachinery.ClientSetInterface) ModelSpecOperationInterface {
+	return &modelSpec{
+		clientSet: client,
+	}
+}
+
+type modelSpec struct {
+	clientSet apimachinery.ClientSetInterface
+	cls       ClassificationOperationInterface
+	obj       ObjectOperationInterface
+	attr      AttributeOperationInterface
+	grp       GroupOperationInterface
+	unique    UniqueOperationInterface
+	asst      AssociationOperationInterface
+}
+
+func (m *modelSpec) SetProxy(cls ClassificationOperationInterface, obj ObjectOperationInterface,
+	attr AttributeOperationInterface, grp GroupOperationInterface, unique UniqueOperationInterface,
+	asst AssociationOperationInterface) {
+
+	m.cls = cls
+	m.obj = obj
+	m.attr = attr
+	m.grp = grp
+	m.unique = unique
+	m.asst = asst
+}
+
+// ExportModelSpec export the models to the spec, the preset attributes, groups and unique rules, the
+// business custom attributes and the hidden models are not exported.
+func (m *modelSpec) ExportModelSpec(kit *rest.Kit, opt *metadata.ExportModelSpecOption) (*metadata.ModelSpec,
+	error) {
+
+	objects, err := m.searchObjects(kit, mapstr.MapStr{metadata.ModelFieldIsHidden: mapstr.MapStr{common.BKDBNE: true}})
+	if err != nil {
+		return nil, err
+	}
+
+	exportCls := make(map[string]bool)
+	for _, cls := range opt.Classifications {
+		exportCls[cls] = true
+	}
+	exportObj := make(map[string]bool)
+	for _, objID := range opt.ObjectIDs {
+		exportObj[objID] = true
+	}
+
+	objIDs := make([]string, 0)
+	objMap := make(map[string]metadata.Object)
+	for _, obj := range objects {
+		if (len(exportCls) > 0 || len(exportObj) > 0) && !exportCls[obj.ObjCls] && !exportObj[obj.ObjectID] {
+			continue
+		}
+		objIDs = append(objIDs, obj.ObjectID)
+		objMap[obj.ObjectID] = obj
+		exportCls[obj.ObjCls] = true
+	}
+
+	spec := &metadata.ModelSpec{
+		Version:         metadata.ModelSpecVersion,
+		Classifications: make([]metadata.ClassificationSpec, 0),
+		Models:          make([]metadata.ObjectSpec, 0),
+		Associations:    make([]metadata.AssociationSpec, 0),
+	}
+
+	classifications, err := m.searchClassifications(kit, mapstr.MapStr{})
+	if err != nil {
+		return nil, err
+	}
+	for _, cls := range classifications {
+		if !exportCls[cls.ClassificationID] {
+			continue
+		}
+		spec.Classifications = append(spec.Classifications, metadata.ClassificationSpec{
+			ID:   cls.ClassificationID,
+			Name: cls.ClassificationName,
+			Icon: cls.ClassificationIcon,
+		})
+	}
+
+	if opt.PresetData {
+		if spec.AssociationKinds, err = m.exportAssociationKinds(kit); err != nil {
+			return nil, err
+		}
+		if spec.ServiceCategories, err = m.exportServiceCategories(kit, opt.Businesses); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(objIDs) == 0 {
+		return spec, nil
+	}
+
+	objCond := mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objIDs}}
+	attributes, err := m.searchAttributes(kit, objCond)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := m.searchGroups(kit, objCond)
+	if err != nil {
+		return nil, err
+	}
+	uniques, err := m.searchUniques(kit, objCond)
+	if err != nil {
+		return nil, err
+	}
+	associations, err := m.searchAssociations(kit, objCond)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, objID := range objIDs {
+		spec.Models = append(spec.Models, exportObjectSpec(objMap[objID], attributes, groups, uniques))
+	}
+
+	for _, asst := range associations {
+		if asst.AsstKindID == common.AssociationKindMainline || (asst.IsPre != nil && *asst.IsPre) {
+			continue
+		}
+		spec.Associations = append(spec.Associations, metadata.AssociationSpec{
+			ID:        asst.AssociationName,
+			ObjectID:  asst.ObjectID,
+			AsstObjID: asst.AsstObjID,
+			Kind:      asst.AsstKindID,
+			Name:      asst.AssociationAliasName,
+			Mapping:   asst.Mapping,
+			OnDelete:  asst.OnDelete,
+		})
+	}
+
+	return spec, nil
+}
+
+func exportObjectSpec(obj metadata.Object, attributes []metadata.Attribute, groups []metadata.Group,
+	uniques []metadata.ObjectUnique) metadata.ObjectSpec {
+
+	objSpec := metadata.ObjectSpec{
+		ID:             obj.ObjectID,
+		Name:           obj.ObjectName,
+		Classification: obj.ObjCls,
+		Icon:           obj.ObjIcon,
+		Preset:         obj.IsPre,
+	}
+
+	objGroups := make([]metadata.Group, 0)
+	for _, group := range groups {
+		if group.ObjectID == obj.ObjectID && !group.IsPre && group.BizID == 0 {
+			objGroups = append(objGroups, group)
+		}
+	}
+	sort.SliceStable(objGroups, func(i, j int) bool { return objGroups[i].GroupIndex < objGroups[j].GroupIndex })
+	for _, group := range objGroups {
+		objSpec.Groups = append(objSpec.Groups, metadata.GroupSpec{
+			ID:       group.GroupID,
+			Name:     group.GroupName,
+			Index:    group.GroupIndex,
+			Collapse: group.IsCollapse,
+		})
+	}
+
+	// the unique rules may use the preset attributes, so all the attributes are used to get the property ids.
+	propertyIDs := make(map[uint64]string)
+	objAttrs := make([]metadata.Attribute, 0)
+	for _, attr := range attributes {
+		if attr.ObjectID != obj.ObjectID {
+			continue
+		}
+		propertyIDs[uint64(attr.ID)] = attr.PropertyID
+		if !attr.IsPre && attr.BizID == 0 {
+			objAttrs = append(objAttrs, attr)
+		}
+	}
+	sort.SliceStable(objAttrs, func(i, j int) bool { return objAttrs[i].PropertyIndex < objAttrs[j].PropertyIndex })
+	for _, attr := range objAttrs {
+		option := attr.Option
+		if s, ok := option.(string); ok && s == "" {
+			option = nil
+		}
+		objSpec.Attributes = append(objSpec.Attributes, metadata.AttributeSpec{
+			ID:          attr.PropertyID,
+			Name:        attr.PropertyName,
+			Type:        attr.PropertyType,
+			Group:       attr.PropertyGroup,
+			Unit:        attr.Unit,
+			Placeholder: attr.Placeholder,
+			Editable:    attr.IsEditable,
+			Required:    attr.IsRequired,
+			Option:      option,
+			Description: attr.Description,
+		})
+	}
+
+	for _, unique := range uniques {
+		if unique.ObjID != obj.ObjectID || unique.Ispre {
+			continue
+		}
+		keys := make([]string, len(unique.Keys))
+		for idx, key := range unique.Keys {
+			keys[idx] = propertyIDs[key.ID]
+		}
+		objSpec.Uniques = append(objSpec.Uniques, metadata.UniqueSpec{Keys: keys, MustCheck: unique.MustCheck})
+	}
+
+	return objSpec
+}
+
+// exportAssociationKinds export all the association kinds, the preset ones are exported too so that the
+// associations using them can be checked, but they can not be changed.
+func (m *modelSpec) exportAssociationKinds(kit *rest.Kit) ([]metadata.AssociationKindSpec, error) {
+	kinds, err := m.searchAssociationKinds(kit, mapstr.MapStr{})
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]metadata.AssociationKindSpec, 0)
+	for _, kind := range kinds {
+		if kind.AssociationKindID == common.AssociationKindMainline {
+			continue
+		}
+		specs = append(specs, metadata.AssociationKindSpec{
+			ID:        kind.AssociationKindID,
+			Name:      kind.AssociationKindName,
+			SrcDes:    kind.SourceToDestinationNote,
+			DestDes:   kind.DestinationToSourceNote,
+			Direction: kind.Direction,
+			Preset:    kind.IsPre != nil && *kind.IsPre,
+		})
+	}
+	return specs, nil
+}
+
+// exportServiceCategories export the built-in service categories and the service categories of the
+// businesses with the names, all the businesses' are exported if the names are not set.
+func (m *modelSpec) exportServiceCategories(kit *rest.Kit, bizNames []string) ([]metadata.ServiceCategorySpec,
+	error) {
+
+	bizCond := mapstr.MapStr{}
+	if len(bizNames) > 0 {
+		bizCond[common.BKAppNameField] = mapstr.MapStr{common.BKDBIN: bizNames}
+	}
+	businesses, err := m.searchBusinesses(kit, bizCond)
+	if err != nil {
+		return nil, err
+	}
+
+	// the built-in categories are listed with every business, so they are exported with the first one.
+	bizIDs := []int64{0}
+	bizNameMap := map[int64]string{0: ""}
+	for _, biz := range businesses {
+		bizIDs = append(bizIDs, biz.BizID)
+		bizNameMap[biz.BizID] = biz.BizName
+	}
+
+	specs := make([]metadata.ServiceCategorySpec, 0)
+	for _, bizID := range bizIDs {
+		rsp, err := m.clientSet.CoreService().Process().ListServiceCategories(kit.Ctx, kit.Header,
+			metadata.ListServiceCategoriesOption{BusinessID: bizID})
+		if err != nil {
+			blog.Errorf("list service categories of business %d failed, err: %v, rid: %s", bizID, err, kit.Rid)
+			return nil, err
+		}
+
+		names := make(map[int64]string)
+		for _, info := range rsp.Info {
+			names[info.ServiceCategory.ID] = info.ServiceCategory.Name
+		}
+		for _, info := range rsp.Info {
+			category := info.ServiceCategory
+			if category.BizID != bizID {
+				continue
+			}
+			spec := metadata.ServiceCategorySpec{
+				Business: bizNameMap[bizID],
+				Name:     category.Name,
+				BuiltIn:  category.IsBuiltIn,
+			}
+			if category.ParentID != 0 {
+				spec.Parent = names[category.ParentID]
+			}
+			specs = append(specs, spec)
+		}
+	}
+	return specs, nil
+}
+
+// PlanModelSpec compares the models in cmdb with the spec and returns the changes to apply the spec.
+func (m *modelSpec) PlanModelSpec(kit *rest.Kit, opt *metadata.ModelSpecOption) (*metadata.ModelSpecPlan, error) {
+	if err := validateModelSpec(kit, &opt.Spec); err != nil {
+		return nil, err
+	}
+
+	current, err := m.ExportModelSpec(kit, &metadata.ExportModelSpecOption{})
+	if err != nil {
+		return nil, err
+	}
+
+	// the preset data is compared only when it's in the spec.
+	if len(opt.Spec.AssociationKinds) > 0 {
+		if current.AssociationKinds, err = m.exportAssociationKinds(kit); err != nil {
+			return nil, err
+		}
+	}
+
+	bizNames := make([]string, 0)
+	for _, category := range opt.Spec.ServiceCategories {
+		if !category.BuiltIn {
+			bizNames = append(bizNames, category.Business)
+		}
+	}
+	existBiz := make(map[string]bool)
+	if len(bizNames) > 0 {
+		if current.ServiceCategories, err = m.exportServiceCategories(kit, bizNames); err != nil {
+			return nil, err
+		}
+		businesses, err := m.searchBusinesses(kit, mapstr.MapStr{common.BKAppNameField: mapstr.MapStr{
+			common.BKDBIN: bizNames}})
+		if err != nil {
+			return nil, err
+		}
+		for _, biz := range businesses {
+			existBiz[biz.BizName] = true
+		}
+	}
+
+	changes := diffModelSpec(current, &opt.Spec, opt.Prune)
+	for idx, change := range changes {
+		if change.Kind == metadata.ModelSpecKindServiceCategory && change.Conflict == "" &&
+			!existBiz[change.ObjectID] {
+			changes[idx].Conflict = fmt.Sprintf("the business %s does not exist", change.ObjectID)
+		}
+	}
+	return &metadata.ModelSpecPlan{Changes: changes}, nil
+}
+
+// validateModelSpec checks the required fields and the duplicate ids of the spec.
+func validateModelSpec(kit *rest.Kit, spec *metadata.ModelSpec) error {
+	if spec.Version != "" && spec.Version != metadata.ModelSpecVersion {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "version")
+	}
+
+	clsIDs := make(map[string]bool)
+	for _, cls := range spec.Classifications {
+		if cls.ID == "" || cls.Name == "" {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "classifications.id/name")
+		}
+		if clsIDs[cls.ID] {
+			return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, "classification "+cls.ID)
+		}
+		clsIDs[cls.ID] = true
+	}
+
+	objIDs := make(map[string]bool)
+	for _, obj := range spec.Models {
+		if obj.ID == "" || obj.Name == "" || obj.Classification == "" {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "models.id/name/classification")
+		}
+		if objIDs[obj.ID] {
+			return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, "model "+obj.ID)
+		}
+		objIDs[obj.ID] = true
+
+		groupIDs := make(map[string]bool)
+		for _, group := range obj.Groups {
+			if group.ID == "" || group.Name == "" {
+				return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, obj.ID+".groups.id/name")
+			}
+			if groupIDs[group.ID] {
+				return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, obj.ID+" group "+group.ID)
+			}
+			groupIDs[group.ID] = true
+		}
+
+		propertyIDs := make(map[string]bool)
+		for _, attr := range obj.Attributes {
+			if attr.ID == "" || attr.Name == "" || attr.Type == "" {
+				return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, obj.ID+".attributes.id/name/type")
+			}
+			if propertyIDs[attr.ID] {
+				return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, obj.ID+" attribute "+attr.ID)
+			}
+			propertyIDs[attr.ID] = true
+		}
+
+		uniqueIDs := make(map[string]bool)
+		for _, unique := range obj.Uniques {
+			if len(unique.Keys) == 0 {
+				return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, obj.ID+".uniques.keys")
+			}
+			id := uniqueSpecID(unique.Keys)
+			if uniqueIDs[id] {
+				return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, obj.ID+" unique "+id)
+			}
+			uniqueIDs[id] = true
+		}
+	}
+
+	kindIDs := make(map[string]bool)
+	for _, kind := range spec.AssociationKinds {
+		if kind.ID == "" || kind.Name == "" {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "association_kinds.id/name")
+		}
+		if kindIDs[kind.ID] {
+			return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, "association kind "+kind.ID)
+		}
+		kindIDs[kind.ID] = true
+	}
+
+	categoryIDs := make(map[string]bool)
+	for _, category := range spec.ServiceCategories {
+		if category.Name == "" || (category.Business == "" && !category.BuiltIn) {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "service_categories.business/name")
+		}
+		id := category.Business + "/" + serviceCategorySpecID(category)
+		if categoryIDs[id] {
+			return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, "service category "+id)
+		}
+		categoryIDs[id] = true
+	}
+
+	asstIDs := make(map[string]bool)
+	for _, asst := range spec.Associations {
+		if asst.ObjectID == "" || asst.AsstObjID == "" || asst.Kind == "" || asst.Mapping == "" {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "associations.object/asst_object/kind/mapping")
+		}
+		id := associationSpecID(asst)
+		if asstIDs[id] {
+			return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, "association "+id)
+		}
+		asstIDs[id] = true
+	}
+	return nil
+}
+
+func (m *modelSpec) ApplyModelSpecChange(kit *rest.Kit, spec *metadata.ModelSpec,
+	change metadata.ModelSpecChange) (int64, error) {
+
+	switch change.Kind {
+	case metadata.ModelSpecKindAssociationKind:
+		return m.applyAssociationKind(kit, spec, change)
+	case metadata.ModelSpecKindServiceCategory:
+		return 0, m.applyServiceCategory(kit, spec, change)
+	case metadata.ModelSpecKindClassification:
+		return m.applyClassification(kit, spec, change)
+	case metadata.ModelSpecKindModel:
+		return m.applyModel(kit, spec, change)
+	case metadata.ModelSpecKindGroup:
+		return 0, m.applyGroup(kit, spec, change)
+	case metadata.ModelSpecKindAttribute:
+		return 0, m.applyAttribute(kit, spec, change)
+	case metadata.ModelSpecKindUnique:
+		return 0, m.applyUnique(kit, spec, change)
+	case metadata.ModelSpecKindAssociation:
+		return 0, m.applyAssociation(kit, spec, change)
+	default:
+		return 0, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "kind")
+	}
+}
+
+func (m *modelSpec) applyAssociationKind(kit *rest.Kit, spec *metadata.ModelSpec,
+	change metadata.ModelSpecChange) (int64, error) {
+
+	var kindSpec *metadata.AssociationKindSpec
+	for idx := range spec.AssociationKinds {
+		if spec.AssociationKinds[idx].ID == change.ID {
+			kindSpec = &spec.AssociationKinds[idx]
+		}
+	}
+	if kindSpec == nil {
+		return 0, kit.CCError.CCErrorf(common.CCErrCommNotFound)
+	}
+
+	if change.Action == metadata.ModelSpecCreate {
+		rsp, err := m.asst.CreateType(kit, &metadata.AssociationKind{
+			AssociationKindID:       kindSpec.ID,
+			AssociationKindName:     kindSpec.Name,
+			OwnerID:                 kit.SupplierAccount,
+			SourceToDestinationNote: kindSpec.SrcDes,
+			DestinationToSourceNote: kindSpec.DestDes,
+			Direction:               kindSpec.Direction,
+		})
+		if err != nil {
+			return 0, err
+		}
+		return rsp.Data.ID, nil
+	}
+
+	kinds, err := m.searchAssociationKinds(kit, mapstr.MapStr{common.AssociationKindIDField: change.ID})
+	if err != nil {
+		return 0, err
+	}
+	if len(kinds) == 0 {
+		return 0, kit.CCError.CCErrorf(common.CCErrCommNotFound)
+	}
+	rsp, err := m.asst.UpdateType(kit, kinds[0].ID, &metadata.UpdateAssociationTypeRequest{
+		AsstName:  kindSpec.Name,
+		SrcDes:    kindSpec.SrcDes,
+		DestDes:   kindSpec.DestDes,
+		Direction: string(kindSpec.Direction),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !rsp.Result {
+		return 0, rsp.CCError()
+	}
+	return 0, nil
+}
+
+func (m *modelSpec) applyServiceCategory(kit *rest.Kit, spec *metadata.ModelSpec,
+	change metadata.ModelSpecChange) error {
+
+	var categorySpec *metadata.ServiceCategorySpec
+	for idx := range spec.ServiceCategories {
+		category := spec.ServiceCategories[idx]
+		if category.Business == change.ObjectID && serviceCategorySpecID(category) == change.ID {
+			categorySpec = &spec.ServiceCategories[idx]
+		}
+	}
+	if categorySpec == nil {
+		return kit.CCError.CCErrorf(common.CCErrCommNotFound)
+	}
+
+	businesses, err := m.searchBusinesses(kit, mapstr.MapStr{common.BKAppNameField: categorySpec.Business})
+	if err != nil {
+		return err
+	}
+	if len(businesses) == 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKAppNameField)
+	}
+	category := &metadata.ServiceCategory{BizID: businesses[0].BizID, Name: categorySpec.Name}
+
+	if categorySpec.Parent != "" {
+		rsp, err := m.clientSet.CoreService().Process().ListServiceCategories(kit.Ctx, kit.Header,
+			metadata.ListServiceCategoriesOption{BusinessID: category.BizID})
+		if err != nil {
+			blog.Errorf("list service categories of business %d failed, err: %v, rid: %s", category.BizID, err,
+				kit.Rid)
+			return err
+		}
+		for _, info := range rsp.Info {
+			if info.ServiceCategory.ParentID == 0 && info.ServiceCategory.Name == categorySpec.Parent {
+				category.ParentID = info.ServiceCategory.ID
+			}
+		}
+		if category.ParentID == 0 {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "parent "+categorySpec.Parent)
+		}
+	}
+
+	if _, err := m.clientSet.CoreService().Process().CreateServiceCategory(kit.Ctx, kit.Header,
+		category); err != nil {
+		blog.Errorf("create service category %#v failed, err: %v, rid: %s", category, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+func (m *modelSpec) applyClassification(kit *rest.Kit, spec *metadata.ModelSpec,
+	change metadata.ModelSpecChange) (int64, error) {
+
+	var clsSpec *metadata.ClassificationSpec
+	for idx := range spec.Classifications {
+		if spec.Classifications[idx].ID == change.ID {
+			clsSpec = &spec.Classifications[idx]
+		}
+	}
+	if clsSpec == nil {
+		return 0, kit.CCError.CCErrorf(common.CCErrCommNotFound)
+	}
+
+	data := mapstr.MapStr{
+		common.BKClassificationNameField: clsSpec.Name,
+		common.BKClassificationIconField: clsSpec.Icon,
+	}
+
+	if change.Action == metadata.ModelSpecCreate {
+		data[common.BKClassificationIDField] = clsSpec.ID
+		cls, err := m.cls.CreateClassification(kit, data)
+		if err != nil {
+			return 0, err
+		}
+		return cls.Classify().ID, nil
+	}
+
+	classifications, err := m.searchClassifications(kit, mapstr.MapStr{common.BKClassificationIDField: change.ID})
+	if err != nil {
+		return 0, err
+	}
+	if len(classifications) == 0 {
+		return 0, kit.CCError.CCErrorf(common.CCErrCommNotFound)
+	}
+	return 0, m.cls.UpdateClassification(kit, data, classifications[0].ID, nil)
+}
+
+func (m *modelSpec) applyModel(kit *rest.Kit, spec *metadata.ModelSpec, change metadata.ModelSpecChange) (int64,
+	error) {
+
+	if change.Action == metadata.ModelSpecDelete {
+		obj, err := m.findObject(kit, change.ID)
+		if err != nil {
+			return 0, err
+		}
+		return 0, m.obj.DeleteObject(kit, obj.ID, true)
+	}
+
+	objSpec := findObjectSpec(spec, change.ID)
+	if objSpec == nil {
+		return 0, kit.CCError.CCErrorf(common.CCErrCommNotFound)
+	}
+
+	data := mapstr.MapStr{
+		common.BKObjNameField: objSpec.Name,
+		common.BKObjIconField: objSpec.Icon,
+	}
+
+	if change.Action == metadata.ModelSpecCreate {
+		data[common.BKObjIDField] = objSpec.ID
+		data[common.BKClassificationIDField] = objSpec.Classification
+		obj, err := m.obj.CreateObject(kit, false, data)
+		if err != nil {
+			return 0, err
+		}
+		return obj.Object().ID, nil
+	}
+
+	obj, err := m.findObject(kit, change.ID)
+	if err != nil {
+		return 0, err
+	}
+	return 0, m.obj.UpdateObject(kit, data, obj.ID)
+}
+
+func (m *modelSpec) applyGroup(kit *rest.Kit, spec *metadata.ModelSpec, change metadata.ModelSpecChange) error {
+	groups, err := m.searchGroups(kit, mapstr.MapStr{
+		common.BKObjIDField:        change.ObjectID,
+		metadata.GroupFieldGroupID: change.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the business custom groups are not managed by the spec.
+	for idx := 0; idx < len(groups); idx++ {
+		if groups[idx].BizID != 0 {
+			groups = append(groups[:idx], groups[idx+1:]...)
+			idx--
+		}
+	}
+
+	if change.Action == metadata.ModelSpecDelete {
+		if len(groups) == 0 {
+			return nil
+		}
+		return m.grp.DeleteObjectGroup(kit, groups[0].ID)
+	}
+
+	var groupSpec *metadata.GroupSpec
+	if objSpec := findObjectSpec(spec, change.ObjectID); objSpec != nil {
+		for idx := range objSpec.Groups {
+			if objSpec.Groups[idx].ID == change.ID {
+				groupSpec = &objSpec.Groups[idx]
+			}
+		}
+	}
+	if groupSpec == nil {
+		return kit.CCError.CCErrorf(common.CCErrCommNotFound)
+	}
+
+	// the default group is created with the model, so it's updated instead.
+	if len(groups) > 0 {
+		cond := &metadata.UpdateGroupCondition{}
+		cond.Condition.ID = groups[0].ID
+		cond.Data.Name = &groupSpec.Name
+		cond.Data.Index = &groupSpec.Index
+		cond.Data.IsCollapse = &groupSpec.Collapse
+		return m.grp.UpdateObjectGroup(kit, cond)
+	}
+
+	data := mapstr.MapStr{
+		metadata.GroupFieldObjectID:   change.ObjectID,
+		metadata.GroupFieldGroupID:    groupSpec.ID,
+		metadata.GroupFieldGroupName:  groupSpec.Name,
+		metadata.GroupFieldGroupIndex: groupSpec.Index,
+		"is_collapse":                 groupSpec.Collapse,
+	}
+	_, err = m.grp.CreateObjectGroup(kit, data, 0)
+	return err
+}
+
+func (m *modelSpec) applyAttribute(kit *rest.Kit, spec *metadata.ModelSpec, change metadata.ModelSpecChange) error {
+	if change.Action == metadata.ModelSpecDelete {
+		attr, err := m.findAttribute(kit, change.ObjectID, change.ID)
+		if err != nil {
+			return err
+		}
+		cond := condition.CreateCondition()
+		cond.Field(common.BKFieldID).Eq(attr.ID)
+		return m.attr.DeleteObjectAttribute(kit, cond, 0)
+	}
+
+	var attrSpec *metadata.AttributeSpec
+	if objSpec := findObjectSpec(spec, change.ObjectID); objSpec != nil {
+		for idx := range objSpec.Attributes {
+			if objSpec.Attributes[idx].ID == change.ID {
+				attrSpec = &objSpec.Attributes[idx]
+			}
+		}
+	}
+	if attrSpec == nil {
+		return kit.CCError.CCErrorf(common.CCErrCommNotFound)
+	}
+
+	data := mapstr.MapStr{
+		metadata.AttributeFieldPropertyName:  attrSpec.Name,
+		metadata.AttributeFieldPropertyGroup: attrSpec.Group,
+		metadata.AttributeFieldUnit:          attrSpec.Unit,
+		metadata.AttributeFieldPlaceHolder:   attrSpec.Placeholder,
+		metadata.AttributeFieldIsEditable:    attrSpec.Editable,
+		metadata.AttributeFieldIsRequired:    attrSpec.Required,
+		metadata.AttributeFieldOption:        attrSpec.Option,
+		metadata.AttributeFieldDescription:   attrSpec.Description,
+	}
+
+	if change.Action == metadata.ModelSpecCreate {
+		data[metadata.AttributeFieldObjectID] = change.ObjectID
+		data[metadata.AttributeFieldPropertyID] = attrSpec.ID
+		data[metadata.AttributeFieldPropertyType] = attrSpec.Type
+		_, err := m.attr.CreateObjectAttribute(kit, data, 0)
+		return err
+	}
+
+	attr, err := m.findAttribute(kit, change.ObjectID, change.ID)
+	if err != nil {
+		return err
+	}
+	return m.attr.UpdateObjectAttribute(kit, data, attr.ID, 0)
+}
+
+func (m *modelSpec) applyUnique(kit *rest.Kit, spec *metadata.ModelSpec, change metadata.ModelSpecChange) error {
+	attributes, err := m.searchAttributes(kit, mapstr.MapStr{common.BKObjIDField: change.ObjectID})
+	if err != nil {
+		return err
+	}
+	propertyIDs := make(map[uint64]string)
+	attrIDs := make(map[string]uint64)
+	for _, attr := range attributes {
+		propertyIDs[uint64(attr.ID)] = attr.PropertyID
+		attrIDs[attr.PropertyID] = uint64(attr.ID)
+	}
+
+	uniques, err := m.unique.Search(kit, change.ObjectID)
+	if err != nil {
+		return err
+	}
+
+	var exist *metadata.ObjectUnique
+	for idx, unique := range uniques {
+		keys := make([]string, len(unique.Keys))
+		for keyIdx, key := range unique.Keys {
+			keys[keyIdx] = propertyIDs[key.ID]
+		}
+		if uniqueSpecID(keys) == change.ID {
+			exist = &uniques[idx]
+			break
+		}
+	}
+
+	if change.Action == metadata.ModelSpecDelete {
+		if exist == nil {
+			return nil
+		}
+		return m.unique.Delete(kit, change.ObjectID, exist.ID)
+	}
+
+	var uniqueSpec *metadata.UniqueSpec
+	if objSpec := findObjectSpec(spec, change.ObjectID); objSpec != nil {
+		for idx := range objSpec.Uniques {
+			if uniqueSpecID(objSpec.Uniques[idx].Keys) == change.ID {
+				uniqueSpec = &objSpec.Uniques[idx]
+			}
+		}
+	}
+	if uniqueSpec == nil {
+		return kit.CCError.CCErrorf(common.CCErrCommNotFound)
+	}
+
+	// the default unique rule is created with the model, so it's updated instead.
+	if exist != nil {
+		return m.unique.Update(kit, change.ObjectID, exist.ID, &metadata.UpdateUniqueRequest{
+			MustCheck: uniqueSpec.MustCheck,
+			Keys:      exist.Keys,
+		})
+	}
+
+	keys := make([]metadata.UniqueKey, len(uniqueSpec.Keys))
+	for idx, propertyID := range uniqueSpec.Keys {
+		attrID, ok := attrIDs[propertyID]
+		if !ok {
+			blog.Errorf("unique key %s of model %s is not exist, rid: %s", propertyID, change.ObjectID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, fmt.Sprintf("%s unique key %s",
+				change.ObjectID, propertyID))
+		}
+		keys[idx] = metadata.UniqueKey{Kind: metadata.UniqueKeyKindProperty, ID: attrID}
+	}
+
+	_, err = m.unique.Create(kit, change.ObjectID, &metadata.CreateUniqueRequest{
+		ObjID:     change.ObjectID,
+		MustCheck: uniqueSpec.MustCheck,
+		Keys:      keys,
+	})
+	return err
+}
+
+func (m *modelSpec) applyAssociation(kit *rest.Kit, spec *metadata.ModelSpec,
+	change metadata.ModelSpecChange) error {
+
+	associations, err := m.searchAssociations(kit, mapstr.MapStr{common.AssociationObjAsstIDField: change.ID})
+	if err != nil {
+		return err
+	}
+
+	if change.Action == metadata.ModelSpecDelete {
+		if len(associations) == 0 {
+			return nil
+		}
+		return m.asst.DeleteAssociationWithPreCheck(kit, associations[0].ID)
+	}
+
+	var asstSpec *metadata.AssociationSpec
+	for idx := range spec.Associations {
+		if associationSpecID(spec.Associations[idx]) == change.ID {
+			asstSpec = &spec.Associations[idx]
+		}
+	}
+	if asstSpec == nil {
+		return kit.CCError.CCErrorf(common.CCErrCommNotFound)
+	}
+
+	if change.Action == metadata.ModelSpecCreate {
+		_, err := m.asst.CreateCommonAssociation(kit, &metadata.Association{
+			AssociationName:      change.ID,
+			AssociationAliasName: asstSpec.Name,
+			ObjectID:             asstSpec.ObjectID,
+			AsstObjID:            asstSpec.AsstObjID,
+			AsstKindID:           asstSpec.Kind,
+			Mapping:              asstSpec.Mapping,
+			OnDelete:             asstSpec.OnDelete,
+		})
+		return err
+	}
+
+	if len(associations) == 0 {
+		return kit.CCError.CCError(common.CCErrorTopoObjectAssociationNotExist)
+	}
+	data := mapstr.MapStr{"bk_obj_asst_name": asstSpec.Name}
+	if asstSpec.OnDelete != "" {
+		data["on_delete"] = asstSpec.OnDelete
+	}
+	return m.asst.UpdateAssociation(kit, data, associations[0].ID)
+}
+
+func findObjectSpec(spec *metadata.ModelSpec, objID string) *metadata.ObjectSpec {
+	for idx := range spec.Models {
+		if spec.Models[idx].ID == objID {
+			return &spec.Models[idx]
+		}
+	}
+	return nil
+}
+
+func (m *modelSpec) findObject(kit *rest.Kit, objID string) (*metadata.Object, error) {
+	objects, err := m.searchObjects(kit, mapstr.MapStr{common.BKObjIDField: objID})
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrTopoObjectSelectFailed, objID)
+	}
+	return &objects[0], nil
+}
+
+func (m *modelSpec) findAttribute(kit *rest.Kit, objID, propertyID string) (*metadata.Attribute, error) {
+	attributes, err := m.searchAttributes(kit, mapstr.MapStr{
+		common.BKObjIDField:      objID,
+		common.BKPropertyIDField: propertyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the business custom attributes are not managed by the spec.
+	for idx := range attributes {
+		if attributes[idx].BizID == 0 {
+			return &attributes[idx], nil
+		}
+	}
+	return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, objID+"."+propertyID)
+}
+
+func (m *modelSpec) searchClassifications(kit *rest.Kit, cond mapstr.MapStr) ([]metadata.Classification, error) {
+	rsp, err := m.clientSet.CoreService().Model().ReadModelClassification(kit.Ctx, kit.Header,
+		&metadata.QueryCondition{Condition: cond})
+	if err != nil {
+		blog.Errorf("search classifications failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("search classifications failed, cond: %#v, err: %s, rid: %s", cond, rsp.ErrMsg, kit.Rid)
+		return nil, rsp.CCError()
+	}
+	return rsp.Data.Info, nil
+}
+
+func (m *modelSpec) searchObjects(kit *rest.Kit, cond mapstr.MapStr) ([]metadata.Object, error) {
+	rsp, err := m.clientSet.CoreService().Model().ReadModel(kit.Ctx, kit.Header,
+		&metadata.QueryCondition{Condition: cond})
+	if err != nil {
+		blog.Errorf("search models failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("search models failed, cond: %#v, err: %s, rid: %s", cond, rsp.ErrMsg, kit.Rid)
+		return nil, rsp.CCError()
+	}
+
+	objects := make([]metadata.Object, len(rsp.Data.Info))
+	for idx, info := range rsp.Data.Info {
+		objects[idx] = info.Spec
+	}
+	return objects, nil
+}
+
+func (m *modelSpec) searchAttributes(kit *rest.Kit, cond mapstr.MapStr) ([]metadata.Attribute, error) {
+	rsp, err := m.clientSet.CoreService().Model().ReadModelAttrByCondition(kit.Ctx, kit.Header,
+		&metadata.QueryCondition{Condition: cond})
+	if err != nil {
+		blog.Errorf("search attributes failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("search attributes failed, cond: %#v, err: %s, rid: %s", cond, rsp.ErrMsg, kit.Rid)
+		return nil, rsp.CCError()
+	}
+	return rsp.Data.Info, nil
+}
+
+func (m *modelSpec) searchGroups(kit *rest.Kit, cond mapstr.MapStr) ([]metadata.Group, error) {
+	rsp, err := m.clientSet.CoreService().Model().ReadAttributeGroupByCondition(kit.Ctx, kit.Header,
+		metadata.QueryCondition{Condition: cond})
+	if err != nil {
+		blog.Errorf("search attribute groups failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("search attribute groups failed, cond: %#v, err: %s, rid: %s", cond, rsp.ErrMsg, kit.Rid)
+		return nil, rsp.CCError()
+	}
+	return rsp.Data.Info, nil
+}
+
+func (m *modelSpec) searchUniques(kit *rest.Kit, cond mapstr.MapStr) ([]metadata.ObjectUnique, error) {
+	rsp, err := m.clientSet.CoreService().Model().ReadModelAttrUnique(kit.Ctx, kit.Header,
+		metadata.QueryCondition{Condition: cond})
+	if err != nil {
+		blog.Errorf("search unique rules failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("search unique rules failed, cond: %#v, err: %s, rid: %s", cond, rsp.ErrMsg, kit.Rid)
+		return nil, rsp.CCError()
+	}
+	return rsp.Data.Info, nil
+}
+
+func (m *modelSpec) searchAssociationKinds(kit *rest.Kit, cond mapstr.MapStr) ([]*metadata.AssociationKind,
+	error) {
+
+	rsp, err := m.clientSet.CoreService().Association().ReadAssociationType(kit.Ctx, kit.Header,
+		&metadata.QueryCondition{Condition: cond})
+	if err != nil {
+		blog.Errorf("search association kinds failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("search association kinds failed, cond: %#v, err: %s, rid: %s", cond, rsp.ErrMsg, kit.Rid)
+		return nil, rsp.CCError()
+	}
+	return rsp.Data.Info, nil
+}
+
+func (m *modelSpec) searchBusinesses(kit *rest.Kit, cond mapstr.MapStr) ([]metadata.BizBasicInfo, error) {
+	rsp, err := m.clientSet.CoreService().Instance().ReadInstance(kit.Ctx, kit.Header, common.BKInnerObjIDApp,
+		&metadata.QueryCondition{
+			Fields:    []string{common.BKAppIDField, common.BKAppNameField},
+			Condition: cond,
+		})
+	if err != nil {
+		blog.Errorf("search businesses failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("search businesses failed, cond: %#v, err: %s, rid: %s", cond, rsp.ErrMsg, kit.Rid)
+		return nil, rsp.CCError()
+	}
+
+	businesses := make([]metadata.BizBasicInfo, 0)
+	for _, info := range rsp.Data.Info {
+		bizID, err := util.GetInt64ByInterface(info[common.BKAppIDField])
+		if err != nil {
+			blog.Errorf("parse business id %#v failed, err: %v, rid: %s", info, err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField)
+		}
+		businesses = append(businesses, metadata.BizBasicInfo{
+			BizID:   bizID,
+			BizName: util.GetStrByInterface(info[common.BKAppNameField]),
+		})
+	}
+	return businesses, nil
+}
+
+func (m *modelSpec) searchAssociations(kit *rest.Kit, cond mapstr.MapStr) ([]metadata.Association, error) {
+	rsp, err := m.clientSet.CoreService().Association().ReadModelAssociation(kit.Ctx, kit.Header,
+		&metadata.QueryCondition{Condition: cond})
+	if err != nil {
+		blog.Errorf("search model associations failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("search model associations failed, cond: %#v, err: %s, rid: %s", cond, rsp.ErrMsg, kit.Rid)
+		return nil, rsp.CCError()
+	}
+	return rsp.Data.Info, nil
+}
@@ -0,0 +1,506 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/model"
+)
+
+// modelSpecDiffer compares the current models exported from cmdb with the desired spec, the changes are
+// collected by phases so that they can be applied in order, e.g. the attributes are created before the
+// unique rules which use them, and the unique rules are deleted before the attributes in them.
+type modelSpecDiffer struct {
+	prune   bool
+	creates map[metadata.ModelSpecKind][]metadata.ModelSpecChange
+	deletes map[metadata.ModelSpecKind][]metadata.ModelSpecChange
+}
+
+var (
+	modelSpecCreateOrder = []metadata.ModelSpecKind{
+		metadata.ModelSpecKindAssociationKind,
+		metadata.ModelSpecKindServiceCategory,
+		metadata.ModelSpecKindClassification,
+		metadata.ModelSpecKindModel,
+		metadata.ModelSpecKindGroup,
+		metadata.ModelSpecKindAttribute,
+		metadata.ModelSpecKindUnique,
+		metadata.ModelSpecKindAssociation,
+	}
+
+	modelSpecDeleteOrder = []metadata.ModelSpecKind{
+		metadata.ModelSpecKindAssociation,
+		metadata.ModelSpecKindUnique,
+		metadata.ModelSpecKindAttribute,
+		metadata.ModelSpecKindGroup,
+		metadata.ModelSpecKindModel,
+	}
+)
+
+// diffModelSpec returns the changes to make the current models the same as the desired spec, the
+// creations and updates come first and then the deletions.
+func diffModelSpec(current, desired *metadata.ModelSpec, prune bool) []metadata.ModelSpecChange {
+	d := &modelSpecDiffer{
+		prune:   prune,
+		creates: make(map[metadata.ModelSpecKind][]metadata.ModelSpecChange),
+		deletes: make(map[metadata.ModelSpecKind][]metadata.ModelSpecChange),
+	}
+
+	d.diffAssociationKinds(current.AssociationKinds, desired.AssociationKinds)
+	d.diffServiceCategories(current.ServiceCategories, desired.ServiceCategories)
+	d.diffClassifications(current.Classifications, desired.Classifications)
+	d.diffModels(current, desired)
+	d.diffAssociations(current, desired)
+
+	changes := make([]metadata.ModelSpecChange, 0)
+	for _, kind := range modelSpecCreateOrder {
+		changes = append(changes, d.creates[kind]...)
+	}
+	for _, kind := range modelSpecDeleteOrder {
+		changes = append(changes, d.deletes[kind]...)
+	}
+	return changes
+}
+
+func (d *modelSpecDiffer) add(change metadata.ModelSpecChange) {
+	if change.Action == metadata.ModelSpecDelete {
+		d.deletes[change.Kind] = append(d.deletes[change.Kind], change)
+		return
+	}
+	d.creates[change.Kind] = append(d.creates[change.Kind], change)
+}
+
+// diffAssociationKinds compares the association kinds, they are never deleted by the spec because they
+// may be used by the associations which are not managed by the spec.
+func (d *modelSpecDiffer) diffAssociationKinds(current, desired []metadata.AssociationKindSpec) {
+	currentMap := make(map[string]metadata.AssociationKindSpec)
+	for _, kind := range current {
+		currentMap[kind.ID] = kind
+	}
+
+	for _, kind := range desired {
+		change := metadata.ModelSpecChange{Kind: metadata.ModelSpecKindAssociationKind, ID: kind.ID}
+		exist, ok := currentMap[kind.ID]
+		if !ok {
+			change.Action = metadata.ModelSpecCreate
+			if kind.Preset {
+				change.Conflict = "the preset association kind does not exist, the cmdb versions may be different"
+			}
+			d.add(change)
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		compareSpecField(fields, "name", exist.Name, kind.Name)
+		compareSpecField(fields, "src_des", exist.SrcDes, kind.SrcDes)
+		compareSpecField(fields, "dest_des", exist.DestDes, kind.DestDes)
+		compareSpecField(fields, "direction", exist.Direction, kind.Direction)
+		if len(fields) == 0 {
+			continue
+		}
+
+		change.Action = metadata.ModelSpecUpdate
+		change.Fields = fields
+		if exist.Preset {
+			change.Conflict = "the preset association kind can not be changed"
+		}
+		d.add(change)
+	}
+}
+
+// serviceCategorySpecID returns the id of the service category in it's business, which is the parent's
+// name and it's name joined by slash for a second level category.
+func serviceCategorySpecID(category metadata.ServiceCategorySpec) string {
+	if category.Parent == "" {
+		return category.Name
+	}
+	return category.Parent + "/" + category.Name
+}
+
+// diffServiceCategories compares the service categories of the businesses, the categories only have names,
+// so they are created if not exist and never updated or deleted. the built-in categories are shared by all
+// the businesses, they can be the parents of the business's categories but are never created.
+func (d *modelSpecDiffer) diffServiceCategories(current, desired []metadata.ServiceCategorySpec) {
+	exists := make(map[string]bool)
+	for _, category := range current {
+		exists[category.Business+"/"+serviceCategorySpecID(category)] = true
+	}
+
+	parents := make(map[string]bool)
+	for _, categories := range [][]metadata.ServiceCategorySpec{current, desired} {
+		for _, category := range categories {
+			if category.Parent == "" {
+				parents[category.Business+"/"+category.Name] = true
+			}
+		}
+	}
+
+	for _, category := range desired {
+		id := serviceCategorySpecID(category)
+		if category.BuiltIn || exists[category.Business+"/"+id] {
+			continue
+		}
+
+		change := metadata.ModelSpecChange{
+			Action:   metadata.ModelSpecCreate,
+			Kind:     metadata.ModelSpecKindServiceCategory,
+			ObjectID: category.Business,
+			ID:       id,
+		}
+		if category.Parent != "" && !parents[category.Business+"/"+category.Parent] && !parents["/"+category.Parent] {
+			change.Conflict = fmt.Sprintf("the parent service category %s does not exist", category.Parent)
+		}
+		d.add(change)
+	}
+}
+
+// diffClassifications compares the classifications, they are never deleted by the spec because the
+// models in them may not be managed by the spec.
+func (d *modelSpecDiffer) diffClassifications(current, desired []metadata.ClassificationSpec) {
+	currentMap := make(map[string]metadata.ClassificationSpec)
+	for _, cls := range current {
+		currentMap[cls.ID] = cls
+	}
+
+	for _, cls := range desired {
+		change := metadata.ModelSpecChange{Kind: metadata.ModelSpecKindClassification, ID: cls.ID}
+		exist, ok := currentMap[cls.ID]
+		if !ok {
+			change.Action = metadata.ModelSpecCreate
+			d.add(change)
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		compareSpecField(fields, "name", exist.Name, cls.Name)
+		compareSpecField(fields, "icon", exist.Icon, cls.Icon)
+		if len(fields) > 0 {
+			change.Action = metadata.ModelSpecUpdate
+			change.Fields = fields
+			d.add(change)
+		}
+	}
+}
+
+func (d *modelSpecDiffer) diffModels(current, desired *metadata.ModelSpec) {
+	currentMap := make(map[string]metadata.ObjectSpec)
+	for _, obj := range current.Models {
+		currentMap[obj.ID] = obj
+	}
+
+	desiredMap := make(map[string]bool)
+	for _, obj := range desired.Models {
+		desiredMap[obj.ID] = true
+		exist, ok := currentMap[obj.ID]
+		if !ok {
+			d.add(metadata.ModelSpecChange{
+				Action: metadata.ModelSpecCreate,
+				Kind:   metadata.ModelSpecKindModel,
+				ID:     obj.ID,
+			})
+			// the model's default group and unique rule are created with it, they are updated when applied.
+			d.diffGroups(obj.ID, nil, obj.Groups)
+			d.diffAttributes(obj.ID, nil, obj.Attributes)
+			d.diffUniques(obj.ID, nil, obj.Uniques)
+			continue
+		}
+
+		// the preset model's own definition is not managed by the spec.
+		if !exist.Preset {
+			fields := make(map[string]interface{})
+			compareSpecField(fields, "name", exist.Name, obj.Name)
+			compareSpecField(fields, "classification", exist.Classification, obj.Classification)
+			compareSpecField(fields, "icon", exist.Icon, obj.Icon)
+			if len(fields) > 0 {
+				change := metadata.ModelSpecChange{
+					Action: metadata.ModelSpecUpdate,
+					Kind:   metadata.ModelSpecKindModel,
+					ID:     obj.ID,
+					Fields: fields,
+				}
+				if _, clsChanged := fields["classification"]; clsChanged {
+					change.Conflict = fmt.Sprintf("the classification can not be changed from %s to %s",
+						exist.Classification, obj.Classification)
+				}
+				d.add(change)
+			}
+		}
+
+		d.diffGroups(obj.ID, exist.Groups, obj.Groups)
+		d.diffAttributes(obj.ID, exist.Attributes, obj.Attributes)
+		d.diffUniques(obj.ID, exist.Uniques, obj.Uniques)
+	}
+
+	if !d.prune {
+		return
+	}
+
+	// prune the custom models in the spec's classifications which are not declared.
+	classifications := make(map[string]bool)
+	for _, cls := range desired.Classifications {
+		classifications[cls.ID] = true
+	}
+	for _, obj := range current.Models {
+		if obj.Preset || desiredMap[obj.ID] || !classifications[obj.Classification] {
+			continue
+		}
+		d.add(metadata.ModelSpecChange{
+			Action: metadata.ModelSpecDelete,
+			Kind:   metadata.ModelSpecKindModel,
+			ID:     obj.ID,
+		})
+	}
+}
+
+func (d *modelSpecDiffer) diffGroups(objID string, current, desired []metadata.GroupSpec) {
+	currentMap := make(map[string]metadata.GroupSpec)
+	for _, group := range current {
+		currentMap[group.ID] = group
+	}
+
+	desiredMap := make(map[string]bool)
+	for _, group := range desired {
+		desiredMap[group.ID] = true
+		change := metadata.ModelSpecChange{Kind: metadata.ModelSpecKindGroup, ObjectID: objID, ID: group.ID}
+		exist, ok := currentMap[group.ID]
+		if !ok {
+			change.Action = metadata.ModelSpecCreate
+			d.add(change)
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		compareSpecField(fields, "name", exist.Name, group.Name)
+		compareSpecField(fields, "index", exist.Index, group.Index)
+		compareSpecField(fields, "collapse", exist.Collapse, group.Collapse)
+		if len(fields) > 0 {
+			change.Action = metadata.ModelSpecUpdate
+			change.Fields = fields
+			d.add(change)
+		}
+	}
+
+	if !d.prune {
+		return
+	}
+
+	for _, group := range current {
+		// the default group is created with the model and can not be deleted.
+		if desiredMap[group.ID] || group.ID == model.NewGroupID(true) {
+			continue
+		}
+		d.add(metadata.ModelSpecChange{
+			Action:   metadata.ModelSpecDelete,
+			Kind:     metadata.ModelSpecKindGroup,
+			ObjectID: objID,
+			ID:       group.ID,
+		})
+	}
+}
+
+func (d *modelSpecDiffer) diffAttributes(objID string, current, desired []metadata.AttributeSpec) {
+	currentMap := make(map[string]metadata.AttributeSpec)
+	for _, attr := range current {
+		currentMap[attr.ID] = attr
+	}
+
+	desiredMap := make(map[string]bool)
+	for _, attr := range desired {
+		desiredMap[attr.ID] = true
+		change := metadata.ModelSpecChange{Kind: metadata.ModelSpecKindAttribute, ObjectID: objID, ID: attr.ID}
+		exist, ok := currentMap[attr.ID]
+		if !ok {
+			change.Action = metadata.ModelSpecCreate
+			d.add(change)
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		compareSpecField(fields, "name", exist.Name, attr.Name)
+		compareSpecField(fields, "type", exist.Type, attr.Type)
+		compareSpecField(fields, "group", exist.Group, attr.Group)
+		compareSpecField(fields, "unit", exist.Unit, attr.Unit)
+		compareSpecField(fields, "placeholder", exist.Placeholder, attr.Placeholder)
+		compareSpecField(fields, "editable", exist.Editable, attr.Editable)
+		compareSpecField(fields, "required", exist.Required, attr.Required)
+		compareSpecField(fields, "option", exist.Option, attr.Option)
+		compareSpecField(fields, "description", exist.Description, attr.Description)
+		if len(fields) == 0 {
+			continue
+		}
+
+		change.Action = metadata.ModelSpecUpdate
+		change.Fields = fields
+		if _, typeChanged := fields["type"]; typeChanged {
+			change.Conflict = fmt.Sprintf("the type can not be changed from %s to %s, the values of the instances "+
+				"need to be converted by the attribute schema change", exist.Type, attr.Type)
+		}
+		d.add(change)
+	}
+
+	if !d.prune {
+		return
+	}
+
+	for _, attr := range current {
+		if desiredMap[attr.ID] {
+			continue
+		}
+		d.add(metadata.ModelSpecChange{
+			Action:   metadata.ModelSpecDelete,
+			Kind:     metadata.ModelSpecKindAttribute,
+			ObjectID: objID,
+			ID:       attr.ID,
+		})
+	}
+}
+
+// uniqueSpecID returns the id of the unique rule in the spec, which is it's sorted keys joined by comma.
+func uniqueSpecID(keys []string) string {
+	sorted := make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func (d *modelSpecDiffer) diffUniques(objID string, current, desired []metadata.UniqueSpec) {
+	currentMap := make(map[string]metadata.UniqueSpec)
+	for _, unique := range current {
+		currentMap[uniqueSpecID(unique.Keys)] = unique
+	}
+
+	desiredMap := make(map[string]bool)
+	for _, unique := range desired {
+		id := uniqueSpecID(unique.Keys)
+		desiredMap[id] = true
+		change := metadata.ModelSpecChange{Kind: metadata.ModelSpecKindUnique, ObjectID: objID, ID: id}
+		exist, ok := currentMap[id]
+		if !ok {
+			change.Action = metadata.ModelSpecCreate
+			d.add(change)
+			continue
+		}
+
+		if exist.MustCheck != unique.MustCheck {
+			change.Action = metadata.ModelSpecUpdate
+			change.Fields = map[string]interface{}{"must_check": unique.MustCheck}
+			d.add(change)
+		}
+	}
+
+	if !d.prune {
+		return
+	}
+
+	for _, unique := range current {
+		id := uniqueSpecID(unique.Keys)
+		if desiredMap[id] {
+			continue
+		}
+		d.add(metadata.ModelSpecChange{
+			Action:   metadata.ModelSpecDelete,
+			Kind:     metadata.ModelSpecKindUnique,
+			ObjectID: objID,
+			ID:       id,
+		})
+	}
+}
+
+// associationSpecID returns the id of the association, it's generated by the models and the kind if not set.
+func associationSpecID(asst metadata.AssociationSpec) string {
+	if asst.ID != "" {
+		return asst.ID
+	}
+	return fmt.Sprintf("%s_%s_%s", asst.ObjectID, asst.Kind, asst.AsstObjID)
+}
+
+func (d *modelSpecDiffer) diffAssociations(current, desired *metadata.ModelSpec) {
+	currentMap := make(map[string]metadata.AssociationSpec)
+	for _, asst := range current.Associations {
+		currentMap[associationSpecID(asst)] = asst
+	}
+
+	desiredMap := make(map[string]bool)
+	for _, asst := range desired.Associations {
+		id := associationSpecID(asst)
+		desiredMap[id] = true
+		change := metadata.ModelSpecChange{Kind: metadata.ModelSpecKindAssociation, ObjectID: asst.ObjectID, ID: id}
+		exist, ok := currentMap[id]
+		if !ok {
+			change.Action = metadata.ModelSpecCreate
+			d.add(change)
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		compareSpecField(fields, "name", exist.Name, asst.Name)
+		compareSpecField(fields, "mapping", exist.Mapping, asst.Mapping)
+		if asst.OnDelete != "" {
+			compareSpecField(fields, "on_delete", exist.OnDelete, asst.OnDelete)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		change.Action = metadata.ModelSpecUpdate
+		change.Fields = fields
+		if _, mappingChanged := fields["mapping"]; mappingChanged {
+			change.Conflict = fmt.Sprintf("the mapping can not be changed from %s to %s", exist.Mapping, asst.Mapping)
+		}
+		d.add(change)
+	}
+
+	if !d.prune {
+		return
+	}
+
+	// prune the associations of the models in the spec.
+	models := make(map[string]bool)
+	for _, obj := range desired.Models {
+		models[obj.ID] = true
+	}
+	for _, asst := range current.Associations {
+		id := associationSpecID(asst)
+		if desiredMap[id] || !models[asst.ObjectID] {
+			continue
+		}
+		d.add(metadata.ModelSpecChange{
+			Action:   metadata.ModelSpecDelete,
+			Kind:     metadata.ModelSpecKindAssociation,
+			ObjectID: asst.ObjectID,
+			ID:       id,
+		})
+	}
+}
+
+// compareSpecField records the desired value if it's different from the current value, the values are
+// compared by their json form, so that the options decoded from json and yaml can be compared.
+func compareSpecField(fields map[string]interface{}, name string, current, desired interface{}) {
+	if reflect.DeepEqual(current, desired) {
+		return
+	}
+
+	currentJs, currentErr := json.Marshal(current)
+	desiredJs, desiredErr := json.Marshal(desired)
+	if currentErr == nil && desiredErr == nil && string(currentJs) == string(desiredJs) {
+		return
+	}
+
+	fields[name] = desired
+}
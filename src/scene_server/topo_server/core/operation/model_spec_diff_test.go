@@ -0,0 +1,195 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"fmt"
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func currentModelSpec() *metadata.ModelSpec {
+	return &metadata.ModelSpec{
+		Classifications: []metadata.ClassificationSpec{{ID: "network", Name: "Network"}},
+		Models: []metadata.ObjectSpec{
+			{
+				ID:             "switch",
+				Name:           "Switch",
+				Classification: "network",
+				Groups:         []metadata.GroupSpec{{ID: "default", Name: "Default", Index: -1}, {ID: "port", Name: "Port"}},
+				Attributes: []metadata.AttributeSpec{
+					{ID: "vendor", Name: "Vendor", Type: "singlechar", Group: "default"},
+					{ID: "port_num", Name: "Ports", Type: "int", Group: "port",
+						Option: map[string]interface{}{"min": "1", "max": "48"}},
+				},
+				Uniques: []metadata.UniqueSpec{{Keys: []string{"bk_inst_name"}, MustCheck: true}},
+			},
+			{ID: "router", Name: "Router", Classification: "network"},
+			{ID: "host", Name: "Host", Classification: "bk_host_manage", Preset: true},
+		},
+		Associations: []metadata.AssociationSpec{
+			{ID: "switch_connect_router", ObjectID: "switch", AsstObjID: "router", Kind: "connect", Mapping: "n:n"},
+		},
+	}
+}
+
+func changeKeys(changes []metadata.ModelSpecChange) []string {
+	keys := make([]string, len(changes))
+	for idx, change := range changes {
+		keys[idx] = fmt.Sprintf("%s %s %s/%s", change.Action, change.Kind, change.ObjectID, change.ID)
+	}
+	return keys
+}
+
+func TestDiffModelSpecNoChange(t *testing.T) {
+	// the option decoded from json is compared with the current option by their json form.
+	desired := currentModelSpec()
+	desired.Models[0].Attributes[1].Option = map[string]interface{}{"max": "48", "min": "1"}
+
+	if changes := diffModelSpec(currentModelSpec(), desired, true); len(changes) != 0 {
+		t.Errorf("expect no change, but got %v", changeKeys(changes))
+	}
+}
+
+func TestDiffModelSpecChanges(t *testing.T) {
+	desired := currentModelSpec()
+	desired.Classifications[0].Name = "Network Device"
+	desired.Models[0].Attributes[0].Name = "Manufacturer"
+	desired.Models[0].Attributes = append(desired.Models[0].Attributes,
+		metadata.AttributeSpec{ID: "sn", Name: "SN", Type: "singlechar", Group: "default"})
+	desired.Models[0].Uniques = append(desired.Models[0].Uniques,
+		metadata.UniqueSpec{Keys: []string{"vendor", "sn"}, MustCheck: false})
+	desired.Models = append(desired.Models, metadata.ObjectSpec{
+		ID:             "firewall",
+		Name:           "Firewall",
+		Classification: "network",
+		Attributes:     []metadata.AttributeSpec{{ID: "zone", Name: "Zone", Type: "singlechar", Group: "default"}},
+	})
+	desired.Associations = append(desired.Associations, metadata.AssociationSpec{
+		ObjectID: "firewall", AsstObjID: "switch", Kind: "connect", Mapping: "n:n"})
+
+	expects := []string{
+		"update classification /network",
+		"create model /firewall",
+		"update attribute switch/vendor",
+		"create attribute switch/sn",
+		"create attribute firewall/zone",
+		"create unique switch/sn,vendor",
+		"create association firewall/firewall_connect_switch",
+	}
+
+	changes := diffModelSpec(currentModelSpec(), desired, false)
+	keys := changeKeys(changes)
+	if fmt.Sprint(keys) != fmt.Sprint(expects) {
+		t.Errorf("expect changes %v, but got %v", expects, keys)
+	}
+}
+
+func TestDiffModelSpecConflict(t *testing.T) {
+	desired := currentModelSpec()
+	desired.Models[0].Classification = "bk_host_manage"
+	desired.Models[0].Attributes[0].Type = "int"
+	desired.Associations[0].Mapping = "1:n"
+
+	changes := diffModelSpec(currentModelSpec(), desired, false)
+	if len(changes) != 3 {
+		t.Fatalf("expect 3 changes, but got %v", changeKeys(changes))
+	}
+	for _, change := range changes {
+		if change.Action != metadata.ModelSpecUpdate || change.Conflict == "" {
+			t.Errorf("expect conflicted update, but got %#v", change)
+		}
+	}
+}
+
+func TestDiffModelSpecPrune(t *testing.T) {
+	desired := currentModelSpec()
+	// remove the groups, the port_num attribute, the unique rules, the models except switch and
+	// the association, the default group and the preset model are never pruned.
+	desired.Models[0].Groups = nil
+	desired.Models[0].Attributes = desired.Models[0].Attributes[:1]
+	desired.Models[0].Uniques = nil
+	desired.Models = desired.Models[:1]
+	desired.Associations = nil
+
+	expects := []string{
+		"delete association switch/switch_connect_router",
+		"delete unique switch/bk_inst_name",
+		"delete attribute switch/port_num",
+		"delete group switch/port",
+		"delete model /router",
+	}
+
+	if changes := diffModelSpec(currentModelSpec(), desired, false); len(changes) != 0 {
+		t.Errorf("expect no change without prune, but got %v", changeKeys(changes))
+	}
+
+	keys := changeKeys(diffModelSpec(currentModelSpec(), desired, true))
+	if fmt.Sprint(keys) != fmt.Sprint(expects) {
+		t.Errorf("expect changes %v, but got %v", expects, keys)
+	}
+}
+
+func TestDiffModelSpecPresetData(t *testing.T) {
+	current := &metadata.ModelSpec{
+		AssociationKinds: []metadata.AssociationKindSpec{
+			{ID: "belong", Name: "Belong", SrcDes: "belong to", DestDes: "has", Direction: "src_to_dest", Preset: true},
+			{ID: "backup", Name: "Backup", SrcDes: "backup", DestDes: "backup by", Direction: "src_to_dest"},
+		},
+		ServiceCategories: []metadata.ServiceCategorySpec{
+			{Name: "Default", BuiltIn: true},
+			{Parent: "Default", Name: "Default", BuiltIn: true},
+			{Business: "game", Name: "Database"},
+		},
+	}
+
+	desired := &metadata.ModelSpec{
+		AssociationKinds: []metadata.AssociationKindSpec{
+			{ID: "belong", Name: "Belong To", SrcDes: "belong to", DestDes: "has", Direction: "src_to_dest", Preset: true},
+			{ID: "backup", Name: "Backup", SrcDes: "backup", DestDes: "backup by", Direction: "bidirectional"},
+			{ID: "deploy", Name: "Deploy", SrcDes: "deploy", DestDes: "deploy on", Direction: "src_to_dest"},
+		},
+		ServiceCategories: []metadata.ServiceCategorySpec{
+			{Name: "Default", BuiltIn: true},
+			{Business: "game", Name: "Database"},
+			{Business: "game", Parent: "Database", Name: "MySQL"},
+			{Business: "game", Parent: "Default", Name: "Cache"},
+			{Business: "game", Parent: "Web", Name: "Nginx"},
+		},
+	}
+
+	expects := []string{
+		"update association_kind /belong",
+		"update association_kind /backup",
+		"create association_kind /deploy",
+		"create service_category game/Database/MySQL",
+		"create service_category game/Default/Cache",
+		"create service_category game/Web/Nginx",
+	}
+
+	changes := diffModelSpec(current, desired, true)
+	keys := changeKeys(changes)
+	if fmt.Sprint(keys) != fmt.Sprint(expects) {
+		t.Fatalf("expect changes %v, but got %v", expects, keys)
+	}
+
+	// the preset association kind can not be changed, and the parent category must exist.
+	conflicts := map[string]bool{"association_kind:belong": true, "service_category:game/Web/Nginx": true}
+	for _, change := range changes {
+		if conflicts[change.Key()] != (change.Conflict != "") {
+			t.Errorf("expect change %s conflicted: %v, but got conflict %q", change.Key(),
+				conflicts[change.Key()], change.Conflict)
+		}
+	}
+}
@@ -443,6 +443,11 @@ func (s *Service) SearchInsts(ctx *rest.Contexts) {
 	//	}
 	// construct the query inst condition
 	queryCond := data.SearchParams
+	if field, err := queryCond.MergeLabelSelector(); err != nil {
+		blog.Errorf("search %s instances failed, invalid label selector, err: %v, rid: %s", objID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
 	if queryCond.Condition == nil {
 		queryCond.Condition = mapstr.New()
 	}
@@ -480,6 +485,11 @@ func (s *Service) SearchInstAndAssociationDetail(ctx *rest.Contexts) {
 
 	// construct the query inst condition
 	queryCond := data.SearchParams
+	if field, err := queryCond.MergeLabelSelector(); err != nil {
+		blog.Errorf("search %s instances failed, invalid label selector, err: %v, rid: %s", objID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
 	if queryCond.Condition == nil {
 		queryCond.Condition = mapstr.New()
 	}
@@ -520,6 +530,11 @@ func (s *Service) SearchInstByObject(ctx *rest.Contexts) {
 	}
 
 	queryCond := data.SearchParams
+	if field, err := queryCond.MergeLabelSelector(); err != nil {
+		blog.Errorf("search %s instances failed, invalid label selector, err: %v, rid: %s", objID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
 	if queryCond.Condition == nil {
 		queryCond.Condition = mapstr.New()
 	}
@@ -0,0 +1,170 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/selector"
+	"configcenter/src/common/util"
+)
+
+// AddInstLabels add labels to the instances of the object, host labels are maintained by host server.
+func (s *Service) AddInstLabels(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	option := selector.LabelAddOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.validateLabelInstances(ctx.Kit, objID, option.InstanceIDs); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, s.EnableTxn, ctx.Kit.Header, func() error {
+		err := s.Engine.CoreAPI.CoreService().Label().AddLabel(ctx.Kit.Ctx, ctx.Kit.Header,
+			common.GetInstTableName(objID), option)
+		if err != nil {
+			blog.Errorf("add %s instance labels failed, option: %+v, err: %v, rid: %s", objID, option, err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// RemoveInstLabels remove labels from the instances of the object.
+func (s *Service) RemoveInstLabels(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	option := selector.LabelRemoveOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if len(option.Keys) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "keys"))
+		return
+	}
+
+	if err := s.validateLabelInstances(ctx.Kit, objID, option.InstanceIDs); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, s.EnableTxn, ctx.Kit.Header, func() error {
+		err := s.Engine.CoreAPI.CoreService().Label().RemoveLabel(ctx.Kit.Ctx, ctx.Kit.Header,
+			common.GetInstTableName(objID), option)
+		if err != nil {
+			blog.Errorf("remove %s instance labels failed, option: %+v, err: %v, rid: %s", objID, option, err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// InstLabelsAggregation aggregates the label keys and values of the object instances matching the condition.
+func (s *Service) InstLabelsAggregation(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	option := struct {
+		Condition mapstr.MapStr `json:"condition"`
+	}{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.validateLabelObject(ctx.Kit, objID); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	cond := option.Condition
+	if cond == nil {
+		cond = mapstr.New()
+	}
+	if common.GetInstTableName(objID) == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = objID
+	}
+
+	result, err := s.Engine.CoreAPI.CoreService().Label().LabelAggregation(ctx.Kit.Ctx, ctx.Kit.Header,
+		common.GetInstTableName(objID), cond)
+	if err != nil {
+		blog.Errorf("aggregate %s instance labels failed, cond: %+v, err: %v, rid: %s", objID, cond, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// validateLabelObject checks that the labels of the object's instances can be maintained by topo server.
+func (s *Service) validateLabelObject(kit *rest.Kit, objID string) errors.CCErrorCoder {
+	switch objID {
+	case "":
+		return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, common.BKObjIDField)
+	case common.BKInnerObjIDHost, common.BKInnerObjIDProc:
+		blog.Errorf("labels of %s instances should not be maintained by object instance api, rid: %s", objID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+	}
+	return nil
+}
+
+// validateLabelInstances checks that all the instances belong to the object.
+func (s *Service) validateLabelInstances(kit *rest.Kit, objID string, instIDs []int64) errors.CCErrorCoder {
+	if err := s.validateLabelObject(kit, objID); err != nil {
+		return err
+	}
+
+	instIDs = util.IntArrayUnique(instIDs)
+	if len(instIDs) == 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "instance_ids")
+	}
+
+	idField := metadata.GetInstIDFieldByObjID(objID)
+	query := &metadata.QueryCondition{
+		Fields:    []string{idField},
+		Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		Condition: mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: instIDs}},
+	}
+	result, err := s.Engine.CoreAPI.CoreService().Instance().ReadInstance(kit.Ctx, kit.Header, objID, query)
+	if err != nil {
+		blog.Errorf("read %s instances failed, ids: %v, err: %v, rid: %s", objID, instIDs, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("read %s instances failed, ids: %v, err: %s, rid: %s", objID, instIDs, result.ErrMsg, kit.Rid)
+		return result.CCError()
+	}
+	if result.Data.Count != len(instIDs) {
+		blog.Errorf("some of the instances %v are not %s instance, rid: %s", instIDs, objID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "instance_ids")
+	}
+	return nil
+}
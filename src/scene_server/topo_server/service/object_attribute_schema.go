@@ -0,0 +1,270 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// PlanObjectAttributeSchemaChange shows how the instances' values will be converted by the schema change.
+func (s *Service) PlanObjectAttributeSchemaChange(ctx *rest.Contexts) {
+	change := new(metadata.AttributeSchemaChange)
+	if err := ctx.DecodeInto(change); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+
+	rsp, err := s.Engine.CoreAPI.CoreService().Model().PlanModelAttrSchemaChange(ctx.Kit.Ctx, ctx.Kit.Header, objID, change)
+	if err != nil {
+		blog.Errorf("plan attribute %s schema change failed, err: %v, rid: %s", change.PropertyID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed))
+		return
+	}
+	if !rsp.Result {
+		ctx.RespAutoError(rsp.CCError())
+		return
+	}
+
+	ctx.RespEntity(rsp.Data)
+}
+
+// ChangeObjectAttributeSchema starts a migration which converts the instances' values and then changes the
+// attribute's definition, the migration is executed by the task server in background.
+func (s *Service) ChangeObjectAttributeSchema(ctx *rest.Contexts) {
+	change := new(metadata.AttributeSchemaChange)
+	if err := ctx.DecodeInto(change); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+
+	rsp, err := s.Engine.CoreAPI.CoreService().Model().CreateModelAttrSchemaMigration(ctx.Kit.Ctx, ctx.Kit.Header, objID,
+		change)
+	if err != nil {
+		blog.Errorf("create attribute %s schema migration failed, err: %v, rid: %s", change.PropertyID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed))
+		return
+	}
+	if !rsp.Result {
+		ctx.RespAutoError(rsp.CCError())
+		return
+	}
+
+	migration := rsp.Data.Migration
+	s.saveAttributeSchemaAuditLog(ctx.Kit, migration)
+
+	detail, err := s.dispatchAttributeSchemaTask(ctx.Kit, rsp.Data)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(detail)
+}
+
+// RollbackObjectAttributeSchema rolls back a migration, the instances' values and the attribute's definition
+// are restored.
+func (s *Service) RollbackObjectAttributeSchema(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	migrationID := ctx.Request.PathParameter("migration_id")
+
+	opt := &metadata.SearchAttributeSchemaMigrationOption{ObjectID: objID, MigrationID: migrationID}
+	details, err := s.searchAttributeSchemaMigrations(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if len(details) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaMigrationNotExist, migrationID))
+		return
+	}
+
+	// a failed migration can be rolled back only after it's task is finished.
+	if details[0].TaskID != "" && !details[0].TaskStatus.IsFinished() {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaMigrationRunning,
+			details[0].Origin.PropertyID))
+		return
+	}
+
+	rsp, err := s.Engine.CoreAPI.CoreService().Model().RollbackModelAttrSchemaMigration(ctx.Kit.Ctx, ctx.Kit.Header,
+		migrationID)
+	if err != nil {
+		blog.Errorf("roll back attribute schema migration %s failed, err: %v, rid: %s", migrationID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed))
+		return
+	}
+	if !rsp.Result {
+		ctx.RespAutoError(rsp.CCError())
+		return
+	}
+
+	detail, err := s.dispatchAttributeSchemaTask(ctx.Kit, rsp.Data)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(detail)
+}
+
+// SearchObjectAttributeSchemaMigrations search the model's attribute schema migrations with their progress.
+func (s *Service) SearchObjectAttributeSchemaMigrations(ctx *rest.Contexts) {
+	opt := new(metadata.SearchAttributeSchemaMigrationOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	opt.ObjectID = ctx.Request.PathParameter(common.BKObjIDField)
+
+	details, err := s.searchAttributeSchemaMigrations(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(details)
+}
+
+// AttributeSchemaMigrationTaskHandler executes a step of the attribute schema migration for the task server.
+func (s *Service) AttributeSchemaMigrationTaskHandler(ctx *rest.Contexts) {
+	step := new(metadata.AttributeSchemaMigrationStep)
+	if err := ctx.DecodeInto(step); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	rsp, err := s.Engine.CoreAPI.CoreService().Model().ExecuteModelAttrSchemaMigrationStep(ctx.Kit.Ctx,
+		ctx.Kit.Header, step)
+	if err != nil {
+		blog.Errorf("execute attribute schema migration step %#v failed, err: %v, rid: %s", step, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed))
+		return
+	}
+	if !rsp.Result {
+		blog.Errorf("execute attribute schema migration step %#v failed, err: %s, rid: %s", step, rsp.ErrMsg,
+			ctx.Kit.Rid)
+		ctx.RespAutoError(rsp.CCError())
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *Service) dispatchAttributeSchemaTask(kit *rest.Kit, task metadata.AttributeSchemaMigrationTask) (
+	*metadata.AttributeSchemaMigrationDetail, error) {
+
+	data := make([]interface{}, len(task.Steps))
+	for idx := range task.Steps {
+		data[idx] = task.Steps[idx]
+	}
+
+	rsp, err := s.Engine.CoreAPI.TaskServer().Task().Create(kit.Ctx, kit.Header, common.AttributeSchemaMigrationTaskName,
+		task.Migration.MigrationID, data)
+	if err != nil {
+		blog.Errorf("dispatch attribute schema migration %s task failed, err: %v, rid: %s", task.Migration.MigrationID,
+			err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("dispatch attribute schema migration %s task failed, err: %s, rid: %s", task.Migration.MigrationID,
+			rsp.ErrMsg, kit.Rid)
+		return nil, rsp.CCError()
+	}
+
+	return &metadata.AttributeSchemaMigrationDetail{
+		AttributeSchemaMigration: task.Migration,
+		TaskID:                   rsp.Data.TaskID,
+		TaskStatus:               rsp.Data.Status,
+	}, nil
+}
+
+// searchAttributeSchemaMigrations search the migrations and gets the progress from their latest task.
+func (s *Service) searchAttributeSchemaMigrations(kit *rest.Kit, opt *metadata.SearchAttributeSchemaMigrationOption) (
+	[]metadata.AttributeSchemaMigrationDetail, error) {
+
+	rsp, err := s.Engine.CoreAPI.CoreService().Model().ReadModelAttrSchemaMigrations(kit.Ctx, kit.Header, opt)
+	if err != nil {
+		blog.Errorf("search attribute schema migrations failed, opt: %#v, err: %v, rid: %s", opt, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		return nil, rsp.CCError()
+	}
+
+	details := make([]metadata.AttributeSchemaMigrationDetail, len(rsp.Data))
+	for idx, migration := range rsp.Data {
+		details[idx].AttributeSchemaMigration = migration
+
+		listOpt := &metadata.ListAPITaskRequest{
+			Condition: mapstr.MapStr{"flag": migration.MigrationID},
+			Page:      metadata.BasePage{Sort: "-create_time", Limit: 1},
+		}
+		taskRsp, err := s.Engine.CoreAPI.TaskServer().Task().ListTask(kit.Ctx, kit.Header,
+			common.AttributeSchemaMigrationTaskName, listOpt)
+		if err != nil {
+			blog.Errorf("list attribute schema migration %s tasks failed, err: %v, rid: %s", migration.MigrationID,
+				err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrTaskListTaskFail)
+		}
+		if !taskRsp.Result {
+			return nil, taskRsp.CCError()
+		}
+
+		if len(taskRsp.Data.Info) == 0 {
+			continue
+		}
+
+		task := taskRsp.Data.Info[0]
+		details[idx].TaskID = task.TaskID
+		details[idx].TaskStatus = task.Status
+		if len(task.Detail) == 0 {
+			continue
+		}
+
+		finished := 0
+		for _, subTask := range task.Detail {
+			if subTask.Status.IsFinished() {
+				finished++
+			}
+		}
+		details[idx].Progress = int64(finished * 100 / len(task.Detail))
+	}
+	return details, nil
+}
+
+// saveAttributeSchemaAuditLog records the schema change of the attribute, the definition is
+// changed when the migration is finished.
+func (s *Service) saveAttributeSchemaAuditLog(kit *rest.Kit, migration metadata.AttributeSchemaMigration) {
+	updateFields := map[string]interface{}{
+		common.BKPropertyIDField:      migration.Target.PropertyID,
+		common.BKPropertyTypeField:    migration.Target.PropertyType,
+		metadata.AttributeFieldOption: migration.Target.Option,
+	}
+
+	audit := auditlog.NewObjectAttributeAuditLog(s.Engine.CoreAPI.CoreService())
+	param := auditlog.NewGenerateAuditCommonParameter(kit, metadata.AuditUpdate).WithUpdateFields(updateFields)
+	auditLog, err := audit.GenerateAuditLog(param, migration.Origin.ID, &migration.Origin)
+	if err != nil {
+		blog.Errorf("generate attribute schema migration %s audit log failed, err: %v, rid: %s",
+			migration.MigrationID, err, kit.Rid)
+		return
+	}
+
+	if err := audit.SaveAuditLog(kit, *auditLog); err != nil {
+		blog.Errorf("save attribute schema migration %s audit log failed, err: %v, rid: %s", migration.MigrationID,
+			err, kit.Rid)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/ac/iam"
+	"configcenter/src/common/auth"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// ExportModelSpec export the models' declarative definition.
+func (s *Service) ExportModelSpec(ctx *rest.Contexts) {
+	opt := new(metadata.ExportModelSpecOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	spec, err := s.Core.ModelSpecOperation().ExportModelSpec(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(spec)
+}
+
+// PlanModelSpec returns the changes to apply the models' declarative definition, nothing is changed.
+func (s *Service) PlanModelSpec(ctx *rest.Contexts) {
+	opt := new(metadata.ModelSpecOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	plan, err := s.Core.ModelSpecOperation().PlanModelSpec(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(plan)
+}
+
+// ApplyModelSpec applies the models' declarative definition, the changes of the plan are applied one by one
+// until one of them failed, the conflicted changes and the changes not selected are skipped.
+func (s *Service) ApplyModelSpec(ctx *rest.Contexts) {
+	opt := new(metadata.ModelSpecOption)
+	if err := ctx.DecodeInto(opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	plan, err := s.Core.ModelSpecOperation().PlanModelSpec(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	selected := make(map[string]bool)
+	for _, key := range opt.Changes {
+		selected[key] = true
+	}
+
+	result := metadata.ModelSpecApplyResult{
+		Applied: make([]metadata.ModelSpecChange, 0),
+		Skipped: make([]metadata.ModelSpecChange, 0),
+	}
+	for _, change := range plan.Changes {
+		if result.Failed != nil || change.Conflict != "" || (len(selected) > 0 && !selected[change.Key()]) {
+			result.Skipped = append(result.Skipped, change)
+			continue
+		}
+
+		txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, s.EnableTxn, ctx.Kit.Header,
+			func() error {
+				id, err := s.Core.ModelSpecOperation().ApplyModelSpecChange(ctx.Kit, &opt.Spec, change)
+				if err != nil {
+					return err
+				}
+				return s.registerModelSpecCreator(ctx.Kit, &opt.Spec, change, id)
+			})
+
+		if txnErr != nil {
+			blog.Errorf("apply model spec change %#v failed, err: %v, rid: %s", change, txnErr, ctx.Kit.Rid)
+			failed := change
+			result.Failed = &failed
+			result.Error = txnErr.Error()
+			continue
+		}
+		result.Applied = append(result.Applied, change)
+	}
+
+	ctx.RespEntity(result)
+}
+
+// registerModelSpecCreator register the created classification, model or association kind's creator action to iam.
+func (s *Service) registerModelSpecCreator(kit *rest.Kit, spec *metadata.ModelSpec, change metadata.ModelSpecChange,
+	id int64) error {
+
+	if !auth.EnableAuthorize() || change.Action != metadata.ModelSpecCreate {
+		return nil
+	}
+
+	iamInstance := metadata.IamInstanceWithCreator{
+		ID:      strconv.FormatInt(id, 10),
+		Creator: kit.User,
+	}
+	switch change.Kind {
+	case metadata.ModelSpecKindClassification:
+		iamInstance.Type = string(iam.SysModelGroup)
+		for _, cls := range spec.Classifications {
+			if cls.ID == change.ID {
+				iamInstance.Name = cls.Name
+			}
+		}
+	case metadata.ModelSpecKindModel:
+		iamInstance.Type = string(iam.SysModel)
+		for _, obj := range spec.Models {
+			if obj.ID == change.ID {
+				iamInstance.Name = obj.Name
+			}
+		}
+	case metadata.ModelSpecKindAssociationKind:
+		iamInstance.Type = string(iam.SysAssociationType)
+		for _, kind := range spec.AssociationKinds {
+			if kind.ID == change.ID {
+				iamInstance.Name = kind.Name
+			}
+		}
+	default:
+		return nil
+	}
+
+	if _, err := s.AuthManager.Authorizer.RegisterResourceCreatorAction(kit.Ctx, kit.Header, iamInstance); err != nil {
+		blog.Errorf("register created %s %s to iam failed, err: %v, rid: %s", change.Kind, change.ID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
@@ -33,6 +33,9 @@ func (s *Service) initBusinessObject(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/object/{id}", Handler: s.UpdateObject})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/object/{id}", Handler: s.DeleteObject})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/objecttopology", Handler: s.SearchObjectTopo})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/object/spec", Handler: s.ExportModelSpec})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/object/spec/plan", Handler: s.PlanModelSpec})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/object/spec", Handler: s.ApplyModelSpec})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -65,6 +68,10 @@ func (s *Service) initBusinessObjectAttribute(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/objectattr/{id}", Handler: s.UpdateObjectAttribute})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/objectattr/biz/{bk_biz_id}/id/{id}", Handler: s.UpdateObjectAttribute})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/objectattr/{id}", Handler: s.DeleteObjectAttribute})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/objectattr/schema/plan/object/{bk_obj_id}", Handler: s.PlanObjectAttributeSchemaChange})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/objectattr/schema/object/{bk_obj_id}", Handler: s.ChangeObjectAttributeSchema})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/objectattr/schema/rollback/object/{bk_obj_id}/migration/{migration_id}", Handler: s.RollbackObjectAttributeSchema})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/objectattr/schema/migration/object/{bk_obj_id}", Handler: s.SearchObjectAttributeSchemaMigrations})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -168,6 +175,9 @@ func (s *Service) initBusinessInst(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/instance/object/{bk_obj_id}/inst/{inst_id}", Handler: s.UpdateInst})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/updatemany/instance/object/{bk_obj_id}", Handler: s.UpdateInsts})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/instance/object/{bk_obj_id}", Handler: s.SearchInstAndAssociationDetail})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/instance/object/{bk_obj_id}/labels", Handler: s.AddInstLabels})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/deletemany/instance/object/{bk_obj_id}/labels", Handler: s.RemoveInstLabels})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/instance/object/{bk_obj_id}/labels/aggregation", Handler: s.InstLabelsAggregation})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/instdetail/object/{bk_obj_id}/inst/{inst_id}", Handler: s.SearchInstByInstID})
 
 	utility.AddToRestfulWebService(web)
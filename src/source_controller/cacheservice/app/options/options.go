@@ -13,6 +13,8 @@
 package options
 
 import (
+	"time"
+
 	"configcenter/src/common/core/cc/config"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
@@ -29,6 +31,14 @@ type ServerOption struct {
 type Config struct {
 	Mongo mongo.Config
 	Redis redis.Config
+	// InstanceObjects is the custom objects whose instances should be cached.
+	InstanceObjects []string
+	// VerifyInterval is the interval to verify the cache with mongodb, the verification is disabled if it's 0.
+	VerifyInterval time.Duration
+	// VerifySampleSize is how many cached resources of each kind are verified in an interval.
+	VerifySampleSize int
+	// WatchHostSnapHistory is whether to generate the watch events of the host snapshot history.
+	WatchHostSnapHistory bool
 }
 
 //NewServerOption create a ServerOption object
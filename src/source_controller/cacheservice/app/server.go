@@ -15,6 +15,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"configcenter/src/common"
@@ -28,6 +29,13 @@ import (
 	"configcenter/src/storage/driver/redis"
 )
 
+const (
+	// defaultVerifyInterval is the default interval to verify the cache with mongodb.
+	defaultVerifyInterval = 10 * time.Minute
+	// defaultVerifySampleSize is the default count of the cached resources verified in an interval.
+	defaultVerifySampleSize = 1000
+)
+
 // CoreServer the core server
 type CacheServer struct {
 	Core    *backbone.Engine
@@ -40,6 +48,44 @@ func (c *CacheServer) onCacheServiceConfigUpdate(previous, current cc.ProcessCon
 		c.Config = new(options.Config)
 	}
 
+	objects, _ := cc.String("cacheService.instance.objects")
+	c.Config.InstanceObjects = make([]string, 0)
+	for _, objID := range strings.Split(objects, ",") {
+		objID = strings.TrimSpace(objID)
+		if len(objID) == 0 {
+			continue
+		}
+		if common.GetInstTableName(objID) != common.BKTableNameBaseInst {
+			blog.Errorf("object %s is not a custom object, its instances can not be cached, skip", objID)
+			continue
+		}
+		c.Config.InstanceObjects = append(c.Config.InstanceObjects, objID)
+	}
+
+	c.Config.VerifyInterval = defaultVerifyInterval
+	if cc.IsExist("cacheService.verify.intervalMinutes") {
+		minutes, err := cc.Int("cacheService.verify.intervalMinutes")
+		if err != nil || minutes < 0 {
+			blog.Errorf("invalid cache verify interval minutes %d, use default %v, err: %v", minutes,
+				defaultVerifyInterval, err)
+		} else {
+			c.Config.VerifyInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	c.Config.VerifySampleSize = defaultVerifySampleSize
+	if cc.IsExist("cacheService.verify.sampleSize") {
+		size, err := cc.Int("cacheService.verify.sampleSize")
+		if err != nil || size <= 0 {
+			blog.Errorf("invalid cache verify sample size %d, use default %d, err: %v", size,
+				defaultVerifySampleSize, err)
+		} else {
+			c.Config.VerifySampleSize = size
+		}
+	}
+
+	c.Config.WatchHostSnapHistory, _ = cc.Bool("cacheService.watch.hostSnapHistory")
+
 	blog.V(3).Infof("the new cfg:%#v the origin cfg:%#v", c.Config, string(current.ConfigData))
 
 }
@@ -19,6 +19,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/storage/dal"
 	"configcenter/src/storage/driver/redis"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/reflector"
@@ -131,7 +132,7 @@ func (b *business) getBusinessName(bizID int64) (string, error) {
 	filter := mapstr.MapStr{
 		common.BKAppIDField: bizID,
 	}
-	if err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(filter).One(context.Background(), bizInfo); err != nil {
+	if err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(filter).One(dal.WithoutTenant(context.Background()), bizInfo); err != nil {
 		blog.Errorf("get biz %d name from mongo failed, err: %v", bizID, err)
 		return "", err
 	}
@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/cacheservice/cache/tools"
+	"configcenter/src/storage/dal"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/driver/redis"
 )
@@ -49,7 +50,7 @@ func (c *Client) GetBizBaseList() ([]BizBaseInfo, error) {
 	blog.Errorf("get biz base list from cache failed, will get from mongodb, err: %v", err)
 	// get from db directly.
 	list := make([]BizBaseInfo, 0)
-	err = mongodb.Client().Table(common.BKTableNameBaseApp).Find(nil).Fields(common.BKAppIDField, common.BKAppNameField).All(context.Background(), &list)
+	err = mongodb.Client().Table(common.BKTableNameBaseApp).Find(nil).Fields(common.BKAppIDField, common.BKAppNameField).All(dal.WithoutTenant(context.Background()), &list)
 	if err != nil {
 		blog.Errorf("sync biz list to refresh cache, but get biz list from mongodb failed, err: %v", err)
 		return nil, err
@@ -287,7 +288,7 @@ func (c *Client) GetModuleBaseList(bizID int64) ([]ModuleBaseInfo, error) {
 		common.BKAppIDField: bizID,
 	}
 
-	err = mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).All(context.Background(), &list)
+	err = mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).All(dal.WithoutTenant(context.Background()), &list)
 	if err != nil {
 		return nil, err
 	}
@@ -383,7 +384,7 @@ func (c *Client) GetSetBaseList(bizID int64) ([]SetBaseInfo, error) {
 		common.BKAppIDField: bizID,
 	}
 
-	err = mongodb.Client().Table(common.BKTableNameBaseSet).Find(filter).All(context.Background(), &list)
+	err = mongodb.Client().Table(common.BKTableNameBaseSet).Find(filter).All(dal.WithoutTenant(context.Background()), &list)
 	if err != nil {
 		return nil, err
 	}
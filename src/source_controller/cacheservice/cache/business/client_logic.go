@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common/json"
 	"configcenter/src/common/mapstr"
 	meta "configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/driver/redis"
 )
@@ -33,7 +34,7 @@ func (c *Client) getBusinessFromMongo(bizID int64) (string, error) {
 	filter := mapstr.MapStr{
 		common.BKAppIDField: bizID,
 	}
-	err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(filter).One(context.Background(), &biz)
+	err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(filter).One(dal.WithoutTenant(context.Background()), &biz)
 	if err != nil {
 		blog.Errorf("get business %d info from db, but failed, err: %v", bizID, err)
 		return "", ccError.New(common.CCErrCommDBSelectFailed, err.Error())
@@ -52,7 +53,7 @@ func (c *Client) listBusinessFromMongo(ctx context.Context, ids []int64, fields
 		},
 	}
 
-	err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(filter).Fields(fields...).All(context.Background(), &list)
+	err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(filter).Fields(fields...).All(dal.WithoutTenant(context.Background()), &list)
 	if err != nil {
 		blog.Errorf("list business info from db failed, err: %v, rid: %v", err, rid)
 		return nil, ccError.New(common.CCErrCommDBSelectFailed, err.Error())
@@ -77,7 +78,7 @@ func (c *Client) listModuleFromMongo(ctx context.Context, ids []int64, fields []
 		},
 	}
 
-	err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).Fields(fields...).All(context.Background(), &list)
+	err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).Fields(fields...).All(dal.WithoutTenant(context.Background()), &list)
 	if err != nil {
 		blog.Errorf("list module info from db failed, err: %v, rid: %v", err, rid)
 		return nil, ccError.New(common.CCErrCommDBSelectFailed, err.Error())
@@ -102,7 +103,7 @@ func (c *Client) listSetFromMongo(ctx context.Context, ids []int64, fields []str
 		},
 	}
 
-	err := mongodb.Client().Table(common.BKTableNameBaseSet).Find(filter).Fields(fields...).All(context.Background(), &list)
+	err := mongodb.Client().Table(common.BKTableNameBaseSet).Find(filter).Fields(fields...).All(dal.WithoutTenant(context.Background()), &list)
 	if err != nil {
 		blog.Errorf("list set info from db failed, err: %v, rid: %v", err, rid)
 		return nil, ccError.New(common.CCErrCommDBSelectFailed, err.Error())
@@ -145,7 +146,7 @@ func (c *Client) getBusinessBaseInfo() (list []BizBaseInfo, err error) {
 
 func (c *Client) genBusinessListKeys(_ int64) ([]string, error) {
 	bizList := make([]BizBaseInfo, 0)
-	err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(nil).Fields(common.BKAppIDField, common.BKAppNameField).All(context.Background(), &bizList)
+	err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(nil).Fields(common.BKAppIDField, common.BKAppNameField).All(dal.WithoutTenant(context.Background()), &bizList)
 	if err != nil {
 		blog.Errorf("get all biz list from mongodb failed, err: %v", err)
 		return nil, err
@@ -397,7 +398,7 @@ func (c *Client) getAllModuleBase(bizID int64) ([]ModuleBaseInfo, error) {
 	filter := mapstr.MapStr{
 		common.BKAppIDField: bizID,
 	}
-	cnt, err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).Count(context.Background())
+	cnt, err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).Count(dal.WithoutTenant(context.Background()))
 	if err != nil {
 		return nil, err
 	}
@@ -406,7 +407,7 @@ func (c *Client) getAllModuleBase(bizID int64) ([]ModuleBaseInfo, error) {
 	for start := 0; start < int(cnt); start += step {
 		modules := make([]ModuleBaseInfo, 0)
 		err = mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).Fields(common.BKModuleIDField, common.BKModuleNameField).
-			Start(uint64(start)).Limit(uint64(step)).All(context.Background(), &modules)
+			Start(uint64(start)).Limit(uint64(step)).All(dal.WithoutTenant(context.Background()), &modules)
 		if err != nil {
 			blog.Errorf("get biz %d module list from mongodb failed, err: %v", bizID, err)
 			return nil, err
@@ -436,7 +437,7 @@ func (c *Client) getAllSetBase(bizID int64) ([]SetBaseInfo, error) {
 		common.BKAppIDField: bizID,
 	}
 
-	cnt, err := mongodb.Client().Table(common.BKTableNameBaseSet).Find(nil).Count(context.Background())
+	cnt, err := mongodb.Client().Table(common.BKTableNameBaseSet).Find(nil).Count(dal.WithoutTenant(context.Background()))
 	if err != nil {
 		return nil, err
 	}
@@ -445,7 +446,7 @@ func (c *Client) getAllSetBase(bizID int64) ([]SetBaseInfo, error) {
 	for start := 0; start < int(cnt); start += step {
 		modules := make([]SetBaseInfo, 0)
 		err = mongodb.Client().Table(common.BKTableNameBaseSet).Find(filter).Fields(common.BKSetIDField, common.BKSetNameField).
-			Start(uint64(start)).Limit(uint64(step)).All(context.Background(), &modules)
+			Start(uint64(start)).Limit(uint64(step)).All(dal.WithoutTenant(context.Background()), &modules)
 		if err != nil {
 			blog.Errorf("get biz %d set list from mongodb failed, err: %v", bizID, err)
 			return nil, err
@@ -478,7 +479,7 @@ func (c *Client) listModuleDetailFromMongo(ids []int64) ([]string, error) {
 	}
 
 	all := make([]mapstr.MapStr, 0)
-	if err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).All(context.Background(), &all); err != nil {
+	if err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).All(dal.WithoutTenant(context.Background()), &all); err != nil {
 		blog.Errorf("list module %d update from mongo failed, err: %v", ids, err)
 		return nil, err
 	}
@@ -505,7 +506,7 @@ func (c *Client) getModuleDetailFromMongoCheckNotFound(id int64) (string, bool,
 		common.BKModuleIDField: id,
 	}
 
-	if err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).One(context.Background(), &mod); err != nil {
+	if err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).One(dal.WithoutTenant(context.Background()), &mod); err != nil {
 		blog.Errorf("get module %d detail from mongo failed, err: %v", id, err)
 
 		// if module is not found, returns not found flag
@@ -529,7 +530,7 @@ func (c *Client) getSetDetailFromMongoCheckNotFound(id int64) (string, bool, err
 		common.BKSetIDField: id,
 	}
 
-	if err := mongodb.Client().Table(common.BKTableNameBaseSet).Find(filter).One(context.Background(), &set); err != nil {
+	if err := mongodb.Client().Table(common.BKTableNameBaseSet).Find(filter).One(dal.WithoutTenant(context.Background()), &set); err != nil {
 		blog.Errorf("get set %d detail from mongo failed, err: %v", id, err)
 
 		// if set is not found, returns not found flag
@@ -550,7 +551,7 @@ func (c *Client) listSetDetailFromMongo(ids []int64) ([]string, error) {
 	}
 
 	sets := make([]map[string]interface{}, 0)
-	if err := mongodb.Client().Table(common.BKTableNameBaseSet).Find(filter).All(context.Background(), &sets); err != nil {
+	if err := mongodb.Client().Table(common.BKTableNameBaseSet).Find(filter).All(dal.WithoutTenant(context.Background()), &sets); err != nil {
 		blog.Errorf("get set %v update from mongo failed, err: %v", ids, err)
 		return nil, err
 	}
@@ -586,7 +587,7 @@ func (c *Client) getCustomLevelBaseFromMongodb(objID string, bizID int64) ([]Cus
 		common.MetadataField: meta.NewMetadata(bizID),
 	}
 	// count for paging use.
-	cnt, err := mongodb.Client().Table(common.BKTableNameBaseInst).Find(filter).Count(context.Background())
+	cnt, err := mongodb.Client().Table(common.BKTableNameBaseInst).Find(filter).Count(dal.WithoutTenant(context.Background()))
 	if err != nil {
 		blog.Errorf("get custom level object: %s, biz: %d, list keys, but count from mongodb failed, err: %v", objID, bizID, err)
 		return nil, err
@@ -595,7 +596,7 @@ func (c *Client) getCustomLevelBaseFromMongodb(objID string, bizID int64) ([]Cus
 	for start := 0; start < int(cnt); start += step {
 		instances := make([]CustomInstanceBase, 0)
 		err = mongodb.Client().Table(common.BKTableNameBaseInst).Find(filter).
-			Start(uint64(start)).Limit(uint64(step)).All(context.Background(), &instances)
+			Start(uint64(start)).Limit(uint64(step)).All(dal.WithoutTenant(context.Background()), &instances)
 		if err != nil {
 			blog.Errorf("get custom level object: %s, biz: %d, list keys, but get from mongodb failed, err: %v", objID, bizID, err)
 			return nil, err
@@ -643,7 +644,7 @@ func (c *Client) getCustomLevelDetailCheckNotFound(objID string, instID int64) (
 		common.BKInstIDField: instID,
 	}
 	instance := make(map[string]interface{})
-	err := mongodb.Client().Table(common.BKTableNameBaseInst).Find(filter).One(context.Background(), &instance)
+	err := mongodb.Client().Table(common.BKTableNameBaseInst).Find(filter).One(dal.WithoutTenant(context.Background()), &instance)
 
 	// if module is not found, returns not found flag
 	if mongodb.Client().IsNotFoundError(err) {
@@ -670,7 +671,7 @@ func (c *Client) listCustomLevelDetail(objID string, instIDs []int64) ([]string,
 	}
 
 	instance := make([]map[string]interface{}, 0)
-	err := mongodb.Client().Table(common.BKTableNameBaseInst).Find(filter).One(context.Background(), &instance)
+	err := mongodb.Client().Table(common.BKTableNameBaseInst).Find(filter).One(dal.WithoutTenant(context.Background()), &instance)
 	if err != nil {
 		blog.Errorf("get custom level object: %s, inst: %v from mongodb failed, err: %v", objID, instIDs, err)
 		return nil, err
@@ -22,6 +22,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/source_controller/cacheservice/cache/tools"
+	"configcenter/src/storage/dal"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/driver/redis"
 	"configcenter/src/storage/reflector"
@@ -366,7 +367,7 @@ func (m *customLevel) getMainlineTopology() ([]MainlineTopoAssociation, error) {
 	filter := mapstr.MapStr{
 		common.AssociationKindIDField: common.AssociationKindMainline,
 	}
-	err := mongodb.Client().Table(common.BKTableNameObjAsst).Find(filter).All(context.Background(), &relations)
+	err := mongodb.Client().Table(common.BKTableNameObjAsst).Find(filter).All(dal.WithoutTenant(context.Background()), &relations)
 	if err != nil {
 		blog.Errorf("get mainline topology association failed, err: %v", err)
 		return nil, err
@@ -402,7 +403,7 @@ func (m *customLevel) getCustomObjInstName(instID int64) (name string, err error
 	filter := mapstr.MapStr{
 		common.BKInstIDField: instID,
 	}
-	err = mongodb.Client().Table(common.BKTableNameBaseInst).Find(filter).One(context.Background(), instance)
+	err = mongodb.Client().Table(common.BKTableNameBaseInst).Find(filter).One(dal.WithoutTenant(context.Background()), instance)
 	if err != nil {
 		blog.Errorf("find mainline custom level with instance: %d, failed, err: %v", instID, err)
 		return "", err
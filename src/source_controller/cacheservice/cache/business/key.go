@@ -120,6 +120,10 @@ func (k keyGenerator) detailKey(instID int64) string {
 	return fmt.Sprintf("%s:%s_detail:%d", k.namespace, k.name, instID)
 }
 
+func (k keyGenerator) detailKeyPrefix() string {
+	return fmt.Sprintf("%s:%s_detail:", k.namespace, k.name)
+}
+
 func (k keyGenerator) detailLockKey(instID int64) string {
 	return fmt.Sprintf("%s:%s_detail:lock:%d", k.namespace, k.name, instID)
 }
@@ -21,6 +21,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/source_controller/cacheservice/cache/tools"
+	"configcenter/src/storage/dal"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/driver/redis"
 	"configcenter/src/storage/reflector"
@@ -206,7 +207,7 @@ func (ms *moduleSet) getModuleNameFromMongo(id int64) (string, error) {
 	filter := mapstr.MapStr{
 		common.BKModuleIDField: id,
 	}
-	if err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).One(context.Background(), mod); err != nil {
+	if err := mongodb.Client().Table(common.BKTableNameBaseModule).Find(filter).One(dal.WithoutTenant(context.Background()), mod); err != nil {
 		blog.Errorf("get module %d name from mongo failed, err: %v", id, err)
 		return "", err
 	}
@@ -219,7 +220,7 @@ func (ms *moduleSet) getSetNameFromMongo(id int64) (string, error) {
 		common.BKSetIDField: id,
 	}
 
-	if err := mongodb.Client().Table(common.BKTableNameBaseSet).Find(filter).One(context.Background(), mod); err != nil {
+	if err := mongodb.Client().Table(common.BKTableNameBaseSet).Find(filter).One(dal.WithoutTenant(context.Background()), mod); err != nil {
 		blog.Errorf("get module %d name from mongo failed, err: %v", id, err)
 		return "", err
 	}
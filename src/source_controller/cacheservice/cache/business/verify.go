@@ -0,0 +1,98 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package business
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	ccError "configcenter/src/common/errors"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/cache/verify"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/redis"
+)
+
+// VerifyResources returns the business, set and module resources which can be verified with mongodb.
+func VerifyResources() []verify.Resource {
+	return []verify.Resource{
+		newVerifyResource(bizKey, common.BKTableNameBaseApp, common.BKAppIDField),
+		newVerifyResource(setKey, common.BKTableNameBaseSet, common.BKSetIDField),
+		newVerifyResource(moduleKey, common.BKTableNameBaseModule, common.BKModuleIDField),
+	}
+}
+
+func newVerifyResource(key keyGenerator, collection, idField string) verify.Resource {
+	prefix := key.detailKeyPrefix()
+	return verify.Resource{
+		Name:       string(key.name),
+		Collection: collection,
+		IDField:    idField,
+		KeyPattern: prefix + "*",
+		ParseKey: func(k string) (int64, bool) {
+			// the lock and expire keys are also matched, they can not be parsed as an id.
+			id, err := strconv.ParseInt(strings.TrimPrefix(k, prefix), 10, 64)
+			return id, err == nil
+		},
+		DetailKey: key.detailKey,
+		ListDetails: func(ctx context.Context, ids []int64) (map[int64]string, error) {
+			return listDetailsFromMongo(ctx, collection, idField, ids)
+		},
+		Repair: func(ctx context.Context, id int64, detail string) error {
+			pipeline := redis.Client().Pipeline()
+			pipeline.Set(key.detailKey(id), detail, 0)
+			// reset the expire time
+			pipeline.Set(key.detailExpireKey(id), time.Now().Unix(), 0)
+			_, err := pipeline.Exec()
+			return err
+		},
+		Remove: func(ctx context.Context, id int64) error {
+			return redis.Client().Del(ctx, key.detailKey(id), key.detailExpireKey(id)).Err()
+		},
+	}
+}
+
+// listDetailsFromMongo list the instances' details from mongodb, returns the map of instance id to detail.
+func listDetailsFromMongo(ctx context.Context, collection, idField string, ids []int64) (map[int64]string, error) {
+	rid := ctx.Value(common.ContextRequestIDField)
+
+	list := make([]map[string]interface{}, 0)
+	filter := mapstr.MapStr{
+		idField: mapstr.MapStr{
+			common.BKDBIN: ids,
+		},
+	}
+
+	if err := mongodb.Client().Table(collection).Find(filter).All(ctx, &list); err != nil {
+		blog.Errorf("list %s details from db failed, err: %v, rid: %v", collection, err, rid)
+		return nil, ccError.New(common.CCErrCommDBSelectFailed, err.Error())
+	}
+
+	details := make(map[int64]string)
+	for _, item := range list {
+		id, err := util.GetInt64ByInterface(item[idField])
+		if err != nil {
+			blog.Errorf("list %s details from db, but got invalid id %v, rid: %v", collection, item[idField], rid)
+			continue
+		}
+		js, _ := json.Marshal(item)
+		details[id] = string(js)
+	}
+	return details, nil
+}
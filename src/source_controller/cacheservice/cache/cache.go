@@ -17,11 +17,15 @@ import (
 
 	"configcenter/src/source_controller/cacheservice/cache/business"
 	"configcenter/src/source_controller/cacheservice/cache/host"
+	"configcenter/src/source_controller/cacheservice/cache/instance"
+	"configcenter/src/source_controller/cacheservice/cache/model"
 	"configcenter/src/source_controller/cacheservice/cache/topo_tree"
+	"configcenter/src/source_controller/cacheservice/cache/verify"
 	"configcenter/src/storage/reflector"
 )
 
-func NewCache(event reflector.Interface) (*ClientSet, error) {
+// NewCache new all the caches, instanceObjects is the custom objects whose instances should be cached.
+func NewCache(event reflector.Interface, instanceObjects []string) (*ClientSet, error) {
 	if err := business.NewCache(event); err != nil {
 		return nil, fmt.Errorf("new business cache failed, err: %v", err)
 	}
@@ -30,6 +34,14 @@ func NewCache(event reflector.Interface) (*ClientSet, error) {
 		return nil, fmt.Errorf("new host cache failed, err: %v", err)
 	}
 
+	if err := model.NewCache(event); err != nil {
+		return nil, fmt.Errorf("new model cache failed, err: %v", err)
+	}
+
+	if err := instance.NewCache(event, instanceObjects); err != nil {
+		return nil, fmt.Errorf("new instance cache failed, err: %v", err)
+	}
+
 	bizClient := business.NewClient()
 	hostClient := host.NewClient()
 
@@ -37,12 +49,25 @@ func NewCache(event reflector.Interface) (*ClientSet, error) {
 		Topology: topo_tree.NewTopologyTree(bizClient),
 		Host:     hostClient,
 		Business: bizClient,
+		Model:    model.NewClient(),
+		Instance: instance.NewClient(instanceObjects),
+		Verifier: NewVerifier(),
 	}
 	return cache, nil
 }
 
+// NewVerifier new a verifier of the host and business caches, it can only be called for once.
+func NewVerifier() *verify.Verifier {
+	resources := []verify.Resource{host.VerifyResource()}
+	resources = append(resources, business.VerifyResources()...)
+	return verify.NewVerifier(resources)
+}
+
 type ClientSet struct {
 	Topology *topo_tree.TopologyTree
 	Host     *host.Client
 	Business *business.Client
+	Model    *model.Client
+	Instance *instance.Client
+	Verifier *verify.Verifier
 }
@@ -25,6 +25,7 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/driver/redis"
 	"configcenter/src/storage/reflector"
@@ -210,7 +211,7 @@ func getHostDetailsFromMongoWithHostID(hostID int64) (ips string, cloudID int64,
 		common.BKHostIDField: hostID,
 	}
 	host := make(metadata.HostMapStr)
-	err = mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).One(context.Background(), &host)
+	err = mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).One(dal.WithoutTenant(context.Background()), &host)
 	if err != nil {
 		blog.Errorf("get host data from mongodb for cache failed, err: %v", err)
 		return "", 0, nil, err
@@ -247,7 +248,7 @@ func listHostDetailsFromMongoWithHostID(hostID []int64) (list []*hostBase, err e
 		},
 	}
 	host := make([]metadata.HostMapStr, 0)
-	err = mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).Sort(common.BKHostIDField).All(context.Background(), &host)
+	err = mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).Sort(common.BKHostIDField).All(dal.WithoutTenant(context.Background()), &host)
 	if err != nil {
 		blog.Errorf("get host data from mongodb for cache failed, err: %v", err)
 		return nil, err
@@ -296,7 +297,7 @@ func getHostDetailsFromMongoWithIP(innerIP string, cloudID int64) (hostID int64,
 		common.BKCloudIDField: cloudID,
 	}
 	host := make(metadata.HostMapStr)
-	err = mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).One(context.Background(), &host)
+	err = mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).One(dal.WithoutTenant(context.Background()), &host)
 	if err != nil {
 		blog.Errorf("get host data from mongodb with ip: %s, cloud: %d for cache failed, err: %v", innerIP, cloudID, err)
 		return 0, nil, err
@@ -0,0 +1,88 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/source_controller/cacheservice/cache/verify"
+	"configcenter/src/storage/driver/redis"
+
+	"github.com/tidwall/gjson"
+)
+
+// VerifyResource returns the host resource which can be verified with mongodb.
+func VerifyResource() verify.Resource {
+	return verify.Resource{
+		Name:       "host",
+		Collection: common.BKTableNameBaseHost,
+		IDField:    common.BKHostIDField,
+		KeyPattern: hostKey.HostDetailKeyPrefix() + "*",
+		ParseKey: func(key string) (int64, bool) {
+			// the lock keys are also matched, they can not be parsed as a host id.
+			id, err := strconv.ParseInt(strings.TrimPrefix(key, hostKey.HostDetailKeyPrefix()), 10, 64)
+			return id, err == nil
+		},
+		DetailKey:   hostKey.HostDetailKey,
+		ListDetails: listHostDetailsForVerify,
+		Repair:      repairHostCache,
+		Remove:      removeHostCache,
+	}
+}
+
+func listHostDetailsForVerify(_ context.Context, ids []int64) (map[int64]string, error) {
+	details := make(map[int64]string)
+	if len(ids) == 0 {
+		return details, nil
+	}
+
+	list, err := listHostDetailsFromMongoWithHostID(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, host := range list {
+		details[host.id] = host.detail
+	}
+	return details, nil
+}
+
+func repairHostCache(_ context.Context, hostID int64, detail string) error {
+	elements := gjson.GetMany(detail, common.BKHostInnerIPField, common.BKCloudIDField)
+	refreshHostDetailCache(hostID, elements[0].String(), elements[1].Int(), []byte(detail))
+	return nil
+}
+
+func removeHostCache(ctx context.Context, hostID int64) error {
+	detail, err := redis.Client().Get(ctx, hostKey.HostDetailKey(hostID)).Result()
+	if err != nil && !redis.IsNilErr(err) {
+		return err
+	}
+
+	pipe := redis.Client().Pipeline()
+	if len(detail) != 0 {
+		// delete the cloud id and ip pair of the removed host.
+		elements := gjson.GetMany(detail, common.BKHostInnerIPField, common.BKCloudIDField)
+		for _, ip := range strings.Split(elements[0].String(), ",") {
+			pipe.Del(hostKey.IPCloudIDKey(ip, elements[1].Int()))
+		}
+	}
+	pipe.Del(hostKey.HostDetailKey(hostID))
+	pipe.ZRem(hostKey.HostIDListKey(), hostID)
+
+	_, err = pipe.Exec()
+	return err
+}
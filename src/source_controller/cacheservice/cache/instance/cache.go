@@ -0,0 +1,72 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instance
+
+import (
+	"fmt"
+	"sync"
+
+	"configcenter/src/source_controller/cacheservice/cache/tools"
+	"configcenter/src/storage/reflector"
+)
+
+var client *Client
+var clientOnce sync.Once
+var cache *instanceCache
+
+// NewClient new the instance cache client, objects is the custom objects whose instances are cached.
+func NewClient(objects []string) *Client {
+
+	if client != nil {
+		return client
+	}
+
+	clientOnce.Do(func() {
+		client = &Client{
+			objects: toObjectMap(objects),
+			metrics: tools.NewCacheMetrics("instance"),
+		}
+	})
+
+	return client
+}
+
+// Attention, it can only be called for once.
+// objects is the custom objects whose instances should be cached, nothing is cached if it's empty.
+func NewCache(event reflector.Interface, objects []string) error {
+
+	if cache != nil || len(objects) == 0 {
+		return nil
+	}
+
+	// cache has not been initialized.
+	inst := &instanceCache{
+		objects: toObjectMap(objects),
+		event:   event,
+	}
+
+	if err := inst.Run(); err != nil {
+		return fmt.Errorf("run instance cache failed, err: %v", err)
+	}
+	cache = inst
+
+	return nil
+}
+
+func toObjectMap(objects []string) map[string]bool {
+	objectMap := make(map[string]bool)
+	for _, objID := range objects {
+		objectMap[objID] = true
+	}
+	return objectMap
+}
@@ -0,0 +1,167 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	ccError "configcenter/src/common/errors"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/cache/tools"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/redis"
+)
+
+type Client struct {
+	// objects whose instances are cached.
+	objects map[string]bool
+	metrics *tools.CacheMetrics
+}
+
+// GetInstance get a custom object's instance with instance id. if the object's instances are not cached,
+// or the instance is not in cache, then get it from mongodb directly.
+func (c *Client) GetInstance(ctx context.Context, objID string, instID int64, fields []string) (string, error) {
+	list, err := c.ListInstances(ctx, objID, &metadata.ListWithIDOption{IDs: []int64{instID}, Fields: fields})
+	if err != nil {
+		return "", err
+	}
+
+	if len(list) == 0 {
+		return "", ccError.New(common.CCErrCommNotFound, fmt.Sprintf("%s instance %d not found", objID, instID))
+	}
+	return list[0], nil
+}
+
+// ListInstances list a custom object's instances with instance ids. if an instance is not exist in cache
+// and still can not find in mongodb, then it will not be returned. so the returned array may not equal to
+// the request instance ids length and the sequence is also may not same.
+func (c *Client) ListInstances(ctx context.Context, objID string, opt *metadata.ListWithIDOption) ([]string, error) {
+	rid := ctx.Value(common.ContextRequestIDField)
+	if len(opt.IDs) > 500 {
+		return nil, errors.New("instance id length is over limit")
+	}
+
+	if len(opt.IDs) == 0 {
+		return nil, errors.New("instance id array is empty")
+	}
+
+	if !c.objects[objID] {
+		// this object's instances are not cached.
+		return c.listInstancesFromMongo(ctx, objID, opt.IDs, opt.Fields, false)
+	}
+
+	keys := make([]string, len(opt.IDs))
+	for idx, id := range opt.IDs {
+		keys[idx] = instKey.detailKey(objID, id)
+	}
+
+	instances, err := redis.Client().MGet(context.Background(), keys...).Result()
+	if err != nil {
+		blog.Errorf("get %s instances %v from cache failed, get from db directly, err: %v, rid: %v", objID, opt.IDs,
+			err, rid)
+		return c.listInstancesFromMongo(ctx, objID, opt.IDs, opt.Fields, false)
+	}
+
+	all := make([]string, 0)
+	toAdd := make([]int64, 0)
+	for idx, inst := range instances {
+		if inst == nil {
+			// can not find in cache
+			toAdd = append(toAdd, opt.IDs[idx])
+			continue
+		}
+
+		detail, ok := inst.(string)
+		if !ok {
+			blog.Errorf("got invalid %s instance cache %v, rid: %v", objID, inst, rid)
+			return nil, fmt.Errorf("got invalid %s instance cache %v", objID, inst)
+		}
+
+		if len(opt.Fields) != 0 {
+			all = append(all, *json.CutJsonDataWithFields(&detail, opt.Fields))
+		} else {
+			all = append(all, detail)
+		}
+	}
+
+	if len(toAdd) == 0 {
+		c.metrics.CollectHit()
+		return all, nil
+	}
+
+	details, err := c.listInstancesFromMongo(ctx, objID, toAdd, opt.Fields, true)
+	if err != nil {
+		return nil, err
+	}
+	return append(all, details...), nil
+}
+
+// listInstancesFromMongo list the instances from mongodb, and refresh them to cache if needed.
+func (c *Client) listInstancesFromMongo(ctx context.Context, objID string, ids []int64, fields []string,
+	refresh bool) ([]string, error) {
+
+	rid := ctx.Value(common.ContextRequestIDField)
+	c.metrics.CollectMiss()
+
+	list := make([]mapstr.MapStr, 0)
+	filter := mapstr.MapStr{
+		common.BKObjIDField: objID,
+		common.BKInstIDField: mapstr.MapStr{
+			common.BKDBIN: ids,
+		},
+	}
+
+	// get the whole instance to refresh cache, and cut the fields later.
+	findFields := fields
+	if refresh {
+		findFields = nil
+	}
+
+	err := mongodb.Client().Table(common.BKTableNameBaseInst).Find(filter).Fields(findFields...).
+		All(dal.WithoutTenant(context.Background()), &list)
+	if err != nil {
+		blog.Errorf("list %s instances from db failed, ids: %v, err: %v, rid: %v", objID, ids, err, rid)
+		return nil, ccError.New(common.CCErrCommDBSelectFailed, err.Error())
+	}
+
+	all := make([]string, len(list))
+	for idx, inst := range list {
+		js, _ := json.Marshal(inst)
+		detail := string(js)
+
+		if refresh {
+			instID, err := util.GetInt64ByInterface(inst[common.BKInstIDField])
+			if err != nil {
+				blog.Errorf("got invalid %s instance id %v, err: %v, rid: %v", objID, inst[common.BKInstIDField],
+					err, rid)
+			} else {
+				refreshInstanceCache(objID, instID, detail)
+			}
+
+			if len(fields) != 0 {
+				detail = *json.CutJsonDataWithFields(&detail, fields)
+			}
+		}
+
+		all[idx] = detail
+	}
+	return all, nil
+}
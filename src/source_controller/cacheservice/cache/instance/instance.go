@@ -0,0 +1,168 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/redis"
+	"configcenter/src/storage/reflector"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/tidwall/gjson"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+type instanceCache struct {
+	// objects whose instances should be cached.
+	objects map[string]bool
+	event   reflector.Interface
+}
+
+// listDoneValue is the value of the list done key, which is the sorted objects joined with comma.
+func (i *instanceCache) listDoneValue() string {
+	objects := make([]string, 0)
+	for objID := range i.objects {
+		objects = append(objects, objID)
+	}
+	sort.Strings(objects)
+	return strings.Join(objects, ",")
+}
+
+func (i *instanceCache) Run() error {
+
+	// the instances of all the custom objects are stored in the same collection, we do not use the
+	// filter to watch the objects, because the delete event has no document to be filtered with, so
+	// the instances of the objects which are not cached are skipped in the event handlers.
+	opts := types.Options{
+		EventStruct: new(map[string]interface{}),
+		Collection:  common.BKTableNameBaseInst,
+	}
+
+	listDone, err := redis.Client().Get(context.Background(), instKey.listDoneKey()).Result()
+	if err != nil && !redis.IsNilErr(err) {
+		blog.Errorf("get instance list done redis key failed, err: %v", err)
+		return fmt.Errorf("get instance list done redis key failed, err: %v", err)
+	}
+
+	if listDone != i.listDoneValue() {
+		listCap := &reflector.Capable{
+			OnChange: reflector.OnChangeEvent{
+				OnLister:     i.onUpsert,
+				OnAdd:        i.onUpsert,
+				OnUpdate:     i.onUpsert,
+				OnListerDone: i.onListDone,
+				OnDelete:     i.onDelete,
+			},
+		}
+		// do with list watcher.
+		page := 500
+		listOpts := &types.ListWatchOptions{
+			Options:  opts,
+			PageSize: &page,
+		}
+		blog.Infof("do instance cache of objects %s with list watcher.", i.listDoneValue())
+		return i.event.ListWatcher(context.Background(), listOpts, listCap)
+	}
+
+	watchCap := &reflector.Capable{
+		OnChange: reflector.OnChangeEvent{
+			OnAdd:    i.onUpsert,
+			OnUpdate: i.onUpsert,
+			OnDelete: i.onDelete,
+		},
+	}
+	// do with watcher only.
+	watchOpts := &types.WatchOptions{
+		Options: opts,
+	}
+	blog.Infof("do instance cache of objects %s with only watcher", i.listDoneValue())
+	return i.event.Watcher(context.Background(), watchOpts, watchCap)
+}
+
+func (i *instanceCache) onUpsert(e *types.Event) {
+	elements := gjson.GetManyBytes(e.DocBytes, common.BKObjIDField, common.BKInstIDField)
+	objID, instID := elements[0].String(), elements[1].Int()
+	if !i.objects[objID] {
+		return
+	}
+
+	blog.V(4).Infof("received %s instance upsert event, oid: %s, doc: %s", objID, e.Oid, e.DocBytes)
+	if instID <= 0 {
+		blog.Errorf("received %s instance upsert event, but got invalid instance id, doc: %s", objID, e.DocBytes)
+		return
+	}
+
+	refreshInstanceCache(objID, instID, string(e.DocBytes))
+}
+
+func (i *instanceCache) onDelete(e *types.Event) {
+	filter := mapstr.MapStr{
+		"oid": e.Oid,
+	}
+	doc := bsonx.Doc{}
+	err := mongodb.Client().Table(common.BKTableNameDelArchive).Find(filter).One(context.Background(), &doc)
+	if err != nil {
+		blog.Errorf("received delete instance event, but get archive deleted doc from mongodb failed, oid: %s, err: %v",
+			e.Oid, err)
+		return
+	}
+
+	byt, err := bson.MarshalExtJSON(doc.Lookup("detail"), false, false)
+	if err != nil {
+		blog.Errorf("received delete instance event, but marshal doc to bytes failed, oid: %s, err: %v", e.Oid, err)
+		return
+	}
+
+	elements := gjson.GetManyBytes(byt, common.BKObjIDField, common.BKInstIDField)
+	objID, instID := elements[0].String(), elements[1].Int()
+	if !i.objects[objID] {
+		return
+	}
+
+	if err := redis.Client().Del(context.Background(), instKey.detailKey(objID, instID)).Err(); err != nil {
+		blog.Errorf("received %s instance delete event, oid: %s, but delete instance %d detail failed, err: %v",
+			objID, e.Oid, instID, err)
+		return
+	}
+	blog.Infof("received %s instance delete event, oid: %s, delete instance %d detail success", objID, e.Oid, instID)
+}
+
+// onListDone is to tell us that all the instances of the objects has been list from mongodb and already
+// sync it to cache.
+func (i *instanceCache) onListDone() {
+	if err := redis.Client().Set(context.Background(), instKey.listDoneKey(), i.listDoneValue(), 0).Err(); err != nil {
+		blog.Errorf("list instance data to cache and list done, but set list done key failed, err: %v", err)
+		return
+	}
+	blog.Infof("list instance data of objects %s to cache and list done", i.listDoneValue())
+}
+
+// refreshInstanceCache refresh the instance's detail cache with a random ttl.
+func refreshInstanceCache(objID string, instID int64, detail string) {
+	ttl := instKey.withRandomExpireSeconds()
+	if err := redis.Client().Set(context.Background(), instKey.detailKey(objID, instID), detail, ttl).Err(); err != nil {
+		blog.Errorf("upsert %s instance %d cache, but upsert to redis failed, err: %v", objID, instID, err)
+		return
+	}
+	blog.V(4).Infof("refresh %s instance %d cache success, ttl: %ds", objID, instID, ttl/time.Second)
+}
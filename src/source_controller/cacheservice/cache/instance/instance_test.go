@@ -0,0 +1,78 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ccRedis "configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/driver/redis"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/alicebob/miniredis"
+)
+
+func TestInstKey(t *testing.T) {
+	if key := instKey.detailKey("switch", 12); key != instKeyNamespace+":switch:detail:12" {
+		t.Errorf("unexpected detail key %s", key)
+	}
+
+	min := instKey.expireSeconds + time.Duration(instKey.expireRangeSeconds[0])*time.Second
+	max := instKey.expireSeconds + time.Duration(instKey.expireRangeSeconds[1])*time.Second
+	for i := 0; i < 10; i++ {
+		if ttl := instKey.withRandomExpireSeconds(); ttl < min || ttl > max {
+			t.Errorf("ttl %v is out of range [%v, %v]", ttl, min, max)
+		}
+	}
+
+	cache := &instanceCache{objects: toObjectMap([]string{"switch", "router", "switch"})}
+	if value := cache.listDoneValue(); value != "router,switch" {
+		t.Errorf("expect list done value router,switch, got %s", value)
+	}
+}
+
+func TestInstanceCacheUpsert(t *testing.T) {
+	redisMock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run mock redis failed, err: %v", err)
+	}
+	defer redisMock.Close()
+	if err := redis.InitClient("redis", &ccRedis.Config{Address: redisMock.Addr(), Database: "0"}); err != nil {
+		t.Fatalf("init redis client failed, err: %v", err)
+	}
+
+	cache := &instanceCache{objects: toObjectMap([]string{"switch"})}
+	doc := `{"bk_obj_id":"switch","bk_inst_id":12,"bk_inst_name":"sw1"}`
+	cache.onUpsert(&types.Event{Oid: "oid-12", DocBytes: []byte(doc)})
+	// the instances of the objects which are not cached and the invalid instances are skipped.
+	cache.onUpsert(&types.Event{Oid: "oid-13", DocBytes: []byte(`{"bk_obj_id":"router","bk_inst_id":13}`)})
+	cache.onUpsert(&types.Event{Oid: "oid-14", DocBytes: []byte(`{"bk_obj_id":"switch"}`)})
+
+	ctx := context.Background()
+	detail, err := redis.Client().Get(ctx, instKey.detailKey("switch", 12)).Result()
+	if err != nil || detail != doc {
+		t.Errorf("expect instance 12 cached, got %s, err: %v", detail, err)
+	}
+	if ttl := redisMock.TTL(instKey.detailKey("switch", 12)); ttl <= 0 {
+		t.Errorf("expect instance 12 cached with ttl, got %v", ttl)
+	}
+
+	if _, err := redis.Client().Get(ctx, instKey.detailKey("router", 13)).Result(); !redis.IsNilErr(err) {
+		t.Errorf("expect the instance of the object which is not cached skipped, err: %v", err)
+	}
+	if _, err := redis.Client().Get(ctx, instKey.detailKey("switch", 0)).Result(); !redis.IsNilErr(err) {
+		t.Errorf("expect the instance without id skipped, err: %v", err)
+	}
+}
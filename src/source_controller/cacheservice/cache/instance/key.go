@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instance
+
+import (
+	"math/rand"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+)
+
+const instKeyNamespace = common.BKCacheKeyV3Prefix + "inst"
+
+var instKey = instKeyGenerator{
+	namespace: instKeyNamespace,
+	// 30 minutes
+	expireSeconds:      30 * 60 * time.Second,
+	expireRangeSeconds: [2]int{-600, 600},
+}
+
+type instKeyGenerator struct {
+	namespace string
+	// expireSeconds is defined how long is the ttl for the key.
+	// it's always used with the expireRangeSeconds to avoid the keys is expired at same time.
+	// the ttl is necessary because the instances of the object which is removed from the config
+	// will not be updated any more, so they must be expired finally.
+	expireSeconds time.Duration
+	// min:[0], max:[1]
+	expireRangeSeconds [2]int
+}
+
+func (i instKeyGenerator) detailKey(objID string, instID int64) string {
+	return i.namespace + ":" + objID + ":detail:" + strconv.FormatInt(instID, 10)
+}
+
+// listDoneKey stores the objects whose instances has been list to cache, joined with comma.
+// the instances need to be list again if the objects is changed.
+func (i instKeyGenerator) listDoneKey() string {
+	return i.namespace + ":listdone"
+}
+
+func (i instKeyGenerator) withRandomExpireSeconds() time.Duration {
+	rand.Seed(time.Now().UnixNano())
+	seconds := rand.Intn(i.expireRangeSeconds[1]-i.expireRangeSeconds[0]) + i.expireRangeSeconds[0]
+	return i.expireSeconds + time.Duration(seconds)*time.Second
+}
@@ -0,0 +1,257 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	ccError "configcenter/src/common/errors"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/cache/tools"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/redis"
+)
+
+type Client struct {
+	// metrics of each kind of model resource.
+	metrics map[string]*tools.CacheMetrics
+}
+
+// ListModels list the models of the request's owner from cache, including the preset models which are shared
+// by all the owners, all the models will be returned if the object ids is empty.
+// if the cache is not ready yet, then get from mongodb directly.
+func (c *Client) ListModels(ctx context.Context, opt *metadata.ListModelCacheOption) ([]string, error) {
+	rid := ctx.Value(common.ContextRequestIDField)
+	if len(opt.ObjectIDs) > 500 {
+		return nil, errors.New("object id length is over limit")
+	}
+
+	ownerID := util.ExtractOwnerFromContext(ctx)
+	owners, cached := readableOwners(objectKind, ownerID)
+	if !cached || !c.isListDone(ctx, objectKind) {
+		c.metrics[objectKind].CollectMiss()
+		return listFromMongo(ctx, common.BKTableNameObjDes, ownerFilter(objectKind, objectFilter(opt.ObjectIDs),
+			ownerID), opt.Fields)
+	}
+
+	all := make([]string, 0)
+	if len(opt.ObjectIDs) == 0 {
+		for _, owner := range owners {
+			models, err := redis.Client().HGetAll(context.Background(), modelKey.objectKey(owner)).Result()
+			if err != nil {
+				blog.Errorf("list all models from cache failed, get from db directly, err: %v, rid: %v", err, rid)
+				c.metrics[objectKind].CollectMiss()
+				return listFromMongo(ctx, common.BKTableNameObjDes, ownerFilter(objectKind, objectFilter(nil),
+					ownerID), opt.Fields)
+			}
+
+			for _, detail := range models {
+				all = append(all, cutFields(detail, opt.Fields))
+			}
+		}
+		c.metrics[objectKind].CollectHit()
+		return all, nil
+	}
+
+	// the models not found in the owner's cache are searched in the shared models' cache.
+	toAdd := opt.ObjectIDs
+	for _, owner := range owners {
+		if len(toAdd) == 0 {
+			break
+		}
+
+		models, err := redis.Client().HMGet(context.Background(), modelKey.objectKey(owner), toAdd...).Result()
+		if err != nil {
+			blog.Errorf("list models %v from cache failed, get from db directly, err: %v, rid: %v", opt.ObjectIDs,
+				err, rid)
+			c.metrics[objectKind].CollectMiss()
+			return listFromMongo(ctx, common.BKTableNameObjDes, ownerFilter(objectKind,
+				objectFilter(opt.ObjectIDs), ownerID), opt.Fields)
+		}
+
+		missing := make([]string, 0)
+		for idx, model := range models {
+			if model == nil {
+				// can not find in cache
+				missing = append(missing, toAdd[idx])
+				continue
+			}
+
+			detail, ok := model.(string)
+			if !ok {
+				blog.Errorf("got invalid model cache %v, rid: %v", model, rid)
+				return nil, fmt.Errorf("got invalid model cache %v", model)
+			}
+			all = append(all, cutFields(detail, opt.Fields))
+		}
+		toAdd = missing
+	}
+
+	if len(toAdd) == 0 {
+		c.metrics[objectKind].CollectHit()
+		return all, nil
+	}
+
+	c.metrics[objectKind].CollectMiss()
+	details, err := listFromMongo(ctx, common.BKTableNameObjDes, ownerFilter(objectKind, objectFilter(toAdd), ownerID),
+		opt.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return append(all, details...), nil
+}
+
+// ListAttributes list all the attributes of a model, including the business's custom attributes.
+func (c *Client) ListAttributes(ctx context.Context, objID string) ([]string, error) {
+	filter := mapstr.MapStr{common.BKObjIDField: objID}
+	return c.listObjectResource(ctx, attributeKind, common.BKTableNameObjAttDes, objID, filter)
+}
+
+// ListUniques list all the unique rules of a model.
+func (c *Client) ListUniques(ctx context.Context, objID string) ([]string, error) {
+	filter := mapstr.MapStr{common.BKObjIDField: objID}
+	return c.listObjectResource(ctx, uniqueKind, common.BKTableNameObjUnique, objID, filter)
+}
+
+// ListAssociations list all the associations of a model, whether the model is the source or the destination
+// model of the association.
+func (c *Client) ListAssociations(ctx context.Context, objID string) ([]string, error) {
+	filter := mapstr.MapStr{
+		common.BKDBOR: []mapstr.MapStr{
+			{common.BKObjIDField: objID},
+			{common.BKAsstObjIDField: objID},
+		},
+	}
+	return c.listObjectResource(ctx, associationKind, common.BKTableNameObjAsst, objID, filter)
+}
+
+// listObjectResource list the request's owner's resources of a model from cache.
+func (c *Client) listObjectResource(ctx context.Context, kind, collection, objID string, filter mapstr.MapStr) (
+	[]string, error) {
+
+	rid := ctx.Value(common.ContextRequestIDField)
+	if len(objID) == 0 {
+		return nil, errors.New("object id is empty")
+	}
+
+	ownerID := util.ExtractOwnerFromContext(ctx)
+	filter = ownerFilter(kind, filter, ownerID)
+	owners, cached := readableOwners(kind, ownerID)
+	if !cached || !c.isListDone(ctx, kind) {
+		c.metrics[kind].CollectMiss()
+		return listFromMongo(ctx, collection, filter, nil)
+	}
+
+	all := make([]string, 0)
+	for _, owner := range owners {
+		details, err := redis.Client().HGetAll(context.Background(), modelKey.resourceKey(kind, owner, objID)).Result()
+		if err != nil {
+			blog.Errorf("list model %s %s from cache failed, get from db directly, err: %v, rid: %v", objID, kind,
+				err, rid)
+			c.metrics[kind].CollectMiss()
+			return listFromMongo(ctx, collection, filter, nil)
+		}
+
+		for _, detail := range details {
+			all = append(all, detail)
+		}
+	}
+
+	c.metrics[kind].CollectHit()
+	return all, nil
+}
+
+// readableOwners returns the owners whose resources of the kind can be read by the owner, the default owner's
+// resources of the shared kinds are the preset ones which can be read by all the owners. the superadmin can read
+// all the owners' resources, which are not cached as a whole, so it returns false for the superadmin.
+func readableOwners(kind, ownerID string) ([]string, bool) {
+	if ownerID == common.BKSuperOwnerID {
+		return nil, false
+	}
+
+	owners := []string{ownerID}
+	if ownerID != common.BKDefaultOwnerID && isSharedKind(kind) {
+		owners = append(owners, common.BKDefaultOwnerID)
+	}
+	return owners, true
+}
+
+// ownerFilter adds the condition of the owners whose resources of the kind can be read by the owner to the filter.
+func ownerFilter(kind string, filter mapstr.MapStr, ownerID string) mapstr.MapStr {
+	if isSharedKind(kind) {
+		return util.SetQueryOwner(filter, ownerID)
+	}
+	return util.SetModOwner(filter, ownerID)
+}
+
+func isSharedKind(kind string) bool {
+	for _, res := range resources {
+		if res.kind == kind {
+			return res.shared
+		}
+	}
+	return false
+}
+
+// isListDone checks if all the resources of this kind has been synced to cache. the cache is only
+// reliable after the list is done, otherwise the resources may be missing in cache.
+func (c *Client) isListDone(ctx context.Context, kind string) bool {
+	_, err := redis.Client().Get(context.Background(), modelKey.listDoneKey(kind)).Result()
+	if err != nil {
+		if !redis.IsNilErr(err) {
+			blog.Errorf("get model %s list done key failed, err: %v, rid: %v", kind, err,
+				ctx.Value(common.ContextRequestIDField))
+		}
+		return false
+	}
+	return true
+}
+
+func objectFilter(objIDs []string) mapstr.MapStr {
+	if len(objIDs) == 0 {
+		return mapstr.MapStr{}
+	}
+	return mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objIDs}}
+}
+
+func cutFields(detail string, fields []string) string {
+	if len(fields) == 0 {
+		return detail
+	}
+	return *json.CutJsonDataWithFields(&detail, fields)
+}
+
+func listFromMongo(ctx context.Context, collection string, filter mapstr.MapStr, fields []string) ([]string, error) {
+	rid := ctx.Value(common.ContextRequestIDField)
+
+	list := make([]map[string]interface{}, 0)
+	err := mongodb.Client().Table(collection).Find(filter).Fields(fields...).All(ctx, &list)
+	if err != nil {
+		blog.Errorf("list %s from db failed, filter: %v, err: %v, rid: %v", collection, filter, err, rid)
+		return nil, ccError.New(common.CCErrCommDBSelectFailed, err.Error())
+	}
+
+	all := make([]string, len(list))
+	for idx, item := range list {
+		js, _ := json.Marshal(item)
+		all[idx] = string(js)
+	}
+	return all, nil
+}
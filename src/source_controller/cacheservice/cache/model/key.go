@@ -0,0 +1,67 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"configcenter/src/common"
+)
+
+const modelKeyNamespace = common.BKCacheKeyV3Prefix + "model"
+
+// the kinds of the model resources which are cached.
+const (
+	objectKind      = "object"
+	attributeKind   = "attribute"
+	uniqueKind      = "unique"
+	associationKind = "association"
+)
+
+var modelKey = modelKeyGenerator{namespace: modelKeyNamespace}
+
+// the model resources are all stored in redis hashes without ttl, they are kept consistent with the
+// mongodb by the watch events.
+type modelKeyGenerator struct {
+	namespace string
+}
+
+// objectKey is a hash key to store all the models of the owner,
+// field: bk_obj_id, value: model's detail.
+func (m modelKeyGenerator) objectKey(ownerID string) string {
+	return m.namespace + ":" + objectKind + ":" + ownerID
+}
+
+// resourceKey is a hash key to store the owner's attributes, uniques or associations of a model,
+// field: id, value: the resource's detail.
+func (m modelKeyGenerator) resourceKey(kind, ownerID, objID string) string {
+	return m.namespace + ":" + kind + ":" + ownerID + ":" + objID
+}
+
+// oidKey is a hash key to store the relation between the mongodb document's oid and the cache keys,
+// which is used to delete the cache when the document is deleted, because the model documents are
+// not archived when they are deleted.
+// field: oid, value: json of oidRelation.
+func (m modelKeyGenerator) oidKey() string {
+	return m.namespace + ":oid"
+}
+
+// listDoneKey is the key to mark that all the resources of the kind has been listed to cache, the resources
+// are cached by owner since this key is used, so the resources cached before are listed again.
+func (m modelKeyGenerator) listDoneKey(kind string) string {
+	return m.namespace + ":owner_listdone:" + kind
+}
+
+// oidRelation records which hash keys and field a mongodb document is stored in.
+type oidRelation struct {
+	Keys  []string `json:"keys"`
+	Field string   `json:"field"`
+}
@@ -0,0 +1,227 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/storage/driver/redis"
+	"configcenter/src/storage/reflector"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/tidwall/gjson"
+)
+
+// resource describes a kind of model resource and how it's stored in cache.
+type resource struct {
+	kind       string
+	collection string
+	// shared is whether the default owner's resources are the preset ones shared by all the owners.
+	shared bool
+	// parse returns the hash keys and the field that the document should be stored in.
+	parse func(doc []byte) (keys []string, field string, err error)
+}
+
+var resources = []resource{
+	{
+		kind:       objectKind,
+		collection: common.BKTableNameObjDes,
+		shared:     true,
+		parse: func(doc []byte) ([]string, string, error) {
+			elements := gjson.GetManyBytes(doc, common.BKObjIDField, common.BKOwnerIDField)
+			objID, ownerID := elements[0].String(), elements[1].String()
+			if len(objID) == 0 || len(ownerID) == 0 {
+				return nil, "", fmt.Errorf("invalid object id or owner id")
+			}
+			return []string{modelKey.objectKey(ownerID)}, objID, nil
+		},
+	},
+	{
+		kind:       attributeKind,
+		collection: common.BKTableNameObjAttDes,
+		shared:     true,
+		parse:      parseObjectResource(attributeKind),
+	},
+	{
+		kind:       uniqueKind,
+		collection: common.BKTableNameObjUnique,
+		shared:     true,
+		parse:      parseObjectResource(uniqueKind),
+	},
+	{
+		kind:       associationKind,
+		collection: common.BKTableNameObjAsst,
+		shared:     true,
+		parse: func(doc []byte) ([]string, string, error) {
+			elements := gjson.GetManyBytes(doc, common.BKFieldID, common.BKObjIDField, common.BKAsstObjIDField,
+				common.BKOwnerIDField)
+			id, objID, asstObjID, ownerID := elements[0].Int(), elements[1].String(), elements[2].String(),
+				elements[3].String()
+			if id <= 0 || len(objID) == 0 || len(asstObjID) == 0 || len(ownerID) == 0 {
+				return nil, "", fmt.Errorf("invalid association id, object id or owner id")
+			}
+
+			// an association belongs to both the source and the destination model.
+			keys := []string{modelKey.resourceKey(associationKind, ownerID, objID)}
+			if asstObjID != objID {
+				keys = append(keys, modelKey.resourceKey(associationKind, ownerID, asstObjID))
+			}
+			return keys, elements[0].String(), nil
+		},
+	},
+}
+
+// parseObjectResource parse the resource which belongs to a model and is identified by id.
+func parseObjectResource(kind string) func(doc []byte) ([]string, string, error) {
+	return func(doc []byte) ([]string, string, error) {
+		elements := gjson.GetManyBytes(doc, common.BKFieldID, common.BKObjIDField, common.BKOwnerIDField)
+		if elements[0].Int() <= 0 || len(elements[1].String()) == 0 || len(elements[2].String()) == 0 {
+			return nil, "", fmt.Errorf("invalid %s id, object id or owner id", kind)
+		}
+		return []string{modelKey.resourceKey(kind, elements[2].String(), elements[1].String())},
+			elements[0].String(), nil
+	}
+}
+
+type modelCache struct {
+	resource resource
+	event    reflector.Interface
+}
+
+func (m *modelCache) Run() error {
+
+	opts := types.Options{
+		EventStruct: new(map[string]interface{}),
+		Collection:  m.resource.collection,
+	}
+
+	_, err := redis.Client().Get(context.Background(), modelKey.listDoneKey(m.resource.kind)).Result()
+	if err != nil {
+		if !redis.IsNilErr(err) {
+			blog.Errorf("get model %s list done redis key failed, err: %v", m.resource.kind, err)
+			return fmt.Errorf("get model %s list done redis key failed, err: %v", m.resource.kind, err)
+		}
+		listCap := &reflector.Capable{
+			OnChange: reflector.OnChangeEvent{
+				OnLister:     m.onUpsert,
+				OnAdd:        m.onUpsert,
+				OnUpdate:     m.onUpsert,
+				OnListerDone: m.onListDone,
+				OnDelete:     m.onDelete,
+			},
+		}
+		// do with list watcher.
+		page := 500
+		listOpts := &types.ListWatchOptions{
+			Options:  opts,
+			PageSize: &page,
+		}
+		blog.Infof("do model %s cache with list watcher.", m.resource.kind)
+		return m.event.ListWatcher(context.Background(), listOpts, listCap)
+	}
+
+	watchCap := &reflector.Capable{
+		OnChange: reflector.OnChangeEvent{
+			OnAdd:    m.onUpsert,
+			OnUpdate: m.onUpsert,
+			OnDelete: m.onDelete,
+		},
+	}
+	// do with watcher only.
+	watchOpts := &types.WatchOptions{
+		Options: opts,
+	}
+	blog.Infof("do model %s cache with only watcher", m.resource.kind)
+	return m.event.Watcher(context.Background(), watchOpts, watchCap)
+}
+
+func (m *modelCache) onUpsert(e *types.Event) {
+	blog.V(4).Infof("received model %s upsert event, oid: %s, doc: %s", m.resource.kind, e.Oid, e.DocBytes)
+
+	keys, field, err := m.resource.parse(e.DocBytes)
+	if err != nil {
+		blog.Errorf("received model %s upsert event, but parse doc failed, oid: %s, doc: %s, err: %v",
+			m.resource.kind, e.Oid, e.DocBytes, err)
+		return
+	}
+
+	relation, err := json.Marshal(oidRelation{Keys: keys, Field: field})
+	if err != nil {
+		blog.Errorf("received model %s upsert event, but marshal oid relation failed, oid: %s, err: %v",
+			m.resource.kind, e.Oid, err)
+		return
+	}
+
+	pipeline := redis.Client().Pipeline()
+	for _, key := range keys {
+		pipeline.HSet(key, field, string(e.DocBytes))
+	}
+	pipeline.HSet(modelKey.oidKey(), e.Oid, string(relation))
+
+	if _, err := pipeline.Exec(); err != nil {
+		blog.Errorf("received model %s upsert event, but upsert cache failed, oid: %s, err: %v",
+			m.resource.kind, e.Oid, err)
+		return
+	}
+}
+
+func (m *modelCache) onDelete(e *types.Event) {
+	blog.Infof("received model %s delete event, oid: %s", m.resource.kind, e.Oid)
+
+	value, err := redis.Client().HGet(context.Background(), modelKey.oidKey(), e.Oid).Result()
+	if err != nil {
+		if redis.IsNilErr(err) {
+			blog.Warnf("received model %s delete event, but oid %s is not in cache, skip", m.resource.kind, e.Oid)
+			return
+		}
+		blog.Errorf("received model %s delete event, but get oid relation failed, oid: %s, err: %v",
+			m.resource.kind, e.Oid, err)
+		return
+	}
+
+	relation := new(oidRelation)
+	if err := json.Unmarshal([]byte(value), relation); err != nil {
+		blog.Errorf("received model %s delete event, but unmarshal oid relation %s failed, oid: %s, err: %v",
+			m.resource.kind, value, e.Oid, err)
+		return
+	}
+
+	pipeline := redis.Client().Pipeline()
+	for _, key := range relation.Keys {
+		pipeline.HDel(key, relation.Field)
+	}
+	pipeline.HDel(modelKey.oidKey(), e.Oid)
+
+	if _, err := pipeline.Exec(); err != nil {
+		blog.Errorf("received model %s delete event, but delete cache failed, oid: %s, err: %v",
+			m.resource.kind, e.Oid, err)
+		return
+	}
+	blog.Infof("received model %s delete event, delete cache %v field %s success, oid: %s", m.resource.kind,
+		relation.Keys, relation.Field, e.Oid)
+}
+
+// onListDone is to tell us that all the resources has been list from mongodb and already
+// sync it to cache.
+func (m *modelCache) onListDone() {
+	if err := redis.Client().Set(context.Background(), modelKey.listDoneKey(m.resource.kind), "done", 0).Err(); err != nil {
+		blog.Errorf("list model %s data to cache and list done, but set list done key failed, err: %v",
+			m.resource.kind, err)
+		return
+	}
+	blog.Infof("list model %s data to cache and list done", m.resource.kind)
+}
@@ -0,0 +1,187 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	ccRedis "configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/driver/redis"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/alicebob/miniredis"
+)
+
+func getResource(t *testing.T, kind string) resource {
+	for _, res := range resources {
+		if res.kind == kind {
+			return res
+		}
+	}
+	t.Fatalf("resource %s not exist", kind)
+	return resource{}
+}
+
+func TestParseResource(t *testing.T) {
+	tests := []struct {
+		kind  string
+		doc   string
+		keys  []string
+		field string
+		err   bool
+	}{
+		{
+			kind:  objectKind,
+			doc:   `{"bk_obj_id":"switch","bk_supplier_account":"0"}`,
+			keys:  []string{modelKeyNamespace + ":object:0"},
+			field: "switch",
+		},
+		{
+			kind:  attributeKind,
+			doc:   `{"id":12,"bk_obj_id":"switch","bk_supplier_account":"1"}`,
+			keys:  []string{modelKeyNamespace + ":attribute:1:switch"},
+			field: "12",
+		},
+		{
+			kind:  uniqueKind,
+			doc:   `{"id":3,"bk_obj_id":"host","bk_supplier_account":"0"}`,
+			keys:  []string{modelKeyNamespace + ":unique:0:host"},
+			field: "3",
+		},
+		{
+			kind: associationKind,
+			doc:  `{"id":5,"bk_obj_id":"switch","bk_asst_obj_id":"host","bk_supplier_account":"0"}`,
+			keys: []string{modelKeyNamespace + ":association:0:switch",
+				modelKeyNamespace + ":association:0:host"},
+			field: "5",
+		},
+		{
+			// the association between the same model is stored in the model's key only once.
+			kind:  associationKind,
+			doc:   `{"id":6,"bk_obj_id":"host","bk_asst_obj_id":"host","bk_supplier_account":"0"}`,
+			keys:  []string{modelKeyNamespace + ":association:0:host"},
+			field: "6",
+		},
+		{kind: objectKind, doc: `{"bk_supplier_account":"0"}`, err: true},
+		{kind: objectKind, doc: `{"bk_obj_id":"switch"}`, err: true},
+		{kind: attributeKind, doc: `{"bk_obj_id":"switch","bk_supplier_account":"0"}`, err: true},
+		{kind: associationKind, doc: `{"id":5,"bk_obj_id":"switch","bk_supplier_account":"0"}`, err: true},
+	}
+
+	for _, test := range tests {
+		keys, field, err := getResource(t, test.kind).parse([]byte(test.doc))
+		if test.err {
+			if err == nil {
+				t.Errorf("parse %s %s expect error, got keys: %v", test.kind, test.doc, keys)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parse %s %s failed, err: %v", test.kind, test.doc, err)
+			continue
+		}
+		if !reflect.DeepEqual(keys, test.keys) || field != test.field {
+			t.Errorf("parse %s %s got keys %v field %s, want keys %v field %s", test.kind, test.doc, keys, field,
+				test.keys, test.field)
+		}
+	}
+}
+
+func TestReadableOwners(t *testing.T) {
+	owners, cached := readableOwners(objectKind, "1")
+	if !cached || !reflect.DeepEqual(owners, []string{"1", common.BKDefaultOwnerID}) {
+		t.Errorf("expect the shared models readable, got %v, %v", owners, cached)
+	}
+
+	// the preset uniques and the mainline association are shared too.
+	for _, kind := range []string{uniqueKind, associationKind} {
+		owners, cached = readableOwners(kind, "1")
+		if !cached || !reflect.DeepEqual(owners, []string{"1", common.BKDefaultOwnerID}) {
+			t.Errorf("expect the shared %s readable, got %v, %v", kind, owners, cached)
+		}
+	}
+
+	owners, cached = readableOwners(attributeKind, common.BKDefaultOwnerID)
+	if !cached || !reflect.DeepEqual(owners, []string{common.BKDefaultOwnerID}) {
+		t.Errorf("expect only the default owner's attributes readable, got %v, %v", owners, cached)
+	}
+
+	if _, cached = readableOwners(objectKind, common.BKSuperOwnerID); cached {
+		t.Errorf("expect the superadmin's models not cached")
+	}
+
+	filter := ownerFilter(objectKind, mapstr.MapStr{common.BKObjIDField: "switch"}, "1")
+	want := mapstr.MapStr{
+		common.BKObjIDField:   "switch",
+		common.BKOwnerIDField: map[string]interface{}{common.BKDBIN: []string{common.BKDefaultOwnerID, "1"}},
+	}
+	if !reflect.DeepEqual(filter, want) {
+		t.Errorf("ownerFilter() = %v, want %v", filter, want)
+	}
+
+	filter = ownerFilter(uniqueKind, mapstr.MapStr{common.BKObjIDField: "switch"}, common.BKDefaultOwnerID)
+	want = mapstr.MapStr{common.BKObjIDField: "switch", common.BKOwnerIDField: common.BKDefaultOwnerID}
+	if !reflect.DeepEqual(filter, want) {
+		t.Errorf("ownerFilter() = %v, want %v", filter, want)
+	}
+}
+
+func TestModelCacheDelete(t *testing.T) {
+	redisMock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run mock redis failed, err: %v", err)
+	}
+	defer redisMock.Close()
+	if err := redis.InitClient("redis", &ccRedis.Config{Address: redisMock.Addr(), Database: "0"}); err != nil {
+		t.Fatalf("init redis client failed, err: %v", err)
+	}
+
+	cache := &modelCache{resource: getResource(t, associationKind)}
+	doc := `{"id":5,"bk_obj_id":"switch","bk_asst_obj_id":"host","bk_supplier_account":"0"}`
+	cache.onUpsert(&types.Event{Oid: "oid-5", DocBytes: []byte(doc)})
+	other := `{"id":6,"bk_obj_id":"switch","bk_asst_obj_id":"router","bk_supplier_account":"0"}`
+	cache.onUpsert(&types.Event{Oid: "oid-6", DocBytes: []byte(other)})
+
+	ctx := context.Background()
+	for _, objID := range []string{"switch", "host"} {
+		detail, err := redis.Client().HGet(ctx, modelKey.resourceKey(associationKind, "0", objID), "5").Result()
+		if err != nil || detail != doc {
+			t.Fatalf("expect association 5 cached for %s, got %s, err: %v", objID, detail, err)
+		}
+	}
+
+	// the deleted document is found by the oid relation, since the model documents are not archived.
+	cache.onDelete(&types.Event{Oid: "oid-5"})
+	for _, objID := range []string{"switch", "host"} {
+		_, err := redis.Client().HGet(ctx, modelKey.resourceKey(associationKind, "0", objID), "5").Result()
+		if !redis.IsNilErr(err) {
+			t.Errorf("expect association 5 removed from %s, err: %v", objID, err)
+		}
+	}
+	if _, err := redis.Client().HGet(ctx, modelKey.oidKey(), "oid-5").Result(); !redis.IsNilErr(err) {
+		t.Errorf("expect oid relation of association 5 removed, err: %v", err)
+	}
+
+	// the other association is not affected.
+	detail, err := redis.Client().HGet(ctx, modelKey.resourceKey(associationKind, "0", "switch"), "6").Result()
+	if err != nil || detail != other {
+		t.Errorf("expect association 6 still cached, got %s, err: %v", detail, err)
+	}
+
+	// the unknown oid is skipped.
+	cache.onDelete(&types.Event{Oid: "oid-unknown"})
+}
@@ -0,0 +1,50 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	"configcenter/src/common/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewCacheMetrics initialize the cache request metrics of the resource, it can only be called for once
+// with the same resource.
+func NewCacheMetrics(resource string) *CacheMetrics {
+	m := new(CacheMetrics)
+	m.totalRequestCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        metrics.Namespace + "cache_total_request_count",
+		Help:        "the total request count of the resources in cache, labeled by whether it hits the cache",
+		ConstLabels: prometheus.Labels{"resource": resource},
+	}, []string{"result"})
+	metrics.Register().MustRegister(m.totalRequestCount)
+
+	return m
+}
+
+// CacheMetrics records how many requests hit the cache and how many requests are served by mongodb.
+type CacheMetrics struct {
+	// record the total request count with the result label, which is hit or miss.
+	totalRequestCount *prometheus.CounterVec
+}
+
+// CollectHit collect a request which is served by cache.
+func (cm *CacheMetrics) CollectHit() {
+	cm.totalRequestCount.With(prometheus.Labels{"result": "hit"}).Inc()
+}
+
+// CollectMiss collect a request which is served by mongodb, because the data is not in cache or
+// the cache is not ready yet.
+func (cm *CacheMetrics) CollectMiss() {
+	cm.totalRequestCount.With(prometheus.Labels{"result": "miss"}).Inc()
+}
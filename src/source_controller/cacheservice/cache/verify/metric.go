@@ -0,0 +1,60 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package verify
+
+import (
+	"configcenter/src/common/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type verifyMetrics struct {
+	// driftCount records how many cached resources has drifted from mongodb in the last verification,
+	// labeled by resource and the drift type, which is mismatch or stale.
+	driftCount *prometheus.GaugeVec
+	// checkedTotal records the total count of the verified cached resources.
+	checkedTotal *prometheus.CounterVec
+	// repairedTotal records the total count of the repaired cached resources.
+	repairedTotal *prometheus.CounterVec
+}
+
+func newVerifyMetrics() *verifyMetrics {
+	m := new(verifyMetrics)
+	m.driftCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: metrics.Namespace + "cache_drift_count",
+		Help: "the count of the cached resources which has drifted from mongodb in the last verification",
+	}, []string{"resource", "type"})
+	metrics.Register().MustRegister(m.driftCount)
+
+	m.checkedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metrics.Namespace + "cache_verify_checked_total",
+		Help: "the total count of the cached resources which are verified with mongodb",
+	}, []string{"resource"})
+	metrics.Register().MustRegister(m.checkedTotal)
+
+	m.repairedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metrics.Namespace + "cache_repaired_total",
+		Help: "the total count of the drifted cached resources which are repaired",
+	}, []string{"resource"})
+	metrics.Register().MustRegister(m.repairedTotal)
+
+	return m
+}
+
+func (m *verifyMetrics) collect(report *Report) {
+	m.driftCount.With(prometheus.Labels{"resource": report.Resource, "type": "mismatch"}).
+		Set(float64(len(report.Mismatched)))
+	m.driftCount.With(prometheus.Labels{"resource": report.Resource, "type": "stale"}).Set(float64(len(report.Stale)))
+	m.checkedTotal.With(prometheus.Labels{"resource": report.Resource}).Add(float64(report.Checked))
+	m.repairedTotal.With(prometheus.Labels{"resource": report.Resource}).Add(float64(report.Repaired))
+}
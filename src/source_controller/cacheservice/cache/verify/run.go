@@ -0,0 +1,76 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package verify
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/driver/redis"
+)
+
+// Run verifies the sampled resources' cache with the interval in background, and repairs the drift.
+// the verification of a resource is done by only one cacheservice instance in an interval.
+func (v *Verifier) Run(interval time.Duration, sampleSize int) {
+	blog.Infof("start cache verifier, interval: %v, sample size: %d", interval, sampleSize)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			for _, res := range v.resources {
+				v.runOnce(res.Name, interval, sampleSize)
+			}
+		}
+	}()
+}
+
+func (v *Verifier) runOnce(name string, interval time.Duration, sampleSize int) {
+	rid := util.GenerateRID()
+	// the cache is shared by all the tenants, so it's verified with all the tenants' data.
+	ctx := dal.WithoutTenant(context.WithValue(context.Background(), common.ContextRequestIDField, rid))
+
+	// the lock is not released after the verification, so that other instances will skip it in this interval.
+	locked, err := redis.Client().SetNX(ctx, lockKey(name), rid, interval).Result()
+	if err != nil {
+		blog.Errorf("verify %s cache, but get the lock failed, err: %v, rid: %s", name, err, rid)
+		return
+	}
+
+	if !locked {
+		blog.V(4).Infof("verify %s cache, but the lock is hold by others, skip, rid: %s", name, rid)
+		return
+	}
+
+	report, err := v.Verify(ctx, name, &Option{SampleSize: sampleSize, Repair: true})
+	if err != nil {
+		blog.Errorf("verify %s cache failed, err: %v, rid: %s", name, err, rid)
+		return
+	}
+
+	if report.Drift() == 0 {
+		blog.V(4).Infof("verify %s cache success, checked: %d, no drift found, rid: %s", name, report.Checked, rid)
+		return
+	}
+	blog.Warnf("verify %s cache, checked: %d, found mismatched: %v, stale: %v, repaired: %d, rid: %s", name,
+		report.Checked, report.Mismatched, report.Stale, report.Repaired, rid)
+}
+
+func lockKey(name string) string {
+	return common.BKCacheKeyV3Prefix + "verify:lock:" + name
+}
@@ -0,0 +1,85 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package verify
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Resource describes how a kind of resource is stored in cache, so that the cache can be verified
+// with the data in mongodb.
+type Resource struct {
+	// Name is the unique name of the resource, like host, biz.
+	Name string
+	// Collection is the mongodb collection where the resource is stored.
+	Collection string
+	// IDField is the resource's id field in the collection.
+	IDField string
+	// KeyPattern is the pattern to scan the detail keys of the resource in redis.
+	KeyPattern string
+	// ParseKey parses the resource id from the key scanned with KeyPattern, returns false if the key is
+	// not a detail key, like the lock or expire keys which are also matched by the pattern.
+	ParseKey func(key string) (int64, bool)
+	// DetailKey returns the detail key of the resource.
+	DetailKey func(id int64) string
+	// ListDetails list the resources' details from mongodb in the same format as they are stored in cache,
+	// the resources which are not exist in mongodb are not returned.
+	ListDetails func(ctx context.Context, ids []int64) (map[int64]string, error)
+	// Repair refreshes the resource's cache with its detail in mongodb.
+	Repair func(ctx context.Context, id int64, detail string) error
+	// Remove removes the resource's cache when it's not exist in mongodb any more.
+	Remove func(ctx context.Context, id int64) error
+}
+
+// Option is the option to verify a resource's cache.
+type Option struct {
+	// Full is whether to scan all the cached resources, otherwise only SampleSize resources are
+	// scanned from where the last verification stopped, so that all the resources are verified in turn.
+	Full bool `json:"full"`
+	// SampleSize is how many cached resources are verified when Full is false.
+	SampleSize int `json:"sample_size"`
+	// Repair is whether to repair the drifted cache.
+	Repair bool `json:"repair"`
+}
+
+// Validate validates the verify option.
+func (o *Option) Validate() error {
+	if !o.Full && o.SampleSize <= 0 {
+		return errors.New("sample size must be positive when it's not a full verification")
+	}
+	return nil
+}
+
+// Report is the result of a resource's cache verification.
+type Report struct {
+	Resource  string    `json:"resource"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	// Checked is how many cached resources are checked.
+	Checked int `json:"checked"`
+	// Mismatched is the resources whose cache is not same with the data in mongodb.
+	Mismatched []int64 `json:"mismatched"`
+	// Stale is the resources which are still in cache but has already been deleted in mongodb.
+	Stale []int64 `json:"stale"`
+	// Repaired is how many drifted resources are repaired.
+	Repaired int `json:"repaired"`
+	// Errors is the errors occurred when repairing the drifted resources.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Drift returns how many resources' cache has drifted from mongodb.
+func (r *Report) Drift() int {
+	return len(r.Mismatched) + len(r.Stale)
+}
@@ -0,0 +1,289 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/redis"
+)
+
+const (
+	// scanStep is how many keys are scanned from redis in one step.
+	scanStep = 500
+	// rebuildStep is how many resources are read from mongodb in one step when the cache is rebuilt.
+	rebuildStep = 500
+)
+
+// Verifier verifies the resources' cache with the data in mongodb, and repairs the drifted cache.
+// the resources which are not in cache are not treated as drift, because the cache is filled lazily
+// or expired with ttl, only the cached ones are verified.
+type Verifier struct {
+	resources []Resource
+	metrics   *verifyMetrics
+
+	lock sync.Mutex
+	// cursors is the redis scan cursor of each resource where the last sampled verification stopped.
+	cursors map[string]uint64
+}
+
+// NewVerifier new a verifier of the resources, it can only be called for once.
+func NewVerifier(resources []Resource) *Verifier {
+	return &Verifier{
+		resources: resources,
+		metrics:   newVerifyMetrics(),
+		cursors:   make(map[string]uint64),
+	}
+}
+
+// Resources returns the names of the resources which can be verified.
+func (v *Verifier) Resources() []string {
+	names := make([]string, len(v.resources))
+	for idx, res := range v.resources {
+		names[idx] = res.Name
+	}
+	return names
+}
+
+func (v *Verifier) getResource(name string) (*Resource, error) {
+	for idx := range v.resources {
+		if v.resources[idx].Name == name {
+			return &v.resources[idx], nil
+		}
+	}
+	return nil, fmt.Errorf("resource %s can not be verified, supported resources: %v", name, v.Resources())
+}
+
+// Verify compares the resource's cache with the data in mongodb, and repairs the drift if needed.
+func (v *Verifier) Verify(ctx context.Context, name string, opt *Option) (*Report, error) {
+	if err := opt.Validate(); err != nil {
+		return nil, err
+	}
+
+	res, err := v.getResource(name)
+	if err != nil {
+		return nil, err
+	}
+
+	rid := ctx.Value(common.ContextRequestIDField)
+	report := &Report{
+		Resource:   name,
+		StartTime:  time.Now(),
+		Mismatched: make([]int64, 0),
+		Stale:      make([]int64, 0),
+	}
+
+	var cursor uint64
+	if !opt.Full {
+		v.lock.Lock()
+		cursor = v.cursors[name]
+		v.lock.Unlock()
+	}
+
+	for {
+		keys, next, err := redis.Client().Scan(ctx, cursor, res.KeyPattern, scanStep).Result()
+		if err != nil {
+			blog.Errorf("verify %s cache, but scan keys with cursor %d failed, err: %v, rid: %v", name, cursor, err, rid)
+			return nil, err
+		}
+
+		ids := make([]int64, 0)
+		for _, key := range keys {
+			if id, ok := res.ParseKey(key); ok {
+				ids = append(ids, id)
+			}
+		}
+
+		if err := v.verifyResources(ctx, res, util.IntArrayUnique(ids), opt.Repair, report); err != nil {
+			return nil, err
+		}
+
+		cursor = next
+		if cursor == 0 {
+			// all the keys has been scanned.
+			break
+		}
+
+		if !opt.Full && report.Checked >= opt.SampleSize {
+			break
+		}
+	}
+
+	if !opt.Full {
+		v.lock.Lock()
+		v.cursors[name] = cursor
+		v.lock.Unlock()
+	}
+
+	report.EndTime = time.Now()
+	v.metrics.collect(report)
+	return report, nil
+}
+
+// verifyResources verifies the cached resources with ids, and records the result in the report.
+func (v *Verifier) verifyResources(ctx context.Context, res *Resource, ids []int64, repair bool,
+	report *Report) error {
+
+	if len(ids) == 0 {
+		return nil
+	}
+	rid := ctx.Value(common.ContextRequestIDField)
+
+	keys := make([]string, len(ids))
+	for idx, id := range ids {
+		keys[idx] = res.DetailKey(id)
+	}
+
+	cached, err := redis.Client().MGet(ctx, keys...).Result()
+	if err != nil {
+		blog.Errorf("verify %s cache, but get details from redis failed, err: %v, rid: %v", res.Name, err, rid)
+		return err
+	}
+
+	details, err := res.ListDetails(ctx, ids)
+	if err != nil {
+		blog.Errorf("verify %s cache, but list details from mongodb failed, err: %v, rid: %v", res.Name, err, rid)
+		return err
+	}
+
+	for idx, id := range ids {
+		if cached[idx] == nil {
+			// expired or deleted after it's scanned.
+			continue
+		}
+		report.Checked++
+
+		detail, exist := details[id]
+		if !exist {
+			report.Stale = append(report.Stale, id)
+			if repair {
+				v.repair(ctx, report, id, func() error { return res.Remove(ctx, id) })
+			}
+			continue
+		}
+
+		cachedDetail, ok := cached[idx].(string)
+		if ok && isSameDetail(cachedDetail, detail) {
+			continue
+		}
+
+		report.Mismatched = append(report.Mismatched, id)
+		if repair {
+			v.repair(ctx, report, id, func() error { return res.Repair(ctx, id, detail) })
+		}
+	}
+	return nil
+}
+
+func (v *Verifier) repair(ctx context.Context, report *Report, id int64, do func() error) {
+	if err := do(); err != nil {
+		blog.Errorf("repair %s %d cache failed, err: %v, rid: %v", report.Resource, id, err,
+			ctx.Value(common.ContextRequestIDField))
+		report.Errors = append(report.Errors, fmt.Sprintf("repair %s %d failed, err: %v", report.Resource, id, err))
+		return
+	}
+	report.Repaired++
+}
+
+// Rebuild refreshes all the resources' cache with the data in mongodb, and removes the stale ones.
+func (v *Verifier) Rebuild(ctx context.Context, name string) (*Report, error) {
+	res, err := v.getResource(name)
+	if err != nil {
+		return nil, err
+	}
+
+	rid := ctx.Value(common.ContextRequestIDField)
+	report := &Report{
+		Resource:   name,
+		StartTime:  time.Now(),
+		Mismatched: make([]int64, 0),
+		Stale:      make([]int64, 0),
+	}
+
+	for start := uint64(0); ; start += rebuildStep {
+		docs := make([]map[string]interface{}, 0)
+		err := mongodb.Client().Table(res.Collection).Find(nil).Fields(res.IDField).Sort(res.IDField).
+			Start(start).Limit(rebuildStep).All(ctx, &docs)
+		if err != nil {
+			blog.Errorf("rebuild %s cache, but list ids from mongodb failed, err: %v, rid: %v", name, err, rid)
+			return nil, err
+		}
+
+		if len(docs) == 0 {
+			break
+		}
+
+		ids := make([]int64, 0)
+		for _, doc := range docs {
+			id, err := util.GetInt64ByInterface(doc[res.IDField])
+			if err != nil {
+				blog.Errorf("rebuild %s cache, but got invalid id %v, rid: %v", name, doc[res.IDField], rid)
+				continue
+			}
+			ids = append(ids, id)
+		}
+
+		details, err := res.ListDetails(ctx, ids)
+		if err != nil {
+			blog.Errorf("rebuild %s cache, but list details from mongodb failed, err: %v, rid: %v", name, err, rid)
+			return nil, err
+		}
+
+		for id, detail := range details {
+			report.Checked++
+			v.repair(ctx, report, id, func() error { return res.Repair(ctx, id, detail) })
+		}
+
+		if len(docs) < rebuildStep {
+			break
+		}
+	}
+
+	// remove the resources which are still in cache but not exist in mongodb.
+	verifyReport, err := v.Verify(ctx, name, &Option{Full: true, Repair: true})
+	if err != nil {
+		return nil, err
+	}
+	report.Stale = verifyReport.Stale
+	report.Repaired += verifyReport.Repaired
+	report.Errors = append(report.Errors, verifyReport.Errors...)
+	report.EndTime = time.Now()
+	return report, nil
+}
+
+// isSameDetail checks if the two json details are same, the mongodb _id field is ignored.
+func isSameDetail(cached, detail string) bool {
+	if cached == detail {
+		return true
+	}
+
+	a, b := make(map[string]interface{}), make(map[string]interface{})
+	if err := json.Unmarshal([]byte(cached), &a); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(detail), &b); err != nil {
+		return false
+	}
+	delete(a, "_id")
+	delete(b, "_id")
+	return reflect.DeepEqual(a, b)
+}
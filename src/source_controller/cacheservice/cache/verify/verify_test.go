@@ -0,0 +1,185 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	ccRedis "configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/driver/redis"
+
+	"github.com/alicebob/miniredis"
+)
+
+func TestIsSameDetail(t *testing.T) {
+	tests := []struct {
+		cached string
+		detail string
+		same   bool
+	}{
+		{cached: `{"id":1,"name":"a"}`, detail: `{"id":1,"name":"a"}`, same: true},
+		// the order of the fields and the mongodb _id field are ignored.
+		{cached: `{"name":"a","id":1}`, detail: `{"id":1,"name":"a"}`, same: true},
+		{cached: `{"_id":"5f","id":1,"name":"a"}`, detail: `{"id":1,"name":"a"}`, same: true},
+		{cached: `{"id":1,"name":"a"}`, detail: `{"id":1,"name":"b"}`, same: false},
+		{cached: `{"id":1}`, detail: `{"id":1,"name":"a"}`, same: false},
+		{cached: `invalid`, detail: `{"id":1}`, same: false},
+	}
+
+	for _, test := range tests {
+		if same := isSameDetail(test.cached, test.detail); same != test.same {
+			t.Errorf("isSameDetail(%s, %s) = %v, want %v", test.cached, test.detail, same, test.same)
+		}
+	}
+}
+
+const testKeyPrefix = "cc:v3:test:detail:"
+
+// newTestResource returns a resource whose details in mongodb are the db map.
+func newTestResource(db map[int64]string) Resource {
+	return Resource{
+		Name:       "test",
+		KeyPattern: testKeyPrefix + "*",
+		ParseKey: func(key string) (int64, bool) {
+			id, err := strconv.ParseInt(strings.TrimPrefix(key, testKeyPrefix), 10, 64)
+			return id, err == nil
+		},
+		DetailKey: func(id int64) string {
+			return testKeyPrefix + strconv.FormatInt(id, 10)
+		},
+		ListDetails: func(ctx context.Context, ids []int64) (map[int64]string, error) {
+			details := make(map[int64]string)
+			for _, id := range ids {
+				if detail, exist := db[id]; exist {
+					details[id] = detail
+				}
+			}
+			return details, nil
+		},
+		Repair: func(ctx context.Context, id int64, detail string) error {
+			return redis.Client().Set(ctx, testKeyPrefix+strconv.FormatInt(id, 10), detail, 0).Err()
+		},
+		Remove: func(ctx context.Context, id int64) error {
+			return redis.Client().Del(ctx, testKeyPrefix+strconv.FormatInt(id, 10)).Err()
+		},
+	}
+}
+
+func sortIDs(ids []int64) []int64 {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestVerify(t *testing.T) {
+	redisMock, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run mock redis failed, err: %v", err)
+	}
+	defer redisMock.Close()
+	if err := redis.InitClient("redis", &ccRedis.Config{Address: redisMock.Addr(), Database: "0"}); err != nil {
+		t.Fatalf("init redis client failed, err: %v", err)
+	}
+
+	// 1-20 are cached and in mongodb, 3 and 7 are changed in mongodb, 21 and 22 are deleted from mongodb.
+	db := make(map[int64]string)
+	for id := int64(1); id <= 22; id++ {
+		detail := fmt.Sprintf(`{"id":%d,"name":"n%d"}`, id, id)
+		if err := redisMock.Set(testKeyPrefix+strconv.FormatInt(id, 10), detail); err != nil {
+			t.Fatalf("set cache failed, err: %v", err)
+		}
+		if id <= 20 {
+			db[id] = detail
+		}
+	}
+	db[3] = `{"id":3,"name":"changed"}`
+	db[7] = `{"id":7,"name":"n7","_id":"5f"}`
+	db[8] = `{"id":8,"name":"changed"}`
+	// the keys which are matched by the pattern but are not detail keys are skipped.
+	if err := redisMock.Set(testKeyPrefix+"lock", "1"); err != nil {
+		t.Fatalf("set cache failed, err: %v", err)
+	}
+
+	verifier := NewVerifier([]Resource{newTestResource(db)})
+	ctx := context.Background()
+
+	if _, err := verifier.Verify(ctx, "test", &Option{}); err == nil {
+		t.Errorf("expect error when the sample size is not set")
+	}
+	if _, err := verifier.Verify(ctx, "unknown", &Option{Full: true}); err == nil {
+		t.Errorf("expect error when the resource is not supported")
+	}
+
+	// the sampled verification stops after the sample size is reached, and continues from where it stops
+	// in the next verification, so all the resources are verified in turn.
+	checked := 0
+	for i := 0; i < 100 && checked < 22; i++ {
+		report, err := verifier.Verify(ctx, "test", &Option{SampleSize: 1})
+		if err != nil {
+			t.Fatalf("sampled verify failed, err: %v", err)
+		}
+		if report.Checked == 0 || report.Repaired != 0 {
+			t.Fatalf("unexpected sampled report: %+v", report)
+		}
+		checked += report.Checked
+	}
+	if checked < 22 {
+		t.Errorf("expect all the cached resources verified in turn, checked: %d", checked)
+	}
+
+	report, err := verifier.Verify(ctx, "test", &Option{Full: true})
+	if err != nil {
+		t.Fatalf("full verify failed, err: %v", err)
+	}
+	if report.Checked != 22 || report.Repaired != 0 {
+		t.Errorf("unexpected full report: %+v", report)
+	}
+	if mismatched := sortIDs(report.Mismatched); !reflect.DeepEqual(mismatched, []int64{3, 8}) {
+		t.Errorf("expect 3 and 8 mismatched, got %v", mismatched)
+	}
+	if stale := sortIDs(report.Stale); !reflect.DeepEqual(stale, []int64{21, 22}) {
+		t.Errorf("expect 21 and 22 stale, got %v", stale)
+	}
+
+	// the drifted cache is not changed without repair.
+	if detail, _ := redisMock.Get(testKeyPrefix + "3"); detail != `{"id":3,"name":"n3"}` {
+		t.Errorf("expect cache of 3 not repaired, got %s", detail)
+	}
+
+	report, err = verifier.Verify(ctx, "test", &Option{Full: true, Repair: true})
+	if err != nil {
+		t.Fatalf("full verify with repair failed, err: %v", err)
+	}
+	if report.Drift() != 4 || report.Repaired != 4 || len(report.Errors) != 0 {
+		t.Errorf("unexpected repaired report: %+v", report)
+	}
+	if detail, _ := redisMock.Get(testKeyPrefix + "3"); detail != db[3] {
+		t.Errorf("expect cache of 3 repaired, got %s", detail)
+	}
+	if redisMock.Exists(testKeyPrefix+"21") || redisMock.Exists(testKeyPrefix+"22") {
+		t.Errorf("expect the stale cache removed")
+	}
+
+	report, err = verifier.Verify(ctx, "test", &Option{Full: true})
+	if err != nil {
+		t.Fatalf("full verify after repair failed, err: %v", err)
+	}
+	if report.Checked != 20 || report.Drift() != 0 {
+		t.Errorf("expect no drift after repair, got %+v", report)
+	}
+}
@@ -21,7 +21,9 @@ import (
 	"configcenter/src/storage/stream"
 )
 
-func NewEvent(watch stream.Interface, isMaster discovery.ServiceManageInterface) error {
+// NewEvent runs the event flows of the watched resources, the host snapshot history
+// events are optional as they are only needed by the users who alert on host changes.
+func NewEvent(watch stream.Interface, isMaster discovery.ServiceManageInterface, watchHostSnapHistory bool) error {
 	e := Event{
 		watch:    watch,
 		isMaster: isMaster,
@@ -72,6 +74,13 @@ func NewEvent(watch stream.Interface, isMaster discovery.ServiceManageInterface)
 		return err
 	}
 
+	if watchHostSnapHistory {
+		if err := e.runHostSnapHistory(context.Background()); err != nil {
+			blog.Errorf("run host snapshot history event flow failed, err: %v", err)
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -178,3 +187,14 @@ func (e *Event) runProcessInstanceRelation(ctx context.Context) error {
 
 	return newFlow(ctx, opts)
 }
+
+func (e *Event) runHostSnapHistory(ctx context.Context) error {
+	opts := FlowOptions{
+		Collection: common.BKTableNameHostSnapHistory,
+		key:        HostSnapHistoryKey,
+		watch:      e.watch,
+		isMaster:   e.isMaster,
+	}
+
+	return newFlow(ctx, opts)
+}
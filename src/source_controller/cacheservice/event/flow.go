@@ -196,6 +196,11 @@ func (f *Flow) loopFlow(ctx context.Context, opts *types.WatchOptions) error {
 				}
 
 			case types.Delete:
+				if f.Collection == common.BKTableNameHostSnapHistory {
+					// the history is only deleted when it exceeds the cap, which is not an event for the watchers.
+					continue
+				}
+
 				if retry, err := f.doDelete(e); err != nil {
 					blog.Errorf("run flow, but do delete failed, doc: %s, err: %v, oid: %s", e.DocBytes, err, e.Oid)
 
@@ -373,10 +378,9 @@ func (f *Flow) initializeHeadTailNode(e *types.Event) (bool, error) {
 		f.key.TailKey(): string(tByte),
 	}
 
-	detail := types.EventDetail{
-		Detail:        types.JsonString(e.DocBytes),
-		UpdatedFields: e.ChangeDesc.UpdatedFields,
-		RemovedFields: e.ChangeDesc.RemovedFields,
+	detail, retry, err := f.newEventDetail(e)
+	if err != nil {
+		return retry, err
 	}
 	detailBytes, err := json.Marshal(detail)
 	if err != nil {
@@ -550,10 +554,9 @@ func (f *Flow) insertNewNode(prevCursor string, prevNode *watch.ChainNode, e *ty
 		f.key.TailKey(): string(tBytes),
 	}
 
-	detail := types.EventDetail{
-		Detail:        types.JsonString(e.DocBytes),
-		UpdatedFields: e.ChangeDesc.UpdatedFields,
-		RemovedFields: e.ChangeDesc.RemovedFields,
+	detail, retry, err := f.newEventDetail(e)
+	if err != nil {
+		return retry, err
 	}
 	detailBytes, err := json.Marshal(detail)
 	if err != nil {
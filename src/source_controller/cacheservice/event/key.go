@@ -184,6 +184,25 @@ var ProcessInstanceRelationKey = Key{
 	},
 }
 
+var hostSnapHistoryFields = []string{common.BKHostIDField, common.BKHostInnerIPField}
+var HostSnapHistoryKey = Key{
+	namespace:  watchCacheNamespace + "host_snapshot_history",
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, hostSnapHistoryFields...)
+		for idx := range hostSnapHistoryFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", hostSnapHistoryFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		fields := gjson.GetManyBytes(doc, hostSnapHistoryFields...)
+		return fields[1].String()
+	},
+}
+
 type Key struct {
 	namespace string
 	// the valid event's life time.
@@ -0,0 +1,149 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/sensitive"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/stream/types"
+
+	"github.com/tidwall/gjson"
+)
+
+// sensitiveFieldsTTL the time the sensitive attributes of a model are cached before they are reloaded.
+const sensitiveFieldsTTL = time.Minute
+
+// sensitiveFieldsCache caches the sensitive attributes of the models, the key is the owner id and the model id.
+var sensitiveFieldsCache = struct {
+	sync.Mutex
+	fields map[string]cachedSensitiveFields
+}{fields: make(map[string]cachedSensitiveFields)}
+
+type cachedSensitiveFields struct {
+	fields   []string
+	expireAt time.Time
+}
+
+// getSensitiveFields returns the property ids of the model's sensitive attributes.
+func getSensitiveFields(ownerID, objID string) ([]string, error) {
+	key := ownerID + ":" + objID
+	sensitiveFieldsCache.Lock()
+	cached, exists := sensitiveFieldsCache.fields[key]
+	sensitiveFieldsCache.Unlock()
+	if exists && time.Now().Before(cached.expireAt) {
+		return cached.fields, nil
+	}
+
+	fields, err := sensitive.GetFields(dal.WithTenant(context.Background(), ownerID), mongodb.Client(), ownerID, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	sensitiveFieldsCache.Lock()
+	sensitiveFieldsCache.fields[key] = cachedSensitiveFields{
+		fields:   fields[objID],
+		expireAt: time.Now().Add(sensitiveFieldsTTL),
+	}
+	sensitiveFieldsCache.Unlock()
+	return fields[objID], nil
+}
+
+// getCollectionObjID returns the model id of the instance document in the collection, returns empty if the
+// collection does not save the model instances.
+func getCollectionObjID(collection string, doc []byte) string {
+	switch collection {
+	case common.BKTableNameBaseHost:
+		return common.BKInnerObjIDHost
+	case common.BKTableNameBaseApp:
+		return common.BKInnerObjIDApp
+	case common.BKTableNameBaseSet:
+		return common.BKInnerObjIDSet
+	case common.BKTableNameBaseModule:
+		return common.BKInnerObjIDModule
+	case common.BKTableNameBaseProcess:
+		return common.BKInnerObjIDProc
+	case common.BKTableNameBaseInst:
+		return gjson.GetBytes(doc, common.BKObjIDField).String()
+	default:
+		return ""
+	}
+}
+
+// newEventDetail generates the event detail, the values of the sensitive attributes in the event are masked,
+// so that they are not exposed to the watchers.
+func (f *Flow) newEventDetail(e *types.Event) (detail types.EventDetail, retry bool, err error) {
+	detail = types.EventDetail{
+		Detail:        types.JsonString(e.DocBytes),
+		UpdatedFields: e.ChangeDesc.UpdatedFields,
+		RemovedFields: e.ChangeDesc.RemovedFields,
+	}
+
+	objID := getCollectionObjID(f.Collection, e.DocBytes)
+	if objID == "" {
+		return detail, false, nil
+	}
+
+	ownerID := gjson.GetBytes(e.DocBytes, common.BKOwnerIDField).String()
+	fields, err := getSensitiveFields(ownerID, objID)
+	if err != nil {
+		blog.Errorf("run flow, get %s sensitive attributes failed, err: %v, oid: %s", objID, err, e.Oid)
+		return detail, true, err
+	}
+
+	if len(fields) == 0 {
+		return detail, false, nil
+	}
+
+	doc := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(e.DocBytes, &doc); err != nil {
+		blog.Errorf("run flow, unmarshal %s doc failed, err: %v, oid: %s", objID, err, e.Oid)
+		return detail, false, err
+	}
+
+	masked := false
+	for _, field := range fields {
+		val, exists := doc[field]
+		if !exists || string(val) == "null" || string(val) == `""` {
+			continue
+		}
+		doc[field] = json.RawMessage(`"` + sensitive.Mask + `"`)
+		masked = true
+	}
+
+	if masked {
+		docBytes, err := json.Marshal(doc)
+		if err != nil {
+			blog.Errorf("run flow, marshal %s masked doc failed, err: %v, oid: %s", objID, err, e.Oid)
+			return detail, false, err
+		}
+		detail.Detail = types.JsonString(docBytes)
+	}
+
+	if len(detail.UpdatedFields) != 0 {
+		updatedFields := make(map[string]interface{}, len(detail.UpdatedFields))
+		for key, val := range detail.UpdatedFields {
+			updatedFields[key] = val
+		}
+		sensitive.Redact(updatedFields, fields)
+		detail.UpdatedFields = updatedFields
+	}
+	return detail, false, nil
+}
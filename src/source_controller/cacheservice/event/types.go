@@ -43,6 +43,8 @@ func GetResourceKeyWithCursorType(res watch.CursorType) (Key, error) {
 		key = ProcessKey
 	case watch.ProcessInstanceRelation:
 		key = ProcessInstanceRelationKey
+	case watch.HostSnapHistory:
+		key = HostSnapHistoryKey
 	default:
 		return key, fmt.Errorf("unsupported cursor type %s", res)
 	}
@@ -234,3 +234,99 @@ func (s *cacheService) SearchTopologyNodePath(ctx *rest.Contexts) {
 
 	ctx.RespEntity(paths)
 }
+
+// ListModelsInCache list models from cache, all the models will be returned if the object ids is empty.
+func (s *cacheService) ListModelsInCache(ctx *rest.Contexts) {
+	opt := new(metadata.ListModelCacheOption)
+	if err := ctx.DecodeInto(&opt); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	details, err := s.cacheSet.Model.ListModels(ctx.Kit.Ctx, opt)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "list models in cache failed, err: %v", err)
+		return
+	}
+	ctx.RespStringArray(details)
+}
+
+// ListModelAttributesInCache list all the attributes of a model from cache.
+func (s *cacheService) ListModelAttributesInCache(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+
+	details, err := s.cacheSet.Model.ListAttributes(ctx.Kit.Ctx, objID)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "list model %s attributes in cache failed, err: %v",
+			objID, err)
+		return
+	}
+	ctx.RespStringArray(details)
+}
+
+// ListModelUniquesInCache list all the unique rules of a model from cache.
+func (s *cacheService) ListModelUniquesInCache(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+
+	details, err := s.cacheSet.Model.ListUniques(ctx.Kit.Ctx, objID)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "list model %s uniques in cache failed, err: %v",
+			objID, err)
+		return
+	}
+	ctx.RespStringArray(details)
+}
+
+// ListModelAssociationsInCache list all the associations of a model from cache, whether the model is the
+// source or the destination model of the association.
+func (s *cacheService) ListModelAssociationsInCache(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+
+	details, err := s.cacheSet.Model.ListAssociations(ctx.Kit.Ctx, objID)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "list model %s associations in cache failed, err: %v",
+			objID, err)
+		return
+	}
+	ctx.RespStringArray(details)
+}
+
+// SearchInstanceInCache search a custom object's instance from cache, if not exist in cache, then get from
+// mongodb directly.
+func (s *cacheService) SearchInstanceInCache(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+
+	instID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKInstIDField), 10, 64)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommParamsIsInvalid, "invalid instance id")
+		return
+	}
+
+	inst, err := s.cacheSet.Instance.GetInstance(ctx.Kit.Ctx, objID, instID, nil)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search %s instance with id in cache failed, err: %v",
+			objID, err)
+		return
+	}
+	ctx.RespString(inst)
+}
+
+// ListInstancesInCache list a custom object's instances with id from cache, if not exist in cache, then get
+// from mongodb directly.
+func (s *cacheService) ListInstancesInCache(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+
+	opt := new(metadata.ListWithIDOption)
+	if err := ctx.DecodeInto(&opt); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	details, err := s.cacheSet.Instance.ListInstances(ctx.Kit.Ctx, objID, opt)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "list %s instances with id in cache failed, err: %v",
+			objID, err)
+		return
+	}
+	ctx.RespStringArray(details)
+}
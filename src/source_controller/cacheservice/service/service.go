@@ -75,20 +75,24 @@ func (s *cacheService) SetConfig(cfg options.Config, engine *backbone.Engine, er
 		return eventErr
 	}
 
-	c, cacheErr := cacheop.NewCache(event)
+	c, cacheErr := cacheop.NewCache(event, s.cfg.InstanceObjects)
 	if cacheErr != nil {
 		blog.Errorf("new cache instance failed, err: %v", cacheErr)
 		return cacheErr
 	}
 	s.cacheSet = c
 
+	if s.cfg.VerifyInterval > 0 {
+		c.Verifier.Run(s.cfg.VerifyInterval, s.cfg.VerifySampleSize)
+	}
+
 	watcher, watchErr := stream.NewStream(s.cfg.Mongo.GetMongoConf())
 	if watchErr != nil {
 		blog.Errorf("new watch stream failed, err: %v", watchErr)
 		return watchErr
 	}
 
-	if err := watchEvent.NewEvent(watcher, engine.ServiceManageInterface); err != nil {
+	if err := watchEvent.NewEvent(watcher, engine.ServiceManageInterface, s.cfg.WatchHostSnapHistory); err != nil {
 		blog.Errorf("new watch event failed, err: %v", err)
 		return err
 	}
@@ -90,6 +90,36 @@ func (s *cacheService) initCache(web *restful.WebService) {
 		Path:    "/find/cache/topo/node_path",
 		Handler: s.SearchTopologyNodePath,
 	})
+	utility.AddHandler(rest.Action{
+		Verb:    http.MethodPost,
+		Path:    "/findmany/cache/model",
+		Handler: s.ListModelsInCache,
+	})
+	utility.AddHandler(rest.Action{
+		Verb:    http.MethodPost,
+		Path:    "/findmany/cache/model/{bk_obj_id}/attribute",
+		Handler: s.ListModelAttributesInCache,
+	})
+	utility.AddHandler(rest.Action{
+		Verb:    http.MethodPost,
+		Path:    "/findmany/cache/model/{bk_obj_id}/unique",
+		Handler: s.ListModelUniquesInCache,
+	})
+	utility.AddHandler(rest.Action{
+		Verb:    http.MethodPost,
+		Path:    "/findmany/cache/model/{bk_obj_id}/association",
+		Handler: s.ListModelAssociationsInCache,
+	})
+	utility.AddHandler(rest.Action{
+		Verb:    http.MethodPost,
+		Path:    "/find/cache/instance/{bk_obj_id}/{bk_inst_id}",
+		Handler: s.SearchInstanceInCache,
+	})
+	utility.AddHandler(rest.Action{
+		Verb:    http.MethodPost,
+		Path:    "/findmany/cache/instance/{bk_obj_id}",
+		Handler: s.ListInstancesInCache,
+	})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -0,0 +1,501 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package computed
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/expression"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/stream/types"
+)
+
+const pageSize = 500
+
+// mainlineIDFields the host's topology fields in the module host relation, key is the model id.
+var mainlineIDFields = map[string]string{
+	common.BKInnerObjIDApp:    common.BKAppIDField,
+	common.BKInnerObjIDSet:    common.BKSetIDField,
+	common.BKInnerObjIDModule: common.BKModuleIDField,
+}
+
+// tableObjects the inner models which have their own instance table.
+var tableObjects = map[string]string{
+	common.BKTableNameBaseApp:     common.BKInnerObjIDApp,
+	common.BKTableNameBaseSet:     common.BKInnerObjIDSet,
+	common.BKTableNameBaseModule:  common.BKInnerObjIDModule,
+	common.BKTableNameBaseHost:    common.BKInnerObjIDHost,
+	common.BKTableNameBaseProcess: common.BKInnerObjIDProc,
+}
+
+// handleInstance recompute the instance's computed attributes, and the computed attributes of the
+// associated instances which aggregate this instance.
+func (c *Computer) handleInstance(ctx context.Context, collection string, e *types.Event) {
+	if e.OperationType == types.Delete {
+		// the aggregations of the deleted instance is recomputed by the association and
+		// module host relation delete events.
+		return
+	}
+
+	doc := eventDoc(e)
+	objID, exist := tableObjects[collection]
+	if !exist {
+		objID = util.GetStrByInterface(doc[common.BKObjIDField])
+	}
+	ownerID := util.GetStrByInterface(doc[common.BKOwnerIDField])
+
+	if err := c.computeInstance(ctx, objID, doc); err != nil {
+		blog.Errorf("compute %s instance failed, err: %v, oid: %s", objID, err, e.Oid)
+	}
+
+	instID, err := util.GetInt64ByInterface(doc[common.GetInstIDField(objID)])
+	if err != nil {
+		blog.Errorf("received %s instance event, but get instance id failed, err: %v, oid: %s", objID, err, e.Oid)
+		return
+	}
+
+	for _, r := range c.rules.dependentRules(objID, ownerID) {
+		if e.OperationType == types.Update && !isFieldChanged(r, e.ChangeDesc) {
+			continue
+		}
+
+		relatedIDs, err := relatedInstIDs(ctx, objID, instID, r.attr.ObjectID)
+		if err != nil {
+			blog.Errorf("get %s instances related to %s instance %d failed, err: %v, oid: %s", r.attr.ObjectID, objID,
+				instID, err, e.Oid)
+			continue
+		}
+
+		if err := c.refresh(ctx, r.attr.ObjectID, ownerID, relatedIDs); err != nil {
+			blog.Errorf("recompute %s instances %v failed, err: %v, oid: %s", r.attr.ObjectID, relatedIDs, err, e.Oid)
+		}
+	}
+}
+
+// handleInstAsst recompute the aggregations of the instances on both sides of the association.
+func (c *Computer) handleInstAsst(ctx context.Context, collection string, e *types.Event) {
+	doc, err := eventOrArchivedDoc(ctx, e)
+	if err != nil {
+		if mongodb.Client().IsNotFoundError(err) {
+			// the associations are archived only when they are deleted in the scope of an owner and an object,
+			// the bulk delete of the dirty associations is skipped.
+			blog.V(4).Infof("received %s delete event, but the doc is not archived, skip, oid: %s", collection, e.Oid)
+			return
+		}
+		blog.Errorf("received %s event, but get the doc failed, err: %v, oid: %s", collection, err, e.Oid)
+		return
+	}
+
+	asst := metadata.InstAsst{}
+	if err := doc.MarshalJSONInto(&asst); err != nil {
+		blog.Errorf("received %s event, but parse doc failed, err: %v, oid: %s", collection, err, e.Oid)
+		return
+	}
+
+	c.refreshRelated(ctx, asst.OwnerID, asst.ObjectID, asst.InstID, asst.AsstObjectID, asst.AsstInstID)
+}
+
+// handleModuleHost recompute the aggregations of the host and it's topology instances.
+func (c *Computer) handleModuleHost(ctx context.Context, collection string, e *types.Event) {
+	doc, err := eventOrArchivedDoc(ctx, e)
+	if err != nil {
+		blog.Errorf("received %s event, but get the doc failed, err: %v, oid: %s", collection, err, e.Oid)
+		return
+	}
+
+	ownerID := util.GetStrByInterface(doc[common.BKOwnerIDField])
+	hostID, err := util.GetInt64ByInterface(doc[common.BKHostIDField])
+	if err != nil {
+		blog.Errorf("received %s event, but get host id failed, err: %v, oid: %s", collection, err, e.Oid)
+		return
+	}
+
+	for objID, field := range mainlineIDFields {
+		instID, err := util.GetInt64ByInterface(doc[field])
+		if err != nil {
+			blog.Errorf("received %s event, but get %s failed, err: %v, oid: %s", collection, field, err, e.Oid)
+			continue
+		}
+		c.refreshRelated(ctx, ownerID, common.BKInnerObjIDHost, hostID, objID, instID)
+	}
+}
+
+// refreshRelated recompute the aggregations between two related instances.
+func (c *Computer) refreshRelated(ctx context.Context, ownerID, objID string, instID int64, asstObjID string,
+	asstInstID int64) {
+
+	if hasAggregation(c.rules.objectRules(objID, ownerID), asstObjID) {
+		if err := c.refresh(ctx, objID, ownerID, []int64{instID}); err != nil {
+			blog.Errorf("recompute %s instance %d failed, err: %v", objID, instID, err)
+		}
+	}
+
+	if hasAggregation(c.rules.objectRules(asstObjID, ownerID), objID) {
+		if err := c.refresh(ctx, asstObjID, ownerID, []int64{asstInstID}); err != nil {
+			blog.Errorf("recompute %s instance %d failed, err: %v", asstObjID, asstInstID, err)
+		}
+	}
+}
+
+// refresh recompute the computed attributes of the instances.
+func (c *Computer) refresh(ctx context.Context, objID, ownerID string, instIDs []int64) error {
+	if len(instIDs) == 0 {
+		return nil
+	}
+
+	cond := instanceCondition(objID, ownerID)
+	cond[common.GetInstIDField(objID)] = map[string]interface{}{common.BKDBIN: instIDs}
+
+	docs := make([]mapstr.MapStr, 0)
+	if err := mongodb.Client().Table(common.GetInstTableName(objID)).Find(cond).All(ctx, &docs); err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		if err := c.computeInstance(ctx, objID, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resyncObject recompute the computed attributes of all the model's instances.
+func (c *Computer) resyncObject(ctx context.Context, objID, ownerID string) error {
+	if len(c.rules.objectRules(objID, ownerID)) == 0 {
+		return nil
+	}
+
+	cond := instanceCondition(objID, ownerID)
+	idField := common.GetInstIDField(objID)
+	for start := uint64(0); ; start += pageSize {
+		docs := make([]mapstr.MapStr, 0)
+		err := mongodb.Client().Table(common.GetInstTableName(objID)).Find(cond).Sort(idField).Start(start).
+			Limit(pageSize).All(ctx, &docs)
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			if err := c.computeInstance(ctx, objID, doc); err != nil {
+				return err
+			}
+		}
+
+		if len(docs) < pageSize {
+			return nil
+		}
+	}
+}
+
+// computeInstance compute the instance's computed attributes, and save the changed values.
+func (c *Computer) computeInstance(ctx context.Context, objID string, doc mapstr.MapStr) error {
+	ownerID := util.GetStrByInterface(doc[common.BKOwnerIDField])
+	rules := c.rules.objectRules(objID, ownerID)
+	if len(rules) == 0 {
+		return nil
+	}
+
+	idField := common.GetInstIDField(objID)
+	instID, err := util.GetInt64ByInterface(doc[idField])
+	if err != nil {
+		return fmt.Errorf("get instance id failed, err: %v", err)
+	}
+
+	changed := make(mapstr.MapStr)
+	for _, r := range rules {
+		value, err := computeValue(ctx, r, instID, doc)
+		if err != nil {
+			blog.Errorf("compute attribute %s of %s instance %d failed, err: %v", r.attr.PropertyID, objID, instID, err)
+			continue
+		}
+
+		if !isSameValue(doc[r.attr.PropertyID], value) {
+			changed[r.attr.PropertyID] = value
+		}
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	cond := instanceCondition(objID, ownerID)
+	cond[idField] = instID
+	if err := mongodb.Client().Table(common.GetInstTableName(objID)).Update(ctx, cond, changed); err != nil {
+		return err
+	}
+
+	blog.V(4).Infof("update computed attributes of %s instance %d, data: %v", objID, instID, changed)
+	return nil
+}
+
+// computeValue compute the value of the instance's computed attribute.
+func computeValue(ctx context.Context, r *rule, instID int64, doc mapstr.MapStr) (interface{}, error) {
+	switch r.option.Type {
+	case metadata.ComputeTypeExpression:
+		result, err := r.expr.Eval(doc)
+		if err == expression.ErrNoValue {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return convertValue(r.option.ValueType, result), nil
+
+	case metadata.ComputeTypeAggregation:
+		return aggregate(ctx, r, instID)
+
+	default:
+		return nil, fmt.Errorf("unsupported compute type %s", r.option.Type)
+	}
+}
+
+// aggregate the field of the instances associated to the instance.
+func aggregate(ctx context.Context, r *rule, instID int64) (interface{}, error) {
+	relatedIDs, err := relatedInstIDs(ctx, r.attr.ObjectID, instID, r.option.AsstObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.option.Function == metadata.AggregateCount {
+		return convertValue(r.option.ValueType, float64(len(relatedIDs))), nil
+	}
+
+	if len(relatedIDs) == 0 {
+		if r.option.Function == metadata.AggregateSum {
+			return convertValue(r.option.ValueType, 0), nil
+		}
+		return nil, nil
+	}
+
+	asstIDField := common.GetInstIDField(r.option.AsstObjID)
+	cond := instanceCondition(r.option.AsstObjID, r.attr.OwnerID)
+	cond[asstIDField] = map[string]interface{}{common.BKDBIN: relatedIDs}
+	docs := make([]mapstr.MapStr, 0)
+	err = mongodb.Client().Table(common.GetInstTableName(r.option.AsstObjID)).Find(cond).
+		Fields(asstIDField, r.option.Field).Sort(asstIDField).All(ctx, &docs)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.option.Function == metadata.AggregateFirst {
+		for _, doc := range docs {
+			if val := doc[r.option.Field]; val != nil {
+				return convertFirstValue(r.option.ValueType, val), nil
+			}
+		}
+		return nil, nil
+	}
+
+	values := make([]float64, 0)
+	for _, doc := range docs {
+		val := doc[r.option.Field]
+		if val == nil {
+			continue
+		}
+		f, err := expression.ToFloat(val)
+		if err != nil {
+			blog.V(4).Infof("aggregate field %s of %s, skip the invalid value %v", r.option.Field, r.option.AsstObjID, val)
+			continue
+		}
+		values = append(values, f)
+	}
+
+	if len(values) == 0 {
+		if r.option.Function == metadata.AggregateSum {
+			return convertValue(r.option.ValueType, 0), nil
+		}
+		return nil, nil
+	}
+
+	sort.Float64s(values)
+	var result float64
+	switch r.option.Function {
+	case metadata.AggregateSum, metadata.AggregateAvg:
+		for _, v := range values {
+			result += v
+		}
+		if r.option.Function == metadata.AggregateAvg {
+			result = result / float64(len(values))
+		}
+	case metadata.AggregateMin:
+		result = values[0]
+	case metadata.AggregateMax:
+		result = values[len(values)-1]
+	default:
+		return nil, fmt.Errorf("unsupported aggregate function %s", r.option.Function)
+	}
+
+	return convertValue(r.option.ValueType, result), nil
+}
+
+// relatedInstIDs returns the ids of the target model's instances which are related to the instance.
+// host and it's topology instances are related by the module host relations, the others are related by
+// the instance associations.
+func relatedInstIDs(ctx context.Context, objID string, instID int64, targetObjID string) ([]int64, error) {
+	if objID == common.BKInnerObjIDHost && mainlineIDFields[targetObjID] != "" {
+		cond := map[string]interface{}{common.BKHostIDField: instID}
+		return distinctIDs(ctx, common.BKTableNameModuleHostConfig, mainlineIDFields[targetObjID], cond)
+	}
+
+	if targetObjID == common.BKInnerObjIDHost && mainlineIDFields[objID] != "" {
+		cond := map[string]interface{}{mainlineIDFields[objID]: instID}
+		return distinctIDs(ctx, common.BKTableNameModuleHostConfig, common.BKHostIDField, cond)
+	}
+
+	cond := map[string]interface{}{
+		common.BKObjIDField:     objID,
+		common.BKInstIDField:    instID,
+		common.BKAsstObjIDField: targetObjID,
+	}
+	ids, err := distinctIDs(ctx, common.BKTableNameInstAsst, common.BKAsstInstIDField, cond)
+	if err != nil {
+		return nil, err
+	}
+
+	reverseCond := map[string]interface{}{
+		common.BKAsstObjIDField:  objID,
+		common.BKAsstInstIDField: instID,
+		common.BKObjIDField:      targetObjID,
+	}
+	reverseIDs, err := distinctIDs(ctx, common.BKTableNameInstAsst, common.BKInstIDField, reverseCond)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.IntArrayUnique(append(ids, reverseIDs...)), nil
+}
+
+func distinctIDs(ctx context.Context, table, field string, cond map[string]interface{}) ([]int64, error) {
+	values, err := mongodb.Client().Table(table).Distinct(ctx, field, cond)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0)
+	for _, value := range values {
+		id, err := util.GetInt64ByInterface(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %v in %s, err: %v", field, value, table, err)
+		}
+		if id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// instanceCondition returns the basic condition to find the model's instances.
+func instanceCondition(objID, ownerID string) map[string]interface{} {
+	cond := map[string]interface{}{
+		common.BKOwnerIDField: ownerID,
+	}
+	if common.GetInstTableName(objID) == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = objID
+	}
+	return cond
+}
+
+func hasAggregation(rules []*rule, asstObjID string) bool {
+	for _, r := range rules {
+		if r.option.Type == metadata.ComputeTypeAggregation && r.option.AsstObjID == asstObjID {
+			return true
+		}
+	}
+	return false
+}
+
+// isFieldChanged check whether the field aggregated by the rule is changed in the update event.
+func isFieldChanged(r *rule, desc *types.ChangeDescription) bool {
+	if r.option.Function == metadata.AggregateCount {
+		return false
+	}
+
+	if desc == nil {
+		return true
+	}
+
+	if _, exist := desc.UpdatedFields[r.option.Field]; exist {
+		return true
+	}
+	return util.InStrArr(desc.RemovedFields, r.option.Field)
+}
+
+func convertValue(valueType string, value float64) interface{} {
+	if valueType == common.FieldTypeInt {
+		return int64(value)
+	}
+	return value
+}
+
+func convertFirstValue(valueType string, value interface{}) interface{} {
+	if valueType == common.FieldTypeSingleChar {
+		if s, ok := value.(string); ok {
+			return s
+		}
+		return fmt.Sprintf("%v", value)
+	}
+
+	f, err := expression.ToFloat(value)
+	if err != nil {
+		return nil
+	}
+	return convertValue(valueType, f)
+}
+
+// isSameValue check whether the stored value equals to the computed value, numbers are compared by value.
+func isSameValue(stored, computed interface{}) bool {
+	if stored == nil || computed == nil {
+		return stored == nil && computed == nil
+	}
+
+	_, isStr := stored.(string)
+	if !isStr {
+		if s, err := expression.ToFloat(stored); err == nil {
+			if c, err := expression.ToFloat(computed); err == nil {
+				return s == c
+			}
+		}
+	}
+	return reflect.DeepEqual(stored, computed)
+}
+
+func eventDoc(e *types.Event) mapstr.MapStr {
+	if doc, ok := e.Document.(*map[string]interface{}); ok && doc != nil {
+		return *doc
+	}
+	return mapstr.MapStr{}
+}
+
+// eventOrArchivedDoc returns the event's doc, the deleted doc is got from the delete archive.
+func eventOrArchivedDoc(ctx context.Context, e *types.Event) (mapstr.MapStr, error) {
+	if e.OperationType != types.Delete {
+		return eventDoc(e), nil
+	}
+
+	archive := struct {
+		Detail mapstr.MapStr `bson:"detail"`
+	}{}
+	filter := map[string]interface{}{"oid": e.Oid}
+	if err := mongodb.Client().Table(common.BKTableNameDelArchive).Find(filter).One(ctx, &archive); err != nil {
+		return nil, err
+	}
+	return archive.Detail, nil
+}
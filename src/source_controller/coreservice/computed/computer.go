@@ -0,0 +1,291 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package computed keeps the values of the computed attributes up to date.
+// it watches the changes of the instances, the instance associations and the host's topology
+// with the storage/stream, recomputes the related computed attributes and saves the values
+// into the instances, so that the computed attributes can be queried like normal fields.
+package computed
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/expression"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/stream"
+	"configcenter/src/storage/stream/types"
+)
+
+// resyncInterval is the interval to recompute all the computed attributes,
+// which repairs the values missed by the watch, such as the events happened when the service is down.
+const resyncInterval = time.Hour
+
+// instCollections the instance collections which changes may affect the computed attributes.
+var instCollections = []string{
+	common.BKTableNameBaseInst,
+	common.BKTableNameBaseApp,
+	common.BKTableNameBaseSet,
+	common.BKTableNameBaseModule,
+	common.BKTableNameBaseHost,
+	common.BKTableNameBaseProcess,
+}
+
+// NewComputer start to watch the changes and keep the computed attributes up to date.
+// only the master do the compute work.
+func NewComputer(watch stream.Interface, isMaster discovery.ServiceManageInterface) error {
+	c := &Computer{
+		watch:    watch,
+		isMaster: isMaster,
+		rules:    newRuleSet(),
+	}
+
+	// the computed attributes of all the tenants are kept up to date, so the db operations are not scoped by tenant
+	ctx := dal.WithoutTenant(context.Background())
+	if err := c.loadRules(ctx); err != nil {
+		blog.Errorf("load computed attribute rules failed, err: %v", err)
+		return err
+	}
+
+	if err := c.runWatch(ctx, common.BKTableNameObjAttDes, c.handleAttribute); err != nil {
+		return err
+	}
+
+	for _, collection := range instCollections {
+		if err := c.runWatch(ctx, collection, c.handleInstance); err != nil {
+			return err
+		}
+	}
+
+	if err := c.runWatch(ctx, common.BKTableNameInstAsst, c.handleInstAsst); err != nil {
+		return err
+	}
+
+	if err := c.runWatch(ctx, common.BKTableNameModuleHostConfig, c.handleModuleHost); err != nil {
+		return err
+	}
+
+	go c.loopResync(ctx)
+	return nil
+}
+
+// Computer recomputes the computed attributes when the data they depend on changed.
+type Computer struct {
+	watch    stream.Interface
+	isMaster discovery.ServiceManageInterface
+	rules    *ruleSet
+}
+
+type eventHandler func(ctx context.Context, collection string, e *types.Event)
+
+func (c *Computer) runWatch(ctx context.Context, collection string, handler eventHandler) error {
+	event := make(map[string]interface{})
+	opts := &types.WatchOptions{
+		Options: types.Options{
+			EventStruct: &event,
+			Collection:  collection,
+		},
+	}
+
+	watcher, err := c.watch.Watch(ctx, opts)
+	if err != nil {
+		blog.Errorf("watch collection %s for computed attributes failed, err: %v", collection, err)
+		return err
+	}
+
+	go func() {
+		for e := range watcher.EventChan {
+			if !c.isMaster.IsMaster() {
+				blog.V(4).Infof("received collection %s event, type: %s, oid: %s, but not master, skip.", collection,
+					e.OperationType, e.Oid)
+				continue
+			}
+
+			switch e.OperationType {
+			case types.Insert, types.Update, types.Replace, types.Delete:
+				handler(ctx, collection, e)
+			default:
+				blog.V(4).Infof("received collection %s event, skip unsupported operation type %s, oid: %s", collection,
+					e.OperationType, e.Oid)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// handleAttribute reload the rules when the attributes changed, and recompute the values of the model's
+// instances when a computed attribute is created or it's rule is changed.
+func (c *Computer) handleAttribute(ctx context.Context, _ string, e *types.Event) {
+	if err := c.loadRules(ctx); err != nil {
+		blog.Errorf("attribute changed, but reload computed attribute rules failed, err: %v, oid: %s", err, e.Oid)
+		return
+	}
+
+	if e.OperationType == types.Delete {
+		return
+	}
+
+	doc := eventDoc(e)
+	if util.GetStrByInterface(doc[common.BKPropertyTypeField]) != common.FieldTypeComputed {
+		return
+	}
+
+	objID := util.GetStrByInterface(doc[common.BKObjIDField])
+	ownerID := util.GetStrByInterface(doc[common.BKOwnerIDField])
+	blog.Infof("computed attribute %s of model %s changed, recompute all the instances", doc[common.BKPropertyIDField], objID)
+	if err := c.resyncObject(ctx, objID, ownerID); err != nil {
+		blog.Errorf("recompute model %s instances failed, err: %v, oid: %s", objID, err, e.Oid)
+	}
+}
+
+func (c *Computer) loadRules(ctx context.Context) error {
+	attrs := make([]metadata.Attribute, 0)
+	cond := map[string]interface{}{
+		common.BKPropertyTypeField: common.FieldTypeComputed,
+	}
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &attrs); err != nil {
+		blog.Errorf("get computed attributes failed, err: %v", err)
+		return err
+	}
+
+	rules := newRuleSet()
+	for _, attr := range attrs {
+		option, err := metadata.ParseComputedOption(ctx, attr.Option)
+		if err != nil {
+			blog.Errorf("computed attribute %s of model %s has invalid option, skip it, err: %v", attr.PropertyID,
+				attr.ObjectID, err)
+			continue
+		}
+
+		r := &rule{attr: attr, option: option}
+		if option.Type == metadata.ComputeTypeExpression {
+			// the option is validated, so the expression can always be parsed.
+			r.expr, _ = expression.Parse(option.Expression)
+		}
+		rules.add(r)
+	}
+
+	c.rules.replace(rules)
+	return nil
+}
+
+// loopResync recompute all the computed attributes periodically.
+func (c *Computer) loopResync(ctx context.Context) {
+	ticker := time.NewTicker(resyncInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !c.isMaster.IsMaster() {
+			continue
+		}
+
+		if err := c.loadRules(ctx); err != nil {
+			blog.Errorf("resync computed attributes, but load rules failed, err: %v", err)
+			continue
+		}
+
+		for _, r := range c.rules.all() {
+			if err := c.resyncObject(ctx, r.attr.ObjectID, r.attr.OwnerID); err != nil {
+				blog.Errorf("resync computed attributes of model %s failed, err: %v", r.attr.ObjectID, err)
+			}
+		}
+	}
+}
+
+// rule is a computed attribute with it's parsed option.
+type rule struct {
+	attr   metadata.Attribute
+	option metadata.ComputedOption
+	expr   *expression.Expression
+}
+
+func (r *rule) match(ownerID string) bool {
+	return r.attr.OwnerID == ownerID
+}
+
+type ruleSet struct {
+	lock sync.RWMutex
+	// byObject the rules of the model, key is the model id.
+	byObject map[string][]*rule
+	// byAsstObject the aggregation rules which aggregate the model's instances, key is the associated model id.
+	byAsstObject map[string][]*rule
+}
+
+func newRuleSet() *ruleSet {
+	return &ruleSet{
+		byObject:     make(map[string][]*rule),
+		byAsstObject: make(map[string][]*rule),
+	}
+}
+
+func (s *ruleSet) add(r *rule) {
+	s.byObject[r.attr.ObjectID] = append(s.byObject[r.attr.ObjectID], r)
+	if r.option.Type == metadata.ComputeTypeAggregation {
+		s.byAsstObject[r.option.AsstObjID] = append(s.byAsstObject[r.option.AsstObjID], r)
+	}
+}
+
+func (s *ruleSet) replace(n *ruleSet) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.byObject = n.byObject
+	s.byAsstObject = n.byAsstObject
+}
+
+// objectRules returns the rules of the model.
+func (s *ruleSet) objectRules(objID, ownerID string) []*rule {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return filterRules(s.byObject[objID], ownerID)
+}
+
+// dependentRules returns the aggregation rules which aggregate the model's instances.
+func (s *ruleSet) dependentRules(objID, ownerID string) []*rule {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return filterRules(s.byAsstObject[objID], ownerID)
+}
+
+// all returns one rule of each model and owner.
+func (s *ruleSet) all() []*rule {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	rules := make([]*rule, 0)
+	for _, objRules := range s.byObject {
+		owners := make(map[string]bool)
+		for _, r := range objRules {
+			if !owners[r.attr.OwnerID] {
+				owners[r.attr.OwnerID] = true
+				rules = append(rules, r)
+			}
+		}
+	}
+	return rules
+}
+
+func filterRules(rules []*rule, ownerID string) []*rule {
+	matched := make([]*rule, 0)
+	for _, r := range rules {
+		if r.match(ownerID) {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
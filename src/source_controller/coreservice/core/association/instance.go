@@ -21,6 +21,7 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/universalsql/mongo"
 	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
 	"configcenter/src/storage/driver/mongodb"
 )
 
@@ -261,10 +262,44 @@ func (m *associationInstance) DeleteInstanceAssociation(kit *rest.Kit, inputPara
 		return &metadata.DeletedCount{}, err
 	}
 
-	err = mongodb.Client().Table(common.BKTableNameInstAsst).Delete(kit.Ctx, inputParam.Condition)
+	err = DeleteInstAsstPerObject(kit, mongodb.Client(), inputParam.Condition)
 	if nil != err {
 		blog.Errorf("delete inst association [%#v] err [%#v], rid: %s", inputParam.Condition, err, kit.Rid)
 		return &metadata.DeletedCount{}, err
 	}
 	return &metadata.DeletedCount{Count: cnt}, nil
 }
+
+// DeleteInstAsstPerObject deletes the instance associations matching the condition of each owner and object
+// separately, so that the deleted associations are archived in the scope of the owner and the object like the
+// instances, the computed attributes recompute the associated instances with the archived associations.
+func DeleteInstAsstPerObject(kit *rest.Kit, db dal.RDB, cond map[string]interface{}) error {
+	owners := []interface{}{cond[common.BKOwnerIDField]}
+	if _, ok := cond[common.BKOwnerIDField].(string); !ok {
+		var err error
+		owners, err = db.Table(common.BKTableNameInstAsst).Distinct(kit.Ctx, common.BKOwnerIDField, cond)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, owner := range owners {
+		ownerCond := make(map[string]interface{})
+		for key, value := range cond {
+			ownerCond[key] = value
+		}
+		ownerCond[common.BKOwnerIDField] = owner
+
+		objIDs, err := db.Table(common.BKTableNameInstAsst).Distinct(kit.Ctx, common.BKObjIDField, ownerCond)
+		if err != nil {
+			return err
+		}
+		for _, objID := range objIDs {
+			ownerCond[common.BKObjIDField] = objID
+			if err := db.Table(common.BKTableNameInstAsst).Delete(kit.Ctx, ownerCond); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
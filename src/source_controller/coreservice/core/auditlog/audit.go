@@ -19,6 +19,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/sensitive"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/storage/driver/mongodb"
@@ -64,9 +65,46 @@ func (m *auditManager) CreateAuditLog(kit *rest.Kit, logs ...metadata.AuditLog)
 	if len(logRows) == 0 {
 		return nil
 	}
+
+	if err := m.redactSensitiveData(kit, logRows); err != nil {
+		return err
+	}
 	return mongodb.Client().Table(common.BKTableNameAuditLog).Insert(kit.Ctx, logRows)
 }
 
+// redactSensitiveData replaces the values of the sensitive attributes in the instance audit logs with the mask,
+// so that they are never saved in the audit logs, whether they are plain or encrypted.
+func (m *auditManager) redactSensitiveData(kit *rest.Kit, logs []metadata.AuditLog) error {
+	objIDs := make([]string, 0)
+	for _, log := range logs {
+		detail, ok := log.OperationDetail.(*metadata.InstanceOpDetail)
+		if ok && detail.Details != nil && detail.ModelID != "" {
+			objIDs = append(objIDs, detail.ModelID)
+		}
+	}
+
+	if len(objIDs) == 0 {
+		return nil
+	}
+
+	fields, err := sensitive.GetFields(kit.Ctx, mongodb.Client(), kit.SupplierAccount, util.StrArrayUnique(objIDs)...)
+	if err != nil {
+		blog.Errorf("get sensitive attributes of models %v failed, err: %v, rid: %s", objIDs, err, kit.Rid)
+		return err
+	}
+
+	for _, log := range logs {
+		detail, ok := log.OperationDetail.(*metadata.InstanceOpDetail)
+		if !ok || detail.Details == nil || len(fields[detail.ModelID]) == 0 {
+			continue
+		}
+		sensitive.Redact(detail.Details.PreData, fields[detail.ModelID])
+		sensitive.Redact(detail.Details.CurData, fields[detail.ModelID])
+		sensitive.Redact(detail.Details.UpdateFields, fields[detail.ModelID])
+	}
+	return nil
+}
+
 func (m *auditManager) SearchAuditLog(kit *rest.Kit, param metadata.QueryCondition) ([]metadata.AuditLog, uint64, error) {
 	condition := param.Condition
 	condition = util.SetQueryOwner(condition, kit.SupplierAccount)
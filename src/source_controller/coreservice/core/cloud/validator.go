@@ -95,6 +95,24 @@ func (c *cloudOperation) validUpdateAccount(kit *rest.Kit, accountID int64, opti
 		}
 	}
 
+	// tag rules check
+	if tagRules, ok := option.Get(common.BKCloudTagRules); ok {
+		bs, err := json.Marshal(tagRules)
+		if err != nil {
+			blog.ErrorJSON("[validUpdateAccount] marshal tag rules failed, err: %s, rules: %s, rid: %s", err, tagRules, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommJSONMarshalFailed)
+		}
+		rules := make([]metadata.CloudTagRule, 0)
+		if err := json.Unmarshal(bs, &rules); err != nil {
+			blog.ErrorJSON("[validUpdateAccount] unmarshal tag rules failed, err: %s, rules: %s, rid: %s", err, tagRules, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCloudValidAccountParamFail, common.BKCloudTagRules)
+		}
+		if rawErr := metadata.ValidateCloudTagRules(rules); rawErr.ErrCode != 0 {
+			blog.ErrorJSON("[validUpdateAccount] tag rules are invalid, rules: %s, rid: %s", rules, kit.Rid)
+			return kit.CCError.CCErrorf(rawErr.ErrCode, rawErr.Args...)
+		}
+	}
+
 	return nil
 }
 
@@ -172,8 +190,24 @@ func (c *cloudOperation) validCreateSyncTask(kit *rest.Kit, task *metadata.Cloud
 		return err
 	}
 
-	// account task count check, one account can only have one task
-	option := &metadata.SearchCloudOption{Condition: mapstr.MapStr{common.BKCloudAccountID: task.AccountID}}
+	if err := c.validResourceType(kit, task.ResourceType); err != nil {
+		return err
+	}
+	if task.ResourceType != metadata.CloudResourceTypeHost {
+		if task.ResourceSync == nil {
+			blog.ErrorJSON("[validCreateSycTask] resource sync config is not set, task: %s, rid: %s", task, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCloudValidSyncTaskParamFail, common.BKCloudResourceSync)
+		}
+		if err := c.validResourceSyncConf(kit, 0, task.ResourceSync); err != nil {
+			return err
+		}
+	}
+
+	// account task count check, one account can only have one task of each resource type
+	option := &metadata.SearchCloudOption{Condition: mapstr.MapStr{
+		common.BKCloudAccountID:    task.AccountID,
+		common.BKCloudResourceType: task.ResourceType,
+	}}
 	multiTask, err := c.SearchSyncTask(kit, option)
 	if nil != err {
 		blog.ErrorJSON("[validCreateSycTask] SearchSyncTask error %s, option: %s, rid: %s", err, option, kit.Rid)
@@ -242,9 +276,114 @@ func (c *cloudOperation) validUpdateSyncTask(kit *rest.Kit, taskID int64, option
 
 	}
 
+	if option.Exists(common.BKCloudResourceType) {
+		resourceType, err := option.String(common.BKCloudResourceType)
+		if err != nil {
+			blog.ErrorJSON("[validUpdateSyncTask] invalid resource type, option: %s, rid: %s", option, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCloudValidSyncTaskParamFail, common.BKCloudResourceType)
+		}
+		if err := c.validResourceType(kit, resourceType); err != nil {
+			return err
+		}
+	}
+
+	if syncInfo, ok := option.Get(common.BKCloudResourceSync); ok {
+		bs, err := json.Marshal(syncInfo)
+		if err != nil {
+			blog.ErrorJSON("validUpdateSyncTask failed, error %s, syncInfo:%s, rid: %s", err, syncInfo, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommJSONMarshalFailed)
+		}
+		syncConf := new(metadata.CloudResourceSyncConf)
+		if err := json.Unmarshal(bs, syncConf); err != nil {
+			blog.ErrorJSON("validUpdateSyncTask failed, error %s, syncInfo:%s, rid: %s", err, syncInfo, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+		}
+
+		if err := c.validResourceSyncConf(kit, taskID, syncConf); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// validResourceType valid the resource type of the cloud sync task, host or the supported non-host resource types
+func (c *cloudOperation) validResourceType(kit *rest.Kit, resourceType string) errors.CCErrorCoder {
+	if resourceType == metadata.CloudResourceTypeHost ||
+		util.InStrArr(metadata.SupportedCloudResourceTypes, resourceType) {
+		return nil
+	}
+
+	blog.Errorf("cloud sync resource type %s is not supported, rid: %s", resourceType, kit.Rid)
+	return kit.CCError.CCErrorf(common.CCErrCloudValidSyncTaskParamFail, common.BKCloudResourceType)
+}
+
+// validResourceSyncConf valid the config to sync non-host cloud resources into a custom object, the object must
+// exist, and an object can only be synced by one task if the instances are not distinguished by the account id.
+func (c *cloudOperation) validResourceSyncConf(kit *rest.Kit, taskID int64,
+	conf *metadata.CloudResourceSyncConf) errors.CCErrorCoder {
+
+	if rawErr := conf.Validate(); rawErr.ErrCode != 0 {
+		blog.ErrorJSON("resource sync config is invalid, conf: %s, rid: %s", conf, kit.Rid)
+		return kit.CCError.CCErrorf(rawErr.ErrCode, rawErr.Args...)
+	}
+
+	cond := mapstr.MapStr{common.BKObjIDField: conf.ObjectID}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	count, err := c.dbProxy.Table(common.BKTableNameObjDes).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.ErrorJSON("count object failed, error %s, condition: %s, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		blog.Errorf("object %s of resource sync config does not exist, rid: %s", conf.ObjectID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCloudValidSyncTaskParamFail, common.BKObjIDField)
+	}
+
+	// the object can be synced by multiple tasks only if all of them distinguish the instances by the account id,
+	// otherwise the task without account scope would delete the instances synced by the other tasks.
+	cond = mapstr.MapStr{
+		common.BKCloudSyncTaskID: map[string]interface{}{common.BKDBNE: taskID},
+	}
+	cond[common.BKCloudResourceSync+"."+common.BKObjIDField] = conf.ObjectID
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	tasks := make([]metadata.CloudSyncTask, 0)
+	err = c.dbProxy.Table(common.BKTableNameCloudSyncTask).Find(cond).Fields(common.BKCloudSyncTaskID,
+		common.BKCloudResourceSync).All(kit.Ctx, &tasks)
+	if err != nil {
+		blog.ErrorJSON("search task failed, error %s, condition: %s, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	if !hasAccountScope(conf) {
+		blog.Errorf("object %s has been synced by other task, but the config has no account scope, rid: %s",
+			conf.ObjectID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCloudValidSyncTaskParamFail, common.BKObjIDField)
+	}
+	for _, task := range tasks {
+		if task.ResourceSync == nil || !hasAccountScope(task.ResourceSync) {
+			blog.Errorf("object %s has been synced by task %d without account scope, rid: %s", conf.ObjectID,
+				task.TaskID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCloudValidSyncTaskParamFail, common.BKObjIDField)
+		}
+	}
+	return nil
+}
+
+// hasAccountScope checks if the resource sync config maps the account id, so that the instances synced by
+// different accounts can be distinguished.
+func hasAccountScope(conf *metadata.CloudResourceSyncConf) bool {
+	for _, field := range conf.FieldMapping {
+		if field == metadata.CloudResourceAccountField {
+			return true
+		}
+	}
+	return false
+}
+
 // Valid sync vpc info
 func (c *cloudOperation) validSyncVpcInfo(kit *rest.Kit, syncVpcs []metadata.VpcSyncInfo) errors.CCErrorCoder {
 	if len(syncVpcs) == 0 {
@@ -56,6 +56,11 @@ type ModelAttribute interface {
 	DeleteModelAttributes(kit *rest.Kit, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
 	SearchModelAttributes(kit *rest.Kit, objID string, inputParam metadata.QueryCondition) (*metadata.QueryModelAttributeDataResult, error)
 	SearchModelAttributesByCondition(kit *rest.Kit, inputParam metadata.QueryCondition) (*metadata.QueryModelAttributeDataResult, error)
+	PlanModelAttributeSchemaChange(kit *rest.Kit, objID string, change metadata.AttributeSchemaChange) (*metadata.AttributeSchemaChangePlan, error)
+	CreateModelAttributeSchemaMigration(kit *rest.Kit, objID string, change metadata.AttributeSchemaChange) (*metadata.AttributeSchemaMigrationTask, error)
+	RollbackModelAttributeSchemaMigration(kit *rest.Kit, migrationID string) (*metadata.AttributeSchemaMigrationTask, error)
+	ExecuteModelAttributeSchemaMigrationStep(kit *rest.Kit, step metadata.AttributeSchemaMigrationStep) error
+	SearchModelAttributeSchemaMigrations(kit *rest.Kit, opt metadata.SearchAttributeSchemaMigrationOption) ([]metadata.AttributeSchemaMigration, error)
 }
 
 // ModelAttrUnique model attribute  unique methods definitions
@@ -186,6 +191,11 @@ type StatisticOperation interface {
 	UpdateOperationChart(kit *rest.Kit, inputParam map[string]interface{}) (interface{}, error)
 	SearchTimerChartData(kit *rest.Kit, inputParam metadata.ChartConfig) (interface{}, error)
 	TimerFreshData(kit *rest.Kit) error
+	TakeTopoSnapshot(kit *rest.Kit, bizID int64) error
+	TakeInstSnapshot(kit *rest.Kit, objID string) error
+	ClearSnapshot(kit *rest.Kit, keepDays int) error
+	SearchTopoSnapshot(kit *rest.Kit, opt metadata.SearchTopoSnapshotOption) (*metadata.TopoSnapshot, error)
+	SearchInstSnapshot(kit *rest.Kit, opt metadata.SearchInstSnapshotOption) (*metadata.InstSnapshot, error)
 }
 
 // Core core itnerfaces methods
@@ -260,6 +270,9 @@ type ProcessOperation interface {
 type LabelOperation interface {
 	AddLabel(kit *rest.Kit, tableName string, option selector.LabelAddOption) errors.CCErrorCoder
 	RemoveLabel(kit *rest.Kit, tableName string, option selector.LabelRemoveOption) errors.CCErrorCoder
+	// LabelAggregation returns the label keys and their values of the instances matching the condition
+	LabelAggregation(kit *rest.Kit, tableName string, condition map[string]interface{}) (map[string][]string,
+		errors.CCErrorCoder)
 }
 
 type SetTemplateOperation interface {
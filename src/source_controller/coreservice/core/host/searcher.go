@@ -13,10 +13,29 @@
 package host
 
 import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/sensitive"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
 )
 
 func (hm *hostManager) ListHosts(kit *rest.Kit, input metadata.ListHosts) (*metadata.ListHostResult, error) {
-	return hm.hostSearcher.ListHosts(kit.Ctx, input)
+	result, err := hm.hostSearcher.ListHosts(kit.Ctx, input)
+	if err != nil || len(result.Info) == 0 {
+		return result, err
+	}
+
+	fields, err := sensitive.GetFields(kit.Ctx, mongodb.Client(), kit.SupplierAccount, common.BKInnerObjIDHost)
+	if err != nil {
+		blog.Errorf("get host sensitive attributes failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	reveal := util.IsRevealSensitive(kit.Header)
+	for _, host := range result.Info {
+		sensitive.Output(kit.Ctx, host, fields[common.BKInnerObjIDHost], reveal, kit.Rid)
+	}
+	return result, nil
 }
@@ -54,13 +54,19 @@ func (m *instanceManager) instCnt(kit *rest.Kit, objID string, cond mapstr.MapSt
 func (m *instanceManager) CreateModelInstance(kit *rest.Kit, objID string, inputParam metadata.CreateModelInstance) (*metadata.CreateOneDataResult, error) {
 	rid := util.ExtractRequestIDFromContext(kit.Ctx)
 
+	sensitiveFields, err := m.getSensitiveFields(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+	removeMaskedSensitiveData(inputParam.Data, sensitiveFields)
+
 	inputParam.Data.Set(common.BKOwnerIDField, kit.SupplierAccount)
-	err := m.validCreateInstanceData(kit, objID, inputParam.Data)
+	err = m.validCreateInstanceData(kit, objID, inputParam.Data)
 	if nil != err {
 		blog.Errorf("CreateModelInstance failed, valid error: %+v, rid: %s", err, rid)
 		return nil, err
 	}
-	id, err := m.save(kit, objID, inputParam.Data)
+	id, err := m.save(kit, objID, inputParam.Data, sensitiveFields)
 	if err != nil {
 		blog.ErrorJSON("CreateModelInstance create objID(%s) instance error. err:%s, data:%s, rid:%s", objID, err.Error(), inputParam.Data, kit.Rid)
 		return nil, err
@@ -72,7 +78,13 @@ func (m *instanceManager) CreateModelInstance(kit *rest.Kit, objID string, input
 func (m *instanceManager) CreateManyModelInstance(kit *rest.Kit, objID string, inputParam metadata.CreateManyModelInstance) (*metadata.CreateManyDataResult, error) {
 	var newIDs []uint64
 	dataResult := &metadata.CreateManyDataResult{}
+	sensitiveFields, err := m.getSensitiveFields(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
 	for itemIdx, item := range inputParam.Datas {
+		removeMaskedSensitiveData(item, sensitiveFields)
 		item.Set(common.BKOwnerIDField, kit.SupplierAccount)
 		err := m.validCreateInstanceData(kit, objID, item)
 		if nil != err {
@@ -85,7 +97,7 @@ func (m *instanceManager) CreateManyModelInstance(kit *rest.Kit, objID string, i
 			continue
 		}
 		item.Set(common.BKOwnerIDField, kit.SupplierAccount)
-		id, err := m.save(kit, objID, item)
+		id, err := m.save(kit, objID, item, sensitiveFields)
 		if nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
 				Message:     err.Error(),
@@ -120,6 +132,12 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 		return nil, kit.CCError.Error(common.CCErrCommNotFound)
 	}
 
+	sensitiveFields, err := m.getSensitiveFields(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+	removeMaskedSensitiveData(inputParam.Data, sensitiveFields)
+
 	for _, origin := range origins {
 		instIDI := origin[instIDFieldName]
 		instID, _ := util.GetInt64ByInterface(instIDI)
@@ -130,10 +148,16 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 		}
 	}
 
-	err = m.update(kit, objID, inputParam.Data, inputParam.Condition)
-	if err != nil {
-		blog.ErrorJSON("UpdateModelInstance update objID(%s) inst error. err:%s, condition:%s, rid:%s", objID, err, inputParam.Condition, kit.Rid)
-		return nil, kit.CCError.Error(common.CCErrCommDBUpdateFailed)
+	if hasSensitiveData(inputParam.Data, sensitiveFields) {
+		if err := m.updateWithSensitiveData(kit, objID, inputParam.Data, origins, sensitiveFields); err != nil {
+			return nil, err
+		}
+	} else {
+		err = m.update(kit, objID, inputParam.Data, inputParam.Condition)
+		if err != nil {
+			blog.ErrorJSON("UpdateModelInstance update objID(%s) inst error. err:%s, condition:%s, rid:%s", objID, err, inputParam.Condition, kit.Rid)
+			return nil, kit.CCError.Error(common.CCErrCommDBUpdateFailed)
+		}
 	}
 
 	if objID == common.BKInnerObjIDHost {
@@ -352,6 +376,10 @@ func (m *instanceManager) SearchModelInstance(kit *rest.Kit, objID string, input
 		return nil, instErr
 	}
 
+	if err := m.outputSensitiveData(kit, objID, instItems); err != nil {
+		return nil, err
+	}
+
 	dataResult := &metadata.QueryResult{
 		Count: finalCount,
 		Info:  instItems,
@@ -371,6 +399,7 @@ func (m *instanceManager) DeleteModelInstance(kit *rest.Kit, objID string, input
 		return &metadata.DeletedCount{}, err
 	}
 
+	instIDs := make([]int64, 0)
 	for _, origin := range origins {
 		instID, err := util.GetInt64ByInterface(origin[instIDFieldName])
 		if nil != err {
@@ -383,6 +412,16 @@ func (m *instanceManager) DeleteModelInstance(kit *rest.Kit, objID string, input
 		if exists {
 			return &metadata.DeletedCount{}, kit.CCError.Error(common.CCErrorInstHasAsst)
 		}
+		instIDs = append(instIDs, instID)
+	}
+
+	if err := m.validInstNotReferenced(kit, objID, instIDs); err != nil {
+		return &metadata.DeletedCount{}, err
+	}
+
+	sensitiveFields, err := m.getSensitiveFields(kit, objID)
+	if err != nil {
+		return &metadata.DeletedCount{}, err
 	}
 
 	err = mongodb.Client().Table(tableName).Delete(kit.Ctx, inputParam.Condition)
@@ -390,6 +429,7 @@ func (m *instanceManager) DeleteModelInstance(kit *rest.Kit, objID string, input
 		blog.ErrorJSON("DeleteModelInstance delete objID(%s) instance error. err:%s, coniditon:%s, rid:%s", objID, err.Error(), inputParam.Condition, kit.Rid)
 		return &metadata.DeletedCount{}, err
 	}
+	m.deleteSensitiveData(kit, objID, origins, sensitiveFields)
 
 	return &metadata.DeletedCount{Count: uint64(len(origins))}, nil
 }
@@ -404,20 +444,35 @@ func (m *instanceManager) CascadeDeleteModelInstance(kit *rest.Kit, objID string
 		return &metadata.DeletedCount{}, err
 	}
 
+	instIDs := make([]int64, 0)
 	for _, origin := range origins {
 		instID, err := util.GetInt64ByInterface(origin[instIDFieldName])
 		if nil != err {
 			return &metadata.DeletedCount{}, err
 		}
+		instIDs = append(instIDs, instID)
+	}
+
+	if err := m.validInstNotReferenced(kit, objID, instIDs); err != nil {
+		return &metadata.DeletedCount{}, err
+	}
+
+	for _, instID := range instIDs {
 		err = m.dependent.DeleteInstAsst(kit, objID, uint64(instID))
 		if nil != err {
 			return &metadata.DeletedCount{}, err
 		}
 	}
+	sensitiveFields, err := m.getSensitiveFields(kit, objID)
+	if err != nil {
+		return &metadata.DeletedCount{}, err
+	}
+
 	inputParam.Condition = util.SetModOwner(inputParam.Condition, kit.SupplierAccount)
 	err = mongodb.Client().Table(tableName).Delete(kit.Ctx, inputParam.Condition)
 	if nil != err {
 		return &metadata.DeletedCount{}, err
 	}
+	m.deleteSensitiveData(kit, objID, origins, sensitiveFields)
 	return &metadata.DeletedCount{Count: uint64(len(origins))}, nil
 }
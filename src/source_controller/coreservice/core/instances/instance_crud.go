@@ -25,7 +25,7 @@ import (
 	"configcenter/src/storage/driver/mongodb"
 )
 
-func (m *instanceManager) save(kit *rest.Kit, objID string, inputParam mapstr.MapStr) (id uint64, err error) {
+func (m *instanceManager) save(kit *rest.Kit, objID string, inputParam mapstr.MapStr, sensitiveFields []string) (id uint64, err error) {
 	if objID == common.BKInnerObjIDHost {
 		inputParam = metadata.ConvertHostSpecialStringToArray(inputParam)
 	}
@@ -43,7 +43,13 @@ func (m *instanceManager) save(kit *rest.Kit, objID string, inputParam mapstr.Ma
 	inputParam.Set(common.BKOwnerIDField, kit.SupplierAccount)
 	inputParam.Set(common.CreateTimeField, ts)
 	inputParam.Set(common.LastTimeField, ts)
+	if err := m.encryptSensitiveData(kit, objID, id, inputParam, sensitiveFields); err != nil {
+		return id, err
+	}
 	err = mongodb.Client().Table(tableName).Insert(kit.Ctx, inputParam)
+	if err != nil {
+		m.deleteSensitiveData(kit, objID, []mapstr.MapStr{inputParam}, sensitiveFields)
+	}
 	return id, err
 }
 
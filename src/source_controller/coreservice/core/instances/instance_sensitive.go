@@ -0,0 +1,140 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/sensitive"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// getSensitiveFields returns the property ids of the model's sensitive attributes
+func (m *instanceManager) getSensitiveFields(kit *rest.Kit, objID string) ([]string, error) {
+	fields, err := sensitive.GetFields(kit.Ctx, mongodb.Client(), kit.SupplierAccount, objID)
+	if err != nil {
+		blog.Errorf("get model %s sensitive attributes failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return fields[objID], nil
+}
+
+// removeMaskedSensitiveData removes the masked sensitive values which are returned to the user by search,
+// the user does not change these values.
+func removeMaskedSensitiveData(data mapstr.MapStr, fields []string) {
+	for _, field := range fields {
+		if data[field] == sensitive.Mask {
+			delete(data, field)
+		}
+	}
+}
+
+// hasSensitiveData returns whether the data contains any sensitive value
+func hasSensitiveData(data mapstr.MapStr, fields []string) bool {
+	for _, field := range fields {
+		if _, exists := data[field]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptSensitiveData replaces the sensitive values in the data with the encrypted values
+func (m *instanceManager) encryptSensitiveData(kit *rest.Kit, objID string, instID uint64, data mapstr.MapStr,
+	fields []string) error {
+
+	for _, field := range fields {
+		val, exists := data[field]
+		if !exists {
+			continue
+		}
+
+		name := sensitive.SecretName(kit.SupplierAccount, objID, int64(instID), field)
+		encrypted, err := sensitive.Encrypt(kit.Ctx, name, val)
+		if err != nil {
+			blog.Errorf("encrypt model %s instance %d sensitive field %s failed, err: %v, rid: %s", objID, instID,
+				field, err, kit.Rid)
+			// do not leave the plain value in the data, it may be logged by the caller
+			data[field] = sensitive.Mask
+			return kit.CCError.CCError(common.CCErrCommInternalServerError)
+		}
+		data[field] = encrypted
+	}
+	return nil
+}
+
+// deleteSensitiveData deletes the secrets of the instances' encrypted values, the failure is only logged because
+// the instances are already deleted.
+func (m *instanceManager) deleteSensitiveData(kit *rest.Kit, objID string, origins []mapstr.MapStr, fields []string) {
+	for _, origin := range origins {
+		for _, field := range fields {
+			if err := sensitive.Delete(kit.Ctx, origin[field]); err != nil {
+				blog.Errorf("delete model %s instance sensitive field %s secret failed, err: %v, rid: %s", objID,
+					field, err, kit.Rid)
+			}
+		}
+	}
+}
+
+// updateWithSensitiveData updates the instances one by one, because the sensitive values of each instance are
+// encrypted with the secret names of its own. the secrets of the previous values are not deleted, the secret
+// provider keeps them as the older versions of the same secret name.
+func (m *instanceManager) updateWithSensitiveData(kit *rest.Kit, objID string, data mapstr.MapStr,
+	origins []mapstr.MapStr, fields []string) error {
+
+	instIDField := common.GetInstIDField(objID)
+	for _, origin := range origins {
+		instID, err := util.GetInt64ByInterface(origin[instIDField])
+		if err != nil {
+			blog.Errorf("get model %s instance id failed, err: %v, origin: %v, rid: %s", objID, err, origin, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, instIDField)
+		}
+
+		instData := data.Clone()
+		if err := m.encryptSensitiveData(kit, objID, uint64(instID), instData, fields); err != nil {
+			return err
+		}
+
+		cond := mapstr.MapStr(util.SetModOwner(mapstr.MapStr{instIDField: instID}, kit.SupplierAccount))
+		if err := m.update(kit, objID, instData, cond); err != nil {
+			blog.ErrorJSON("update model %s instance %s failed, err: %s, rid: %s", objID, instID, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+		}
+	}
+	return nil
+}
+
+// outputSensitiveData decrypts the sensitive values of the instances if the user is allowed to see them,
+// otherwise masks them.
+func (m *instanceManager) outputSensitiveData(kit *rest.Kit, objID string, insts []mapstr.MapStr) error {
+	if len(insts) == 0 {
+		return nil
+	}
+
+	fields, err := m.getSensitiveFields(kit, objID)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	reveal := util.IsRevealSensitive(kit.Header)
+	for _, inst := range insts {
+		sensitive.Output(kit.Ctx, inst, fields, reveal, kit.Rid)
+	}
+	return nil
+}
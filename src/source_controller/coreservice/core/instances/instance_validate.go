@@ -144,6 +144,11 @@ func (m *instanceManager) validCreateInstanceData(kit *rest.Kit, objID string, i
 			delete(instanceData, key)
 			continue
 		}
+		if property.PropertyType == common.FieldTypeComputed {
+			// the value is computed by the system after the instance is created
+			instanceData[key] = nil
+			continue
+		}
 		if value, ok := val.(string); ok {
 			val = strings.TrimSpace(value)
 			instanceData[key] = val
@@ -156,6 +161,10 @@ func (m *instanceManager) validCreateInstanceData(kit *rest.Kit, objID string, i
 		}
 	}
 
+	if err := m.validInstRefData(kit, instanceData, valid.properties); err != nil {
+		return err
+	}
+
 	skip, err := hooks.IsSkipValidateHook(kit, objID, instanceData)
 	if err != nil {
 		blog.Errorf("check is skip validate %s hook failed, err: %v, rid: %s", objID, err, kit.Rid)
@@ -295,7 +304,7 @@ func (m *instanceManager) validUpdateInstanceData(kit *rest.Kit, objID string, i
 		}
 
 		property, ok := valid.properties[key]
-		if !ok || (!property.IsEditable && !canEditAll) {
+		if !ok || property.PropertyType == common.FieldTypeComputed || (!property.IsEditable && !canEditAll) {
 			delete(instanceData, key)
 			continue
 		}
@@ -311,6 +320,10 @@ func (m *instanceManager) validUpdateInstanceData(kit *rest.Kit, objID string, i
 		}
 	}
 
+	if err := m.validInstRefData(kit, instanceData, valid.properties); err != nil {
+		return err
+	}
+
 	if err := m.changeStringToTime(instanceData, valid.propertySlice); err != nil {
 		blog.Errorf("there is an error in converting the time type string to the time type, err: %s, rid: %s", err, kit.Rid)
 		return err
@@ -377,6 +390,76 @@ func (m *instanceManager) validMainlineInstanceName(kit *rest.Kit, objID string,
 	return nil
 }
 
+// validInstRefData valid the instances referenced by the instance reference fields exist
+func (m *instanceManager) validInstRefData(kit *rest.Kit, instanceData mapstr.MapStr, properties map[string]metadata.Attribute) error {
+	for key, val := range instanceData {
+		property, ok := properties[key]
+		if !ok || property.PropertyType != common.FieldTypeInstRef || val == nil {
+			continue
+		}
+
+		refOption, err := metadata.ParseInstRefOption(kit.Ctx, property.Option)
+		if err != nil {
+			blog.Errorf("parse field %s instance reference option failed, err: %v, rid: %s", key, err, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, key)
+		}
+
+		refInstID, err := util.GetInt64ByInterface(val)
+		if err != nil {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsNeedInt, key)
+		}
+
+		cond := mapstr.MapStr{common.GetInstIDField(refOption.ObjectID): refInstID}
+		cnt, err := m.countInstance(kit, refOption.ObjectID, cond)
+		if err != nil {
+			blog.Errorf("count referenced instance failed, objID: %s, instID: %d, err: %v, rid: %s", refOption.ObjectID,
+				refInstID, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		if cnt == 0 {
+			blog.Errorf("referenced instance %d of model %s does not exist, rid: %s", refInstID, refOption.ObjectID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCoreServiceInstRefNotExist, refInstID, refOption.ObjectID, key)
+		}
+	}
+	return nil
+}
+
+// validInstNotReferenced valid the instances are not referenced by any instance reference fields of other instances
+func (m *instanceManager) validInstNotReferenced(kit *rest.Kit, objID string, instIDs []int64) error {
+	if len(instIDs) == 0 {
+		return nil
+	}
+
+	attrCond := map[string]interface{}{
+		common.BKPropertyTypeField:                       common.FieldTypeInstRef,
+		common.BKOptionField + "." + common.BKObjIDField: objID,
+	}
+	attrCond = util.SetQueryOwner(attrCond, kit.SupplierAccount)
+	attrs := make([]metadata.Attribute, 0)
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(attrCond).All(kit.Ctx, &attrs); err != nil {
+		blog.Errorf("find instance reference attributes failed, objID: %s, err: %v, rid: %s", objID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	for _, attr := range attrs {
+		cond := mapstr.MapStr{attr.PropertyID: mapstr.MapStr{common.BKDBIN: instIDs}}
+		cnt, err := m.countInstance(kit, attr.ObjectID, cond)
+		if err != nil {
+			blog.Errorf("count referencing instance failed, objID: %s, field: %s, err: %v, rid: %s", attr.ObjectID,
+				attr.PropertyID, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		if cnt > 0 {
+			blog.Errorf("instances %v of model %s are referenced by field %s of model %s, rid: %s", instIDs, objID,
+				attr.PropertyID, attr.ObjectID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCoreServiceInstReferencedByOthers, attr.PropertyID, attr.ObjectID)
+		}
+	}
+	return nil
+}
+
 // validCloudID valid the bk_cloud_id
 func (m *instanceManager) validCloudID(kit *rest.Kit, objID string, BKInnerObjIDHost mapstr.MapStr) error {
 	if objID == common.BKInnerObjIDHost {
@@ -241,6 +241,20 @@ func FillLostedFieldValue(ctx context.Context, valData mapstr.MapStr, propertys
 				valData[field.PropertyID] = nil
 			case common.FieldTypeBool:
 				valData[field.PropertyID] = false
+			case common.FieldTypeEnumMulti:
+				enumOptions, err := metadata.ParseEnumOption(ctx, field.Option)
+				if err != nil {
+					blog.Warnf("ParseEnumOption failed: %v, rid: %s", err, rid)
+					valData[field.PropertyID] = nil
+					continue
+				}
+				defaultIDs := make([]string, 0)
+				for _, k := range enumOptions {
+					if k.IsDefault {
+						defaultIDs = append(defaultIDs, k.ID)
+					}
+				}
+				valData[field.PropertyID] = defaultIDs
 			default:
 				valData[field.PropertyID] = nil
 			}
@@ -13,6 +13,8 @@
 package label
 
 import (
+	"sort"
+
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
@@ -116,3 +118,42 @@ func (p *labelOperation) RemoveLabel(kit *rest.Kit, tableName string, option sel
 	}
 	return nil
 }
+
+// labelAggregationItem the values of a label key
+type labelAggregationItem struct {
+	Key    string   `bson:"_id"`
+	Values []string `bson:"values"`
+}
+
+func (p *labelOperation) LabelAggregation(kit *rest.Kit, tableName string, condition map[string]interface{}) (
+	map[string][]string, errors.CCErrorCoder) {
+
+	if condition == nil {
+		condition = make(map[string]interface{})
+	}
+	condition = util.SetQueryOwner(condition, kit.SupplierAccount)
+	pipeline := []map[string]interface{}{
+		{common.BKDBMatch: condition},
+		{"$project": map[string]interface{}{"labels": map[string]interface{}{"$objectToArray": "$labels"}}},
+		{"$unwind": "$labels"},
+		{common.BKDBGroup: map[string]interface{}{
+			"_id":    "$labels.k",
+			"values": map[string]interface{}{common.BKDBAddToSet: "$labels.v"},
+		}},
+	}
+	items := make([]labelAggregationItem, 0)
+	if err := mongodb.Client().Table(tableName).AggregateAll(kit.Ctx, pipeline, &items); err != nil {
+		if !mongodb.Client().IsNotFoundError(err) {
+			blog.ErrorJSON("LabelAggregation failed, aggregate labels failed, table: %s, condition: %s, err: %s, rid: %s",
+				tableName, condition, err.Error(), kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommDBSelectFailed)
+		}
+	}
+
+	aggregation := make(map[string][]string)
+	for _, item := range items {
+		sort.Strings(item.Values)
+		aggregation[item.Key] = item.Values
+	}
+	return aggregation, nil
+}
@@ -23,6 +23,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/expression"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
@@ -87,6 +88,12 @@ func (m *modelAttribute) save(kit *rest.Kit, attribute metadata.Attribute) (id u
 		attribute.LastTime.Time = time.Now()
 	}
 
+	// computed attribute's value is maintained by the system, it can not be edited or required.
+	if attribute.PropertyType == common.FieldTypeComputed {
+		attribute.IsEditable = false
+		attribute.IsRequired = false
+	}
+
 	if err = m.saveCheck(kit, attribute); err != nil {
 		return 0, err
 	}
@@ -215,7 +222,16 @@ func (m *modelAttribute) checkAttributeValidity(kit *rest.Kit, attribute metadat
 	if attribute.PropertyType != "" {
 		switch attribute.PropertyType {
 		case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeEnum,
-			common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeUser, common.FieldTypeOrganization, common.FieldTypeTimeZone, common.FieldTypeBool, common.FieldTypeList:
+			common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeUser, common.FieldTypeOrganization, common.FieldTypeTimeZone, common.FieldTypeBool, common.FieldTypeList,
+			common.FieldTypeIP, common.FieldTypeURL, common.FieldTypeEnumMulti:
+		case common.FieldTypeInstRef:
+			if err := m.checkInstRefOption(kit, attribute.Option); err != nil {
+				return err
+			}
+		case common.FieldTypeComputed:
+			if err := m.checkComputedOption(kit, attribute.ObjectID, attribute.Option); err != nil {
+				return err
+			}
 		default:
 			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldPropertyType)
 		}
@@ -230,8 +246,95 @@ func (m *modelAttribute) checkAttributeValidity(kit *rest.Kit, attribute metadat
 	return nil
 }
 
+// checkInstRefOption check the model referenced by the instance reference attribute exists
+func (m *modelAttribute) checkInstRefOption(kit *rest.Kit, option interface{}) error {
+	refOption, err := metadata.ParseInstRefOption(kit.Ctx, option)
+	if err != nil {
+		blog.Errorf("parse instance reference option %#v failed, err: %v, rid: %s", option, err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+
+	cond := map[string]interface{}{common.BKObjIDField: refOption.ObjectID}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	cnt, err := mongodb.Client().Table(common.BKTableNameObjDes).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count referenced model %s failed, err: %v, rid: %s", refOption.ObjectID, err, kit.Rid)
+		return kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	if cnt == 0 {
+		blog.Errorf("referenced model %s does not exist, rid: %s", refOption.ObjectID, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+	return nil
+}
+
+// checkComputedOption check the rule of the computed attribute, the fields used by the expression must be
+// the model's non-computed attributes, and the aggregated model and field must exist.
+func (m *modelAttribute) checkComputedOption(kit *rest.Kit, objID string, option interface{}) error {
+	computedOption, err := metadata.ParseComputedOption(kit.Ctx, option)
+	if err != nil {
+		blog.Errorf("parse computed option %#v failed, err: %v, rid: %s", option, err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+
+	var fieldObjID string
+	var fields []string
+	switch computedOption.Type {
+	case metadata.ComputeTypeExpression:
+		exp, err := expression.Parse(computedOption.Expression)
+		if err != nil {
+			blog.Errorf("parse computed expression %s failed, err: %v, rid: %s", computedOption.Expression, err, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+		}
+		fieldObjID, fields = objID, exp.Fields()
+
+	case metadata.ComputeTypeAggregation:
+		cond := map[string]interface{}{common.BKObjIDField: computedOption.AsstObjID}
+		cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+		cnt, err := mongodb.Client().Table(common.BKTableNameObjDes).Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count aggregated model %s failed, err: %v, rid: %s", computedOption.AsstObjID, err, kit.Rid)
+			return kit.CCError.Error(common.CCErrCommDBSelectFailed)
+		}
+
+		if cnt == 0 {
+			blog.Errorf("aggregated model %s does not exist, rid: %s", computedOption.AsstObjID, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+		}
+
+		if computedOption.Field != "" {
+			fieldObjID, fields = computedOption.AsstObjID, []string{computedOption.Field}
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	cond := map[string]interface{}{
+		common.BKObjIDField:        fieldObjID,
+		common.BKPropertyIDField:   map[string]interface{}{common.BKDBIN: fields},
+		common.BKPropertyTypeField: map[string]interface{}{common.BKDBNE: common.FieldTypeComputed},
+		// the sensitive attributes are encrypted, they can not be computed
+		metadata.AttributeFieldIsSensitive: map[string]interface{}{common.BKDBNE: true},
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	cnt, err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count computed fields %v of model %s failed, err: %v, rid: %s", fields, fieldObjID, err, kit.Rid)
+		return kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	if cnt != uint64(len(fields)) {
+		blog.Errorf("computed fields %v are not all the attributes of model %s, rid: %s", fields, fieldObjID, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+	return nil
+}
+
 func (m *modelAttribute) update(kit *rest.Kit, data mapstr.MapStr, cond universalsql.Condition) (cnt uint64, err error) {
-	cnt, err = m.checkUpdate(kit, data, cond)
+	cnt, sensitiveChanged, err := m.checkUpdate(kit, data, cond)
 	if err != nil {
 		blog.ErrorJSON("checkUpdate error. data:%s, cond:%s, rid:%s", data, cond, kit.Rid)
 		return cnt, err
@@ -242,6 +345,13 @@ func (m *modelAttribute) update(kit *rest.Kit, data mapstr.MapStr, cond universa
 		return 0, err
 	}
 
+	// encrypt or decrypt the saved values of the attributes whose sensitive flag is changed
+	for _, attr := range sensitiveChanged {
+		if err := m.migrateSensitiveValues(kit, attr); err != nil {
+			return 0, err
+		}
+	}
+
 	return cnt, err
 }
 
@@ -610,6 +720,12 @@ func (m *modelAttribute) saveCheck(kit *rest.Kit, attribute metadata.Attribute)
 		return err
 	}
 
+	if attribute.IsSensitive {
+		if err := m.checkSensitive(kit, attribute.PropertyType); err != nil {
+			return err
+		}
+	}
+
 	// check name duplicate
 	if err := m.checkUnique(kit, true, attribute.ObjectID, attribute.PropertyID, attribute.PropertyName, attribute.BizID); err != nil {
 		blog.ErrorJSON("save attribute check unique err:%s, input:%s, rid:%s", err.Error(), attribute, kit.Rid)
@@ -619,17 +735,18 @@ func (m *modelAttribute) saveCheck(kit *rest.Kit, attribute metadata.Attribute)
 	return nil
 }
 
-// checkUpdate 删除不可以更新字段，检验字段是否重复， 返回更新的行数，错误
-func (m *modelAttribute) checkUpdate(kit *rest.Kit, data mapstr.MapStr, cond universalsql.Condition) (changeRow uint64, err error) {
+// checkUpdate 删除不可以更新字段，检验字段是否重复， 返回更新的行数，敏感标识变化的属性，错误
+func (m *modelAttribute) checkUpdate(kit *rest.Kit, data mapstr.MapStr, cond universalsql.Condition) (changeRow uint64,
+	sensitiveChanged []metadata.Attribute, err error) {
 
 	dbAttributeArr, err := m.search(kit, cond)
 	if err != nil {
 		blog.Errorf("request(%s): find nothing by the condition(%#v)  error(%s)", kit.Rid, cond.ToMapStr(), err.Error())
-		return changeRow, err
+		return changeRow, nil, err
 	}
 	if 0 == len(dbAttributeArr) {
 		blog.Errorf("request(%s): find nothing by the condition(%#v)", kit.Rid, cond.ToMapStr())
-		return changeRow, nil
+		return changeRow, nil, nil
 	}
 
 	// 更新的属性是否存在预定义字段。
@@ -661,15 +778,28 @@ func (m *modelAttribute) checkUpdate(kit *rest.Kit, data mapstr.MapStr, cond uni
 		for _, dbAttribute := range dbAttributeArr {
 			if dbAttribute.PropertyType != propertyType {
 				blog.ErrorJSON("update option, but property type not the same, db attributes: %s, rid:%s", dbAttributeArr, kit.Ctx)
-				return changeRow, kit.CCError.Errorf(common.CCErrCommParamsInvalid, "cond")
+				return changeRow, nil, kit.CCError.Errorf(common.CCErrCommParamsInvalid, "cond")
 			}
 		}
 		if err := util.ValidPropertyOption(propertyType, option, kit.CCError); err != nil {
 			blog.ErrorJSON("valid property option failed, err: %s, data: %s, rid:%s", err, data, kit.Ctx)
-			return changeRow, err
+			return changeRow, nil, err
+		}
+		if propertyType == common.FieldTypeComputed {
+			for _, dbAttribute := range dbAttributeArr {
+				if err := m.checkComputedOption(kit, dbAttribute.ObjectID, option); err != nil {
+					return changeRow, nil, err
+				}
+			}
 		}
 	}
 
+	// computed attribute's value is maintained by the system, it can not be edited or required.
+	if dbAttributeArr[0].PropertyType == common.FieldTypeComputed {
+		data.Remove(metadata.AttributeFieldIsEditable)
+		data.Remove(metadata.AttributeFieldIsRequired)
+	}
+
 	// 删除不可更新字段， 避免由于传入数据，修改字段
 	// TODO: 改成白名单方式
 	data.Remove(metadata.AttributeFieldPropertyID)
@@ -698,36 +828,41 @@ func (m *modelAttribute) checkUpdate(kit *rest.Kit, data mapstr.MapStr, cond uni
 		cnt, err := mongodb.Client().Table(common.BKTableNamePropertyGroup).Find(cond).Count(kit.Ctx)
 		if err != nil {
 			blog.ErrorJSON("property group count failed, err: %s, condition: %s, rid: %s", err, cond, kit.Rid)
-			return changeRow, err
+			return changeRow, nil, err
 		}
 		if cnt != uint64(len(objIDs)) {
 			blog.Errorf("property group invalid, objIDs: %s have %d property groups, rid: %s", objIDs, cnt, kit.Rid)
-			return changeRow, kit.CCError.Errorf(common.CCErrCommParamsInvalid, metadata.AttributeFieldPropertyGroup)
+			return changeRow, nil, kit.CCError.Errorf(common.CCErrCommParamsInvalid, metadata.AttributeFieldPropertyGroup)
 		}
 	}
 
 	attribute := metadata.Attribute{}
 	if err = data.MarshalJSONInto(&attribute); err != nil {
 		blog.Errorf("request(%s): MarshalJSONInto(%#v), error is %v", kit.Rid, data, err)
-		return changeRow, err
+		return changeRow, nil, err
 	}
 
 	if err = m.checkAttributeValidity(kit, attribute); err != nil {
-		return changeRow, err
+		return changeRow, nil, err
+	}
+
+	sensitiveChanged, err = m.checkUpdateSensitive(kit, data, dbAttributeArr)
+	if err != nil {
+		return changeRow, nil, err
 	}
 
 	for _, dbAttribute := range dbAttributeArr {
 		err = m.checkUnique(kit, false, dbAttribute.ObjectID, dbAttribute.PropertyID, attribute.PropertyName, attribute.BizID)
 		if err != nil {
 			blog.ErrorJSON("save attribute check unique err:%s, input:%s, rid:%s", err.Error(), attribute, kit.Rid)
-			return changeRow, err
+			return changeRow, nil, err
 		}
 		if err = m.checkChangeField(kit, dbAttribute, data); err != nil {
-			return changeRow, err
+			return changeRow, nil, err
 		}
 	}
 
-	return uint64(len(dbAttributeArr)), err
+	return uint64(len(dbAttributeArr)), sensitiveChanged, err
 
 }
 
@@ -0,0 +1,856 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/expression"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+const (
+	// schemaMigrationBatchSize the count of instances converted in a migration step.
+	schemaMigrationBatchSize = 200
+	// schemaPlanSampleLimit the max count of the samples and the unconvertible values in a plan.
+	schemaPlanSampleLimit = 20
+)
+
+// PlanModelAttributeSchemaChange checks the schema change and converts all the instances' values of the
+// attribute without saving them, so that the user can see what will happen before the migration.
+func (m *modelAttribute) PlanModelAttributeSchemaChange(kit *rest.Kit, objID string,
+	change metadata.AttributeSchemaChange) (*metadata.AttributeSchemaChangePlan, error) {
+
+	origin, target, err := m.buildSchemaChangeTarget(kit, objID, change)
+	if err != nil {
+		return nil, err
+	}
+
+	converter, err := newAttrValueConverter(kit.Ctx, *origin, *target, change.EnumMapping)
+	if err != nil {
+		blog.Errorf("create attribute %s value converter failed, err: %v, rid: %s", origin.PropertyID, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaChangeNotAllowed, origin.PropertyID, err.Error())
+	}
+
+	plan := &metadata.AttributeSchemaChangePlan{
+		Origin:        *origin,
+		Target:        *target,
+		Samples:       make([]metadata.AttributeValueConversion, 0),
+		Unconvertible: make([]metadata.AttributeValueConversion, 0),
+	}
+
+	idField := common.GetInstIDField(objID)
+	err = m.walkInstances(kit, objID, []string{idField, origin.PropertyID}, func(insts []mapstr.MapStr) error {
+		for _, inst := range insts {
+			plan.InstanceCount++
+			instID, _ := util.GetInt64ByInterface(inst[idField])
+			from := inst[origin.PropertyID]
+			to, err := converter.convert(kit.Ctx, from)
+			if err != nil {
+				plan.UnconvertibleCount++
+				if len(plan.Unconvertible) < schemaPlanSampleLimit {
+					plan.Unconvertible = append(plan.Unconvertible, metadata.AttributeValueConversion{
+						InstID: instID, From: from, Reason: err.Error()})
+				}
+				continue
+			}
+
+			if isSameAttrValue(from, to) && origin.PropertyID == target.PropertyID {
+				continue
+			}
+
+			plan.ChangedCount++
+			if len(plan.Samples) < schemaPlanSampleLimit {
+				plan.Samples = append(plan.Samples, metadata.AttributeValueConversion{InstID: instID, From: from, To: to})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// CreateModelAttributeSchemaMigration creates a migration of the schema change, the migration is executed in
+// steps by the task server, the instances' values are converted in batches and then the attribute's
+// definition is changed in the finish step.
+func (m *modelAttribute) CreateModelAttributeSchemaMigration(kit *rest.Kit, objID string,
+	change metadata.AttributeSchemaChange) (*metadata.AttributeSchemaMigrationTask, error) {
+
+	plan, err := m.PlanModelAttributeSchemaChange(kit, objID, change)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.UnconvertibleCount > 0 && !change.Force {
+		blog.Errorf("%d values of attribute %s can not be converted, rid: %s", plan.UnconvertibleCount,
+			plan.Origin.PropertyID, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaValueUnconvertible, plan.UnconvertibleCount,
+			plan.Origin.PropertyID)
+	}
+
+	if err := m.checkNoRunningSchemaMigration(kit, objID, plan.Origin.PropertyID, plan.Target.PropertyID); err != nil {
+		return nil, err
+	}
+
+	id, err := mongodb.Client().NextSequence(kit.Ctx, common.BKTableNameAttributeSchemaMigration)
+	if err != nil {
+		blog.Errorf("get attribute schema migration id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrObjectDBOpErrno)
+	}
+
+	now := time.Now()
+	migration := metadata.AttributeSchemaMigration{
+		MigrationID: strconv.FormatUint(id, 10),
+		ObjectID:    objID,
+		OwnerID:     kit.SupplierAccount,
+		Change:      change,
+		Origin:      plan.Origin,
+		Target:      plan.Target,
+		Status:      metadata.AttributeSchemaMigrating,
+		Creator:     kit.User,
+		CreateTime:  now,
+		LastTime:    now,
+	}
+
+	if err := mongodb.Client().Table(common.BKTableNameAttributeSchemaMigration).Insert(kit.Ctx, migration); err != nil {
+		blog.Errorf("create attribute schema migration %#v failed, err: %v, rid: %s", migration, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	instIDs, err := m.getInstIDs(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := buildSchemaMigrationSteps(migration, metadata.AttributeSchemaStepConvert, instIDs,
+		metadata.AttributeSchemaStepFinish)
+	return &metadata.AttributeSchemaMigrationTask{Migration: migration, Steps: steps}, nil
+}
+
+// RollbackModelAttributeSchemaMigration starts to roll back a migrated or a failed migration, the instances'
+// values are restored from the backup in batches and then the attribute's definition is reverted.
+func (m *modelAttribute) RollbackModelAttributeSchemaMigration(kit *rest.Kit,
+	migrationID string) (*metadata.AttributeSchemaMigrationTask, error) {
+
+	migration, err := m.getSchemaMigration(kit, migrationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if migration.Status != metadata.AttributeSchemaMigrated && migration.Status != metadata.AttributeSchemaMigrating {
+		blog.Errorf("attribute schema migration %s status is %s, can not roll back, rid: %s", migrationID,
+			migration.Status, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaMigrationStatusInvalid, migrationID,
+			migration.Status)
+	}
+
+	// the attribute may have been changed by a later migration, only the latest one can be rolled back.
+	if migration.Status == metadata.AttributeSchemaMigrated {
+		if err := m.checkNoRunningSchemaMigration(kit, migration.ObjectID, migration.Target.PropertyID,
+			migration.Origin.PropertyID); err != nil {
+			return nil, err
+		}
+
+		cond := map[string]interface{}{common.BKFieldID: migration.Target.ID}
+		current := new(metadata.Attribute)
+		if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).One(kit.Ctx, current); err != nil {
+			blog.Errorf("get attribute %d failed, err: %v, rid: %s", migration.Target.ID, err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		if current.PropertyID != migration.Target.PropertyID || current.PropertyType != migration.Target.PropertyType {
+			return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaChangeNotAllowed, current.PropertyID,
+				"changed by a later migration")
+		}
+	}
+
+	if err := m.updateSchemaMigrationStatus(kit, migration, metadata.AttributeSchemaRollingBack); err != nil {
+		return nil, err
+	}
+
+	instIDs, err := m.getBackupInstIDs(kit, migrationID)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := buildSchemaMigrationSteps(*migration, metadata.AttributeSchemaStepRestore, instIDs,
+		metadata.AttributeSchemaStepRevert)
+	return &metadata.AttributeSchemaMigrationTask{Migration: *migration, Steps: steps}, nil
+}
+
+// ExecuteModelAttributeSchemaMigrationStep executes a step of the migration or the roll back,
+// all the steps can be executed repeatedly, so that a failed task can be retried.
+func (m *modelAttribute) ExecuteModelAttributeSchemaMigrationStep(kit *rest.Kit,
+	step metadata.AttributeSchemaMigrationStep) error {
+
+	migration, err := m.getSchemaMigration(kit, step.MigrationID)
+	if err != nil {
+		return err
+	}
+
+	switch step.Step {
+	case metadata.AttributeSchemaStepConvert, metadata.AttributeSchemaStepFinish:
+		if migration.Status == metadata.AttributeSchemaMigrated && step.Step == metadata.AttributeSchemaStepFinish {
+			return nil
+		}
+		if migration.Status != metadata.AttributeSchemaMigrating {
+			return kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaMigrationStatusInvalid, migration.MigrationID,
+				migration.Status)
+		}
+	case metadata.AttributeSchemaStepRestore, metadata.AttributeSchemaStepRevert:
+		if migration.Status == metadata.AttributeSchemaRolledBack && step.Step == metadata.AttributeSchemaStepRevert {
+			return nil
+		}
+		if migration.Status != metadata.AttributeSchemaRollingBack {
+			return kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaMigrationStatusInvalid, migration.MigrationID,
+				migration.Status)
+		}
+	default:
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "step")
+	}
+
+	switch step.Step {
+	case metadata.AttributeSchemaStepConvert:
+		return m.convertSchemaMigrationInsts(kit, migration, step.InstIDs)
+	case metadata.AttributeSchemaStepFinish:
+		return m.finishSchemaMigration(kit, migration)
+	case metadata.AttributeSchemaStepRestore:
+		return m.restoreSchemaMigrationInsts(kit, migration, step.InstIDs)
+	default:
+		return m.revertSchemaMigration(kit, migration)
+	}
+}
+
+// SearchModelAttributeSchemaMigrations search the attribute schema migrations, the latest is the first.
+func (m *modelAttribute) SearchModelAttributeSchemaMigrations(kit *rest.Kit,
+	opt metadata.SearchAttributeSchemaMigrationOption) ([]metadata.AttributeSchemaMigration, error) {
+
+	cond := make(map[string]interface{})
+	if opt.ObjectID != "" {
+		cond[common.BKObjIDField] = opt.ObjectID
+	}
+	if opt.MigrationID != "" {
+		cond["migration_id"] = opt.MigrationID
+	}
+	if opt.PropertyID != "" {
+		cond[common.BKDBOR] = []map[string]interface{}{
+			{"origin." + common.BKPropertyIDField: opt.PropertyID},
+			{"target." + common.BKPropertyIDField: opt.PropertyID},
+		}
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	migrations := make([]metadata.AttributeSchemaMigration, 0)
+	err := mongodb.Client().Table(common.BKTableNameAttributeSchemaMigration).Find(cond).
+		Sort("-"+common.CreateTimeField).All(kit.Ctx, &migrations)
+	if err != nil {
+		blog.Errorf("search attribute schema migrations failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return migrations, nil
+}
+
+// buildSchemaChangeTarget checks whether the schema change is allowed and returns the origin attribute and
+// the attribute after changed.
+func (m *modelAttribute) buildSchemaChangeTarget(kit *rest.Kit, objID string,
+	change metadata.AttributeSchemaChange) (*metadata.Attribute, *metadata.Attribute, error) {
+
+	if change.PropertyID == "" {
+		return nil, nil, kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, common.BKPropertyIDField)
+	}
+
+	if change.NewPropertyID == "" && change.NewPropertyType == "" && change.NewOption == nil &&
+		len(change.EnumMapping) == 0 {
+		return nil, nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "change")
+	}
+
+	if common.IsInnerModel(objID) {
+		return nil, nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaChangeNotAllowed, change.PropertyID,
+			"inner model")
+	}
+
+	cond := map[string]interface{}{
+		common.BKObjIDField:      objID,
+		common.BKPropertyIDField: change.PropertyID,
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	origin := new(metadata.Attribute)
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).One(kit.Ctx, origin); err != nil {
+		if mongodb.Client().IsNotFoundError(err) {
+			blog.Errorf("attribute %s of model %s is not exist, rid: %s", change.PropertyID, objID, kit.Rid)
+			return nil, nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, common.BKPropertyIDField)
+		}
+		blog.Errorf("get attribute %s of model %s failed, err: %v, rid: %s", change.PropertyID, objID, err, kit.Rid)
+		return nil, nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if origin.IsPre {
+		return nil, nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaChangeNotAllowed, origin.PropertyID,
+			"preset attribute")
+	}
+
+	if !schemaChangeableTypes[origin.PropertyType] {
+		return nil, nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaChangeNotAllowed, origin.PropertyID,
+			fmt.Sprintf("%s attribute", origin.PropertyType))
+	}
+
+	target := *origin
+	if change.NewPropertyType != "" {
+		target.PropertyType = change.NewPropertyType
+		target.Option = nil
+	}
+	if change.NewOption != nil {
+		target.Option = change.NewOption
+	}
+
+	if !schemaChangeableTypes[target.PropertyType] {
+		return nil, nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaChangeNotAllowed, origin.PropertyID,
+			fmt.Sprintf("can not change to %s attribute", target.PropertyType))
+	}
+
+	if err := util.ValidPropertyOption(target.PropertyType, target.Option, kit.CCError); err != nil {
+		return nil, nil, err
+	}
+
+	if change.NewPropertyID != "" && change.NewPropertyID != origin.PropertyID {
+		target.PropertyID = change.NewPropertyID
+		if err := m.checkAttributeValidity(kit, target); err != nil {
+			return nil, nil, err
+		}
+
+		cond := map[string]interface{}{
+			common.BKObjIDField:      objID,
+			common.BKPropertyIDField: target.PropertyID,
+		}
+		cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+		cnt, err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count attribute %s of model %s failed, err: %v, rid: %s", target.PropertyID, objID, err, kit.Rid)
+			return nil, nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		if cnt > 0 {
+			return nil, nil, kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, target.PropertyID)
+		}
+	}
+
+	if target.PropertyID != origin.PropertyID || target.PropertyType != origin.PropertyType {
+		inUnique, err := m.checkAttributeInUnique(kit, map[string][]int64{objID: {origin.ID}})
+		if err != nil {
+			return nil, nil, err
+		}
+		if inUnique {
+			return nil, nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaChangeNotAllowed, origin.PropertyID,
+				"used by unique rules")
+		}
+
+		if err := m.checkAttrNotComputed(kit, *origin); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return origin, &target, nil
+}
+
+// checkAttrNotComputed check the attribute is not used by the computed attributes, the rules of
+// the computed attributes will be broken if the attribute's id or type is changed.
+func (m *modelAttribute) checkAttrNotComputed(kit *rest.Kit, attr metadata.Attribute) error {
+	cond := map[string]interface{}{common.BKPropertyTypeField: common.FieldTypeComputed}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	computedAttrs := make([]metadata.Attribute, 0)
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &computedAttrs); err != nil {
+		blog.Errorf("get computed attributes failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	for _, computedAttr := range computedAttrs {
+		option, err := metadata.ParseComputedOption(kit.Ctx, computedAttr.Option)
+		if err != nil {
+			continue
+		}
+
+		used := false
+		switch option.Type {
+		case metadata.ComputeTypeExpression:
+			if computedAttr.ObjectID != attr.ObjectID {
+				continue
+			}
+			exp, err := expression.Parse(option.Expression)
+			if err != nil {
+				continue
+			}
+			used = util.InStrArr(exp.Fields(), attr.PropertyID)
+		case metadata.ComputeTypeAggregation:
+			used = option.AsstObjID == attr.ObjectID && option.Field == attr.PropertyID
+		}
+
+		if used {
+			return kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaChangeNotAllowed, attr.PropertyID,
+				fmt.Sprintf("used by computed attribute %s of model %s", computedAttr.PropertyID, computedAttr.ObjectID))
+		}
+	}
+	return nil
+}
+
+func (m *modelAttribute) checkNoRunningSchemaMigration(kit *rest.Kit, objID string, propertyIDs ...string) error {
+	cond := map[string]interface{}{
+		common.BKObjIDField: objID,
+		"status": map[string]interface{}{common.BKDBIN: []metadata.AttributeSchemaMigrationStatus{
+			metadata.AttributeSchemaMigrating, metadata.AttributeSchemaRollingBack}},
+		common.BKDBOR: []map[string]interface{}{
+			{"origin." + common.BKPropertyIDField: map[string]interface{}{common.BKDBIN: propertyIDs}},
+			{"target." + common.BKPropertyIDField: map[string]interface{}{common.BKDBIN: propertyIDs}},
+		},
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	cnt, err := mongodb.Client().Table(common.BKTableNameAttributeSchemaMigration).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count running attribute schema migrations failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if cnt > 0 {
+		return kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaMigrationRunning, propertyIDs[0])
+	}
+	return nil
+}
+
+func (m *modelAttribute) getSchemaMigration(kit *rest.Kit, migrationID string) (*metadata.AttributeSchemaMigration, error) {
+	cond := map[string]interface{}{"migration_id": migrationID}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	migration := new(metadata.AttributeSchemaMigration)
+	if err := mongodb.Client().Table(common.BKTableNameAttributeSchemaMigration).Find(cond).One(kit.Ctx, migration); err != nil {
+		if mongodb.Client().IsNotFoundError(err) {
+			return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaMigrationNotExist, migrationID)
+		}
+		blog.Errorf("get attribute schema migration %s failed, err: %v, rid: %s", migrationID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return migration, nil
+}
+
+func (m *modelAttribute) updateSchemaMigrationStatus(kit *rest.Kit, migration *metadata.AttributeSchemaMigration,
+	status metadata.AttributeSchemaMigrationStatus) error {
+
+	now := time.Now()
+	cond := map[string]interface{}{"migration_id": migration.MigrationID}
+	data := map[string]interface{}{
+		"status":             status,
+		common.LastTimeField: now,
+	}
+	if status == metadata.AttributeSchemaMigrated {
+		data["finish_time"] = now
+		migration.FinishTime = &now
+	}
+	if err := mongodb.Client().Table(common.BKTableNameAttributeSchemaMigration).Update(kit.Ctx, cond, data); err != nil {
+		blog.Errorf("update attribute schema migration %s status to %s failed, err: %v, rid: %s",
+			migration.MigrationID, status, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	migration.Status = status
+	return nil
+}
+
+// convertSchemaMigrationInsts converts the values of the instances and saves the original values into backup.
+// an instance whose source value is not changed since the last conversion is skipped, so the step can be rerun,
+// and the instances changed during the migration can be converted again.
+func (m *modelAttribute) convertSchemaMigrationInsts(kit *rest.Kit, migration *metadata.AttributeSchemaMigration,
+	instIDs []int64) error {
+
+	if len(instIDs) == 0 {
+		return nil
+	}
+
+	idField := common.GetInstIDField(migration.ObjectID)
+	cond := map[string]interface{}{idField: map[string]interface{}{common.BKDBIN: instIDs}}
+	insts := make([]mapstr.MapStr, 0)
+	err := mongodb.Client().Table(common.GetInstTableName(migration.ObjectID)).Find(cond).
+		Fields(idField, migration.Origin.PropertyID, migration.Target.PropertyID).All(kit.Ctx, &insts)
+	if err != nil {
+		blog.Errorf("get instances %v of model %s failed, err: %v, rid: %s", instIDs, migration.ObjectID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return m.convertInsts(kit, migration, insts)
+}
+
+func (m *modelAttribute) convertInsts(kit *rest.Kit, migration *metadata.AttributeSchemaMigration,
+	insts []mapstr.MapStr) error {
+
+	converter, err := newAttrValueConverter(kit.Ctx, migration.Origin, migration.Target, migration.Change.EnumMapping)
+	if err != nil {
+		blog.Errorf("create attribute value converter failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaChangeNotAllowed, migration.Origin.PropertyID,
+			err.Error())
+	}
+
+	idField := common.GetInstIDField(migration.ObjectID)
+	instIDs := make([]int64, 0, len(insts))
+	for _, inst := range insts {
+		instID, _ := util.GetInt64ByInterface(inst[idField])
+		instIDs = append(instIDs, instID)
+	}
+
+	backups, err := m.getSchemaBackups(kit, migration.MigrationID, instIDs)
+	if err != nil {
+		return err
+	}
+
+	renamed := migration.Origin.PropertyID != migration.Target.PropertyID
+	for idx, inst := range insts {
+		instID := instIDs[idx]
+		source := inst[migration.Origin.PropertyID]
+
+		backup, hasBackup := backups[instID]
+		if hasBackup {
+			if renamed && isSameAttrValue(source, backup.Value) {
+				continue
+			}
+			if !renamed {
+				// the unconvertible value is cleared when the migration is forced.
+				converted, err := converter.convert(kit.Ctx, backup.Value)
+				if (err == nil || migration.Change.Force) && isSameAttrValue(source, converted) {
+					continue
+				}
+			}
+		}
+
+		// the source value is not converted yet, back it up before it's overwritten.
+		backupCond := map[string]interface{}{"migration_id": migration.MigrationID, common.BKInstIDField: instID}
+		backupDoc := metadata.AttributeSchemaBackup{MigrationID: migration.MigrationID, InstID: instID, Value: source}
+		if err := mongodb.Client().Table(common.BKTableNameAttributeSchemaBackup).Upsert(kit.Ctx, backupCond,
+			backupDoc); err != nil {
+			blog.Errorf("backup instance %d value failed, err: %v, rid: %s", instID, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+		}
+
+		converted, err := converter.convert(kit.Ctx, source)
+		if err != nil {
+			if !migration.Change.Force {
+				blog.Errorf("convert instance %d value %v failed, err: %v, rid: %s", instID, source, err, kit.Rid)
+				return kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaValueUnconvertible, 1,
+					migration.Origin.PropertyID)
+			}
+			blog.Warnf("convert instance %d value %v failed, clear it, err: %v, rid: %s", instID, source, err, kit.Rid)
+			converted = nil
+		}
+
+		cond := map[string]interface{}{idField: instID}
+		data := map[string]interface{}{migration.Target.PropertyID: converted}
+		if err := mongodb.Client().Table(common.GetInstTableName(migration.ObjectID)).Update(kit.Ctx, cond,
+			data); err != nil {
+			blog.Errorf("update instance %d value failed, err: %v, rid: %s", instID, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+		}
+	}
+	return nil
+}
+
+// finishSchemaMigration converts the instances which are changed or failed to be converted during the
+// migration, then changes the attribute's definition and removes the old field if the attribute is renamed.
+// the task server executes all the steps even if some of them failed, so all the instances are checked here.
+func (m *modelAttribute) finishSchemaMigration(kit *rest.Kit, migration *metadata.AttributeSchemaMigration) error {
+	fields := []string{common.GetInstIDField(migration.ObjectID), migration.Origin.PropertyID,
+		migration.Target.PropertyID}
+	err := m.walkInstances(kit, migration.ObjectID, fields, func(insts []mapstr.MapStr) error {
+		return m.convertInsts(kit, migration, insts)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := m.changeAttributeSchema(kit, migration.Origin, migration.Target); err != nil {
+		return err
+	}
+
+	return m.updateSchemaMigrationStatus(kit, migration, metadata.AttributeSchemaMigrated)
+}
+
+// restoreSchemaMigrationInsts restores the original values of the instances from the backup.
+func (m *modelAttribute) restoreSchemaMigrationInsts(kit *rest.Kit, migration *metadata.AttributeSchemaMigration,
+	instIDs []int64) error {
+
+	backups, err := m.getSchemaBackups(kit, migration.MigrationID, instIDs)
+	if err != nil {
+		return err
+	}
+
+	idField := common.GetInstIDField(migration.ObjectID)
+	table := common.GetInstTableName(migration.ObjectID)
+	for _, backup := range backups {
+		cond := map[string]interface{}{idField: backup.InstID}
+		data := map[string]interface{}{migration.Origin.PropertyID: backup.Value}
+		if err := mongodb.Client().Table(table).Update(kit.Ctx, cond, data); err != nil {
+			blog.Errorf("restore instance %d value failed, err: %v, rid: %s", backup.InstID, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+		}
+	}
+
+	if migration.Origin.PropertyID == migration.Target.PropertyID || len(instIDs) == 0 {
+		return nil
+	}
+
+	cond := map[string]interface{}{idField: map[string]interface{}{common.BKDBIN: instIDs}}
+	if err := mongodb.Client().Table(table).DropColumns(kit.Ctx, cond, []string{migration.Target.PropertyID}); err != nil {
+		blog.Errorf("drop instances %v field %s failed, err: %v, rid: %s", instIDs, migration.Target.PropertyID,
+			err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// revertSchemaMigration converts the values of the instances created after the migration back, because they
+// have no backup, then reverts the attribute's definition and removes the backup.
+// if the migration is not finished, the attribute's definition is not changed, nothing need to be reverted.
+func (m *modelAttribute) revertSchemaMigration(kit *rest.Kit, migration *metadata.AttributeSchemaMigration) error {
+	if migration.FinishTime != nil {
+		if err := m.revertFinishedSchemaMigration(kit, migration); err != nil {
+			return err
+		}
+	}
+
+	if err := m.updateSchemaMigrationStatus(kit, migration, metadata.AttributeSchemaRolledBack); err != nil {
+		return err
+	}
+
+	backupCond := map[string]interface{}{"migration_id": migration.MigrationID}
+	if err := mongodb.Client().Table(common.BKTableNameAttributeSchemaBackup).Delete(kit.Ctx, backupCond); err != nil {
+		// the backup is useless now, do not fail the roll back.
+		blog.Errorf("delete attribute schema migration %s backup failed, err: %v, rid: %s", migration.MigrationID,
+			err, kit.Rid)
+	}
+	return nil
+}
+
+func (m *modelAttribute) revertFinishedSchemaMigration(kit *rest.Kit, migration *metadata.AttributeSchemaMigration) error {
+	reverseMapping := make(map[string]string)
+	for from, to := range migration.Change.EnumMapping {
+		reverseMapping[to] = from
+	}
+	converter, err := newAttrValueConverter(kit.Ctx, migration.Target, migration.Origin, reverseMapping)
+	if err != nil {
+		blog.Errorf("create attribute value converter failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCoreServiceAttrSchemaChangeNotAllowed, migration.Target.PropertyID,
+			err.Error())
+	}
+
+	idField := common.GetInstIDField(migration.ObjectID)
+	table := common.GetInstTableName(migration.ObjectID)
+	cond := map[string]interface{}{
+		common.LastTimeField: map[string]interface{}{common.BKDBGTE: migration.CreateTime},
+	}
+	err = m.walkInstancesByCond(kit, migration.ObjectID, cond, []string{idField, migration.Target.PropertyID},
+		func(insts []mapstr.MapStr) error {
+			instIDs := make([]int64, 0, len(insts))
+			for _, inst := range insts {
+				instID, _ := util.GetInt64ByInterface(inst[idField])
+				instIDs = append(instIDs, instID)
+			}
+
+			backups, err := m.getSchemaBackups(kit, migration.MigrationID, instIDs)
+			if err != nil {
+				return err
+			}
+
+			for idx, inst := range insts {
+				if _, exist := backups[instIDs[idx]]; exist {
+					continue
+				}
+
+				value, err := converter.convert(kit.Ctx, inst[migration.Target.PropertyID])
+				if err != nil {
+					blog.Warnf("convert instance %d value %v back failed, clear it, err: %v, rid: %s", instIDs[idx],
+						inst[migration.Target.PropertyID], err, kit.Rid)
+					value = nil
+				}
+
+				updateCond := map[string]interface{}{idField: instIDs[idx]}
+				data := map[string]interface{}{migration.Origin.PropertyID: value}
+				if err := mongodb.Client().Table(table).Update(kit.Ctx, updateCond, data); err != nil {
+					blog.Errorf("revert instance %d value failed, err: %v, rid: %s", instIDs[idx], err, kit.Rid)
+					return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	return m.changeAttributeSchema(kit, migration.Target, migration.Origin)
+}
+
+// changeAttributeSchema changes the attribute's definition from the current to the next, the current field
+// of the instances is removed if the attribute is renamed.
+func (m *modelAttribute) changeAttributeSchema(kit *rest.Kit, current, next metadata.Attribute) error {
+	cond := map[string]interface{}{common.BKFieldID: current.ID}
+	data := map[string]interface{}{
+		common.BKPropertyIDField:      next.PropertyID,
+		common.BKPropertyTypeField:    next.PropertyType,
+		metadata.AttributeFieldOption: next.Option,
+		common.LastTimeField:          time.Now(),
+	}
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Update(kit.Ctx, cond, data); err != nil {
+		blog.Errorf("change attribute %d schema failed, data: %#v, err: %v, rid: %s", current.ID, data, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	if current.PropertyID == next.PropertyID {
+		return nil
+	}
+
+	instCond := map[string]interface{}{common.BKObjIDField: current.ObjectID}
+	instCond = util.SetQueryOwner(instCond, current.OwnerID)
+	if err := mongodb.Client().Table(common.GetInstTableName(current.ObjectID)).DropColumns(kit.Ctx, instCond,
+		[]string{current.PropertyID}); err != nil {
+		blog.Errorf("drop model %s instances field %s failed, err: %v, rid: %s", current.ObjectID, current.PropertyID,
+			err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+func (m *modelAttribute) getSchemaBackups(kit *rest.Kit, migrationID string,
+	instIDs []int64) (map[int64]metadata.AttributeSchemaBackup, error) {
+
+	cond := map[string]interface{}{
+		"migration_id":       migrationID,
+		common.BKInstIDField: map[string]interface{}{common.BKDBIN: instIDs},
+	}
+	backups := make([]metadata.AttributeSchemaBackup, 0)
+	if err := mongodb.Client().Table(common.BKTableNameAttributeSchemaBackup).Find(cond).All(kit.Ctx, &backups); err != nil {
+		blog.Errorf("get attribute schema migration %s backup failed, err: %v, rid: %s", migrationID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	backupMap := make(map[int64]metadata.AttributeSchemaBackup, len(backups))
+	for _, backup := range backups {
+		backupMap[backup.InstID] = backup
+	}
+	return backupMap, nil
+}
+
+func (m *modelAttribute) getBackupInstIDs(kit *rest.Kit, migrationID string) ([]int64, error) {
+	cond := map[string]interface{}{"migration_id": migrationID}
+	ids, err := mongodb.Client().Table(common.BKTableNameAttributeSchemaBackup).Distinct(kit.Ctx,
+		common.BKInstIDField, cond)
+	if err != nil {
+		blog.Errorf("get attribute schema migration %s backup instances failed, err: %v, rid: %s", migrationID,
+			err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	instIDs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		instID, err := util.GetInt64ByInterface(id)
+		if err != nil {
+			continue
+		}
+		instIDs = append(instIDs, instID)
+	}
+	return instIDs, nil
+}
+
+func (m *modelAttribute) getInstIDs(kit *rest.Kit, objID string) ([]int64, error) {
+	idField := common.GetInstIDField(objID)
+	instIDs := make([]int64, 0)
+	err := m.walkInstances(kit, objID, []string{idField}, func(insts []mapstr.MapStr) error {
+		for _, inst := range insts {
+			instID, _ := util.GetInt64ByInterface(inst[idField])
+			instIDs = append(instIDs, instID)
+		}
+		return nil
+	})
+	return instIDs, err
+}
+
+func (m *modelAttribute) walkInstances(kit *rest.Kit, objID string, fields []string,
+	handler func(insts []mapstr.MapStr) error) error {
+	return m.walkInstancesByCond(kit, objID, map[string]interface{}{}, fields, handler)
+}
+
+// walkInstancesByCond walks the instances of the model in pages which are sorted by the instance id.
+func (m *modelAttribute) walkInstancesByCond(kit *rest.Kit, objID string, cond map[string]interface{},
+	fields []string, handler func(insts []mapstr.MapStr) error) error {
+
+	cond[common.BKObjIDField] = objID
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	idField := common.GetInstIDField(objID)
+	table := common.GetInstTableName(objID)
+	for start := uint64(0); ; start += schemaMigrationBatchSize {
+		insts := make([]mapstr.MapStr, 0)
+		err := mongodb.Client().Table(table).Find(cond).Fields(fields...).Sort(idField).Start(start).
+			Limit(schemaMigrationBatchSize).All(kit.Ctx, &insts)
+		if err != nil {
+			blog.Errorf("get model %s instances failed, cond: %#v, err: %v, rid: %s", objID, cond, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		if len(insts) == 0 {
+			return nil
+		}
+
+		if err := handler(insts); err != nil {
+			return err
+		}
+
+		if len(insts) < schemaMigrationBatchSize {
+			return nil
+		}
+	}
+}
+
+// buildSchemaMigrationSteps split the instances into batches, each batch is a step, and then the last step.
+func buildSchemaMigrationSteps(migration metadata.AttributeSchemaMigration, batchStep string, instIDs []int64,
+	lastStep string) []metadata.AttributeSchemaMigrationStep {
+
+	steps := make([]metadata.AttributeSchemaMigrationStep, 0)
+	for start := 0; start < len(instIDs); start += schemaMigrationBatchSize {
+		end := start + schemaMigrationBatchSize
+		if end > len(instIDs) {
+			end = len(instIDs)
+		}
+		steps = append(steps, metadata.AttributeSchemaMigrationStep{
+			MigrationID: migration.MigrationID,
+			ObjectID:    migration.ObjectID,
+			Step:        batchStep,
+			InstIDs:     instIDs[start:end],
+		})
+	}
+
+	steps = append(steps, metadata.AttributeSchemaMigrationStep{
+		MigrationID: migration.MigrationID,
+		ObjectID:    migration.ObjectID,
+		Step:        lastStep,
+	})
+	return steps
+}
+
+// isSameAttrValue compare the values by their text form, because the same value may have different types
+// after it's read from db, such as int32 and int64, []string and primitive.A.
+func isSameAttrValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+}
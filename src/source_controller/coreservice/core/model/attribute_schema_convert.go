@@ -0,0 +1,331 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/expression"
+	"configcenter/src/common/metadata"
+)
+
+// schemaChangeableTypes the property types which can be the origin or the target of a schema change.
+var schemaChangeableTypes = map[string]bool{
+	common.FieldTypeSingleChar: true,
+	common.FieldTypeLongChar:   true,
+	common.FieldTypeInt:        true,
+	common.FieldTypeFloat:      true,
+	common.FieldTypeEnum:       true,
+	common.FieldTypeEnumMulti:  true,
+	common.FieldTypeBool:       true,
+	common.FieldTypeList:       true,
+	common.FieldTypeDate:       true,
+	common.FieldTypeTime:       true,
+	common.FieldTypeTimeZone:   true,
+	common.FieldTypeUser:       true,
+	common.FieldTypeIP:         true,
+	common.FieldTypeURL:        true,
+}
+
+// attrValueConverter convert the values of the origin attribute to the values of the target attribute.
+type attrValueConverter struct {
+	origin metadata.Attribute
+	target metadata.Attribute
+	// enumMapping remap the origin enum id to the target enum id.
+	enumMapping map[string]string
+	// originEnum the origin enum options, key is the enum id.
+	originEnum map[string]metadata.EnumVal
+	// targetEnum the target enum options.
+	targetEnum metadata.EnumOption
+}
+
+func newAttrValueConverter(ctx context.Context, origin, target metadata.Attribute,
+	enumMapping map[string]string) (*attrValueConverter, error) {
+
+	c := &attrValueConverter{
+		origin:      origin,
+		target:      target,
+		enumMapping: enumMapping,
+		originEnum:  make(map[string]metadata.EnumVal),
+	}
+
+	if isEnumType(origin.PropertyType) {
+		options, err := metadata.ParseEnumOption(ctx, origin.Option)
+		if err != nil {
+			return nil, fmt.Errorf("parse origin enum option failed, %v", err)
+		}
+		for _, option := range options {
+			c.originEnum[option.ID] = option
+		}
+	}
+
+	if isEnumType(target.PropertyType) {
+		options, err := metadata.ParseEnumOption(ctx, target.Option)
+		if err != nil {
+			return nil, fmt.Errorf("parse target enum option failed, %v", err)
+		}
+		c.targetEnum = options
+	}
+
+	return c, nil
+}
+
+func isEnumType(propertyType string) bool {
+	return propertyType == common.FieldTypeEnum || propertyType == common.FieldTypeEnumMulti
+}
+
+// convert the value, the converted value is validated by the target attribute.
+// nil or empty values are always converted to nil.
+func (c *attrValueConverter) convert(ctx context.Context, val interface{}) (interface{}, error) {
+	if val == nil {
+		return nil, nil
+	}
+
+	if s, ok := val.(string); ok && strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var result interface{}
+	var err error
+	switch c.target.PropertyType {
+	case common.FieldTypeInt:
+		result, err = c.toInt(val)
+	case common.FieldTypeFloat:
+		result, err = c.toFloat(val)
+	case common.FieldTypeBool:
+		result, err = c.toBool(val)
+	case common.FieldTypeEnum:
+		result, err = c.toEnum(val)
+	case common.FieldTypeEnumMulti:
+		result, err = c.toEnumMulti(val)
+	default:
+		result, err = c.toString(val)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if rawErr := c.target.Validate(ctx, result, c.target.PropertyID); rawErr.ErrCode != 0 {
+		return nil, fmt.Errorf("value %v is invalid for the %s attribute", result, c.target.PropertyType)
+	}
+	return result, nil
+}
+
+// texts returns the text form of the value, the enum ids are converted to the enum names.
+func (c *attrValueConverter) texts(val interface{}) ([]string, error) {
+	switch c.origin.PropertyType {
+	case common.FieldTypeEnum, common.FieldTypeEnumMulti:
+		ids, err := c.enumIDs(val)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(ids))
+		for idx, id := range ids {
+			option, exist := c.originEnum[id]
+			if !exist {
+				return nil, fmt.Errorf("enum id %s is not exist", id)
+			}
+			names[idx] = option.Name
+		}
+		return names, nil
+	}
+
+	switch v := val.(type) {
+	case string:
+		return []string{strings.TrimSpace(v)}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case float32, float64:
+		f, _ := expression.ToFloat(v)
+		return []string{strconv.FormatFloat(f, 'f', -1, 64)}, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return []string{fmt.Sprintf("%d", v)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", val)
+	}
+}
+
+// enumIDs returns the enum ids of an enum or multi-select enum value.
+func (c *attrValueConverter) enumIDs(val interface{}) ([]string, error) {
+	if c.origin.PropertyType == common.FieldTypeEnum {
+		id, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("enum value %v is not string", val)
+		}
+		return []string{id}, nil
+	}
+	return metadata.ParseEnumMultiValue(val)
+}
+
+func (c *attrValueConverter) toString(val interface{}) (interface{}, error) {
+	texts, err := c.texts(val)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Join(texts, ","), nil
+}
+
+func (c *attrValueConverter) toFloat(val interface{}) (interface{}, error) {
+	if _, ok := val.(bool); ok {
+		return nil, errors.New("bool value can not be converted to number")
+	}
+
+	if isEnumType(c.origin.PropertyType) {
+		texts, err := c.texts(val)
+		if err != nil {
+			return nil, err
+		}
+		if len(texts) != 1 {
+			return nil, errors.New("multiple enum values can not be converted to number")
+		}
+		val = texts[0]
+	}
+
+	f, err := expression.ToFloat(val)
+	if err != nil {
+		return nil, fmt.Errorf("%v is not a number", val)
+	}
+	return f, nil
+}
+
+func (c *attrValueConverter) toInt(val interface{}) (interface{}, error) {
+	f, err := c.toFloat(val)
+	if err != nil {
+		return nil, err
+	}
+
+	value := f.(float64)
+	if value != math.Trunc(value) {
+		return nil, fmt.Errorf("%v is not an integer", val)
+	}
+	if value > float64(common.MaxInt64) || value < float64(common.MinInt64) {
+		return nil, fmt.Errorf("%v is out of range", val)
+	}
+	return int64(value), nil
+}
+
+func (c *attrValueConverter) toBool(val interface{}) (interface{}, error) {
+	switch v := val.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a bool", v)
+		}
+		return b, nil
+	}
+
+	f, err := expression.ToFloat(val)
+	if err != nil || (f != 0 && f != 1) {
+		return nil, fmt.Errorf("%v is not a bool", val)
+	}
+	return f == 1, nil
+}
+
+// matchTargetEnum match the target enum id of the origin value, the origin enum id is remapped by the
+// enum mapping, other values are matched by the target enum id and then by the target enum name.
+func (c *attrValueConverter) matchTargetEnums(val interface{}) ([]string, error) {
+	var values []string
+	if isEnumType(c.origin.PropertyType) {
+		ids, err := c.enumIDs(val)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if mapped, exist := c.enumMapping[id]; exist {
+				id = mapped
+			}
+			values = append(values, id)
+		}
+	} else {
+		texts, err := c.texts(val)
+		if err != nil {
+			return nil, err
+		}
+		for _, text := range texts {
+			if c.target.PropertyType == common.FieldTypeEnumMulti {
+				for _, item := range strings.Split(text, ",") {
+					values = append(values, strings.TrimSpace(item))
+				}
+				continue
+			}
+			values = append(values, text)
+		}
+	}
+
+	ids := make([]string, 0, len(values))
+	exists := make(map[string]bool)
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		id, matched := c.matchTargetEnum(value)
+		if !matched {
+			return nil, fmt.Errorf("%s does not match any enum option", value)
+		}
+		if !exists[id] {
+			exists[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (c *attrValueConverter) matchTargetEnum(value string) (string, bool) {
+	for _, option := range c.targetEnum {
+		if option.ID == value {
+			return option.ID, true
+		}
+	}
+
+	for _, option := range c.targetEnum {
+		if option.Name == value {
+			return option.ID, true
+		}
+	}
+	return "", false
+}
+
+func (c *attrValueConverter) toEnum(val interface{}) (interface{}, error) {
+	ids, err := c.matchTargetEnums(val)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(ids) {
+	case 0:
+		return nil, nil
+	case 1:
+		return ids[0], nil
+	default:
+		return nil, errors.New("multiple enum values can not be converted to a single enum")
+	}
+}
+
+func (c *attrValueConverter) toEnumMulti(val interface{}) (interface{}, error) {
+	ids, err := c.matchTargetEnums(val)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return ids, nil
+}
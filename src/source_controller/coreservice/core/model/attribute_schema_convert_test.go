@@ -0,0 +1,197 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func enumOption(ids ...string) []interface{} {
+	option := make([]interface{}, 0)
+	for _, id := range ids {
+		option = append(option, map[string]interface{}{"id": id, "name": "name_" + id, "type": "text"})
+	}
+	return option
+}
+
+func TestAttrValueConvert(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		origin  metadata.Attribute
+		target  metadata.Attribute
+		mapping map[string]string
+		value   interface{}
+		expect  interface{}
+		wantErr bool
+	}{
+		{
+			name:   "char to int",
+			origin: metadata.Attribute{PropertyType: common.FieldTypeSingleChar},
+			target: metadata.Attribute{PropertyType: common.FieldTypeInt},
+			value:  " 12 ",
+			expect: int64(12),
+		},
+		{
+			name:    "char to int not number",
+			origin:  metadata.Attribute{PropertyType: common.FieldTypeSingleChar},
+			target:  metadata.Attribute{PropertyType: common.FieldTypeInt},
+			value:   "abc",
+			wantErr: true,
+		},
+		{
+			name:    "float to int with fraction",
+			origin:  metadata.Attribute{PropertyType: common.FieldTypeFloat},
+			target:  metadata.Attribute{PropertyType: common.FieldTypeInt},
+			value:   1.5,
+			wantErr: true,
+		},
+		{
+			name:   "int to char",
+			origin: metadata.Attribute{PropertyType: common.FieldTypeInt},
+			target: metadata.Attribute{PropertyType: common.FieldTypeSingleChar},
+			value:  int64(3),
+			expect: "3",
+		},
+		{
+			name:   "empty value",
+			origin: metadata.Attribute{PropertyType: common.FieldTypeSingleChar},
+			target: metadata.Attribute{PropertyType: common.FieldTypeInt},
+			value:  "",
+			expect: nil,
+		},
+		{
+			name:   "char to bool",
+			origin: metadata.Attribute{PropertyType: common.FieldTypeSingleChar},
+			target: metadata.Attribute{PropertyType: common.FieldTypeBool},
+			value:  "true",
+			expect: true,
+		},
+		{
+			name:   "enum to char",
+			origin: metadata.Attribute{PropertyType: common.FieldTypeEnum, Option: enumOption("a", "b")},
+			target: metadata.Attribute{PropertyType: common.FieldTypeSingleChar},
+			value:  "b",
+			expect: "name_b",
+		},
+		{
+			name:   "char to enum by name",
+			origin: metadata.Attribute{PropertyType: common.FieldTypeSingleChar},
+			target: metadata.Attribute{PropertyType: common.FieldTypeEnum, Option: enumOption("a", "b")},
+			value:  "name_a",
+			expect: "a",
+		},
+		{
+			name:    "char to enum not matched",
+			origin:  metadata.Attribute{PropertyType: common.FieldTypeSingleChar},
+			target:  metadata.Attribute{PropertyType: common.FieldTypeEnum, Option: enumOption("a", "b")},
+			value:   "c",
+			wantErr: true,
+		},
+		{
+			name:    "enum remap",
+			origin:  metadata.Attribute{PropertyType: common.FieldTypeEnum, Option: enumOption("a", "b")},
+			target:  metadata.Attribute{PropertyType: common.FieldTypeEnum, Option: enumOption("x", "y")},
+			mapping: map[string]string{"a": "x", "b": "y"},
+			value:   "b",
+			expect:  "y",
+		},
+		{
+			name:   "enum to multi-select enum",
+			origin: metadata.Attribute{PropertyType: common.FieldTypeEnum, Option: enumOption("a", "b")},
+			target: metadata.Attribute{PropertyType: common.FieldTypeEnumMulti, Option: enumOption("a", "b")},
+			value:  "a",
+			expect: []string{"a"},
+		},
+		{
+			name:   "char to multi-select enum",
+			origin: metadata.Attribute{PropertyType: common.FieldTypeLongChar},
+			target: metadata.Attribute{PropertyType: common.FieldTypeEnumMulti, Option: enumOption("a", "b")},
+			value:  "a, name_b,a",
+			expect: []string{"a", "b"},
+		},
+		{
+			name:    "multi-select enum to enum",
+			origin:  metadata.Attribute{PropertyType: common.FieldTypeEnumMulti, Option: enumOption("a", "b")},
+			target:  metadata.Attribute{PropertyType: common.FieldTypeEnum, Option: enumOption("a", "b")},
+			value:   []interface{}{"a", "b"},
+			wantErr: true,
+		},
+		{
+			name:   "multi-select enum to char",
+			origin: metadata.Attribute{PropertyType: common.FieldTypeEnumMulti, Option: enumOption("a", "b")},
+			target: metadata.Attribute{PropertyType: common.FieldTypeLongChar},
+			value:  []interface{}{"a", "b"},
+			expect: "name_a,name_b",
+		},
+		{
+			name:    "int out of option range",
+			origin:  metadata.Attribute{PropertyType: common.FieldTypeSingleChar},
+			target:  metadata.Attribute{PropertyType: common.FieldTypeInt, Option: map[string]interface{}{"min": "1", "max": "10"}},
+			value:   "11",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		converter, err := newAttrValueConverter(ctx, test.origin, test.target, test.mapping)
+		if err != nil {
+			t.Fatalf("%s: create converter failed, err: %v", test.name, err)
+		}
+
+		result, err := converter.convert(ctx, test.value)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expect error, but got %#v", test.name, result)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: convert failed, err: %v", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(result, test.expect) {
+			t.Errorf("%s: expect %#v, but got %#v", test.name, test.expect, result)
+		}
+	}
+}
+
+func TestBuildSchemaMigrationSteps(t *testing.T) {
+	migration := metadata.AttributeSchemaMigration{MigrationID: "1", ObjectID: "switch"}
+	instIDs := make([]int64, schemaMigrationBatchSize+1)
+	for idx := range instIDs {
+		instIDs[idx] = int64(idx + 1)
+	}
+
+	steps := buildSchemaMigrationSteps(migration, metadata.AttributeSchemaStepConvert, instIDs,
+		metadata.AttributeSchemaStepFinish)
+	if len(steps) != 3 {
+		t.Fatalf("expect 3 steps, but got %d", len(steps))
+	}
+
+	if len(steps[0].InstIDs) != schemaMigrationBatchSize || len(steps[1].InstIDs) != 1 {
+		t.Errorf("instances are not split into batches correctly, got %d and %d", len(steps[0].InstIDs),
+			len(steps[1].InstIDs))
+	}
+
+	if steps[2].Step != metadata.AttributeSchemaStepFinish || len(steps[2].InstIDs) != 0 {
+		t.Errorf("the last step should be finish without instances, got %#v", steps[2])
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/sensitive"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// checkSensitive checks the attribute can be sensitive, only the char attributes can be encrypted,
+// and the secret provider must be configured.
+func (m *modelAttribute) checkSensitive(kit *rest.Kit, propertyType string) error {
+	if !sensitive.Enabled() {
+		blog.Errorf("sensitive attribute encryption is not enabled, rid: %s", kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldIsSensitive)
+	}
+
+	switch propertyType {
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar:
+		return nil
+	default:
+		blog.Errorf("attribute of type %s can not be sensitive, rid: %s", propertyType, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldIsSensitive)
+	}
+}
+
+// checkUpdateSensitive checks the change of the attributes' sensitive flag, returns the attributes whose
+// instance values need to be encrypted or decrypted after the attributes are updated.
+func (m *modelAttribute) checkUpdateSensitive(kit *rest.Kit, data mapstr.MapStr, dbAttrs []metadata.Attribute) (
+	[]metadata.Attribute, error) {
+
+	val, exists := data.Get(metadata.AttributeFieldIsSensitive)
+	if !exists {
+		return nil, nil
+	}
+
+	isSensitive, ok := val.(bool)
+	if !ok {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldIsSensitive)
+	}
+
+	changed := make([]metadata.Attribute, 0)
+	for _, attr := range dbAttrs {
+		if attr.IsSensitive == isSensitive {
+			continue
+		}
+
+		if isSensitive {
+			if err := m.checkSensitive(kit, attr.PropertyType); err != nil {
+				return nil, err
+			}
+
+			inUnique, err := m.checkAttributeInUnique(kit, map[string][]int64{attr.ObjectID: {attr.ID}})
+			if err != nil {
+				return nil, err
+			}
+			if inUnique {
+				blog.Errorf("attribute %s of %s is in unique, can not be sensitive, rid: %s", attr.PropertyID,
+					attr.ObjectID, kit.Rid)
+				return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldIsSensitive)
+			}
+		}
+
+		attr.IsSensitive = isSensitive
+		changed = append(changed, attr)
+	}
+	return changed, nil
+}
+
+// migrateSensitiveValues encrypts the plain values of the attribute in all the instances when it becomes
+// sensitive, or decrypts the encrypted values when it is no longer sensitive.
+func (m *modelAttribute) migrateSensitiveValues(kit *rest.Kit, attr metadata.Attribute) error {
+	cond := map[string]interface{}{
+		attr.PropertyID: map[string]interface{}{common.BKDBNIN: []interface{}{nil, ""}},
+	}
+	if !util.IsInnerObject(attr.ObjectID) {
+		cond[common.BKObjIDField] = attr.ObjectID
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	idField := common.GetInstIDField(attr.ObjectID)
+	table := common.GetInstTableName(attr.ObjectID)
+	fields := []string{idField, attr.PropertyID, common.BKOwnerIDField}
+	for start := uint64(0); ; start += schemaMigrationBatchSize {
+		insts := make([]mapstr.MapStr, 0)
+		err := mongodb.Client().Table(table).Find(cond).Fields(fields...).Sort(idField).Start(start).
+			Limit(schemaMigrationBatchSize).All(kit.Ctx, &insts)
+		if err != nil {
+			blog.Errorf("get model %s instances failed, cond: %#v, err: %v, rid: %s", attr.ObjectID, cond, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		for _, inst := range insts {
+			if err := m.migrateSensitiveValue(kit, attr, inst); err != nil {
+				return err
+			}
+		}
+
+		if len(insts) < schemaMigrationBatchSize {
+			return nil
+		}
+	}
+}
+
+// migrateSensitiveValue encrypts or decrypts the attribute value of one instance
+func (m *modelAttribute) migrateSensitiveValue(kit *rest.Kit, attr metadata.Attribute, inst mapstr.MapStr) error {
+	idField := common.GetInstIDField(attr.ObjectID)
+	instID, err := util.GetInt64ByInterface(inst[idField])
+	if err != nil {
+		blog.Errorf("get model %s instance id failed, err: %v, inst: %v, rid: %s", attr.ObjectID, err, inst, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, idField)
+	}
+
+	val := inst[attr.PropertyID]
+	if attr.IsSensitive == sensitive.IsEncrypted(val) {
+		return nil
+	}
+
+	var newVal interface{}
+	if attr.IsSensitive {
+		ownerID := util.GetStrByInterface(inst[common.BKOwnerIDField])
+		name := sensitive.SecretName(ownerID, attr.ObjectID, instID, attr.PropertyID)
+		newVal, err = sensitive.Encrypt(kit.Ctx, name, val)
+	} else {
+		newVal, err = sensitive.Decrypt(kit.Ctx, val)
+	}
+	if err != nil {
+		blog.Errorf("migrate model %s instance %d sensitive field %s failed, err: %v, rid: %s", attr.ObjectID,
+			instID, attr.PropertyID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommInternalServerError)
+	}
+
+	cond := map[string]interface{}{idField: instID}
+	data := map[string]interface{}{attr.PropertyID: newVal}
+	if err := mongodb.Client().Table(common.GetInstTableName(attr.ObjectID)).Update(kit.Ctx, cond, data); err != nil {
+		blog.Errorf("update model %s instance %d sensitive field %s failed, err: %v, rid: %s", attr.ObjectID,
+			instID, attr.PropertyID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	if !attr.IsSensitive {
+		if err := sensitive.Delete(kit.Ctx, val); err != nil {
+			blog.Errorf("delete model %s instance %d sensitive field %s secret failed, err: %v, rid: %s",
+				attr.ObjectID, instID, attr.PropertyID, err, kit.Rid)
+		}
+	}
+	return nil
+}
@@ -258,6 +258,14 @@ func (m *modelAttrUnique) getUniqueProperties(kit *rest.Kit, objID string, keys
 		return nil, kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, "keys")
 	}
 
+	// the sensitive attributes are encrypted with random nonces, so they can not be compared by unique check
+	for _, property := range properties {
+		if property.IsSensitive {
+			blog.Errorf("[ObjectUnique] getUniqueProperties key %s of %s is sensitive, rid: %s", property.PropertyID, objID, kit.Rid)
+			return nil, kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, "keys")
+		}
+	}
+
 	return properties, nil
 }
 
@@ -412,6 +420,12 @@ func getBasicDataType(propertyType string) (interface{}, error) {
 		return nil, nil
 	case common.FieldTypeOrganization:
 		return nil, nil
+	case common.FieldTypeIP, common.FieldTypeURL:
+		return "", nil
+	case common.FieldTypeEnumMulti:
+		return nil, nil
+	case common.FieldTypeInstRef:
+		return 0, nil
 	default:
 		return nil, fmt.Errorf("unsupported type: %s", propertyType)
 	}
@@ -0,0 +1,212 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// aggregationGroup is a group of the aggregation pipeline's result, the _id is the values of the group by fields.
+type aggregationGroup struct {
+	ID    map[string]interface{} `bson:"_id"`
+	Count int64                  `bson:"count"`
+	Value interface{}            `bson:"value"`
+}
+
+// AggregationChartData aggregates the instances of the chart's model which match the filter, the instances are
+// grouped by the group by fields, and the enum values of the groups are translated to their names.
+func (m *operationManager) AggregationChartData(kit *rest.Kit, chart metadata.ChartConfig) (
+	*metadata.ChartAggregationData, error) {
+
+	if field, err := chart.ValidateAggregation(); err != nil {
+		blog.Errorf("aggregation chart %s is invalid, field: %s, err: %v, rid: %s", chart.Name, field, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	ownerID := aggregationOwner(kit, chart)
+	fields := make([]string, 0)
+	fields = append(fields, chart.GroupBy...)
+	if chart.AggregateField != "" {
+		fields = append(fields, chart.AggregateField)
+	}
+	attributes := make([]metadata.Attribute, 0)
+	attrCond := map[string]interface{}{
+		common.BKObjIDField:      chart.ObjID,
+		common.BKPropertyIDField: map[string]interface{}{common.BKDBIN: fields},
+	}
+	attrCond = util.SetQueryOwner(attrCond, ownerID)
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(attrCond).All(kit.Ctx, &attributes); err != nil {
+		blog.Errorf("search aggregation chart attributes failed, chart: %s, err: %v, rid: %s", chart.Name, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if field, err := chart.ValidateAggregationFields(attributes); err != nil {
+		blog.Errorf("aggregation chart %s is invalid, field: %s, err: %v, rid: %s", chart.Name, field, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	pipeline, err := buildAggregationPipeline(chart, attributes, ownerID)
+	if err != nil {
+		blog.Errorf("build aggregation chart %s pipeline failed, err: %v, rid: %s", chart.Name, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "filter")
+	}
+
+	groups := make([]aggregationGroup, 0)
+	table := common.GetInstTableName(chart.ObjID)
+	if err := mongodb.Client().Table(table).AggregateAll(kit.Ctx, pipeline, &groups); err != nil {
+		blog.Errorf("aggregate chart %s data failed, pipeline: %#v, err: %v, rid: %s", chart.Name, pipeline, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrOperationGetChartDataFail)
+	}
+
+	enumNames := make(map[string]map[string]string)
+	for _, attr := range attributes {
+		if attr.PropertyType != common.FieldTypeEnum && attr.PropertyType != common.FieldTypeEnumMulti {
+			continue
+		}
+		options, err := metadata.ParseEnumOption(kit.Ctx, attr.Option)
+		if err != nil {
+			blog.Errorf("parse attribute %s enum option failed, err: %v, rid: %s", attr.PropertyID, err, kit.Rid)
+			return nil, err
+		}
+		enumNames[attr.PropertyID] = make(map[string]string)
+		for _, option := range options {
+			enumNames[attr.PropertyID][option.ID] = option.Name
+		}
+	}
+
+	return convertAggregationGroups(chart, groups, enumNames), nil
+}
+
+// aggregationOwner returns the owner whose instances are aggregated, the superadmin, like the chart metrics
+// refresher, aggregates the instances of the chart's owner, the others can only aggregate their own instances.
+func aggregationOwner(kit *rest.Kit, chart metadata.ChartConfig) string {
+	if kit.SupplierAccount == common.BKSuperOwnerID && chart.OwnerID != "" {
+		return chart.OwnerID
+	}
+	return kit.SupplierAccount
+}
+
+// buildAggregationPipeline builds the pipeline to aggregate the owner's instances of the chart's model, the list
+// and multi-select enum fields are unwound so that the instance is counted in each group of it's values.
+func buildAggregationPipeline(chart metadata.ChartConfig, attributes []metadata.Attribute, ownerID string) (
+	[]M, error) {
+
+	filters := make([]map[string]interface{}, 0)
+	if ownerCond := util.SetModOwner(nil, ownerID); len(ownerCond) > 0 {
+		filters = append(filters, ownerCond)
+	}
+	if common.GetInstTableName(chart.ObjID) == common.BKTableNameBaseInst {
+		filters = append(filters, map[string]interface{}{common.BKObjIDField: chart.ObjID})
+	}
+	if len(chart.Filter) > 0 {
+		rule, key, err := querybuilder.ParseRule(chart.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("parse filter failed, key: %s, err: %v", key, err)
+		}
+		filter, key, err := rule.ToMgo()
+		if err != nil {
+			return nil, fmt.Errorf("convert filter failed, key: %s, err: %v", key, err)
+		}
+		filters = append(filters, filter)
+	}
+
+	pipeline := make([]M, 0)
+	switch len(filters) {
+	case 0:
+	case 1:
+		pipeline = append(pipeline, M{common.BKDBMatch: filters[0]})
+	default:
+		pipeline = append(pipeline, M{common.BKDBMatch: M{common.BKDBAND: filters}})
+	}
+
+	attrTypes := make(map[string]string)
+	for _, attr := range attributes {
+		attrTypes[attr.PropertyID] = attr.PropertyType
+	}
+
+	groupID := M{}
+	for idx, field := range chart.GroupBy {
+		if attrTypes[field] == common.FieldTypeList || attrTypes[field] == common.FieldTypeEnumMulti {
+			pipeline = append(pipeline, M{"$unwind": M{"path": "$" + field, "preserveNullAndEmptyArrays": true}})
+		}
+		groupID[aggregationGroupKey(idx)] = "$" + field
+	}
+
+	group := M{"_id": groupID, "count": M{common.BKDBSum: 1}}
+	switch chart.Aggregation {
+	case metadata.ChartAggregationSum:
+		group["value"] = M{common.BKDBSum: "$" + chart.AggregateField}
+	case metadata.ChartAggregationAvg:
+		group["value"] = M{"$avg": "$" + chart.AggregateField}
+	}
+	pipeline = append(pipeline, M{common.BKDBGroup: group})
+
+	return pipeline, nil
+}
+
+func aggregationGroupKey(idx int) string {
+	return fmt.Sprintf("g%d", idx)
+}
+
+// convertAggregationGroups converts the aggregation result to the chart data, the items are sorted by their keys.
+func convertAggregationGroups(chart metadata.ChartConfig, groups []aggregationGroup,
+	enumNames map[string]map[string]string) *metadata.ChartAggregationData {
+
+	aggregation := chart.Aggregation
+	if aggregation == "" {
+		aggregation = metadata.ChartAggregationCount
+	}
+
+	data := &metadata.ChartAggregationData{
+		GroupBy:     chart.GroupBy,
+		Aggregation: aggregation,
+		Items:       make([]metadata.ChartAggregationItem, 0),
+	}
+
+	for _, group := range groups {
+		item := metadata.ChartAggregationItem{
+			Keys:  make([]string, len(chart.GroupBy)),
+			Count: group.Count,
+			Value: float64(group.Count),
+		}
+		for idx, field := range chart.GroupBy {
+			value, exist := group.ID[aggregationGroupKey(idx)]
+			if !exist || value == nil {
+				continue
+			}
+			item.Keys[idx] = util.GetStrByInterface(value)
+			if name, ok := enumNames[field][item.Keys[idx]]; ok {
+				item.Keys[idx] = name
+			}
+		}
+		if aggregation != metadata.ChartAggregationCount {
+			// the value is null if none of the instances in the group has the field.
+			item.Value, _ = util.GetFloat64ByInterface(group.Value)
+		}
+		data.Items = append(data.Items, item)
+	}
+
+	sort.Slice(data.Items, func(i, j int) bool {
+		return strings.Join(data.Items[i].Keys, "\x00") < strings.Join(data.Items[j].Keys, "\x00")
+	})
+	return data
+}
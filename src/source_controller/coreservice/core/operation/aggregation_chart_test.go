@@ -0,0 +1,114 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func TestBuildAggregationPipeline(t *testing.T) {
+	chart := metadata.ChartConfig{
+		ObjID: "switch",
+		Filter: map[string]interface{}{
+			"field":    "vendor",
+			"operator": "equal",
+			"value":    "cisco",
+		},
+		GroupBy:        []string{"region", "tags"},
+		Aggregation:    metadata.ChartAggregationSum,
+		AggregateField: "ports",
+	}
+	attributes := []metadata.Attribute{
+		{PropertyID: "region", PropertyType: common.FieldTypeEnum},
+		{PropertyID: "tags", PropertyType: common.FieldTypeEnumMulti},
+		{PropertyID: "ports", PropertyType: common.FieldTypeInt},
+	}
+
+	pipeline, err := buildAggregationPipeline(chart, attributes, "0")
+	if err != nil {
+		t.Fatalf("build pipeline failed, err: %v", err)
+	}
+
+	want := []M{
+		{common.BKDBMatch: M{common.BKDBAND: []map[string]interface{}{
+			{common.BKOwnerIDField: "0"},
+			{common.BKObjIDField: "switch"},
+			{"vendor": map[string]interface{}{common.BKDBEQ: "cisco"}},
+		}}},
+		{"$unwind": M{"path": "$tags", "preserveNullAndEmptyArrays": true}},
+		{common.BKDBGroup: M{
+			"_id":   M{"g0": "$region", "g1": "$tags"},
+			"count": M{common.BKDBSum: 1},
+			"value": M{common.BKDBSum: "$ports"},
+		}},
+	}
+	if !reflect.DeepEqual(pipeline, want) {
+		t.Errorf("pipeline = %#v, want %#v", pipeline, want)
+	}
+
+	host := metadata.ChartConfig{ObjID: common.BKInnerObjIDHost, GroupBy: []string{"bk_os_type"}}
+	pipeline, err = buildAggregationPipeline(host, nil, "1")
+	if err != nil {
+		t.Fatalf("build pipeline failed, err: %v", err)
+	}
+	want = []M{
+		{common.BKDBMatch: map[string]interface{}{common.BKOwnerIDField: "1"}},
+		{common.BKDBGroup: M{"_id": M{"g0": "$bk_os_type"}, "count": M{common.BKDBSum: 1}}},
+	}
+	if !reflect.DeepEqual(pipeline, want) {
+		t.Errorf("pipeline = %#v, want %#v", pipeline, want)
+	}
+
+	// the superadmin's pipeline is not scoped to an owner.
+	pipeline, err = buildAggregationPipeline(host, nil, common.BKSuperOwnerID)
+	if err != nil {
+		t.Fatalf("build pipeline failed, err: %v", err)
+	}
+	want = []M{{common.BKDBGroup: M{"_id": M{"g0": "$bk_os_type"}, "count": M{common.BKDBSum: 1}}}}
+	if !reflect.DeepEqual(pipeline, want) {
+		t.Errorf("pipeline = %#v, want %#v", pipeline, want)
+	}
+}
+
+func TestConvertAggregationGroups(t *testing.T) {
+	chart := metadata.ChartConfig{
+		ObjID:          "switch",
+		GroupBy:        []string{"region"},
+		Aggregation:    metadata.ChartAggregationAvg,
+		AggregateField: "ports",
+	}
+	groups := []aggregationGroup{
+		{ID: map[string]interface{}{"g0": "sz"}, Count: 2, Value: 24.5},
+		{ID: map[string]interface{}{}, Count: 1, Value: nil},
+		{ID: map[string]interface{}{"g0": "bj"}, Count: 3, Value: int32(48)},
+	}
+	enumNames := map[string]map[string]string{"region": {"bj": "Beijing", "sz": "Shenzhen"}}
+
+	data := convertAggregationGroups(chart, groups, enumNames)
+	want := &metadata.ChartAggregationData{
+		GroupBy:     []string{"region"},
+		Aggregation: metadata.ChartAggregationAvg,
+		Items: []metadata.ChartAggregationItem{
+			{Keys: []string{""}, Count: 1, Value: 0},
+			{Keys: []string{"Beijing"}, Count: 3, Value: 48},
+			{Keys: []string{"Shenzhen"}, Count: 2, Value: 24.5},
+		},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("data = %#v, want %#v", data, want)
+	}
+}
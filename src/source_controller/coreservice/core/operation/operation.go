@@ -60,6 +60,12 @@ func (m *operationManager) SearchChartData(kit *rest.Kit, inputParam metadata.Ch
 			return nil, err
 		}
 		return data, nil
+	case common.OperationAggregation:
+		data, err := m.AggregationChartData(kit, inputParam)
+		if err != nil {
+			return nil, err
+		}
+		return data, nil
 	default:
 		data, err := m.CommonModelStatistic(kit, inputParam)
 		if err != nil {
@@ -0,0 +1,808 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// snapshotPageSize the number of the instances read at one time when taking the instance snapshot
+const snapshotPageSize = 1000
+
+// snapshotChunkSize the max number of the sets, modules, hosts or instances stored in a chunk of the snapshot
+const snapshotChunkSize = 500
+
+// snapshotBaseDays the days after which a new base instance snapshot is taken, the instance snapshots taken
+// in between only store the diff since the previous one, so a snapshot is rebuilt from at most this many snapshots.
+const snapshotBaseDays = 7
+
+// snapshotIgnoredFields the fields which are not stored in the snapshot's instance, they change without
+// the instance's attributes being changed.
+var snapshotIgnoredFields = []string{"_id", common.BKOwnerIDField, common.CreateTimeField, common.LastTimeField}
+
+// TakeTopoSnapshot takes today's snapshot of the business's topology, the snapshot taken earlier today is replaced.
+func (m *operationManager) TakeTopoSnapshot(kit *rest.Kit, bizID int64) error {
+	biz := mapstr.MapStr{}
+	bizCond := util.SetModOwner(mapstr.MapStr{common.BKAppIDField: bizID}, kit.SupplierAccount)
+	err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(bizCond).
+		Fields(common.BKAppNameField, common.BKOwnerIDField).One(kit.Ctx, &biz)
+	if err != nil {
+		if mongodb.Client().IsNotFoundError(err) {
+			blog.Errorf("take topo snapshot failed, business %d not exist, rid: %s", bizID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+		}
+		blog.Errorf("search business %d failed, err: %v, rid: %s", bizID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	// the snapshot belongs to the business's owner, the snapshot timer takes it as the superadmin.
+	snapshot := metadata.TopoSnapshot{
+		BizID:      bizID,
+		BizName:    util.GetStrByInterface(biz[common.BKAppNameField]),
+		Date:       time.Now().Format(metadata.SnapshotDateLayout),
+		OwnerID:    util.GetStrByInterface(biz[common.BKOwnerIDField]),
+		CreateTime: time.Now(),
+	}
+
+	topoCond := mapstr.MapStr{common.BKAppIDField: bizID, common.BKOwnerIDField: snapshot.OwnerID}
+	snapshot.Sets, err = m.searchSnapshotNodes(kit, common.BKTableNameBaseSet, topoCond, common.BKSetIDField,
+		common.BKSetNameField, common.BKParentIDField)
+	if err != nil {
+		return err
+	}
+
+	snapshot.Modules, err = m.searchSnapshotNodes(kit, common.BKTableNameBaseModule, topoCond,
+		common.BKModuleIDField, common.BKModuleNameField, common.BKSetIDField)
+	if err != nil {
+		return err
+	}
+
+	snapshot.Hosts, err = m.searchSnapshotHosts(kit, topoCond)
+	if err != nil {
+		return err
+	}
+
+	cond := mapstr.MapStr{
+		common.BKOwnerIDField:      snapshot.OwnerID,
+		common.BKAppIDField:        bizID,
+		metadata.SnapshotDateField: snapshot.Date,
+	}
+	if err := m.clearSnapshotChunks(kit, common.BKTableNameTopoSnapshot, cond); err != nil {
+		return err
+	}
+
+	// the first chunk is saved at last, so the snapshot is searchable only after all its chunks are saved.
+	chunks := splitTopoSnapshot(&snapshot, snapshotChunkSize)
+	for i := 1; i < len(chunks); i++ {
+		if err := m.saveSnapshotChunk(kit, common.BKTableNameTopoSnapshot, cond, i, chunks[i]); err != nil {
+			return err
+		}
+	}
+	return m.saveSnapshotChunk(kit, common.BKTableNameTopoSnapshot, cond, 0, chunks[0])
+}
+
+// splitTopoSnapshot splits the topology snapshot into chunks, each of which has at most size sets, modules
+// and hosts in total. there is at least one chunk, the first chunk keeps the count of the chunks.
+func splitTopoSnapshot(snapshot *metadata.TopoSnapshot, size int) []metadata.TopoSnapshotChunk {
+	chunks := make([]metadata.TopoSnapshotChunk, 0)
+	newChunk := func() *metadata.TopoSnapshotChunk {
+		chunks = append(chunks, metadata.TopoSnapshotChunk{
+			BizID:      snapshot.BizID,
+			BizName:    snapshot.BizName,
+			Date:       snapshot.Date,
+			OwnerID:    snapshot.OwnerID,
+			Chunk:      len(chunks),
+			Sets:       make([]metadata.SnapshotNode, 0),
+			Modules:    make([]metadata.SnapshotNode, 0),
+			Hosts:      make([]metadata.SnapshotHost, 0),
+			CreateTime: snapshot.CreateTime,
+		})
+		return &chunks[len(chunks)-1]
+	}
+
+	chunk, count := newChunk(), 0
+	next := func() {
+		if count == size {
+			chunk, count = newChunk(), 0
+		}
+		count++
+	}
+	for _, set := range snapshot.Sets {
+		next()
+		chunk.Sets = append(chunk.Sets, set)
+	}
+	for _, module := range snapshot.Modules {
+		next()
+		chunk.Modules = append(chunk.Modules, module)
+	}
+	for _, host := range snapshot.Hosts {
+		next()
+		chunk.Hosts = append(chunk.Hosts, host)
+	}
+
+	chunks[0].ChunkCount = len(chunks)
+	return chunks
+}
+
+// searchSnapshotNodes searches the sets or modules of the business as the nodes of the topology snapshot.
+func (m *operationManager) searchSnapshotNodes(kit *rest.Kit, table string, cond mapstr.MapStr, idField,
+	nameField, parentField string) ([]metadata.SnapshotNode, error) {
+
+	docs := make([]mapstr.MapStr, 0)
+	err := mongodb.Client().Table(table).Find(cond).Fields(idField, nameField, parentField).All(kit.Ctx, &docs)
+	if err != nil {
+		blog.Errorf("search %s for topo snapshot failed, cond: %v, err: %v, rid: %s", table, cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	nodes := make([]metadata.SnapshotNode, 0)
+	for _, doc := range docs {
+		node := metadata.SnapshotNode{Name: util.GetStrByInterface(doc[nameField])}
+		if node.ID, err = util.GetInt64ByInterface(doc[idField]); err != nil {
+			blog.Errorf("parse %s %s failed, doc: %v, err: %v, rid: %s", table, idField, doc, err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommParseDBFailed)
+		}
+		if node.ParentID, err = util.GetInt64ByInterface(doc[parentField]); err != nil {
+			blog.Errorf("parse %s %s failed, doc: %v, err: %v, rid: %s", table, parentField, doc, err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommParseDBFailed)
+		}
+		nodes = append(nodes, node)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// searchSnapshotHosts searches the hosts of the business and the modules they belong to.
+func (m *operationManager) searchSnapshotHosts(kit *rest.Kit, cond mapstr.MapStr) ([]metadata.SnapshotHost, error) {
+	relations := make([]metadata.ModuleHost, 0)
+	err := mongodb.Client().Table(common.BKTableNameModuleHostConfig).Find(cond).
+		Fields(common.BKHostIDField, common.BKModuleIDField).All(kit.Ctx, &relations)
+	if err != nil {
+		blog.Errorf("search host relations for topo snapshot failed, cond: %v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	hostModules := make(map[int64][]int64)
+	hostIDs := make([]int64, 0)
+	for _, relation := range relations {
+		if _, exist := hostModules[relation.HostID]; !exist {
+			hostIDs = append(hostIDs, relation.HostID)
+		}
+		hostModules[relation.HostID] = append(hostModules[relation.HostID], relation.ModuleID)
+	}
+
+	hostIPs := make(map[int64]string)
+	for start := 0; start < len(hostIDs); start += snapshotPageSize {
+		end := start + snapshotPageSize
+		if end > len(hostIDs) {
+			end = len(hostIDs)
+		}
+
+		hosts := make([]mapstr.MapStr, 0)
+		hostCond := mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs[start:end]}}
+		err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(hostCond).
+			Fields(common.BKHostIDField, common.BKHostInnerIPField).All(kit.Ctx, &hosts)
+		if err != nil {
+			blog.Errorf("search hosts for topo snapshot failed, err: %v, rid: %s", err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		for _, host := range hosts {
+			hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+			if err != nil {
+				blog.Errorf("parse host id failed, host: %v, err: %v, rid: %s", host, err, kit.Rid)
+				return nil, kit.CCError.CCError(common.CCErrCommParseDBFailed)
+			}
+			hostIPs[hostID] = util.GetStrByInterface(host[common.BKHostInnerIPField])
+		}
+	}
+
+	sort.Slice(hostIDs, func(i, j int) bool { return hostIDs[i] < hostIDs[j] })
+	hosts := make([]metadata.SnapshotHost, 0)
+	for _, hostID := range hostIDs {
+		moduleIDs := hostModules[hostID]
+		sort.Slice(moduleIDs, func(i, j int) bool { return moduleIDs[i] < moduleIDs[j] })
+		hosts = append(hosts, metadata.SnapshotHost{ID: hostID, InnerIP: hostIPs[hostID], ModuleIDs: moduleIDs})
+	}
+	return hosts, nil
+}
+
+// TakeInstSnapshot takes today's snapshot of the model's instances, the instances are read page by page
+// in the order of their ids, and stored as a snapshot of each owner.
+func (m *operationManager) TakeInstSnapshot(kit *rest.Kit, objID string) error {
+	modelCond := mapstr.MapStr{common.BKObjIDField: objID}
+	count, err := mongodb.Client().Table(common.BKTableNameObjDes).Find(modelCond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("search model %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		blog.Errorf("take instance snapshot failed, model %s not exist, rid: %s", objID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+	}
+
+	date := time.Now().Format(metadata.SnapshotDateLayout)
+	clearCond := mapstr.MapStr{common.BKObjIDField: objID, metadata.SnapshotDateField: date}
+	clearCond = util.SetModOwner(clearCond, kit.SupplierAccount)
+	if err := m.clearSnapshotChunks(kit, common.BKTableNameInstSnapshot, clearCond); err != nil {
+		return err
+	}
+
+	// the chunks of each owner's snapshot are saved once they are full, except for the first one which
+	// is saved at last with the count of the chunks.
+	writers := make(map[string]*instSnapshotWriter)
+	getWriter := func(ownerID string) (*instSnapshotWriter, error) {
+		if writer, exist := writers[ownerID]; exist {
+			return writer, nil
+		}
+		writer, err := m.newInstSnapshotWriter(kit, objID, ownerID, date)
+		if err != nil {
+			return nil, err
+		}
+		writers[ownerID] = writer
+		return writer, nil
+	}
+	if kit.SupplierAccount != common.BKSuperOwnerID {
+		if _, err := getWriter(kit.SupplierAccount); err != nil {
+			return err
+		}
+	}
+
+	table := common.GetInstTableName(objID)
+	idField := common.GetInstIDField(objID)
+	nameField := common.GetInstNameField(objID)
+	if objID == common.BKInnerObjIDHost {
+		nameField = common.BKHostInnerIPField
+	}
+
+	lastID := int64(0)
+	for {
+		cond := mapstr.MapStr{idField: mapstr.MapStr{common.BKDBGT: lastID}}
+		if table == common.BKTableNameBaseInst {
+			cond[common.BKObjIDField] = objID
+		}
+		cond = util.SetModOwner(cond, kit.SupplierAccount)
+
+		insts := make([]mapstr.MapStr, 0)
+		err := mongodb.Client().Table(table).Find(cond).Sort(idField).Limit(snapshotPageSize).All(kit.Ctx, &insts)
+		if err != nil {
+			blog.Errorf("search model %s instances failed, err: %v, rid: %s", objID, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		for _, inst := range insts {
+			ownerID := util.GetStrByInterface(inst[common.BKOwnerIDField])
+			item, err := newSnapshotInst(inst, idField, nameField)
+			if err != nil {
+				blog.Errorf("convert model %s instance failed, inst: %v, err: %v, rid: %s", objID, inst, err, kit.Rid)
+				return kit.CCError.CCError(common.CCErrCommParseDBFailed)
+			}
+			writer, err := getWriter(ownerID)
+			if err != nil {
+				return err
+			}
+			if err := writer.add(kit, item); err != nil {
+				return err
+			}
+			lastID = item.ID
+		}
+
+		if len(insts) < snapshotPageSize {
+			break
+		}
+	}
+
+	for _, writer := range writers {
+		if err := writer.close(kit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// instSnapshotWriter writes the chunks of an owner's instance snapshot.
+type instSnapshotWriter struct {
+	m *operationManager
+	// cond is the condition of the owner's snapshot, without the chunk's index.
+	cond  mapstr.MapStr
+	chunk metadata.InstSnapshotChunk
+	// first is the first chunk which is held until all the other chunks are saved.
+	first *metadata.InstSnapshotChunk
+	// prev is the previous snapshot which the diff is taken from, it's nil if a base snapshot is written.
+	prev *instSnapshotState
+	// added is the ids of the instances added to the diff snapshot, the others in prev are removed.
+	added map[int64]struct{}
+}
+
+// newInstSnapshotWriter creates the writer of the owner's instance snapshot on the date. It writes a base
+// snapshot if there is no previous snapshot or the base of the previous one is taken snapshotBaseDays ago,
+// otherwise it writes the diff since the previous snapshot.
+func (m *operationManager) newInstSnapshotWriter(kit *rest.Kit, objID, ownerID, date string) (
+	*instSnapshotWriter, error) {
+
+	writer := &instSnapshotWriter{
+		m: m,
+		cond: mapstr.MapStr{
+			common.BKOwnerIDField:      ownerID,
+			common.BKObjIDField:        objID,
+			metadata.SnapshotDateField: date,
+		},
+		chunk: metadata.InstSnapshotChunk{
+			ObjID:      objID,
+			Date:       date,
+			OwnerID:    ownerID,
+			BaseDate:   date,
+			Instances:  make([]metadata.SnapshotInst, 0),
+			CreateTime: time.Now(),
+		},
+	}
+
+	// the snapshot of the date is cleared before, so the previous one is the latest snapshot.
+	cond := mapstr.MapStr{common.BKOwnerIDField: ownerID, common.BKObjIDField: objID}
+	prev, err := m.rebuildInstSnapshot(kit, cond, date)
+	if err != nil {
+		return nil, err
+	}
+	if prev == nil || isSnapshotBaseExpired(prev.header.BaseDate, date) {
+		return writer, nil
+	}
+
+	writer.chunk.BaseDate = prev.header.BaseDate
+	writer.prev = prev
+	writer.added = make(map[int64]struct{})
+	return writer, nil
+}
+
+// isSnapshotBaseExpired checks whether a new base snapshot should be taken on the date.
+func isSnapshotBaseExpired(baseDate, date string) bool {
+	base, err := time.Parse(metadata.SnapshotDateLayout, baseDate)
+	if err != nil {
+		return true
+	}
+	current, err := time.Parse(metadata.SnapshotDateLayout, date)
+	if err != nil {
+		return true
+	}
+	return !current.Before(base.AddDate(0, 0, snapshotBaseDays))
+}
+
+// add adds the instance to the snapshot, only the changes of the instance is added to the diff snapshot.
+// the current chunk is saved if it's full.
+func (w *instSnapshotWriter) add(kit *rest.Kit, inst metadata.SnapshotInst) error {
+	if w.prev != nil {
+		w.added[inst.ID] = struct{}{}
+		diff, changed := w.prev.diff(inst)
+		if !changed {
+			return nil
+		}
+		inst = diff
+	}
+
+	if err := w.tryFlush(kit); err != nil {
+		return err
+	}
+	w.chunk.Instances = append(w.chunk.Instances, inst)
+	return nil
+}
+
+// tryFlush saves the current chunk if it's full.
+func (w *instSnapshotWriter) tryFlush(kit *rest.Kit) error {
+	if len(w.chunk.Instances)+len(w.chunk.Removed) < snapshotChunkSize {
+		return nil
+	}
+	return w.flush(kit)
+}
+
+// flush saves the current chunk and starts the next one.
+func (w *instSnapshotWriter) flush(kit *rest.Kit) error {
+	chunk := w.chunk
+	w.chunk.Chunk++
+	w.chunk.Instances = make([]metadata.SnapshotInst, 0)
+	w.chunk.Removed = nil
+
+	if chunk.Chunk == 0 {
+		w.first = &chunk
+		return nil
+	}
+	return w.m.saveSnapshotChunk(kit, common.BKTableNameInstSnapshot, w.cond, chunk.Chunk, chunk)
+}
+
+// close saves the removed instances of the diff snapshot, the rest of the instances and the first chunk
+// with the count of the chunks.
+func (w *instSnapshotWriter) close(kit *rest.Kit) error {
+	if w.prev != nil {
+		for _, inst := range w.prev.instances() {
+			if _, exist := w.added[inst.ID]; exist {
+				continue
+			}
+			if err := w.tryFlush(kit); err != nil {
+				return err
+			}
+			w.chunk.Removed = append(w.chunk.Removed, inst.ID)
+		}
+	}
+
+	if w.first == nil || len(w.chunk.Instances) > 0 || len(w.chunk.Removed) > 0 {
+		if err := w.flush(kit); err != nil {
+			return err
+		}
+	}
+	w.first.ChunkCount = w.chunk.Chunk
+	return w.m.saveSnapshotChunk(kit, common.BKTableNameInstSnapshot, w.cond, 0, *w.first)
+}
+
+// instSnapshotState is the instances of an owner's snapshot with all their attributes, which is rebuilt
+// from the base snapshot and the diffs after it.
+type instSnapshotState struct {
+	// header is the header of the latest snapshot applied.
+	header snapshotChunkHeader
+	insts  map[int64]metadata.SnapshotInst
+}
+
+func newInstSnapshotState() *instSnapshotState {
+	return &instSnapshotState{insts: make(map[int64]metadata.SnapshotInst)}
+}
+
+// apply applies the chunk of a snapshot to the state, the instances of the diff only have the changed attributes.
+func (s *instSnapshotState) apply(chunk metadata.InstSnapshotChunk) {
+	for _, inst := range chunk.Instances {
+		if prev, exist := s.insts[inst.ID]; exist && !chunk.IsBase() {
+			data := prev.Data.Clone()
+			for field, value := range inst.Data {
+				data[field] = value
+			}
+			for _, field := range inst.Unset {
+				delete(data, field)
+			}
+			inst.Data = data
+		}
+		inst.Unset = nil
+		s.insts[inst.ID] = inst
+	}
+
+	for _, id := range chunk.Removed {
+		delete(s.insts, id)
+	}
+}
+
+// diff returns the instance stored in the diff snapshot and whether it's changed since the state. the added
+// instance is stored with all its attributes, the changed one is stored with the changed attributes only.
+func (s *instSnapshotState) diff(inst metadata.SnapshotInst) (metadata.SnapshotInst, bool) {
+	prev, exist := s.insts[inst.ID]
+	if !exist {
+		return inst, true
+	}
+	if prev.Checksum == inst.Checksum && prev.Name == inst.Name {
+		return inst, false
+	}
+
+	diff := metadata.SnapshotInst{
+		ID:       inst.ID,
+		Name:     inst.Name,
+		Checksum: inst.Checksum,
+		Data:     mapstr.MapStr{},
+	}
+	for field, value := range inst.Data {
+		if before, exist := prev.Data[field]; !exist || !isSnapshotValueEqual(before, value) {
+			diff.Data[field] = value
+		}
+	}
+	for field := range prev.Data {
+		if _, exist := inst.Data[field]; !exist {
+			diff.Unset = append(diff.Unset, field)
+		}
+	}
+	sort.Strings(diff.Unset)
+	return diff, true
+}
+
+// instances returns the instances of the state in the order of their ids.
+func (s *instSnapshotState) instances() []metadata.SnapshotInst {
+	insts := make([]metadata.SnapshotInst, 0, len(s.insts))
+	for _, inst := range s.insts {
+		insts = append(insts, inst)
+	}
+	sort.Slice(insts, func(i, j int) bool { return insts[i].ID < insts[j].ID })
+	return insts
+}
+
+// isSnapshotValueEqual compares the attribute values encoded as json like the checksum, so that the values
+// read from the instance and the snapshot are equal even if they are decoded as different number types.
+func isSnapshotValueEqual(a, b interface{}) bool {
+	aJs, aErr := json.Marshal(a)
+	bJs, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aJs, bJs)
+}
+
+// newSnapshotInst converts the instance to the snapshot's instance, the checksum is calculated with the instance's
+// attributes encoded as json, whose keys are sorted.
+func newSnapshotInst(inst mapstr.MapStr, idField, nameField string) (metadata.SnapshotInst, error) {
+	id, err := util.GetInt64ByInterface(inst[idField])
+	if err != nil {
+		return metadata.SnapshotInst{}, err
+	}
+
+	name := util.GetStrByInterface(inst[nameField])
+	for _, field := range snapshotIgnoredFields {
+		delete(inst, field)
+	}
+	data, err := json.Marshal(inst)
+	if err != nil {
+		return metadata.SnapshotInst{}, err
+	}
+
+	return metadata.SnapshotInst{
+		ID:       id,
+		Name:     name,
+		Checksum: fmt.Sprintf("%x", md5.Sum(data)),
+		Data:     inst,
+	}, nil
+}
+
+// clearSnapshotChunks removes all the chunks of the snapshots matching the condition, it's called before
+// a snapshot is retaken so that the chunks of the snapshot taken earlier are not left.
+func (m *operationManager) clearSnapshotChunks(kit *rest.Kit, table string, cond mapstr.MapStr) error {
+	if err := mongodb.Client().Table(table).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("clear %s chunks failed, cond: %v, err: %v, rid: %s", table, cond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+// saveSnapshotChunk saves the chunk of the snapshot matching the condition.
+func (m *operationManager) saveSnapshotChunk(kit *rest.Kit, table string, cond mapstr.MapStr, index int,
+	chunk interface{}) error {
+
+	chunkCond := cond.Clone()
+	chunkCond[metadata.SnapshotChunkField] = index
+	if err := mongodb.Client().Table(table).Upsert(kit.Ctx, chunkCond, chunk); err != nil {
+		blog.Errorf("save %s chunk failed, cond: %v, err: %v, rid: %s", table, chunkCond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// ClearSnapshot removes the topology and instance snapshots older than the keep days.
+func (m *operationManager) ClearSnapshot(kit *rest.Kit, keepDays int) error {
+	if keepDays <= 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "keep_days")
+	}
+
+	expireDate := time.Now().AddDate(0, 0, -keepDays).Format(metadata.SnapshotDateLayout)
+	topoCond := mapstr.MapStr{metadata.SnapshotDateField: mapstr.MapStr{common.BKDBLT: expireDate}}
+
+	// the instance snapshots are removed with their base, the snapshots based on a base taken snapshotBaseDays
+	// before the expire date are all expired, the others are kept to rebuild the snapshots not expired.
+	baseExpireDate := time.Now().AddDate(0, 0, -keepDays-snapshotBaseDays).Format(metadata.SnapshotDateLayout)
+	instCond := mapstr.MapStr{metadata.SnapshotBaseDateField: mapstr.MapStr{common.BKDBLT: baseExpireDate}}
+
+	conds := map[string]mapstr.MapStr{
+		common.BKTableNameTopoSnapshot: topoCond,
+		common.BKTableNameInstSnapshot: instCond,
+	}
+	for table, cond := range conds {
+		cond = util.SetModOwner(cond, kit.SupplierAccount)
+		if err := mongodb.Client().Table(table).Delete(kit.Ctx, cond); err != nil {
+			blog.Errorf("clear %s before %s failed, err: %v, rid: %s", table, expireDate, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+		}
+	}
+	return nil
+}
+
+// SearchTopoSnapshot searches the latest topology snapshot of the business taken on or before the date,
+// returns nil if there is none.
+func (m *operationManager) SearchTopoSnapshot(kit *rest.Kit, opt metadata.SearchTopoSnapshotOption) (
+	*metadata.TopoSnapshot, error) {
+
+	if field, err := opt.Validate(); err != nil {
+		blog.Errorf("search topo snapshot option is invalid, field: %s, err: %v, rid: %s", field, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	cond := mapstr.MapStr{common.BKAppIDField: opt.BizID}
+	chunks := make([]metadata.TopoSnapshotChunk, 0)
+	if err := m.searchSnapshotChunks(kit, common.BKTableNameTopoSnapshot, cond, opt.Date, &chunks); err != nil {
+		return nil, err
+	}
+
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
+	snapshot := &metadata.TopoSnapshot{
+		BizID:      chunks[0].BizID,
+		BizName:    chunks[0].BizName,
+		Date:       chunks[0].Date,
+		OwnerID:    chunks[0].OwnerID,
+		Sets:       make([]metadata.SnapshotNode, 0),
+		Modules:    make([]metadata.SnapshotNode, 0),
+		Hosts:      make([]metadata.SnapshotHost, 0),
+		CreateTime: chunks[0].CreateTime,
+	}
+	for _, chunk := range chunks {
+		snapshot.Sets = append(snapshot.Sets, chunk.Sets...)
+		snapshot.Modules = append(snapshot.Modules, chunk.Modules...)
+		snapshot.Hosts = append(snapshot.Hosts, chunk.Hosts...)
+	}
+	return snapshot, nil
+}
+
+// SearchInstSnapshot searches the latest instance snapshot of the model taken on or before the date,
+// returns nil if there is none.
+func (m *operationManager) SearchInstSnapshot(kit *rest.Kit, opt metadata.SearchInstSnapshotOption) (
+	*metadata.InstSnapshot, error) {
+
+	if field, err := opt.Validate(); err != nil {
+		blog.Errorf("search instance snapshot option is invalid, field: %s, err: %v, rid: %s", field, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	state, err := m.rebuildInstSnapshot(kit, mapstr.MapStr{common.BKObjIDField: opt.ObjID}, opt.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	if state == nil {
+		return nil, nil
+	}
+
+	return &metadata.InstSnapshot{
+		ObjID:      opt.ObjID,
+		Date:       state.header.Date,
+		OwnerID:    state.header.OwnerID,
+		Instances:  state.instances(),
+		CreateTime: state.header.CreateTime,
+	}, nil
+}
+
+// rebuildInstSnapshot rebuilds the latest instance snapshot matching the condition taken on or before the date
+// from its base snapshot and the diffs up to it, returns nil if there is none.
+func (m *operationManager) rebuildInstSnapshot(kit *rest.Kit, cond mapstr.MapStr, date string) (
+	*instSnapshotState, error) {
+
+	table := common.BKTableNameInstSnapshot
+	header, err := m.searchSnapshotHeader(kit, table, cond, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if header == nil {
+		return nil, nil
+	}
+
+	headers := []snapshotChunkHeader{*header}
+	if header.BaseDate != header.Date {
+		chainCond := cond.Clone()
+		chainCond[common.BKOwnerIDField] = header.OwnerID
+		chainCond[metadata.SnapshotChunkField] = 0
+		chainCond[metadata.SnapshotBaseDateField] = header.BaseDate
+		chainCond[metadata.SnapshotDateField] = mapstr.MapStr{common.BKDBLTE: header.Date}
+
+		headers = make([]snapshotChunkHeader, 0)
+		err := mongodb.Client().Table(table).Find(chainCond).Sort(metadata.SnapshotDateField).All(kit.Ctx, &headers)
+		if err != nil {
+			blog.Errorf("search %s failed, cond: %v, err: %v, rid: %s", table, chainCond, err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		if len(headers) == 0 || headers[0].Date != header.BaseDate {
+			blog.Errorf("the base of %s is not found, cond: %v, rid: %s", table, chainCond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+	}
+
+	state := newInstSnapshotState()
+	for _, chainHeader := range headers {
+		chunks := make([]metadata.InstSnapshotChunk, 0)
+		if err := m.searchHeaderChunks(kit, table, cond, &chainHeader, &chunks); err != nil {
+			return nil, err
+		}
+		for _, chunk := range chunks {
+			state.apply(chunk)
+		}
+	}
+	state.header = *header
+	return state, nil
+}
+
+// snapshotChunkHeader is the fields of the snapshot's first chunk used to search the other chunks.
+type snapshotChunkHeader struct {
+	Date       string    `bson:"date"`
+	OwnerID    string    `bson:"bk_supplier_account"`
+	ChunkCount int       `bson:"chunk_count"`
+	BaseDate   string    `bson:"base_date"`
+	CreateTime time.Time `bson:"create_time"`
+}
+
+// searchSnapshotChunks searches all the chunks of the latest snapshot matching the condition taken on or before
+// the date in the order of their indexes, the result is empty if there is none.
+func (m *operationManager) searchSnapshotChunks(kit *rest.Kit, table string, cond mapstr.MapStr, date string,
+	result interface{}) error {
+
+	header, err := m.searchSnapshotHeader(kit, table, cond, date)
+	if err != nil {
+		return err
+	}
+
+	if header == nil {
+		return nil
+	}
+	return m.searchHeaderChunks(kit, table, cond, header, result)
+}
+
+// searchSnapshotHeader searches the header of the latest snapshot matching the condition taken on or before
+// the date, returns nil if there is none.
+func (m *operationManager) searchSnapshotHeader(kit *rest.Kit, table string, cond mapstr.MapStr, date string) (
+	*snapshotChunkHeader, error) {
+
+	headerCond := cond.Clone()
+	headerCond[metadata.SnapshotChunkField] = 0
+	headerCond[metadata.SnapshotDateField] = mapstr.MapStr{common.BKDBLTE: date}
+	headerCond = util.SetModOwner(headerCond, kit.SupplierAccount)
+
+	headers := make([]snapshotChunkHeader, 0)
+	err := mongodb.Client().Table(table).Find(headerCond).Sort("-"+metadata.SnapshotDateField).Limit(1).
+		All(kit.Ctx, &headers)
+	if err != nil {
+		blog.Errorf("search %s failed, cond: %v, err: %v, rid: %s", table, headerCond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	return &headers[0], nil
+}
+
+// searchHeaderChunks searches all the chunks of the snapshot with the header in the order of their indexes.
+func (m *operationManager) searchHeaderChunks(kit *rest.Kit, table string, cond mapstr.MapStr,
+	header *snapshotChunkHeader, result interface{}) error {
+
+	chunkCond := cond.Clone()
+	chunkCond[common.BKOwnerIDField] = header.OwnerID
+	chunkCond[metadata.SnapshotDateField] = header.Date
+	chunkCond[metadata.SnapshotChunkField] = mapstr.MapStr{common.BKDBLT: header.ChunkCount}
+	count, err := mongodb.Client().Table(table).Find(chunkCond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count %s chunks failed, cond: %v, err: %v, rid: %s", table, chunkCond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	// the snapshot is being retaken if some of its chunks are removed.
+	if int(count) != header.ChunkCount {
+		blog.Errorf("%s has %d chunks, but %d is expected, cond: %v, rid: %s", table, count,
+			header.ChunkCount, chunkCond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	err = mongodb.Client().Table(table).Find(chunkCond).Sort(metadata.SnapshotChunkField).All(kit.Ctx, result)
+	if err != nil {
+		blog.Errorf("search %s chunks failed, cond: %v, err: %v, rid: %s", table, chunkCond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package operation
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestNewSnapshotInst(t *testing.T) {
+	inst := mapstr.MapStr{
+		common.BKInstIDField:   int64(12),
+		common.BKInstNameField: "switch-01",
+		"ports":                48,
+		common.LastTimeField:   time.Now(),
+	}
+	item, err := newSnapshotInst(inst.Clone(), common.BKInstIDField, common.BKInstNameField)
+	if err != nil {
+		t.Fatalf("new snapshot inst failed, err: %v", err)
+	}
+	if item.ID != 12 || item.Name != "switch-01" || item.Checksum == "" {
+		t.Errorf("unexpected snapshot inst: %+v", item)
+	}
+	if _, exist := item.Data[common.LastTimeField]; exist || item.Data["ports"] != 48 {
+		t.Errorf("unexpected snapshot inst data: %v", item.Data)
+	}
+
+	// the checksum doesn't change if only the update time changed.
+	inst[common.LastTimeField] = time.Now().Add(time.Hour)
+	same, err := newSnapshotInst(inst.Clone(), common.BKInstIDField, common.BKInstNameField)
+	if err != nil {
+		t.Fatalf("new snapshot inst failed, err: %v", err)
+	}
+	if same.Checksum != item.Checksum {
+		t.Errorf("checksum changed with the update time, %s != %s", same.Checksum, item.Checksum)
+	}
+
+	inst["ports"] = 24
+	changed, err := newSnapshotInst(inst.Clone(), common.BKInstIDField, common.BKInstNameField)
+	if err != nil {
+		t.Fatalf("new snapshot inst failed, err: %v", err)
+	}
+	if changed.Checksum == item.Checksum {
+		t.Errorf("checksum is not changed with the attribute")
+	}
+
+	if _, err := newSnapshotInst(mapstr.MapStr{}, common.BKInstIDField, common.BKInstNameField); err == nil {
+		t.Errorf("expect error when the instance has no id")
+	}
+}
+
+func TestSplitTopoSnapshot(t *testing.T) {
+	snapshot := &metadata.TopoSnapshot{
+		BizID:   2,
+		Date:    "2020-12-02",
+		OwnerID: "0",
+		Sets:    []metadata.SnapshotNode{{ID: 1}, {ID: 2}},
+		Modules: []metadata.SnapshotNode{{ID: 3}, {ID: 4}, {ID: 5}},
+		Hosts:   []metadata.SnapshotHost{{ID: 6}, {ID: 7}},
+	}
+
+	chunks := splitTopoSnapshot(snapshot, 3)
+	if len(chunks) != 3 || chunks[0].ChunkCount != 3 {
+		t.Fatalf("expect 3 chunks, got %d, chunk count: %d", len(chunks), chunks[0].ChunkCount)
+	}
+	for i, chunk := range chunks {
+		if chunk.Chunk != i || chunk.BizID != 2 || chunk.Date != "2020-12-02" || chunk.OwnerID != "0" {
+			t.Errorf("unexpected chunk %d: %+v", i, chunk)
+		}
+		if i > 0 && chunk.ChunkCount != 0 {
+			t.Errorf("only the first chunk has the chunk count, chunk %d: %+v", i, chunk)
+		}
+	}
+	if len(chunks[0].Sets) != 2 || len(chunks[0].Modules) != 1 || len(chunks[1].Modules) != 2 ||
+		len(chunks[1].Hosts) != 1 || len(chunks[2].Hosts) != 1 {
+		t.Errorf("unexpected chunks: %+v", chunks)
+	}
+
+	// an empty snapshot still has a chunk.
+	empty := splitTopoSnapshot(&metadata.TopoSnapshot{BizID: 3}, 3)
+	if len(empty) != 1 || empty[0].ChunkCount != 1 || empty[0].BizID != 3 {
+		t.Errorf("unexpected chunks of empty snapshot: %+v", empty)
+	}
+}
+
+// diffSnapshotChunk takes the diff snapshot of the instances since the state like the instSnapshotWriter.
+func diffSnapshotChunk(t *testing.T, state *instSnapshotState, date string,
+	insts []mapstr.MapStr) metadata.InstSnapshotChunk {
+
+	chunk := metadata.InstSnapshotChunk{Date: date, BaseDate: "2020-12-01", Instances: make([]metadata.SnapshotInst, 0)}
+	added := make(map[int64]struct{})
+	for _, inst := range insts {
+		item, err := newSnapshotInst(inst.Clone(), common.BKInstIDField, common.BKInstNameField)
+		if err != nil {
+			t.Fatalf("new snapshot inst failed, err: %v", err)
+		}
+		added[item.ID] = struct{}{}
+		if diff, changed := state.diff(item); changed {
+			chunk.Instances = append(chunk.Instances, diff)
+		}
+	}
+	for _, inst := range state.instances() {
+		if _, exist := added[inst.ID]; !exist {
+			chunk.Removed = append(chunk.Removed, inst.ID)
+		}
+	}
+	return chunk
+}
+
+func TestRebuildInstSnapshot(t *testing.T) {
+	newInst := func(id int64, name string, data mapstr.MapStr) mapstr.MapStr {
+		data[common.BKInstIDField] = id
+		data[common.BKInstNameField] = name
+		return data
+	}
+	days := [][]mapstr.MapStr{
+		{
+			newInst(1, "switch-01", mapstr.MapStr{"ports": 48, "vendor": "a"}),
+			newInst(2, "switch-02", mapstr.MapStr{"ports": 24, "vendor": "b"}),
+			newInst(3, "switch-03", mapstr.MapStr{"ports": 24}),
+		},
+		// switch-02's ports changed, switch-01's vendor removed, switch-03 removed and switch-04 added.
+		{
+			newInst(1, "switch-01", mapstr.MapStr{"ports": 48}),
+			newInst(2, "switch-02", mapstr.MapStr{"ports": 48, "vendor": "b"}),
+			newInst(4, "switch-04", mapstr.MapStr{"ports": 8, "vendor": "c"}),
+		},
+		// switch-04 renamed and switch-01 unchanged.
+		{
+			newInst(1, "switch-01", mapstr.MapStr{"ports": 48}),
+			newInst(2, "switch-02", mapstr.MapStr{"ports": 48, "vendor": "b"}),
+			newInst(4, "switch-04-new", mapstr.MapStr{"ports": 8, "vendor": "c"}),
+		},
+	}
+
+	// only the base snapshot stores all the instances with their attributes.
+	base := metadata.InstSnapshotChunk{Date: "2020-12-01", BaseDate: "2020-12-01"}
+	for _, inst := range days[0] {
+		item, err := newSnapshotInst(inst.Clone(), common.BKInstIDField, common.BKInstNameField)
+		if err != nil {
+			t.Fatalf("new snapshot inst failed, err: %v", err)
+		}
+		base.Instances = append(base.Instances, item)
+	}
+	state := newInstSnapshotState()
+	state.apply(base)
+
+	second := diffSnapshotChunk(t, state, "2020-12-02", days[1])
+	if len(second.Instances) != 3 || len(second.Removed) != 1 || second.Removed[0] != 3 {
+		t.Fatalf("unexpected diff of the second day: %+v", second)
+	}
+	if changed := second.Instances[1]; changed.ID != 2 || len(changed.Data) != 1 || changed.Data["ports"] != 48 {
+		t.Errorf("only the changed attributes should be stored, got: %+v", changed)
+	}
+	if unset := second.Instances[0]; unset.ID != 1 || len(unset.Data) != 0 || len(unset.Unset) != 1 ||
+		unset.Unset[0] != "vendor" {
+		t.Errorf("the removed attributes should be stored, got: %+v", unset)
+	}
+	if added := second.Instances[2]; added.ID != 4 || len(added.Data) != 4 {
+		t.Errorf("the added instance should be stored with all its attributes, got: %+v", added)
+	}
+	state.apply(second)
+
+	third := diffSnapshotChunk(t, state, "2020-12-03", days[2])
+	if len(third.Instances) != 1 || third.Instances[0].ID != 4 || len(third.Removed) != 0 {
+		t.Fatalf("unexpected diff of the third day: %+v", third)
+	}
+
+	// rebuild the third day from the base and the diffs, which should be the same as the instances of the day.
+	rebuilt := newInstSnapshotState()
+	for _, chunk := range []metadata.InstSnapshotChunk{base, second, third} {
+		rebuilt.apply(chunk)
+	}
+	insts := rebuilt.instances()
+	if len(insts) != len(days[2]) {
+		t.Fatalf("expect %d instances, got: %+v", len(days[2]), insts)
+	}
+	for i, inst := range days[2] {
+		expected, err := newSnapshotInst(inst.Clone(), common.BKInstIDField, common.BKInstNameField)
+		if err != nil {
+			t.Fatalf("new snapshot inst failed, err: %v", err)
+		}
+		if insts[i].ID != expected.ID || insts[i].Name != expected.Name || insts[i].Checksum != expected.Checksum ||
+			len(insts[i].Unset) != 0 || !isSnapshotValueEqual(insts[i].Data, expected.Data) {
+			t.Errorf("rebuilt instance %+v is not the same as %+v", insts[i], expected)
+		}
+	}
+}
+
+func TestIsSnapshotBaseExpired(t *testing.T) {
+	if isSnapshotBaseExpired("2020-12-01", "2020-12-07") {
+		t.Errorf("the base taken 6 days ago should not expire")
+	}
+	if !isSnapshotBaseExpired("2020-12-01", "2020-12-08") {
+		t.Errorf("the base taken %d days ago should expire", snapshotBaseDays)
+	}
+	if !isSnapshotBaseExpired("", "2020-12-08") {
+		t.Errorf("the invalid base date should expire")
+	}
+}
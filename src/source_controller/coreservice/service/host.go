@@ -20,6 +20,7 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/sensitive"
 	"configcenter/src/common/util"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/driver/redis"
@@ -165,6 +166,10 @@ func (s *coreService) GetHostByID(ctx *rest.Contexts) {
 		return
 	}
 
+	if err := s.outputHostSensitiveData(ctx.Kit, result); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
 	ctx.RespEntity(result)
 }
 
@@ -224,6 +229,10 @@ func (s *coreService) GetHosts(ctx *rest.Contexts) {
 	for index, host := range result {
 		info[index] = mapstr.MapStr(host)
 	}
+	if err := s.outputHostSensitiveData(ctx.Kit, result...); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
 	ctx.RespEntity(metadata.HostInfo{
 		Count: int(finalCount),
 		Info:  info,
@@ -323,3 +332,22 @@ func (s *coreService) TransferHostResourceDirectory(ctx *rest.Contexts) {
 
 	ctx.RespEntity(nil)
 }
+
+// outputHostSensitiveData decrypts the sensitive values of the hosts if the user is allowed to see them,
+// otherwise masks them.
+func (s *coreService) outputHostSensitiveData(kit *rest.Kit, hosts ...metadata.HostMapStr) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	fields, err := sensitive.GetFields(kit.Ctx, mongodb.Client(), kit.SupplierAccount, common.BKInnerObjIDHost)
+	if err != nil {
+		blog.Errorf("get host sensitive attributes failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	reveal := util.IsRevealSensitive(kit.Header)
+	for _, host := range hosts {
+		sensitive.Output(kit.Ctx, host, fields[common.BKInnerObjIDHost], reveal, kit.Rid)
+	}
+	return nil
+}
@@ -45,3 +45,19 @@ func (s *coreService) RemoveLabels(ctx *rest.Contexts) {
 	}
 	ctx.RespEntity(nil)
 }
+
+func (s *coreService) LabelsAggregation(ctx *rest.Contexts) {
+	inputData := selector.LabelAggregationRequest{}
+	if err := ctx.DecodeInto(&inputData); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+	aggregation, err := s.core.LabelOperation().LabelAggregation(ctx.Kit, inputData.TableName, inputData.Condition)
+	if err != nil {
+		blog.Errorf("LabelsAggregation failed, table: %s, condition: %+v, err: %s, rid: %s", inputData.TableName,
+			inputData.Condition, err.Error(), ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(aggregation)
+}
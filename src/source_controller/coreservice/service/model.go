@@ -489,6 +489,58 @@ func (s *coreService) SearchModelAttributes(ctx *rest.Contexts) {
 	ctx.RespEntity(dataResult)
 }
 
+func (s *coreService) PlanModelAttributeSchemaChange(ctx *rest.Contexts) {
+
+	inputData := metadata.AttributeSchemaChange{}
+	if err := ctx.DecodeInto(&inputData); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntityWithError(s.core.ModelOperation().PlanModelAttributeSchemaChange(ctx.Kit, ctx.Request.PathParameter("bk_obj_id"), inputData))
+}
+
+func (s *coreService) CreateModelAttributeSchemaMigration(ctx *rest.Contexts) {
+
+	inputData := metadata.AttributeSchemaChange{}
+	if err := ctx.DecodeInto(&inputData); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntityWithError(s.core.ModelOperation().CreateModelAttributeSchemaMigration(ctx.Kit, ctx.Request.PathParameter("bk_obj_id"), inputData))
+}
+
+func (s *coreService) RollbackModelAttributeSchemaMigration(ctx *rest.Contexts) {
+	ctx.RespEntityWithError(s.core.ModelOperation().RollbackModelAttributeSchemaMigration(ctx.Kit, ctx.Request.PathParameter("migration_id")))
+}
+
+func (s *coreService) ExecuteModelAttributeSchemaMigrationStep(ctx *rest.Contexts) {
+
+	inputData := metadata.AttributeSchemaMigrationStep{}
+	if err := ctx.DecodeInto(&inputData); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.ModelOperation().ExecuteModelAttributeSchemaMigrationStep(ctx.Kit, inputData); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) SearchModelAttributeSchemaMigrations(ctx *rest.Contexts) {
+
+	inputData := metadata.SearchAttributeSchemaMigrationOption{}
+	if err := ctx.DecodeInto(&inputData); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntityWithError(s.core.ModelOperation().SearchModelAttributeSchemaMigrations(ctx.Kit, inputData))
+}
+
 func (s *coreService) SearchModelAttrUnique(ctx *rest.Contexts) {
 
 	inputData := metadata.QueryCondition{}
@@ -185,6 +185,8 @@ func (s *coreService) SearchChartCommon(ctx *rest.Contexts) {
 		return
 	}
 
+	// the preset charts of the default owner are shared, the others are only visible to their owner.
+	opt = util.SetQueryOwner(opt, ctx.Kit.SupplierAccount)
 	chartConfig := make([]metadata.ChartConfig, 0)
 	if err := mongodb.Client().Table(common.BKTableNameChartConfig).Find(opt).All(ctx.Kit.Ctx, &chartConfig); err != nil {
 		blog.Errorf("search chart config fail, option: %v, err: %v, rid: %v", opt, err, ctx.Kit.Rid)
@@ -207,6 +209,26 @@ func (s *coreService) SearchChartCommon(ctx *rest.Contexts) {
 	ctx.RespEntityWithCount(int64(count), nil)
 }
 
+// SearchChartsCommon search all the chart configs which match the condition
+func (s *coreService) SearchChartsCommon(ctx *rest.Contexts) {
+	opt := make(map[string]interface{})
+	if err := ctx.DecodeInto(&opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	// the preset charts of the default owner are shared, the others are only visible to their owner.
+	opt = util.SetQueryOwner(opt, ctx.Kit.SupplierAccount)
+	chartConfig := make([]metadata.ChartConfig, 0)
+	if err := mongodb.Client().Table(common.BKTableNameChartConfig).Find(opt).All(ctx.Kit.Ctx, &chartConfig); err != nil {
+		blog.Errorf("search chart configs fail, option: %v, err: %v, rid: %v", opt, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrOperationSearchChartFail))
+		return
+	}
+
+	ctx.RespEntityWithCount(int64(len(chartConfig)), chartConfig)
+}
+
 func (s *coreService) TimerFreshData(ctx *rest.Contexts) {
 	exist, err := mongodb.Client().HasTable(context.Background(), common.BKTableNameChartData)
 	if err != nil {
@@ -243,3 +265,83 @@ func (s *coreService) SearchCloudMapping(ctx *rest.Contexts) {
 	}
 	ctx.RespEntity(respData)
 }
+
+func (s *coreService) TakeTopoSnapshot(ctx *rest.Contexts) {
+	opt := metadata.TakeTopoSnapshotOption{}
+	if err := ctx.DecodeInto(&opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.StatisticOperation().TakeTopoSnapshot(ctx.Kit, opt.BizID); err != nil {
+		blog.Errorf("take business %d topo snapshot failed, err: %v, rid: %s", opt.BizID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) TakeInstSnapshot(ctx *rest.Contexts) {
+	opt := metadata.TakeInstSnapshotOption{}
+	if err := ctx.DecodeInto(&opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.StatisticOperation().TakeInstSnapshot(ctx.Kit, opt.ObjID); err != nil {
+		blog.Errorf("take model %s instance snapshot failed, err: %v, rid: %s", opt.ObjID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) ClearSnapshot(ctx *rest.Contexts) {
+	opt := metadata.ClearSnapshotOption{}
+	if err := ctx.DecodeInto(&opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.StatisticOperation().ClearSnapshot(ctx.Kit, opt.KeepDays); err != nil {
+		blog.Errorf("clear snapshot failed, keep days: %d, err: %v, rid: %s", opt.KeepDays, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) SearchTopoSnapshot(ctx *rest.Contexts) {
+	opt := metadata.SearchTopoSnapshotOption{}
+	if err := ctx.DecodeInto(&opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	snapshot, err := s.core.StatisticOperation().SearchTopoSnapshot(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(snapshot)
+}
+
+func (s *coreService) SearchInstSnapshot(ctx *rest.Contexts) {
+	opt := metadata.SearchInstSnapshotOption{}
+	if err := ctx.DecodeInto(&opt); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	snapshot, err := s.core.StatisticOperation().SearchInstSnapshot(ctx.Kit, opt)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(snapshot)
+}
@@ -17,11 +17,14 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
 	"configcenter/src/common/rdapi"
+	"configcenter/src/common/sensitive"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/app/options"
+	"configcenter/src/source_controller/coreservice/computed"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/source_controller/coreservice/core/association"
 	"configcenter/src/source_controller/coreservice/core/auditlog"
@@ -42,6 +45,7 @@ import (
 	dbSystem "configcenter/src/source_controller/coreservice/core/system"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/storage/driver/redis"
+	"configcenter/src/storage/stream"
 
 	"github.com/emicklei/go-restful"
 )
@@ -101,6 +105,11 @@ func (s *coreService) SetConfig(cfg options.Config, engine *backbone.Engine, err
 	mongodb.Client() = db
 	s.rds = cache */
 
+	if err := sensitive.Init("coreService.sensitive"); err != nil {
+		blog.Errorf("init sensitive attribute encryption failed, err: %v", err)
+		return err
+	}
+
 	// connect the remote mongodb
 	instance := instances.New(s, lang)
 	hostApplyRuleCore := hostapplyrule.New(instance)
@@ -123,6 +132,17 @@ func (s *coreService) SetConfig(cfg options.Config, engine *backbone.Engine, err
 		e.New(mongodb.Client(), redis.Client()),
 		coreCommon.New(),
 	)
+
+	watcher, watchErr := stream.NewStream(s.cfg.Mongo.GetMongoConf())
+	if watchErr != nil {
+		blog.Errorf("new watch stream failed, err: %v", watchErr)
+		return watchErr
+	}
+
+	if err := computed.NewComputer(watcher, engine.ServiceManageInterface); err != nil {
+		blog.Errorf("new computed attribute computer failed, err: %v", err)
+		return err
+	}
 	return nil
 }
 
@@ -70,6 +70,11 @@ func (s *coreService) initModel(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/model/{bk_obj_id}/attributes", Handler: s.DeleteModelAttribute})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/model/{bk_obj_id}/attributes", Handler: s.SearchModelAttributes})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/model/attributes", Handler: s.SearchModelAttributesByCondition})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/model/{bk_obj_id}/attributes/schema/plan", Handler: s.PlanModelAttributeSchemaChange})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/model/{bk_obj_id}/attributes/schema/migration", Handler: s.CreateModelAttributeSchemaMigration})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/model/attributes/schema/migration/{migration_id}/rollback", Handler: s.RollbackModelAttributeSchemaMigration})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/model/attributes/schema/migration/step", Handler: s.ExecuteModelAttributeSchemaMigrationStep})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/model/attributes/schema/migration", Handler: s.SearchModelAttributeSchemaMigrations})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -264,6 +269,7 @@ func (s *coreService) initOperation(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/operation/chart", Handler: s.UpdateOperationChart})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/operation/chart/{id}", Handler: s.DeleteOperationChart})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/chart/common", Handler: s.SearchChartCommon})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/operation/chart/common", Handler: s.SearchChartsCommon})
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/inst/count", Handler: s.SearchInstCount})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/chart/data", Handler: s.SearchChartData})
@@ -271,6 +277,12 @@ func (s *coreService) initOperation(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/timer/chart/data", Handler: s.SearchTimerChartData})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/start/operation/chart/timer", Handler: s.TimerFreshData})
 
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/operation/topo/snapshot", Handler: s.TakeTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/operation/inst/snapshot", Handler: s.TakeInstSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/delete/operation/snapshot", Handler: s.ClearSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/topo/snapshot", Handler: s.SearchTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/inst/snapshot", Handler: s.SearchInstSnapshot})
+
 	utility.AddToRestfulWebService(web)
 }
 
@@ -282,6 +294,7 @@ func (s *coreService) label(web *restful.WebService) {
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/labels", Handler: s.AddLabels})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/deletemany/labels", Handler: s.RemoveLabels})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/labels/aggregation", Handler: s.LabelsAggregation})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -49,6 +49,7 @@ type Config struct {
 	MaxIdleConns uint64
 	RsName       string
 	SocketTimeout   int
+	Tenant       local.TenantConf
 }
 
 // BuildURI return mongo uri according to  https://docs.mongodb.com/manual/reference/connection-string/
@@ -77,6 +78,7 @@ func (c Config) GetMongoConf() local.MongoConf {
 		URI:          c.BuildURI(),
 		RsName:       c.RsName,
 		SocketTimeout:  c.SocketTimeout,
+		Tenant:         c.Tenant,
 	}
 }
 
@@ -87,6 +89,7 @@ func (c Config) GetMongoClient() (db dal.RDB, err error) {
 		URI:          c.BuildURI(),
 		RsName:       c.RsName,
 		SocketTimeout: c.SocketTimeout,
+		Tenant:        c.Tenant,
 	}
 	db, err = local.NewMgo(mongoConf, time.Minute)
 	if err != nil {
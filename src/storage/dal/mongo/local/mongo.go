@@ -43,6 +43,7 @@ type Mongo struct {
 	dbname string
 	sess   mongo.Session
 	tm     *TxnManager
+	tenant TenantConf
 }
 
 var _ dal.DB = new(Mongo)
@@ -54,6 +55,7 @@ type MongoConf struct {
 	URI            string
 	RsName         string
 	SocketTimeout  int
+	Tenant         TenantConf
 }
 
 // NewMgo returns new RDB
@@ -96,6 +98,7 @@ func NewMgo(config MongoConf, timeout time.Duration) (*Mongo, error) {
 		dbc:    client,
 		dbname: connStr.Database,
 		tm:     &TxnManager{},
+		tenant: config.Tenant.normalize(),
 	}, nil
 }
 
@@ -316,14 +319,15 @@ func (f *Find) All(ctx context.Context, result interface{}) error {
 		findOpts.SetSort(f.sort)
 	}
 	// 查询条件为空时候，mongodb 不返回数据
-	if f.filter == nil {
-		f.filter = bson.M{}
+	filter, err := f.tenantFilter(ctx, f.filter, true)
+	if err != nil {
+		return err
 	}
 
 	opt := getCollectionOption(ctx)
 
 	return f.tm.AutoRunWithTxn(ctx, f.dbc, func(ctx context.Context) error {
-		cursor, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).Find(ctx, f.filter, findOpts)
+		cursor, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).Find(ctx, filter, findOpts)
 		if err != nil {
 			return err
 		}
@@ -358,13 +362,14 @@ func (f *Find) One(ctx context.Context, result interface{}) error {
 		findOpts.SetSort(f.sort)
 	}
 	// 查询条件为空时候，mongodb panic
-	if f.filter == nil {
-		f.filter = bson.M{}
+	filter, err := f.tenantFilter(ctx, f.filter, true)
+	if err != nil {
+		return err
 	}
 
 	opt := getCollectionOption(ctx)
 	return f.tm.AutoRunWithTxn(ctx, f.dbc, func(ctx context.Context) error {
-		cursor, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).Find(ctx, f.filter, findOpts)
+		cursor, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).Find(ctx, filter, findOpts)
 		if err != nil {
 			return err
 		}
@@ -386,8 +391,9 @@ func (f *Find) Count(ctx context.Context) (uint64, error) {
 		blog.V(4).InfoDepthf(2, "mongo count cost %dms, rid: %v", time.Since(start)/time.Millisecond, rid)
 	}()
 
-	if f.filter == nil {
-		f.filter = bson.M{}
+	filter, err := f.tenantFilter(ctx, f.filter, true)
+	if err != nil {
+		return 0, err
 	}
 
 	opt := getCollectionOption(ctx)
@@ -398,11 +404,11 @@ func (f *Find) Count(ctx context.Context) (uint64, error) {
 	}
 	if !useTxn {
 		// not use transaction.
-		cnt, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).CountDocuments(ctx, f.filter)
+		cnt, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).CountDocuments(ctx, filter)
 		return uint64(cnt), err
 	} else {
 		// use transaction
-		cnt, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).CountDocuments(sessCtx, f.filter)
+		cnt, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).CountDocuments(sessCtx, filter)
 		// do not release th session, otherwise, the session will be returned to the
 		// session pool and will be reused. then mongodb driver will increase the transaction number
 		// automatically and do read/write retry if policy is set.
@@ -423,6 +429,12 @@ func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
 	}()
 
 	rows := util.ConverToInterfaceSlice(docs)
+	if err := c.checkTenantDocs(ctx, rows); err != nil {
+		return err
+	}
+	if err := c.checkTenantQuota(ctx, len(rows)); err != nil {
+		return err
+	}
 
 	return c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
 		_, err := c.dbc.Database(c.dbname).Collection(c.collName).InsertMany(ctx, rows)
@@ -438,8 +450,9 @@ func (c *Collection) Update(ctx context.Context, filter types.Filter, doc interf
 		blog.V(4).InfoDepthf(2, "mongo update cost %dms, rid: %v", time.Since(start)/time.Millisecond, rid)
 	}()
 
-	if filter == nil {
-		filter = bson.M{}
+	filter, err := c.tenantFilter(ctx, filter, false)
+	if err != nil {
+		return err
 	}
 
 	data := bson.M{"$set": doc}
@@ -457,6 +470,11 @@ func (c *Collection) Upsert(ctx context.Context, filter types.Filter, doc interf
 		blog.V(4).InfoDepthf(2, "mongo upsert cost %dms, rid: %v", time.Since(start)/time.Millisecond, rid)
 	}()
 
+	filter, err := c.tenantFilter(ctx, filter, false)
+	if err != nil {
+		return err
+	}
+
 	// set upsert option
 	upsert := true
 	replaceOpt := &options.UpdateOptions{
@@ -486,6 +504,11 @@ func (c *Collection) UpdateMultiModel(ctx context.Context, filter types.Filter,
 		data["$"+item.Op] = item.Doc
 	}
 
+	filter, err := c.tenantFilter(ctx, filter, false)
+	if err != nil {
+		return err
+	}
+
 	return c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
 		_, err := c.dbc.Database(c.dbname).Collection(c.collName).UpdateMany(ctx, filter, data)
 		return err
@@ -500,9 +523,18 @@ func (c *Collection) Delete(ctx context.Context, filter types.Filter) error {
 	defer func() {
 		blog.V(4).InfoDepthf(2, "mongo delete cost %dms, rid: %v", time.Since(start)/time.Millisecond, rid)
 	}()
+
+	archive := c.isArchiveScoped(filter)
+	filter, err := c.tenantFilter(ctx, filter, false)
+	if err != nil {
+		return err
+	}
+
 	return c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
-		if err := c.tryArchiveDeletedDoc(ctx, filter); err != nil {
-			return err
+		if archive {
+			if err := c.tryArchiveDeletedDoc(ctx, filter); err != nil {
+				return err
+			}
 		}
 		_, err := c.dbc.Database(c.dbname).Collection(c.collName).DeleteMany(ctx, filter)
 		return err
@@ -510,6 +542,30 @@ func (c *Collection) Delete(ctx context.Context, filter types.Filter) error {
 
 }
 
+// isArchiveScoped checks whether the deleted docs matching the filter should be archived. The instance
+// associations are archived only when the delete is scoped to an owner and an object like the instances
+// of an object, so that a bulk delete over the whole collection, such as the dirty data clean, is not archived.
+func (c *Collection) isArchiveScoped(filter types.Filter) bool {
+	if c.collName != common.BKTableNameInstAsst {
+		return true
+	}
+
+	value := reflect.ValueOf(filter)
+	if value.Kind() != reflect.Map || value.Type().Key().Kind() != reflect.String {
+		return false
+	}
+	for _, field := range []string{common.BKOwnerIDField, common.BKObjIDField} {
+		scope := value.MapIndex(reflect.ValueOf(field).Convert(value.Type().Key()))
+		if !scope.IsValid() {
+			return false
+		}
+		if _, ok := scope.Interface().(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Collection) tryArchiveDeletedDoc(ctx context.Context, filter types.Filter) error {
 	switch c.collName {
 	case common.BKTableNameModuleHostConfig:
@@ -521,6 +577,7 @@ func (c *Collection) tryArchiveDeletedDoc(ctx context.Context, filter types.Filt
 	case common.BKTableNameBaseInst:
 	case common.BKTableNameBaseProcess:
 	case common.BKTableNameProcessInstanceRelation:
+	case common.BKTableNameInstAsst:
 	default:
 		// do not archive the delete docs
 		return nil
@@ -751,9 +808,14 @@ func (c *Collection) DropColumn(ctx context.Context, field string) error {
 		blog.V(4).InfoDepthf(2, "mongo drop-column cost: %sms, rid: %s", time.Since(start)/time.Millisecond, rid)
 	}()
 
+	filter, err := c.tenantFilter(ctx, nil, false)
+	if err != nil {
+		return err
+	}
+
 	datac := dtype.Document{"$unset": dtype.Document{field: ""}}
 	return c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
-		_, err := c.dbc.Database(c.dbname).Collection(c.collName).UpdateMany(ctx, dtype.Document{}, datac)
+		_, err := c.dbc.Database(c.dbname).Collection(c.collName).UpdateMany(ctx, filter, datac)
 		return err
 	})
 }
@@ -765,6 +827,12 @@ func (c *Collection) DropColumns(ctx context.Context, filter types.Filter, field
 		unsetFields[field] = ""
 	}
 	datac := dtype.Document{"$unset": unsetFields}
+
+	filter, err := c.tenantFilter(ctx, filter, false)
+	if err != nil {
+		return err
+	}
+
 	return c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
 		_, err := c.dbc.Database(c.dbname).Collection(c.collName).UpdateMany(ctx, filter, datac)
 		return err
@@ -779,8 +847,9 @@ func (c *Collection) DropDocsColumn(ctx context.Context, field string, filter ty
 		blog.V(4).InfoDepthf(2, "mongo drop-docs-column cost: %sms, rid: %s", time.Since(start)/time.Millisecond, rid)
 	}()
 	// 查询条件为空时候，mongodb 不返回数据
-	if filter == nil {
-		filter = bson.M{}
+	filter, err := c.tenantFilter(ctx, filter, false)
+	if err != nil {
+		return err
 	}
 
 	datac := dtype.Document{"$unset": dtype.Document{field: ""}}
@@ -798,6 +867,11 @@ func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, res
 		blog.V(4).InfoDepthf(2, "mongo aggregate-all cost %dms, rid: %v", time.Since(start)/time.Millisecond, rid)
 	}()
 
+	pipeline, err := c.tenantPipeline(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
 	opt := getCollectionOption(ctx)
 
 	return c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
@@ -819,6 +893,11 @@ func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, res
 		blog.V(4).InfoDepthf(2, "mongo aggregate-one cost %dms, rid: %v", time.Since(start)/time.Millisecond, rid)
 	}()
 
+	pipeline, err := c.tenantPipeline(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
 	opt := getCollectionOption(ctx)
 
 	return c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
@@ -845,14 +924,15 @@ func (c *Collection) Distinct(ctx context.Context, field string, filter types.Fi
 		blog.V(4).InfoDepthf(2, "mongo distinct cost %dms, rid: %v", time.Since(start)/time.Millisecond, rid)
 	}()
 
-	if filter == nil {
-		filter = bson.M{}
+	filter, err := c.tenantFilter(ctx, filter, true)
+	if err != nil {
+		return nil, err
 	}
 	opt := getCollectionOption(ctx)
 
 	var results []interface{} = nil
 
-	err := c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
+	err = c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
 		var err error
 		results, err = c.dbc.Database(c.dbname).Collection(c.collName, opt).Distinct(ctx, field, filter)
 		return err
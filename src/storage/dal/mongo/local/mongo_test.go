@@ -22,10 +22,12 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/util"
 	"configcenter/src/storage/dal/types"
 
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/x/mongo/driver/uuid"
 )
 
@@ -1445,4 +1447,28 @@ func TestDistinct(t *testing.T) {
 		require.NoError(t, err,"convert to []bool error")
 		require.Equal(t,[]bool{false,true},results)
 	}
-}
\ No newline at end of file
+}
+func TestIsArchiveScoped(t *testing.T) {
+	inst := &Collection{collName: common.BKTableNameBaseInst}
+	require.True(t, inst.isArchiveScoped(map[string]interface{}{common.BKFieldID: 1}))
+
+	asst := &Collection{collName: common.BKTableNameInstAsst}
+	require.True(t, asst.isArchiveScoped(mapstr.MapStr{
+		common.BKOwnerIDField: "0",
+		common.BKObjIDField:   "rack",
+		common.BKInstIDField:  1,
+	}))
+	require.True(t, asst.isArchiveScoped(bson.M{common.BKOwnerIDField: "0", common.BKObjIDField: "rack"}))
+
+	unscoped := []types.Filter{
+		nil,
+		map[string]interface{}{common.BKFieldID: 1},
+		map[string]interface{}{common.BKOwnerIDField: "0", common.BKFieldID: 1},
+		map[string]interface{}{common.BKObjIDField: "rack", common.BKFieldID: 1},
+		map[string]interface{}{common.BKOwnerIDField: "0",
+			common.BKObjIDField: map[string]interface{}{common.BKDBIN: []string{"rack", "host"}}},
+	}
+	for _, filter := range unscoped {
+		require.False(t, asst.isArchiveScoped(filter), "filter: %v", filter)
+	}
+}
@@ -0,0 +1,257 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DefaultTenantQuota is the key of the quota which applies to all the tenants that are not configured separately.
+const DefaultTenantQuota = "default"
+
+// TenantConf is the tenant isolation config of the db operations.
+type TenantConf struct {
+	// Strict is whether to reject the operations on the tenant tables whose context has neither a tenant
+	// nor opted out of the tenant scope with dal.WithoutTenant. the request handlers get the tenant from the
+	// request header, the background jobs which operate on the db directly, like the cache sync, the computed
+	// attributes and the api jobs, must set their context with dal.WithTenant or dal.WithoutTenant before
+	// it's enabled, the jobs which call the apis, like the cloud sync and the operation timers, use the
+	// superadmin tenant which is not scoped.
+	Strict bool
+	// Quota is the max count of documents each tenant can have in a table, tenant -> table -> max count,
+	// the DefaultTenantQuota tenant applies to all the tenants which are not configured.
+	// the tenants and tables are matched case-insensitively, 0 means no limit.
+	Quota map[string]map[string]uint64
+}
+
+// tenantTables are the tables whose documents belong to a tenant with the bk_supplier_account field,
+// the find, update and delete operations on these tables are automatically scoped to the context's tenant.
+var tenantTables = map[string]bool{
+	common.BKTableNameObjDes:                     true,
+	common.BKTableNameObjAttDes:                  true,
+	common.BKTableNameObjClassification:          true,
+	common.BKTableNameObjUnique:                  true,
+	common.BKTableNamePropertyGroup:              true,
+	common.BKTableNameAsstDes:                    true,
+	common.BKTableNameObjAsst:                    true,
+	common.BKTableNameInstAsst:                   true,
+	common.BKTableNameBaseApp:                    true,
+	common.BKTableNameBaseSet:                    true,
+	common.BKTableNameBaseModule:                 true,
+	common.BKTableNameBaseHost:                   true,
+	common.BKTableNameBaseInst:                   true,
+	common.BKTableNameBasePlat:                   true,
+	common.BKTableNameBaseProcess:                true,
+	common.BKTableNameModuleHostConfig:           true,
+	common.BKTableNameServiceCategory:            true,
+	common.BKTableNameServiceTemplate:            true,
+	common.BKTableNameServiceInstance:            true,
+	common.BKTableNameProcessTemplate:            true,
+	common.BKTableNameProcessInstanceRelation:    true,
+	common.BKTableNameSetTemplate:                true,
+	common.BKTableNameSetServiceTemplateRelation: true,
+	common.BKTableNameHostApplyRule:              true,
+	common.BKTableNameDynamicGroup:               true,
+	common.BKTableNameHostSnapHistory:            true,
+}
+
+// sharedTenantTables are the tenant tables whose default tenant's documents are the preset metadata
+// shared by all the tenants, like the preset models, their attributes and uniques, the mainline association,
+// the preset service categories and the default cloud area, they can be read by all the tenants.
+var sharedTenantTables = map[string]bool{
+	common.BKTableNameObjDes:            true,
+	common.BKTableNameObjAttDes:         true,
+	common.BKTableNameObjClassification: true,
+	common.BKTableNameAsstDes:           true,
+	common.BKTableNamePropertyGroup:     true,
+	common.BKTableNameObjUnique:         true,
+	common.BKTableNameObjAsst:           true,
+	common.BKTableNameServiceCategory:   true,
+	common.BKTableNameBasePlat:          true,
+}
+
+// isTenantTable checks if the table's documents belong to a tenant.
+func isTenantTable(collName string) bool {
+	return tenantTables[collName]
+}
+
+// tenantCondition returns the bk_supplier_account condition of the context's tenant, scoped is false if the
+// operation is not scoped. for read operations on the shared tables, the tenant can also read the default tenant's
+// preset metadata, otherwise the tenant can only read and modify its own data. the super tenant and the context
+// which has opted out of the tenant scope is not scoped.
+func (c *Collection) tenantCondition(ctx context.Context, read bool) (cond interface{}, scoped bool, err error) {
+	if !isTenantTable(c.collName) || dal.IsWithoutTenant(ctx) {
+		return nil, false, nil
+	}
+
+	tenant := dal.TenantFromContext(ctx)
+	if tenant == "" {
+		if c.tenant.Strict {
+			blog.Errorf("operate table %s without tenant, rid: %v", c.collName, ctx.Value(common.ContextRequestIDField))
+			return nil, false, types.ErrTenantNotSet
+		}
+		return nil, false, nil
+	}
+
+	if tenant == common.BKSuperOwnerID {
+		return nil, false, nil
+	}
+
+	if read && tenant != common.BKDefaultOwnerID && sharedTenantTables[c.collName] {
+		return bson.M{common.BKDBIN: []string{common.BKDefaultOwnerID, tenant}}, true, nil
+	}
+	return tenant, true, nil
+}
+
+// tenantFilter returns the filter scoped to the context's tenant.
+func (c *Collection) tenantFilter(ctx context.Context, filter types.Filter, read bool) (types.Filter, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	cond, scoped, err := c.tenantCondition(ctx, read)
+	if err != nil {
+		return nil, err
+	}
+	if !scoped {
+		return filter, nil
+	}
+
+	// the origin filter is kept as is in $and, so that its own bk_supplier_account condition, if any,
+	// can only narrow the result but never escape the tenant scope.
+	return bson.M{common.BKDBAND: []interface{}{filter, bson.M{common.BKOwnerIDField: cond}}}, nil
+}
+
+// tenantPipeline returns the aggregate pipeline scoped to the context's tenant, the tenant condition is
+// prepended as the first $match stage so that the following stages can only see the tenant's data.
+func (c *Collection) tenantPipeline(ctx context.Context, pipeline interface{}) (interface{}, error) {
+	cond, scoped, err := c.tenantCondition(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	if !scoped {
+		return pipeline, nil
+	}
+
+	stages := []interface{}{bson.M{common.BKDBMatch: bson.M{common.BKOwnerIDField: cond}}}
+	if pipeline == nil {
+		return stages, nil
+	}
+
+	value := reflect.ValueOf(pipeline)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, fmt.Errorf("aggregate pipeline of table %s must be an array, but got %T", c.collName, pipeline)
+	}
+	for i := 0; i < value.Len(); i++ {
+		stages = append(stages, value.Index(i).Interface())
+	}
+	return stages, nil
+}
+
+// checkTenantDocs checks that the documents to be inserted belong to the context's tenant, so that a tenant
+// can not create data for the other tenants. the documents without bk_supplier_account are rejected too,
+// since they can not be read by any tenant after they are inserted.
+func (c *Collection) checkTenantDocs(ctx context.Context, docs []interface{}) error {
+	_, scoped, err := c.tenantCondition(ctx, false)
+	if err != nil {
+		return err
+	}
+	if !scoped {
+		return nil
+	}
+
+	tenant := dal.TenantFromContext(ctx)
+	for _, doc := range docs {
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return err
+		}
+
+		value, err := bson.Raw(raw).LookupErr(common.BKOwnerIDField)
+		if err != nil {
+			blog.Errorf("insert document without tenant into table %s by tenant %s, rid: %v", c.collName, tenant,
+				ctx.Value(common.ContextRequestIDField))
+			return types.ErrTenantMismatch
+		}
+		if owner, ok := value.StringValueOK(); !ok || owner != tenant {
+			blog.Errorf("insert document of tenant %s into table %s by tenant %s, rid: %v", value, c.collName,
+				tenant, ctx.Value(common.ContextRequestIDField))
+			return types.ErrTenantMismatch
+		}
+	}
+	return nil
+}
+
+// normalize lower cases the tenants and tables of the quota, so that they can be matched case-insensitively.
+func (t TenantConf) normalize() TenantConf {
+	quota := make(map[string]map[string]uint64, len(t.Quota))
+	for tenant, tables := range t.Quota {
+		lower := make(map[string]uint64, len(tables))
+		for table, max := range tables {
+			lower[strings.ToLower(table)] = max
+		}
+		quota[strings.ToLower(tenant)] = lower
+	}
+	return TenantConf{Strict: t.Strict, Quota: quota}
+}
+
+// getTenantQuota returns the max count of documents the tenant can have in the table, 0 means no limit.
+func (c *Collection) getTenantQuota(tenant string) uint64 {
+	quota, exist := c.tenant.Quota[strings.ToLower(tenant)]
+	if !exist {
+		quota = c.tenant.Quota[DefaultTenantQuota]
+	}
+	return quota[strings.ToLower(c.collName)]
+}
+
+// checkTenantQuota checks if the context's tenant will exceed its quota of the table after inserting count documents.
+// it's not atomic with the insertion, so the quota may be exceeded slightly by the concurrent insertions.
+func (c *Collection) checkTenantQuota(ctx context.Context, count int) error {
+	if !isTenantTable(c.collName) || count == 0 {
+		return nil
+	}
+
+	tenant := dal.TenantFromContext(ctx)
+	if tenant == "" || tenant == common.BKSuperOwnerID {
+		return nil
+	}
+
+	quota := c.getTenantQuota(tenant)
+	if quota == 0 {
+		return nil
+	}
+
+	opt := getCollectionOption(ctx)
+	exist, err := c.dbc.Database(c.dbname).Collection(c.collName, opt).CountDocuments(ctx,
+		bson.M{common.BKOwnerIDField: tenant})
+	if err != nil {
+		return fmt.Errorf("count tenant %s documents in table %s failed, err: %v", tenant, c.collName, err)
+	}
+
+	if uint64(exist)+uint64(count) > quota {
+		blog.Errorf("tenant %s has %d documents in table %s, inserting %d more exceeds the quota %d, rid: %v",
+			tenant, exist, c.collName, count, quota, ctx.Value(common.ContextRequestIDField))
+		return types.ErrTenantQuotaExceeded
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func newTenantCollection(collName string, conf TenantConf) *Collection {
+	return &Collection{collName: collName, Mongo: &Mongo{tenant: conf.normalize()}}
+}
+
+func TestTenantFilter(t *testing.T) {
+	coll := newTenantCollection(common.BKTableNameBaseHost, TenantConf{})
+	origin := bson.M{common.BKHostIDField: 1}
+
+	// read and write are scoped to the tenant only, the default tenant's hosts are not shared.
+	for _, read := range []bool{true, false} {
+		filter, err := coll.tenantFilter(dal.WithTenant(context.Background(), "a"), origin, read)
+		require.NoError(t, err)
+		require.Equal(t, bson.M{common.BKDBAND: []interface{}{origin, bson.M{common.BKOwnerIDField: "a"}}}, filter)
+	}
+
+	// read of the preset metadata tables is scoped to the tenant and the shared default tenant,
+	// but write is still scoped to the tenant only.
+	objColl := newTenantCollection(common.BKTableNameObjDes, TenantConf{})
+	filter, err := objColl.tenantFilter(dal.WithTenant(context.Background(), "a"), origin, true)
+	require.NoError(t, err)
+	require.Equal(t, bson.M{common.BKDBAND: []interface{}{origin, bson.M{common.BKOwnerIDField: bson.M{
+		common.BKDBIN: []string{common.BKDefaultOwnerID, "a"}}}}}, filter)
+
+	filter, err = objColl.tenantFilter(dal.WithTenant(context.Background(), "a"), origin, false)
+	require.NoError(t, err)
+	require.Equal(t, bson.M{common.BKDBAND: []interface{}{origin, bson.M{common.BKOwnerIDField: "a"}}}, filter)
+
+	// the default tenant can only read its own data.
+	filter, err = objColl.tenantFilter(dal.WithTenant(context.Background(), common.BKDefaultOwnerID), nil, true)
+	require.NoError(t, err)
+	require.Equal(t, bson.M{common.BKDBAND: []interface{}{bson.M{},
+		bson.M{common.BKOwnerIDField: common.BKDefaultOwnerID}}}, filter)
+
+	// the super tenant, the opted out context and the context without tenant are not scoped.
+	for _, ctx := range []context.Context{
+		dal.WithTenant(context.Background(), common.BKSuperOwnerID),
+		dal.WithoutTenant(dal.WithTenant(context.Background(), "a")),
+		context.Background(),
+	} {
+		filter, err = coll.tenantFilter(ctx, origin, true)
+		require.NoError(t, err)
+		require.Equal(t, origin, filter)
+	}
+
+	// the table which is not owned by tenant is not scoped.
+	filter, err = newTenantCollection(common.BKTableNameSystem, TenantConf{}).
+		tenantFilter(dal.WithTenant(context.Background(), "a"), origin, true)
+	require.NoError(t, err)
+	require.Equal(t, origin, filter)
+}
+
+func TestTenantFilterCanNotEscape(t *testing.T) {
+	coll := newTenantCollection(common.BKTableNameBaseInst, TenantConf{})
+	ctx := dal.WithTenant(context.Background(), "a")
+
+	// the filters which try to read the other tenant's data are always combined with the tenant condition.
+	escapes := []types.Filter{
+		bson.M{common.BKOwnerIDField: "b"},
+		bson.M{common.BKDBOR: []bson.M{{common.BKOwnerIDField: "b"}, {common.BKOwnerIDField: "a"}}},
+		map[string]interface{}{common.BKOwnerIDField: map[string]interface{}{common.BKDBNE: "a"}},
+	}
+	for _, escape := range escapes {
+		filter, err := coll.tenantFilter(ctx, escape, true)
+		require.NoError(t, err)
+		and := filter.(bson.M)[common.BKDBAND].([]interface{})
+		require.Len(t, and, 2)
+		require.Equal(t, escape, and[0])
+		require.Equal(t, bson.M{common.BKOwnerIDField: "a"}, and[1])
+	}
+}
+
+func TestTenantPipeline(t *testing.T) {
+	coll := newTenantCollection(common.BKTableNameBaseInst, TenantConf{})
+	ctx := dal.WithTenant(context.Background(), "a")
+	match := bson.M{common.BKDBMatch: bson.M{common.BKOwnerIDField: "a"}}
+	group := bson.M{"$group": bson.M{"_id": "$" + common.BKObjIDField}}
+
+	// the tenant condition is always the first stage whatever the type of the pipeline is.
+	for _, pipeline := range []interface{}{
+		[]bson.M{group},
+		[]map[string]interface{}{group},
+		bson.A{group},
+	} {
+		scoped, err := coll.tenantPipeline(ctx, pipeline)
+		require.NoError(t, err)
+		require.Len(t, scoped, 2)
+		require.Equal(t, match, scoped.([]interface{})[0])
+		require.EqualValues(t, group, scoped.([]interface{})[1])
+	}
+
+	_, err := coll.tenantPipeline(ctx, group)
+	require.Error(t, err)
+
+	// the pipeline of the system jobs is not scoped.
+	pipeline := []bson.M{group}
+	scoped, err := coll.tenantPipeline(dal.WithoutTenant(ctx), pipeline)
+	require.NoError(t, err)
+	require.Equal(t, pipeline, scoped)
+}
+
+func TestCheckTenantDocs(t *testing.T) {
+	coll := newTenantCollection(common.BKTableNameBaseInst, TenantConf{})
+	ctx := dal.WithTenant(context.Background(), "a")
+
+	require.NoError(t, coll.checkTenantDocs(ctx, []interface{}{
+		bson.M{common.BKOwnerIDField: "a"},
+		map[string]interface{}{common.BKInstIDField: 1, common.BKOwnerIDField: "a"},
+	}))
+	// the document without tenant can not be inserted, since it can not be read by any tenant.
+	require.Equal(t, types.ErrTenantMismatch, coll.checkTenantDocs(ctx, []interface{}{
+		bson.M{common.BKOwnerIDField: "a"},
+		map[string]interface{}{common.BKInstIDField: 1},
+	}))
+	require.Equal(t, types.ErrTenantMismatch, coll.checkTenantDocs(ctx, []interface{}{
+		bson.M{common.BKOwnerIDField: "a"},
+		bson.M{common.BKOwnerIDField: "b"},
+	}))
+	require.Equal(t, types.ErrTenantMismatch, coll.checkTenantDocs(ctx, []interface{}{
+		bson.M{common.BKOwnerIDField: common.BKDefaultOwnerID},
+	}))
+
+	// the system jobs can insert the documents of any tenant.
+	require.NoError(t, coll.checkTenantDocs(dal.WithoutTenant(ctx), []interface{}{
+		bson.M{common.BKOwnerIDField: "b"},
+		bson.M{common.BKInstIDField: 1},
+	}))
+}
+
+func TestTenantSharedPresetTables(t *testing.T) {
+	ctx := dal.WithTenant(context.Background(), "a")
+	shared := bson.M{common.BKOwnerIDField: bson.M{common.BKDBIN: []string{common.BKDefaultOwnerID, "a"}}}
+
+	// a non default tenant can read the preset uniques of the host and the mainline association,
+	// which are owned by the default tenant.
+	filters := map[string]types.Filter{
+		common.BKTableNameObjUnique: bson.M{common.BKObjIDField: common.BKInnerObjIDHost},
+		common.BKTableNameObjAsst: bson.M{common.BKObjIDField: common.BKInnerObjIDSet,
+			common.AssociationKindIDField: common.AssociationKindMainline},
+		common.BKTableNameServiceCategory: bson.M{common.BKIsPre: true},
+		common.BKTableNameBasePlat:        bson.M{common.BKCloudIDField: common.BKDefaultDirSubArea},
+	}
+	for table, origin := range filters {
+		coll := newTenantCollection(table, TenantConf{})
+		filter, err := coll.tenantFilter(ctx, origin, true)
+		require.NoError(t, err)
+		require.Equal(t, bson.M{common.BKDBAND: []interface{}{origin, shared}}, filter, table)
+
+		// but the tenant can only modify its own ones.
+		filter, err = coll.tenantFilter(ctx, origin, false)
+		require.NoError(t, err)
+		require.Equal(t, bson.M{common.BKDBAND: []interface{}{origin, bson.M{common.BKOwnerIDField: "a"}}},
+			filter, table)
+	}
+}
+
+func TestTenantFilterStrict(t *testing.T) {
+	coll := newTenantCollection(common.BKTableNameBaseHost, TenantConf{Strict: true})
+
+	_, err := coll.tenantFilter(context.Background(), nil, true)
+	require.Equal(t, types.ErrTenantNotSet, err)
+
+	// the system jobs can still operate with an explicit opt-out.
+	filter, err := coll.tenantFilter(dal.WithoutTenant(context.Background()), nil, false)
+	require.NoError(t, err)
+	require.Equal(t, bson.M{}, filter)
+}
+
+func TestTenantQuota(t *testing.T) {
+	coll := newTenantCollection(common.BKTableNameBaseHost, TenantConf{Quota: map[string]map[string]uint64{
+		DefaultTenantQuota: {common.BKTableNameBaseHost: 10},
+		"Tenant_A":         {"cc_hostbase": 20},
+	}})
+
+	require.Equal(t, uint64(20), coll.getTenantQuota("tenant_a"))
+	require.Equal(t, uint64(10), coll.getTenantQuota("b"))
+
+	coll.collName = common.BKTableNameBaseInst
+	require.Equal(t, uint64(0), coll.getTenantQuota("tenant_a"))
+	require.Equal(t, uint64(0), coll.getTenantQuota("b"))
+}
+
+// TestTenantIsolation proves that a tenant can not read or modify the other tenant's data with a real mongodb.
+func TestTenantIsolation(t *testing.T) {
+	if os.Getenv("MONGOURI") == "" {
+		t.Skip("MONGOURI is not set")
+	}
+
+	db := dbCleint(t)
+	table := db.Table(common.BKTableNameBaseInst)
+	objID := "tmp_test_tenant_isolation"
+	system := dal.WithoutTenant(context.Background())
+	ctxA := dal.WithTenant(context.Background(), "tenant_a")
+	ctxB := dal.WithTenant(context.Background(), "tenant_b")
+	cond := bson.M{common.BKObjIDField: objID}
+
+	require.NoError(t, table.Delete(system, cond))
+	defer table.Delete(system, cond)
+
+	require.NoError(t, table.Insert(system, []bson.M{
+		{common.BKObjIDField: objID, common.BKInstIDField: 1, common.BKOwnerIDField: "tenant_a"},
+		{common.BKObjIDField: objID, common.BKInstIDField: 2, common.BKOwnerIDField: "tenant_b"},
+		{common.BKObjIDField: objID, common.BKInstIDField: 3, common.BKOwnerIDField: common.BKDefaultOwnerID},
+	}))
+
+	// tenant b's instance can not be read by tenant a, even if it's asked explicitly.
+	result := make([]bson.M, 0)
+	require.NoError(t, table.Find(bson.M{common.BKObjIDField: objID, common.BKOwnerIDField: "tenant_b"}).
+		All(ctxA, &result))
+	require.Len(t, result, 0)
+
+	// the default tenant's instance can not be read by tenant a either, only the preset metadata is shared.
+	result = make([]bson.M, 0)
+	require.NoError(t, table.Find(cond).All(ctxA, &result))
+	require.Len(t, result, 1)
+	require.Equal(t, "tenant_a", result[0][common.BKOwnerIDField])
+
+	cnt, err := table.Find(cond).Count(ctxA)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), cnt)
+
+	aggregated := make([]bson.M, 0)
+	require.NoError(t, table.AggregateAll(ctxA, []bson.M{{common.BKDBMatch: cond}}, &aggregated))
+	require.Len(t, aggregated, 1)
+
+	// tenant a can not insert the instance of the other tenants.
+	require.Equal(t, types.ErrTenantMismatch, table.Insert(ctxA, bson.M{common.BKObjIDField: objID,
+		common.BKInstIDField: 4, common.BKOwnerIDField: "tenant_b"}))
+
+	// tenant a's update and delete can not affect tenant b's and the default tenant's instances.
+	require.NoError(t, table.Update(ctxA, cond, bson.M{"name": "a"}))
+	require.NoError(t, table.Delete(ctxA, cond))
+
+	cnt, err = table.Find(cond).Count(ctxB)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), cnt)
+
+	result = make([]bson.M, 0)
+	require.NoError(t, table.Find(cond).Sort(common.BKInstIDField).All(system, &result))
+	require.Len(t, result, 2)
+	for _, inst := range result {
+		require.NotEqual(t, "tenant_a", inst[common.BKOwnerIDField])
+		require.NotEqual(t, "a", inst["name"])
+	}
+
+	// the default tenant's host can not be read by tenant a.
+	hostTable := db.Table(common.BKTableNameBaseHost)
+	hostCond := bson.M{common.BKHostInnerIPField: objID}
+	require.NoError(t, hostTable.Delete(system, hostCond))
+	defer hostTable.Delete(system, hostCond)
+	require.NoError(t, hostTable.Insert(system, bson.M{common.BKHostInnerIPField: objID,
+		common.BKOwnerIDField: common.BKDefaultOwnerID}))
+
+	cnt, err = hostTable.Find(hostCond).Count(ctxA)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), cnt)
+}
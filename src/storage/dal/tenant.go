@@ -0,0 +1,53 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dal
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/util"
+)
+
+type skipTenantKey struct{}
+
+// WithTenant returns a context whose db operations are scoped to the tenant(bk_supplier_account),
+// it's used by the jobs which are not triggered by a request but operate on a tenant's data.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, common.ContextRequestOwnerField, tenant)
+}
+
+// WithoutTenant returns a context whose db operations are not scoped by tenant, it's an explicit opt-out
+// for the system jobs which operate on all the tenants' data, like the data migration and cache sync.
+// do not use it in the request handlers, they should always be scoped to the request's tenant.
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipTenantKey{}, true)
+}
+
+// IsWithoutTenant checks if the context has opted out of the tenant scope.
+func IsWithoutTenant(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	skip, _ := ctx.Value(skipTenantKey{}).(bool)
+	return skip
+}
+
+// TenantFromContext returns the tenant which the context's db operations are scoped to,
+// returns empty if the context has opted out of the tenant scope or has no tenant.
+func TenantFromContext(ctx context.Context) string {
+	if IsWithoutTenant(ctx) {
+		return ""
+	}
+	return util.ExtractOwnerFromContext(ctx)
+}
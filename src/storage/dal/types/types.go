@@ -24,6 +24,9 @@ var (
 	ErrDocumentNotFound    = errors.New("document not found")
 	ErrDuplicated          = errors.New("duplicated")
 	ErrSessionNotStarted   = errors.New("session is not started")
+	ErrTenantNotSet        = errors.New("tenant is not set")
+	ErrTenantQuotaExceeded = errors.New("tenant quota exceeded")
+	ErrTenantMismatch      = errors.New("document's tenant mismatches the context's tenant")
 
 	UpdateOpAddToSet = "addToSet"
 	UpdateOpPull     = "pull"
@@ -0,0 +1,186 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/types"
+	cacheop "configcenter/src/source_controller/cacheservice/cache"
+	"configcenter/src/source_controller/cacheservice/cache/verify"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/mongo"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/redis"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewCacheCommand())
+}
+
+type cacheConf struct {
+	resources  []string
+	full       bool
+	sampleSize int
+	repair     bool
+	report     string
+}
+
+// NewCacheCommand verifies the cache in redis with the data in mongodb, and repairs or rebuilds the cache.
+func NewCacheCommand() *cobra.Command {
+	conf := new(cacheConf)
+
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "verify or rebuild the cache of cacheservice",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "verify the cache with the data in mongodb, and repair the drift optionally",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheVerify(conf)
+		},
+	}
+	verifyCmd.Flags().BoolVar(&conf.full, "full", false, "verify all the cached resources instead of a sample")
+	verifyCmd.Flags().IntVar(&conf.sampleSize, "sample-size", 1000, "how many cached resources are verified if it's not full")
+	verifyCmd.Flags().BoolVar(&conf.repair, "repair", false, "repair the drifted cache")
+	cmd.AddCommand(verifyCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "rebuild",
+		Short: "refresh all the cache with the data in mongodb, and remove the stale cache",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheRebuild(conf)
+		},
+	})
+
+	cmd.PersistentFlags().StringSliceVar(&conf.resources, "resources", nil,
+		"the resources to verify or rebuild, supports host, biz, set and module, default is all")
+	cmd.PersistentFlags().StringVar(&conf.report, "report", "", "the file to write the report in json")
+
+	return cmd
+}
+
+// newCacheVerifier connects the mongodb with mongo-uri and the redis with the config in zookeeper,
+// and returns the verifier of the cache.
+func newCacheVerifier() (*verify.Verifier, error) {
+	if config.Conf.MongoURI == "" {
+		return nil, errors.New("mongo-uri must set via flag or environment variable")
+	}
+	mongoConf := &mongo.Config{
+		Connect:      config.Conf.MongoURI,
+		RsName:       config.Conf.MongoRsName,
+		MaxOpenConns: mongo.DefaultMaxOpenConns,
+		MaxIdleConns: mongo.MinimumMaxIdleOpenConns,
+	}
+	if err := mongodb.InitClient("", mongoConf); err != nil {
+		return nil, err
+	}
+
+	zk, err := config.NewZkService(config.Conf.ZkAddr)
+	if err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("%s/%s", types.CC_SERVCONF_BASEPATH, types.CCConfigureRedis)
+	strConf, err := zk.ZkCli.Get(path)
+	if err != nil {
+		return nil, fmt.Errorf("get path [%s] from zk [%v] failed: %v", path, zk.ZkCli.ZkHost, err)
+	}
+	if err := cc.SetRedisFromByte([]byte(strConf)); err != nil {
+		return nil, fmt.Errorf("parse redis config from zk path [%s] failed: %v", path, err)
+	}
+	redisConf, err := cc.Redis("redis")
+	if err != nil {
+		return nil, err
+	}
+	if err := redis.InitClient("redis", &redisConf); err != nil {
+		return nil, err
+	}
+
+	return cacheop.NewVerifier(), nil
+}
+
+func runCacheVerify(c *cacheConf) error {
+	opt := &verify.Option{Full: c.full, SampleSize: c.sampleSize, Repair: c.repair}
+	if err := opt.Validate(); err != nil {
+		return err
+	}
+
+	verifier, err := newCacheVerifier()
+	if err != nil {
+		return err
+	}
+
+	return runCacheResources(verifier, c, func(ctx context.Context, name string) (*verify.Report, error) {
+		return verifier.Verify(ctx, name, opt)
+	})
+}
+
+func runCacheRebuild(c *cacheConf) error {
+	verifier, err := newCacheVerifier()
+	if err != nil {
+		return err
+	}
+
+	return runCacheResources(verifier, c, func(ctx context.Context, name string) (*verify.Report, error) {
+		return verifier.Rebuild(ctx, name)
+	})
+}
+
+// runCacheResources runs the verify or rebuild operation on each of the resources, prints and writes the reports.
+func runCacheResources(verifier *verify.Verifier, c *cacheConf,
+	do func(ctx context.Context, name string) (*verify.Report, error)) error {
+
+	resources := c.resources
+	if len(resources) == 0 {
+		resources = verifier.Resources()
+	}
+
+	ctx := dal.WithoutTenant(context.Background())
+	reports := make([]*verify.Report, 0)
+	for _, name := range resources {
+		report, err := do(ctx, name)
+		if err != nil {
+			fmt.Print(WithRedColor(fmt.Sprintf("%s cache failed, err: %v", name, err)))
+			continue
+		}
+		reports = append(reports, report)
+
+		fmt.Printf("%s: checked %d, mismatched %d, stale %d, repaired %d\n", name, report.Checked,
+			len(report.Mismatched), len(report.Stale), report.Repaired)
+		for _, e := range report.Errors {
+			fmt.Print(WithRedColor(e))
+		}
+	}
+
+	if c.report == "" {
+		return nil
+	}
+	out, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal cache report failed, err: %v", err)
+	}
+	return ioutil.WriteFile(c.report, out, 0644)
+}
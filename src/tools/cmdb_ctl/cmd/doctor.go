@@ -0,0 +1,227 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"configcenter/src/storage/dal"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewDoctorCommand())
+}
+
+type doctorConf struct {
+	checks      []string
+	fix         bool
+	autoApprove bool
+	report      string
+	list        bool
+}
+
+// NewDoctorCommand checks the consistency of the data in the db, and fixes the issues optionally.
+func NewDoctorCommand() *cobra.Command {
+	conf := new(doctorConf)
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "check the consistency of the data in the db, and fix the issues optionally",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(conf)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&conf.checks, "checks", nil, "the names of the checks to run, default is all")
+	cmd.Flags().BoolVar(&conf.fix, "fix", false, "fix the issues which can be fixed automatically")
+	cmd.Flags().BoolVar(&conf.autoApprove, "auto-approve", false, "fix the issues without confirmation")
+	cmd.Flags().StringVar(&conf.report, "report", "", "the file to write the report in json")
+	cmd.Flags().BoolVar(&conf.list, "list", false, "list the checks")
+
+	return cmd
+}
+
+// doctorCheck a consistency check of the data in the db.
+type doctorCheck struct {
+	Name        string
+	Description string
+	// Run finds the issues, the issues are fixed by their fix func if it's not nil
+	Run func(ctx context.Context, db dal.RDB) ([]doctorIssue, error)
+}
+
+// doctorIssue an inconsistent data found by the check.
+type doctorIssue struct {
+	Detail string `json:"detail"`
+	// Fixable whether the issue can be fixed automatically, the ones not fixable need to be fixed by hand
+	Fixable  bool   `json:"fixable"`
+	Fixed    bool   `json:"fixed"`
+	FixError string `json:"fix_error,omitempty"`
+	fix      func(ctx context.Context, db dal.RDB) error
+}
+
+// doctorCheckReport the result of a check
+type doctorCheckReport struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Error       string        `json:"error,omitempty"`
+	Issues      []doctorIssue `json:"issues"`
+}
+
+// doctorReport the report of the doctor command
+type doctorReport struct {
+	StartTime time.Time           `json:"start_time"`
+	EndTime   time.Time           `json:"end_time"`
+	Fix       bool                `json:"fix"`
+	Checks    []doctorCheckReport `json:"checks"`
+}
+
+// doctorChecks the registry of the checks, they are run in the order they are registered.
+var doctorChecks = make([]doctorCheck, 0)
+
+func registerDoctorCheck(check doctorCheck) {
+	for _, registered := range doctorChecks {
+		if registered.Name == check.Name {
+			panic(fmt.Sprintf("doctor check %s is registered twice", check.Name))
+		}
+	}
+	doctorChecks = append(doctorChecks, check)
+}
+
+// selectDoctorChecks returns the checks with the names, all the checks are returned if no names are specified.
+func selectDoctorChecks(names []string) ([]doctorCheck, error) {
+	if len(names) == 0 {
+		return doctorChecks, nil
+	}
+
+	checks := make([]doctorCheck, 0)
+	for _, name := range names {
+		found := false
+		for _, check := range doctorChecks {
+			if check.Name == name {
+				checks = append(checks, check)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("doctor check %s does not exist, use --list to show the checks", name)
+		}
+	}
+	return checks, nil
+}
+
+func runDoctor(c *doctorConf) error {
+	if c.list {
+		for _, check := range doctorChecks {
+			fmt.Printf("%-32s %s\n", check.Name, check.Description)
+		}
+		return nil
+	}
+
+	checks, err := selectDoctorChecks(c.checks)
+	if err != nil {
+		return err
+	}
+
+	service, err := config.NewMongoService(config.Conf.MongoURI, config.Conf.MongoRsName)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	report := &doctorReport{StartTime: time.Now(), Checks: make([]doctorCheckReport, 0)}
+	fixable := 0
+	for _, check := range checks {
+		checkReport := doctorCheckReport{Name: check.Name, Description: check.Description}
+		fmt.Printf("check %s: %s\n", check.Name, check.Description)
+
+		issues, err := check.Run(ctx, service.DbProxy)
+		if err != nil {
+			checkReport.Error = err.Error()
+			fmt.Print(WithRedColor(fmt.Sprintf("check %s failed, err: %v", check.Name, err)))
+		}
+		for _, issue := range issues {
+			if issue.Fixable {
+				fixable++
+				fmt.Printf("  * %s\n", issue.Detail)
+			} else {
+				fmt.Printf("  ! %s (need to be fixed by hand)\n", issue.Detail)
+			}
+		}
+		if err == nil && len(issues) == 0 {
+			fmt.Print(WithGreenColor("ok"))
+		}
+		checkReport.Issues = issues
+		report.Checks = append(report.Checks, checkReport)
+	}
+
+	if c.fix && fixable > 0 && confirmDoctorFix(fixable, c.autoApprove) {
+		report.Fix = true
+		fixDoctorIssues(ctx, service.DbProxy, report)
+	}
+	report.EndTime = time.Now()
+
+	if c.report == "" {
+		return nil
+	}
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal doctor report failed, err: %v", err)
+	}
+	return ioutil.WriteFile(c.report, out, 0644)
+}
+
+func confirmDoctorFix(fixable int, autoApprove bool) bool {
+	if autoApprove {
+		return true
+	}
+	fmt.Printf("Do you want to fix these %d issues? Only 'yes' will be accepted: ", fixable)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != "yes" {
+		fmt.Println("fix cancelled")
+		return false
+	}
+	return true
+}
+
+// fixDoctorIssues fixes the fixable issues one by one, a failed fix doesn't stop the others.
+func fixDoctorIssues(ctx context.Context, db dal.RDB, report *doctorReport) {
+	fixed, failed := 0, 0
+	for checkIdx := range report.Checks {
+		issues := report.Checks[checkIdx].Issues
+		for idx := range issues {
+			if !issues[idx].Fixable || issues[idx].fix == nil {
+				continue
+			}
+			if err := issues[idx].fix(ctx, db); err != nil {
+				failed++
+				issues[idx].FixError = err.Error()
+				fmt.Print(WithRedColor(fmt.Sprintf("fix %s failed, err: %v", issues[idx].Detail, err)))
+				continue
+			}
+			fixed++
+			issues[idx].Fixed = true
+		}
+	}
+	fmt.Printf("%d issues fixed, %d failed\n", fixed, failed)
+}
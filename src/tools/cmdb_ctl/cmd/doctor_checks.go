@@ -0,0 +1,549 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+// doctorPageSize the count of the documents to check at a time
+const doctorPageSize = 1000
+
+func init() {
+	registerDoctorCheck(doctorCheck{
+		Name:        "orphan-module-host",
+		Description: "module host relations whose host, module, set or business does not exist",
+		Run:         checkOrphanModuleHost,
+	})
+	registerDoctorCheck(doctorCheck{
+		Name:        "host-multiple-biz",
+		Description: "hosts belong to more than one business",
+		Run:         checkHostMultipleBiz,
+	})
+	registerDoctorCheck(doctorCheck{
+		Name:        "dangling-inst-asst",
+		Description: "instance associations whose source or target instance does not exist",
+		Run:         checkDanglingInstAsst,
+	})
+	registerDoctorCheck(doctorCheck{
+		Name:        "service-instance-without-host",
+		Description: "service instances whose host does not exist or is not in the service instance's module",
+		Run:         checkServiceInstanceWithoutHost,
+	})
+	registerDoctorCheck(doctorCheck{
+		Name:        "sequence-behind-max-id",
+		Description: "id sequences which are behind the max id in the table, the next created id would be duplicated",
+		Run:         checkSequenceBehindMaxID,
+	})
+	registerDoctorCheck(doctorCheck{
+		Name:        "missing-unique-index",
+		Description: "unique indexes of the ids which are missing",
+		Run:         checkMissingUniqueIndex,
+	})
+}
+
+// existIDs returns the ids which exist in the table.
+func existIDs(ctx context.Context, db dal.RDB, table, idField string, filter map[string]interface{},
+	ids []int64) (map[int64]bool, error) {
+
+	exist := make(map[int64]bool)
+	ids = util.IntArrayUnique(ids)
+	if len(ids) == 0 {
+		return exist, nil
+	}
+
+	cond := map[string]interface{}{idField: map[string]interface{}{common.BKDBIN: ids}}
+	for key, value := range filter {
+		cond[key] = value
+	}
+	docs := make([]map[string]interface{}, 0)
+	if err := db.Table(table).Find(cond).Fields(idField).All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("get %s from %s failed, err: %v", idField, table, err)
+	}
+	for _, doc := range docs {
+		id, err := util.GetInt64ByInterface(doc[idField])
+		if err != nil {
+			return nil, fmt.Errorf("parse %s %v in %s failed, err: %v", idField, doc[idField], table, err)
+		}
+		exist[id] = true
+	}
+	return exist, nil
+}
+
+// existInstIDs returns the ids of the object's instances which exist.
+func existInstIDs(ctx context.Context, db dal.RDB, objID string, ids []int64) (map[int64]bool, error) {
+	table := common.GetInstTableName(objID)
+	var filter map[string]interface{}
+	if table == common.BKTableNameBaseInst {
+		filter = map[string]interface{}{common.BKObjIDField: objID}
+	}
+	return existIDs(ctx, db, table, common.GetInstIDField(objID), filter, ids)
+}
+
+func checkOrphanModuleHost(ctx context.Context, db dal.RDB) ([]doctorIssue, error) {
+	issues := make([]doctorIssue, 0)
+	for start := uint64(0); ; start += doctorPageSize {
+		relations := make([]metadata.ModuleHost, 0)
+		err := db.Table(common.BKTableNameModuleHostConfig).Find(map[string]interface{}{}).
+			Sort(common.BKHostIDField+","+common.BKModuleIDField).Start(start).Limit(doctorPageSize).All(ctx, &relations)
+		if err != nil {
+			return issues, fmt.Errorf("get module host relations failed, err: %v", err)
+		}
+
+		hostIDs, moduleIDs, setIDs, bizIDs := make([]int64, 0), make([]int64, 0), make([]int64, 0), make([]int64, 0)
+		for _, relation := range relations {
+			hostIDs = append(hostIDs, relation.HostID)
+			moduleIDs = append(moduleIDs, relation.ModuleID)
+			setIDs = append(setIDs, relation.SetID)
+			bizIDs = append(bizIDs, relation.AppID)
+		}
+		hosts, err := existIDs(ctx, db, common.BKTableNameBaseHost, common.BKHostIDField, nil, hostIDs)
+		if err != nil {
+			return issues, err
+		}
+		modules, err := existIDs(ctx, db, common.BKTableNameBaseModule, common.BKModuleIDField, nil, moduleIDs)
+		if err != nil {
+			return issues, err
+		}
+		sets, err := existIDs(ctx, db, common.BKTableNameBaseSet, common.BKSetIDField, nil, setIDs)
+		if err != nil {
+			return issues, err
+		}
+		bizs, err := existIDs(ctx, db, common.BKTableNameBaseApp, common.BKAppIDField, nil, bizIDs)
+		if err != nil {
+			return issues, err
+		}
+
+		for _, relation := range relations {
+			missing := make([]string, 0)
+			if !hosts[relation.HostID] {
+				missing = append(missing, "host")
+			}
+			if !modules[relation.ModuleID] {
+				missing = append(missing, "module")
+			}
+			if !sets[relation.SetID] {
+				missing = append(missing, "set")
+			}
+			if !bizs[relation.AppID] {
+				missing = append(missing, "business")
+			}
+			if len(missing) == 0 {
+				continue
+			}
+
+			filter := map[string]interface{}{
+				common.BKHostIDField:   relation.HostID,
+				common.BKModuleIDField: relation.ModuleID,
+				common.BKSetIDField:    relation.SetID,
+				common.BKAppIDField:    relation.AppID,
+			}
+			issues = append(issues, doctorIssue{
+				Detail: fmt.Sprintf("module host relation(biz: %d, set: %d, module: %d, host: %d) has no %s",
+					relation.AppID, relation.SetID, relation.ModuleID, relation.HostID, strings.Join(missing, ", ")),
+				Fixable: true,
+				fix: func(ctx context.Context, db dal.RDB) error {
+					return db.Table(common.BKTableNameModuleHostConfig).Delete(ctx, filter)
+				},
+			})
+		}
+
+		if len(relations) < doctorPageSize {
+			return issues, nil
+		}
+	}
+}
+
+// checkHostMultipleBiz finds the hosts in more than one business, they can't be fixed automatically since
+// which business the host should belong to is unknown.
+func checkHostMultipleBiz(ctx context.Context, db dal.RDB) ([]doctorIssue, error) {
+	pipeline := []map[string]interface{}{
+		{common.BKDBGroup: map[string]interface{}{
+			"_id":               "$" + common.BKHostIDField,
+			common.BKAppIDField: map[string]interface{}{common.BKDBAddToSet: "$" + common.BKAppIDField},
+		}},
+		{common.BKDBMatch: map[string]interface{}{
+			common.BKAppIDField + ".1": map[string]interface{}{common.BKDBExists: true},
+		}},
+	}
+	hosts := make([]struct {
+		HostID int64   `bson:"_id"`
+		BizIDs []int64 `bson:"bk_biz_id"`
+	}, 0)
+	if err := db.Table(common.BKTableNameModuleHostConfig).AggregateAll(ctx, pipeline, &hosts); err != nil {
+		return nil, fmt.Errorf("aggregate the businesses of the hosts failed, err: %v", err)
+	}
+
+	issues := make([]doctorIssue, 0)
+	for _, host := range hosts {
+		issues = append(issues, doctorIssue{
+			Detail: fmt.Sprintf("host %d belongs to businesses %v", host.HostID, host.BizIDs),
+		})
+	}
+	return issues, nil
+}
+
+func checkDanglingInstAsst(ctx context.Context, db dal.RDB) ([]doctorIssue, error) {
+	issues := make([]doctorIssue, 0)
+	lastID := int64(0)
+	for {
+		cond := map[string]interface{}{common.BKFieldID: map[string]interface{}{common.BKDBGT: lastID}}
+		associations := make([]metadata.InstAsst, 0)
+		err := db.Table(common.BKTableNameInstAsst).Find(cond).Sort(common.BKFieldID).Limit(doctorPageSize).
+			All(ctx, &associations)
+		if err != nil {
+			return issues, fmt.Errorf("get instance associations failed, err: %v", err)
+		}
+		if len(associations) == 0 {
+			return issues, nil
+		}
+		lastID = associations[len(associations)-1].ID
+
+		objInstIDs := make(map[string][]int64)
+		for _, asst := range associations {
+			objInstIDs[asst.ObjectID] = append(objInstIDs[asst.ObjectID], asst.InstID)
+			objInstIDs[asst.AsstObjectID] = append(objInstIDs[asst.AsstObjectID], asst.AsstInstID)
+		}
+		exist := make(map[string]map[int64]bool)
+		for objID, instIDs := range objInstIDs {
+			if exist[objID], err = existInstIDs(ctx, db, objID, instIDs); err != nil {
+				return issues, err
+			}
+		}
+
+		for _, asst := range associations {
+			missing := make([]string, 0)
+			if !exist[asst.ObjectID][asst.InstID] {
+				missing = append(missing, fmt.Sprintf("source %s %d", asst.ObjectID, asst.InstID))
+			}
+			if !exist[asst.AsstObjectID][asst.AsstInstID] {
+				missing = append(missing, fmt.Sprintf("target %s %d", asst.AsstObjectID, asst.AsstInstID))
+			}
+			if len(missing) == 0 {
+				continue
+			}
+
+			id := asst.ID
+			issues = append(issues, doctorIssue{
+				Detail: fmt.Sprintf("instance association %d(%s) has no %s", id, asst.ObjectAsstID,
+					strings.Join(missing, ", ")),
+				Fixable: true,
+				fix: func(ctx context.Context, db dal.RDB) error {
+					return db.Table(common.BKTableNameInstAsst).Delete(ctx, map[string]interface{}{common.BKFieldID: id})
+				},
+			})
+		}
+
+		if len(associations) < doctorPageSize {
+			return issues, nil
+		}
+	}
+}
+
+func checkServiceInstanceWithoutHost(ctx context.Context, db dal.RDB) ([]doctorIssue, error) {
+	issues := make([]doctorIssue, 0)
+	lastID := int64(0)
+	for {
+		cond := map[string]interface{}{common.BKFieldID: map[string]interface{}{common.BKDBGT: lastID}}
+		instances := make([]metadata.ServiceInstance, 0)
+		err := db.Table(common.BKTableNameServiceInstance).Find(cond).Sort(common.BKFieldID).Limit(doctorPageSize).
+			All(ctx, &instances)
+		if err != nil {
+			return issues, fmt.Errorf("get service instances failed, err: %v", err)
+		}
+		if len(instances) == 0 {
+			return issues, nil
+		}
+		lastID = instances[len(instances)-1].ID
+
+		hostIDs := make([]int64, 0)
+		for _, instance := range instances {
+			hostIDs = append(hostIDs, instance.HostID)
+		}
+		hosts, err := existIDs(ctx, db, common.BKTableNameBaseHost, common.BKHostIDField, nil, hostIDs)
+		if err != nil {
+			return issues, err
+		}
+		relations := make([]metadata.ModuleHost, 0)
+		relationCond := map[string]interface{}{
+			common.BKHostIDField: map[string]interface{}{common.BKDBIN: util.IntArrayUnique(hostIDs)},
+		}
+		if err := db.Table(common.BKTableNameModuleHostConfig).Find(relationCond).All(ctx, &relations); err != nil {
+			return issues, fmt.Errorf("get module host relations failed, err: %v", err)
+		}
+		hostModules := make(map[string]bool)
+		for _, relation := range relations {
+			hostModules[fmt.Sprintf("%d:%d", relation.HostID, relation.ModuleID)] = true
+		}
+
+		for _, instance := range instances {
+			var reason string
+			switch {
+			case !hosts[instance.HostID]:
+				reason = fmt.Sprintf("host %d does not exist", instance.HostID)
+			case !hostModules[fmt.Sprintf("%d:%d", instance.HostID, instance.ModuleID)]:
+				reason = fmt.Sprintf("host %d is not in module %d", instance.HostID, instance.ModuleID)
+			default:
+				continue
+			}
+
+			id := instance.ID
+			issues = append(issues, doctorIssue{
+				Detail: fmt.Sprintf("service instance %d(%s) of business %d: %s", id, instance.Name,
+					instance.BizID, reason),
+				Fixable: true,
+				fix: func(ctx context.Context, db dal.RDB) error {
+					return deleteServiceInstance(ctx, db, id)
+				},
+			})
+		}
+
+		if len(instances) < doctorPageSize {
+			return issues, nil
+		}
+	}
+}
+
+// deleteServiceInstance deletes the service instance with it's processes.
+func deleteServiceInstance(ctx context.Context, db dal.RDB, id int64) error {
+	relationCond := map[string]interface{}{common.BKServiceInstanceIDField: id}
+	relations := make([]metadata.ProcessInstanceRelation, 0)
+	if err := db.Table(common.BKTableNameProcessInstanceRelation).Find(relationCond).All(ctx, &relations); err != nil {
+		return fmt.Errorf("get process instance relations failed, err: %v", err)
+	}
+
+	if len(relations) > 0 {
+		processIDs := make([]int64, 0)
+		for _, relation := range relations {
+			processIDs = append(processIDs, relation.ProcessID)
+		}
+		processCond := map[string]interface{}{common.BKProcessIDField: map[string]interface{}{common.BKDBIN: processIDs}}
+		if err := db.Table(common.BKTableNameBaseProcess).Delete(ctx, processCond); err != nil {
+			return fmt.Errorf("delete processes %v failed, err: %v", processIDs, err)
+		}
+		if err := db.Table(common.BKTableNameProcessInstanceRelation).Delete(ctx, relationCond); err != nil {
+			return fmt.Errorf("delete process instance relations failed, err: %v", err)
+		}
+	}
+
+	instanceCond := map[string]interface{}{common.BKFieldID: id}
+	if err := db.Table(common.BKTableNameServiceInstance).Delete(ctx, instanceCond); err != nil {
+		return fmt.Errorf("delete service instance failed, err: %v", err)
+	}
+	return nil
+}
+
+// sequenceTables the tables whose ids are generated by the sequence named by the table name, and their id fields.
+var sequenceTables = []struct {
+	table   string
+	idField string
+}{
+	{common.BKTableNameBaseApp, common.BKAppIDField},
+	{common.BKTableNameBaseSet, common.BKSetIDField},
+	{common.BKTableNameBaseModule, common.BKModuleIDField},
+	{common.BKTableNameBaseHost, common.BKHostIDField},
+	{common.BKTableNameBaseProcess, common.BKProcessIDField},
+	{common.BKTableNameBasePlat, common.BKCloudIDField},
+	{common.BKTableNameBaseInst, common.BKInstIDField},
+	{common.BKTableNameInstAsst, common.BKFieldID},
+	{common.BKTableNameObjDes, common.BKFieldID},
+	{common.BKTableNameObjAttDes, common.BKFieldID},
+	{common.BKTableNameObjClassification, common.BKFieldID},
+	{common.BKTableNameObjAsst, common.BKFieldID},
+	{common.BKTableNameObjUnique, common.BKFieldID},
+	{common.BKTableNameAsstDes, common.BKFieldID},
+	{common.BKTableNamePropertyGroup, common.BKFieldID},
+	{common.BKTableNameServiceCategory, common.BKFieldID},
+	{common.BKTableNameServiceTemplate, common.BKFieldID},
+	{common.BKTableNameProcessTemplate, common.BKFieldID},
+	{common.BKTableNameServiceInstance, common.BKFieldID},
+	{common.BKTableNameSetTemplate, common.BKFieldID},
+	{common.BKTableNameHostApplyRule, common.BKFieldID},
+}
+
+func checkSequenceBehindMaxID(ctx context.Context, db dal.RDB) ([]doctorIssue, error) {
+	issues := make([]doctorIssue, 0)
+	for _, seq := range sequenceTables {
+		docs := make([]map[string]interface{}, 0)
+		err := db.Table(seq.table).Find(map[string]interface{}{}).Fields(seq.idField).Sort("-"+seq.idField).
+			Limit(1).All(ctx, &docs)
+		if err != nil {
+			return issues, fmt.Errorf("get max %s of %s failed, err: %v", seq.idField, seq.table, err)
+		}
+		if len(docs) == 0 {
+			continue
+		}
+		maxID, err := util.GetInt64ByInterface(docs[0][seq.idField])
+		if err != nil {
+			return issues, fmt.Errorf("parse max %s %v of %s failed, err: %v", seq.idField, docs[0][seq.idField],
+				seq.table, err)
+		}
+
+		sequences := make([]struct {
+			SequenceID int64 `bson:"SequenceID"`
+		}, 0)
+		seqCond := map[string]interface{}{"_id": seq.table}
+		if err := db.Table(common.BKTableNameIDgenerator).Find(seqCond).All(ctx, &sequences); err != nil {
+			return issues, fmt.Errorf("get sequence of %s failed, err: %v", seq.table, err)
+		}
+		sequence := int64(0)
+		if len(sequences) > 0 {
+			sequence = sequences[0].SequenceID
+		}
+		if sequence >= maxID {
+			continue
+		}
+
+		table, exist := seq.table, len(sequences) > 0
+		issues = append(issues, doctorIssue{
+			Detail:  fmt.Sprintf("sequence of %s is %d, behind the max %s %d", table, sequence, seq.idField, maxID),
+			Fixable: true,
+			fix: func(ctx context.Context, db dal.RDB) error {
+				return fixSequence(ctx, db, table, maxID, exist)
+			},
+		})
+	}
+	return issues, nil
+}
+
+// fixSequence sets the sequence to the max id, the sequence may be increased by the running services in the
+// meantime, so it's only updated when it's still behind.
+func fixSequence(ctx context.Context, db dal.RDB, table string, maxID int64, exist bool) error {
+	if !exist {
+		doc := map[string]interface{}{"_id": table, "SequenceID": maxID}
+		return db.Table(common.BKTableNameIDgenerator).Insert(ctx, doc)
+	}
+
+	cond := map[string]interface{}{
+		"_id":        table,
+		"SequenceID": map[string]interface{}{common.BKDBLT: maxID},
+	}
+	return db.Table(common.BKTableNameIDgenerator).Update(ctx, cond, map[string]interface{}{"SequenceID": maxID})
+}
+
+// uniqueIndexes the unique indexes the tables must have, the ids are unique but the indexes of them are
+// created as normal indexes by the early versions.
+var uniqueIndexes = []struct {
+	table string
+	index types.Index
+}{
+	{common.BKTableNameBaseApp, types.Index{Name: "idx_unique_bizID",
+		Keys: map[string]int32{common.BKAppIDField: 1}, Unique: true, Background: true}},
+	{common.BKTableNameBaseSet, types.Index{Name: "idx_unique_setID",
+		Keys: map[string]int32{common.BKSetIDField: 1}, Unique: true, Background: true}},
+	{common.BKTableNameBaseModule, types.Index{Name: "idx_unique_moduleID",
+		Keys: map[string]int32{common.BKModuleIDField: 1}, Unique: true, Background: true}},
+	{common.BKTableNameBaseHost, types.Index{Name: "idx_unique_hostID",
+		Keys: map[string]int32{common.BKHostIDField: 1}, Unique: true, Background: true}},
+	{common.BKTableNameBaseProcess, types.Index{Name: "idx_unique_processID",
+		Keys: map[string]int32{common.BKProcessIDField: 1}, Unique: true, Background: true}},
+	{common.BKTableNameBaseInst, types.Index{Name: "idx_unique_instID",
+		Keys: map[string]int32{common.BKInstIDField: 1}, Unique: true, Background: true}},
+	{common.BKTableNameModuleHostConfig, types.Index{Name: "idx_unique_hostID_moduleID",
+		Keys: map[string]int32{common.BKHostIDField: 1, common.BKModuleIDField: 1}, Unique: true, Background: true}},
+	{common.BKTableNameInstAsst, types.Index{Name: "idx_unique_id",
+		Keys: map[string]int32{common.BKFieldID: 1}, Unique: true, Background: true}},
+	{common.BKTableNameServiceInstance, types.Index{Name: "idx_unique_id",
+		Keys: map[string]int32{common.BKFieldID: 1}, Unique: true, Background: true}},
+	{common.BKTableNameProcessInstanceRelation, types.Index{Name: "idx_unique_processID",
+		Keys: map[string]int32{common.BKProcessIDField: 1}, Unique: true, Background: true}},
+}
+
+func checkMissingUniqueIndex(ctx context.Context, db dal.RDB) ([]doctorIssue, error) {
+	issues := make([]doctorIssue, 0)
+	for _, unique := range uniqueIndexes {
+		indexes, err := db.Table(unique.table).Indexes(ctx)
+		if err != nil {
+			return issues, fmt.Errorf("get indexes of %s failed, err: %v", unique.table, err)
+		}
+		keys := indexKeys(unique.index)
+		if hasUniqueIndex(indexes, keys) {
+			continue
+		}
+
+		duplicated, err := countDuplicated(ctx, db, unique.table, keys)
+		if err != nil {
+			return issues, err
+		}
+		detail := fmt.Sprintf("%s has no unique index of %s", unique.table, strings.Join(keys, ", "))
+		if duplicated > 0 {
+			// the index can't be created until the duplicated documents are fixed
+			issues = append(issues, doctorIssue{
+				Detail: fmt.Sprintf("%s, and %d values of it are duplicated", detail, duplicated),
+			})
+			continue
+		}
+
+		table, index := unique.table, unique.index
+		issues = append(issues, doctorIssue{
+			Detail:  detail,
+			Fixable: true,
+			fix: func(ctx context.Context, db dal.RDB) error {
+				return db.Table(table).CreateIndex(ctx, index)
+			},
+		})
+	}
+	return issues, nil
+}
+
+// indexKeys returns the sorted keys of the index.
+func indexKeys(index types.Index) []string {
+	keys := make([]string, 0)
+	for key := range index.Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// hasUniqueIndex checks whether there is a unique index of the keys, the order of the keys doesn't matter.
+func hasUniqueIndex(indexes []types.Index, keys []string) bool {
+	for _, index := range indexes {
+		if !index.Unique {
+			continue
+		}
+		if strings.Join(indexKeys(index), ",") == strings.Join(keys, ",") {
+			return true
+		}
+	}
+	return false
+}
+
+// countDuplicated counts the values of the keys which are duplicated in the table.
+func countDuplicated(ctx context.Context, db dal.RDB, table string, keys []string) (int, error) {
+	group := make(map[string]interface{})
+	for _, key := range keys {
+		group[key] = "$" + key
+	}
+	pipeline := []map[string]interface{}{
+		{common.BKDBGroup: map[string]interface{}{
+			"_id":   group,
+			"count": map[string]interface{}{common.BKDBSum: 1},
+		}},
+		{common.BKDBMatch: map[string]interface{}{"count": map[string]interface{}{common.BKDBGT: 1}}},
+	}
+	duplicated := make([]map[string]interface{}, 0)
+	if err := db.Table(table).AggregateAll(ctx, pipeline, &duplicated); err != nil {
+		return 0, fmt.Errorf("count duplicated %s of %s failed, err: %v", strings.Join(keys, ", "), table, err)
+	}
+	return len(duplicated), nil
+}
@@ -0,0 +1,56 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"testing"
+
+	"configcenter/src/storage/dal/types"
+)
+
+func TestHasUniqueIndex(t *testing.T) {
+	indexes := []types.Index{
+		{Name: "_id_", Keys: map[string]int32{"_id": 1}},
+		{Name: "bk_host_id_1", Keys: map[string]int32{"bk_host_id": 1}},
+		{Name: "idx_unique", Keys: map[string]int32{"bk_module_id": 1, "bk_host_id": -1}, Unique: true},
+	}
+
+	if hasUniqueIndex(indexes, []string{"bk_host_id"}) {
+		t.Errorf("the index of bk_host_id is not unique")
+	}
+	if !hasUniqueIndex(indexes, []string{"bk_host_id", "bk_module_id"}) {
+		t.Errorf("the unique index of bk_host_id and bk_module_id exists")
+	}
+	if hasUniqueIndex(indexes, []string{"bk_module_id"}) {
+		t.Errorf("the unique index of bk_module_id doesn't exist")
+	}
+}
+
+func TestSelectDoctorChecks(t *testing.T) {
+	checks, err := selectDoctorChecks(nil)
+	if err != nil || len(checks) != len(doctorChecks) {
+		t.Fatalf("all the checks should be selected, got %d, err: %v", len(checks), err)
+	}
+
+	checks, err = selectDoctorChecks([]string{"sequence-behind-max-id", "orphan-module-host"})
+	if err != nil {
+		t.Fatalf("select checks failed, err: %v", err)
+	}
+	if len(checks) != 2 || checks[0].Name != "sequence-behind-max-id" || checks[1].Name != "orphan-module-host" {
+		t.Fatalf("unexpected checks selected: %+v", checks)
+	}
+
+	if _, err = selectDoctorChecks([]string{"not-exist"}); err == nil {
+		t.Fatalf("select the check not exist should fail")
+	}
+}
@@ -0,0 +1,484 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/discovery"
+	apiutil "configcenter/src/apimachinery/util"
+	"configcenter/src/common/backbone/service_mange/zk"
+	"configcenter/src/common/etcdclient"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/registerdiscover"
+	"configcenter/src/common/util"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	rootCmd.AddCommand(NewModelCommand())
+}
+
+type modelConf struct {
+	user            string
+	supplierAccount string
+	file            string
+	output          string
+	classifications []string
+	objectIDs       []string
+	presetData      bool
+	businesses      []string
+	prune           bool
+	selected        []string
+	autoApprove     bool
+
+	sourceRegDiscv        string
+	sourceUser            string
+	sourceSupplierAccount string
+}
+
+// NewModelCommand manages the models by the declarative yaml spec.
+func NewModelCommand() *cobra.Command {
+	conf := new(modelConf)
+
+	cmd := &cobra.Command{
+		Use:   "model",
+		Short: "export the models to a yaml spec, and plan or apply the spec",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "export the models to a yaml spec",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runModelExportCmd(conf)
+		},
+	}
+	exportCmd.Flags().StringVarP(&conf.output, "output", "o", "", "the file to write the spec, default is stdout")
+	exportCmd.Flags().StringSliceVar(&conf.classifications, "classifications", nil,
+		"export the models in these classifications, default is all")
+	exportCmd.Flags().StringSliceVar(&conf.objectIDs, "objects", nil, "export the models with these ids, default is all")
+	exportCmd.Flags().BoolVar(&conf.presetData, "preset-data", false,
+		"export the association kinds and the service categories too")
+	exportCmd.Flags().StringSliceVar(&conf.businesses, "businesses", nil,
+		"export the service categories of the businesses with these names, default is all")
+
+	planCmd := &cobra.Command{
+		Use:   "plan",
+		Short: "show the changes to apply the yaml spec",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runModelPlanCmd(conf)
+		},
+	}
+
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "apply the yaml spec, the changes are shown and confirmed before applied",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runModelApplyCmd(conf)
+		},
+	}
+
+	promoteCmd := &cobra.Command{
+		Use:   "promote",
+		Short: "promote the models and the preset data from the source cmdb, the changes are confirmed before applied",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runModelPromoteCmd(conf)
+		},
+	}
+	promoteCmd.Flags().StringVar(&conf.sourceRegDiscv, "source-regdiscv", "",
+		"the register-discover address of the source cmdb, such as 127.0.0.1:2181 or zk://127.0.0.1:2181 for "+
+			"zookeeper, etcd://127.0.0.1:2379 for etcd and file:///data/cmdb/discovery for files")
+	promoteCmd.Flags().StringVar(&conf.sourceUser, "source-user", "",
+		"the user who exports the models from the source cmdb, default is the user")
+	promoteCmd.Flags().StringVar(&conf.sourceSupplierAccount, "source-supplier-account", "",
+		"the supplier account of the models in the source cmdb, default is the supplier account")
+	promoteCmd.Flags().StringSliceVar(&conf.classifications, "classifications", nil,
+		"promote the models in these classifications, default is all")
+	promoteCmd.Flags().StringSliceVar(&conf.objectIDs, "objects", nil, "promote the models with these ids, default is all")
+	promoteCmd.Flags().BoolVar(&conf.presetData, "preset-data", true,
+		"promote the association kinds and the service categories too")
+	promoteCmd.Flags().StringSliceVar(&conf.businesses, "businesses", nil,
+		"promote the service categories of the businesses with these names, default is all")
+	promoteCmd.Flags().StringVarP(&conf.output, "output", "o", "",
+		"the file to save the spec exported from the source cmdb")
+
+	for _, subCmd := range []*cobra.Command{planCmd, applyCmd} {
+		subCmd.Flags().StringVarP(&conf.file, "file", "f", "", "the yaml spec file")
+	}
+	for _, subCmd := range []*cobra.Command{planCmd, applyCmd, promoteCmd} {
+		subCmd.Flags().BoolVar(&conf.prune, "prune", false,
+			"delete the groups, attributes, unique rules, associations and models which are not in the spec")
+		subCmd.Flags().StringSliceVar(&conf.selected, "select", nil,
+			"the keys of the changes to apply, such as model:switch,attribute:switch/vendor, default is all")
+	}
+	for _, subCmd := range []*cobra.Command{applyCmd, promoteCmd} {
+		subCmd.Flags().BoolVar(&conf.autoApprove, "auto-approve", false, "apply the changes without confirmation")
+	}
+
+	cmd.AddCommand(exportCmd, planCmd, applyCmd, promoteCmd)
+	cmd.PersistentFlags().StringVar(&conf.user, "user", "admin", "the user who operates the models")
+	cmd.PersistentFlags().StringVar(&conf.supplierAccount, "supplier-account", "0", "the supplier account of the models")
+
+	return cmd
+}
+
+type modelService struct {
+	clientSet apimachinery.ClientSetInterface
+	header    http.Header
+}
+
+func newModelService(c *modelConf) (*modelService, error) {
+	return newModelServiceWithRegDiscv(config.Conf.ZkAddr, c.user, c.supplierAccount)
+}
+
+// newSourceModelService connects to the source cmdb which the models are promoted from.
+func newSourceModelService(c *modelConf) (*modelService, error) {
+	if c.sourceRegDiscv == "" {
+		return nil, errors.New("the register-discover address of the source cmdb must be set by the source-regdiscv flag")
+	}
+
+	user, supplierAccount := c.sourceUser, c.sourceSupplierAccount
+	if user == "" {
+		user = c.user
+	}
+	if supplierAccount == "" {
+		supplierAccount = c.supplierAccount
+	}
+	return newModelServiceWithRegDiscv(c.sourceRegDiscv, user, supplierAccount)
+}
+
+// newModelServiceWithRegDiscv connects to the cmdb by the register-discover address, the backend is chosen by
+// the scheme of the address in the same way as the cmdb services.
+func newModelServiceWithRegDiscv(regdiscv, user, supplierAccount string) (*modelService, error) {
+	regDiscover, err := newRegDiscover(regdiscv)
+	if err != nil {
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", etcdclient.RedactAddr(regdiscv), err)
+	}
+	serviceDiscovery, err := discovery.NewServiceDiscoveryWithRegDiscover(regDiscover)
+	if err != nil {
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", etcdclient.RedactAddr(regdiscv), err)
+	}
+	apiMachineryConfig := &apiutil.APIMachineryConfig{
+		QPS:       1000,
+		Burst:     2000,
+		TLSConfig: nil,
+	}
+	clientSet, err := apimachinery.NewApiMachinery(apiMachineryConfig, serviceDiscovery)
+	if err != nil {
+		return nil, fmt.Errorf("new api machinery failed, err: %v", err)
+	}
+
+	return &modelService{
+		clientSet: clientSet,
+		header:    util.BuildHeader(user, supplierAccount),
+	}, nil
+}
+
+func newRegDiscover(regdiscv string) (*registerdiscover.RegDiscover, error) {
+	typ, addr, err := registerdiscover.ParseRegDiscvAddr(regdiscv)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case registerdiscover.RegDiscvEtcd:
+		conf, err := etcdclient.ParseConfig(addr, 40*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		client := etcdclient.NewEtcdClientWithConfig(conf)
+		if err := client.Ping(); err != nil {
+			return nil, err
+		}
+		return registerdiscover.NewRegDiscoverWithServer(registerdiscover.NewEtcdRegDiscv(client)), nil
+
+	case registerdiscover.RegDiscvFile:
+		fileRegDiscv := registerdiscover.NewFileRegDiscv(addr)
+		if err := fileRegDiscv.Ping(); err != nil {
+			return nil, err
+		}
+		return registerdiscover.NewRegDiscoverWithServer(fileRegDiscv), nil
+
+	default:
+		client := zk.NewZkClient(addr, 40*time.Second)
+		if err := client.Start(); err != nil {
+			return nil, err
+		}
+		if err := client.Ping(); err != nil {
+			return nil, err
+		}
+		return registerdiscover.NewRegDiscoverEx(client), nil
+	}
+}
+
+func runModelExportCmd(c *modelConf) error {
+	srv, err := newModelService(c)
+	if err != nil {
+		return err
+	}
+
+	spec, err := srv.export(c)
+	if err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("marshal model spec failed, err: %v", err)
+	}
+
+	if c.output == "" {
+		fmt.Print(string(out))
+		return nil
+	}
+	return ioutil.WriteFile(c.output, out, 0644)
+}
+
+func runModelPlanCmd(c *modelConf) error {
+	opt, err := loadModelSpec(c)
+	if err != nil {
+		return err
+	}
+	srv, err := newModelService(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = srv.plan(opt)
+	return err
+}
+
+func runModelApplyCmd(c *modelConf) error {
+	opt, err := loadModelSpec(c)
+	if err != nil {
+		return err
+	}
+	srv, err := newModelService(c)
+	if err != nil {
+		return err
+	}
+
+	return srv.apply(opt, c.autoApprove)
+}
+
+// runModelPromoteCmd exports the models and the preset data from the source cmdb, and applies them to the
+// cmdb, the changes can be selected so that only a part of them are promoted.
+func runModelPromoteCmd(c *modelConf) error {
+	source, err := newSourceModelService(c)
+	if err != nil {
+		return err
+	}
+	spec, err := source.export(c)
+	if err != nil {
+		return fmt.Errorf("export from the source cmdb failed, %v", err)
+	}
+
+	if c.output != "" {
+		out, err := yaml.Marshal(spec)
+		if err != nil {
+			return fmt.Errorf("marshal model spec failed, err: %v", err)
+		}
+		if err := ioutil.WriteFile(c.output, out, 0644); err != nil {
+			return fmt.Errorf("write spec file %s failed, err: %v", c.output, err)
+		}
+	}
+
+	srv, err := newModelService(c)
+	if err != nil {
+		return err
+	}
+	return srv.apply(&metadata.ModelSpecOption{Spec: *spec, Prune: c.prune, Changes: c.selected}, c.autoApprove)
+}
+
+func (s *modelService) export(c *modelConf) (*metadata.ModelSpec, error) {
+	opt := &metadata.ExportModelSpecOption{
+		Classifications: c.classifications,
+		ObjectIDs:       c.objectIDs,
+		PresetData:      c.presetData,
+		Businesses:      c.businesses,
+	}
+	rsp, err := s.clientSet.TopoServer().Object().ExportModelSpec(context.Background(), s.header, opt)
+	if err != nil {
+		return nil, fmt.Errorf("export model spec failed, err: %v", err)
+	}
+	if !rsp.Result {
+		return nil, fmt.Errorf("export model spec failed, err: %s", rsp.ErrMsg)
+	}
+	return &rsp.Data, nil
+}
+
+// apply shows the changes to apply the spec, and applies them after confirmed, the conflicted changes are
+// reported after applied.
+func (s *modelService) apply(opt *metadata.ModelSpecOption, autoApprove bool) error {
+	changes, err := s.plan(opt)
+	if err != nil {
+		return err
+	}
+	if changes == 0 {
+		return nil
+	}
+
+	if !autoApprove {
+		fmt.Print("Do you want to apply these changes? Only 'yes' will be accepted: ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != "yes" {
+			fmt.Println("apply cancelled")
+			return nil
+		}
+	}
+
+	rsp, err := s.clientSet.TopoServer().Object().ApplyModelSpec(context.Background(), s.header, opt)
+	if err != nil {
+		return fmt.Errorf("apply model spec failed, err: %v", err)
+	}
+	if !rsp.Result {
+		return fmt.Errorf("apply model spec failed, err: %s", rsp.ErrMsg)
+	}
+
+	result := rsp.Data
+	fmt.Printf("%d changes applied, %d skipped\n", len(result.Applied), len(result.Skipped))
+	for _, change := range result.Skipped {
+		if change.Conflict != "" {
+			fmt.Printf("! %s: %s\n", formatModelSpecChange(change), change.Conflict)
+		}
+	}
+	if result.Failed != nil {
+		return fmt.Errorf("apply %s failed, err: %s", formatModelSpecChange(*result.Failed), result.Error)
+	}
+	return nil
+}
+
+// plan prints the changes to apply the spec and returns the count of the changes which can be applied.
+func (s *modelService) plan(opt *metadata.ModelSpecOption) (int, error) {
+	rsp, err := s.clientSet.TopoServer().Object().PlanModelSpec(context.Background(), s.header, opt)
+	if err != nil {
+		return 0, fmt.Errorf("plan model spec failed, err: %v", err)
+	}
+	if !rsp.Result {
+		return 0, fmt.Errorf("plan model spec failed, err: %s", rsp.ErrMsg)
+	}
+
+	selected := make(map[string]bool)
+	for _, key := range opt.Changes {
+		selected[key] = true
+	}
+
+	changes, conflicts, unselected := 0, 0, 0
+	for _, change := range rsp.Data.Changes {
+		if change.Conflict != "" {
+			conflicts++
+			fmt.Printf("! %s: %s\n", formatModelSpecChange(change), change.Conflict)
+			continue
+		}
+		if len(selected) > 0 && !selected[change.Key()] {
+			unselected++
+			fmt.Printf("  %s (not selected)\n", formatModelSpecChange(change))
+			continue
+		}
+		changes++
+
+		switch change.Action {
+		case metadata.ModelSpecCreate:
+			fmt.Printf("+ %s\n", formatModelSpecChange(change))
+		case metadata.ModelSpecDelete:
+			fmt.Printf("- %s\n", formatModelSpecChange(change))
+		default:
+			fmt.Printf("~ %s\n", formatModelSpecChange(change))
+			fields := make([]string, 0)
+			for field := range change.Fields {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			for _, field := range fields {
+				fmt.Printf("    %s: %v\n", field, change.Fields[field])
+			}
+		}
+	}
+
+	if changes == 0 && unselected > 0 {
+		fmt.Println("no changes are selected")
+		return 0, nil
+	}
+	if changes == 0 {
+		fmt.Println("no changes, the models are up to date")
+		return 0, nil
+	}
+	fmt.Printf("plan: %d to change, %d conflicted, %d not selected\n", changes, conflicts, unselected)
+	return changes, nil
+}
+
+// formatModelSpecChange formats the change with it's key, so that the key can be used to select it.
+func formatModelSpecChange(change metadata.ModelSpecChange) string {
+	return fmt.Sprintf("%s %s", change.Action, change.Key())
+}
+
+// loadModelSpec loads the yaml spec file, the yaml maps in the attribute options are converted to json
+// compatible maps so that they can be sent to the server.
+func loadModelSpec(c *modelConf) (*metadata.ModelSpecOption, error) {
+	if c.file == "" {
+		return nil, errors.New("the spec file must be set by the file flag")
+	}
+
+	content, err := ioutil.ReadFile(c.file)
+	if err != nil {
+		return nil, fmt.Errorf("read spec file %s failed, err: %v", c.file, err)
+	}
+
+	opt := &metadata.ModelSpecOption{Prune: c.prune, Changes: c.selected}
+	if err := yaml.Unmarshal(content, &opt.Spec); err != nil {
+		return nil, fmt.Errorf("unmarshal spec file %s failed, err: %v", c.file, err)
+	}
+
+	for objIdx := range opt.Spec.Models {
+		attributes := opt.Spec.Models[objIdx].Attributes
+		for idx := range attributes {
+			attributes[idx].Option = convertYamlValue(attributes[idx].Option)
+		}
+	}
+	return opt, nil
+}
+
+// convertYamlValue converts the map[interface{}]interface{} decoded by yaml to map[string]interface{}.
+func convertYamlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = convertYamlValue(val)
+		}
+		return m
+	case []interface{}:
+		for idx := range v {
+			v[idx] = convertYamlValue(v[idx])
+		}
+		return v
+	default:
+		return value
+	}
+}
@@ -128,6 +128,23 @@ func setExcelRowDataByIndex(rowMap mapstr.MapStr, sheet *xlsx.Sheet, rowIndex in
 				cell.SetString(cellVal)
 			}
 
+		case common.FieldTypeEnumMulti:
+			arrVal, ok := property.Option.([]interface{})
+			enumIDs, err := metadata.ParseEnumMultiValue(val)
+			if ok && err == nil {
+				enumNames := make([]string, 0)
+				for _, enumID := range enumIDs {
+					enumNames = append(enumNames, getEnumNameByID(enumID, arrVal))
+				}
+				cell.SetString(strings.Join(enumNames, ","))
+			}
+
+		case common.FieldTypeInstRef:
+			intVal, err := util.GetInt64ByInterface(val)
+			if nil == err {
+				cell.SetInt64(intVal)
+			}
+
 		case common.FieldTypeBool:
 			bl, ok := val.(bool)
 			if ok {
@@ -245,7 +262,20 @@ func getDataFromByExcelRow(ctx context.Context, row *xlsx.Row, rowIndex int, fie
 			if optionOk {
 				host[fieldName] = getEnumIDByName(cell.Value, option)
 			}
-		case common.FieldTypeInt:
+		case common.FieldTypeEnumMulti:
+			option, optionOk := field.Option.([]interface{})
+			if optionOk {
+				enumIDs := make([]string, 0)
+				for _, enumName := range strings.Split(cell.Value, ",") {
+					if enumName = strings.TrimSpace(enumName); enumName != "" {
+						enumIDs = append(enumIDs, getEnumIDByName(enumName, option))
+					}
+				}
+				host[fieldName] = enumIDs
+			}
+		case common.FieldTypeIP, common.FieldTypeURL:
+			host[fieldName] = strings.TrimSpace(cell.Value)
+		case common.FieldTypeInt, common.FieldTypeInstRef:
 			intVal, err := util.GetInt64ByInterface(host[fieldName])
 			// convertor int not err , set field value to correct type
 			if nil == err {
@@ -365,7 +395,7 @@ func productExcelHealer(ctx context.Context, fields map[string]Property, filter
 		cellEnName.SetStyle(styleCell)
 
 		switch field.PropertyType {
-		case common.FieldTypeInt:
+		case common.FieldTypeInt, common.FieldTypeInstRef:
 			sheet.Col(index).SetType(xlsx.CellTypeNumeric)
 		case common.FieldTypeFloat:
 			sheet.Col(index).SetType(xlsx.CellTypeNumeric)
@@ -277,6 +277,11 @@ func getPropertyTypeAliasName(propertyType string, defLang lang.DefaultCCLanguag
 	case common.FieldTypeOrganization:
 	case common.FieldTypeBool:
 	case common.FieldTypeTimeZone:
+	case common.FieldTypeIP:
+	case common.FieldTypeURL:
+	case common.FieldTypeEnumMulti:
+	case common.FieldTypeInstRef:
+	case common.FieldTypeComputed:
 
 	}
 	if "" == name {
@@ -96,7 +96,9 @@ func ConvAttrOption(attrItems map[int]map[string]interface{}) {
 			continue
 		}
 		fieldType, _ := attr[common.BKPropertyTypeField].(string)
-		if common.FieldTypeEnum != fieldType && common.FieldTypeInt != fieldType && common.FieldTypeList != fieldType {
+		if common.FieldTypeEnum != fieldType && common.FieldTypeInt != fieldType && common.FieldTypeList != fieldType &&
+			common.FieldTypeEnumMulti != fieldType && common.FieldTypeInstRef != fieldType &&
+			common.FieldTypeComputed != fieldType {
 			continue
 		}
 